@@ -0,0 +1,122 @@
+// Package agenda gathers what there is to do today across every workspace:
+// the tasks due today or overdue, those back from snooze today and those
+// being worked on. Snoozed tasks stay off it until they wake.
+package agenda
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+	"github.com/happytaoer/cli_kanban/internal/workspace"
+)
+
+// Why a task is on the agenda
+const (
+	Overdue = "overdue"
+	Today   = "today"
+	Waking  = "waking" // back from snooze today
+	Doing   = "doing"  // in a column between the first and the done column
+)
+
+// Item is a task on the agenda
+type Item struct {
+	Kind   string // Overdue, Today, Waking or Doing
+	Column string // name of the task's column
+	Task   model.Task
+}
+
+// Section holds the agenda of one workspace
+type Section struct {
+	Workspace string
+	Items     []Item
+	Err       error // the workspace couldn't be read; Items is empty
+}
+
+// Load returns the agenda of every workspace that has something on it or
+// failed to open, by workspace name. Each database is opened read-only where
+// its schema is current.
+func Load(now time.Time) ([]Section, error) {
+	workspaces, err := workspace.List()
+	if err != nil {
+		return nil, err
+	}
+	var sections []Section
+	for _, ws := range workspaces {
+		items, err := load(ws.Path, now)
+		if err != nil || len(items) > 0 {
+			sections = append(sections, Section{Workspace: ws.Name, Items: items, Err: err})
+		}
+	}
+	return sections, nil
+}
+
+// load returns the agenda of the workspace database at path: overdue tasks,
+// most overdue first, then those due today, then those waking today, then
+// those in progress
+func load(path string, now time.Time) ([]Item, error) {
+	database, err := db.NewReadOnly(path)
+	if err != nil {
+		// An older schema needs migrating first
+		if database, err = db.New(path); err != nil {
+			return nil, err
+		}
+	}
+	defer database.Close()
+
+	columns, err := database.GetBoard()
+	if err != nil || len(columns) == 0 {
+		return nil, err
+	}
+	today := dates.StartOfDay(now)
+	var due, waking, doing []Item
+	for i, col := range columns {
+		if col.Done {
+			continue
+		}
+		for _, task := range col.Tasks {
+			if task.Snoozed(now) {
+				continue
+			}
+			switch {
+			case task.Due != nil && dates.Day(*task.Due, now.Location()).Before(today):
+				due = append(due, Item{Kind: Overdue, Column: col.Name, Task: task})
+			case task.Due != nil && dates.Day(*task.Due, now.Location()).Equal(today):
+				due = append(due, Item{Kind: Today, Column: col.Name, Task: task})
+			case task.WokeToday(now):
+				waking = append(waking, Item{Kind: Waking, Column: col.Name, Task: task})
+			case i > 0:
+				doing = append(doing, Item{Kind: Doing, Column: col.Name, Task: task})
+			}
+		}
+	}
+	// By due date, keeping board order within a day
+	sort.SliceStable(due, func(i, j int) bool { return due[i].Task.Due.Before(*due[j].Task.Due) })
+	return append(append(due, waking...), doing...), nil
+}
+
+// Complete moves a task of a workspace to its done column, returning the
+// next occurrence of a recurring task or nil
+func Complete(ws string, id int64) (*model.Task, error) {
+	path, err := workspace.Path(ws)
+	if err != nil {
+		return nil, err
+	}
+	database, err := db.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workspace %q: %w", ws, err)
+	}
+	defer database.Close()
+
+	columns, err := database.GetColumns()
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("workspace %q has no columns", ws)
+	}
+	return database.UpdateTaskStatus(id, columns[model.DoneColumn(columns)].Status)
+}
@@ -0,0 +1,230 @@
+// Package archive packs a whole workspace into one file to move it between
+// machines or keep it as a backup: a gzipped tarball holding the workspace
+// database, with its columns, tags, settings, templates and activity, and a
+// manifest with the checksum of every file.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/crypt"
+)
+
+const (
+	// FormatVersion is the archive format this binary writes and reads
+	FormatVersion = 1
+	// Extension ends the names of archives
+	Extension = ".kanban.tar.gz"
+
+	// ManifestName is the first entry of an archive
+	ManifestName = "manifest.json"
+	// DatabaseName is the entry holding the workspace database
+	DatabaseName = "workspace.db"
+)
+
+// Manifest describes an archive and what it holds
+type Manifest struct {
+	Format     int       `json:"format"`
+	AppVersion string    `json:"app_version"`
+	Schema     int       `json:"schema_version"` // of the database
+	Workspace  string    `json:"workspace"`
+	Encrypted  bool      `json:"encrypted"` // the database is sealed with its passphrase
+	CreatedAt  time.Time `json:"created_at"`
+	// Files maps the name of every other entry to its SHA-256, in hex
+	Files map[string]string `json:"files"`
+}
+
+// Write writes an archive to path holding files, the paths of the entries
+// by name, and the manifest, whose checksums it fills in. The archive is
+// written next to path first, so a failure leaves path as it was.
+func Write(path string, manifest Manifest, files map[string]string) error {
+	names := make([]string, 0, len(files))
+	manifest.Format = FormatVersion
+	manifest.Files = make(map[string]string, len(files))
+	for name, src := range files {
+		sum, err := checksum(src)
+		if err != nil {
+			return err
+		}
+		manifest.Files[name] = sum
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	tmpPath := path + ".partial"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create archive %q: %w", path, err)
+	}
+	err = writeEntries(f, data, files, names, manifest.CreatedAt)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write archive %q: %w", path, err)
+	}
+	return nil
+}
+
+// writeEntries writes the gzipped tarball of the manifest, encoded as
+// manifest, and files to w
+func writeEntries(w io.Writer, manifest []byte, files map[string]string, names []string, modTime time.Time) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: ManifestName, Mode: 0o600, Size: int64(len(manifest)), ModTime: modTime}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := addFile(tw, name, files[name], modTime); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// addFile adds the file at src to an archive as name
+func addFile(tw *tar.Writer, name, src string, modTime time.Time) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: info.Size(), ModTime: modTime}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Extract unpacks the archive at path into dir, an empty directory, and
+// returns its manifest. It fails unless the archive holds exactly the files
+// the manifest lists, each with its checksum, and the database is encrypted
+// when the manifest says so.
+func Extract(path, dir string) (Manifest, error) {
+	var manifest Manifest
+	f, err := os.Open(path)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to open archive %q: %w", path, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return manifest, fmt.Errorf("%s is not an archive: %w", path, err)
+	}
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil || header.Name != ManifestName {
+		return manifest, fmt.Errorf("%s is not an archive: it doesn't start with %s", path, ManifestName)
+	}
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("archive %s: failed to read %s: %w", path, ManifestName, err)
+	}
+	if manifest.Format > FormatVersion {
+		return manifest, fmt.Errorf("archive %s has format version %d, this binary supports %d; please upgrade cli_kanban", path, manifest.Format, FormatVersion)
+	}
+
+	seen := map[string]bool{}
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return manifest, fmt.Errorf("failed to read archive %q: %w", path, err)
+		}
+		want, ok := manifest.Files[header.Name]
+		if !ok || seen[header.Name] || header.Typeflag != tar.TypeReg || !plainName(header.Name) {
+			return manifest, fmt.Errorf("archive %s: unexpected entry %q", path, header.Name)
+		}
+		seen[header.Name] = true
+		sum, err := extractFile(tr, filepath.Join(dir, header.Name))
+		if err != nil {
+			return manifest, fmt.Errorf("failed to read archive %q: %w", path, err)
+		}
+		if sum != want {
+			return manifest, fmt.Errorf("archive %s: checksum mismatch for %s, the archive is damaged", path, header.Name)
+		}
+	}
+	for name := range manifest.Files {
+		if !seen[name] {
+			return manifest, fmt.Errorf("archive %s: %s is missing, the archive is incomplete", path, name)
+		}
+	}
+	if _, ok := manifest.Files[DatabaseName]; ok {
+		encrypted, err := crypt.IsEncrypted(filepath.Join(dir, DatabaseName))
+		if err != nil {
+			return manifest, err
+		}
+		if encrypted != manifest.Encrypted {
+			return manifest, fmt.Errorf("archive %s: %s doesn't match its manifest", path, DatabaseName)
+		}
+	}
+	return manifest, nil
+}
+
+// plainName reports whether name is a file name of its own, which can't
+// reach outside the directory it is extracted to
+func plainName(name string) bool {
+	return name == filepath.Base(name) && name != "." && name != ".." && name != ManifestName
+}
+
+// extractFile writes the entry r to dst, returning its checksum
+func extractFile(r io.Reader, dst string) (string, error) {
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	_, err = io.Copy(f, io.TeeReader(r, h))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return hex.EncodeToString(h.Sum(nil)), err
+}
+
+// checksum returns the SHA-256 of the file at path, in hex
+func checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q: %w", path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %q: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
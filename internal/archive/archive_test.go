@@ -0,0 +1,226 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/crypt"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+const passphrase = "correct horse"
+
+// newWorkspace writes the database of a workspace holding two tasks, one
+// with a tag and a checklist, and returns its path
+func newWorkspace(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "work.db")
+	database, err := db.New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = database.CreateTasks([]model.Task{
+		{Title: "Write docs", Status: model.StatusTodo, Tags: []string{"docs"}, Subtasks: []model.Subtask{{Title: "outline"}}},
+		{Title: "Shipped", Status: model.StatusDone},
+	})
+	if closeErr := database.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+	return path
+}
+
+// archiveWorkspace archives the workspace database at path as archive
+// does: a snapshot of a plain database, an encrypted one as it is
+func archiveWorkspace(t *testing.T, path string, encrypted bool) string {
+	t.Helper()
+	src := path
+	if !encrypted {
+		src = filepath.Join(t.TempDir(), DatabaseName)
+		database, err := db.New(path)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		err = database.Snapshot(src)
+		if closeErr := database.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			t.Fatalf("Snapshot: %v", err)
+		}
+	}
+	output := filepath.Join(t.TempDir(), "work"+Extension)
+	manifest := Manifest{
+		AppVersion: "test",
+		Schema:     db.SchemaVersion(),
+		Workspace:  "work",
+		Encrypted:  encrypted,
+		CreatedAt:  time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC),
+	}
+	if err := Write(output, manifest, map[string]string{DatabaseName: src}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return output
+}
+
+// checkTasks checks the database at path holds the tasks of newWorkspace
+func checkTasks(t *testing.T, path string) {
+	t.Helper()
+	database, err := db.New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer database.Close()
+	columns, err := database.GetBoard()
+	if err != nil {
+		t.Fatalf("GetBoard: %v", err)
+	}
+	todo, done := columns[0].Tasks, columns[len(columns)-1].Tasks
+	if len(todo) != 1 || len(done) != 1 || done[0].Title != "Shipped" {
+		t.Fatalf("board = %+v, want Write docs in todo and Shipped in done", columns)
+	}
+	if todo[0].Title != "Write docs" || len(todo[0].Tags) != 1 || len(todo[0].Subtasks) != 1 {
+		t.Errorf("Write docs lost fields: %+v", todo[0])
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, encrypted := range []bool{false, true} {
+		name := "plain"
+		if encrypted {
+			name = "encrypted"
+		}
+		t.Run(name, func(t *testing.T) {
+			path := newWorkspace(t)
+			if encrypted {
+				if err := db.Encrypt(path, passphrase); err != nil {
+					t.Fatalf("Encrypt: %v", err)
+				}
+				db.SetPassphraseFunc(func(string, int) (string, error) { return passphrase, nil })
+				t.Cleanup(func() { db.SetPassphraseFunc(nil) })
+			}
+			output := archiveWorkspace(t, path, encrypted)
+
+			dir := t.TempDir()
+			manifest, err := Extract(output, dir)
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			if manifest.Format != FormatVersion || manifest.Workspace != "work" || manifest.Encrypted != encrypted ||
+				manifest.Schema != db.SchemaVersion() || len(manifest.Files) != 1 {
+				t.Errorf("manifest = %+v", manifest)
+			}
+			extracted := filepath.Join(dir, DatabaseName)
+			if sealed, err := crypt.IsEncrypted(extracted); err != nil || sealed != encrypted {
+				t.Fatalf("IsEncrypted = %v, %v; want %v", sealed, err, encrypted)
+			}
+			if encrypted {
+				// The sealed file goes into the archive as it is
+				want, _ := os.ReadFile(path)
+				got, _ := os.ReadFile(extracted)
+				if !bytes.Equal(got, want) {
+					t.Error("the extracted database differs from the encrypted workspace")
+				}
+			}
+			checkTasks(t, extracted)
+		})
+	}
+}
+
+// writeArchive writes an archive of files, the contents of the entries by
+// name, with manifest as it is, checksums included
+func writeArchive(t *testing.T, manifest Manifest, files map[string][]byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := map[string]string{}
+	var names []string
+	for name, data := range files {
+		paths[name] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[name], data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "work"+Extension)
+	f, err := os.Create(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := writeEntries(f, data, paths, names, manifest.CreatedAt); err != nil {
+		t.Fatal(err)
+	}
+	return output
+}
+
+// sum returns the checksum a manifest lists for data
+func sum(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func TestExtractRejectsMismatch(t *testing.T) {
+	plain := []byte("SQLite format 3\x00")
+	path := newWorkspace(t)
+	if err := db.Encrypt(path, passphrase); err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plainSum, sealedSum := sum(plain), sum(sealed)
+
+	tests := []struct {
+		name     string
+		manifest Manifest
+		files    map[string][]byte
+		want     string
+	}{
+		{"checksum", Manifest{Format: 1, Files: map[string]string{DatabaseName: strings.Repeat("0", 64)}},
+			map[string][]byte{DatabaseName: plain}, "checksum mismatch for workspace.db"},
+		{"missing file", Manifest{Format: 1, Files: map[string]string{DatabaseName: plainSum, "notes.txt": plainSum}},
+			map[string][]byte{DatabaseName: plain}, "notes.txt is missing"},
+		{"unlisted file", Manifest{Format: 1, Files: map[string]string{DatabaseName: plainSum}},
+			map[string][]byte{DatabaseName: plain, "notes.txt": plain}, `unexpected entry "notes.txt"`},
+		{"said encrypted", Manifest{Format: 1, Encrypted: true, Files: map[string]string{DatabaseName: plainSum}},
+			map[string][]byte{DatabaseName: plain}, "workspace.db doesn't match its manifest"},
+		{"said plain", Manifest{Format: 1, Files: map[string]string{DatabaseName: sealedSum}},
+			map[string][]byte{DatabaseName: sealed}, "workspace.db doesn't match its manifest"},
+		{"newer format", Manifest{Format: FormatVersion + 1, Files: map[string]string{DatabaseName: plainSum}},
+			map[string][]byte{DatabaseName: plain}, "please upgrade cli_kanban"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := writeArchive(t, tt.manifest, tt.files)
+			_, err := Extract(output, t.TempDir())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Extract error = %v, want one saying %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("not an archive"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Extract(path, t.TempDir()); err == nil || !strings.Contains(err.Error(), "is not an archive") {
+		t.Fatalf("Extract error = %v, want one saying it is not an archive", err)
+	}
+}
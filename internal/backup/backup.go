@@ -0,0 +1,203 @@
+// Package backup keeps timestamped copies of the workspace databases.
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/workspace"
+)
+
+const (
+	// TimeFormat names the backup files; it sorts chronologically
+	TimeFormat = "20060102T150405.000"
+	// DefaultInterval is the minimum age of the last backup before the next one
+	DefaultInterval = "1d"
+	// DefaultKeep is how many backups are kept per workspace
+	DefaultKeep = 10
+
+	dirName = "backups"
+)
+
+// Backup is a copy of a workspace database
+type Backup struct {
+	Name string // timestamp the backup is named after
+	Time time.Time
+	Path string
+}
+
+// Dir returns the directory holding the backups of a workspace
+func Dir(ws string) (string, error) {
+	dataDir, err := workspace.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, dirName, ws), nil
+}
+
+// List returns the backups of a workspace, newest first
+func List(ws string) ([]Backup, error) {
+	dir, err := Dir(ws)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup directory %q: %w", dir, err)
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".db")
+		if e.IsDir() || name == e.Name() {
+			continue
+		}
+		t, err := time.ParseInLocation(TimeFormat, name, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Name: name, Time: t, Path: filepath.Join(dir, e.Name())})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+// Create copies the database at dbPath into a new backup of the workspace.
+// The copy is consistent even while another process has the database open.
+func Create(ws, dbPath string) (Backup, error) {
+	dir, err := Dir(ws)
+	if err != nil {
+		return Backup{}, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return Backup{}, fmt.Errorf("failed to create backup directory %q: %w", dir, err)
+	}
+
+	now := time.Now()
+	name := now.Format(TimeFormat)
+	path := filepath.Join(dir, name+".db")
+	if err := db.CopyFile(dbPath, path); err != nil {
+		return Backup{}, err
+	}
+	return Backup{Name: name, Time: now, Path: path}, nil
+}
+
+// Rotate deletes all but the newest keep backups of a workspace
+func Rotate(ws string, keep int) error {
+	backups, err := List(ws)
+	if err != nil {
+		return err
+	}
+	for len(backups) > keep {
+		old := backups[len(backups)-1]
+		if err := os.Remove(old.Path); err != nil {
+			return fmt.Errorf("failed to delete old backup %q: %w", old.Path, err)
+		}
+		backups = backups[:len(backups)-1]
+	}
+	return nil
+}
+
+// Auto backs up the database at dbPath when the newest backup of the
+// workspace is older than interval (e.g. 12h, 1d, 1w), then keeps the newest
+// keep backups. A keep of 0 disables backups. It reports whether a backup
+// was made.
+func Auto(ws, dbPath, interval string, keep int) (bool, error) {
+	if keep <= 0 {
+		return false, nil
+	}
+	if interval == "" {
+		interval = DefaultInterval
+	}
+	cutoff, err := dates.Ago(interval, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("invalid backup interval: %w", err)
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		// Nothing to back up before the workspace is first opened
+		return false, nil
+	}
+
+	backups, err := List(ws)
+	if err != nil {
+		return false, err
+	}
+	if len(backups) > 0 && backups[0].Time.After(cutoff) {
+		return false, nil
+	}
+	if _, err := Create(ws, dbPath); err != nil {
+		return false, err
+	}
+	return true, Rotate(ws, keep)
+}
+
+// Find returns the backup of a workspace named by from: a timestamp as
+// listed by List, a unique prefix of one, or "latest"
+func Find(ws, from string) (Backup, error) {
+	backups, err := List(ws)
+	if err != nil {
+		return Backup{}, err
+	}
+	if len(backups) == 0 {
+		return Backup{}, fmt.Errorf("workspace %q has no backups", ws)
+	}
+	if from == "latest" {
+		return backups[0], nil
+	}
+
+	var matches []Backup
+	for _, b := range backups {
+		if b.Name == from {
+			return b, nil
+		}
+		if strings.HasPrefix(b.Name, from) {
+			matches = append(matches, b)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Backup{}, fmt.Errorf("no backup of workspace %q matches %q", ws, from)
+	case 1:
+		return matches[0], nil
+	}
+	return Backup{}, fmt.Errorf("%q matches %d backups of workspace %q, give more of the timestamp", from, len(matches), ws)
+}
+
+// Restore replaces the database at dbPath with a backup. The current
+// database, if any, is backed up first; that backup is returned. It can't
+// be restored over while another process has it open.
+func Restore(ws, dbPath string, b Backup) (*Backup, error) {
+	var current *Backup
+	if _, err := os.Stat(dbPath); err == nil {
+		// Its write-ahead log would be applied over the backup
+		if err := db.Checkpoint(dbPath); err != nil {
+			return nil, err
+		}
+		created, err := Create(ws, dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up the current database: %w", err)
+		}
+		current = &created
+	}
+
+	tmpPath := dbPath + ".restore"
+	_ = os.Remove(tmpPath)
+	if err := workspace.CopyFile(b.Path, tmpPath, 0o600); err != nil {
+		return current, err
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return current, fmt.Errorf("failed to restore backup %s: %w", b.Name, err)
+	}
+	return current, nil
+}
@@ -0,0 +1,148 @@
+// Package config loads the optional user configuration file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/happytaoer/cli_kanban/internal/workspace"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the config file inside the config directory
+const FileName = workspace.ConfigFile
+
+// Config holds the user settings read from the config file
+type Config struct {
+	Theme     Theme     `yaml:"theme"`
+	Backups   Backups   `yaml:"backups"`
+	Activity  Activity  `yaml:"activity"`
+	Pomodoro  Pomodoro  `yaml:"pomodoro"`
+	Reminders Reminders `yaml:"reminders"`
+	Server    Server    `yaml:"server"`
+	Stale     Stale     `yaml:"stale"`
+	// Vim turns the vim layer of the TUI on or off: h/j/k/l, gg/G and
+	// counts such as 3j; nil means on
+	Vim *bool `yaml:"vim"`
+	// SampleBoard fills a workspace the TUI creates with example tasks and
+	// greets it with a welcome overlay; nil means on
+	SampleBoard *bool `yaml:"sample_board"`
+	// TitleLines is how many lines a task title wraps onto on its card
+	// before it is cut with "…"; 0, the default, shows whole titles
+	TitleLines int `yaml:"title_lines"`
+	// RestoreSession opens each workspace where the TUI left it: the
+	// focused column, selected task, filters and zoom; nil means on
+	RestoreSession *bool `yaml:"restore_session"`
+	// Keys replaces the keys of TUI actions, by action name, e.g.
+	// "deleteTask: x"; "disabled" removes an action's binding
+	Keys map[string]KeyList `yaml:"keys"`
+	// Webhooks lists, by workspace name, the URLs that receive a JSON POST
+	// when a task is created, moved, completed or deleted; the URLs under
+	// "*" receive the changes of every workspace
+	Webhooks map[string][]string `yaml:"webhooks"`
+}
+
+// KeyList is one key or a list of keys; a single key needs no brackets
+type KeyList []string
+
+// UnmarshalYAML reads a single key or a sequence of them
+func (l *KeyList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = KeyList{node.Value}
+		return nil
+	}
+	var keys []string
+	if err := node.Decode(&keys); err != nil {
+		return err
+	}
+	*l = keys
+	return nil
+}
+
+// Stale configures when a task counts as stale
+type Stale struct {
+	// After is how long a task may go untouched, e.g. 14d or 3w; empty
+	// means db.DefaultStaleAfter and "never" marks no task stale
+	After string `yaml:"after"`
+}
+
+// Server configures the HTTP API started by the serve command
+type Server struct {
+	// Token, when set, is the bearer token every request must carry
+	Token string `yaml:"token"`
+}
+
+// Reminders configures how the TUI announces tasks that become due
+type Reminders struct {
+	// Notify is bell (the default), osc, osc777, notify-send or none
+	Notify string `yaml:"notify"`
+}
+
+// Pomodoro configures focus mode in the TUI
+type Pomodoro struct {
+	// Work and Break are the lengths of a pomodoro and of the break after
+	// it, e.g. 25m; empty means 25m and 5m
+	Work  string `yaml:"work"`
+	Break string `yaml:"break"`
+	// Notify announces each transition: bell (the default), osc, osc777,
+	// notify-send or none
+	Notify string `yaml:"notify"`
+}
+
+// Activity configures the activity log of each workspace
+type Activity struct {
+	// Retention is how long entries are kept, e.g. 90d, 12w or 6m; empty
+	// means db.DefaultActivityRetention and "never" keeps them forever
+	Retention string `yaml:"retention"`
+}
+
+// Backups configures the workspace backups made on startup
+type Backups struct {
+	// Interval is the minimum age of the last backup before a new one is
+	// made, e.g. 12h, 1d or 1w; empty means backup.DefaultInterval
+	Interval string `yaml:"interval"`
+	// Keep is how many backups are kept per workspace; 0 disables them and
+	// nil means backup.DefaultKeep
+	Keep *int `yaml:"keep"`
+}
+
+// Theme selects a built-in color theme by name and overrides single colors
+// by role, e.g. "selected: '#2563EB'"
+type Theme struct {
+	Name   string            `yaml:"name"`
+	Colors map[string]string `yaml:"colors"`
+}
+
+// Path returns the location of the config file
+func Path() (string, error) {
+	configDir, err := workspace.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, FileName), nil
+}
+
+// Load reads the config file at path. A missing or empty file yields the
+// zero Config; unknown keys are reported as errors.
+func Load(path string) (Config, error) {
+	var cfg Config
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read config %q: %w", path, err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("failed to parse config %q: %w", path, err)
+	}
+	return cfg, nil
+}
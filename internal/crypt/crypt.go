@@ -0,0 +1,184 @@
+// Package crypt encrypts workspace databases with a passphrase: the whole
+// file is sealed with AES-256-GCM under a key derived from the passphrase
+// with PBKDF2-HMAC-SHA256.
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Magic starts every encrypted database, where a plain one starts with
+// "SQLite format 3"
+const Magic = "cli_kanban crypt"
+
+const (
+	formatVersion = 1
+	// iterations of PBKDF2 for new keys; opening a file uses the count it
+	// was written with
+	iterations = 600_000
+	// minIterations and maxIterations bound the count a file may give: a
+	// damaged header can't make the key worthless or take hours to derive
+	minIterations = 100_000
+	maxIterations = 10 * iterations
+	saltSize      = 16
+	// headerSize is the size of the magic, format version, iteration count,
+	// salt and nonce that precede the sealed database
+	headerSize = len(Magic) + 1 + 4 + saltSize + 12
+)
+
+var (
+	// ErrWrongPassphrase is returned when a file doesn't open with the
+	// passphrase given. GCM can't tell it from a damaged file.
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	// ErrNotEncrypted is returned when opening a file that isn't encrypted
+	ErrNotEncrypted = errors.New("not an encrypted database")
+)
+
+// Key encrypts a database. It is kept while the database is open, so
+// saving it doesn't derive the key again.
+type Key struct {
+	salt       []byte
+	iterations int
+	aead       cipher.AEAD
+}
+
+// NewKey derives a key from passphrase with a new random salt
+func NewKey(passphrase string) (*Key, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to make a salt: %w", err)
+	}
+	return deriveKey(passphrase, salt, iterations)
+}
+
+// deriveKey derives the key of passphrase with salt
+func deriveKey(passphrase string, salt []byte, iterations int) (*Key, error) {
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{salt: salt, iterations: iterations, aead: aead}, nil
+}
+
+// header returns the header of a file sealed with nonce
+func (k *Key) header(nonce []byte) []byte {
+	header := make([]byte, 0, headerSize)
+	header = append(header, Magic...)
+	header = append(header, formatVersion)
+	header = binary.BigEndian.AppendUint32(header, uint32(k.iterations))
+	header = append(header, k.salt...)
+	return append(header, nonce...)
+}
+
+// Seal encrypts a database with a new nonce. The header is authenticated
+// along with it, so a changed iteration count or salt fails to open.
+func (k *Key) Seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to make a nonce: %w", err)
+	}
+	header := k.header(nonce)
+	return k.aead.Seal(header, nonce, plain, header), nil
+}
+
+// Open decrypts an encrypted database with passphrase, returning the key
+// to seal it again with
+func Open(data []byte, passphrase string) ([]byte, *Key, error) {
+	if !bytes.HasPrefix(data, []byte(Magic)) {
+		return nil, nil, ErrNotEncrypted
+	}
+	if len(data) < headerSize {
+		return nil, nil, errors.New("encrypted database is truncated")
+	}
+	rest := data[len(Magic):]
+	if rest[0] != formatVersion {
+		return nil, nil, fmt.Errorf("encrypted database has format version %d, this binary supports %d; please upgrade cli_kanban", rest[0], formatVersion)
+	}
+	count := int(binary.BigEndian.Uint32(rest[1:5]))
+	if count < minIterations || count > maxIterations {
+		return nil, nil, fmt.Errorf("encrypted database has %d key iterations, outside %d to %d: the file is damaged", count, minIterations, maxIterations)
+	}
+	salt := rest[5 : 5+saltSize]
+	nonce := rest[5+saltSize : headerSize-len(Magic)]
+
+	key, err := deriveKey(passphrase, append([]byte(nil), salt...), count)
+	if err != nil {
+		return nil, nil, err
+	}
+	plain, err := key.aead.Open(nil, nonce, data[headerSize:], data[:headerSize])
+	if err != nil {
+		return nil, nil, ErrWrongPassphrase
+	}
+	return plain, key, nil
+}
+
+// OpenFile decrypts the encrypted database at path with passphrase
+func OpenFile(path, passphrase string) ([]byte, *Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read db %q: %w", path, err)
+	}
+	return Open(data, passphrase)
+}
+
+// IsEncrypted reports whether the database at path is encrypted. A
+// missing or empty file isn't.
+func IsEncrypted(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to open db %q: %w", path, err)
+	}
+	defer f.Close()
+
+	start := make([]byte, len(Magic))
+	if _, err := io.ReadFull(f, start); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read db %q: %w", path, err)
+	}
+	return string(start) == Magic, nil
+}
+
+// WriteFile replaces the file at path with data, writing it next to it
+// first, so a failure leaves the old file whole
+func WriteFile(path string, data []byte) error {
+	tmpPath := path + ".crypt"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create db %q: %w", tmpPath, err)
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write db %q: %w", path, err)
+	}
+	return nil
+}
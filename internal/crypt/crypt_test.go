@@ -0,0 +1,129 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// sealedFixture is "SQLite format 3\x00 fixture" sealed with the passphrase
+// "correct horse", the salt "0123456789abcdef" and 100,000 iterations by an
+// earlier cli_kanban, so a change of the key derivation can't go unnoticed
+const sealedFixture = "636c695f6b616e62616e20637279707401000186a030313233343536373839616263646566931a275c0dd841afa45e5510237a8368f399980a3b5f5820557385e7ec18c6d7aa96e5a0c425f93b3035ae21f421c5636fad4fb2"
+
+// seal seals plain with passphrase, deriving the key with the fewest
+// iterations Open accepts to keep the tests fast
+func seal(t *testing.T, plain []byte, passphrase string) []byte {
+	t.Helper()
+	key, err := deriveKey(passphrase, []byte("0123456789abcdef"), minIterations)
+	if err != nil {
+		t.Fatalf("deriveKey: %v", err)
+	}
+	sealed, err := key.Seal(plain)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	return sealed
+}
+
+func TestOpenEarlierFile(t *testing.T) {
+	data, err := hex.DecodeString(sealedFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, key, err := Open(data, "correct horse")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if string(plain) != "SQLite format 3\x00 fixture" {
+		t.Errorf("Open = %q", plain)
+	}
+	if key.iterations != 100_000 {
+		t.Errorf("key has %d iterations, want the 100000 of the file", key.iterations)
+	}
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	key, err := NewKey("secret")
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	plain := []byte("SQLite format 3\x00 and the rest of the database")
+	sealed, err := key.Seal(plain)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if bytes.Contains(sealed, plain[16:]) {
+		t.Error("the sealed file holds the database in the clear")
+	}
+
+	opened, reopenKey, err := Open(sealed, "secret")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(opened, plain) {
+		t.Errorf("Open = %q, want %q", opened, plain)
+	}
+	// The key Open returns seals a file that opens again
+	again, err := reopenKey.Seal(plain)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if bytes.Equal(again, sealed) {
+		t.Error("sealing again reused the nonce")
+	}
+	if opened, _, err := Open(again, "secret"); err != nil || !bytes.Equal(opened, plain) {
+		t.Errorf("Open after sealing again = %q, %v", opened, err)
+	}
+}
+
+func TestOpenWrongPassphrase(t *testing.T) {
+	sealed := seal(t, []byte("plain"), "secret")
+	if _, _, err := Open(sealed, "Secret"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Open with the wrong passphrase = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestOpenDamaged(t *testing.T) {
+	sealed := seal(t, []byte("plain"), "secret")
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"plain database", []byte("SQLite format 3\x00"), ErrNotEncrypted.Error()},
+		{"truncated header", sealed[:headerSize-1], "truncated"},
+		{"truncated data", sealed[:len(sealed)-1], ErrWrongPassphrase.Error()},
+		{"changed salt", flip(sealed, len(Magic)+5), ErrWrongPassphrase.Error()},
+		{"newer format", flip(sealed, len(Magic)), "format version"},
+		{"too few iterations", withIterations(sealed, 1), "key iterations"},
+		{"too many iterations", withIterations(sealed, 4_000_000_000), "key iterations"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Open(tt.data, "secret")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Open = %v, want an error with %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// flip returns a copy of data with a bit of the byte at i flipped
+func flip(data []byte, i int) []byte {
+	data = append([]byte(nil), data...)
+	data[i] ^= 2
+	return data
+}
+
+// withIterations returns a copy of data with the iteration count of its
+// header set to n
+func withIterations(data []byte, n uint32) []byte {
+	data = append([]byte(nil), data...)
+	binary.BigEndian.PutUint32(data[len(Magic)+1:], n)
+	return data
+}
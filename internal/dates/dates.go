@@ -0,0 +1,186 @@
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateFormat is the layout used to display and enter calendar dates
+const DateFormat = "2006-01-02"
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Parse parses an absolute or relative date relative to now and returns
+// midnight of that day in now's location. Accepted forms:
+//
+//	2025-03-14            absolute date
+//	today, tomorrow, yesterday
+//	+3d, +2w, +1m, -1d    offset in days, weeks or months
+//	fri, friday           next occurrence of the weekday (never today)
+func Parse(input string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	loc := now.Location()
+	today := StartOfDay(now)
+
+	switch s {
+	case "":
+		return time.Time{}, fmt.Errorf("empty date")
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if t, err := time.ParseInLocation(DateFormat, s, loc); err == nil {
+		return t, nil
+	}
+
+	if s[0] == '+' || s[0] == '-' {
+		if len(s) < 3 {
+			return time.Time{}, fmt.Errorf("invalid relative date %q", input)
+		}
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative date %q", input)
+		}
+		switch s[len(s)-1] {
+		case 'd':
+			return today.AddDate(0, 0, n), nil
+		case 'w':
+			return today.AddDate(0, 0, 7*n), nil
+		case 'm':
+			return today.AddDate(0, n, 0), nil
+		}
+		return time.Time{}, fmt.Errorf("invalid relative date %q: unit must be d, w or m", input)
+	}
+
+	if wd, ok := weekdays[s]; ok {
+		days := (int(wd) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD, today, tomorrow, +3d, +2w or a weekday", input)
+}
+
+// StartOfDay returns midnight of t's calendar day in t's location
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Day returns midnight of due's calendar day in loc. Due dates are stored
+// without a timezone, so the calendar date is kept as-is.
+func Day(due time.Time, loc *time.Location) time.Time {
+	return time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, loc)
+}
+
+// Relative formats t relative to now in a compact form, e.g. "just now",
+// "5m ago", "3d ago" or "in 2w"
+func Relative(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var s string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		s = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		s = fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 14*24*time.Hour:
+		s = fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d < 60*24*time.Hour:
+		s = fmt.Sprintf("%dw", int(d/(7*24*time.Hour)))
+	case d < 365*24*time.Hour:
+		s = fmt.Sprintf("%dmo", int(d/(30*24*time.Hour)))
+	default:
+		s = fmt.Sprintf("%dy", int(d/(365*24*time.Hour)))
+	}
+
+	if future {
+		return "in " + s
+	}
+	return s + " ago"
+}
+
+// Ago parses an age such as 48h, 90d, 12w or 6m (hours, days, weeks or
+// months) and returns the time that long before now
+func Ago(input string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	if len(s) < 2 {
+		return time.Time{}, fmt.Errorf("invalid age %q: use e.g. 48h, 90d, 12w or 6m", input)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("invalid age %q: use e.g. 48h, 90d, 12w or 6m", input)
+	}
+	switch s[len(s)-1] {
+	case 'h':
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case 'd':
+		return now.AddDate(0, 0, -n), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*n), nil
+	case 'm':
+		return now.AddDate(0, -n, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid age %q: unit must be h, d, w or m", input)
+}
+
+// Duration formats a duration compactly in minutes, hours or days, e.g. 2.5d
+func Duration(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%.1fh", d.Hours())
+	default:
+		return fmt.Sprintf("%.1fd", d.Hours()/24)
+	}
+}
+
+// Since parses the start of a reporting period and returns midnight of that
+// day in now's location. It accepts the forms of Parse, except that a weekday
+// means its latest occurrence (today on that day), and ages such as 7d or 2w.
+func Since(input string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	today := StartOfDay(now)
+	if wd, ok := weekdays[s]; ok {
+		days := (int(today.Weekday()) - int(wd) + 7) % 7
+		return today.AddDate(0, 0, -days), nil
+	}
+	if t, err := Parse(s, now); err == nil {
+		return t, nil
+	}
+	if t, err := Ago(s, now); err == nil {
+		return StartOfDay(t), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD, today, yesterday, a weekday or an age such as 7d", input)
+}
+
+// HoursMinutes formats a duration as hours and minutes, e.g. "3h 05m" or "45m"
+func HoursMinutes(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
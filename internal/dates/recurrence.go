@@ -0,0 +1,238 @@
+package dates
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence is how often a task repeats. The zero value doesn't repeat.
+type Recurrence struct {
+	Days     int            // every Days days, when Weekdays and Monthly are unset
+	Weekdays []time.Weekday // on these days of the week, sorted from Sunday
+	Monthly  bool           // every month on Day
+	Day      int            // day of the month, 0 for the day of the previous due date
+}
+
+// workweek is the set of days matched by "weekdays"
+var workweek = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// RecurrenceHelp lists the accepted recurrence rules
+const RecurrenceHelp = "daily, weekly, weekdays, weekly on mon,fri, every 3 days, every 2 weeks, monthly or monthly on 15"
+
+// ParseRecurrence parses a recurrence rule. Accepted forms:
+//
+//	daily, weekly            every day or week, counted from the due date
+//	every 3 days, every 2w   every N days or weeks
+//	weekdays                 Monday to Friday
+//	weekly on mon,thu        on the listed days of the week
+//	monthly                  every month on the day of the due date
+//	monthly on 15            every month on that day (the last day in shorter months)
+//
+// An empty rule or "none" means the task doesn't repeat.
+func ParseRecurrence(input string) (Recurrence, error) {
+	s := strings.Join(strings.Fields(strings.ToLower(input)), " ")
+	invalid := fmt.Errorf("invalid recurrence %q: use %s", input, RecurrenceHelp)
+
+	switch s {
+	case "", "none", "never":
+		return Recurrence{}, nil
+	case "daily":
+		return Recurrence{Days: 1}, nil
+	case "weekly":
+		return Recurrence{Days: 7}, nil
+	case "weekdays":
+		return Recurrence{Weekdays: workweek}, nil
+	case "monthly":
+		return Recurrence{Monthly: true}, nil
+	}
+
+	if rest, ok := strings.CutPrefix(s, "every "); ok {
+		rest = strings.ReplaceAll(rest, " ", "")
+		unit := 1
+		switch {
+		case strings.HasSuffix(rest, "weeks"), strings.HasSuffix(rest, "week"), strings.HasSuffix(rest, "w"):
+			unit = 7
+		case strings.HasSuffix(rest, "days"), strings.HasSuffix(rest, "day"), strings.HasSuffix(rest, "d"):
+		default:
+			return Recurrence{}, invalid
+		}
+		n, count := 1, strings.TrimRight(rest, "adeksyw")
+		if count != "" {
+			var err error
+			if n, err = strconv.Atoi(count); err != nil || n < 1 || n > 3650 {
+				return Recurrence{}, invalid
+			}
+		}
+		return Recurrence{Days: n * unit}, nil
+	}
+
+	if rest, ok := strings.CutPrefix(s, "weekly on "); ok {
+		seen := map[time.Weekday]bool{}
+		var days []time.Weekday
+		for _, name := range strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ' ' }) {
+			wd, ok := weekdays[name]
+			if !ok {
+				return Recurrence{}, fmt.Errorf("invalid recurrence %q: unknown weekday %q", input, name)
+			}
+			if !seen[wd] {
+				seen[wd] = true
+				days = append(days, wd)
+			}
+		}
+		if len(days) == 0 {
+			return Recurrence{}, invalid
+		}
+		sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+		return Recurrence{Weekdays: days}, nil
+	}
+
+	if rest, ok := strings.CutPrefix(s, "monthly on "); ok {
+		rest = strings.TrimPrefix(rest, "the ")
+		rest = strings.TrimRight(rest, "stndrh")
+		day, err := strconv.Atoi(rest)
+		if err != nil || day < 1 || day > 31 {
+			return Recurrence{}, fmt.Errorf("invalid recurrence %q: the day of the month must be 1 to 31", input)
+		}
+		return Recurrence{Monthly: true, Day: day}, nil
+	}
+
+	return Recurrence{}, invalid
+}
+
+// IsZero reports whether the rule doesn't repeat
+func (r Recurrence) IsZero() bool {
+	return r.Days == 0 && len(r.Weekdays) == 0 && !r.Monthly
+}
+
+// String returns the rule in the canonical form ParseRecurrence accepts,
+// as stored in the database
+func (r Recurrence) String() string {
+	switch {
+	case r.Monthly && r.Day > 0:
+		return fmt.Sprintf("monthly on %d", r.Day)
+	case r.Monthly:
+		return "monthly"
+	case len(r.Weekdays) > 0:
+		if r.isWorkweek() {
+			return "weekdays"
+		}
+		names := make([]string, len(r.Weekdays))
+		for i, wd := range r.Weekdays {
+			names[i] = strings.ToLower(wd.String()[:3])
+		}
+		return "weekly on " + strings.Join(names, ",")
+	case r.Days == 1:
+		return "daily"
+	case r.Days == 7:
+		return "weekly"
+	case r.Days%7 == 0 && r.Days > 0:
+		return fmt.Sprintf("every %d weeks", r.Days/7)
+	case r.Days > 0:
+		return fmt.Sprintf("every %d days", r.Days)
+	}
+	return ""
+}
+
+// Describe returns the rule for display, e.g. "Weekly on Mon, Fri"
+func (r Recurrence) Describe() string {
+	switch {
+	case r.Monthly && r.Day > 0:
+		return fmt.Sprintf("Monthly on day %d", r.Day)
+	case r.Monthly:
+		return "Monthly"
+	case len(r.Weekdays) > 0:
+		if r.isWorkweek() {
+			return "Weekdays (Mon–Fri)"
+		}
+		names := make([]string, len(r.Weekdays))
+		for i, wd := range r.Weekdays {
+			names[i] = wd.String()[:3]
+		}
+		return "Weekly on " + strings.Join(names, ", ")
+	}
+	s := r.String()
+	if s == "" {
+		return ""
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func (r Recurrence) isWorkweek() bool {
+	if len(r.Weekdays) != len(workweek) {
+		return false
+	}
+	for i, wd := range r.Weekdays {
+		if wd != workweek[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Anchor returns a plain monthly rule fixed to the day of the month of day,
+// so the occurrences after one moved to the end of a shorter month go back
+// to that day; other rules are returned as they are
+func (r Recurrence) Anchor(day time.Time) Recurrence {
+	if r.Monthly && r.Day == 0 {
+		r.Day = day.Day()
+	}
+	return r
+}
+
+// Next returns the first date of the rule strictly after day, at midnight
+// in day's location. A rule that doesn't repeat returns the zero time.
+func (r Recurrence) Next(day time.Time) time.Time {
+	day = StartOfDay(day)
+	switch {
+	case r.Monthly:
+		want := r.Day
+		if want == 0 {
+			want = day.Day()
+		}
+		// This month if the day is still ahead, else the next one
+		for months := 0; months <= 1; months++ {
+			first := time.Date(day.Year(), day.Month()+time.Month(months), 1, 0, 0, 0, 0, day.Location())
+			last := first.AddDate(0, 1, -1).Day()
+			d := want
+			if d > last {
+				d = last
+			}
+			if next := first.AddDate(0, 0, d-1); next.After(day) {
+				return next
+			}
+		}
+		return time.Time{}
+	case len(r.Weekdays) > 0:
+		for i := 1; i <= 7; i++ {
+			next := day.AddDate(0, 0, i)
+			for _, wd := range r.Weekdays {
+				if next.Weekday() == wd {
+					return next
+				}
+			}
+		}
+		return time.Time{}
+	case r.Days > 0:
+		return day.AddDate(0, 0, r.Days)
+	}
+	return time.Time{}
+}
+
+// NextDue returns the due date of the occurrence after one due on due, or
+// completed today when it had no due date. Occurrences missed while the
+// task was overdue are skipped, so the result is never before today.
+func (r Recurrence) NextDue(due *time.Time, now time.Time) time.Time {
+	today := StartOfDay(now)
+	from := today
+	if due != nil {
+		from = Day(*due, now.Location())
+	}
+	next := r.Next(from)
+	for !next.IsZero() && next.Before(today) {
+		next = r.Next(next)
+	}
+	return next
+}
@@ -0,0 +1,286 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// Activity actions
+const (
+	ActionCreate    = "create"
+	ActionEdit      = "edit" // title, description, due date or priority changed
+	ActionMove      = "move"
+	ActionDelete    = "delete" // moved to the trash
+	ActionRestore   = "restore"
+	ActionArchive   = "archive"
+	ActionUnarchive = "unarchive"
+	ActionPurge     = "purge" // deleted permanently
+)
+
+// DefaultActivityRetention is how long activity entries are kept unless configured otherwise
+const DefaultActivityRetention = "180d"
+
+// Activity is an entry of the activity log
+type Activity struct {
+	ID        int64            `json:"id"`
+	At        time.Time        `json:"at"`
+	Action    string           `json:"action"`
+	TaskID    int64            `json:"task_id"`
+	OldStatus model.TaskStatus `json:"old_status,omitempty"`
+	NewStatus model.TaskStatus `json:"new_status,omitempty"`
+	OldTitle  string           `json:"old_title,omitempty"`
+	NewTitle  string           `json:"new_title,omitempty"`
+}
+
+// createActivityTable creates the append-only activity log. Triggers on the
+// tasks table write it, so every change is recorded whether it comes from the
+// TUI, a command or an undo.
+func createActivityTable(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS activity (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		action TEXT NOT NULL,
+		task_id INTEGER NOT NULL,
+		old_status TEXT NOT NULL DEFAULT '',
+		new_status TEXT NOT NULL DEFAULT '',
+		old_title TEXT NOT NULL DEFAULT '',
+		new_title TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_activity_task ON activity(task_id, id);
+
+	CREATE TRIGGER IF NOT EXISTS activity_create AFTER INSERT ON tasks
+	BEGIN
+		INSERT INTO activity (action, task_id, new_status, new_title) VALUES ('create', NEW.id, NEW.status, NEW.title);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS activity_move AFTER UPDATE OF status ON tasks
+	WHEN OLD.status IS NOT NEW.status
+	BEGIN
+		INSERT INTO activity (action, task_id, old_status, new_status, old_title, new_title)
+		VALUES ('move', NEW.id, OLD.status, NEW.status, OLD.title, NEW.title);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS activity_edit AFTER UPDATE OF title, description, due, priority ON tasks
+	WHEN OLD.title IS NOT NEW.title OR OLD.description IS NOT NEW.description
+		OR OLD.due IS NOT NEW.due OR OLD.priority IS NOT NEW.priority
+	BEGIN
+		INSERT INTO activity (action, task_id, old_status, new_status, old_title, new_title)
+		VALUES ('edit', NEW.id, OLD.status, NEW.status, OLD.title, NEW.title);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS activity_delete AFTER UPDATE OF deleted_at ON tasks
+	WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL
+	BEGIN
+		INSERT INTO activity (action, task_id, old_status, new_title) VALUES ('delete', NEW.id, NEW.status, NEW.title);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS activity_restore AFTER UPDATE OF deleted_at ON tasks
+	WHEN OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL
+	BEGIN
+		INSERT INTO activity (action, task_id, new_status, new_title) VALUES ('restore', NEW.id, NEW.status, NEW.title);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS activity_archive AFTER UPDATE OF archived_at ON tasks
+	WHEN OLD.archived_at IS NULL AND NEW.archived_at IS NOT NULL
+	BEGIN
+		INSERT INTO activity (action, task_id, old_status, new_title) VALUES ('archive', NEW.id, NEW.status, NEW.title);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS activity_unarchive AFTER UPDATE OF archived_at ON tasks
+	WHEN OLD.archived_at IS NOT NULL AND NEW.archived_at IS NULL
+	BEGIN
+		INSERT INTO activity (action, task_id, new_status, new_title) VALUES ('unarchive', NEW.id, NEW.status, NEW.title);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS activity_purge AFTER DELETE ON tasks
+	BEGIN
+		INSERT INTO activity (action, task_id, old_status, old_title) VALUES ('purge', OLD.id, OLD.status, OLD.title);
+	END;
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create activity table: %w", err)
+	}
+	return nil
+}
+
+const activityColumns = "id, at, action, task_id, old_status, new_status, old_title, new_title"
+
+// GetActivity returns the latest activity entries of the workspace, newest first
+func (db *DB) GetActivity(limit int) ([]Activity, error) {
+	return queryActivity(db.conn, "SELECT "+activityColumns+" FROM activity ORDER BY id DESC LIMIT ?", limit)
+}
+
+// GetTaskActivity returns the activity entries of a task, newest first
+func (db *DB) GetTaskActivity(taskID int64) ([]Activity, error) {
+	return queryActivity(db.conn, "SELECT "+activityColumns+" FROM activity WHERE task_id = ? ORDER BY id DESC", taskID)
+}
+
+// PruneActivity deletes activity entries older than the given time and
+// returns how many were deleted
+func (db *DB) PruneActivity(before time.Time) (int64, error) {
+	result, err := db.exec("DELETE FROM activity WHERE at < ?", before.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune activity: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// createWebhookCursorTable creates the table holding, per webhook URL, the
+// ID of the last activity entry delivered to it. It isn't one of the
+// revisionTables: a delivery doesn't change what the board shows. The
+// activity_cursor setting it replaces handed entries out once for every URL.
+func createWebhookCursorTable(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS webhook_cursors (
+		url TEXT PRIMARY KEY,
+		activity_id INTEGER NOT NULL
+	);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create webhook cursors table: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM settings WHERE key = 'activity_cursor'"); err != nil {
+		return fmt.Errorf("failed to delete setting %q: %w", "activity_cursor", err)
+	}
+	return nil
+}
+
+// ActivityBatch is the next task changes to post to a webhook URL
+type ActivityBatch struct {
+	Cursor  int64      // ID of the last entry delivered to the URL
+	Through int64      // ID the cursor moves to once Entries are delivered
+	Entries []Activity // task creations, moves and deletions to the trash, oldest first
+}
+
+// PendingActivity returns up to limit task creations, moves and deletions to
+// the trash not delivered to url yet, oldest first. They stay pending until
+// AdvanceActivity records them delivered. The first call for a URL starts
+// with what was logged in the last minute rather than the whole log.
+func (db *DB) PendingActivity(url string, limit int, now time.Time) (ActivityBatch, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return ActivityBatch{}, fmt.Errorf("failed to read activity: %w", err)
+	}
+	defer tx.Rollback()
+
+	var batch ActivityBatch
+	err = tx.QueryRow("SELECT activity_id FROM webhook_cursors WHERE url = ?", url).Scan(&batch.Cursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := tx.QueryRow(
+			"SELECT COALESCE(MAX(id), 0) FROM activity WHERE at < ?", now.Add(-time.Minute).UTC().Format("2006-01-02 15:04:05"),
+		).Scan(&batch.Cursor); err != nil {
+			return ActivityBatch{}, fmt.Errorf("failed to read activity: %w", err)
+		}
+		if _, err := tx.Exec("INSERT INTO webhook_cursors (url, activity_id) VALUES (?, ?)", url, batch.Cursor); err != nil {
+			return ActivityBatch{}, fmt.Errorf("failed to save webhook cursor: %w", err)
+		}
+	} else if err != nil {
+		return ActivityBatch{}, fmt.Errorf("failed to read webhook cursor: %w", err)
+	}
+
+	batch.Entries, err = queryActivity(tx,
+		"SELECT "+activityColumns+" FROM activity WHERE id > ? AND action IN (?, ?, ?) ORDER BY id LIMIT ?",
+		batch.Cursor, ActionCreate, ActionMove, ActionDelete, limit)
+	if err != nil {
+		return ActivityBatch{}, err
+	}
+	// Skipped actions are passed over too, so the cursor only stays behind
+	// the wanted entries that didn't fit in limit
+	if len(batch.Entries) == limit {
+		batch.Through = batch.Entries[len(batch.Entries)-1].ID
+	} else if err := tx.QueryRow("SELECT COALESCE(MAX(id), ?) FROM activity", batch.Cursor).Scan(&batch.Through); err != nil {
+		return ActivityBatch{}, fmt.Errorf("failed to read activity: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return ActivityBatch{}, fmt.Errorf("failed to read activity: %w", err)
+	}
+	return batch, nil
+}
+
+// AdvanceActivity moves the cursor of url from from to to, recording the
+// entries up to to as delivered, if it is still at from. It returns false when another process moved the
+// cursor meanwhile; that process delivers what follows.
+func (db *DB) AdvanceActivity(url string, from, to int64) (bool, error) {
+	result, err := db.exec("UPDATE webhook_cursors SET activity_id = ? WHERE url = ? AND activity_id = ?", to, url, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to save webhook cursor: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n == 1, nil
+}
+
+// queryActivity runs a query selecting activityColumns
+func queryActivity(ex execer, query string, args ...interface{}) ([]Activity, error) {
+	rows, err := ex.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activity: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Activity
+	for rows.Next() {
+		var a Activity
+		if err := rows.Scan(&a.ID, &a.At, &a.Action, &a.TaskID, &a.OldStatus, &a.NewStatus, &a.OldTitle, &a.NewTitle); err != nil {
+			return nil, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		entries = append(entries, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query activity: %w", err)
+	}
+	return entries, nil
+}
+
+// Describe summarizes the entry, naming columns as they are called on the
+// board, e.g. "moved Todo → Done" or "renamed to Fix login"
+func (a Activity) Describe(columns []model.Column) string {
+	name := func(status model.TaskStatus) string {
+		if col, ok := model.FindColumn(columns, string(status)); ok {
+			return col.Name
+		}
+		return string(status)
+	}
+	switch a.Action {
+	case ActionCreate:
+		return "created in " + name(a.NewStatus)
+	case ActionMove:
+		return fmt.Sprintf("moved %s → %s", name(a.OldStatus), name(a.NewStatus))
+	case ActionEdit:
+		if a.OldTitle != a.NewTitle {
+			return fmt.Sprintf("renamed from %q", a.OldTitle)
+		}
+		return "edited"
+	case ActionDelete:
+		return "moved to the trash"
+	case ActionRestore:
+		return "restored to " + name(a.NewStatus)
+	case ActionArchive:
+		return "archived"
+	case ActionUnarchive:
+		return "unarchived to " + name(a.NewStatus)
+	case ActionPurge:
+		return "deleted permanently"
+	}
+	return a.Action
+}
+
+// Title returns the title of the task the entry is about, as of the entry
+func (a Activity) Title() string {
+	if a.NewTitle != "" {
+		return a.NewTitle
+	}
+	return a.OldTitle
+}
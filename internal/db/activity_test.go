@@ -0,0 +1,71 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// pendingTitles returns the titles of the tasks of the entries pending for url
+func pendingTitles(t *testing.T, database *DB, url string, now time.Time) (ActivityBatch, []string) {
+	t.Helper()
+	batch, err := database.PendingActivity(url, 10, now)
+	if err != nil {
+		t.Fatalf("PendingActivity: %v", err)
+	}
+	var titles []string
+	for _, a := range batch.Entries {
+		titles = append(titles, a.Title())
+	}
+	return batch, titles
+}
+
+func TestPendingActivityPerURL(t *testing.T) {
+	database := newTestDB(t)
+	tasks := createTasks(t, database, "first", "second")
+	now := time.Now()
+	const hook, other = "http://localhost/hook", "http://localhost/other"
+
+	// Reading the entries doesn't deliver them
+	for i := 0; i < 2; i++ {
+		if _, titles := pendingTitles(t, database, hook, now); len(titles) != 2 {
+			t.Fatalf("pending = %q, want both creations", titles)
+		}
+	}
+	batch, created := pendingTitles(t, database, hook, now)
+	if ok, err := database.AdvanceActivity(hook, batch.Cursor, batch.Entries[0].ID); err != nil || !ok {
+		t.Fatalf("AdvanceActivity = %v, %v", ok, err)
+	}
+	if _, titles := pendingTitles(t, database, hook, now); len(titles) != 1 || titles[0] != created[1] {
+		t.Fatalf("pending = %q, want %q", titles, created[1:])
+	}
+	if _, titles := pendingTitles(t, database, other, now); len(titles) != 2 {
+		t.Fatalf("pending for another URL = %q, want both creations", titles)
+	}
+
+	// A cursor moved meanwhile is left where it is
+	if ok, err := database.AdvanceActivity(hook, batch.Cursor, batch.Through); err != nil || ok {
+		t.Fatalf("AdvanceActivity from a stale cursor = %v, %v; want false", ok, err)
+	}
+
+	// Edits aren't posted, but the cursor moves past them
+	if err := database.UpdateTask(tasks[0].ID, "renamed", model.StatusTodo); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	batch, titles := pendingTitles(t, database, hook, now)
+	if len(titles) != 1 || batch.Through <= batch.Entries[0].ID {
+		t.Fatalf("pending = %q through %d, want the last creation then the edit", titles, batch.Through)
+	}
+	if ok, err := database.AdvanceActivity(hook, batch.Cursor, batch.Through); err != nil || !ok {
+		t.Fatalf("AdvanceActivity = %v, %v", ok, err)
+	}
+	if batch, titles := pendingTitles(t, database, hook, now); len(titles) != 0 || batch.Through != batch.Cursor {
+		t.Fatalf("pending = %q, want none", titles)
+	}
+
+	// A new URL starts with the last minute of the log
+	if _, titles := pendingTitles(t, database, "http://localhost/new", now.Add(time.Hour)); len(titles) != 0 {
+		t.Fatalf("pending for a new URL = %q, want none older than a minute", titles)
+	}
+}
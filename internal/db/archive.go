@@ -0,0 +1,104 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// GetArchive retrieves the archived tasks that are not in the trash, most
+// recently archived first
+func (db *DB) GetArchive() ([]model.Task, error) {
+	return queryTasks(db.conn, "SELECT "+taskColumns+" FROM tasks WHERE archived_at IS NOT NULL AND deleted_at IS NULL ORDER BY archived_at DESC, id DESC")
+}
+
+// ArchiveTask takes a task off the board into the archive
+func (db *DB) ArchiveTask(id int64) error {
+	now := time.Now()
+	result, err := db.exec(
+		"UPDATE tasks SET archived_at = ?, updated_at = ? WHERE id = ? AND "+activeTaskSQL,
+		now, now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to archive task: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("task %d not found", id)
+	}
+	return nil
+}
+
+// ArchiveColumn archives every task of a column, returning how many were archived
+func (db *DB) ArchiveColumn(status model.TaskStatus) (int, error) {
+	now := time.Now()
+	result, err := db.exec(
+		"UPDATE tasks SET archived_at = ?, updated_at = ? WHERE status = ? AND "+activeTaskSQL,
+		now, now, status,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive column: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
+
+// ArchiveDone archives the tasks of the done column completed before the
+// cutoff, returning how many were archived. Tasks without a completion time
+// fall back to their last update.
+func (db *DB) ArchiveDone(before time.Time) (int, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive tasks: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"UPDATE tasks SET archived_at = ? WHERE "+doneTaskSQL+" AND "+activeTaskSQL+" AND COALESCE(completed_at, updated_at) < ?",
+		time.Now(), before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive tasks: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to archive tasks: %w", err)
+	}
+	return int(rows), nil
+}
+
+// UnarchiveTask brings an archived task back to its column
+func (db *DB) UnarchiveTask(id int64) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to unarchive task: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status model.TaskStatus
+	if err := tx.QueryRow("SELECT status FROM tasks WHERE id = ? AND archived_at IS NOT NULL AND deleted_at IS NULL", id).Scan(&status); err != nil {
+		return fmt.Errorf("task %d is not archived", id)
+	}
+	if err := checkWIPLimit(tx, id, status); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE tasks SET archived_at = NULL, updated_at = ? WHERE id = ?", time.Now(), id); err != nil {
+		return fmt.Errorf("failed to unarchive task: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to unarchive task: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// boardStatuses are the columns of a new board
+var boardStatuses = []model.TaskStatus{model.StatusTodo, model.StatusInProgress, model.StatusDone}
+
+// newLargeBoard returns a board of n tasks spread over its columns, each
+// with a tag and a checklist item
+func newLargeBoard(t testing.TB, n int) *DB {
+	t.Helper()
+	database := newTestDB(t)
+	drafts := make([]model.Task, n)
+	for i := range drafts {
+		drafts[i] = model.Task{
+			Title:    fmt.Sprintf("Task %d", i),
+			Status:   boardStatuses[i%len(boardStatuses)],
+			Priority: model.PriorityMedium,
+			Tags:     []string{fmt.Sprintf("tag%d", i%20)},
+			Subtasks: []model.Subtask{{Title: "step"}},
+		}
+	}
+	if _, err := database.CreateTasks(drafts); err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+	return database
+}
+
+// deleteFirst moves the first task of a column to the trash
+func deleteFirst(t *testing.T, database *DB, status model.TaskStatus) {
+	t.Helper()
+	first, err := database.GetTasksByStatus(status, 0, 1)
+	if err != nil || len(first) != 1 {
+		t.Fatalf("GetTasksByStatus = %v, %v", first, err)
+	}
+	if err := database.DeleteTask(first[0].ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetTasksByStatusPages(t *testing.T) {
+	database := newLargeBoard(t, 30)
+	deleteFirst(t, database, model.StatusTodo)
+	all, err := database.GetTasksByStatus(model.StatusTodo, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 9 {
+		t.Fatalf("todo holds %d tasks, want 9 without the deleted one", len(all))
+	}
+
+	tests := []struct {
+		offset, limit int
+		want          []model.Task
+	}{
+		{0, 4, all[:4]},
+		{4, 4, all[4:8]},
+		{8, 4, all[8:]},
+		{9, 4, nil},
+		{3, 0, all[3:]},
+		{-1, 2, all[:2]},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("offset %d limit %d", tt.offset, tt.limit), func(t *testing.T) {
+			page, err := database.GetTasksByStatus(model.StatusTodo, tt.offset, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page) != len(tt.want) {
+				t.Fatalf("got %d tasks, want %d", len(page), len(tt.want))
+			}
+			for i := range page {
+				if page[i].ID != tt.want[i].ID {
+					t.Fatalf("task %d is #%d, want #%d", i, page[i].ID, tt.want[i].ID)
+				}
+				if len(page[i].Tags) != 1 || len(page[i].Subtasks) != 1 {
+					t.Errorf("#%d is missing its tag or checklist: %+v", page[i].ID, page[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCountTasksByStatus(t *testing.T) {
+	database := newLargeBoard(t, 10)
+	deleteFirst(t, database, model.StatusTodo)
+	first, err := database.GetTasksByStatus(model.StatusInProgress, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.ArchiveTasks([]int64{first[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	counts, err := database.CountTasksByStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[model.TaskStatus]int{model.StatusTodo: 3, model.StatusInProgress: 2, model.StatusDone: 3}
+	if len(counts) != len(want) {
+		t.Fatalf("counts = %v, want %v", counts, want)
+	}
+	for status, n := range want {
+		if counts[status] != n {
+			t.Errorf("counts = %v, want %v", counts, want)
+		}
+	}
+}
+
+// BenchmarkGetTasksByStatus reads a page of 50 tasks from the middle of a
+// column of a board of 10,000 tasks
+func BenchmarkGetTasksByStatus(b *testing.B) {
+	database := newLargeBoard(b, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		page, err := database.GetTasksByStatus(model.StatusTodo, 1000, 50)
+		if err != nil || len(page) != 50 {
+			b.Fatalf("GetTasksByStatus = %d tasks, %v", len(page), err)
+		}
+	}
+}
+
+// BenchmarkCountTasksByStatus counts the tasks of the columns of a board of
+// 10,000 tasks
+func BenchmarkCountTasksByStatus(b *testing.B) {
+	database := newLargeBoard(b, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := database.CountTasksByStatus(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetBoard loads the whole of a board of 10,000 tasks, as the TUI
+// does, for comparison
+func BenchmarkGetBoard(b *testing.B) {
+	database := newLargeBoard(b, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := database.GetBoard(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Tasks in the trash or the archive stay where they are, and a recurring
+// one doesn't repeat
+func TestUpdateTaskStatusRefusesTasksOffTheBoard(t *testing.T) {
+	database := newTestDB(t)
+	tasks, err := database.CreateTasks([]model.Task{
+		{Title: "trashed", Status: model.StatusTodo, Recurrence: "daily"},
+		{Title: "archived", Status: model.StatusTodo, Recurrence: "daily"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+	trashed, archived := tasks[0], tasks[1]
+	if err := database.DeleteTask(trashed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.ArchiveTasks([]int64{archived.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	for task, want := range map[int64]string{
+		trashed.ID:  fmt.Sprintf("task %d is in the trash", trashed.ID),
+		archived.ID: fmt.Sprintf("task %d is in the archive", archived.ID),
+	} {
+		next, err := database.UpdateTaskStatus(task, model.StatusDone)
+		if err == nil || err.Error() != want || next != nil {
+			t.Errorf("UpdateTaskStatus(%d) = %v, %v; want %q", task, next, err, want)
+		}
+		got, err := database.GetTask(task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != model.StatusTodo || got.CompletedAt != nil {
+			t.Errorf("task %d moved: %+v", task, got)
+		}
+	}
+	if counts, err := database.CountTasksByStatus(); err != nil || len(counts) != 0 {
+		t.Errorf("CountTasksByStatus = %v, %v; want no new occurrence on the board", counts, err)
+	}
+}
+
+// Importing over a board that gave out IDs doesn't give them out again, and
+// keeps the parents and dependencies among the imported tasks
+func TestImportTasksOverwriteDoesNotReuseIDs(t *testing.T) {
+	database := newTestDB(t)
+	old := createTasks(t, database, "old one", "old two")
+	last := old[0].ID
+	if old[1].ID > last {
+		last = old[1].ID
+	}
+	if err := database.DeleteTask(last); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.PurgeTask(last); err != nil {
+		t.Fatal(err)
+	}
+
+	columns := []model.Column{{Name: "To Do", Status: model.StatusTodo, Tasks: []model.Task{
+		{ID: 1, Title: "parent", Priority: model.PriorityMedium},
+		{ID: 2, Title: "child", Priority: model.PriorityMedium, Parent: 1, BlockedBy: []int64{1}},
+	}}}
+	if _, err := database.ImportTasks(columns, true); err != nil {
+		t.Fatalf("ImportTasks: %v", err)
+	}
+	tasks, err := database.GetTasksByStatus(model.StatusTodo, 0, 0)
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("GetTasksByStatus = %v, %v", tasks, err)
+	}
+	ids := map[string]int64{}
+	for _, task := range tasks {
+		if task.ID <= last {
+			t.Errorf("imported %q as #%d, reusing an ID given out before", task.Title, task.ID)
+		}
+		ids[task.Title] = task.ID
+	}
+	child, err := database.GetTask(ids["child"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child.Parent != ids["parent"] || len(child.BlockedBy) != 1 || child.BlockedBy[0] != ids["parent"] {
+		t.Errorf("child has parent %d and blockers %v, want #%d for both", child.Parent, child.BlockedBy, ids["parent"])
+	}
+}
+
+// Tasks of a column without a name or status, as a CSV with only a title
+// gives, go to the first column and leave the board's columns as they are
+func TestImportTasksWithoutColumn(t *testing.T) {
+	for _, replace := range []bool{false, true} {
+		database := newTestDB(t)
+		columns := []model.Column{{Tasks: []model.Task{{Title: "loose", Priority: model.PriorityMedium}}}}
+		if _, err := database.ImportTasks(columns, replace); err != nil {
+			t.Fatalf("ImportTasks(replace %v): %v", replace, err)
+		}
+		board, err := database.GetBoard()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(board) != len(boardStatuses) || len(board[0].Tasks) != 1 || board[0].Tasks[0].Title != "loose" {
+			t.Errorf("replace %v: board = %+v, want the task in the first of the board's columns", replace, board)
+		}
+	}
+}
+
+// A created task comes back at the version it is stored with, so an edit
+// made on it is checked
+func TestCreateTaskReturnsStoredVersion(t *testing.T) {
+	database := newTestDB(t)
+	task, err := database.CreateTaskFrom(model.Task{Title: "new", Status: model.StatusTodo})
+	if err != nil {
+		t.Fatalf("CreateTaskFrom: %v", err)
+	}
+	stored, err := database.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Version != stored.Version || task.Version != 1 {
+		t.Errorf("created task at version %d, stored at %d; want 1 for both", task.Version, stored.Version)
+	}
+}
@@ -0,0 +1,231 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// GetTasks retrieves the tasks with the given IDs, in the trash and the
+// archive too, ordered by ID. IDs that don't exist are left out.
+func (db *DB) GetTasks(ids []int64) ([]model.Task, error) {
+	return getTasks(db.conn, ids)
+}
+
+// getTasks retrieves the tasks with the given IDs, within a transaction or not
+func getTasks(ex execer, ids []int64) ([]model.Task, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	placeholders, args := idArgs(ids)
+	return queryTasks(ex, "SELECT "+taskColumns+" FROM tasks WHERE id IN ("+placeholders+") ORDER BY id", args...)
+}
+
+// idArgs returns the placeholders and arguments of an IN list of IDs
+func idArgs(ids []int64) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", "), args
+}
+
+// narrowLoads is how many tasks a query may read the tags, checklists or
+// dependencies of by ID, such as a page of a column. For more, such as the
+// whole board, reading every row and skipping those of other tasks is
+// quicker.
+const narrowLoads = 500
+
+// onlyTasks returns the WHERE clause restricting a query to the rows whose
+// column is one of the tasks, with its arguments, or nothing for more
+// tasks than narrowLoads
+func onlyTasks(column string, tasks []model.Task) (string, []interface{}) {
+	if len(tasks) > narrowLoads {
+		return "", nil
+	}
+	ids := make([]int64, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+	}
+	placeholders, args := idArgs(ids)
+	return " WHERE " + column + " IN (" + placeholders + ")", args
+}
+
+// eachTask runs change on every task in one transaction, so either all of
+// them change or none does
+func (tx *Tx) eachTask(ids []int64, change func(id int64) error) error {
+	for _, id := range ids {
+		if err := change(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateActiveTask runs an update on a task of the board, failing when the
+// task is in the trash, archived or gone
+func updateActiveTask(tx *sql.Tx, id int64, what, set string, args ...interface{}) error {
+	result, err := tx.Exec("UPDATE tasks SET "+set+" WHERE id = ? AND "+activeTaskSQL, append(args, id)...)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", what, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("task %d not found", id)
+	}
+	return nil
+}
+
+// MoveTasks moves tasks to the bottom of a column, in the given order. With
+// strict WIP limits on, nothing moves when the column can't take them all.
+// The next occurrences of recurring tasks moved into the done column are
+// created and returned.
+func (db *DB) MoveTasks(ids []int64, status model.TaskStatus) ([]model.Task, error) {
+	var repeats []model.Task
+	err := db.inTx("move tasks", func(tx *Tx) error {
+		var err error
+		repeats, err = tx.MoveTasks(ids, status)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repeats, nil
+}
+
+// MoveTasks moves tasks to the bottom of a column like DB.MoveTasks does
+func (tx *Tx) MoveTasks(ids []int64, status model.TaskStatus) ([]model.Task, error) {
+	tasks, err := tx.GetTasks(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*model.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	done, err := doneStatuses(tx.tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var repeats []model.Task
+	now := time.Now()
+	err = tx.eachTask(ids, func(id int64) error {
+		task, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("task %d not found", id)
+		}
+		if task.Status != status {
+			if err := checkWIPLimit(tx.tx, id, status); err != nil {
+				return err
+			}
+		}
+		if err := updateActiveTask(tx.tx, id, "move tasks", movePositionSQL+", status = ?, updated_at = ?, "+completedAtSQL,
+			status, status, status, now, done[status], now); err != nil {
+			return err
+		}
+		if done[status] && !done[task.Status] && task.Recurrence != "" {
+			next, err := repeatTask(tx.tx, task, now)
+			if err != nil {
+				return err
+			}
+			repeats = append(repeats, *next)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repeats, nil
+}
+
+// SetTasksPriority sets the priority of several tasks
+func (db *DB) SetTasksPriority(ids []int64, priority model.TaskPriority) error {
+	return db.inTx("update task priorities", func(tx *Tx) error { return tx.SetTasksPriority(ids, priority) })
+}
+
+// SetTasksPriority sets the priority of several tasks
+func (tx *Tx) SetTasksPriority(ids []int64, priority model.TaskPriority) error {
+	now := time.Now()
+	return tx.eachTask(ids, func(id int64) error {
+		return updateActiveTask(tx.tx, id, "update task priorities", "priority = ?, updated_at = ?", priority, now)
+	})
+}
+
+// AddTasksTag adds a tag to several tasks, creating it if needed. Tasks that
+// already have it keep it once.
+func (db *DB) AddTasksTag(ids []int64, tag string) error {
+	return db.inTx("tag tasks", func(tx *Tx) error { return tx.AddTasksTag(ids, tag) })
+}
+
+// AddTasksTag adds a tag to several tasks like DB.AddTasksTag does
+func (tx *Tx) AddTasksTag(ids []int64, tag string) error {
+	tags := cleanTags([]string{tag})
+	if len(tags) == 0 {
+		return fmt.Errorf("empty tag")
+	}
+	now := time.Now()
+	return tx.eachTask(ids, func(id int64) error {
+		if err := updateActiveTask(tx.tx, id, "tag tasks", "updated_at = ?", now); err != nil {
+			return err
+		}
+		labelID, err := ensureLabel(tx.tx, tags[0])
+		if err != nil {
+			return err
+		}
+		if _, err := tx.tx.Exec("INSERT OR IGNORE INTO task_labels (task_id, label_id) VALUES (?, ?)", id, labelID); err != nil {
+			return fmt.Errorf("failed to add label %q: %w", tags[0], err)
+		}
+		return nil
+	})
+}
+
+// ArchiveTasks takes several tasks off the board into the archive
+func (db *DB) ArchiveTasks(ids []int64) error {
+	return db.inTx("archive tasks", func(tx *Tx) error { return tx.ArchiveTasks(ids) })
+}
+
+// ArchiveTasks takes several tasks off the board into the archive
+func (tx *Tx) ArchiveTasks(ids []int64) error {
+	now := time.Now()
+	return tx.eachTask(ids, func(id int64) error {
+		return updateActiveTask(tx.tx, id, "archive tasks", "archived_at = ?, updated_at = ?", now, now)
+	})
+}
+
+// DeleteTasks moves several tasks to the trash
+func (db *DB) DeleteTasks(ids []int64) error {
+	return db.inTx("delete tasks", func(tx *Tx) error { return tx.DeleteTasks(ids) })
+}
+
+// DeleteTasks moves several tasks to the trash
+func (tx *Tx) DeleteTasks(ids []int64) error {
+	now := time.Now()
+	return tx.eachTask(ids, func(id int64) error {
+		return updateActiveTask(tx.tx, id, "delete tasks", "deleted_at = ?", now)
+	})
+}
+
+// RestoreTasks writes several task snapshots back in one transaction, like
+// RestoreTask does for one
+func (db *DB) RestoreTasks(tasks []model.Task) error {
+	return db.inTx("restore tasks", func(tx *Tx) error { return tx.RestoreTasks(tasks) })
+}
+
+// RestoreTasks writes task snapshots back under their IDs, like
+// DB.RestoreTask does for one
+func (tx *Tx) RestoreTasks(tasks []model.Task) error {
+	for _, task := range tasks {
+		if err := restoreTask(tx.tx, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
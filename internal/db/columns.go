@@ -0,0 +1,495 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// createColumnTables creates the board_columns table. A new or pre-existing
+// workspace is seeded with the default columns, plus a column for any status
+// its tasks use that isn't one of them.
+func createColumnTables(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS board_columns (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		status TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		position INTEGER NOT NULL
+	);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create columns table: %w", err)
+	}
+
+	var count int
+	if err := ex.QueryRow("SELECT COUNT(*) FROM board_columns").Scan(&count); err != nil {
+		return fmt.Errorf("failed to count columns: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	columns := model.DefaultColumns()
+	for i, col := range columns {
+		if _, err := ex.Exec("INSERT INTO board_columns (status, name, position) VALUES (?, ?, ?)", col.Status, col.Name, i); err != nil {
+			return fmt.Errorf("failed to seed columns: %w", err)
+		}
+	}
+	if _, err := ex.Exec(`
+		INSERT INTO board_columns (status, name, position)
+		SELECT status, status, ? + ROW_NUMBER() OVER (ORDER BY status) - 1
+		FROM (SELECT DISTINCT status FROM tasks WHERE status NOT IN (SELECT status FROM board_columns))
+	`, len(columns)); err != nil {
+		return fmt.Errorf("failed to seed columns: %w", err)
+	}
+	return nil
+}
+
+// GetColumns returns the workspace's columns in board order, without tasks
+func (db *DB) GetColumns() ([]model.Column, error) {
+	return queryColumns(db.conn)
+}
+
+// queryColumns loads the columns in board order
+func queryColumns(ex execer) ([]model.Column, error) {
+	rows, err := ex.Query("SELECT status, name, wip_limit, is_done FROM board_columns ORDER BY position, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []model.Column
+	for rows.Next() {
+		col := model.Column{Tasks: []model.Task{}}
+		if err := rows.Scan(&col.Status, &col.Name, &col.WIPLimit, &col.Done); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns = append(columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	return columns, nil
+}
+
+// doneSet holds the statuses of the done columns
+type doneSet map[model.TaskStatus]bool
+
+// doneStatuses returns the columns marked done. Every change that completes
+// or reopens a task goes by them: a task entering one of them is completed,
+// and one leaving them for another column is open again.
+func doneStatuses(ex execer) (doneSet, error) {
+	rows, err := ex.Query("SELECT status FROM board_columns WHERE is_done = 1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up done columns: %w", err)
+	}
+	defer rows.Close()
+	done := doneSet{}
+	for rows.Next() {
+		var status model.TaskStatus
+		if err := rows.Scan(&status); err != nil {
+			return nil, fmt.Errorf("failed to look up done columns: %w", err)
+		}
+		done[status] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to look up done columns: %w", err)
+	}
+	return done, nil
+}
+
+// doneTaskSQL matches the tasks in a done column, like doneStatuses
+const doneTaskSQL = "status IN (SELECT status FROM board_columns WHERE is_done = 1)"
+
+// rightmostStatus returns the status of the rightmost column, which was the
+// done column before columns were marked done; migrations from before then
+// go by it
+func rightmostStatus(ex execer) (model.TaskStatus, error) {
+	var status model.TaskStatus
+	if err := ex.QueryRow("SELECT status FROM board_columns ORDER BY position DESC, id DESC LIMIT 1").Scan(&status); err != nil {
+		return "", fmt.Errorf("failed to look up done column: %w", err)
+	}
+	return status, nil
+}
+
+// ensureDoneColumn marks the rightmost column done when none is, so a board
+// always has a column to complete tasks in
+func ensureDoneColumn(ex execer) error {
+	if _, err := ex.Exec(`
+		UPDATE board_columns SET is_done = 1
+		WHERE NOT EXISTS (SELECT 1 FROM board_columns WHERE is_done = 1)
+			AND id = (SELECT id FROM board_columns ORDER BY position DESC, id DESC LIMIT 1)
+	`); err != nil {
+		return fmt.Errorf("failed to mark the done column: %w", err)
+	}
+	return nil
+}
+
+// SetColumnDone marks a column as a done column or not. Tasks already in a
+// column marked done are completed now, and those in a column no longer
+// done are open again. At least one column stays done.
+func (db *DB) SetColumnDone(status model.TaskStatus, done bool) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to mark the done column: %w", err)
+	}
+	defer tx.Rollback()
+
+	columns, err := queryColumns(tx)
+	if err != nil {
+		return err
+	}
+	col, ok := model.FindColumn(columns, string(status))
+	if !ok {
+		return fmt.Errorf("column not found")
+	}
+	if col.Done == done {
+		return nil
+	}
+	if !done && len(model.DoneStatuses(columns)) == 1 {
+		return fmt.Errorf("%s is the only done column: mark another column done first", col.Name)
+	}
+
+	if _, err := tx.Exec("UPDATE board_columns SET is_done = ? WHERE status = ?", done, status); err != nil {
+		return fmt.Errorf("failed to mark the done column: %w", err)
+	}
+	now := time.Now()
+	if _, err := tx.Exec("UPDATE tasks SET "+completedAtSQL+" WHERE status = ?", done, now, status); err != nil {
+		return fmt.Errorf("failed to mark the done column: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to mark the done column: %w", err)
+	}
+	return nil
+}
+
+// columnStatusRe matches runs of characters that aren't allowed in a status key
+var columnStatusRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// newColumnStatus derives a unique status key such as "code_review" from a column name
+func newColumnStatus(name string, columns []model.Column) model.TaskStatus {
+	base := strings.Trim(columnStatusRe.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if base == "" {
+		base = "column"
+	}
+
+	taken := func(status string) bool {
+		for _, col := range columns {
+			if string(col.Status) == status {
+				return true
+			}
+		}
+		return false
+	}
+
+	status := base
+	for n := 2; taken(status); n++ {
+		status = fmt.Sprintf("%s_%d", base, n)
+	}
+	return model.TaskStatus(status)
+}
+
+// validColumnStatus reports whether status can be used as the key of a new column
+func validColumnStatus(status model.TaskStatus, columns []model.Column) bool {
+	if status == "" || columnStatusRe.MatchString(strings.ReplaceAll(string(status), "_", "")) {
+		return false
+	}
+	for _, col := range columns {
+		if col.Status == status {
+			return false
+		}
+	}
+	return true
+}
+
+// checkColumnName validates a column name against the other columns of the board
+func checkColumnName(name string, columns []model.Column, self model.TaskStatus) error {
+	if name == "" {
+		return fmt.Errorf("column name cannot be empty")
+	}
+	if col, ok := model.FindColumn(columns, name); ok && col.Status != self {
+		return fmt.Errorf("column %q already exists", col.Name)
+	}
+	return nil
+}
+
+// renumberColumns stores the given column order as positions 0..n-1
+func renumberColumns(ex execer, columns []model.Column) error {
+	for pos, col := range columns {
+		if _, err := ex.Exec("UPDATE board_columns SET position = ? WHERE status = ?", pos, col.Status); err != nil {
+			return fmt.Errorf("failed to reorder columns: %w", err)
+		}
+	}
+	return nil
+}
+
+// insertColumn adds a column at the given index of the current order (clamped
+// to the ends). The status key is kept when it is well-formed and unused,
+// otherwise one is derived from the name.
+func insertColumn(ex execer, name string, status model.TaskStatus, index int) (model.Column, error) {
+	name = strings.TrimSpace(name)
+	columns, err := queryColumns(ex)
+	if err != nil {
+		return model.Column{}, err
+	}
+	if err := checkColumnName(name, columns, ""); err != nil {
+		return model.Column{}, err
+	}
+
+	if !validColumnStatus(status, columns) {
+		status = newColumnStatus(name, columns)
+	}
+	col := model.Column{Name: name, Status: status, Tasks: []model.Task{}}
+	if _, err := ex.Exec("INSERT INTO board_columns (status, name, position) VALUES (?, ?, ?)", col.Status, col.Name, len(columns)); err != nil {
+		return model.Column{}, fmt.Errorf("failed to create column: %w", err)
+	}
+
+	if index < 0 || index > len(columns) {
+		index = len(columns)
+	}
+	ordered := append(append(append([]model.Column{}, columns[:index]...), col), columns[index:]...)
+	if err := renumberColumns(ex, ordered); err != nil {
+		return model.Column{}, err
+	}
+	return col, nil
+}
+
+// CreateColumn adds a column named name at position index (0 is leftmost;
+// an out-of-range index appends it) and returns it
+func (db *DB) CreateColumn(name string, index int) (model.Column, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return model.Column{}, fmt.Errorf("failed to create column: %w", err)
+	}
+	defer tx.Rollback()
+
+	col, err := insertColumn(tx, name, "", index)
+	if err != nil {
+		return model.Column{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.Column{}, fmt.Errorf("failed to create column: %w", err)
+	}
+	return col, nil
+}
+
+// RenameColumn changes the display name of a column; its status key is kept
+func (db *DB) RenameColumn(status model.TaskStatus, name string) error {
+	name = strings.TrimSpace(name)
+	columns, err := db.GetColumns()
+	if err != nil {
+		return err
+	}
+	if err := checkColumnName(name, columns, status); err != nil {
+		return err
+	}
+
+	result, err := db.exec("UPDATE board_columns SET name = ? WHERE status = ?", name, status)
+	if err != nil {
+		return fmt.Errorf("failed to rename column: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("column not found")
+	}
+
+	return nil
+}
+
+// SetWIPLimit sets the maximum number of tasks of a column; 0 removes the limit
+func (db *DB) SetWIPLimit(status model.TaskStatus, limit int) error {
+	if limit < 0 {
+		return fmt.Errorf("WIP limit cannot be negative")
+	}
+
+	result, err := db.exec("UPDATE board_columns SET wip_limit = ? WHERE status = ?", limit, status)
+	if err != nil {
+		return fmt.Errorf("failed to set WIP limit: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("column not found")
+	}
+
+	return nil
+}
+
+// ErrWIPLimitExceeded is returned when strict WIP limits block moving a task into a full column
+var ErrWIPLimitExceeded = errors.New("WIP limit exceeded")
+
+// checkWIPLimit returns ErrWIPLimitExceeded if strict WIP limits are on and
+// moving task id into the status column would push it over its limit
+func checkWIPLimit(ex execer, id int64, status model.TaskStatus) error {
+	strict, err := strictWIP(ex)
+	if err != nil || !strict {
+		return err
+	}
+
+	var name string
+	var limit, count int
+	if err := ex.QueryRow("SELECT name, wip_limit FROM board_columns WHERE status = ?", status).Scan(&name, &limit); err != nil {
+		return fmt.Errorf("failed to look up column: %w", err)
+	}
+	if limit <= 0 {
+		return nil
+	}
+	// Snoozed tasks are off the board until they wake
+	if err := ex.QueryRow("SELECT COUNT(*) FROM tasks WHERE status = ? AND id != ? AND "+activeTaskSQL+" AND "+awakeTaskSQL, status, id, time.Now().Format(dates.DateFormat)).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count tasks: %w", err)
+	}
+	if count >= limit {
+		return fmt.Errorf("%w: %s already has %d/%d tasks", ErrWIPLimitExceeded, name, count, limit)
+	}
+	return nil
+}
+
+// DeleteColumn removes a column. If it still has tasks they are moved to the
+// moveTo column; with an empty moveTo a non-empty column is not deleted.
+func (db *DB) DeleteColumn(status, moveTo model.TaskStatus) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete column: %w", err)
+	}
+	defer tx.Rollback()
+
+	columns, err := queryColumns(tx)
+	if err != nil {
+		return err
+	}
+	var remaining []model.Column
+	found, targetFound := false, false
+	for _, col := range columns {
+		switch col.Status {
+		case status:
+			found = true
+			continue
+		case moveTo:
+			targetFound = true
+		}
+		remaining = append(remaining, col)
+	}
+	if !found {
+		return fmt.Errorf("column not found")
+	}
+	if len(remaining) == 0 {
+		return fmt.Errorf("cannot delete the last column")
+	}
+
+	var count, hidden int
+	if err := tx.QueryRow(
+		"SELECT COUNT(*) FILTER (WHERE "+activeTaskSQL+"), COUNT(*) FILTER (WHERE NOT ("+activeTaskSQL+")) FROM tasks WHERE status = ?", status,
+	).Scan(&count, &hidden); err != nil {
+		return fmt.Errorf("failed to count tasks: %w", err)
+	}
+	if count > 0 {
+		if moveTo == "" {
+			return fmt.Errorf("column is not empty: it has %d tasks", count)
+		}
+		if !targetFound {
+			return fmt.Errorf("target column %q not found", moveTo)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM board_columns WHERE status = ?", status); err != nil {
+		return fmt.Errorf("failed to delete column: %w", err)
+	}
+	// A new column may get the status back, but not the sort mode, the
+	// collapsed state or the width
+	if _, err := tx.Exec("DELETE FROM settings WHERE key IN (?, ?, ?)", SettingSortPrefix+string(status), SettingCollapsedPrefix+string(status), SettingWidthPrefix+string(status)); err != nil {
+		return fmt.Errorf("failed to delete column: %w", err)
+	}
+	if err := renumberColumns(tx, remaining); err != nil {
+		return err
+	}
+	if err := ensureDoneColumn(tx); err != nil {
+		return err
+	}
+
+	if count+hidden > 0 {
+		// Tasks in the trash or the archive follow the others, or go to the
+		// first column when there is nothing else to move
+		if moveTo == "" {
+			moveTo = remaining[0].Status
+		}
+		done, err := doneStatuses(tx)
+		if err != nil {
+			return err
+		}
+		// Append the moved tasks below the target column's own, keeping their order
+		var offset int
+		if err := tx.QueryRow(nextPositionSQL, moveTo).Scan(&offset); err != nil {
+			return fmt.Errorf("failed to get task position: %w", err)
+		}
+		var first int
+		if err := tx.QueryRow("SELECT MIN(position) FROM tasks WHERE status = ?", status).Scan(&first); err != nil {
+			return fmt.Errorf("failed to get task position: %w", err)
+		}
+		now := time.Now()
+		if _, err := tx.Exec(
+			"UPDATE tasks SET status = ?, position = position - ? + ?, updated_at = ?, "+completedAtSQL+" WHERE status = ?",
+			moveTo, first, offset, now, done[moveTo], now, status,
+		); err != nil {
+			return fmt.Errorf("failed to move tasks: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete column: %w", err)
+	}
+	return nil
+}
+
+// MoveColumn moves a column left (delta < 0) or right (delta > 0) by one place
+func (db *DB) MoveColumn(status model.TaskStatus, delta int) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to move column: %w", err)
+	}
+	defer tx.Rollback()
+
+	columns, err := queryColumns(tx)
+	if err != nil {
+		return err
+	}
+	from := -1
+	for i, col := range columns {
+		if col.Status == status {
+			from = i
+		}
+	}
+	if from < 0 {
+		return fmt.Errorf("column not found")
+	}
+	to := from + delta
+	if to < 0 || to >= len(columns) {
+		return nil
+	}
+	columns[from], columns[to] = columns[to], columns[from]
+
+	if err := renumberColumns(tx, columns); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to move column: %w", err)
+	}
+	return nil
+}
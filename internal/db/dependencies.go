@@ -0,0 +1,162 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// ErrDependencyCycle is returned when a dependency would make a task wait,
+// directly or through other tasks, on itself
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// createDependencyTable creates the table of which task blocks which, and
+// the revision triggers on it so open boards pick up changes
+func createDependencyTable(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS task_dependencies (
+		task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		blocker_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		PRIMARY KEY (task_id, blocker_id),
+		CHECK (task_id != blocker_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocker ON task_dependencies(blocker_id);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create task_dependencies table: %w", err)
+	}
+	return createRevisionTriggers(ex, "task_dependencies")
+}
+
+// loadDependencies fills in the BlockedBy of each task, lowest ID first
+func loadDependencies(ex execer, tasks []model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(tasks))
+	for i := range tasks {
+		index[tasks[i].ID] = i
+		tasks[i].BlockedBy = nil
+	}
+
+	where, args := onlyTasks("task_id", tasks)
+	rows, err := ex.Query("SELECT task_id, blocker_id FROM task_dependencies"+where+" ORDER BY task_id, blocker_id", args...)
+	if err != nil {
+		return fmt.Errorf("failed to query dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID, blockerID int64
+		if err := rows.Scan(&taskID, &blockerID); err != nil {
+			return fmt.Errorf("failed to scan dependency: %w", err)
+		}
+		if i, ok := index[taskID]; ok {
+			tasks[i].BlockedBy = append(tasks[i].BlockedBy, blockerID)
+		}
+	}
+	return rows.Err()
+}
+
+// AddDependency records that a task is blocked by another one. It refuses
+// a task blocking itself and, with ErrDependencyCycle, a blocker that
+// already waits on the task.
+func (db *DB) AddDependency(taskID, blockerID int64) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to add dependency: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := addDependency(tx, taskID, blockerID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add dependency: %w", err)
+	}
+	return nil
+}
+
+// addDependency checks and records a dependency within a transaction
+func addDependency(ex execer, taskID, blockerID int64) error {
+	if taskID == blockerID {
+		return fmt.Errorf("task #%d can't block itself", taskID)
+	}
+	for _, id := range []int64{taskID, blockerID} {
+		var exists int
+		if err := ex.QueryRow("SELECT COUNT(*) FROM tasks WHERE id = ?", id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to look up task: %w", err)
+		}
+		if exists == 0 {
+			return fmt.Errorf("task #%d not found", id)
+		}
+	}
+
+	waits, err := waitsOn(ex, blockerID, taskID)
+	if err != nil {
+		return err
+	}
+	if waits {
+		return fmt.Errorf("%w: #%d already waits on #%d", ErrDependencyCycle, blockerID, taskID)
+	}
+
+	if _, err := ex.Exec("INSERT OR IGNORE INTO task_dependencies (task_id, blocker_id) VALUES (?, ?)", taskID, blockerID); err != nil {
+		return fmt.Errorf("failed to add dependency: %w", err)
+	}
+	return nil
+}
+
+// waitsOn reports whether task from is blocked by task to, directly or
+// through the tasks blocking it
+func waitsOn(ex execer, from, to int64) (bool, error) {
+	rows, err := ex.Query(`
+		WITH RECURSIVE blockers(id) AS (
+			SELECT blocker_id FROM task_dependencies WHERE task_id = ?
+			UNION
+			SELECT d.blocker_id FROM task_dependencies AS d JOIN blockers AS b ON d.task_id = b.id
+		)
+		SELECT 1 FROM blockers WHERE id = ? LIMIT 1`, from, to)
+	if err != nil {
+		return false, fmt.Errorf("failed to check dependencies: %w", err)
+	}
+	defer rows.Close()
+	found := rows.Next()
+	return found, rows.Err()
+}
+
+// RemoveDependency drops the dependency of a task on a blocker
+func (db *DB) RemoveDependency(taskID, blockerID int64) error {
+	result, err := db.exec("DELETE FROM task_dependencies WHERE task_id = ? AND blocker_id = ?", taskID, blockerID)
+	if err != nil {
+		return fmt.Errorf("failed to remove dependency: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("task #%d is not blocked by #%d", taskID, blockerID)
+	}
+	return nil
+}
+
+// setDependencies replaces the blockers of a task, skipping the ones that
+// no longer exist
+func setDependencies(ex execer, taskID int64, blockers []int64) error {
+	if _, err := ex.Exec("DELETE FROM task_dependencies WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("failed to restore dependencies: %w", err)
+	}
+	for _, blockerID := range blockers {
+		if _, err := ex.Exec(
+			"INSERT OR IGNORE INTO task_dependencies (task_id, blocker_id) SELECT ?, id FROM tasks WHERE id = ? AND id != ?",
+			taskID, blockerID, taskID,
+		); err != nil {
+			return fmt.Errorf("failed to restore dependencies: %w", err)
+		}
+	}
+	return nil
+}
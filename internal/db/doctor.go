@@ -0,0 +1,277 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Problem is an issue found by Check
+type Problem struct {
+	Check   string // name of the check that found it
+	Detail  string
+	Fixable bool // Repair can fix it
+}
+
+// Report is the result of Check
+type Report struct {
+	SchemaVersion int
+	Problems      []Problem
+}
+
+// Check runs the integrity checks on the database: SQLite's own integrity
+// and foreign key checks, tasks in columns that don't exist, labels no task
+// uses and tasks sharing a position in their column. A database at another
+// schema than the current one is a problem of its own, and only gets
+// SQLite's checks, as its tables may differ; an older one is fixed by
+// opening it with New, which migrates it.
+func (db *DB) Check() (Report, error) {
+	var report Report
+	version, err := schemaVersion(db.conn)
+	if err != nil {
+		return report, err
+	}
+	report.SchemaVersion = version
+
+	checks := []func() ([]Problem, error){
+		db.checkIntegrity,
+		db.checkForeignKeys,
+	}
+	switch latest := SchemaVersion(); {
+	case version < latest:
+		report.Problems = append(report.Problems, Problem{
+			Check:   "schema",
+			Detail:  fmt.Sprintf("version %d needs migrating to %d", version, latest),
+			Fixable: true,
+		})
+	case version > latest:
+		report.Problems = append(report.Problems, Problem{
+			Check:  "schema",
+			Detail: fmt.Sprintf("version %d is newer than this cli_kanban supports (%d); please upgrade cli_kanban", version, latest),
+		})
+	default:
+		checks = append(checks, db.checkOrphanedTasks, db.checkUnusedLabels, db.checkPositions)
+	}
+	for _, check := range checks {
+		problems, err := check()
+		if err != nil {
+			return report, err
+		}
+		report.Problems = append(report.Problems, problems...)
+	}
+	return report, nil
+}
+
+// checkIntegrity runs PRAGMA integrity_check
+func (db *DB) checkIntegrity() ([]Problem, error) {
+	rows, err := db.conn.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check integrity: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []Problem
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, fmt.Errorf("failed to scan integrity check: %w", err)
+		}
+		if msg != "ok" {
+			problems = append(problems, Problem{Check: "integrity", Detail: msg})
+		}
+	}
+	return problems, rows.Err()
+}
+
+// checkForeignKeys runs PRAGMA foreign_key_check. Label links, subtasks, time
+// entries, due reminders and dependencies of missing tasks can be deleted.
+func (db *DB) checkForeignKeys() ([]Problem, error) {
+	rows, err := db.conn.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []Problem
+	for rows.Next() {
+		var table, parent string
+		var rowid, fkid int64
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key check: %w", err)
+		}
+		problems = append(problems, Problem{
+			Check:   "foreign-keys",
+			Detail:  fmt.Sprintf("%s row %d points at a missing %s row", table, rowid, parent),
+			Fixable: table == "task_labels" || table == "subtasks" || table == "time_entries" || table == "due_reminders" || table == "task_dependencies",
+		})
+	}
+	return problems, rows.Err()
+}
+
+// checkOrphanedTasks finds tasks whose column doesn't exist
+func (db *DB) checkOrphanedTasks() ([]Problem, error) {
+	rows, err := db.conn.Query(`
+		SELECT status, COUNT(*) FROM tasks
+		WHERE status NOT IN (SELECT status FROM board_columns)
+		GROUP BY status ORDER BY status
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check task columns: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []Problem
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan task columns: %w", err)
+		}
+		problems = append(problems, Problem{
+			Check:   "orphaned-tasks",
+			Detail:  fmt.Sprintf("%d task(s) in missing column %q", count, status),
+			Fixable: true,
+		})
+	}
+	return problems, rows.Err()
+}
+
+// checkUnusedLabels finds labels no task uses
+func (db *DB) checkUnusedLabels() ([]Problem, error) {
+	names, err := queryStrings(db.conn, "SELECT name FROM labels WHERE id NOT IN (SELECT label_id FROM task_labels) ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check labels: %w", err)
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	return []Problem{{
+		Check:   "unused-labels",
+		Detail:  fmt.Sprintf("%d label(s) without tasks: %s", len(names), strings.Join(names, ", ")),
+		Fixable: true,
+	}}, nil
+}
+
+// checkPositions finds columns in which tasks share a position
+func (db *DB) checkPositions() ([]Problem, error) {
+	statuses, err := queryStrings(db.conn, `
+		SELECT DISTINCT status FROM tasks
+		GROUP BY status, position HAVING COUNT(*) > 1
+		ORDER BY status
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check task positions: %w", err)
+	}
+	var problems []Problem
+	for _, status := range statuses {
+		problems = append(problems, Problem{
+			Check:   "positions",
+			Detail:  fmt.Sprintf("tasks in column %q share positions", status),
+			Fixable: true,
+		})
+	}
+	return problems, nil
+}
+
+// Repair fixes what Check reports as fixable: label links, subtasks, time
+// entries, due reminders and dependencies of missing tasks are deleted, tasks in missing columns move to the end of the
+// first column, unused labels are deleted and columns with shared positions
+// are renumbered in their current order. It returns the number of rows
+// changed.
+func (db *DB) Repair() (int64, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin repair: %w", err)
+	}
+	defer tx.Rollback()
+
+	var changed int64
+	run := func(query string, args ...interface{}) error {
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to repair database: %w", err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		changed += n
+		return nil
+	}
+
+	if err := run("DELETE FROM task_labels WHERE task_id NOT IN (SELECT id FROM tasks) OR label_id NOT IN (SELECT id FROM labels)"); err != nil {
+		return 0, err
+	}
+	if err := run("DELETE FROM subtasks WHERE task_id NOT IN (SELECT id FROM tasks)"); err != nil {
+		return 0, err
+	}
+	if err := run("DELETE FROM time_entries WHERE task_id NOT IN (SELECT id FROM tasks)"); err != nil {
+		return 0, err
+	}
+	if err := run("DELETE FROM due_reminders WHERE task_id NOT IN (SELECT id FROM tasks)"); err != nil {
+		return 0, err
+	}
+	if err := run("DELETE FROM task_dependencies WHERE task_id NOT IN (SELECT id FROM tasks) OR blocker_id NOT IN (SELECT id FROM tasks)"); err != nil {
+		return 0, err
+	}
+
+	columns, err := queryColumns(tx)
+	if err != nil {
+		return 0, err
+	}
+	if len(columns) > 0 {
+		first := columns[0].Status
+		orphans, err := queryStrings(tx, "SELECT id FROM tasks WHERE status NOT IN (SELECT status FROM board_columns) ORDER BY position, id")
+		if err != nil {
+			return 0, fmt.Errorf("failed to find orphaned tasks: %w", err)
+		}
+		for _, id := range orphans {
+			if err := run("UPDATE tasks SET status = ?, position = "+nextPositionSQL+" WHERE id = ?", first, first, id); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := run("DELETE FROM labels WHERE id NOT IN (SELECT label_id FROM task_labels)"); err != nil {
+		return 0, err
+	}
+
+	statuses, err := queryStrings(tx, "SELECT DISTINCT status FROM tasks GROUP BY status, position HAVING COUNT(*) > 1")
+	if err != nil {
+		return 0, fmt.Errorf("failed to check task positions: %w", err)
+	}
+	for _, status := range statuses {
+		ids, err := queryStrings(tx, "SELECT id FROM tasks WHERE status = ? ORDER BY position, id", status)
+		if err != nil {
+			return 0, fmt.Errorf("failed to query tasks: %w", err)
+		}
+		for pos, id := range ids {
+			if err := run("UPDATE tasks SET position = ? WHERE id = ? AND position != ?", pos, id, pos); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit repair: %w", err)
+	}
+	return changed, nil
+}
+
+// queryStrings returns the first column of each row of a query
+func queryStrings(ex execer, query string, args ...interface{}) ([]string, error) {
+	rows, err := ex.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// inspect runs Check on the database at path as doctor does and returns the
+// report, checking the file is left as it was and nothing added next to it
+func inspect(t *testing.T, path string) Report {
+	t.Helper()
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	database, err := Inspect(path)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	report, err := database.Check()
+	if closeErr := database.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if after, err := os.ReadFile(path); err != nil || !bytes.Equal(after, before) {
+		t.Errorf("Check changed the database (%v)", err)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if _, err := os.Stat(path + suffix); err == nil {
+			t.Errorf("Check left a %s file", suffix)
+		}
+	}
+	return report
+}
+
+func TestCheckLeavesDatabase(t *testing.T) {
+	t.Run("old schema", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "old.db")
+		conn := newDBAtSchema(t, path, 20)
+		seedOldSchema(t, conn)
+		conn.Close()
+
+		report := inspect(t, path)
+		if report.SchemaVersion != 20 {
+			t.Errorf("schema = %d, want the 20 on disk", report.SchemaVersion)
+		}
+		if len(report.Problems) != 1 || report.Problems[0].Check != "schema" || !report.Problems[0].Fixable {
+			t.Fatalf("problems = %+v, want the schema to migrate", report.Problems)
+		}
+
+		// Opening it for writing migrates it
+		database, err := New(path)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		defer database.Close()
+		if report, err := database.Check(); err != nil || report.SchemaVersion != SchemaVersion() || len(report.Problems) != 0 {
+			t.Fatalf("Check after New = %+v, %v; want a current and sound database", report, err)
+		}
+	})
+
+	t.Run("expired trash", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "work.db")
+		database, err := New(path)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		tasks := createTasks(t, database, "trashed")
+		if err := database.DeleteTask(tasks[0].ID); err != nil {
+			t.Fatalf("DeleteTask: %v", err)
+		}
+		if _, err := database.exec("UPDATE tasks SET deleted_at = '2000-01-01 00:00:00'"); err != nil {
+			t.Fatal(err)
+		}
+		if err := database.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if report := inspect(t, path); report.SchemaVersion != SchemaVersion() || len(report.Problems) != 0 {
+			t.Fatalf("report = %+v, want a current and sound database", report)
+		}
+	})
+}
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/happytaoer/cli_kanban/internal/crypt"
+)
+
+// PassphraseFunc returns the passphrase of the encrypted database at path.
+// After a wrong passphrase it is called again with the number of failed
+// attempts; it gives up by returning an error.
+type PassphraseFunc func(path string, failed int) (string, error)
+
+// passphrase asks for the passphrases of encrypted databases
+var passphrase PassphraseFunc
+
+// SetPassphraseFunc makes the package ask fn for the passphrase of an
+// encrypted database when it is opened. Without one, encrypted databases
+// don't open.
+func SetPassphraseFunc(fn PassphraseFunc) {
+	passphrase = fn
+}
+
+// ErrLocked is returned when an encrypted database is opened for writing
+// while another process has it open
+var ErrLocked = errors.New("encrypted workspace is open in another process")
+
+// vault is the decrypted copy an encrypted database is opened from. It
+// lives in a private temporary directory; closing the database encrypts it
+// back over the file when it changed and removes it.
+type vault struct {
+	path  string // the encrypted file
+	dir   string // the temporary directory holding the copy
+	plain string // the copy
+	key   *crypt.Key
+	sum   [sha256.Size]byte // of the copy as decrypted, to tell whether it changed
+	lock  string            // the lock file held while the copy is written back; "" when read-only
+}
+
+// lockPath returns the lock file of the encrypted database at path
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// lockedError explains the lock file held on an encrypted database
+func lockedError(lock string) error {
+	holder := ""
+	if data, err := os.ReadFile(lock); err == nil {
+		if pid, dir, ok := strings.Cut(strings.TrimSpace(string(data)), " "); ok {
+			holder = fmt.Sprintf(" (process %s, which decrypted it to %s)", pid, dir)
+		}
+	}
+	return fmt.Errorf("%w%s; if that process is no longer running, remove %s to open the last saved version", ErrLocked, holder, lock)
+}
+
+// unlock decrypts the database at path to a temporary copy when it is
+// encrypted, asking for its passphrase, and returns nil when it isn't.
+// Writing takes the lock of the database until the vault is closed. A
+// wrong passphrase leaves the file and everything around it untouched.
+func unlock(path string, write bool) (*vault, error) {
+	encrypted, err := crypt.IsEncrypted(path)
+	if err != nil || !encrypted {
+		return nil, err
+	}
+	lock := lockPath(path)
+	if _, err := os.Stat(lock); err == nil && write {
+		return nil, lockedError(lock)
+	}
+	if passphrase == nil {
+		return nil, fmt.Errorf("%s is encrypted and there is no way to ask its passphrase", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read db %q: %w", path, err)
+	}
+
+	v := &vault{path: path}
+	var plain []byte
+	for failed := 0; ; failed++ {
+		pass, err := passphrase(path, failed)
+		if err != nil {
+			return nil, err
+		}
+		plain, v.key, err = crypt.Open(data, pass)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, crypt.ErrWrongPassphrase) {
+			return nil, fmt.Errorf("failed to decrypt db %q: %w", path, err)
+		}
+	}
+	v.sum = sha256.Sum256(plain)
+
+	if write {
+		f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			return nil, lockedError(lock)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock db %q: %w", path, err)
+		}
+		v.lock = lock
+		defer f.Close()
+		if v.dir, err = os.MkdirTemp("", "cli_kanban-"); err == nil {
+			_, err = fmt.Fprintf(f, "%d %s\n", os.Getpid(), v.dir)
+		}
+		if err != nil {
+			v.discard()
+			return nil, fmt.Errorf("failed to lock db %q: %w", path, err)
+		}
+	} else if v.dir, err = os.MkdirTemp("", "cli_kanban-"); err != nil {
+		return nil, fmt.Errorf("failed to decrypt db %q: %w", path, err)
+	}
+
+	v.plain = filepath.Join(v.dir, filepath.Base(path))
+	if err := os.WriteFile(v.plain, plain, 0o600); err != nil {
+		v.discard()
+		return nil, fmt.Errorf("failed to decrypt db %q: %w", path, err)
+	}
+	return v, nil
+}
+
+// close encrypts the copy back over the database when it changed, then
+// removes it. When that fails the copy and the lock are kept, so nothing
+// is lost and the database isn't opened from an outdated file meanwhile.
+func (v *vault) close() error {
+	if v == nil || v.dir == "" {
+		return nil
+	}
+	if v.lock != "" {
+		plain, err := os.ReadFile(v.plain)
+		if err == nil && sha256.Sum256(plain) != v.sum {
+			var sealed []byte
+			if sealed, err = v.key.Seal(plain); err == nil {
+				err = crypt.WriteFile(v.path, sealed)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save encrypted db %q, the changes are kept in %s: %w", v.path, v.plain, err)
+		}
+	}
+	v.discard()
+	return nil
+}
+
+// discard removes the copy and releases the lock without saving anything
+func (v *vault) discard() {
+	if v == nil {
+		return
+	}
+	if v.dir != "" {
+		_ = os.RemoveAll(v.dir)
+		v.dir = ""
+	}
+	if v.lock != "" {
+		_ = os.Remove(v.lock)
+		v.lock = ""
+	}
+}
+
+// Encrypt encrypts the plain database at path in place with passphrase.
+// The database must not be open in another process.
+func Encrypt(path, passphrase string) error {
+	if encrypted, err := crypt.IsEncrypted(path); err != nil || encrypted {
+		if err == nil {
+			err = fmt.Errorf("%s is already encrypted", path)
+		}
+		return err
+	}
+
+	if err := Checkpoint(path); err != nil {
+		return err
+	}
+
+	plain, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read db %q: %w", path, err)
+	}
+	key, err := crypt.NewKey(passphrase)
+	if err != nil {
+		return err
+	}
+	sealed, err := key.Seal(plain)
+	if err != nil {
+		return err
+	}
+	return crypt.WriteFile(path, sealed)
+}
+
+// Decrypt turns the encrypted database at path back into a plain one,
+// asking for its passphrase
+func Decrypt(path string) error {
+	v, err := unlock(path, true)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return fmt.Errorf("%s is not encrypted", path)
+	}
+	defer v.discard()
+
+	plain, err := os.ReadFile(v.plain)
+	if err == nil && !bytes.HasPrefix(plain, []byte("SQLite format 3")) {
+		err = errors.New("the decrypted file is not a database")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decrypt db %q: %w", path, err)
+	}
+	return crypt.WriteFile(path, plain)
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// parentValue returns the parent_task_id stored for an epic ID, NULL for none
+func parentValue(parent int64) interface{} {
+	if parent == 0 {
+		return nil
+	}
+	return parent
+}
+
+// SetTaskParent puts a task under an epic; a parent of 0 takes it out of
+// its epic. It refuses a task under itself and an epic that is already,
+// directly or through other epics, under the task.
+func (db *DB) SetTaskParent(id, parent int64) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to set epic: %w", err)
+	}
+	defer tx.Rollback()
+
+	if parent != 0 {
+		if parent == id {
+			return fmt.Errorf("task #%d can't be its own epic", id)
+		}
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM tasks WHERE id = ?", parent).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to look up task: %w", err)
+		}
+		if exists == 0 {
+			return fmt.Errorf("task #%d not found", parent)
+		}
+		var under int
+		if err := tx.QueryRow(`
+			WITH RECURSIVE epics(id) AS (
+				SELECT parent_task_id FROM tasks WHERE id = ?
+				UNION
+				SELECT t.parent_task_id FROM tasks AS t JOIN epics AS e ON t.id = e.id
+			)
+			SELECT COUNT(*) FROM epics WHERE id = ?`, parent, id).Scan(&under); err != nil {
+			return fmt.Errorf("failed to check epics: %w", err)
+		}
+		if under > 0 {
+			return fmt.Errorf("#%d is already under #%d", parent, id)
+		}
+	}
+
+	if err := updateActiveTask(tx, id, "set epic", "parent_task_id = ?, updated_at = ?", parentValue(parent), time.Now()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to set epic: %w", err)
+	}
+	return nil
+}
+
+// DeleteEpic moves an epic to the trash together with its children on the
+// board, or with children false, takes them out of it first so they stay
+// where they are
+func (db *DB) DeleteEpic(id int64, children bool) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete epic: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if children {
+		if _, err := tx.Exec("UPDATE tasks SET deleted_at = ? WHERE parent_task_id = ? AND "+activeTaskSQL, now, id); err != nil {
+			return fmt.Errorf("failed to delete epic: %w", err)
+		}
+	} else if _, err := tx.Exec("UPDATE tasks SET parent_task_id = NULL, updated_at = ? WHERE parent_task_id = ? AND "+activeTaskSQL, now, id); err != nil {
+		return fmt.Errorf("failed to delete epic: %w", err)
+	}
+	if err := updateActiveTask(tx, id, "delete epic", "deleted_at = ?", now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete epic: %w", err)
+	}
+	return nil
+}
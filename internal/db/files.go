@@ -0,0 +1,116 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/happytaoer/cli_kanban/internal/crypt"
+	"github.com/happytaoer/cli_kanban/internal/workspace"
+)
+
+// CopyFile writes a consistent copy of the database at src to dst, which
+// must not exist yet, without migrating it, and flushes it to disk. Changes still in the
+// write-ahead log of a process that has it open are in the copy too. An
+// encrypted database is copied as it is: its file is only ever replaced
+// whole, so it is consistent on its own.
+func CopyFile(src, dst string) error {
+	encrypted, err := crypt.IsEncrypted(src)
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		return workspace.CopyFile(src, dst, 0o600)
+	}
+	// Create dst with its mode first: VACUUM INTO creates it with the umask
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create db %q: %w", dst, err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("failed to create db %q: %w", dst, err)
+	}
+	if err := vacuumInto(src, dst); err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("failed to copy db %q to %q: %w", src, dst, err)
+	}
+	return nil
+}
+
+// vacuumInto writes a copy of the database at src into the empty file dst
+// and flushes it to disk, which VACUUM INTO doesn't do
+func vacuumInto(src, dst string) error {
+	conn, err := openFile(src)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec("VACUUM INTO ?", dst)
+	if closeErr := conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(dst, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return workspace.SyncDir(filepath.Dir(dst))
+}
+
+// Checkpoint folds the write-ahead log of the database at path back into
+// its file, so the file can be moved or replaced on its own. It fails while
+// another process has the database open, as its log would be left behind.
+func Checkpoint(path string) error {
+	encrypted, err := crypt.IsEncrypted(path)
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		// The log is kept with the decrypted copy; the lock tells it is open
+		if _, err := os.Stat(lockPath(path)); err == nil {
+			return lockedError(lockPath(path))
+		}
+		return nil
+	}
+
+	conn, err := openFile(path)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	if closeErr := conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	// The log stays around while another connection has the database open
+	if _, err := os.Stat(path + "-wal"); err == nil {
+		return fmt.Errorf("%s is open in another process: close it first", path)
+	}
+	return nil
+}
+
+// openFile opens the existing plain database at path as it is, waiting
+// for the locks of other processes like New does
+func openFile(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	conn, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=%d", path, busyTimeout.Milliseconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+func TestCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.db")
+	database, err := New(src)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer database.Close()
+	// Still open, so the task sits in the write-ahead log
+	createTasks(t, database, "in the log")
+
+	dst := filepath.Join(dir, "dst.db")
+	if err := CopyFile(src, dst); err != nil {
+		t.Fatalf("CopyFile: %v", err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("copy has mode %o, want 600", mode)
+	}
+	copied, err := New(dst)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer copied.Close()
+	assertTitles(t, boardTitles(t, copied), model.StatusTodo, "in the log")
+
+	// An existing file is left alone
+	if err := os.WriteFile(filepath.Join(dir, "taken.db"), []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyFile(src, filepath.Join(dir, "taken.db")); err == nil {
+		t.Fatal("CopyFile over an existing file succeeded")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "taken.db")); string(data) != "keep" {
+		t.Errorf("CopyFile changed the existing file to %q", data)
+	}
+}
+
+func TestDeleteAllTasksLeavesNothing(t *testing.T) {
+	const secret = "zanzibarquux" // one word, as the search index keeps it
+	path := filepath.Join(t.TempDir(), "tmpl.db")
+	database, err := New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	tasks := createTasks(t, database, secret, secret+" again")
+	board, err := database.BoardID()
+	if err != nil {
+		t.Fatalf("BoardID: %v", err)
+	}
+	// Fill the search index where there is one
+	if _, err := database.Search(secret, SearchOptions{}); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if err := database.DeleteAllTasks(); err != nil {
+		t.Fatalf("DeleteAllTasks: %v", err)
+	}
+	if err := database.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(secret)) {
+		t.Errorf("the file still holds %q", secret)
+	}
+
+	database, err = New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer database.Close()
+	created := createTasks(t, database, "first")
+	if created[0].ID != 1 || created[0].Subtasks[0].ID != 1 {
+		t.Errorf("new task is #%d with item %d after #%d, want IDs to start over", created[0].ID, created[0].Subtasks[0].ID, tasks[1].ID)
+	}
+	if again, err := database.BoardID(); err != nil || again == board {
+		t.Errorf("BoardID = %q, %v; want a new ID once task IDs start over", again, err)
+	}
+}
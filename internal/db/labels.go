@@ -0,0 +1,214 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// createLabelTables creates the labels tables and moves tags from the legacy
+// comma-separated tasks.tags column into them
+func createLabelTables(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS labels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE
+	);
+
+	CREATE TABLE IF NOT EXISTS task_labels (
+		task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		label_id INTEGER NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+		PRIMARY KEY (task_id, label_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_task_labels_label ON task_labels(label_id);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create label tables: %w", err)
+	}
+
+	return migrateTagsColumn(ex)
+}
+
+// migrateTagsColumn copies tags stored in tasks.tags into task_labels and clears the column
+func migrateTagsColumn(ex execer) error {
+	rows, err := ex.Query("SELECT id, tags FROM tasks WHERE tags IS NOT NULL AND tags != ''")
+	if err != nil {
+		return fmt.Errorf("failed to read legacy tags: %w", err)
+	}
+	legacy := make(map[int64][]string)
+	for rows.Next() {
+		var id int64
+		var tagsStr string
+		if err := rows.Scan(&id, &tagsStr); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan legacy tags: %w", err)
+		}
+		legacy[id] = parseTags(tagsStr)
+	}
+	rows.Close()
+
+	for id, tags := range legacy {
+		if err := setTaskLabels(ex, id, tags); err != nil {
+			return fmt.Errorf("failed to migrate tags of task %d: %w", id, err)
+		}
+	}
+	if _, err := ex.Exec("UPDATE tasks SET tags = '' WHERE tags != ''"); err != nil {
+		return fmt.Errorf("failed to clear legacy tags: %w", err)
+	}
+	return nil
+}
+
+// loadLabels fills in the Tags of each task
+func loadLabels(ex execer, tasks []model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(tasks))
+	for i := range tasks {
+		index[tasks[i].ID] = i
+		tasks[i].Tags = []string{}
+	}
+
+	where, args := onlyTasks("tl.task_id", tasks)
+	rows, err := ex.Query(`
+		SELECT tl.task_id, l.name
+		FROM task_labels tl
+		JOIN labels l ON l.id = tl.label_id`+where+`
+		ORDER BY tl.rowid
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query task labels: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID int64
+		var name string
+		if err := rows.Scan(&taskID, &name); err != nil {
+			return fmt.Errorf("failed to scan task label: %w", err)
+		}
+		if i, ok := index[taskID]; ok {
+			tasks[i].Tags = append(tasks[i].Tags, name)
+		}
+	}
+	return rows.Err()
+}
+
+// setTaskLabels replaces the labels of a task, creating labels that don't exist yet
+func setTaskLabels(ex execer, taskID int64, tags []string) error {
+	if _, err := ex.Exec("DELETE FROM task_labels WHERE task_id = ?", taskID); err != nil {
+		return fmt.Errorf("failed to clear task labels: %w", err)
+	}
+	return addTaskLabels(ex, taskID, tags)
+}
+
+// addTaskLabels adds labels to a task, keeping those it has
+func addTaskLabels(ex execer, taskID int64, tags []string) error {
+	for _, name := range cleanTags(tags) {
+		labelID, err := ensureLabel(ex, name)
+		if err != nil {
+			return err
+		}
+		if _, err := ex.Exec("INSERT OR IGNORE INTO task_labels (task_id, label_id) VALUES (?, ?)", taskID, labelID); err != nil {
+			return fmt.Errorf("failed to add label %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ensureLabel returns the ID of the named label, creating it if needed
+func ensureLabel(ex execer, name string) (int64, error) {
+	if _, err := ex.Exec("INSERT OR IGNORE INTO labels (name) VALUES (?)", name); err != nil {
+		return 0, fmt.Errorf("failed to create label %q: %w", name, err)
+	}
+	var id int64
+	if err := ex.QueryRow("SELECT id FROM labels WHERE name = ?", name).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to look up label %q: %w", name, err)
+	}
+	return id, nil
+}
+
+// UpdateTaskTags replaces the tags of a task
+func (db *DB) UpdateTaskTags(id int64, tags []string) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to update task tags: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE tasks SET updated_at = ? WHERE id = ?", time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update task tags: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("task not found")
+	}
+
+	if err := setTaskLabels(tx, id, tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update task tags: %w", err)
+	}
+	return nil
+}
+
+// GetLabels returns the names of all labels, sorted by name
+func (db *DB) GetLabels() ([]string, error) {
+	rows, err := db.conn.Query("SELECT name FROM labels ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan label: %w", err)
+		}
+		labels = append(labels, name)
+	}
+	return labels, rows.Err()
+}
+
+// parseTags converts comma-separated string to slice
+func parseTags(tagsStr string) []string {
+	if tagsStr == "" {
+		return []string{}
+	}
+	return cleanTags(strings.Split(tagsStr, ","))
+}
+
+// cleanTags lowercases, trims and de-duplicates tags, dropping empty ones
+func cleanTags(tags []string) []string {
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.TrimSpace(strings.ToLower(t))
+		if t != "" && !seen[t] {
+			cleaned = append(cleaned, t)
+			seen[t] = true
+		}
+	}
+	return cleaned
+}
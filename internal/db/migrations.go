@@ -0,0 +1,329 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSchemaTooNew is returned when a database was written by a newer
+// cli_kanban than this one
+var ErrSchemaTooNew = errors.New("database schema is newer than this cli_kanban")
+
+// migration is one step of the schema history. Pending steps run in order on
+// New, each in its own transaction, and are recorded in schema_migrations.
+//
+// Databases from before schema_migrations existed have no record of the steps
+// they already went through, so every step must also be safe to run on a
+// database that has its change already: create with IF NOT EXISTS and add
+// columns with addColumn.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// migrations is the schema history, oldest first. Append new steps at the end
+// with the next version; never change or remove a released one.
+var migrations = []migration{
+	{1, "create tasks", createTasksTable},
+	{2, "add tasks.description", func(tx *sql.Tx) error {
+		_, err := addColumn(tx, "tasks", "description", "TEXT DEFAULT ''")
+		return err
+	}},
+	{3, "add tasks.tags", func(tx *sql.Tx) error {
+		_, err := addColumn(tx, "tasks", "tags", "TEXT DEFAULT ''")
+		return err
+	}},
+	{4, "add tasks.due", func(tx *sql.Tx) error {
+		_, err := addColumn(tx, "tasks", "due", "DATETIME DEFAULT NULL")
+		return err
+	}},
+	{5, "add tasks.priority", func(tx *sql.Tx) error {
+		_, err := addColumn(tx, "tasks", "priority", "TEXT DEFAULT ''")
+		return err
+	}},
+	{6, "backfill task timestamps", backfillTimestamps},
+	{7, "create board_columns", func(tx *sql.Tx) error { return createColumnTables(tx) }},
+	{8, "add board_columns.wip_limit", func(tx *sql.Tx) error {
+		_, err := addColumn(tx, "board_columns", "wip_limit", "INTEGER NOT NULL DEFAULT 0")
+		return err
+	}},
+	{9, "create settings", func(tx *sql.Tx) error { return createSettingsTable(tx) }},
+	{10, "add tasks.position", addPositionColumn},
+	{11, "add tasks.completed_at", addCompletedAtColumn},
+	{12, "create labels", func(tx *sql.Tx) error { return createLabelTables(tx) }},
+	{13, "create subtasks", func(tx *sql.Tx) error { return createSubtaskTables(tx) }},
+	{14, "create sessions", func(tx *sql.Tx) error { return createSessionTable(tx) }},
+	{15, "create revision", func(tx *sql.Tx) error { return createRevisionTable(tx) }},
+	{16, "add tasks.deleted_at", func(tx *sql.Tx) error {
+		_, err := addColumn(tx, "tasks", "deleted_at", "DATETIME DEFAULT NULL")
+		return err
+	}},
+	{17, "add tasks.archived_at", addArchivedAtColumn},
+	{18, "create activity", func(tx *sql.Tx) error { return createActivityTable(tx) }},
+	{19, "add tasks.recurrence", func(tx *sql.Tx) error {
+		_, err := addColumn(tx, "tasks", "recurrence", "TEXT NOT NULL DEFAULT ''")
+		return err
+	}},
+	{20, "create time_entries", func(tx *sql.Tx) error { return createTimeEntryTable(tx) }},
+	{21, "create due_reminders", func(tx *sql.Tx) error { return createReminderTable(tx) }},
+	{22, "create task_templates", func(tx *sql.Tx) error { return createTemplateTable(tx) }},
+	{23, "add tasks.source", addSourceColumns},
+	{24, "create task_dependencies", func(tx *sql.Tx) error { return createDependencyTable(tx) }},
+	{25, "create saved_views", func(tx *sql.Tx) error { return createViewTable(tx) }},
+	{26, "add tasks.parent_task_id", addParentColumn},
+	{27, "create board_state", func(tx *sql.Tx) error { return createBoardStateTable(tx) }},
+	{28, "add tasks.version", addVersionColumn},
+	{29, "add board_columns.is_done", addDoneFlag},
+	{30, "add tasks.snoozed_until", addSnoozeColumns},
+	{31, "add tasks.pinned", addPinnedColumns},
+	{32, "create webhook_cursors", createWebhookCursorTable},
+}
+
+// SchemaVersion is the schema version this binary writes
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate brings the database at path up to SchemaVersion. It refuses
+// databases with a newer schema, which this binary could damage.
+func (db *DB) migrate(path string) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	);
+	`
+	if _, err := db.exec(schema); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	current, err := schemaVersion(db.conn)
+	if err != nil {
+		return err
+	}
+	if latest := SchemaVersion(); current > latest {
+		return fmt.Errorf("%w: %s has schema version %d, this binary supports up to %d; please upgrade cli_kanban", ErrSchemaTooNew, path, current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version > current {
+			if err := db.applyMigration(m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyMigration runs one step and records it, unless another process
+// applied it in the meantime
+func (db *DB) applyMigration(m migration) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+
+	// The transaction holds the write lock, so this check can't race
+	current, err := schemaVersion(tx)
+	if err != nil {
+		return err
+	}
+	if current >= m.version {
+		return nil
+	}
+
+	if err := m.up(tx); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", m.version, m.name, time.Now()); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.version, err)
+	}
+	return nil
+}
+
+// schemaVersion returns the newest version recorded in schema_migrations, 0 for none
+func schemaVersion(ex execer) (int, error) {
+	var version int
+	if err := ex.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// addColumn adds a column to a table unless it exists already, and reports
+// whether it was added so the caller can backfill it
+func addColumn(ex execer, table, column, definition string) (bool, error) {
+	var count int
+	if err := ex.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+	if _, err := ex.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return false, fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return true, nil
+}
+
+// createTasksTable creates the tasks table as it was first released
+func createTasksTable(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		description TEXT DEFAULT '',
+		status TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+	return nil
+}
+
+// backfillTimestamps fills in timestamps on rows written before they were maintained
+func backfillTimestamps(tx *sql.Tx) error {
+	if _, err := tx.Exec("UPDATE tasks SET created_at = ? WHERE created_at IS NULL", time.Now()); err != nil {
+		return fmt.Errorf("failed to backfill created_at: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE tasks SET updated_at = created_at WHERE updated_at IS NULL"); err != nil {
+		return fmt.Errorf("failed to backfill updated_at: %w", err)
+	}
+	return nil
+}
+
+// addPositionColumn adds the manual task order
+func addPositionColumn(tx *sql.Tx) error {
+	added, err := addColumn(tx, "tasks", "position", "INTEGER NOT NULL DEFAULT 0")
+	if err != nil || !added {
+		return err
+	}
+	// Number each column's tasks newest first, the order they were shown in
+	if _, err := tx.Exec(`
+		UPDATE tasks SET position = (
+			SELECT COUNT(*) FROM tasks AS t
+			WHERE t.status = tasks.status
+			AND (t.created_at > tasks.created_at OR (t.created_at = tasks.created_at AND t.id > tasks.id))
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to backfill task positions: %w", err)
+	}
+	return nil
+}
+
+// addCompletedAtColumn adds the completion time of done tasks
+func addCompletedAtColumn(tx *sql.Tx) error {
+	added, err := addColumn(tx, "tasks", "completed_at", "DATETIME DEFAULT NULL")
+	if err != nil || !added {
+		return err
+	}
+	// Treat tasks already in the done column as completed when last updated
+	done, err := rightmostStatus(tx)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE tasks SET completed_at = updated_at WHERE status = ?", done); err != nil {
+		return fmt.Errorf("failed to backfill completed_at: %w", err)
+	}
+	return nil
+}
+
+// addArchivedAtColumn adds the archive time and the index of board queries
+func addArchivedAtColumn(tx *sql.Tx) error {
+	if _, err := addColumn(tx, "tasks", "archived_at", "DATETIME DEFAULT NULL"); err != nil {
+		return err
+	}
+	// Board queries only read active tasks, so archived ones don't slow them down
+	if _, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_board ON tasks(status, position, id) WHERE " + activeTaskSQL); err != nil {
+		return fmt.Errorf("failed to create board index: %w", err)
+	}
+	return nil
+}
+
+// addSourceColumns adds where imported tasks come from, and the index
+// matching them up when the same source is imported again
+func addSourceColumns(tx *sql.Tx) error {
+	for _, column := range []string{"source", "source_id", "url"} {
+		if _, err := addColumn(tx, "tasks", column, "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_source ON tasks(source, source_id) WHERE source != ''"); err != nil {
+		return fmt.Errorf("failed to create source index: %w", err)
+	}
+	return nil
+}
+
+// addParentColumn adds the epic a task belongs to. A purged epic leaves its
+// children on the board without one.
+func addParentColumn(tx *sql.Tx) error {
+	if _, err := addColumn(tx, "tasks", "parent_task_id", "INTEGER DEFAULT NULL REFERENCES tasks(id) ON DELETE SET NULL"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_parent ON tasks(parent_task_id) WHERE parent_task_id IS NOT NULL"); err != nil {
+		return fmt.Errorf("failed to create parent index: %w", err)
+	}
+	return nil
+}
+
+// addVersionColumn adds the version of each task and the trigger counting
+// it up on every change but a reorder, so an edit made on an older version
+// is noticed instead of overwriting the change
+func addVersionColumn(tx *sql.Tx) error {
+	if _, err := addColumn(tx, "tasks", "version", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		CREATE TRIGGER IF NOT EXISTS tasks_version AFTER UPDATE OF title, description, due, recurrence, priority, status,
+			source, source_id, url, parent_task_id, created_at, updated_at, completed_at, deleted_at, archived_at ON tasks
+		BEGIN
+			UPDATE tasks SET version = OLD.version + 1 WHERE id = NEW.id;
+		END
+	`); err != nil {
+		return fmt.Errorf("failed to create version trigger: %w", err)
+	}
+	return nil
+}
+
+// addSnoozeColumns adds the day a snoozed task comes back to the board and
+// when it did
+func addSnoozeColumns(tx *sql.Tx) error {
+	if _, err := addColumn(tx, "tasks", "snoozed_until", "TEXT DEFAULT NULL"); err != nil {
+		return err
+	}
+	_, err := addColumn(tx, "tasks", "woke_at", "DATETIME DEFAULT NULL")
+	return err
+}
+
+// addPinnedColumns adds the flag of pinned tasks and the filter of saved
+// views showing only them
+func addPinnedColumns(tx *sql.Tx) error {
+	if _, err := addColumn(tx, "tasks", "pinned", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	_, err := addColumn(tx, "saved_views", "pinned_only", "INTEGER NOT NULL DEFAULT 0")
+	return err
+}
+
+// addDoneFlag adds the flag marking the columns where tasks count as
+// completed, and sets it on the rightmost column, which was the only one
+// until then
+func addDoneFlag(tx *sql.Tx) error {
+	if _, err := addColumn(tx, "board_columns", "is_done", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	return ensureDoneColumn(tx)
+}
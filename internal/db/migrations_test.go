@@ -0,0 +1,290 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// newDBAtSchema writes a database at path that went through the first
+// version steps of the schema history only, as an older cli_kanban left it
+func newDBAtSchema(t *testing.T, path string, version int) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", path+"?_txlock=immediate&_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	old := &DB{conn: conn}
+	if _, err := conn.Exec("CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at DATETIME NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations[:version] {
+		if err := old.applyMigration(m); err != nil {
+			t.Fatalf("building schema %d: %v", version, err)
+		}
+	}
+	return conn
+}
+
+// hasColumn reports whether a table of the database has a column; a table
+// that doesn't exist has none
+func hasColumn(t *testing.T, conn *sql.DB, table, column string) bool {
+	t.Helper()
+	var count int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count > 0
+}
+
+// exec runs a statement of a fixture
+func exec(t *testing.T, conn *sql.DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := conn.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+// seedOldSchema writes the tasks of the fixture with what the schema of the
+// database holds: a todo task with a description, tags, a due date, a
+// priority and a checklist where the schema has them, a done task, and a
+// task in a column only the tasks named before board_columns existed
+func seedOldSchema(t *testing.T, conn *sql.DB) {
+	t.Helper()
+	exec(t, conn, "INSERT INTO tasks (title, description, status) VALUES ('first', 'notes', 'todo'), ('second', '', 'done'), ('third', '', 'review')")
+	if hasColumn(t, conn, "board_columns", "status") {
+		exec(t, conn, "INSERT INTO board_columns (status, name, position) VALUES ('review', 'review', 3)")
+	}
+	switch {
+	case hasColumn(t, conn, "task_labels", "task_id"):
+		exec(t, conn, "INSERT INTO labels (name) VALUES ('home'), ('work')")
+		exec(t, conn, "INSERT INTO task_labels (task_id, label_id) SELECT tasks.id, labels.id FROM tasks, labels WHERE tasks.title = 'first'")
+	case hasColumn(t, conn, "tasks", "tags"):
+		exec(t, conn, "UPDATE tasks SET tags = 'Home, work' WHERE title = 'first'")
+	}
+	if hasColumn(t, conn, "tasks", "due") {
+		exec(t, conn, "UPDATE tasks SET due = '2025-03-14 00:00:00' WHERE title = 'first'")
+	}
+	if hasColumn(t, conn, "tasks", "priority") {
+		exec(t, conn, "UPDATE tasks SET priority = 'high' WHERE title = 'first'")
+	}
+	if hasColumn(t, conn, "subtasks", "task_id") {
+		exec(t, conn, "INSERT INTO subtasks (task_id, title, done) SELECT id, 'step', 1 FROM tasks WHERE title = 'first'")
+	}
+}
+
+// TestMigrateFromEverySchema builds a database at each version of the
+// schema history, with and without the record of the steps it went through
+// as databases from before schema_migrations have none, and checks New
+// brings it to the current schema with its tasks intact
+func TestMigrateFromEverySchema(t *testing.T) {
+	for version := 1; version <= SchemaVersion(); version++ {
+		for _, tracked := range []bool{true, false} {
+			name := fmt.Sprintf("schema %d", version)
+			if !tracked {
+				name += " untracked"
+			}
+			t.Run(name, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "old.db")
+				conn := newDBAtSchema(t, path, version)
+				seedOldSchema(t, conn)
+				if !tracked {
+					exec(t, conn, "DROP TABLE schema_migrations")
+				}
+				if err := conn.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				database, err := New(path)
+				if err != nil {
+					t.Fatalf("New: %v", err)
+				}
+				defer database.Close()
+				checkMigrated(t, database, version)
+			})
+		}
+	}
+}
+
+// checkMigrated checks the fixture of seedOldSchema came through the
+// migration from version
+func checkMigrated(t *testing.T, database *DB, version int) {
+	t.Helper()
+	if current, err := schemaVersion(database.conn); err != nil || current != SchemaVersion() {
+		t.Fatalf("schema version = %d, %v; want %d", current, err, SchemaVersion())
+	}
+
+	columns, err := database.GetBoard()
+	if err != nil {
+		t.Fatalf("GetBoard: %v", err)
+	}
+	tasks := map[string]model.Task{}
+	for _, col := range columns {
+		for _, task := range col.Tasks {
+			if task.Status != col.Status {
+				t.Errorf("%q is in column %s with status %s", task.Title, col.Status, task.Status)
+			}
+			tasks[task.Title] = task
+		}
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("board holds %v, want the 3 tasks of the fixture", tasks)
+	}
+	if _, ok := model.FindColumn(columns, "review"); !ok {
+		t.Errorf("the review column is gone: %v", columns)
+	}
+	for title, status := range map[string]model.TaskStatus{"first": model.StatusTodo, "second": model.StatusDone, "third": "review"} {
+		if tasks[title].Status != status {
+			t.Errorf("%q is in %s, want %s", title, tasks[title].Status, status)
+		}
+	}
+
+	first := tasks["first"]
+	if first.Description != "notes" {
+		t.Errorf("description = %q, want notes", first.Description)
+	}
+	if first.CreatedAt.IsZero() || first.UpdatedAt.IsZero() {
+		t.Errorf("timestamps were not kept or backfilled: %v, %v", first.CreatedAt, first.UpdatedAt)
+	}
+	if hadStep(version, "add tasks.tags") && (len(first.Tags) != 2 || first.Tags[0] != "home" || first.Tags[1] != "work") {
+		t.Errorf("tags = %q, want home and work", first.Tags)
+	}
+	if hadStep(version, "add tasks.due") {
+		if want := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC); first.Due == nil || !first.Due.Equal(want) {
+			t.Errorf("due = %v, want %v", first.Due, want)
+		}
+	}
+	if hadStep(version, "add tasks.priority") && first.Priority != model.PriorityHigh {
+		t.Errorf("priority = %q, want high", first.Priority)
+	}
+	if hadStep(version, "create subtasks") && (len(first.Subtasks) != 1 || first.Subtasks[0].Title != "step" || !first.Subtasks[0].Done) {
+		t.Errorf("checklist = %+v, want the done step", first.Subtasks)
+	}
+	if first.Version < 1 || first.Pinned || first.SnoozedUntil != nil || first.DeletedAt != nil || first.ArchivedAt != nil {
+		t.Errorf("columns added since got other defaults than a new task's: %+v", first)
+	}
+}
+
+// hadStep reports whether a database at version went through the step
+// of the schema history named name
+func hadStep(version int, name string) bool {
+	for _, m := range migrations[:version] {
+		if m.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loadDump writes the database of the SQL dump testdata/name to a temporary
+// file and returns its path
+func loadDump(t *testing.T, name string) string {
+	t.Helper()
+	dump, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "old.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Exec(string(dump)); err != nil {
+		t.Fatalf("loading %s: %v", name, err)
+	}
+	return path
+}
+
+// TestMigrateReleasedDatabases migrates databases written by earlier
+// releases rather than built from today's steps. Each build made its
+// fixture through its own db package: the first task in todo with a
+// description, the tags Home and work, a due date and, where the build had
+// them, a high priority and a done checklist step; the second in done; the
+// third in a Review column it added, or in progress without custom columns;
+// and, where it had a trash and an archive, a task in each. The databases
+// were then dumped with sqlite3's .dump.
+func TestMigrateReleasedDatabases(t *testing.T) {
+	tests := []struct {
+		dump string
+		// full is set for builds with priorities, checklists, custom
+		// columns, the trash and the archive
+		full bool
+	}{
+		{"baseline.sql", false},   // the first release
+		{"unversioned.sql", true}, // the last one before schema_migrations
+		{"schema17.sql", true},    // the first one with it, at version 17
+	}
+	for _, tt := range tests {
+		t.Run(tt.dump, func(t *testing.T) {
+			database, err := New(loadDump(t, tt.dump))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer database.Close()
+			if current, err := schemaVersion(database.conn); err != nil || current != SchemaVersion() {
+				t.Fatalf("schema version = %d, %v; want %d", current, err, SchemaVersion())
+			}
+
+			columns, err := database.GetBoard()
+			if err != nil {
+				t.Fatalf("GetBoard: %v", err)
+			}
+			var names []string
+			tasks := map[string]model.Task{}
+			for _, col := range columns {
+				names = append(names, col.Name)
+				for _, task := range col.Tasks {
+					tasks[task.Title] = task
+				}
+			}
+			wantColumns, third := "Todo,In Progress,Done", model.StatusInProgress
+			if tt.full {
+				wantColumns, third = "Todo,In Progress,Review,Done", "review"
+			}
+			if got := strings.Join(names, ","); got != wantColumns {
+				t.Errorf("columns = %s, want %s", got, wantColumns)
+			}
+			if len(tasks) != 3 || tasks["first"].Status != model.StatusTodo || tasks["second"].Status != model.StatusDone || tasks["third"].Status != third {
+				t.Fatalf("board holds %+v, want first in todo, second in done and third in %s", tasks, third)
+			}
+			if done := columns[len(columns)-1]; !done.Done || done.Status != model.StatusDone {
+				t.Errorf("last column = %+v, want Done as the done column", done)
+			}
+
+			first := tasks["first"]
+			if first.Description != "notes" || strings.Join(first.Tags, ",") != "home,work" {
+				t.Errorf("first has description %q and tags %q, want notes, home and work", first.Description, first.Tags)
+			}
+			if want := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC); first.Due == nil || !first.Due.Equal(want) {
+				t.Errorf("due = %v, want %v", first.Due, want)
+			}
+			if first.CreatedAt.IsZero() || first.Version < 1 || first.Pinned || first.SnoozedUntil != nil {
+				t.Errorf("first came through as %+v", first)
+			}
+			if !tt.full {
+				return
+			}
+
+			if first.Priority != model.PriorityHigh || len(first.Subtasks) != 1 || first.Subtasks[0].Title != "step" || !first.Subtasks[0].Done {
+				t.Errorf("first has priority %q and checklist %+v, want high and the done step", first.Priority, first.Subtasks)
+			}
+			if tasks["second"].CompletedAt == nil {
+				t.Error("second lost its completion time")
+			}
+			if trash, err := database.GetTrash(); err != nil || len(trash) != 1 || trash[0].Title != "trashed" {
+				t.Errorf("trash = %+v, %v, want the trashed task", trash, err)
+			}
+			if archive, err := database.GetArchive(); err != nil || len(archive) != 1 || archive[0].Title != "archived" {
+				t.Errorf("archive = %+v, %v, want the archived task", archive, err)
+			}
+		})
+	}
+}
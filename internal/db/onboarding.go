@@ -0,0 +1,45 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// SeedSampleBoard fills a new workspace with example tasks and marks its
+// welcome overlay to be shown. It does nothing to a workspace that already
+// has tasks, in the trash and archive included.
+func (db *DB) SeedSampleBoard(drafts []model.Task) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to create sample board: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&count); err != nil {
+		return fmt.Errorf("failed to count tasks: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	done, err := doneStatuses(tx)
+	if err != nil {
+		return err
+	}
+	if _, err := insertTasks(tx, drafts, done, time.Now()); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO settings (key, value) VALUES (?, 'false') ON CONFLICT(key) DO UPDATE SET value = excluded.value",
+		SettingWelcomed,
+	); err != nil {
+		return fmt.Errorf("failed to save setting %q: %w", SettingWelcomed, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create sample board: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// normalizeRecurrence validates a recurrence rule and returns it in the
+// canonical form it is stored in, "" for none
+func normalizeRecurrence(rule string) (string, error) {
+	r, err := dates.ParseRecurrence(rule)
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
+
+// UpdateTaskRecurrence sets how a task repeats; an empty rule stops it repeating
+func (db *DB) UpdateTaskRecurrence(id int64, rule string) error {
+	recurrence, err := normalizeRecurrence(rule)
+	if err != nil {
+		return err
+	}
+	result, err := db.exec("UPDATE tasks SET recurrence = ?, updated_at = ? WHERE id = ?", recurrence, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update task recurrence: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("task not found")
+	}
+
+	return nil
+}
+
+// repeatTask creates the next occurrence of a recurring task that was just
+// completed: a copy at the top of the first column, due on the rule's next
+// date, with its checklist unticked. The rule moves to the new task, so
+// reopening and completing the old one again doesn't repeat it twice. Like a
+// new task, the occurrence isn't held back by a WIP limit.
+func repeatTask(ex execer, task *model.Task, now time.Time) (*model.Task, error) {
+	rule, err := dates.ParseRecurrence(task.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	// A plain monthly rule moves with the day it is counted from, which a
+	// shorter month cuts short: the day it started from is kept in the rule
+	from := now
+	if task.Due != nil {
+		from = dates.Day(*task.Due, now.Location())
+	}
+	rule = rule.Anchor(from)
+	columns, err := queryColumns(ex)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("failed to repeat task: the board has no columns")
+	}
+	status := columns[0].Status
+
+	next := *task
+	next.Status = status
+	next.Recurrence = rule.String()
+	next.Version = 1
+	next.CreatedAt, next.UpdatedAt = now, now
+	next.CompletedAt, next.DeletedAt, next.ArchivedAt = nil, nil, nil
+	next.SnoozedUntil, next.WokeAt = nil, nil
+	if due := rule.NextDue(task.Due, now); !due.IsZero() {
+		// Due dates are stored without a timezone, as calendar dates
+		due = time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+		next.Due = &due
+	}
+	next.Subtasks = make([]model.Subtask, len(task.Subtasks))
+	for i, st := range task.Subtasks {
+		next.Subtasks[i] = model.Subtask{Title: st.Title}
+	}
+
+	var dueValue interface{}
+	if next.Due != nil {
+		dueValue = next.Due.Format("2006-01-02 15:04:05")
+	}
+	result, err := ex.Exec(
+		"INSERT INTO tasks (title, description, due, recurrence, priority, pinned, status, position, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, (SELECT COALESCE(MIN(position), 1) - 1 FROM tasks WHERE status = ?), ?, ?)",
+		next.Title, next.Description, dueValue, next.Recurrence, next.Priority, next.Pinned, status, status, now, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to repeat task: %w", err)
+	}
+	if next.ID, err = result.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	if err := setTaskLabels(ex, next.ID, task.Tags); err != nil {
+		return nil, err
+	}
+	if err := insertSubtasks(ex, next.ID, next.Subtasks); err != nil {
+		return nil, err
+	}
+	if _, err := ex.Exec("UPDATE tasks SET recurrence = '' WHERE id = ?", task.ID); err != nil {
+		return nil, fmt.Errorf("failed to repeat task: %w", err)
+	}
+	return &next, nil
+}
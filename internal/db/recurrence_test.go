@@ -0,0 +1,35 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// A monthly task due on the 31st comes back on the 31st after a shorter
+// month moved one occurrence to its last day
+func TestRepeatTaskKeepsTheDayOfTheMonth(t *testing.T) {
+	database := newTestDB(t)
+	due := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+	task, err := database.CreateTaskFrom(model.Task{Title: "rent", Status: model.StatusTodo, Due: &due, Recurrence: "monthly"})
+	if err != nil {
+		t.Fatalf("CreateTaskFrom: %v", err)
+	}
+
+	for _, want := range []string{"2026-02-28", "2026-03-31", "2026-04-30"} {
+		task, err = repeatTask(database.conn, task, *task.Due)
+		if err != nil {
+			t.Fatalf("repeatTask: %v", err)
+		}
+		if got := task.Due.Format("2006-01-02"); got != want {
+			t.Errorf("next occurrence due %s, want %s", got, want)
+		}
+		if task.Version != 1 {
+			t.Errorf("next occurrence at version %d, want 1", task.Version)
+		}
+		if task.Recurrence != "monthly on 31" {
+			t.Errorf("next occurrence repeats %q, want %q", task.Recurrence, "monthly on 31")
+		}
+	}
+}
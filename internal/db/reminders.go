@@ -0,0 +1,82 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// createReminderTable creates the table recording which due dates were
+// announced. It isn't a revision table: announcing a task doesn't change the
+// board.
+func createReminderTable(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS due_reminders (
+		task_id INTEGER PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+		due TEXT NOT NULL
+	);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create due reminders table: %w", err)
+	}
+	return nil
+}
+
+// DueTasks returns the tasks on the board outside the done columns that are
+// due today or overdue, most overdue first. Snoozed tasks wait until they wake.
+func (db *DB) DueTasks(now time.Time) ([]model.Task, error) {
+	tasks, err := queryTasks(db.conn, "SELECT "+taskColumns+" FROM tasks WHERE "+activeTaskSQL+" AND due IS NOT NULL AND NOT "+doneTaskSQL+" ORDER BY due, position, id")
+	if err != nil {
+		return nil, err
+	}
+
+	today := dates.StartOfDay(now)
+	due := tasks[:0]
+	for _, task := range tasks {
+		if !dates.Day(*task.Due, now.Location()).After(today) && !task.Snoozed(now) {
+			due = append(due, task)
+		}
+	}
+	return due, nil
+}
+
+// TakeDueReminders returns the due tasks that weren't announced yet and
+// records them as announced, so each due date is announced once, whichever
+// process asks first. Changing a task's due date announces it again.
+func (db *DB) TakeDueReminders(now time.Time) ([]model.Task, error) {
+	tasks, err := db.DueTasks(now)
+	if err != nil || len(tasks) == 0 {
+		return nil, err
+	}
+
+	tx, err := db.begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to record reminders: %w", err)
+	}
+	defer tx.Rollback()
+
+	var fresh []model.Task
+	for _, task := range tasks {
+		result, err := tx.Exec(`
+			INSERT INTO due_reminders (task_id, due) VALUES (?, ?)
+			ON CONFLICT (task_id) DO UPDATE SET due = excluded.due WHERE due != excluded.due
+		`, task.ID, task.Due.Format(dates.DateFormat))
+		if err != nil {
+			return nil, fmt.Errorf("failed to record reminder: %w", err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if n > 0 {
+			fresh = append(fresh, task)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to record reminders: %w", err)
+	}
+	return fresh, nil
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// busyTimeout is how long SQLite waits for another connection's lock
+	busyTimeout = 5 * time.Second
+	// busyRetries is how often a write is retried once SQLite gives up waiting
+	busyRetries = 3
+	busyBackoff = 100 * time.Millisecond
+)
+
+// isBusy reports whether err means the database was locked by another connection
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+// retryBusy runs fn, retrying with a growing pause while the database is busy
+func retryBusy(fn func() error) error {
+	err := fn()
+	for attempt := 1; attempt <= busyRetries && isBusy(err); attempt++ {
+		time.Sleep(time.Duration(attempt) * busyBackoff)
+		err = fn()
+	}
+	return err
+}
+
+// exec runs a single write statement, retrying while the database is busy
+func (db *DB) exec(query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := retryBusy(func() error {
+		var err error
+		result, err = db.conn.Exec(query, args...)
+		return err
+	})
+	return result, err
+}
+
+// begin starts a write transaction, retrying while another connection holds
+// the write lock. Transactions take the lock when they start (_txlock=immediate),
+// so their statements don't run into it halfway through.
+func (db *DB) begin() (*sql.Tx, error) {
+	var tx *sql.Tx
+	err := retryBusy(func() error {
+		var err error
+		tx, err = db.conn.Begin()
+		return err
+	})
+	return tx, err
+}
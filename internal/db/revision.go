@@ -0,0 +1,57 @@
+package db
+
+import "fmt"
+
+// revisionTables are the tables whose changes show on the board. Triggers on
+// them bump the revision counter, so an open TUI notices writes made by other
+// processes.
+var revisionTables = []string{"tasks", "board_columns", "labels", "task_labels", "subtasks", "settings"}
+
+// createRevisionTable creates the revision counter and the triggers that bump it
+func createRevisionTable(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS revision (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		value INTEGER NOT NULL
+	);
+	INSERT OR IGNORE INTO revision (id, value) VALUES (1, 0);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create revision table: %w", err)
+	}
+
+	for _, table := range revisionTables {
+		if err := createRevisionTriggers(ex, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createRevisionTriggers creates the triggers bumping the revision counter
+// on every change to a table. Tables created after the revision counter
+// call it themselves.
+func createRevisionTriggers(ex execer, table string) error {
+	for _, op := range []string{"INSERT", "UPDATE", "DELETE"} {
+		trigger := fmt.Sprintf(`
+		CREATE TRIGGER IF NOT EXISTS revision_%[1]s_%[2]s AFTER %[2]s ON %[1]s
+		BEGIN
+			UPDATE revision SET value = value + 1 WHERE id = 1;
+		END;
+		`, table, op)
+		if _, err := ex.Exec(trigger); err != nil {
+			return fmt.Errorf("failed to create revision trigger on %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// Revision returns a counter that changes whenever the board data changes,
+// whichever process wrote it
+func (db *DB) Revision() (int64, error) {
+	var revision int64
+	if err := db.conn.QueryRow("SELECT value FROM revision WHERE id = 1").Scan(&revision); err != nil {
+		return 0, fmt.Errorf("failed to read revision: %w", err)
+	}
+	return revision, nil
+}
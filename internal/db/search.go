@@ -0,0 +1,142 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// SearchOptions narrows a Search beyond the query
+type SearchOptions struct {
+	Column  model.TaskStatus // only tasks in this column
+	Label   string           // only tasks with this tag
+	Overdue bool             // only tasks due before today
+	Now     time.Time        // reference time for Overdue, time.Now() when zero
+}
+
+// Search returns the tasks on the board whose title or description contains
+// every word of query, case-insensitively. With SQLite's FTS5 compiled in,
+// words match by prefix through a full-text index and the best matches come
+// first; otherwise a LIKE scan finds them, most recently updated first.
+func (db *DB) Search(query string, opts SearchOptions) ([]model.Task, error) {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("search query cannot be empty")
+	}
+
+	fts, err := db.ensureSearchIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var where []string
+	var args []interface{}
+	order := "updated_at DESC, id DESC"
+	if fts {
+		phrases := make([]string, len(words))
+		for i, w := range words {
+			phrases[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"*`
+		}
+		where = append(where, "id IN (SELECT rowid FROM tasks_fts WHERE tasks_fts MATCH ?)")
+		args = append(args, strings.Join(phrases, " "))
+		order = "(SELECT rank FROM tasks_fts WHERE tasks_fts MATCH ? AND rowid = tasks.id), " + order
+	} else {
+		for _, w := range words {
+			pattern := "%" + escapeLike(strings.ToLower(w)) + "%"
+			where = append(where, `(LOWER(title) LIKE ? ESCAPE '\' OR LOWER(description) LIKE ? ESCAPE '\')`)
+			args = append(args, pattern, pattern)
+		}
+	}
+	if opts.Column != "" {
+		where = append(where, "status = ?")
+		args = append(args, opts.Column)
+	}
+	if opts.Label != "" {
+		where = append(where, "id IN (SELECT tl.task_id FROM task_labels tl JOIN labels l ON l.id = tl.label_id WHERE l.name = ?)")
+		args = append(args, strings.ToLower(strings.TrimSpace(opts.Label)))
+	}
+	if fts {
+		args = append(args, args[0])
+	}
+
+	tasks, err := queryTasks(db.conn, "SELECT "+taskColumns+" FROM tasks WHERE "+activeTaskSQL+" AND "+strings.Join(where, " AND ")+" ORDER BY "+order, args...)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Overdue {
+		return tasks, nil
+	}
+
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	today := dates.StartOfDay(now)
+	overdue := tasks[:0]
+	for _, task := range tasks {
+		if task.Due != nil && dates.Day(*task.Due, now.Location()).Before(today) {
+			overdue = append(overdue, task)
+		}
+	}
+	return overdue, nil
+}
+
+// ensureSearchIndex brings the full-text index up to date and reports
+// whether it is available. The index depends on how SQLite was compiled, so
+// it is created on demand rather than by a migration, and it is rebuilt from
+// the tasks table when the revision moved instead of kept in sync by
+// triggers: a binary built without FTS5 must still be able to write tasks.
+func (db *DB) ensureSearchIndex() (bool, error) {
+	var fts bool
+	if err := db.conn.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts); err != nil {
+		return false, fmt.Errorf("failed to check for full-text search: %w", err)
+	}
+	if !fts {
+		return false, nil
+	}
+
+	schema := `
+	CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(title, description, content='tasks', content_rowid='id');
+	CREATE TABLE IF NOT EXISTS tasks_fts_revision (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		revision INTEGER NOT NULL
+	);
+	`
+	if _, err := db.exec(schema); err != nil {
+		return false, fmt.Errorf("failed to create search index: %w", err)
+	}
+
+	revision, err := db.Revision()
+	if err != nil {
+		return false, err
+	}
+	var indexed int64 = -1
+	_ = db.conn.QueryRow("SELECT revision FROM tasks_fts_revision WHERE id = 1").Scan(&indexed)
+	if indexed == revision {
+		return true, nil
+	}
+
+	tx, err := db.begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to rebuild search index: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("INSERT INTO tasks_fts(tasks_fts) VALUES ('rebuild')"); err != nil {
+		return false, fmt.Errorf("failed to rebuild search index: %w", err)
+	}
+	if _, err := tx.Exec("INSERT OR REPLACE INTO tasks_fts_revision (id, revision) VALUES (1, ?)", revision); err != nil {
+		return false, fmt.Errorf("failed to rebuild search index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to rebuild search index: %w", err)
+	}
+	return true, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s, using backslash as the escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
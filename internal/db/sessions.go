@@ -0,0 +1,88 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// HeartbeatInterval is how often an open session should call Heartbeat
+	HeartbeatInterval = 5 * time.Second
+	// sessionTimeout is how long a session without heartbeat counts as live,
+	// so sessions of crashed processes expire
+	sessionTimeout = 3 * HeartbeatInterval
+)
+
+// createSessionTable creates the table of TUI sessions that have the workspace open
+func createSessionTable(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pid INTEGER NOT NULL,
+		heartbeat_at INTEGER NOT NULL
+	);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create sessions table: %w", err)
+	}
+	return nil
+}
+
+// OpenSession registers an interactive session on the workspace and returns
+// its ID. Expired sessions are cleaned up on the way.
+func (db *DB) OpenSession() (int64, error) {
+	now := time.Now()
+	if _, err := db.exec("DELETE FROM sessions WHERE heartbeat_at < ?", now.Add(-sessionTimeout).Unix()); err != nil {
+		return 0, fmt.Errorf("failed to clean up sessions: %w", err)
+	}
+	result, err := db.exec("INSERT INTO sessions (pid, heartbeat_at) VALUES (?, ?)", os.Getpid(), now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to open session: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return id, nil
+}
+
+// Heartbeat keeps a session live. A session that expired in the meantime
+// (e.g. while the machine slept) is registered again under the same ID.
+func (db *DB) Heartbeat(id int64) error {
+	if _, err := db.exec(
+		"INSERT INTO sessions (id, pid, heartbeat_at) VALUES (?, ?, ?) ON CONFLICT(id) DO UPDATE SET heartbeat_at = excluded.heartbeat_at",
+		id, os.Getpid(), time.Now().Unix(),
+	); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// CloseSession removes a session
+func (db *DB) CloseSession(id int64) error {
+	if _, err := db.exec("DELETE FROM sessions WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to close session: %w", err)
+	}
+	return nil
+}
+
+// OlderSession returns the process ID of the oldest live session opened
+// before the given one, or 0 when there is none. The first session on a
+// workspace owns it; later ones should stay read-only while it is live.
+func (db *DB) OlderSession(id int64) (int, error) {
+	var pid int
+	err := db.conn.QueryRow(
+		"SELECT pid FROM sessions WHERE id < ? AND heartbeat_at >= ? ORDER BY id LIMIT 1",
+		id, time.Now().Add(-sessionTimeout).Unix(),
+	).Scan(&pid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up sessions: %w", err)
+	}
+	return pid, nil
+}
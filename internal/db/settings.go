@@ -0,0 +1,491 @@
+package db
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// Workspace setting keys
+const (
+	// SettingStrictWIP turns WIP limit warnings into a hard block
+	SettingStrictWIP = "strict_wip"
+	// SettingSortPrefix followed by a column's status keys how the tasks of
+	// the column are sorted; columns without one keep their manual order
+	SettingSortPrefix = "sort:"
+	// SettingCollapsedPrefix followed by a column's status marks a column
+	// collapsed to a narrow strip on the board
+	SettingCollapsedPrefix = "collapsed:"
+	// SettingWidthPrefix followed by a column's status sets how wide the
+	// column is on the board; see ColumnWidth
+	SettingWidthPrefix = "width:"
+	// SettingSwimlanes is what the board groups its tasks into swimlanes by:
+	// tag or priority
+	SettingSwimlanes = "swimlanes"
+	// SettingDefaultView is the name of the saved view the board opens with
+	SettingDefaultView = "default_view"
+	// SettingWelcomed is false on a sample board whose welcome overlay
+	// hasn't been dismissed yet
+	SettingWelcomed = "welcomed"
+	// SettingBoardID is the random ID of the board in the database; see
+	// BoardID. It isn't a setting config lists or changes.
+	SettingBoardID = "board_id"
+)
+
+// SettingInfo describes a workspace setting `config` can read and change
+type SettingInfo struct {
+	Key     string   // the key, or for a column setting the prefix before the column's status
+	Kind    string   // "bool", "int", "string" or "width", a ColumnWidth
+	Values  []string // the values a string setting takes; nil for any
+	Default string   // the value when it isn't set
+	Column  bool     // there is one setting per column
+	Help    string
+}
+
+// knownSettings are the settings of this version; other keys, e.g. from a
+// newer cli_kanban, are kept as they are
+var knownSettings = []SettingInfo{
+	{Key: SettingStrictWIP, Kind: "bool", Default: "false", Help: "block moves into columns at their WIP limit instead of asking"},
+	{Key: SettingSwimlanes, Kind: "string", Values: []string{"tag", "priority"}, Help: "what the board groups its tasks into swimlanes by"},
+	{Key: SettingDefaultView, Kind: "string", Help: "saved view the board opens with"},
+	{Key: SettingSortPrefix, Kind: "string", Values: []string{"priority", "due", "created", "title"}, Column: true, Help: "how the tasks of the column are sorted"},
+	{Key: SettingCollapsedPrefix, Kind: "bool", Default: "false", Column: true, Help: "the column is collapsed to a narrow strip"},
+	{Key: SettingWidthPrefix, Kind: "width", Default: "1x", Column: true, Help: "width of the column: a share such as 2x, or a number of cells such as 40"},
+	{Key: SettingWelcomed, Kind: "bool", Default: "true", Help: "the welcome overlay of the sample board was dismissed"},
+}
+
+// KnownSettings returns the settings of this version
+func KnownSettings() []SettingInfo {
+	return append([]SettingInfo(nil), knownSettings...)
+}
+
+// LookupSetting returns the known setting a key belongs to, with the status
+// of the column for a column setting
+func LookupSetting(key string) (SettingInfo, model.TaskStatus, bool) {
+	for _, info := range knownSettings {
+		if info.Column && strings.HasPrefix(key, info.Key) && len(key) > len(info.Key) {
+			return info, model.TaskStatus(strings.TrimPrefix(key, info.Key)), true
+		}
+		if !info.Column && key == info.Key {
+			return info, "", true
+		}
+	}
+	return SettingInfo{}, "", false
+}
+
+// Setting is a stored key/value pair
+type Setting struct {
+	Key   string
+	Value string
+}
+
+// createSettingsTable creates the per-workspace key/value settings table
+func createSettingsTable(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create settings table: %w", err)
+	}
+	return nil
+}
+
+// getSetting returns the value of a setting and whether it is set
+func getSetting(ex execer, key string) (string, bool, error) {
+	var value string
+	err := ex.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read setting %q: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// GetSetting returns the value of a workspace setting and whether it is set
+func (db *DB) GetSetting(key string) (string, bool, error) {
+	return getSetting(db.conn, key)
+}
+
+// Settings returns every stored setting ordered by key, the ones this
+// version doesn't know included
+func (db *DB) Settings() ([]Setting, error) {
+	rows, err := db.conn.Query("SELECT key, value FROM settings ORDER BY key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []Setting
+	for rows.Next() {
+		var s Setting
+		if err := rows.Scan(&s.Key, &s.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+		settings = append(settings, s)
+	}
+	return settings, rows.Err()
+}
+
+// GetString returns a workspace setting, or fallback when it isn't set
+func (db *DB) GetString(key, fallback string) (string, error) {
+	value, ok, err := getSetting(db.conn, key)
+	if err != nil || !ok {
+		return fallback, err
+	}
+	return value, nil
+}
+
+// getBool returns a true/false setting, or fallback when it isn't set
+func getBool(ex execer, key string, fallback bool) (bool, error) {
+	value, ok, err := getSetting(ex, key)
+	if err != nil || !ok {
+		return fallback, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback, fmt.Errorf("invalid %s setting %q: %w", key, value, err)
+	}
+	return b, nil
+}
+
+// GetBool returns a true/false workspace setting, or fallback when it isn't set
+func (db *DB) GetBool(key string, fallback bool) (bool, error) {
+	return getBool(db.conn, key, fallback)
+}
+
+// GetInt returns a whole number workspace setting, or fallback when it isn't set
+func (db *DB) GetInt(key string, fallback int) (int, error) {
+	value, ok, err := getSetting(db.conn, key)
+	if err != nil || !ok {
+		return fallback, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback, fmt.Errorf("invalid %s setting %q: %w", key, value, err)
+	}
+	return n, nil
+}
+
+// SetBool stores a true/false workspace setting
+func (db *DB) SetBool(key string, value bool) error {
+	return db.SetSetting(key, strconv.FormatBool(value))
+}
+
+// SetInt stores a whole number workspace setting
+func (db *DB) SetInt(key string, value int) error {
+	return db.SetSetting(key, strconv.Itoa(value))
+}
+
+// DeleteSetting removes a workspace setting, which goes back to its default
+func (db *DB) DeleteSetting(key string) error {
+	if _, err := db.exec("DELETE FROM settings WHERE key = ?", key); err != nil {
+		return fmt.Errorf("failed to delete setting %q: %w", key, err)
+	}
+	return nil
+}
+
+// ConfigureSetting checks a value against the known setting of key and
+// stores it; an empty value removes the setting. Keys this version doesn't
+// know are refused, so a typo doesn't go unnoticed.
+func (db *DB) ConfigureSetting(key, value string) error {
+	info, status, ok := LookupSetting(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if info.Column {
+		columns, err := db.GetColumns()
+		if err != nil {
+			return err
+		}
+		if !hasStatus(columns, status) {
+			return fmt.Errorf("%s: no column has the status %q", key, status)
+		}
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return db.DeleteSetting(key)
+	}
+
+	switch info.Kind {
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not true or false", key, value)
+		}
+		if info.Key == SettingCollapsedPrefix && !b {
+			return db.DeleteSetting(key)
+		}
+		return db.SetBool(key, b)
+	case "int":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a whole number", key, value)
+		}
+		return db.SetInt(key, n)
+	case "width":
+		width, err := ParseColumnWidth(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if width == (ColumnWidth{Parts: 1}) {
+			return db.DeleteSetting(key)
+		}
+		return db.SetSetting(key, width.String())
+	}
+	if key == SettingDefaultView {
+		return db.SetDefaultView(value)
+	}
+	if info.Values != nil {
+		for _, v := range info.Values {
+			if strings.EqualFold(v, value) {
+				return db.SetSetting(key, v)
+			}
+		}
+		return fmt.Errorf("%s: %q must be one of %s", key, value, strings.Join(info.Values, ", "))
+	}
+	return db.SetSetting(key, value)
+}
+
+// hasStatus reports whether a column has the status
+func hasStatus(columns []model.Column, status model.TaskStatus) bool {
+	for _, col := range columns {
+		if col.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSetting stores a workspace setting
+func (db *DB) SetSetting(key, value string) error {
+	if _, err := db.exec(
+		"INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value",
+		key, value,
+	); err != nil {
+		return fmt.Errorf("failed to save setting %q: %w", key, err)
+	}
+	return nil
+}
+
+// BoardID returns the random ID of the board in the database, made the
+// first time it is asked for. A task ID means the same task only as long as
+// the board ID stays the same: it is made anew whenever the board's tasks
+// are replaced and their IDs given out again.
+func (db *DB) BoardID() (string, error) {
+	id, ok, err := getSetting(db.conn, SettingBoardID)
+	if err != nil || ok {
+		return id, err
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to make board ID: %w", err)
+	}
+	id = hex.EncodeToString(b)
+	if err := db.SetSetting(SettingBoardID, id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// resetBoardID drops the board ID, so the next BoardID makes a new one
+func resetBoardID(ex execer) error {
+	if _, err := ex.Exec("DELETE FROM settings WHERE key = ?", SettingBoardID); err != nil {
+		return fmt.Errorf("failed to reset board ID: %w", err)
+	}
+	return nil
+}
+
+// strictWIP reports whether WIP limits are enforced as a hard block
+func strictWIP(ex execer) (bool, error) {
+	return getBool(ex, SettingStrictWIP, false)
+}
+
+// StrictWIP reports whether WIP limits are enforced as a hard block
+func (db *DB) StrictWIP() (bool, error) {
+	return strictWIP(db.conn)
+}
+
+// SetStrictWIP turns hard enforcement of WIP limits on or off
+func (db *DB) SetStrictWIP(strict bool) error {
+	return db.SetBool(SettingStrictWIP, strict)
+}
+
+// SortModes returns how the tasks of each column are sorted, by column
+// status, for the columns that aren't in manual order
+func (db *DB) SortModes() (map[model.TaskStatus]string, error) {
+	rows, err := db.conn.Query("SELECT key, value FROM settings WHERE key LIKE ?", SettingSortPrefix+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sort modes: %w", err)
+	}
+	defer rows.Close()
+
+	modes := make(map[model.TaskStatus]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan sort mode: %w", err)
+		}
+		modes[model.TaskStatus(strings.TrimPrefix(key, SettingSortPrefix))] = value
+	}
+	return modes, rows.Err()
+}
+
+// SetSortMode stores how the tasks of a column are sorted; an empty mode
+// puts the column back in its manual order. Sorting never touches the
+// positions of the tasks, so the manual order comes back as it was.
+func (db *DB) SetSortMode(status model.TaskStatus, mode string) error {
+	if mode == "" {
+		if _, err := db.exec("DELETE FROM settings WHERE key = ?", SettingSortPrefix+string(status)); err != nil {
+			return fmt.Errorf("failed to save sort mode: %w", err)
+		}
+		return nil
+	}
+	return db.SetSetting(SettingSortPrefix+string(status), mode)
+}
+
+// CollapsedColumns returns the statuses of the columns collapsed on the board
+func (db *DB) CollapsedColumns() (map[model.TaskStatus]bool, error) {
+	rows, err := db.conn.Query("SELECT key FROM settings WHERE key LIKE ?", SettingCollapsedPrefix+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collapsed columns: %w", err)
+	}
+	defer rows.Close()
+
+	collapsed := make(map[model.TaskStatus]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan collapsed column: %w", err)
+		}
+		collapsed[model.TaskStatus(strings.TrimPrefix(key, SettingCollapsedPrefix))] = true
+	}
+	return collapsed, rows.Err()
+}
+
+// SetColumnCollapsed stores whether a column is collapsed on the board
+func (db *DB) SetColumnCollapsed(status model.TaskStatus, collapsed bool) error {
+	if !collapsed {
+		if _, err := db.exec("DELETE FROM settings WHERE key = ?", SettingCollapsedPrefix+string(status)); err != nil {
+			return fmt.Errorf("failed to save collapsed column: %w", err)
+		}
+		return nil
+	}
+	return db.SetSetting(SettingCollapsedPrefix+string(status), "true")
+}
+
+// ColumnWidth is how wide a column is on the board: Parts shares of the room
+// the fixed columns leave, or Cells cells, border included, when Cells is set
+type ColumnWidth struct {
+	Parts int
+	Cells int
+}
+
+const (
+	// maxWidthParts is the largest share a column takes
+	maxWidthParts = 10
+	// minWidthCells and maxWidthCells bound a fixed column width; the board
+	// still widens a column to its readable minimum
+	minWidthCells = 10
+	maxWidthCells = 300
+)
+
+// ParseColumnWidth reads a column width: a share such as 2x, or a number of
+// cells such as 40
+func ParseColumnWidth(value string) (ColumnWidth, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if parts, ok := strings.CutSuffix(value, "x"); ok {
+		n, err := strconv.Atoi(parts)
+		if err != nil || n < 1 || n > maxWidthParts {
+			return ColumnWidth{}, fmt.Errorf("%q must be a share from 1x to %dx", value, maxWidthParts)
+		}
+		return ColumnWidth{Parts: n}, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return ColumnWidth{}, fmt.Errorf("%q is not a share such as 2x or a number of cells such as 40", value)
+	}
+	if n < minWidthCells || n > maxWidthCells {
+		return ColumnWidth{}, fmt.Errorf("%q must be from %d to %d cells", value, minWidthCells, maxWidthCells)
+	}
+	return ColumnWidth{Cells: n}, nil
+}
+
+// String returns the width as ParseColumnWidth reads it
+func (w ColumnWidth) String() string {
+	if w.Cells > 0 {
+		return strconv.Itoa(w.Cells)
+	}
+	return strconv.Itoa(w.Parts) + "x"
+}
+
+// ColumnWidths returns the widths set for columns, by status; values that
+// don't parse are left out, so the column keeps the default width
+func (db *DB) ColumnWidths() (map[model.TaskStatus]ColumnWidth, error) {
+	rows, err := db.conn.Query("SELECT key, value FROM settings WHERE key LIKE ?", SettingWidthPrefix+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read column widths: %w", err)
+	}
+	defer rows.Close()
+
+	widths := make(map[model.TaskStatus]ColumnWidth)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan column width: %w", err)
+		}
+		if width, err := ParseColumnWidth(value); err == nil {
+			widths[model.TaskStatus(strings.TrimPrefix(key, SettingWidthPrefix))] = width
+		}
+	}
+	return widths, rows.Err()
+}
+
+// SetSwimlanes stores what the board groups its tasks into swimlanes by;
+// an empty mode gives the flat board
+func (db *DB) SetSwimlanes(mode string) error {
+	if mode == "" {
+		if _, err := db.exec("DELETE FROM settings WHERE key = ?", SettingSwimlanes); err != nil {
+			return fmt.Errorf("failed to save swimlanes: %w", err)
+		}
+		return nil
+	}
+	return db.SetSetting(SettingSwimlanes, mode)
+}
+
+// SetSortModes replaces the sort modes of every column, by column status;
+// columns left out go back to their manual order
+func (db *DB) SetSortModes(modes map[model.TaskStatus]string) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to save sort modes: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM settings WHERE key LIKE ?", SettingSortPrefix+"%"); err != nil {
+		return fmt.Errorf("failed to save sort modes: %w", err)
+	}
+	for status, mode := range modes {
+		if mode == "" {
+			continue
+		}
+		if _, err := tx.Exec("INSERT INTO settings (key, value) VALUES (?, ?)", SettingSortPrefix+string(status), mode); err != nil {
+			return fmt.Errorf("failed to save sort modes: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save sort modes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// awakeTaskSQL matches the tasks that aren't snoozed: never snoozed, woken
+// already or due to wake by the day it takes as parameter, as YYYY-MM-DD
+const awakeTaskSQL = "(snoozed_until IS NULL OR woke_at IS NOT NULL OR snoozed_until <= ?)"
+
+// snoozeValue converts a wake day to the day stored in snoozed_until
+func snoozeValue(until *time.Time) interface{} {
+	if until == nil {
+		return nil
+	}
+	return until.Format(dates.DateFormat)
+}
+
+// parseSnoozedUntil converts snoozed_until to midnight of the wake day in
+// the local time zone
+func parseSnoozedUntil(s sql.NullString) *time.Time {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	until, err := time.ParseInLocation(dates.DateFormat, s.String, time.Local)
+	if err != nil {
+		return nil
+	}
+	return &until
+}
+
+// SnoozeTask takes a task off the board until the day of until, when it
+// comes back at the top of its column
+func (db *DB) SnoozeTask(id int64, until time.Time) error {
+	result, err := db.exec(
+		"UPDATE tasks SET snoozed_until = ?, woke_at = NULL, updated_at = ? WHERE id = ? AND "+activeTaskSQL,
+		until.Format(dates.DateFormat), time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to snooze task: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("task %d not found", id)
+	}
+	return nil
+}
+
+// WakeTask brings a snoozed task back to the top of its column now
+func (db *DB) WakeTask(id int64) error {
+	return db.inTx("wake task", func(tx *Tx) error {
+		var status model.TaskStatus
+		if err := tx.tx.QueryRow("SELECT status FROM tasks WHERE id = ? AND snoozed_until IS NOT NULL AND woke_at IS NULL AND "+activeTaskSQL, id).Scan(&status); err != nil {
+			return fmt.Errorf("task %d is not snoozed", id)
+		}
+		return wakeTask(tx.tx, id, status, time.Now())
+	})
+}
+
+// WakeTasks brings back the snoozed tasks whose wake day has come, each at
+// the top of its column, and returns how many woke
+func (db *DB) WakeTasks(now time.Time) (int, error) {
+	woken := 0
+	err := db.inTx("wake tasks", func(tx *Tx) error {
+		rows, err := tx.tx.Query(
+			"SELECT id, status FROM tasks WHERE snoozed_until IS NOT NULL AND woke_at IS NULL AND snoozed_until <= ? AND "+activeTaskSQL+" ORDER BY snoozed_until, id",
+			now.Format(dates.DateFormat),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to query snoozed tasks: %w", err)
+		}
+		type due struct {
+			id     int64
+			status model.TaskStatus
+		}
+		var tasks []due
+		for rows.Next() {
+			var task due
+			if err := rows.Scan(&task.id, &task.status); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to query snoozed tasks: %w", err)
+			}
+			tasks = append(tasks, task)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to query snoozed tasks: %w", err)
+		}
+		rows.Close()
+
+		for _, task := range tasks {
+			if err := wakeTask(tx.tx, task.id, task.status, now); err != nil {
+				return err
+			}
+		}
+		woken = len(tasks)
+		return nil
+	})
+	return woken, err
+}
+
+// wakeTask moves a snoozed task to the top of its column and records when
+// it woke. Like a reorder, it doesn't count as a change to the task.
+func wakeTask(ex execer, id int64, status model.TaskStatus, now time.Time) error {
+	if _, err := ex.Exec(
+		"UPDATE tasks SET woke_at = ?, position = (SELECT COALESCE(MIN(position), 1) - 1 FROM tasks WHERE status = ?) WHERE id = ?",
+		now, status, id,
+	); err != nil {
+		return fmt.Errorf("failed to wake task: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// SyncResult counts what SyncTasks did
+type SyncResult struct {
+	Created int
+	Updated int
+}
+
+// SyncTasks imports tasks from an outside source in one transaction. A draft
+// whose Source and SourceID match a task not in the trash updates it: the
+// title, description and URL are replaced, tags are added and none removed,
+// and the task moves only when it became done at the source (to the done
+// column) or was reopened there (out of the done column, to draft.Status).
+// Other drafts are created on top of their column, in order.
+func (db *DB) SyncTasks(drafts []model.Task) (SyncResult, error) {
+	var result SyncResult
+	tx, err := db.begin()
+	if err != nil {
+		return result, fmt.Errorf("failed to import tasks: %w", err)
+	}
+	defer tx.Rollback()
+
+	done, err := doneStatuses(tx)
+	if err != nil {
+		return result, err
+	}
+	now := time.Now()
+	var created []model.Task
+	for _, draft := range drafts {
+		if draft.Source == "" || draft.SourceID == "" {
+			return result, fmt.Errorf("task %q has no source", draft.Title)
+		}
+		var id int64
+		var status model.TaskStatus
+		err := tx.QueryRow("SELECT id, status FROM tasks WHERE source = ? AND source_id = ? AND deleted_at IS NULL ORDER BY id LIMIT 1", draft.Source, draft.SourceID).Scan(&id, &status)
+		if errors.Is(err, sql.ErrNoRows) {
+			created = append(created, draft)
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to look up imported task: %w", err)
+		}
+
+		if done[draft.Status] != done[status] {
+			status = draft.Status
+		}
+		if _, err := tx.Exec(
+			"UPDATE tasks SET title = ?, description = ?, url = ?, "+movePositionSQL+", status = ?, updated_at = ?, "+completedAtSQL+" WHERE id = ?",
+			draft.Title, draft.Description, draft.URL, status, status, status, now, done[status], now, id,
+		); err != nil {
+			return result, fmt.Errorf("failed to update task %q: %w", draft.Title, err)
+		}
+		if err := addTaskLabels(tx, id, draft.Tags); err != nil {
+			return result, err
+		}
+		result.Updated++
+	}
+
+	if _, err := insertTasks(tx, created, done, now); err != nil {
+		return result, err
+	}
+	result.Created = len(created)
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to import tasks: %w", err)
+	}
+	return result, nil
+}
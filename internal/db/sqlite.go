@@ -1,116 +1,515 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/happytaoer/cli_kanban/internal/crypt"
 	"github.com/happytaoer/cli_kanban/internal/model"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type DB struct {
-	conn *sql.DB
+	conn     *sql.DB
+	readOnly bool      // opened by NewReadOnly; Close leaves the files alone
+	vault    *vault    // the decrypted copy of an encrypted database, nil for a plain one
+	keep     *sql.Conn // holds a database in memory open while the pool closes idle connections
 }
 
-// New creates a new database connection and initializes tables
+// New opens the database at dbPath and migrates it to the current schema.
+// An encrypted database is decrypted with the passphrase asked for, and
+// saved encrypted again by Close.
 func New(dbPath string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", dbPath)
+	v, err := unlock(dbPath, true)
 	if err != nil {
+		return nil, err
+	}
+	path := dbPath
+	if v != nil {
+		path = v.plain
+	}
+
+	// Take the write lock when a transaction starts, so concurrent transactions
+	// wait for each other instead of failing halfway through. WAL lets other
+	// processes read while one writes, and the busy timeout makes them wait for
+	// the lock instead of failing right away.
+	dsn := fmt.Sprintf("%s?_txlock=immediate&_journal_mode=WAL&_busy_timeout=%d&_foreign_keys=on", path, busyTimeout.Milliseconds())
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		v.discard()
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	db := &DB{conn: conn}
-	if err := db.initTables(); err != nil {
+	db := &DB{conn: conn, vault: v}
+	if err := db.migrate(dbPath); err != nil {
+		db.abort()
+		return nil, err
+	}
+	if _, err := db.PurgeTrash(time.Now().Add(-TrashRetention)); err != nil {
+		db.abort()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// NewMemory opens a new database held in memory, at the current schema,
+// such as the board of --demo. It is gone once closed.
+func NewMemory() (*DB, error) {
+	// The memdb VFS shares the database among the connections of the pool
+	// under the usual locks, so concurrent transactions wait for each other
+	// as they do on a file. It lives while a connection has it open.
+	dsn := fmt.Sprintf("file:/cli_kanban-%d-%d?vfs=memdb&_txlock=immediate&_busy_timeout=%d&_foreign_keys=on", os.Getpid(), time.Now().UnixNano(), busyTimeout.Milliseconds())
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	keep, err := conn.Conn(context.Background())
+	if err != nil {
 		conn.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db := &DB{conn: conn, keep: keep}
+	if err := db.migrate("the in-memory database"); err != nil {
+		db.Close()
 		return nil, err
 	}
+	return db, nil
+}
 
+// OpenReadOnly opens an existing database for quick lookups such as shell
+// completion. Nothing is created or migrated, so a database from an older
+// schema may fail the queries instead.
+func OpenReadOnly(dbPath string) (*DB, error) {
+	// Quick lookups can't stop to ask for a passphrase
+	if encrypted, err := crypt.IsEncrypted(dbPath); err != nil || encrypted {
+		if err == nil {
+			err = fmt.Errorf("%s is encrypted", dbPath)
+		}
+		return nil, err
+	}
+	// A read-only connection can't clean up the write-ahead log files it
+	// creates, so unless another process already has them open the database
+	// is read as immutable, which doesn't touch them
+	mode := "immutable=1"
+	if _, err := os.Stat(dbPath + "-wal"); err == nil {
+		mode = "mode=ro"
+	}
+	dsn := fmt.Sprintf("file:%s?%s&_busy_timeout=%d", dbPath, mode, busyTimeout.Milliseconds())
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return &DB{conn: conn}, nil
+}
+
+// NewReadOnly opens an existing database that must not be changed, such as
+// a board opened with --read-only. Unlike OpenReadOnly it keeps seeing the
+// writes of other processes, and unlike New it neither migrates nor purges,
+// so the database must already have the current schema. An encrypted
+// database is read from a decrypted copy, so it doesn't see them.
+func NewReadOnly(dbPath string) (*DB, error) {
+	db, err := openReadOnly(dbPath, "mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	current, err := schemaVersion(db.conn)
+	if err != nil {
+		db.abort()
+		return nil, err
+	}
+	switch latest := SchemaVersion(); {
+	case current > latest:
+		db.abort()
+		return nil, fmt.Errorf("%w: %s has schema version %d, this binary supports up to %d; please upgrade cli_kanban", ErrSchemaTooNew, dbPath, current, latest)
+	case current < latest:
+		db.abort()
+		return nil, fmt.Errorf("%s has schema version %d and needs upgrading to %d, which a read-only database can't do; open it once without --read-only", dbPath, current, latest)
+	}
 	return db, nil
 }
 
-// Close closes the database connection
+// Inspect opens an existing database read-only at whatever schema it has,
+// for Check to look at it as it is. Like OpenReadOnly it reads the database
+// as immutable unless another process has its write-ahead log open, so the
+// files are left exactly as they were.
+func Inspect(dbPath string) (*DB, error) {
+	mode := "immutable=1"
+	if _, err := os.Stat(dbPath + "-wal"); err == nil {
+		mode = "mode=ro"
+	}
+	return openReadOnly(dbPath, mode)
+}
+
+// openReadOnly opens an existing database with a read-only mode, from a
+// decrypted copy when it is encrypted
+func openReadOnly(dbPath, mode string) (*DB, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	v, err := unlock(dbPath, false)
+	if err != nil {
+		return nil, err
+	}
+	path := dbPath
+	if v != nil {
+		path = v.plain
+	}
+	dsn := fmt.Sprintf("file:%s?%s&_busy_timeout=%d&_foreign_keys=on", path, mode, busyTimeout.Milliseconds())
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		v.discard()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return &DB{conn: conn, readOnly: true, vault: v}, nil
+}
+
+// Close closes the database connection, first folding the write-ahead log
+// back into the database file so it can be copied on its own. An encrypted
+// database is then encrypted back from its copy.
 func (db *DB) Close() error {
-	return db.conn.Close()
+	if !db.readOnly {
+		_, _ = db.conn.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	}
+	if db.keep != nil {
+		_ = db.keep.Close()
+	}
+	err := db.conn.Close()
+	if vaultErr := db.vault.close(); err == nil {
+		err = vaultErr
+	}
+	return err
+}
+
+// abort closes a database that failed to open, saving nothing
+func (db *DB) abort() {
+	db.conn.Close()
+	db.vault.discard()
+}
+
+// Snapshot writes a consistent copy of the whole database to path, which
+// must not exist yet. Changes made meanwhile by other connections are
+// either all in it or not at all.
+func (db *DB) Snapshot(path string) error {
+	if _, err := db.conn.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to copy database to %q: %w", path, err)
+	}
+	return nil
 }
 
-// initTables creates the necessary tables if they don't exist
-func (db *DB) initTables() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS tasks (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title TEXT NOT NULL,
-		description TEXT DEFAULT '',
-		status TEXT NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
+// completedAt returns the completion time to store for a task in the given column
+func completedAt(status model.TaskStatus, done doneSet, now time.Time) *time.Time {
+	if !done[status] {
+		return nil
+	}
+	return &now
+}
+
+// completedAtSQL keeps completed_at when a task stays done, sets it when the
+// task enters the done column and clears it when it leaves. It takes the
+// "is done" flag and the current time as parameters.
+const completedAtSQL = "completed_at = CASE WHEN ? THEN COALESCE(completed_at, ?) ELSE NULL END"
 
-	CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
-	`
+// nextPositionSQL is a subquery for the position after the last task of a column, taking the status as parameter
+const nextPositionSQL = "(SELECT COALESCE(MAX(position), -1) + 1 FROM tasks WHERE status = ?)"
 
-	_, err := db.conn.Exec(schema)
+// movePositionSQL keeps a task's position when its status is unchanged and
+// appends it to the end of its new column otherwise. It takes the new status
+// twice as parameters.
+const movePositionSQL = "position = CASE WHEN status = ? THEN position ELSE " + nextPositionSQL + " END"
+
+// CreateTask creates a new task at the top of its column
+func (db *DB) CreateTask(title string, status model.TaskStatus) (*model.Task, error) {
+	return db.CreateTaskFrom(model.Task{Title: title, Status: status})
+}
+
+// CreateTaskFrom creates a new task at the top of its column with the title,
+// description, priority, due date, tags and checklist of draft
+func (db *DB) CreateTaskFrom(draft model.Task) (*model.Task, error) {
+	tasks, err := db.CreateTasks([]model.Task{draft})
 	if err != nil {
-		return fmt.Errorf("failed to create tables: %w", err)
+		return nil, err
 	}
+	return &tasks[0], nil
+}
 
-	// Migrate existing tables to add description column if it doesn't exist
-	_, err = db.conn.Exec(`
-		ALTER TABLE tasks ADD COLUMN description TEXT DEFAULT '';
-	`)
-	// Ignore error if column already exists
-	if err != nil && err.Error() != "duplicate column name: description" {
-		// Column might already exist, which is fine
+// CreateTasks creates several tasks in one transaction, as CreateTaskFrom
+// does, so that either all of them or none are created. Tasks landing in the
+// same column keep the order of drafts at its top.
+func (db *DB) CreateTasks(drafts []model.Task) ([]model.Task, error) {
+	var tasks []model.Task
+	err := db.inTx("create task", func(tx *Tx) error {
+		var err error
+		tasks, err = tx.CreateTasks(drafts)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return tasks, nil
+}
 
-	// Migrate existing tables to add tags column if it doesn't exist
-	_, err = db.conn.Exec(`
-		ALTER TABLE tasks ADD COLUMN tags TEXT DEFAULT '';
-	`)
-	// Ignore error if column already exists
+// CreateTasks creates several tasks like DB.CreateTasks does
+func (tx *Tx) CreateTasks(drafts []model.Task) ([]model.Task, error) {
+	done, err := doneStatuses(tx.tx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := insertTasks(tx.tx, drafts, done, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i, task := range tasks {
+		if len(drafts[i].Subtasks) > 0 {
+			// Read the checklist back for the IDs of its items
+			created, err := tx.GetTask(task.ID)
+			if err != nil {
+				return nil, err
+			}
+			tasks[i] = *created
+		}
+	}
+	return tasks, nil
+}
 
-	// Migrate existing tables to add due column if it doesn't exist
-	_, err = db.conn.Exec(`
-		ALTER TABLE tasks ADD COLUMN due DATETIME DEFAULT NULL;
-	`)
-	// Ignore error if column already exists
+// insertTasks inserts drafts on top of their columns, keeping their order.
+// The last draft goes on top first, and each one above the previous one;
+// positions are looked up once per column, keeping thousands of inserts fast.
+func insertTasks(ex execer, drafts []model.Task, done doneSet, now time.Time) ([]model.Task, error) {
+	tasks := make([]model.Task, len(drafts))
+	next := map[model.TaskStatus]int{}
+	for i := len(drafts) - 1; i >= 0; i-- {
+		status := drafts[i].Status
+		position, ok := next[status]
+		if !ok {
+			if err := ex.QueryRow("SELECT COALESCE(MIN(position), 1) - 1 FROM tasks WHERE status = ?", status).Scan(&position); err != nil {
+				return nil, fmt.Errorf("failed to get task position: %w", err)
+			}
+		}
+		next[status] = position - 1
 
-	return nil
+		task, err := insertTask(ex, drafts[i], position, done, now)
+		if err != nil {
+			return nil, err
+		}
+		tasks[i] = task
+	}
+	return tasks, nil
 }
 
-// CreateTask creates a new task
-func (db *DB) CreateTask(title string, status model.TaskStatus) (*model.Task, error) {
-	now := time.Now()
-	result, err := db.conn.Exec(
-		"INSERT INTO tasks (title, description, tags, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
-		title, "", "", status, now, now,
+// insertTask inserts draft at position in its column, done being the done
+// columns
+func insertTask(ex execer, draft model.Task, position int, done doneSet, now time.Time) (model.Task, error) {
+	recurrence, err := normalizeRecurrence(draft.Recurrence)
+	if err != nil {
+		return model.Task{}, err
+	}
+	var dueValue interface{}
+	if draft.Due != nil {
+		dueValue = draft.Due.Format("2006-01-02 15:04:05")
+	}
+
+	completed := completedAt(draft.Status, done, now)
+	result, err := ex.Exec(
+		"INSERT INTO tasks (title, description, due, recurrence, priority, status, source, source_id, url, parent_task_id, position, created_at, updated_at, completed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		draft.Title, draft.Description, dueValue, recurrence, draft.Priority, draft.Status, draft.Source, draft.SourceID, draft.URL, parentValue(draft.Parent), position, now, now, completed,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create task: %w", err)
+		return model.Task{}, fmt.Errorf("failed to create task: %w", err)
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+		return model.Task{}, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	if err := setTaskLabels(ex, id, draft.Tags); err != nil {
+		return model.Task{}, err
+	}
+	if err := insertSubtasks(ex, id, draft.Subtasks); err != nil {
+		return model.Task{}, err
 	}
 
-	return &model.Task{
+	return model.Task{
 		ID:          id,
-		Title:       title,
-		Description: "",
-		Tags:        []string{},
-		Status:      status,
+		Title:       draft.Title,
+		Description: draft.Description,
+		Due:         draft.Due,
+		Recurrence:  recurrence,
+		Priority:    draft.Priority,
+		Tags:        cleanTags(draft.Tags),
+		Subtasks:    []model.Subtask{},
+		Status:      draft.Status,
+		Source:      draft.Source,
+		SourceID:    draft.SourceID,
+		URL:         draft.URL,
+		Parent:      draft.Parent,
+		Position:    position,
+		Version:     1,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		CompletedAt: completed,
 	}, nil
 }
 
-// GetAllTasks retrieves all tasks
-func (db *DB) GetAllTasks() ([]model.Task, error) {
-	rows, err := db.conn.Query(
-		"SELECT id, title, description, tags, due, status, created_at, updated_at FROM tasks ORDER BY created_at DESC",
+// DuplicateSuffix is appended to the title of a duplicated task
+const DuplicateSuffix = " (copy)"
+
+// DuplicateTask copies a task on the board right below it in its column: its
+// title with suffix appended, description, priority, tags and checklist, with
+// every item unchecked. The copy is a new task created now; the due date and
+// recurrence are left out.
+func (db *DB) DuplicateTask(id int64, suffix string) (*model.Task, error) {
+	src, err := db.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if src.DeletedAt != nil || src.ArchivedAt != nil {
+		return nil, fmt.Errorf("task %d not found", id)
+	}
+
+	tx, err := db.begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to duplicate task: %w", err)
+	}
+	defer tx.Rollback()
+
+	var position int
+	if err := tx.QueryRow("SELECT position FROM tasks WHERE id = ? AND "+activeTaskSQL, id).Scan(&position); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("task %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to duplicate task: %w", err)
+	}
+	// Make room below the original
+	if _, err := tx.Exec("UPDATE tasks SET position = position + 1 WHERE status = ? AND position > ?", src.Status, position); err != nil {
+		return nil, fmt.Errorf("failed to duplicate task: %w", err)
+	}
+
+	done, err := doneStatuses(tx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result, err := tx.Exec(
+		"INSERT INTO tasks (title, description, priority, status, position, created_at, updated_at, completed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		src.Title+suffix, src.Description, src.Priority, src.Status, position+1, now, now, completedAt(src.Status, done, now),
 	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to duplicate task: %w", err)
+	}
+	copyID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	if err := setTaskLabels(tx, copyID, src.Tags); err != nil {
+		return nil, err
+	}
+	subtasks := make([]model.Subtask, len(src.Subtasks))
+	for i, st := range src.Subtasks {
+		subtasks[i] = model.Subtask{Title: st.Title}
+	}
+	if err := insertSubtasks(tx, copyID, subtasks); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to duplicate task: %w", err)
+	}
+	return db.GetTask(copyID)
+}
+
+// taskColumns is the column list selected by every task query, in scanTask order
+const taskColumns = "id, title, description, due, recurrence, priority, status, source, source_id, url, parent_task_id, position, version, created_at, updated_at, completed_at, deleted_at, archived_at, snoozed_until, woke_at, pinned"
+
+// activeTaskSQL matches the tasks shown on the board: neither in the trash nor archived
+const activeTaskSQL = "deleted_at IS NULL AND archived_at IS NULL"
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask scans a single task row selected with taskColumns.
+// Tags live in a separate table and are filled in by loadLabels.
+func scanTask(row rowScanner) (*model.Task, error) {
+	var task model.Task
+	var dueStr sql.NullString
+	var priority sql.NullString
+	var parent sql.NullInt64
+	var completed, deleted, archived, woke sql.NullTime
+	var snoozed sql.NullString
+	err := row.Scan(&task.ID, &task.Title, &task.Description, &dueStr, &task.Recurrence, &priority, &task.Status, &task.Source, &task.SourceID, &task.URL, &parent, &task.Position, &task.Version, &task.CreatedAt, &task.UpdatedAt, &completed, &deleted, &archived, &snoozed, &woke, &task.Pinned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan task: %w", err)
+	}
+	task.Tags = []string{}
+	task.Subtasks = []model.Subtask{}
+	task.Due = parseDue(dueStr)
+	task.Priority = model.TaskPriority(priority.String)
+	task.Parent = parent.Int64
+	if completed.Valid {
+		task.CompletedAt = &completed.Time
+	}
+	if deleted.Valid {
+		task.DeletedAt = &deleted.Time
+	}
+	if archived.Valid {
+		task.ArchivedAt = &archived.Time
+	}
+	task.SnoozedUntil = parseSnoozedUntil(snoozed)
+	if woke.Valid {
+		task.WokeAt = &woke.Time
+	}
+	return &task, nil
+}
+
+// GetTask retrieves a single task by ID
+func (db *DB) GetTask(id int64) (*model.Task, error) {
+	return getTask(db.conn, id)
+}
+
+// getTask retrieves a single task by ID, within a transaction or not
+func getTask(ex execer, id int64) (*model.Task, error) {
+	row := ex.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id)
+	task, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("task %d not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tasks := []model.Task{*task}
+	if err := loadLabels(ex, tasks); err != nil {
+		return nil, err
+	}
+	if err := loadSubtasks(ex, tasks); err != nil {
+		return nil, err
+	}
+	if err := loadDependencies(ex, tasks); err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+// GetAllTasks retrieves all tasks on the board, leaving out the trash and the archive
+func (db *DB) GetAllTasks() ([]model.Task, error) {
+	return queryTasks(db.conn, "SELECT "+taskColumns+" FROM tasks WHERE "+activeTaskSQL+" ORDER BY position, id")
+}
+
+// queryTasks runs a query selecting taskColumns and loads the tags and
+// checklists of the tasks it returns
+func queryTasks(ex execer, query string, args ...interface{}) ([]model.Task, error) {
+	rows, err := ex.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tasks: %w", err)
 	}
@@ -118,54 +517,342 @@ func (db *DB) GetAllTasks() ([]model.Task, error) {
 
 	var tasks []model.Task
 	for rows.Next() {
-		var task model.Task
-		var tagsStr string
-		var dueStr sql.NullString
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &tagsStr, &dueStr, &task.Status, &task.CreatedAt, &task.UpdatedAt)
+		task, err := scanTask(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan task: %w", err)
+			return nil, err
 		}
-		task.Tags = parseTags(tagsStr)
-		task.Due = parseDue(dueStr)
-		tasks = append(tasks, task)
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	rows.Close()
+
+	if err := loadLabels(ex, tasks); err != nil {
+		return nil, err
+	}
+	if err := loadSubtasks(ex, tasks); err != nil {
+		return nil, err
+	}
+	if err := loadDependencies(ex, tasks); err != nil {
+		return nil, err
 	}
 
 	return tasks, nil
 }
 
-// GetTasksByStatus retrieves tasks by status
-func (db *DB) GetTasksByStatus(status model.TaskStatus) ([]model.Task, error) {
-	rows, err := db.conn.Query(
-		"SELECT id, title, description, tags, due, status, created_at, updated_at FROM tasks WHERE status = ? ORDER BY created_at DESC",
-		status,
+// GetBoard retrieves all tasks grouped into the board columns, in column order
+func (db *DB) GetBoard() ([]model.Column, error) {
+	columns, err := db.GetColumns()
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := db.GetAllTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range tasks {
+		for i := range columns {
+			if columns[i].Status == task.Status {
+				columns[i].Tasks = append(columns[i].Tasks, task)
+				break
+			}
+		}
+	}
+
+	return columns, nil
+}
+
+// GetTasksByStatus retrieves a page of the tasks of a column in board
+// order, excluding the trash and the archive: limit tasks from offset on,
+// or all of them from offset on when limit is 0. Only that page is read, so
+// a column of thousands of tasks can be listed a bit at a time.
+func (db *DB) GetTasksByStatus(status model.TaskStatus, offset, limit int) ([]model.Task, error) {
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	return queryTasks(db.conn,
+		"SELECT "+taskColumns+" FROM tasks WHERE status = ? AND "+activeTaskSQL+" ORDER BY position, id LIMIT ? OFFSET ?",
+		status, limit, max(offset, 0),
 	)
+}
+
+// CountTasksByStatus returns how many tasks each column holds, excluding
+// the trash and the archive; columns without tasks are left out
+func (db *DB) CountTasksByStatus() (map[model.TaskStatus]int, error) {
+	rows, err := db.conn.Query("SELECT status, COUNT(*) FROM tasks WHERE " + activeTaskSQL + " GROUP BY status")
 	if err != nil {
-		return nil, fmt.Errorf("failed to query tasks: %w", err)
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
 	}
 	defer rows.Close()
 
-	var tasks []model.Task
+	counts := make(map[model.TaskStatus]int)
 	for rows.Next() {
-		var task model.Task
-		var tagsStr string
-		var dueStr sql.NullString
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &tagsStr, &dueStr, &task.Status, &task.CreatedAt, &task.UpdatedAt)
+		var status model.TaskStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to count tasks: %w", err)
+		}
+		counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	return counts, nil
+}
+
+// GetTasksDueBetween retrieves the tasks due on the days from from up to but
+// excluding to, excluding the trash and the archive, by due date. Only the
+// calendar dates of from and to count.
+func (db *DB) GetTasksDueBetween(from, to time.Time) ([]model.Task, error) {
+	return queryTasks(db.conn,
+		"SELECT "+taskColumns+" FROM tasks WHERE "+activeTaskSQL+" AND due >= ? AND due < ? ORDER BY due, position, id",
+		from.Format("2006-01-02"), to.Format("2006-01-02"),
+	)
+}
+
+// CountTasks returns the total number of tasks, excluding the trash
+func (db *DB) CountTasks() (int, error) {
+	var count int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM tasks WHERE deleted_at IS NULL").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	return count, nil
+}
+
+// CountBoard returns the number of tasks on the board and of those in the
+// done columns, excluding the trash and the archive. It reads databases
+// opened with OpenReadOnly too, whose schema may predate done columns; the
+// rightmost column is the done one there.
+func (db *DB) CountBoard() (total, done int, err error) {
+	doneSQL := doneTaskSQL
+	var flagged int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM pragma_table_info('board_columns') WHERE name = 'is_done'").Scan(&flagged); err != nil {
+		return 0, 0, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	if flagged == 0 {
+		doneSQL = "status = (SELECT status FROM board_columns ORDER BY position DESC, id DESC LIMIT 1)"
+	}
+	err = db.conn.QueryRow(
+		"SELECT COUNT(*), COUNT(CASE WHEN "+doneSQL+" THEN 1 END) FROM tasks WHERE "+activeTaskSQL,
+	).Scan(&total, &done)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	return total, done, nil
+}
+
+// ImportTasks inserts the tasks of the given columns in a single transaction,
+// so a malformed task leaves the database untouched. Columns are matched to
+// the board by status key or name and created at the right end when missing.
+// When replace is true all existing tasks are deleted first and the board's
+// columns are replaced by the imported ones. Task IDs are preserved when the
+// board never gave out any, and new ones are assigned otherwise, so an ID
+// isn't reused for another task. It returns the number of tasks imported.
+func (db *DB) ImportTasks(columns []model.Column, replace bool) (int, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	keepIDs := false
+	if replace {
+		var used int64
+		if err := tx.QueryRow("SELECT COALESCE(MAX(seq), 0) FROM sqlite_sequence WHERE name = 'tasks'").Scan(&used); err != nil {
+			return 0, fmt.Errorf("failed to read task IDs: %w", err)
+		}
+		keepIDs = used == 0
+		if _, err := tx.Exec("DELETE FROM task_labels"); err != nil {
+			return 0, fmt.Errorf("failed to clear task labels: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM subtasks"); err != nil {
+			return 0, fmt.Errorf("failed to clear subtasks: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM time_entries"); err != nil {
+			return 0, fmt.Errorf("failed to clear time entries: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM due_reminders"); err != nil {
+			return 0, fmt.Errorf("failed to clear due reminders: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM task_dependencies"); err != nil {
+			return 0, fmt.Errorf("failed to clear dependencies: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM tasks"); err != nil {
+			return 0, fmt.Errorf("failed to clear tasks: %w", err)
+		}
+		if err := resetBoardID(tx); err != nil {
+			return 0, err
+		}
+		named := false
+		for _, col := range columns {
+			named = named || !unnamedColumn(col)
+		}
+		if named {
+			if _, err := tx.Exec("DELETE FROM board_columns"); err != nil {
+				return 0, fmt.Errorf("failed to clear columns: %w", err)
+			}
+		}
+	}
+
+	// Resolve every imported column to a board column, creating missing
+	// ones. The tasks of a column without a name or status, such as those of
+	// a CSV without a column field, go to the first column.
+	var tasks, unplaced []model.Task
+	for _, col := range columns {
+		if unnamedColumn(col) {
+			unplaced = append(unplaced, col.Tasks...)
+			continue
+		}
+		board, err := queryColumns(tx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan task: %w", err)
+			return 0, err
+		}
+		target, ok := model.FindColumn(board, string(col.Status))
+		if !ok {
+			target, ok = model.FindColumn(board, col.Name)
+		}
+		if !ok {
+			name := col.Name
+			if strings.TrimSpace(name) == "" {
+				name = string(col.Status)
+			}
+			if target, err = insertColumn(tx, name, col.Status, len(board)); err != nil {
+				return 0, fmt.Errorf("failed to import column %q: %w", col.Name, err)
+			}
+			if col.Done {
+				if _, err := tx.Exec("UPDATE board_columns SET is_done = 1 WHERE status = ?", target.Status); err != nil {
+					return 0, fmt.Errorf("failed to import column %q: %w", col.Name, err)
+				}
+			}
+		}
+		for _, task := range col.Tasks {
+			task.Status = target.Status
+			tasks = append(tasks, task)
 		}
-		task.Tags = parseTags(tagsStr)
-		task.Due = parseDue(dueStr)
-		tasks = append(tasks, task)
 	}
 
-	return tasks, nil
+	if len(unplaced) > 0 {
+		board, err := queryColumns(tx)
+		if err != nil {
+			return 0, err
+		}
+		if len(board) == 0 {
+			return 0, fmt.Errorf("failed to import tasks: the board has no columns")
+		}
+		for _, task := range unplaced {
+			task.Status = board[0].Status
+			tasks = append(tasks, task)
+		}
+	}
+
+	// Columns from a document that doesn't mark its done columns replace
+	// the board's with the rightmost one done, as it was before
+	if err := ensureDoneColumn(tx); err != nil {
+		return 0, err
+	}
+	done, err := doneStatuses(tx)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	// The IDs the tasks had in the import, to keep the dependencies among them
+	imported := make(map[int64]int64, len(tasks))
+	for i, task := range tasks {
+		title := strings.TrimSpace(task.Title)
+		if title == "" {
+			return 0, fmt.Errorf("task %d: title is empty", i+1)
+		}
+		if _, ok := model.ParsePriority(string(task.Priority)); !ok {
+			return 0, fmt.Errorf("task %d (%q): unknown priority %q", i+1, title, task.Priority)
+		}
+		recurrence, err := normalizeRecurrence(task.Recurrence)
+		if err != nil {
+			return 0, fmt.Errorf("task %d (%q): %w", i+1, title, err)
+		}
+
+		createdAt, updatedAt := task.CreatedAt, task.UpdatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+		if updatedAt.IsZero() {
+			updatedAt = createdAt
+		}
+		var dueValue interface{}
+		if task.Due != nil {
+			dueValue = task.Due.Format("2006-01-02 15:04:05")
+		}
+		completed := completedAt(task.Status, done, updatedAt)
+		if completed != nil && task.CompletedAt != nil {
+			completed = task.CompletedAt
+		}
+
+		var id interface{}
+		if keepIDs && task.ID > 0 {
+			id = task.ID
+		}
+
+		result, err := tx.Exec(
+			"INSERT INTO tasks (id, title, description, due, recurrence, priority, pinned, status, source, source_id, url, position, created_at, updated_at, completed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, "+nextPositionSQL+", ?, ?, ?)",
+			id, title, task.Description, dueValue, recurrence, task.Priority, task.Pinned, task.Status, task.Source, task.SourceID, task.URL, task.Status, createdAt, updatedAt, completed,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to import task %d (%q): %w", i+1, title, err)
+		}
+		taskID, err := result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get last insert id: %w", err)
+		}
+		if err := setTaskLabels(tx, taskID, task.Tags); err != nil {
+			return 0, fmt.Errorf("failed to import task %d (%q): %w", i+1, title, err)
+		}
+		if err := insertSubtasks(tx, taskID, task.Subtasks); err != nil {
+			return 0, fmt.Errorf("failed to import task %d (%q): %w", i+1, title, err)
+		}
+		if task.ID > 0 {
+			imported[task.ID] = taskID
+		}
+	}
+	for i, task := range tasks {
+		if parentID, ok := imported[task.Parent]; ok && task.Parent > 0 {
+			if _, err := tx.Exec("UPDATE tasks SET parent_task_id = ? WHERE id = ?", parentID, imported[task.ID]); err != nil {
+				return 0, fmt.Errorf("failed to import task %d (%q): %w", i+1, task.Title, err)
+			}
+		}
+		for _, blocker := range task.BlockedBy {
+			blockerID, ok := imported[blocker]
+			if !ok {
+				continue // not part of the import
+			}
+			if err := addDependency(tx, imported[task.ID], blockerID); err != nil {
+				return 0, fmt.Errorf("task %d (%q): %w", i+1, task.Title, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return len(tasks), nil
+}
+
+// unnamedColumn reports whether an imported column has neither a name nor a
+// status to match or create a board column with
+func unnamedColumn(col model.Column) bool {
+	return col.Status == "" && strings.TrimSpace(col.Name) == ""
 }
 
 // UpdateTask updates a task
 func (db *DB) UpdateTask(id int64, title string, status model.TaskStatus) error {
-	result, err := db.conn.Exec(
-		"UPDATE tasks SET title = ?, status = ?, updated_at = ? WHERE id = ?",
-		title, status, time.Now(), id,
+	done, err := doneStatuses(db.conn)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	result, err := db.exec(
+		"UPDATE tasks SET title = ?, "+movePositionSQL+", status = ?, updated_at = ?, "+completedAtSQL+" WHERE id = ?",
+		title, status, status, status, now, done[status], now, id,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update task: %w", err)
@@ -183,31 +870,214 @@ func (db *DB) UpdateTask(id int64, title string, status model.TaskStatus) error
 	return nil
 }
 
-// UpdateTaskStatus updates only the status of a task
-func (db *DB) UpdateTaskStatus(id int64, status model.TaskStatus) error {
-	result, err := db.conn.Exec(
-		"UPDATE tasks SET status = ?, updated_at = ? WHERE id = ?",
-		status, time.Now(), id,
+// UpdateTaskStatus updates only the status of a task. With strict WIP limits
+// on, moving a task into a full column fails with ErrWIPLimitExceeded. When
+// a recurring task moves into the done column its next occurrence is created
+// and returned; otherwise the returned task is nil. Tasks in the trash or
+// the archive aren't moved.
+func (db *DB) UpdateTaskStatus(id int64, status model.TaskStatus) (*model.Task, error) {
+	task, err := db.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := onBoard(task); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update task status: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkWIPLimit(tx, id, status); err != nil {
+		return nil, err
+	}
+	done, err := doneStatuses(tx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result, err := tx.Exec(
+		"UPDATE tasks SET "+movePositionSQL+", status = ?, updated_at = ?, "+completedAtSQL+" WHERE id = ? AND "+activeTaskSQL,
+		status, status, status, now, done[status], now, id,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update task status: %w", err)
+		return nil, fmt.Errorf("failed to update task status: %w", err)
 	}
 
 	rows, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("task not found")
+		return nil, fmt.Errorf("task not found")
 	}
 
+	var next *model.Task
+	if done[status] && !done[task.Status] && task.Recurrence != "" {
+		if next, err = repeatTask(tx, task, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update task status: %w", err)
+	}
+	return next, nil
+}
+
+// onBoard fails for a task in the trash or the archive
+func onBoard(task *model.Task) error {
+	switch {
+	case task.DeletedAt != nil:
+		return fmt.Errorf("task %d is in the trash", task.ID)
+	case task.ArchivedAt != nil:
+		return fmt.Errorf("task %d is in the archive", task.ID)
+	}
 	return nil
 }
 
+// SwapTaskPositions exchanges the positions of two tasks in the same column.
+// The column is renumbered first, so positions stay unique however quickly
+// reorders follow each other.
+func (db *DB) SwapTaskPositions(id, otherID int64) error {
+	return db.inTx("reorder tasks", func(tx *Tx) error { return tx.SwapTaskPositions(id, otherID) })
+}
+
+// SwapTaskPositions exchanges the positions of two tasks like
+// DB.SwapTaskPositions does
+func (tx *Tx) SwapTaskPositions(id, otherID int64) error {
+	var status, otherStatus model.TaskStatus
+	if err := tx.tx.QueryRow("SELECT status FROM tasks WHERE id = ?", id).Scan(&status); err != nil {
+		return fmt.Errorf("task %d not found", id)
+	}
+	if err := tx.tx.QueryRow("SELECT status FROM tasks WHERE id = ?", otherID).Scan(&otherStatus); err != nil {
+		return fmt.Errorf("task %d not found", otherID)
+	}
+	if status != otherStatus {
+		return fmt.Errorf("tasks %d and %d are in different columns", id, otherID)
+	}
+
+	rows, err := tx.tx.Query("SELECT id FROM tasks WHERE status = ? ORDER BY position, id", status)
+	if err != nil {
+		return fmt.Errorf("failed to query tasks: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var taskID int64
+		if err := rows.Scan(&taskID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan task: %w", err)
+		}
+		ids = append(ids, taskID)
+	}
+	rows.Close()
+
+	for i, taskID := range ids {
+		switch taskID {
+		case id:
+			taskID = otherID
+		case otherID:
+			taskID = id
+		}
+		if _, err := tx.tx.Exec("UPDATE tasks SET position = ? WHERE id = ?", i, taskID); err != nil {
+			return fmt.Errorf("failed to reorder tasks: %w", err)
+		}
+	}
+	return nil
+}
+
+// ErrConflict is returned when an edit was made on an older version of a
+// task than the one stored, so writing it would undo the changes between
+var ErrConflict = errors.New("task changed elsewhere")
+
+// ConflictError is the ErrConflict of an edit: Task is the task as stored
+// now, and Version the version the edit was made on
+type ConflictError struct {
+	Task    model.Task
+	Version int64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%v: task %d is at version %d, the edit was made on version %d", ErrConflict, e.Task.ID, e.Task.Version, e.Version)
+}
+
+// Is makes errors.Is(err, ErrConflict) match a ConflictError
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// UpdateTaskFields writes the title, description, column, priority, due
+// date and tags of task over the stored task with its ID, in one transaction.
+// Like UpdateTaskStatus, a move into a full column fails under strict WIP
+// limits, and a recurring task moving into the done column returns its next
+// occurrence; otherwise the returned task is nil. When task has a version
+// and the stored task another, nothing is written and a *ConflictError is
+// returned.
+func (db *DB) UpdateTaskFields(task model.Task) (*model.Task, error) {
+	var next *model.Task
+	err := db.inTx("update task", func(tx *Tx) error {
+		var err error
+		next, err = tx.UpdateTaskFields(task)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// UpdateTaskFields writes the fields of task over the stored task like
+// DB.UpdateTaskFields does
+func (tx *Tx) UpdateTaskFields(task model.Task) (*model.Task, error) {
+	stored, err := tx.GetTask(task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Version != 0 && task.Version != stored.Version {
+		return nil, &ConflictError{Task: *stored, Version: task.Version}
+	}
+
+	if task.Status != stored.Status {
+		if err := checkWIPLimit(tx.tx, task.ID, task.Status); err != nil {
+			return nil, err
+		}
+	}
+	done, err := doneStatuses(tx.tx)
+	if err != nil {
+		return nil, err
+	}
+	var dueValue interface{}
+	if task.Due != nil {
+		dueValue = task.Due.Format("2006-01-02 15:04:05")
+	}
+	now := time.Now()
+	if _, err := tx.tx.Exec(
+		"UPDATE tasks SET title = ?, description = ?, priority = ?, due = ?, "+movePositionSQL+", status = ?, updated_at = ?, "+completedAtSQL+" WHERE id = ?",
+		task.Title, task.Description, task.Priority, dueValue, task.Status, task.Status, task.Status, now, done[task.Status], now, task.ID,
+	); err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+	if err := setTaskLabels(tx.tx, task.ID, task.Tags); err != nil {
+		return nil, err
+	}
+
+	var next *model.Task
+	if done[task.Status] && !done[stored.Status] && stored.Recurrence != "" {
+		updated := *stored
+		updated.Title, updated.Description, updated.Priority, updated.Due, updated.Tags = task.Title, task.Description, task.Priority, task.Due, task.Tags
+		if next, err = repeatTask(tx.tx, &updated, now); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
 // UpdateTaskDescription updates only the description of a task
 func (db *DB) UpdateTaskDescription(id int64, description string) error {
-	result, err := db.conn.Exec(
+	result, err := db.exec(
 		"UPDATE tasks SET description = ?, updated_at = ? WHERE id = ?",
 		description, time.Now(), id,
 	)
@@ -227,11 +1097,14 @@ func (db *DB) UpdateTaskDescription(id int64, description string) error {
 	return nil
 }
 
-// DeleteTask deletes a task
-func (db *DB) DeleteTask(id int64) error {
-	result, err := db.conn.Exec("DELETE FROM tasks WHERE id = ?", id)
+// SetTaskPinned pins a task above the others of its column, or unpins it
+func (db *DB) SetTaskPinned(id int64, pinned bool) error {
+	result, err := db.exec(
+		"UPDATE tasks SET pinned = ?, updated_at = ? WHERE id = ?",
+		pinned, time.Now(), id,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
+		return fmt.Errorf("failed to pin task: %w", err)
 	}
 
 	rows, err := result.RowsAffected()
@@ -246,15 +1119,14 @@ func (db *DB) DeleteTask(id int64) error {
 	return nil
 }
 
-// UpdateTaskTags updates only the tags of a task
-func (db *DB) UpdateTaskTags(id int64, tags []string) error {
-	tagsStr := tagsToString(tags)
-	result, err := db.conn.Exec(
-		"UPDATE tasks SET tags = ?, updated_at = ? WHERE id = ?",
-		tagsStr, time.Now(), id,
+// UpdateTaskPriority updates only the priority of a task
+func (db *DB) UpdateTaskPriority(id int64, priority model.TaskPriority) error {
+	result, err := db.exec(
+		"UPDATE tasks SET priority = ?, updated_at = ? WHERE id = ?",
+		priority, time.Now(), id,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update task tags: %w", err)
+		return fmt.Errorf("failed to update task priority: %w", err)
 	}
 
 	rows, err := result.RowsAffected()
@@ -269,34 +1141,130 @@ func (db *DB) UpdateTaskTags(id int64, tags []string) error {
 	return nil
 }
 
-// parseTags converts comma-separated string to slice
-func parseTags(tagsStr string) []string {
-	if tagsStr == "" {
-		return []string{}
+// DeleteTask moves a task to the trash. It can be brought back with
+// RestoreFromTrash until it is purged.
+func (db *DB) DeleteTask(id int64) error {
+	return db.inTx("delete task", func(tx *Tx) error { return tx.DeleteTask(id) })
+}
+
+// DeleteTask moves a task to the trash
+func (tx *Tx) DeleteTask(id int64) error {
+	result, err := tx.tx.Exec("UPDATE tasks SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
 	}
-	parts := strings.Split(tagsStr, ",")
-	var tags []string
-	for _, p := range parts {
-		t := strings.TrimSpace(strings.ToLower(p))
-		if t != "" {
-			tags = append(tags, t)
-		}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-	return tags
+
+	if rows == 0 {
+		return fmt.Errorf("task not found")
+	}
+
+	return nil
 }
 
-// tagsToString converts slice to comma-separated string
-func tagsToString(tags []string) string {
-	var cleaned []string
-	seen := make(map[string]bool)
-	for _, t := range tags {
-		t = strings.TrimSpace(strings.ToLower(t))
-		if t != "" && !seen[t] {
-			cleaned = append(cleaned, t)
-			seen[t] = true
+// RestoreTask writes a task snapshot back under its ID, including its tags
+// and checklist. A deleted task is re-inserted; an existing one is overwritten.
+func (db *DB) RestoreTask(task model.Task) error {
+	return db.inTx("restore task", func(tx *Tx) error { return restoreTask(tx.tx, task) })
+}
+
+// restoreTask writes a task snapshot back within a transaction
+func restoreTask(tx *sql.Tx, task model.Task) error {
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM board_columns WHERE status = ?", task.Status).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to look up column: %w", err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("column %q no longer exists", task.Status)
+	}
+
+	var dueValue interface{}
+	if task.Due != nil {
+		dueValue = task.Due.Format("2006-01-02 15:04:05")
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO tasks (id, title, description, due, recurrence, priority, status, source, source_id, url, parent_task_id, position, created_at, updated_at, completed_at, deleted_at, archived_at, snoozed_until, woke_at, pinned)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, (SELECT id FROM tasks WHERE id = ?), ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET title = excluded.title, description = excluded.description, due = excluded.due, recurrence = excluded.recurrence,
+			priority = excluded.priority, status = excluded.status, source = excluded.source, source_id = excluded.source_id, url = excluded.url,
+			parent_task_id = excluded.parent_task_id, position = excluded.position,
+			created_at = excluded.created_at, updated_at = excluded.updated_at, completed_at = excluded.completed_at,
+			deleted_at = excluded.deleted_at, archived_at = excluded.archived_at, snoozed_until = excluded.snoozed_until, woke_at = excluded.woke_at,
+			pinned = excluded.pinned`,
+		task.ID, task.Title, task.Description, dueValue, task.Recurrence, task.Priority, task.Status, task.Source, task.SourceID, task.URL, task.Parent, task.Position, task.CreatedAt, task.UpdatedAt, task.CompletedAt, task.DeletedAt, task.ArchivedAt, snoozeValue(task.SnoozedUntil), task.WokeAt, task.Pinned,
+	); err != nil {
+		return fmt.Errorf("failed to restore task: %w", err)
+	}
+
+	if err := setTaskLabels(tx, task.ID, task.Tags); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM subtasks WHERE task_id = ?", task.ID); err != nil {
+		return fmt.Errorf("failed to restore subtasks: %w", err)
+	}
+	if err := insertSubtasks(tx, task.ID, task.Subtasks); err != nil {
+		return err
+	}
+	return setDependencies(tx, task.ID, task.BlockedBy)
+}
+
+// DeleteAllTasks deletes every task with its activity and logged time in
+// one transaction, keeping the rest of the board intact, and starts the
+// task IDs over. The file is compacted after, so nothing of the tasks is
+// left in its free pages or the search index.
+func (db *DB) DeleteAllTasks() error {
+	err := db.inTx("delete tasks", func(tx *Tx) error {
+		if _, err := tx.tx.Exec("DELETE FROM task_labels"); err != nil {
+			return fmt.Errorf("failed to delete task labels: %w", err)
+		}
+		if _, err := tx.tx.Exec("DELETE FROM subtasks"); err != nil {
+			return fmt.Errorf("failed to delete subtasks: %w", err)
+		}
+		if _, err := tx.tx.Exec("DELETE FROM time_entries"); err != nil {
+			return fmt.Errorf("failed to delete time entries: %w", err)
+		}
+		if _, err := tx.tx.Exec("DELETE FROM due_reminders"); err != nil {
+			return fmt.Errorf("failed to delete due reminders: %w", err)
+		}
+		if _, err := tx.tx.Exec("DELETE FROM task_dependencies"); err != nil {
+			return fmt.Errorf("failed to delete dependencies: %w", err)
+		}
+		if _, err := tx.tx.Exec("DELETE FROM tasks"); err != nil {
+			return fmt.Errorf("failed to delete tasks: %w", err)
 		}
+		if _, err := tx.tx.Exec("DELETE FROM activity"); err != nil {
+			return fmt.Errorf("failed to delete activity: %w", err)
+		}
+		// The board state holds the selected task and the search query
+		if _, err := tx.tx.Exec("DELETE FROM board_state"); err != nil {
+			return fmt.Errorf("failed to delete board state: %w", err)
+		}
+		if _, err := tx.tx.Exec("DELETE FROM sqlite_sequence WHERE name IN ('tasks', 'subtasks', 'time_entries', 'activity')"); err != nil {
+			return fmt.Errorf("failed to reset task IDs: %w", err)
+		}
+		if err := resetBoardID(tx.tx); err != nil {
+			return err
+		}
+		// The webhooks' places in the activity log, which starts over too
+		if _, err := tx.tx.Exec("DELETE FROM webhook_cursors"); err != nil {
+			return fmt.Errorf("failed to delete webhook cursors: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	return strings.Join(cleaned, ",")
+	if _, err := db.ensureSearchIndex(); err != nil {
+		return err
+	}
+	if _, err := db.exec("VACUUM"); err != nil {
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	return nil
 }
 
 // parseDue converts nullable string to *time.Time
@@ -327,7 +1295,7 @@ func (db *DB) UpdateTaskDue(id int64, due *time.Time) error {
 		dueValue = nil
 	}
 
-	result, err := db.conn.Exec(
+	result, err := db.exec(
 		"UPDATE tasks SET due = ?, updated_at = ? WHERE id = ?",
 		dueValue, time.Now(), id,
 	)
@@ -346,3 +1314,22 @@ func (db *DB) UpdateTaskDue(id int64, due *time.Time) error {
 
 	return nil
 }
+
+// UpdateTaskURL sets a task's link; an empty url removes it
+func (db *DB) UpdateTaskURL(id int64, url string) error {
+	result, err := db.exec("UPDATE tasks SET url = ?, updated_at = ? WHERE id = ?", url, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update task url: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("task not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// ColumnStats summarizes the tasks of one column
+type ColumnStats struct {
+	Name   string
+	Status model.TaskStatus
+	Count  int
+	Stale  int         // tasks untouched since the stale cutoff; always 0 in the done column
+	Oldest *model.Task // task created first, nil for an empty column
+}
+
+// WeekStats counts the tasks completed in the week starting on Start (a Monday)
+type WeekStats struct {
+	Start     time.Time
+	Completed int
+}
+
+// Stats summarizes a board and what was completed since a point in time
+type Stats struct {
+	Since     time.Time
+	Columns   []ColumnStats
+	Weeks     []WeekStats   // oldest first, including weeks without completions
+	Completed int           // tasks completed since Since, archived ones included
+	CycleTime time.Duration // average time from creation to completion of those tasks
+}
+
+// DefaultStaleAfter is how long a task may go untouched before it is stale
+const DefaultStaleAfter = "14d"
+
+// StaleBefore returns the time before which a task last updated is stale,
+// for an age such as 14d or 3w. Empty means DefaultStaleAfter; "never"
+// returns the zero time, so no task is stale.
+func StaleBefore(after string, now time.Time) (time.Time, error) {
+	switch after {
+	case "":
+		after = DefaultStaleAfter
+	case "never":
+		return time.Time{}, nil
+	}
+	return dates.Ago(after, now)
+}
+
+// IsStale reports whether a task outside the done column was last updated
+// before the stale cutoff from StaleBefore
+func IsStale(task model.Task, done bool, staleBefore time.Time) bool {
+	return !done && task.UpdatedAt.Before(staleBefore)
+}
+
+// Stats returns the column counts of the board, with the tasks last updated
+// before staleBefore counted as stale, and the throughput and cycle time of
+// the tasks completed since the given time
+func (db *DB) Stats(since, staleBefore, now time.Time) (Stats, error) {
+	stats := Stats{Since: since}
+
+	columns, err := db.GetBoard()
+	if err != nil {
+		return stats, err
+	}
+	for _, col := range columns {
+		cs := ColumnStats{Name: col.Name, Status: col.Status, Count: len(col.Tasks)}
+		for i := range col.Tasks {
+			if cs.Oldest == nil || col.Tasks[i].CreatedAt.Before(cs.Oldest.CreatedAt) {
+				cs.Oldest = &col.Tasks[i]
+			}
+			if IsStale(col.Tasks[i], col.Done, staleBefore) {
+				cs.Stale++
+			}
+		}
+		stats.Columns = append(stats.Columns, cs)
+	}
+
+	rows, err := db.conn.Query("SELECT created_at, completed_at FROM tasks WHERE completed_at >= ? AND deleted_at IS NULL", since)
+	if err != nil {
+		return stats, fmt.Errorf("failed to query completed tasks: %w", err)
+	}
+	defer rows.Close()
+
+	perWeek := make(map[time.Time]int)
+	var total time.Duration
+	for rows.Next() {
+		var created, completed time.Time
+		if err := rows.Scan(&created, &completed); err != nil {
+			return stats, fmt.Errorf("failed to scan completed task: %w", err)
+		}
+		stats.Completed++
+		total += completed.Sub(created)
+		perWeek[weekStart(completed.In(now.Location()))]++
+	}
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("failed to query completed tasks: %w", err)
+	}
+	if stats.Completed > 0 {
+		stats.CycleTime = total / time.Duration(stats.Completed)
+	}
+
+	for week := weekStart(since.In(now.Location())); !week.After(now); week = week.AddDate(0, 0, 7) {
+		stats.Weeks = append(stats.Weeks, WeekStats{Start: week, Completed: perWeek[week]})
+	}
+	return stats, nil
+}
+
+// weekStart returns midnight of the Monday starting t's week
+func weekStart(t time.Time) time.Time {
+	day := dates.StartOfDay(t)
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	return day.AddDate(0, 0, -offset)
+}
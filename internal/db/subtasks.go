@@ -0,0 +1,248 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// createSubtaskTables creates the checklist table
+func createSubtaskTables(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS subtasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		title TEXT NOT NULL,
+		done INTEGER NOT NULL DEFAULT 0,
+		position INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_subtasks_task ON subtasks(task_id, position);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create subtasks table: %w", err)
+	}
+	return nil
+}
+
+// loadSubtasks fills in the Subtasks of each task, in checklist order
+func loadSubtasks(ex execer, tasks []model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(tasks))
+	for i := range tasks {
+		index[tasks[i].ID] = i
+		tasks[i].Subtasks = []model.Subtask{}
+	}
+
+	where, args := onlyTasks("task_id", tasks)
+	rows, err := ex.Query("SELECT id, task_id, title, done FROM subtasks"+where+" ORDER BY task_id, position, id", args...)
+	if err != nil {
+		return fmt.Errorf("failed to query subtasks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st model.Subtask
+		var taskID int64
+		if err := rows.Scan(&st.ID, &taskID, &st.Title, &st.Done); err != nil {
+			return fmt.Errorf("failed to scan subtask: %w", err)
+		}
+		if i, ok := index[taskID]; ok {
+			tasks[i].Subtasks = append(tasks[i].Subtasks, st)
+		}
+	}
+	return rows.Err()
+}
+
+// insertSubtasks appends subtasks to a task's checklist
+func insertSubtasks(ex execer, taskID int64, subtasks []model.Subtask) error {
+	var next int
+	if err := ex.QueryRow("SELECT COALESCE(MAX(position), -1) + 1 FROM subtasks WHERE task_id = ?", taskID).Scan(&next); err != nil {
+		return fmt.Errorf("failed to get subtask position: %w", err)
+	}
+	for _, st := range subtasks {
+		title := strings.TrimSpace(st.Title)
+		if title == "" {
+			continue
+		}
+		if _, err := ex.Exec(
+			"INSERT INTO subtasks (task_id, title, done, position) VALUES (?, ?, ?, ?)",
+			taskID, title, st.Done, next,
+		); err != nil {
+			return fmt.Errorf("failed to add subtask %q: %w", title, err)
+		}
+		next++
+	}
+	return nil
+}
+
+// touchTask bumps a task's updated_at, returning an error if it doesn't exist
+func touchTask(ex execer, id int64) error {
+	result, err := ex.Exec("UPDATE tasks SET updated_at = ? WHERE id = ?", time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("task not found")
+	}
+
+	return nil
+}
+
+// AddSubtask appends a checklist item to a task
+func (db *DB) AddSubtask(taskID int64, title string) error {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return errors.New("subtask title cannot be empty")
+	}
+
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to add subtask: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := touchTask(tx, taskID); err != nil {
+		return err
+	}
+	if err := insertSubtasks(tx, taskID, []model.Subtask{{Title: title}}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add subtask: %w", err)
+	}
+	return nil
+}
+
+// subtaskParent returns the task ID owning a subtask
+func subtaskParent(ex execer, id int64) (int64, error) {
+	var taskID int64
+	err := ex.QueryRow("SELECT task_id FROM subtasks WHERE id = ?", id).Scan(&taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("subtask not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up subtask: %w", err)
+	}
+	return taskID, nil
+}
+
+// ToggleSubtask flips the done state of a checklist item
+func (db *DB) ToggleSubtask(id int64) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to toggle subtask: %w", err)
+	}
+	defer tx.Rollback()
+
+	taskID, err := subtaskParent(tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE subtasks SET done = NOT done WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to toggle subtask: %w", err)
+	}
+	if err := touchTask(tx, taskID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to toggle subtask: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubtask removes a checklist item
+func (db *DB) DeleteSubtask(id int64) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete subtask: %w", err)
+	}
+	defer tx.Rollback()
+
+	taskID, err := subtaskParent(tx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM subtasks WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete subtask: %w", err)
+	}
+	if err := touchTask(tx, taskID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete subtask: %w", err)
+	}
+	return nil
+}
+
+// MoveSubtask moves a checklist item up (delta < 0) or down (delta > 0) by one place
+func (db *DB) MoveSubtask(id int64, delta int) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to move subtask: %w", err)
+	}
+	defer tx.Rollback()
+
+	taskID, err := subtaskParent(tx, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query("SELECT id FROM subtasks WHERE task_id = ? ORDER BY position, id", taskID)
+	if err != nil {
+		return fmt.Errorf("failed to query subtasks: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var sid int64
+		if err := rows.Scan(&sid); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan subtask: %w", err)
+		}
+		ids = append(ids, sid)
+	}
+	rows.Close()
+
+	from := -1
+	for i, sid := range ids {
+		if sid == id {
+			from = i
+		}
+	}
+	to := from + delta
+	if from < 0 || to < 0 || to >= len(ids) {
+		return nil
+	}
+	ids[from], ids[to] = ids[to], ids[from]
+
+	// Renumber the whole checklist so positions stay dense and unique
+	for pos, sid := range ids {
+		if _, err := tx.Exec("UPDATE subtasks SET position = ? WHERE id = ?", pos, sid); err != nil {
+			return fmt.Errorf("failed to move subtask: %w", err)
+		}
+	}
+	if err := touchTask(tx, taskID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to move subtask: %w", err)
+	}
+	return nil
+}
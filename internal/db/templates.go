@@ -0,0 +1,128 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// createTemplateTable creates the table of task templates. Tags and checklist
+// items are stored as JSON arrays.
+func createTemplateTable(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS task_templates (
+		name TEXT PRIMARY KEY COLLATE NOCASE,
+		title TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		priority TEXT NOT NULL DEFAULT '',
+		tags TEXT NOT NULL DEFAULT '[]',
+		subtasks TEXT NOT NULL DEFAULT '[]',
+		created_at DATETIME NOT NULL
+	);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create task templates table: %w", err)
+	}
+	return nil
+}
+
+// SaveTemplate stores a template, replacing any other of the same name
+func (db *DB) SaveTemplate(t model.Template) error {
+	name := strings.TrimSpace(t.Name)
+	if name == "" {
+		return errors.New("template name cannot be empty")
+	}
+	title := strings.TrimSpace(t.Title)
+	if title == "" {
+		return errors.New("template title cannot be empty")
+	}
+	tags, err := json.Marshal(cleanTags(t.Tags))
+	if err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+	subtasks := []string{}
+	for _, st := range t.Subtasks {
+		if st = strings.TrimSpace(st); st != "" {
+			subtasks = append(subtasks, st)
+		}
+	}
+	items, err := json.Marshal(subtasks)
+	if err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+
+	if _, err := db.exec(
+		`INSERT INTO task_templates (name, title, description, priority, tags, subtasks, created_at) VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name) DO UPDATE SET name = excluded.name, title = excluded.title, description = excluded.description,
+			priority = excluded.priority, tags = excluded.tags, subtasks = excluded.subtasks`,
+		name, title, t.Description, t.Priority, string(tags), string(items),
+	); err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+	return nil
+}
+
+// GetTemplates returns all templates ordered by name
+func (db *DB) GetTemplates() ([]model.Template, error) {
+	return queryTemplates(db.conn, "ORDER BY name")
+}
+
+// GetTemplate returns the template with the given name, ignoring case
+func (db *DB) GetTemplate(name string) (*model.Template, error) {
+	templates, err := queryTemplates(db.conn, "WHERE name = ?", strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("template %q not found", name)
+	}
+	return &templates[0], nil
+}
+
+// DeleteTemplate deletes the template with the given name, ignoring case
+func (db *DB) DeleteTemplate(name string) error {
+	result, err := db.exec("DELETE FROM task_templates WHERE name = ?", strings.TrimSpace(name))
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("template %q not found", name)
+	}
+	return nil
+}
+
+// queryTemplates selects templates; where follows the FROM clause
+func queryTemplates(ex execer, where string, args ...interface{}) ([]model.Template, error) {
+	rows, err := ex.Query("SELECT name, title, description, priority, tags, subtasks FROM task_templates "+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []model.Template{}
+	for rows.Next() {
+		var t model.Template
+		var tags, subtasks string
+		if err := rows.Scan(&t.Name, &t.Title, &t.Description, &t.Priority, &tags, &subtasks); err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		if err := json.Unmarshal([]byte(tags), &t.Tags); err != nil {
+			return nil, fmt.Errorf("template %s has invalid tags: %w", t.Name, err)
+		}
+		if err := json.Unmarshal([]byte(subtasks), &t.Subtasks); err != nil {
+			return nil, fmt.Errorf("template %s has an invalid checklist: %w", t.Name, err)
+		}
+		templates = append(templates, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	return templates, nil
+}
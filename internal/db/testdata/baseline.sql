@@ -0,0 +1,16 @@
+PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		description TEXT DEFAULT '',
+		status TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	, tags TEXT DEFAULT '', due DATETIME DEFAULT NULL);
+INSERT INTO tasks VALUES(1,'first','notes','todo','2026-10-15 00:02:39.783061329+00:00','2026-10-15 00:02:39.783988231+00:00','home,work','2025-03-14 00:00:00');
+INSERT INTO tasks VALUES(2,'second','','done','2026-10-15 00:02:39.784248672+00:00','2026-10-15 00:02:39.784248672+00:00','',NULL);
+INSERT INTO tasks VALUES(3,'third','','in_progress','2026-10-15 00:02:39.784550253+00:00','2026-10-15 00:02:39.784550253+00:00','',NULL);
+INSERT INTO sqlite_sequence VALUES('tasks',3);
+CREATE INDEX idx_tasks_status ON tasks(status);
+COMMIT;
@@ -0,0 +1,141 @@
+PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		description TEXT DEFAULT '',
+		status TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	, tags TEXT DEFAULT '', due DATETIME DEFAULT NULL, priority TEXT DEFAULT '', position INTEGER NOT NULL DEFAULT 0, completed_at DATETIME DEFAULT NULL, deleted_at DATETIME DEFAULT NULL, archived_at DATETIME DEFAULT NULL);
+INSERT INTO tasks VALUES(1,'first','notes','todo','2026-10-15 00:02:40.163712333+00:00','2026-10-15 00:02:40.164128634+00:00','','2025-03-14 00:00:00','high',0,NULL,NULL,NULL);
+INSERT INTO tasks VALUES(2,'second','','done','2026-10-15 00:02:40.164185112+00:00','2026-10-15 00:02:40.164185112+00:00','',NULL,'',0,'2026-10-15 00:02:40.164185112+00:00',NULL,NULL);
+INSERT INTO tasks VALUES(3,'third','','review','2026-10-15 00:02:40.16438866+00:00','2026-10-15 00:02:40.16438866+00:00','',NULL,'',0,NULL,NULL,NULL);
+INSERT INTO tasks VALUES(4,'trashed','','todo','2026-10-15 00:02:40.164453901+00:00','2026-10-15 00:02:40.164453901+00:00','',NULL,'',-1,NULL,'2026-10-15 00:02:40.164500427+00:00',NULL);
+INSERT INTO tasks VALUES(5,'archived','','done','2026-10-15 00:02:40.164540803+00:00','2026-10-15 00:02:40.164586778+00:00','',NULL,'',-1,'2026-10-15 00:02:40.164540803+00:00',NULL,'2026-10-15 00:02:40.164586778+00:00');
+CREATE TABLE board_columns (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		status TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		position INTEGER NOT NULL
+	, wip_limit INTEGER NOT NULL DEFAULT 0);
+INSERT INTO board_columns VALUES(1,'todo','Todo',0,0);
+INSERT INTO board_columns VALUES(2,'in_progress','In Progress',1,0);
+INSERT INTO board_columns VALUES(3,'done','Done',3,0);
+INSERT INTO board_columns VALUES(4,'review','Review',2,0);
+CREATE TABLE settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+CREATE TABLE labels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE
+	);
+INSERT INTO labels VALUES(1,'home');
+INSERT INTO labels VALUES(2,'work');
+CREATE TABLE task_labels (
+		task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		label_id INTEGER NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+		PRIMARY KEY (task_id, label_id)
+	);
+INSERT INTO task_labels VALUES(1,1);
+INSERT INTO task_labels VALUES(1,2);
+CREATE TABLE subtasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		title TEXT NOT NULL,
+		done INTEGER NOT NULL DEFAULT 0,
+		position INTEGER NOT NULL DEFAULT 0
+	);
+INSERT INTO subtasks VALUES(1,1,'step',1,0);
+CREATE TABLE sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pid INTEGER NOT NULL,
+		heartbeat_at INTEGER NOT NULL
+	);
+CREATE TABLE revision (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		value INTEGER NOT NULL
+	);
+INSERT INTO revision VALUES(1,24);
+INSERT INTO sqlite_sequence VALUES('board_columns',4);
+INSERT INTO sqlite_sequence VALUES('tasks',5);
+INSERT INTO sqlite_sequence VALUES('labels',2);
+INSERT INTO sqlite_sequence VALUES('subtasks',1);
+CREATE TRIGGER revision_tasks_INSERT AFTER INSERT ON tasks
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_tasks_UPDATE AFTER UPDATE ON tasks
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_tasks_DELETE AFTER DELETE ON tasks
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_board_columns_INSERT AFTER INSERT ON board_columns
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_board_columns_UPDATE AFTER UPDATE ON board_columns
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_board_columns_DELETE AFTER DELETE ON board_columns
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_labels_INSERT AFTER INSERT ON labels
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_labels_UPDATE AFTER UPDATE ON labels
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_labels_DELETE AFTER DELETE ON labels
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_task_labels_INSERT AFTER INSERT ON task_labels
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_task_labels_UPDATE AFTER UPDATE ON task_labels
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_task_labels_DELETE AFTER DELETE ON task_labels
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_subtasks_INSERT AFTER INSERT ON subtasks
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_subtasks_UPDATE AFTER UPDATE ON subtasks
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_subtasks_DELETE AFTER DELETE ON subtasks
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_settings_INSERT AFTER INSERT ON settings
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_settings_UPDATE AFTER UPDATE ON settings
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE TRIGGER revision_settings_DELETE AFTER DELETE ON settings
+			BEGIN
+				UPDATE revision SET value = value + 1 WHERE id = 1;
+			END;
+CREATE INDEX idx_tasks_status ON tasks(status);
+CREATE INDEX idx_task_labels_label ON task_labels(label_id);
+CREATE INDEX idx_subtasks_task ON subtasks(task_id, position);
+CREATE INDEX idx_tasks_board ON tasks(status, position, id) WHERE deleted_at IS NULL AND archived_at IS NULL;
+COMMIT;
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/dates"
+)
+
+// TimeEntry is a span of time logged on a task. End is nil while the timer runs.
+type TimeEntry struct {
+	ID     int64      `json:"id"`
+	TaskID int64      `json:"task_id"`
+	Title  string     `json:"title"` // title of the task
+	Start  time.Time  `json:"start"`
+	End    *time.Time `json:"end,omitempty"`
+}
+
+// Duration returns the time logged by the entry, up to now while it runs
+func (e TimeEntry) Duration(now time.Time) time.Duration {
+	end := now
+	if e.End != nil {
+		end = *e.End
+	}
+	if end.Before(e.Start) {
+		return 0
+	}
+	return end.Sub(e.Start)
+}
+
+// createTimeEntryTable creates the table of time logged on tasks
+func createTimeEntryTable(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS time_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		started_at DATETIME NOT NULL,
+		ended_at DATETIME DEFAULT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_time_entries_task ON time_entries(task_id);
+	CREATE INDEX IF NOT EXISTS idx_time_entries_started ON time_entries(started_at);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create time entries table: %w", err)
+	}
+	return nil
+}
+
+// StartTimer starts a timer on a task. Only one timer runs at a time, so a
+// timer running on another task is stopped first.
+func (db *DB) StartTimer(taskID int64) (*TimeEntry, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+	defer tx.Rollback()
+
+	var title string
+	if err := tx.QueryRow("SELECT title FROM tasks WHERE id = ? AND deleted_at IS NULL", taskID).Scan(&title); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("task %d not found", taskID)
+		}
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec("UPDATE time_entries SET ended_at = ? WHERE ended_at IS NULL", now); err != nil {
+		return nil, fmt.Errorf("failed to stop the running timer: %w", err)
+	}
+	result, err := tx.Exec("INSERT INTO time_entries (task_id, started_at) VALUES (?, ?)", taskID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+	return &TimeEntry{ID: id, TaskID: taskID, Title: title, Start: now}, nil
+}
+
+// StopTimer stops the running timer and returns its entry, or nil when no
+// timer was running
+func (db *DB) StopTimer() (*TimeEntry, error) {
+	running, err := db.RunningTimer()
+	if err != nil || running == nil {
+		return nil, err
+	}
+	now := time.Now()
+	if _, err := db.exec("UPDATE time_entries SET ended_at = ? WHERE id = ?", now, running.ID); err != nil {
+		return nil, fmt.Errorf("failed to stop timer: %w", err)
+	}
+	running.End = &now
+	return running, nil
+}
+
+// RunningTimer returns the entry of the running timer, or nil when none runs
+func (db *DB) RunningTimer() (*TimeEntry, error) {
+	entries, err := queryTimeEntries(db.conn, "WHERE e.ended_at IS NULL ORDER BY e.started_at DESC LIMIT 1")
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// TaskTimeLogged returns the total time logged on a task by stopped timers
+func (db *DB) TaskTimeLogged(taskID int64) (time.Duration, error) {
+	entries, err := queryTimeEntries(db.conn, "WHERE e.task_id = ? AND e.ended_at IS NOT NULL", taskID)
+	if err != nil {
+		return 0, err
+	}
+	var total time.Duration
+	for _, e := range entries {
+		total += e.Duration(*e.End)
+	}
+	return total, nil
+}
+
+// GetTimeEntries returns the time entries that end after since (or are still
+// running), oldest first
+func (db *DB) GetTimeEntries(since time.Time) ([]TimeEntry, error) {
+	return queryTimeEntries(db.conn, "WHERE e.ended_at IS NULL OR e.ended_at > ? ORDER BY e.started_at, e.id", since)
+}
+
+// queryTimeEntries selects time entries e with the title of their task t;
+// where follows the FROM clause
+func queryTimeEntries(ex execer, where string, args ...interface{}) ([]TimeEntry, error) {
+	rows, err := ex.Query("SELECT e.id, e.task_id, t.title, e.started_at, e.ended_at FROM time_entries e JOIN tasks t ON t.id = e.task_id "+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query time entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []TimeEntry
+	for rows.Next() {
+		var e TimeEntry
+		var end sql.NullTime
+		if err := rows.Scan(&e.ID, &e.TaskID, &e.Title, &e.Start, &end); err != nil {
+			return nil, fmt.Errorf("failed to scan time entry: %w", err)
+		}
+		if end.Valid {
+			e.End = &end.Time
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query time entries: %w", err)
+	}
+	return entries, nil
+}
+
+// Timesheet is the time logged since a day, totalled per task and per day
+type Timesheet struct {
+	Since time.Time
+	Tasks []TaskTime // most time first
+	Days  []DayTime  // oldest first
+	Total time.Duration
+}
+
+// TaskTime is the time logged on a task
+type TaskTime struct {
+	ID     int64
+	Title  string
+	Logged time.Duration
+}
+
+// DayTime is the time logged on a calendar day
+type DayTime struct {
+	Day    time.Time
+	Logged time.Duration
+}
+
+// GetTimesheet totals the time logged from since until now. Entries spanning
+// midnight count towards each day they cover, and a running timer counts up
+// to now.
+func (db *DB) GetTimesheet(since, now time.Time) (Timesheet, error) {
+	sheet := Timesheet{Since: since}
+	entries, err := db.GetTimeEntries(since)
+	if err != nil {
+		return sheet, err
+	}
+
+	tasks := map[int64]int{} // task ID to index in sheet.Tasks
+	days := map[time.Time]time.Duration{}
+	for _, e := range entries {
+		start, end := e.Start.In(now.Location()), now
+		if e.End != nil {
+			end = e.End.In(now.Location())
+		}
+		if start.Before(since) {
+			start = since
+		}
+		if !end.After(start) {
+			continue
+		}
+		logged := end.Sub(start)
+		for start.Before(end) {
+			day := dates.StartOfDay(start)
+			next := day.AddDate(0, 0, 1)
+			if next.After(end) {
+				next = end
+			}
+			days[day] += next.Sub(start)
+			start = next
+		}
+
+		i, ok := tasks[e.TaskID]
+		if !ok {
+			i = len(sheet.Tasks)
+			tasks[e.TaskID] = i
+			sheet.Tasks = append(sheet.Tasks, TaskTime{ID: e.TaskID, Title: e.Title})
+		}
+		sheet.Tasks[i].Logged += logged
+		sheet.Total += logged
+	}
+
+	sort.SliceStable(sheet.Tasks, func(i, j int) bool { return sheet.Tasks[i].Logged > sheet.Tasks[j].Logged })
+	for day, logged := range days {
+		sheet.Days = append(sheet.Days, DayTime{Day: day, Logged: logged})
+	}
+	sort.Slice(sheet.Days, func(i, j int) bool { return sheet.Days[i].Day.Before(sheet.Days[j].Day) })
+	return sheet, nil
+}
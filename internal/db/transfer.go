@@ -0,0 +1,147 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// MoveTaskTo moves a task into the column status of another workspace's
+// database, keeping its description, tags, checklist, logged time and
+// timestamps (a running timer is stopped); an empty
+// status means the first column. The copy is inserted and checked in dst
+// before the task is deleted here, and both transactions are committed only
+// once each has succeeded, so a failure leaves the task where it was. It
+// returns the task under its new ID.
+func (db *DB) MoveTaskTo(dst *DB, id int64, status model.TaskStatus) (*model.Task, error) {
+	task, err := db.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if task.DeletedAt != nil {
+		return nil, fmt.Errorf("task %d is in the trash", id)
+	}
+
+	dstTx, err := dst.begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to move task: %w", err)
+	}
+	defer dstTx.Rollback()
+
+	columns, err := queryColumns(dstTx)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("the destination workspace has no columns")
+		}
+		status = columns[0].Status
+	}
+	if _, ok := model.FindColumn(columns, string(status)); !ok {
+		return nil, fmt.Errorf("unknown column %q in the destination workspace", status)
+	}
+	if err := checkWIPLimit(dstTx, 0, status); err != nil {
+		return nil, err
+	}
+	done, err := doneStatuses(dstTx)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := *task
+	moved.Status = status
+	moved.DeletedAt = nil
+	moved.Parent = 0 // the epic stays behind
+	moved.Version = 1
+	moved.CompletedAt = completedAt(status, done, time.Now())
+	if moved.CompletedAt != nil && task.CompletedAt != nil {
+		moved.CompletedAt = task.CompletedAt
+	}
+	var dueValue interface{}
+	if task.Due != nil {
+		dueValue = task.Due.Format("2006-01-02 15:04:05")
+	}
+	result, err := dstTx.Exec(
+		"INSERT INTO tasks (title, description, due, recurrence, priority, pinned, status, source, source_id, url, position, created_at, updated_at, completed_at, archived_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, "+nextPositionSQL+", ?, ?, ?, ?)",
+		task.Title, task.Description, dueValue, task.Recurrence, task.Priority, task.Pinned, status, task.Source, task.SourceID, task.URL, status, task.CreatedAt, task.UpdatedAt, moved.CompletedAt, task.ArchivedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert task into the destination: %w", err)
+	}
+	if moved.ID, err = result.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	if err := setTaskLabels(dstTx, moved.ID, task.Tags); err != nil {
+		return nil, err
+	}
+	if err := insertSubtasks(dstTx, moved.ID, task.Subtasks); err != nil {
+		return nil, err
+	}
+	entries, err := queryTimeEntries(db.conn, "WHERE e.task_id = ? ORDER BY e.started_at", id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, e := range entries {
+		end := now
+		if e.End != nil {
+			end = *e.End
+		}
+		if _, err := dstTx.Exec("INSERT INTO time_entries (task_id, started_at, ended_at) VALUES (?, ?, ?)", moved.ID, e.Start, end); err != nil {
+			return nil, fmt.Errorf("failed to copy logged time: %w", err)
+		}
+	}
+
+	// Verify the copy before giving up the original
+	var title, description string
+	var labels, subtasks int
+	if err := dstTx.QueryRow(
+		"SELECT title, description, (SELECT COUNT(*) FROM task_labels WHERE task_id = tasks.id), (SELECT COUNT(*) FROM subtasks WHERE task_id = tasks.id) FROM tasks WHERE id = ?",
+		moved.ID,
+	).Scan(&title, &description, &labels, &subtasks); err != nil {
+		return nil, fmt.Errorf("failed to verify the moved task: %w", err)
+	}
+	if title != task.Title || description != task.Description || labels != len(cleanTags(task.Tags)) || subtasks != len(task.Subtasks) {
+		return nil, fmt.Errorf("failed to verify the moved task: the copy in the destination differs")
+	}
+
+	srcTx, err := db.begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to move task: %w", err)
+	}
+	defer srcTx.Rollback()
+	if _, err := srcTx.Exec("DELETE FROM task_labels WHERE task_id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to delete task labels: %w", err)
+	}
+	if _, err := srcTx.Exec("DELETE FROM subtasks WHERE task_id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to delete subtasks: %w", err)
+	}
+	if _, err := srcTx.Exec("DELETE FROM time_entries WHERE task_id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to delete time entries: %w", err)
+	}
+	if _, err := srcTx.Exec("DELETE FROM due_reminders WHERE task_id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to delete due reminders: %w", err)
+	}
+	if _, err := srcTx.Exec("DELETE FROM task_dependencies WHERE task_id = ? OR blocker_id = ?", id, id); err != nil {
+		return nil, fmt.Errorf("failed to delete dependencies: %w", err)
+	}
+	if _, err := srcTx.Exec("DELETE FROM tasks WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to delete task: %w", err)
+	}
+
+	if err := dstTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit the moved task: %w", err)
+	}
+	if err := srcTx.Commit(); err != nil {
+		// The copy is already committed; take it back so the task isn't duplicated
+		if _, undoErr := dst.exec("DELETE FROM tasks WHERE id = ?", moved.ID); undoErr == nil {
+			_ = deleteOrphans(dst.conn)
+		}
+		return nil, fmt.Errorf("failed to remove the task from the source: %w", err)
+	}
+
+	moved.Tags = cleanTags(task.Tags)
+	return &moved, nil
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// TrashRetention is how long deleted tasks stay in the trash before they are
+// purged when the database is opened
+const TrashRetention = 30 * 24 * time.Hour
+
+// GetTrash retrieves the deleted tasks, most recently deleted first
+func (db *DB) GetTrash() ([]model.Task, error) {
+	return queryTasks(db.conn, "SELECT "+taskColumns+" FROM tasks WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC")
+}
+
+// RestoreFromTrash brings a deleted task back to its column
+func (db *DB) RestoreFromTrash(id int64) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to restore task: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status model.TaskStatus
+	if err := tx.QueryRow("SELECT status FROM tasks WHERE id = ? AND deleted_at IS NOT NULL", id).Scan(&status); err != nil {
+		return fmt.Errorf("task %d is not in the trash", id)
+	}
+	if err := checkWIPLimit(tx, id, status); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE tasks SET deleted_at = NULL, updated_at = ? WHERE id = ?", time.Now(), id); err != nil {
+		return fmt.Errorf("failed to restore task: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to restore task: %w", err)
+	}
+	return nil
+}
+
+// PurgeTask permanently deletes a task from the trash
+func (db *DB) PurgeTask(id int64) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to purge task: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM tasks WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return fmt.Errorf("failed to purge task: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("task %d is not in the trash", id)
+	}
+	if err := deleteOrphans(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to purge task: %w", err)
+	}
+	return nil
+}
+
+// PurgeTrash permanently deletes tasks that were moved to the trash before
+// the cutoff, returning how many were removed
+func (db *DB) PurgeTrash(before time.Time) (int, error) {
+	tx, err := db.begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows > 0 {
+		if err := deleteOrphans(tx); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	return int(rows), nil
+}
+
+// deleteOrphans removes the tag links, checklist items, time entries and
+// dependencies of deleted tasks
+func deleteOrphans(ex execer) error {
+	if _, err := ex.Exec("DELETE FROM task_labels WHERE task_id NOT IN (SELECT id FROM tasks)"); err != nil {
+		return fmt.Errorf("failed to delete task labels: %w", err)
+	}
+	if _, err := ex.Exec("DELETE FROM subtasks WHERE task_id NOT IN (SELECT id FROM tasks)"); err != nil {
+		return fmt.Errorf("failed to delete subtasks: %w", err)
+	}
+	if _, err := ex.Exec("DELETE FROM time_entries WHERE task_id NOT IN (SELECT id FROM tasks)"); err != nil {
+		return fmt.Errorf("failed to delete time entries: %w", err)
+	}
+	if _, err := ex.Exec("DELETE FROM due_reminders WHERE task_id NOT IN (SELECT id FROM tasks)"); err != nil {
+		return fmt.Errorf("failed to delete due reminders: %w", err)
+	}
+	if _, err := ex.Exec("DELETE FROM task_dependencies WHERE task_id NOT IN (SELECT id FROM tasks) OR blocker_id NOT IN (SELECT id FROM tasks)"); err != nil {
+		return fmt.Errorf("failed to delete dependencies: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// Tx is a write transaction on the database. Its methods change the board
+// like the methods of DB of the same name, but nothing they change is saved
+// until the function given to WithTx returns; its reads see those changes.
+type Tx struct {
+	tx *sql.Tx
+}
+
+// WithTx runs fn in one transaction: what it changed is saved together when
+// it returns nil, and none of it when it returns an error or panics.
+// Make changes that belong together through tx, such as writing back a task
+// and deleting another, so that either all of them happen or none does.
+func (db *DB) WithTx(fn func(tx *Tx) error) error {
+	return db.inTx("save changes", fn)
+}
+
+// inTx runs fn in one transaction like WithTx does; what names the change
+// in the errors of starting and committing it
+func (db *DB) inTx(what string, fn func(tx *Tx) error) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", what, err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Tx{tx: tx}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to %s: %w", what, err)
+	}
+	return nil
+}
+
+// GetTask retrieves a single task by ID, with the changes of the transaction
+func (tx *Tx) GetTask(id int64) (*model.Task, error) {
+	return getTask(tx.tx, id)
+}
+
+// GetTasks retrieves the tasks with the given IDs like DB.GetTasks does,
+// with the changes of the transaction
+func (tx *Tx) GetTasks(ids []int64) ([]model.Task, error) {
+	return getTasks(tx.tx, ids)
+}
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// newTestDB opens an empty board held in memory, closed with the test
+func newTestDB(t testing.TB) *DB {
+	t.Helper()
+	database, err := NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+// createTasks creates tasks with the given titles in the todo column
+func createTasks(t *testing.T, database *DB, titles ...string) []model.Task {
+	t.Helper()
+	drafts := make([]model.Task, len(titles))
+	for i, title := range titles {
+		drafts[i] = model.Task{Title: title, Status: model.StatusTodo, Tags: []string{"kept"}, Subtasks: []model.Subtask{{Title: "step"}}}
+	}
+	tasks, err := database.CreateTasks(drafts)
+	if err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+	return tasks
+}
+
+// boardTitles returns the titles of the tasks on the board by column
+func boardTitles(t *testing.T, database *DB) map[model.TaskStatus][]string {
+	t.Helper()
+	columns, err := database.GetBoard()
+	if err != nil {
+		t.Fatalf("GetBoard: %v", err)
+	}
+	titles := map[model.TaskStatus][]string{}
+	for _, col := range columns {
+		for _, task := range col.Tasks {
+			titles[col.Status] = append(titles[col.Status], task.Title)
+		}
+	}
+	return titles
+}
+
+func assertTitles(t *testing.T, got map[model.TaskStatus][]string, status model.TaskStatus, want ...string) {
+	t.Helper()
+	if len(got[status]) != len(want) {
+		t.Fatalf("%s column holds %q, want %q", status, got[status], want)
+	}
+	for i := range want {
+		if got[status][i] != want[i] {
+			t.Fatalf("%s column holds %q, want %q", status, got[status], want)
+		}
+	}
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	database := newTestDB(t)
+	tasks := createTasks(t, database, "write back", "delete")
+	injected := errors.New("injected")
+
+	err := database.WithTx(func(tx *Tx) error {
+		task := tasks[0]
+		task.Title = "written back"
+		if _, err := tx.UpdateTaskFields(task); err != nil {
+			return err
+		}
+		if err := tx.DeleteTask(tasks[1].ID); err != nil {
+			return err
+		}
+		if _, err := tx.CreateTasks([]model.Task{{Title: "created", Status: model.StatusTodo}}); err != nil {
+			return err
+		}
+		// The transaction sees its own changes before they are saved
+		if got, err := tx.GetTask(tasks[0].ID); err != nil || got.Title != "written back" {
+			t.Errorf("GetTask in the transaction = %v, %v; want the title written back", got, err)
+		}
+		return injected
+	})
+	if !errors.Is(err, injected) {
+		t.Fatalf("WithTx = %v, want the error of fn", err)
+	}
+	assertTitles(t, boardTitles(t, database), model.StatusTodo, "write back", "delete")
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	database := newTestDB(t)
+	tasks := createTasks(t, database, "stays")
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("WithTx swallowed the panic of fn")
+			}
+		}()
+		_ = database.WithTx(func(tx *Tx) error {
+			if err := tx.DeleteTask(tasks[0].ID); err != nil {
+				return err
+			}
+			panic("injected")
+		})
+	}()
+	assertTitles(t, boardTitles(t, database), model.StatusTodo, "stays")
+
+	// The connection is free again for the next transaction
+	if err := database.WithTx(func(tx *Tx) error { return tx.DeleteTask(tasks[0].ID) }); err != nil {
+		t.Fatalf("WithTx after the panic: %v", err)
+	}
+	assertTitles(t, boardTitles(t, database), model.StatusTodo)
+}
+
+func TestWithTxSavesOnSuccess(t *testing.T) {
+	database := newTestDB(t)
+	tasks := createTasks(t, database, "move", "delete")
+
+	err := database.WithTx(func(tx *Tx) error {
+		if _, err := tx.MoveTasks([]int64{tasks[0].ID}, model.StatusDone); err != nil {
+			return err
+		}
+		return tx.DeleteTask(tasks[1].ID)
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	got := boardTitles(t, database)
+	assertTitles(t, got, model.StatusTodo)
+	assertTitles(t, got, model.StatusDone, "move")
+}
+
+// Bulk changes fail on the task that is gone, after changing the ones
+// before it; none of them may be saved
+func TestBulkChangesAreAtomic(t *testing.T) {
+	const missing = int64(999)
+	tests := []struct {
+		name   string
+		change func(database *DB, ids []int64) error
+	}{
+		{"move", func(database *DB, ids []int64) error {
+			_, err := database.MoveTasks(ids, model.StatusDone)
+			return err
+		}},
+		{"priority", func(database *DB, ids []int64) error {
+			return database.SetTasksPriority(ids, model.PriorityUrgent)
+		}},
+		{"tag", func(database *DB, ids []int64) error { return database.AddTasksTag(ids, "added") }},
+		{"archive", func(database *DB, ids []int64) error { return database.ArchiveTasks(ids) }},
+		{"delete", func(database *DB, ids []int64) error { return database.DeleteTasks(ids) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database := newTestDB(t)
+			tasks := createTasks(t, database, "a", "b")
+			if err := tt.change(database, []int64{tasks[0].ID, tasks[1].ID, missing}); err == nil {
+				t.Fatal("change of a missing task succeeded")
+			}
+
+			assertTitles(t, boardTitles(t, database), model.StatusTodo, "a", "b")
+			for _, task := range tasks {
+				got, err := database.GetTask(task.ID)
+				if err != nil {
+					t.Fatalf("GetTask: %v", err)
+				}
+				if got.Priority != task.Priority || len(got.Tags) != 1 || got.ArchivedAt != nil || got.DeletedAt != nil {
+					t.Errorf("task %d was changed: %+v", task.ID, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteAllTasksIsAtomic(t *testing.T) {
+	database := newTestDB(t)
+	createTasks(t, database, "a", "b")
+
+	// Fail the batch after the tags and checklists are deleted
+	if _, err := database.conn.Exec("CREATE TRIGGER fail_delete BEFORE DELETE ON tasks BEGIN SELECT RAISE(ABORT, 'injected'); END"); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.DeleteAllTasks(); err == nil {
+		t.Fatal("DeleteAllTasks succeeded despite the failing trigger")
+	}
+	columns, err := database.GetBoard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range columns[0].Tasks {
+		if len(task.Tags) != 1 || len(task.Subtasks) != 1 {
+			t.Errorf("task %q lost its tags or checklist: %+v", task.Title, task)
+		}
+	}
+	if len(columns[0].Tasks) != 2 {
+		t.Fatalf("board holds %d tasks, want 2", len(columns[0].Tasks))
+	}
+
+	if _, err := database.conn.Exec("DROP TRIGGER fail_delete"); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.DeleteAllTasks(); err != nil {
+		t.Fatalf("DeleteAllTasks: %v", err)
+	}
+	if count, err := database.CountTasks(); err != nil || count != 0 {
+		t.Fatalf("CountTasks = %d, %v; want 0", count, err)
+	}
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// createViewTable creates the table of saved views. Column sorts and hidden
+// columns are stored as JSON.
+func createViewTable(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS saved_views (
+		name TEXT PRIMARY KEY COLLATE NOCASE,
+		search TEXT NOT NULL DEFAULT '',
+		label TEXT NOT NULL DEFAULT '',
+		urgent_only INTEGER NOT NULL DEFAULT 0,
+		stale_only INTEGER NOT NULL DEFAULT 0,
+		sorts TEXT NOT NULL DEFAULT '{}',
+		hidden_columns TEXT NOT NULL DEFAULT '[]',
+		created_at DATETIME NOT NULL
+	);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create saved views table: %w", err)
+	}
+	return nil
+}
+
+// SaveView stores a view, replacing any other of the same name
+func (db *DB) SaveView(v model.SavedView) error {
+	name := strings.TrimSpace(v.Name)
+	if name == "" {
+		return errors.New("view name cannot be empty")
+	}
+	sorts := v.Sorts
+	if sorts == nil {
+		sorts = map[model.TaskStatus]string{}
+	}
+	sortsJSON, err := json.Marshal(sorts)
+	if err != nil {
+		return fmt.Errorf("failed to save view: %w", err)
+	}
+	hidden := v.Hidden
+	if hidden == nil {
+		hidden = []model.TaskStatus{}
+	}
+	hiddenJSON, err := json.Marshal(hidden)
+	if err != nil {
+		return fmt.Errorf("failed to save view: %w", err)
+	}
+
+	if _, err := db.exec(
+		`INSERT INTO saved_views (name, search, label, urgent_only, stale_only, pinned_only, sorts, hidden_columns, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(name) DO UPDATE SET name = excluded.name, search = excluded.search, label = excluded.label, urgent_only = excluded.urgent_only,
+			stale_only = excluded.stale_only, pinned_only = excluded.pinned_only, sorts = excluded.sorts, hidden_columns = excluded.hidden_columns`,
+		name, v.Search, v.Label, v.UrgentOnly, v.StaleOnly, v.PinnedOnly, string(sortsJSON), string(hiddenJSON),
+	); err != nil {
+		return fmt.Errorf("failed to save view: %w", err)
+	}
+	return nil
+}
+
+// GetViews returns all saved views ordered by name
+func (db *DB) GetViews() ([]model.SavedView, error) {
+	return queryViews(db.conn, "ORDER BY name")
+}
+
+// GetView returns the view with the given name, ignoring case
+func (db *DB) GetView(name string) (*model.SavedView, error) {
+	views, err := queryViews(db.conn, "WHERE name = ?", strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(views) == 0 {
+		return nil, fmt.Errorf("view %q not found", name)
+	}
+	return &views[0], nil
+}
+
+// DeleteView deletes the view with the given name, ignoring case. When it
+// was the default view, the board opens without a view again.
+func (db *DB) DeleteView(name string) error {
+	tx, err := db.begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM saved_views WHERE name = ?", strings.TrimSpace(name))
+	if err != nil {
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("view %q not found", name)
+	}
+	if _, err := tx.Exec("DELETE FROM settings WHERE key = ? AND value = ? COLLATE NOCASE", SettingDefaultView, strings.TrimSpace(name)); err != nil {
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+	return nil
+}
+
+// DefaultView returns the view the board opens with, or nil when it opens
+// without one
+func (db *DB) DefaultView() (*model.SavedView, error) {
+	name, ok, err := getSetting(db.conn, SettingDefaultView)
+	if err != nil || !ok {
+		return nil, err
+	}
+	views, err := queryViews(db.conn, "WHERE name = ?", name)
+	if err != nil || len(views) == 0 {
+		return nil, err
+	}
+	return &views[0], nil
+}
+
+// SetDefaultView makes the named view the one the board opens with; an
+// empty name opens the board without a view
+func (db *DB) SetDefaultView(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		if _, err := db.exec("DELETE FROM settings WHERE key = ?", SettingDefaultView); err != nil {
+			return fmt.Errorf("failed to save default view: %w", err)
+		}
+		return nil
+	}
+	view, err := db.GetView(name)
+	if err != nil {
+		return err
+	}
+	return db.SetSetting(SettingDefaultView, view.Name)
+}
+
+// queryViews selects saved views; where follows the FROM clause
+func queryViews(ex execer, where string, args ...interface{}) ([]model.SavedView, error) {
+	rows, err := ex.Query("SELECT name, search, label, urgent_only, stale_only, pinned_only, sorts, hidden_columns FROM saved_views "+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query views: %w", err)
+	}
+	defer rows.Close()
+
+	views := []model.SavedView{}
+	for rows.Next() {
+		var v model.SavedView
+		var sorts, hidden string
+		if err := rows.Scan(&v.Name, &v.Search, &v.Label, &v.UrgentOnly, &v.StaleOnly, &v.PinnedOnly, &sorts, &hidden); err != nil {
+			return nil, fmt.Errorf("failed to scan view: %w", err)
+		}
+		if err := json.Unmarshal([]byte(sorts), &v.Sorts); err != nil {
+			return nil, fmt.Errorf("view %s has invalid sorts: %w", v.Name, err)
+		}
+		if err := json.Unmarshal([]byte(hidden), &v.Hidden); err != nil {
+			return nil, fmt.Errorf("view %s has invalid hidden columns: %w", v.Name, err)
+		}
+		views = append(views, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query views: %w", err)
+	}
+	return views, nil
+}
+
+// createBoardStateTable creates the table holding where the board was left,
+// as JSON. It isn't one of the revisionTables: saving it doesn't change
+// what the board shows, so open boards don't reload for it.
+func createBoardStateTable(ex execer) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS board_state (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		state TEXT NOT NULL,
+		saved_at DATETIME NOT NULL
+	);
+	`
+	if _, err := ex.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create board state table: %w", err)
+	}
+	return nil
+}
+
+// SaveBoardState stores where the board was left, replacing what was stored
+func (db *DB) SaveBoardState(state model.BoardState) error {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to save board state: %w", err)
+	}
+	if _, err := db.exec(
+		`INSERT INTO board_state (id, state, saved_at) VALUES (1, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(id) DO UPDATE SET state = excluded.state, saved_at = excluded.saved_at`,
+		string(stateJSON),
+	); err != nil {
+		return fmt.Errorf("failed to save board state: %w", err)
+	}
+	return nil
+}
+
+// BoardState returns where the board was left, or nil when it was never
+// saved. A state that doesn't parse is left out too, so the board opens
+// as it would the first time.
+func (db *DB) BoardState() (*model.BoardState, error) {
+	var stateJSON string
+	err := db.conn.QueryRow("SELECT state FROM board_state WHERE id = 1").Scan(&stateJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read board state: %w", err)
+	}
+	var state model.BoardState
+	if err := json.Unmarshal([]byte(stateJSON), &state); err != nil {
+		return nil, nil
+	}
+	return &state, nil
+}
@@ -0,0 +1,203 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// csvHeader is the header row written by WriteCSV
+var csvHeader = []string{"id", "column", "position", "title", "description", "created_at", "priority", "tags", "blocked_by", "parent", "pinned"}
+
+// WriteCSV writes one row per task with a header row, in board order
+func WriteCSV(w io.Writer, doc Document) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, col := range doc.Columns {
+		for i, task := range col.Tasks {
+			record := []string{
+				strconv.FormatInt(task.ID, 10),
+				col.Name,
+				strconv.Itoa(i + 1),
+				task.Title,
+				task.Description,
+				task.CreatedAt.Format(time.RFC3339),
+				string(task.Priority),
+				strings.Join(task.Tags, ","),
+				joinIDs(task.BlockedBy, ","),
+				parentID(task.Parent),
+				pinnedText(task.Pinned),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// parentID formats the ID of a task's epic, "" for none
+func parentID(parent int64) string {
+	if parent == 0 {
+		return ""
+	}
+	return strconv.FormatInt(parent, 10)
+}
+
+// pinnedText formats whether a task is pinned, "" when it isn't
+func pinnedText(pinned bool) string {
+	if !pinned {
+		return ""
+	}
+	return "true"
+}
+
+// joinIDs joins task IDs with sep between them
+func joinIDs(ids []int64, sep string) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(s, sep)
+}
+
+// ReadCSV parses a CSV file with a header row into a document. Only the title
+// column is required; columns appear in the order they are first seen and
+// tasks are ordered by the position column when present.
+func ReadCSV(r io.Reader) (*Document, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV file is empty")
+		}
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	fields := make(map[string]int, len(header))
+	for i, name := range header {
+		fields[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := fields["title"]; !ok {
+		return nil, errors.New("CSV header has no title column")
+	}
+
+	type positioned struct {
+		task     model.Task
+		position int
+	}
+	var columnNames []string
+	tasksByColumn := make(map[string][]positioned)
+
+	line := 1
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+
+		get := func(name string) string {
+			if i, ok := fields[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		task := model.Task{
+			Title:       get("title"),
+			Description: get("description"),
+		}
+		task.Tags = []string{}
+		for _, tag := range strings.Split(get("tags"), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				task.Tags = append(task.Tags, tag)
+			}
+		}
+		if v := get("priority"); v != "" {
+			priority, ok := model.ParsePriority(v)
+			if !ok {
+				return nil, fmt.Errorf("CSV line %d: invalid priority %q", line, v)
+			}
+			task.Priority = priority
+		}
+		if v := get("id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("CSV line %d: invalid id %q", line, v)
+			}
+			task.ID = id
+		}
+		for _, v := range strings.Split(get("blocked_by"), ",") {
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(strings.TrimPrefix(v, "#"), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("CSV line %d: invalid blocked_by id %q", line, v)
+			}
+			task.BlockedBy = append(task.BlockedBy, id)
+		}
+		if v := get("parent"); v != "" {
+			id, err := strconv.ParseInt(strings.TrimPrefix(v, "#"), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("CSV line %d: invalid parent id %q", line, v)
+			}
+			task.Parent = id
+		}
+		if v := get("pinned"); v != "" {
+			pinned, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("CSV line %d: invalid pinned %q", line, v)
+			}
+			task.Pinned = pinned
+		}
+		if v := get("created_at"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("CSV line %d: invalid created_at %q", line, v)
+			}
+			task.CreatedAt = t
+			task.UpdatedAt = t
+		}
+		position := line
+		if v := get("position"); v != "" {
+			p, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("CSV line %d: invalid position %q", line, v)
+			}
+			position = p
+		}
+
+		column := get("column")
+		if _, seen := tasksByColumn[column]; !seen {
+			columnNames = append(columnNames, column)
+		}
+		tasksByColumn[column] = append(tasksByColumn[column], positioned{task: task, position: position})
+	}
+
+	doc := &Document{Version: FormatVersion}
+	for _, name := range columnNames {
+		entries := tasksByColumn[name]
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].position < entries[j].position })
+		col := Column{Name: name, Tasks: make([]model.Task, 0, len(entries))}
+		for _, e := range entries {
+			col.Tasks = append(col.Tasks, e.task)
+		}
+		doc.Columns = append(doc.Columns, col)
+	}
+	return doc, nil
+}
@@ -0,0 +1,149 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// ICSComponent is the calendar component a task becomes in an iCalendar export
+type ICSComponent string
+
+const (
+	ICSTodo  ICSComponent = "VTODO"  // a to-do, due on the task's due date
+	ICSEvent ICSComponent = "VEVENT" // an all-day event on the task's due date
+)
+
+// icsTimeLayout is the layout of UTC date-times
+const icsTimeLayout = "20060102T150405Z"
+
+// icsLineLimit is the longest content line allowed, in octets, before it is
+// folded (RFC 5545, section 3.1)
+const icsLineLimit = 75
+
+// icsEscaper escapes text property values (RFC 5545, section 3.3.11)
+var icsEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// icsPriorities maps task priorities to iCalendar priorities, 1 the highest
+var icsPriorities = map[model.TaskPriority]int{
+	model.PriorityUrgent: 1,
+	model.PriorityHigh:   3,
+	model.PriorityMedium: 5,
+	model.PriorityLow:    7,
+}
+
+// WriteICS writes the tasks that have a due date as an iCalendar file, one
+// component each. Their UIDs are made of the task ID and the workspace, so
+// they stay the same from one export to the next and a subscribed calendar
+// updates its entries instead of duplicating them. Tasks in a done column
+// are completed to-dos; events have no such status.
+func WriteICS(w io.Writer, doc Document, component ICSComponent) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		bw.WriteString(foldICS(s))
+	}
+
+	stamp := doc.ExportedAt.UTC().Format(icsTimeLayout)
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//cli_kanban//cli_kanban//EN")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:" + escapeICS(doc.Workspace))
+	for _, col := range doc.Columns {
+		done := col.Done
+		for _, task := range col.Tasks {
+			if task.Due == nil {
+				continue
+			}
+			day := task.Due.Format("20060102")
+			line("BEGIN:" + string(component))
+			line(fmt.Sprintf("UID:task-%d@%s.cli_kanban", task.ID, doc.Workspace))
+			line("DTSTAMP:" + stamp)
+			line("CREATED:" + task.CreatedAt.UTC().Format(icsTimeLayout))
+			line("LAST-MODIFIED:" + task.UpdatedAt.UTC().Format(icsTimeLayout))
+			line("SUMMARY:" + escapeICS(task.Title))
+			if description := strings.TrimSpace(task.Description); description != "" {
+				line("DESCRIPTION:" + escapeICS(description))
+			}
+			if len(task.Tags) > 0 {
+				tags := make([]string, len(task.Tags))
+				for j, tag := range task.Tags {
+					tags[j] = escapeICS(tag)
+				}
+				line("CATEGORIES:" + strings.Join(tags, ","))
+			}
+			if priority, ok := icsPriorities[task.Priority]; ok {
+				line(fmt.Sprintf("PRIORITY:%d", priority))
+			}
+			if task.URL != "" {
+				line("URL:" + task.URL)
+			}
+			for _, blocker := range task.BlockedBy {
+				// RFC 9253's relation type for the tasks this one waits on
+				line(fmt.Sprintf("RELATED-TO;RELTYPE=DEPENDS-ON:task-%d@%s.cli_kanban", blocker, doc.Workspace))
+			}
+			if task.Parent != 0 {
+				line(fmt.Sprintf("RELATED-TO;RELTYPE=PARENT:task-%d@%s.cli_kanban", task.Parent, doc.Workspace))
+			}
+
+			switch component {
+			case ICSEvent:
+				line("DTSTART;VALUE=DATE:" + day)
+				line("DTEND;VALUE=DATE:" + task.Due.AddDate(0, 0, 1).Format("20060102"))
+				line("TRANSP:TRANSPARENT")
+			default:
+				line("DUE;VALUE=DATE:" + day)
+				if done {
+					line("STATUS:COMPLETED")
+					if task.CompletedAt != nil {
+						line("COMPLETED:" + task.CompletedAt.UTC().Format(icsTimeLayout))
+					}
+				} else {
+					line("STATUS:NEEDS-ACTION")
+				}
+			}
+			line("END:" + string(component))
+		}
+	}
+	line("END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+// escapeICS escapes s for a text property value
+func escapeICS(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// foldICS ends a content line with CRLF, folding it into lines of at most
+// icsLineLimit octets, each continuation starting with a space. Lines are
+// only split between characters, never inside a UTF-8 sequence.
+func foldICS(s string) string {
+	var b strings.Builder
+	limit := icsLineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space counts towards the next line's limit
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+	return b.String()
+}
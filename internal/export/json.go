@@ -0,0 +1,76 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// FormatVersion is the version of the JSON board document.
+// Bump it whenever the document layout changes incompatibly.
+const FormatVersion = 1
+
+// Document is the versioned JSON representation of a whole board
+type Document struct {
+	Version    int       `json:"version"`
+	Workspace  string    `json:"workspace"`
+	ExportedAt time.Time `json:"exported_at"`
+	Columns    []Column  `json:"columns"`
+}
+
+// Column is a board column with its tasks in display order
+type Column struct {
+	Name   string           `json:"name"`
+	Status model.TaskStatus `json:"status"`
+	Done   bool             `json:"done,omitempty"` // tasks in it count as completed
+	Tasks  []model.Task     `json:"tasks"`
+}
+
+// NewDocument builds an export document from the board columns
+func NewDocument(workspace string, columns []model.Column, exportedAt time.Time) Document {
+	doc := Document{
+		Version:    FormatVersion,
+		Workspace:  workspace,
+		ExportedAt: exportedAt,
+		Columns:    make([]Column, 0, len(columns)),
+	}
+	for _, col := range columns {
+		tasks := col.Tasks
+		if tasks == nil {
+			tasks = []model.Task{}
+		}
+		doc.Columns = append(doc.Columns, Column{
+			Name:   col.Name,
+			Status: col.Status,
+			Done:   col.Done,
+			Tasks:  tasks,
+		})
+	}
+	return doc
+}
+
+// WriteJSON writes the document as indented JSON
+func WriteJSON(w io.Writer, doc Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// ReadJSON parses a JSON board document and validates its version
+func ReadJSON(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse board document: %w", err)
+	}
+	if doc.Version == 0 {
+		return nil, errors.New("board document has no version field")
+	}
+	if doc.Version > FormatVersion {
+		return nil, fmt.Errorf("board document version %d is newer than supported version %d, please upgrade cli_kanban", doc.Version, FormatVersion)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,181 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// newBoard returns a board held in memory, closed with the test
+func newBoard(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+// seedBoard fills a board with tasks using what a document carries: tags,
+// a checklist with a ticked item, a due date, a priority, a pin, a repeat
+// rule, a dependency, an epic, a custom column and a manual order
+func seedBoard(t *testing.T, database *db.DB) {
+	t.Helper()
+	due := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	tasks, err := database.CreateTasks([]model.Task{
+		{Title: "Write docs", Description: "The **API** part", Status: model.StatusTodo, Priority: model.PriorityHigh, Tags: []string{"docs", "api"}, Due: &due,
+			Subtasks: []model.Subtask{{Title: "outline"}, {Title: "examples"}}},
+		{Title: "Fix bug", Status: model.StatusTodo, Priority: model.PriorityUrgent, Recurrence: "weekly"},
+		{Title: "Epic", Status: model.StatusInProgress},
+		{Title: "Shipped", Status: model.StatusDone, Tags: []string{"release"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+	review, err := database.CreateColumn("Review", 2)
+	if err != nil {
+		t.Fatalf("CreateColumn: %v", err)
+	}
+	if _, err := database.CreateTasks([]model.Task{{Title: "In review", Status: review.Status}}); err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+
+	docs, bug, epic := tasks[0], tasks[1], tasks[2]
+	steps := []error{
+		database.SwapTaskPositions(docs.ID, bug.ID),
+		database.SetTaskPinned(bug.ID, true),
+		database.AddDependency(docs.ID, bug.ID),
+		database.SetTaskParent(docs.ID, epic.ID),
+	}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	written, err := database.GetTask(docs.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.ToggleSubtask(written.Subtasks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exportBoard returns the document of a board as JSON
+func exportBoard(t *testing.T, database *db.DB, exportedAt time.Time) []byte {
+	t.Helper()
+	columns, err := database.GetBoard()
+	if err != nil {
+		t.Fatalf("GetBoard: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, NewDocument("work", columns, exportedAt)); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// withoutVersions returns a document with the edit counters of its tasks
+// cleared: they count the edits of a task in one database, and an import
+// starts them over
+func withoutVersions(t *testing.T, data []byte) []byte {
+	t.Helper()
+	doc, err := ReadJSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	for _, col := range doc.Columns {
+		for i := range col.Tasks {
+			col.Tasks[i].Version = 0
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, *doc); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestJSONRoundTrip exports a board, imports the document into a new board as
+// import does, and checks the new board exports the same document: the same
+// columns, and in them the same tasks in the same order with their IDs,
+// tags, checklists, dependencies and every other field
+func TestJSONRoundTrip(t *testing.T) {
+	source := newBoard(t)
+	seedBoard(t, source)
+	exportedAt := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	exported := exportBoard(t, source, exportedAt)
+
+	doc, err := ReadJSON(bytes.NewReader(exported))
+	if err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	columns := make([]model.Column, len(doc.Columns))
+	for i, col := range doc.Columns {
+		columns[i] = model.Column{Name: col.Name, Status: col.Status, Done: col.Done, Tasks: col.Tasks}
+	}
+	target := newBoard(t)
+	imported, err := target.ImportTasks(columns, true)
+	if err != nil {
+		t.Fatalf("ImportTasks: %v", err)
+	}
+	if imported != 5 {
+		t.Fatalf("imported %d tasks, want 5", imported)
+	}
+
+	reexported := exportBoard(t, target, exportedAt)
+	want, got := withoutVersions(t, exported), withoutVersions(t, reexported)
+	if !bytes.Equal(want, got) {
+		t.Errorf("the imported board exports differently\nexported:\n%s\nafter import:\n%s", want, got)
+	}
+
+	// The documents compare equal only if they carry what was seeded
+	var check Document
+	if err := json.Unmarshal(reexported, &check); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, col := range check.Columns {
+		names = append(names, col.Name)
+	}
+	if len(names) != 4 || names[2] != "Review" || !check.Columns[3].Done {
+		t.Fatalf("columns = %q, want Review third and Done last and done", names)
+	}
+	todo := check.Columns[0].Tasks
+	if len(todo) != 2 || todo[0].Title != "Fix bug" || todo[1].Title != "Write docs" {
+		t.Fatalf("todo = %+v, want Fix bug above Write docs", todo)
+	}
+	docs := todo[1]
+	if len(docs.Tags) != 2 || len(docs.Subtasks) != 2 || !docs.Subtasks[0].Done || docs.Subtasks[1].Done ||
+		len(docs.BlockedBy) != 1 || docs.BlockedBy[0] != todo[0].ID || docs.Parent == 0 || docs.Due == nil {
+		t.Errorf("Write docs lost fields: %+v", docs)
+	}
+	if !todo[0].Pinned || todo[0].Recurrence != "weekly" || todo[0].Priority != model.PriorityUrgent {
+		t.Errorf("Fix bug lost fields: %+v", todo[0])
+	}
+}
+
+func TestReadJSONVersion(t *testing.T) {
+	tests := []struct {
+		name, doc string
+		ok        bool
+	}{
+		{"current", `{"version": 1, "columns": []}`, true},
+		{"no version", `{"columns": []}`, false},
+		{"newer", `{"version": 2, "columns": []}`, false},
+		{"malformed", `{"version": 1, "columns": [`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadJSON(bytes.NewReader([]byte(tt.doc)))
+			if (err == nil) != tt.ok {
+				t.Errorf("ReadJSON(%s) error = %v, want ok %v", tt.doc, err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// markdownEscaper escapes characters that carry meaning in GitHub-flavored markdown
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`>`, `\>`,
+	`#`, `\#`,
+	`|`, `\|`,
+	`~`, `\~`,
+)
+
+// WriteMarkdown renders the document as markdown: one heading per column and
+// one checkbox per task with its short ID and the IDs of the tasks blocking
+// it, checked for tasks in a done column. Subtasks are rendered as
+// nested checkboxes.
+func WriteMarkdown(w io.Writer, doc Document) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "# %s\n", escapeMarkdown(doc.Workspace))
+	for _, col := range doc.Columns {
+		fmt.Fprintf(bw, "\n## %s\n\n", escapeMarkdown(col.Name))
+		if len(col.Tasks) == 0 {
+			fmt.Fprintln(bw, "_No tasks_")
+			continue
+		}
+
+		mark := " "
+		if col.Done {
+			mark = "x"
+		}
+		for _, task := range col.Tasks {
+			// The short ID, escaped so GitHub doesn't link it to an issue
+			fmt.Fprintf(bw, "- [%s] %s \\#%d", mark, escapeMarkdown(task.Title), task.ID)
+			if task.Priority != model.PriorityNone {
+				fmt.Fprintf(bw, " _(%s)_", task.Priority)
+			}
+			if task.Pinned {
+				fmt.Fprint(bw, " _pinned_")
+			}
+			for _, tag := range task.Tags {
+				fmt.Fprintf(bw, " `%s`", strings.ReplaceAll(tag, "`", ""))
+			}
+			if len(task.BlockedBy) > 0 {
+				fmt.Fprintf(bw, " _blocked by \\#%s_", joinIDs(task.BlockedBy, `, \#`))
+			}
+			if task.Parent != 0 {
+				fmt.Fprintf(bw, " _part of \\#%d_", task.Parent)
+			}
+			fmt.Fprintln(bw)
+			for _, line := range strings.Split(task.Description, "\n") {
+				line = strings.TrimSpace(line)
+				if line == "" {
+					continue
+				}
+				fmt.Fprintf(bw, "  - %s\n", escapeMarkdown(line))
+			}
+			for _, st := range task.Subtasks {
+				stMark := " "
+				if st.Done {
+					stMark = "x"
+				}
+				fmt.Fprintf(bw, "  - [%s] %s\n", stMark, escapeMarkdown(st.Title))
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// escapeMarkdown escapes markdown-significant characters in s
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// orgTagRe matches runs of characters that aren't allowed in org tags
+var orgTagRe = regexp.MustCompile(`[^\p{L}\p{N}_@#%]+`)
+
+// orgPriorities maps task priorities to org priority cookies
+var orgPriorities = map[model.TaskPriority]string{
+	model.PriorityUrgent: "[#A] ",
+	model.PriorityHigh:   "[#A] ",
+	model.PriorityMedium: "[#B] ",
+	model.PriorityLow:    "[#C] ",
+}
+
+// WriteOrg renders the document as an org-mode file: one top-level heading
+// per column and one second-level heading per task, with the TODO keyword, or
+// DONE in the done columns, the priority as a cookie and tags as org
+// tags. Due dates become DEADLINE lines and completion times CLOSED ones. An
+// :ID: property carries the task ID for matching the tasks on a later import,
+// a :BLOCKER: property in org-edna's ids() form the tasks it waits on, and
+// :PINNED: marks the pinned tasks.
+// The description and the checklist make the body, indented so that none of
+// their lines is read as a heading.
+func WriteOrg(w io.Writer, doc Document) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "#+TITLE: %s\n", oneLine(doc.Workspace))
+	for _, col := range doc.Columns {
+		fmt.Fprintf(bw, "\n* %s\n", oneLine(col.Name))
+
+		keyword := "TODO"
+		if col.Done {
+			keyword = "DONE"
+		}
+		for _, task := range col.Tasks {
+			fmt.Fprintf(bw, "** %s %s%s", keyword, orgPriorities[task.Priority], oneLine(task.Title))
+			if tags := orgTags(task.Tags); tags != "" {
+				fmt.Fprintf(bw, " %s", tags)
+			}
+			fmt.Fprintln(bw)
+
+			var planning []string
+			if keyword == "DONE" && task.CompletedAt != nil {
+				planning = append(planning, "CLOSED: ["+task.CompletedAt.Local().Format("2006-01-02 Mon 15:04")+"]")
+			}
+			if task.Due != nil {
+				planning = append(planning, "DEADLINE: <"+task.Due.Format("2006-01-02 Mon")+">")
+			}
+			if len(planning) > 0 {
+				fmt.Fprintf(bw, "   %s\n", strings.Join(planning, " "))
+			}
+			fmt.Fprintf(bw, "   :PROPERTIES:\n   :ID: %d\n", task.ID)
+			if len(task.BlockedBy) > 0 {
+				fmt.Fprintf(bw, "   :BLOCKER: ids(%s)\n", joinIDs(task.BlockedBy, " "))
+			}
+			if task.Parent != 0 {
+				fmt.Fprintf(bw, "   :PARENT: %d\n", task.Parent)
+			}
+			if task.Pinned {
+				fmt.Fprintln(bw, "   :PINNED: t")
+			}
+			fmt.Fprintln(bw, "   :END:")
+
+			if description := strings.TrimRight(task.Description, " \t\n"); description != "" {
+				for _, line := range strings.Split(description, "\n") {
+					if line = strings.TrimRight(line, " \t\r"); line == "" {
+						fmt.Fprintln(bw)
+						continue
+					}
+					fmt.Fprintf(bw, "   %s\n", line)
+				}
+			}
+			for _, st := range task.Subtasks {
+				mark := " "
+				if st.Done {
+					mark = "X"
+				}
+				fmt.Fprintf(bw, "   - [%s] %s\n", mark, oneLine(st.Title))
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// orgTags renders tags as an org tag list such as :bug:frontend:, with the
+// characters org doesn't allow in tags replaced by underscores
+func orgTags(tags []string) string {
+	var names []string
+	for _, tag := range tags {
+		if name := strings.Trim(orgTagRe.ReplaceAllString(tag, "_"), "_"); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return ":" + strings.Join(names, ":") + ":"
+}
+
+// oneLine joins the lines of s with spaces, for headings and list items
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
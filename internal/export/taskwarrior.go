@@ -0,0 +1,292 @@
+package export
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// TaskwarriorSource is the source of tasks imported from taskwarrior, whose
+// source IDs are the taskwarrior UUIDs
+const TaskwarriorSource = "taskwarrior"
+
+// twTimeLayout is the layout of taskwarrior dates, which are always in UTC
+const twTimeLayout = "20060102T150405Z"
+
+// twTask is a task as taskwarrior exports and imports it
+type twTask struct {
+	UUID        string         `json:"uuid"`
+	Description string         `json:"description"`
+	Status      string         `json:"status"` // pending, waiting, completed, deleted or recurring
+	Entry       string         `json:"entry,omitempty"`
+	Modified    string         `json:"modified,omitempty"`
+	End         string         `json:"end,omitempty"`
+	Due         string         `json:"due,omitempty"`
+	Priority    string         `json:"priority,omitempty"` // H, M or L
+	Project     string         `json:"project,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Depends     twDepends      `json:"depends,omitempty"` // UUIDs of the tasks this one waits on
+	Annotations []twAnnotation `json:"annotations,omitempty"`
+}
+
+// twDepends lists the UUIDs a task depends on. Taskwarrior 2.6 and later
+// write an array, older versions one comma-separated string; both are read.
+type twDepends []string
+
+// UnmarshalJSON reads either form of the dependency list
+func (d *twDepends) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*d = list
+		return nil
+	}
+	var joined string
+	if err := json.Unmarshal(data, &joined); err != nil {
+		return fmt.Errorf("depends is neither a list nor a string of UUIDs")
+	}
+	*d = nil
+	for _, uuid := range strings.Split(joined, ",") {
+		if uuid = strings.TrimSpace(uuid); uuid != "" {
+			*d = append(*d, uuid)
+		}
+	}
+	return nil
+}
+
+// twAnnotation is a dated note on a taskwarrior task
+type twAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// TaskwarriorSummary counts what ReadTaskwarrior took and what it left out
+type TaskwarriorSummary struct {
+	Tasks     int
+	Deleted   int // deleted tasks, left out
+	Recurring int // recurrence templates, left out; their pending instances are imported
+}
+
+// ReadTaskwarrior parses the output of task export, either a JSON array or
+// one task object per line, into drafts for db.SyncTasks: pending and waiting
+// tasks get status open and completed ones status done. Tags and the project
+// become tags, the due date is kept as a day, and annotations follow each
+// other in the description, one line each with their date. The newest task
+// comes first, so it ends up on top of its column.
+func ReadTaskwarrior(r io.Reader, open, done model.TaskStatus) ([]model.Task, TaskwarriorSummary, error) {
+	var summary TaskwarriorSummary
+	tasks, err := decodeTaskwarrior(r)
+	if err != nil {
+		return nil, summary, fmt.Errorf("failed to parse taskwarrior export: %w", err)
+	}
+
+	var drafts []model.Task
+	var entries []time.Time
+	for _, tw := range tasks {
+		switch tw.Status {
+		case "deleted":
+			summary.Deleted++
+			continue
+		case "recurring":
+			summary.Recurring++
+			continue
+		}
+		title := strings.TrimSpace(tw.Description)
+		if tw.UUID == "" {
+			return nil, summary, fmt.Errorf("task %q has no uuid", title)
+		}
+		if title == "" {
+			title = "(untitled task)"
+		}
+
+		draft := model.Task{
+			Title:    title,
+			Status:   open,
+			Priority: twPriority(tw.Priority),
+			Tags:     append([]string{}, tw.Tags...),
+			Source:   TaskwarriorSource,
+			SourceID: tw.UUID,
+		}
+		if tw.Status == "completed" {
+			draft.Status = done
+		}
+		if tw.Project != "" {
+			draft.Tags = append(draft.Tags, tw.Project)
+		}
+		if tw.Due != "" {
+			due, err := time.Parse(twTimeLayout, tw.Due)
+			if err != nil {
+				return nil, summary, fmt.Errorf("task %q: invalid due date %q", title, tw.Due)
+			}
+			local := due.Local()
+			local = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+			draft.Due = &local
+		}
+		var notes []string
+		for _, note := range tw.Annotations {
+			line := strings.TrimSpace(note.Description)
+			if entry, err := time.Parse(twTimeLayout, note.Entry); err == nil {
+				line = entry.Local().Format("2006-01-02") + ": " + line
+			}
+			notes = append(notes, line)
+		}
+		draft.Description = strings.Join(notes, "\n")
+
+		entry, _ := time.Parse(twTimeLayout, tw.Entry)
+		drafts = append(drafts, draft)
+		entries = append(entries, entry)
+	}
+
+	order := make([]int, len(drafts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return entries[order[i]].After(entries[order[j]]) })
+	sorted := make([]model.Task, len(drafts))
+	for i, index := range order {
+		sorted[i] = drafts[index]
+	}
+	summary.Tasks = len(sorted)
+	return sorted, summary, nil
+}
+
+// decodeTaskwarrior reads a JSON array of tasks, or task objects one after
+// the other as older taskwarrior versions export them
+func decodeTaskwarrior(r io.Reader) ([]twTask, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("the file is empty")
+			}
+			return nil, err
+		}
+		if b[0] != ' ' && b[0] != '\t' && b[0] != '\r' && b[0] != '\n' {
+			break
+		}
+		_, _ = br.ReadByte()
+	}
+
+	dec := json.NewDecoder(br)
+	var tasks []twTask
+	if b, _ := br.Peek(1); b[0] == '[' {
+		if err := dec.Decode(&tasks); err != nil {
+			return nil, err
+		}
+		return tasks, nil
+	}
+	for {
+		var task twTask
+		if err := dec.Decode(&task); err != nil {
+			if errors.Is(err, io.EOF) {
+				return tasks, nil
+			}
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+}
+
+// twPriority maps a taskwarrior priority to a task priority
+func twPriority(priority string) model.TaskPriority {
+	switch priority {
+	case "H":
+		return model.PriorityHigh
+	case "M":
+		return model.PriorityMedium
+	case "L":
+		return model.PriorityLow
+	}
+	return model.PriorityNone
+}
+
+// WriteTaskwarrior writes the board as a JSON array that task import reads.
+// Tasks in the done columns are completed and all others pending; each line
+// of the description becomes an annotation, and urgent tasks get priority H,
+// the highest taskwarrior has. Tasks imported from taskwarrior keep their UUID,
+// and others get one derived from the workspace and task ID, so importing
+// the same board into taskwarrior twice updates its tasks instead of adding
+// them again. Dependencies among the tasks become depends lists.
+func WriteTaskwarrior(w io.Writer, doc Document) error {
+	uuids := make(map[int64]string)
+	for _, col := range doc.Columns {
+		for _, task := range col.Tasks {
+			uuids[task.ID] = twUUID(doc.Workspace, task)
+		}
+	}
+
+	tasks := []twTask{}
+	for _, col := range doc.Columns {
+		completed := col.Done
+		for _, task := range col.Tasks {
+			tw := twTask{
+				UUID:        uuids[task.ID],
+				Description: task.Title,
+				Status:      "pending",
+				Entry:       task.CreatedAt.UTC().Format(twTimeLayout),
+				Modified:    task.UpdatedAt.UTC().Format(twTimeLayout),
+				Tags:        task.Tags,
+			}
+			for _, blocker := range task.BlockedBy {
+				if uuid, ok := uuids[blocker]; ok {
+					tw.Depends = append(tw.Depends, uuid)
+				}
+			}
+			if completed {
+				tw.Status = "completed"
+				end := task.UpdatedAt
+				if task.CompletedAt != nil {
+					end = *task.CompletedAt
+				}
+				tw.End = end.UTC().Format(twTimeLayout)
+			}
+			if task.Due != nil {
+				tw.Due = task.Due.UTC().Format(twTimeLayout)
+			}
+			switch task.Priority {
+			case model.PriorityLow:
+				tw.Priority = "L"
+			case model.PriorityMedium:
+				tw.Priority = "M"
+			case model.PriorityHigh, model.PriorityUrgent:
+				tw.Priority = "H"
+			}
+			for _, line := range strings.Split(task.Description, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					tw.Annotations = append(tw.Annotations, twAnnotation{Entry: tw.Modified, Description: line})
+				}
+			}
+			tasks = append(tasks, tw)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(tasks)
+}
+
+// twUUID returns the UUID a task is exported with: the one it was imported
+// from taskwarrior with, or one derived from the workspace and task ID
+func twUUID(workspace string, task model.Task) string {
+	if task.Source == TaskwarriorSource && task.SourceID != "" {
+		return task.SourceID
+	}
+	return nameUUID("cli_kanban/" + workspace + "/" + strconv.FormatInt(task.ID, 10))
+}
+
+// nameUUID returns the version 5 style UUID named by name, the same each time
+func nameUUID(name string) string {
+	sum := sha1.Sum([]byte(name))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
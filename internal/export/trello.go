@@ -0,0 +1,176 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// trelloBoard is the part of a Trello board export that is read
+type trelloBoard struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Lists []struct {
+		ID     string  `json:"id"`
+		Name   string  `json:"name"`
+		Closed bool    `json:"closed"`
+		Pos    float64 `json:"pos"`
+	} `json:"lists"`
+	Cards []struct {
+		ID        string   `json:"id"`
+		Name      string   `json:"name"`
+		Desc      string   `json:"desc"`
+		IDList    string   `json:"idList"`
+		Closed    bool     `json:"closed"`
+		Pos       float64  `json:"pos"`
+		Due       *string  `json:"due"`
+		ShortURL  string   `json:"shortUrl"`
+		IDMembers []string `json:"idMembers"`
+		Labels    []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+		Badges struct {
+			Attachments int `json:"attachments"`
+			Comments    int `json:"comments"`
+		} `json:"badges"`
+	} `json:"cards"`
+	Checklists []struct {
+		ID         string  `json:"id"`
+		IDCard     string  `json:"idCard"`
+		Name       string  `json:"name"`
+		Pos        float64 `json:"pos"`
+		CheckItems []struct {
+			Name  string  `json:"name"`
+			State string  `json:"state"` // "complete" or "incomplete"
+			Pos   float64 `json:"pos"`
+		} `json:"checkItems"`
+	} `json:"checklists"`
+}
+
+// TrelloSummary counts what ReadTrello took from a board and what it left out
+type TrelloSummary struct {
+	Lists          int
+	Cards          int
+	Labels         int // labels put on cards, as tags
+	ChecklistItems int
+	DueDates       int
+
+	ArchivedLists int // left out unless archived ones are included
+	ArchivedCards int // archived, or in an archived list
+	Attachments   int
+	Comments      int
+	Members       int // card members, which have no counterpart on the board
+}
+
+// ReadTrello parses the JSON export of a Trello board into a document: lists
+// become columns and cards tasks, both in board order, with their
+// descriptions, labels as tags, due dates and checklists. A card with
+// several checklists gets their items one after the other, each prefixed
+// with the checklist's name. Archived lists and cards are left out unless
+// includeArchived is set. Tasks keep the card's ID and short URL as their
+// source. The summary says what was read and what was skipped.
+func ReadTrello(r io.Reader, includeArchived bool) (*Document, TrelloSummary, error) {
+	var summary TrelloSummary
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil {
+		return nil, summary, fmt.Errorf("failed to parse Trello board: %w", err)
+	}
+	if len(board.Lists) == 0 {
+		return nil, summary, fmt.Errorf("the Trello board has no lists: export it from the board's menu, Print, export and share, Export as JSON")
+	}
+
+	lists := board.Lists
+	sort.SliceStable(lists, func(i, j int) bool { return lists[i].Pos < lists[j].Pos })
+	doc := &Document{Version: FormatVersion, Workspace: board.Name}
+	column := map[string]int{} // list ID to index in doc.Columns
+	for _, list := range lists {
+		if list.Closed && !includeArchived {
+			summary.ArchivedLists++
+			continue
+		}
+		column[list.ID] = len(doc.Columns)
+		doc.Columns = append(doc.Columns, Column{Name: strings.TrimSpace(list.Name), Tasks: []model.Task{}})
+	}
+	summary.Lists = len(doc.Columns)
+
+	checklists := board.Checklists
+	sort.SliceStable(checklists, func(i, j int) bool { return checklists[i].Pos < checklists[j].Pos })
+	cards := board.Cards
+	sort.SliceStable(cards, func(i, j int) bool { return cards[i].Pos < cards[j].Pos })
+	for _, card := range cards {
+		index, ok := column[card.IDList]
+		if !ok || (card.Closed && !includeArchived) {
+			summary.ArchivedCards++
+			continue
+		}
+
+		task := model.Task{
+			Title:       strings.TrimSpace(card.Name),
+			Description: card.Desc,
+			Tags:        []string{},
+			Subtasks:    []model.Subtask{},
+			Source:      "trello:" + board.ID,
+			SourceID:    card.ID,
+			URL:         card.ShortURL,
+		}
+		if task.Title == "" {
+			task.Title = "(untitled card)"
+		}
+		for _, label := range card.Labels {
+			name := strings.TrimSpace(label.Name)
+			if name == "" {
+				// Trello labels may have only a color
+				name = label.Color
+			}
+			if name != "" {
+				task.Tags = append(task.Tags, name)
+				summary.Labels++
+			}
+		}
+		if card.Due != nil && *card.Due != "" {
+			due, err := time.Parse(time.RFC3339, *card.Due)
+			if err != nil {
+				return nil, summary, fmt.Errorf("card %q: invalid due date %q", task.Title, *card.Due)
+			}
+			local := due.Local()
+			local = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+			task.Due = &local
+			summary.DueDates++
+		}
+
+		var cardLists int
+		for _, cl := range checklists {
+			if cl.IDCard == card.ID {
+				cardLists++
+			}
+		}
+		for _, cl := range checklists {
+			if cl.IDCard != card.ID {
+				continue
+			}
+			items := cl.CheckItems
+			sort.SliceStable(items, func(i, j int) bool { return items[i].Pos < items[j].Pos })
+			for _, item := range items {
+				title := strings.TrimSpace(item.Name)
+				if cardLists > 1 {
+					title = strings.TrimSpace(cl.Name) + ": " + title
+				}
+				task.Subtasks = append(task.Subtasks, model.Subtask{Title: title, Done: item.State == "complete"})
+				summary.ChecklistItems++
+			}
+		}
+
+		summary.Attachments += card.Badges.Attachments
+		summary.Comments += card.Badges.Comments
+		summary.Members += len(card.IDMembers)
+		doc.Columns[index].Tasks = append(doc.Columns[index].Tasks, task)
+		summary.Cards++
+	}
+	return doc, summary, nil
+}
@@ -0,0 +1,167 @@
+// Package github reads the issues of a repository through the GitHub REST API.
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the address of the GitHub REST API
+const DefaultBaseURL = "https://api.github.com"
+
+// pageSize is the number of issues asked for per page, the most GitHub allows
+const pageSize = 100
+
+// ErrRateLimited is returned when GitHub refuses a request because the rate
+// limit of the token, or of the address without one, is used up
+var ErrRateLimited = errors.New("GitHub rate limit exceeded")
+
+// Issue is an issue of a repository
+type Issue struct {
+	Number  int     `json:"number"`
+	Title   string  `json:"title"`
+	Body    string  `json:"body"`
+	State   string  `json:"state"` // "open" or "closed"
+	HTMLURL string  `json:"html_url"`
+	Labels  []Label `json:"labels"`
+
+	// PullRequest is set on pull requests, which the issues API lists too
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+// Label is a label of an issue
+type Label struct {
+	Name string `json:"name"`
+}
+
+// Closed reports whether the issue is closed
+func (i Issue) Closed() bool {
+	return i.State == "closed"
+}
+
+// Client calls the GitHub REST API
+type Client struct {
+	BaseURL string // e.g. DefaultBaseURL, or the API of a GitHub Enterprise server
+	Token   string // optional; without one GitHub allows far fewer requests and no private repositories
+	HTTP    *http.Client
+}
+
+// NewClient returns a client of the API at baseURL, DefaultBaseURL when empty
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// IssueQuery selects the issues listed by Issues
+type IssueQuery struct {
+	State  string   // "open", "closed" or "all"
+	Labels []string // only issues with all of these labels
+}
+
+// repoRe matches an owner/name repository
+var repoRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// ValidateRepo checks that repo has the owner/name form
+func ValidateRepo(repo string) error {
+	if !repoRe.MatchString(repo) {
+		return fmt.Errorf("invalid repository %q: use owner/name, e.g. happytaoer/cli_kanban", repo)
+	}
+	return nil
+}
+
+// Issues returns one page of the issues of repo, oldest first and pull
+// requests left out, and the number of the next page, 0 after the last
+// one. Pages start at 1.
+func (c *Client) Issues(repo string, query IssueQuery, page int) ([]Issue, int, error) {
+	if err := ValidateRepo(repo); err != nil {
+		return nil, 0, err
+	}
+	params := url.Values{}
+	params.Set("state", query.State)
+	if len(query.Labels) > 0 {
+		params.Set("labels", strings.Join(query.Labels, ","))
+	}
+	params.Set("sort", "created")
+	params.Set("direction", "asc")
+	params.Set("per_page", strconv.Itoa(pageSize))
+	params.Set("page", strconv.Itoa(page))
+
+	req, err := http.NewRequest(http.MethodGet, c.BaseURL+"/repos/"+repo+"/issues?"+params.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to reach GitHub: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := responseError(resp, repo); err != nil {
+		return nil, 0, err
+	}
+
+	var issues []Issue
+	if err := json.NewDecoder(resp.Body).Decode(&issues); err != nil {
+		return nil, 0, fmt.Errorf("failed to read issues: %w", err)
+	}
+	next := 0
+	if strings.Contains(resp.Header.Get("Link"), `rel="next"`) {
+		next = page + 1
+	}
+
+	kept := issues[:0]
+	for _, issue := range issues {
+		if issue.PullRequest == nil {
+			kept = append(kept, issue)
+		}
+	}
+	return kept, next, nil
+}
+
+// responseError turns an unsuccessful response into an error saying why
+func responseError(resp *http.Response, repo string) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return fmt.Errorf("%w, try again after %s", ErrRateLimited, time.Unix(reset, 0).Format("15:04"))
+		}
+		return ErrRateLimited
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	_ = json.Unmarshal(data, &body)
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return errors.New("GitHub refused the token: check GITHUB_TOKEN")
+	case http.StatusNotFound:
+		return fmt.Errorf("repository %s not found (set GITHUB_TOKEN for private repositories)", repo)
+	}
+	if body.Message != "" {
+		return fmt.Errorf("GitHub answered %s: %s", resp.Status, body.Message)
+	}
+	return fmt.Errorf("GitHub answered %s", resp.Status)
+}
@@ -0,0 +1,199 @@
+package gitsync
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// repo is the local clone a workspace syncs through
+type repo struct {
+	dir string
+}
+
+// git runs a git command in the clone, returning its output. The error of
+// a failed command carries what git printed about it.
+func (r repo) git(stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.dir}, args...)...)
+	cmd.Stdin = stdin
+	// Never stop at a prompt for credentials: sync may run unattended
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		name := args[0]
+		for i := 0; i+2 < len(args) && args[i] == "-c"; i += 2 {
+			name = args[i+2]
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", name, msg)
+		}
+		return "", fmt.Errorf("git %s: %w", name, err)
+	}
+	return stdout.String(), nil
+}
+
+// clone clones remote into dir
+func clone(remote, dir string) (repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return repo{}, errors.New("git is not installed")
+	}
+	cmd := exec.Command("git", "clone", "--quiet", remote, dir)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return repo{}, fmt.Errorf("failed to clone %s: %s", remote, strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return repo{dir: dir}, nil
+}
+
+// resolve returns the commit a revision names, or "" when there is none,
+// such as HEAD before the first commit
+func (r repo) resolve(rev string) string {
+	out, err := r.git(nil, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// branch returns the branch checked out in the clone
+func (r repo) branch() (string, error) {
+	out, err := r.git(nil, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// isAncestor reports whether commit a is an ancestor of commit b, or b itself
+func (r repo) isAncestor(a, b string) bool {
+	_, err := r.git(nil, "merge-base", "--is-ancestor", a, b)
+	return err == nil
+}
+
+// files returns the content of the files in dir at commit, by file name. A
+// commit of "" has none.
+func (r repo) files(commit, dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if commit == "" {
+		return files, nil
+	}
+	out, err := r.git(nil, "ls-tree", "-z", commit, "--", dir+"/")
+	if err != nil {
+		return nil, err
+	}
+	var names, blobs []string
+	for _, entry := range strings.Split(out, "\x00") {
+		// <mode> SP <type> SP <object> TAB <path>
+		meta, path, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		names = append(names, strings.TrimPrefix(path, dir+"/"))
+		blobs = append(blobs, fields[2])
+	}
+	if len(blobs) == 0 {
+		return files, nil
+	}
+
+	out, err = r.git(strings.NewReader(strings.Join(blobs, "\n")+"\n"), "cat-file", "--batch")
+	if err != nil {
+		return nil, err
+	}
+	rd := bufio.NewReader(strings.NewReader(out))
+	for _, name := range names {
+		// <object> SP <type> SP <size> LF <content> LF
+		header, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("git cat-file: unexpected %q", strings.TrimSpace(header))
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file: unexpected %q", strings.TrimSpace(header))
+		}
+		data := make([]byte, size+1)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		files[name] = data[:size]
+	}
+	return files, nil
+}
+
+// commit records the files of dir as they are in the work tree on top of
+// the tree of base, with parents, and moves the branch to it. It returns the
+// new commit, or "" when the tree is the one of the first parent already.
+func (r repo) commit(base, dir, message string, parents ...string) (string, error) {
+	if base != "" {
+		if _, err := r.git(nil, "read-tree", base); err != nil {
+			return "", err
+		}
+	} else if _, err := r.git(nil, "read-tree", "--empty"); err != nil {
+		return "", err
+	}
+	if _, err := r.git(nil, "add", "--all", "--", dir); err != nil {
+		return "", err
+	}
+	out, err := r.git(nil, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	tree := strings.TrimSpace(out)
+	if len(parents) == 1 {
+		if current, err := r.git(nil, "rev-parse", parents[0]+"^{tree}"); err == nil && strings.TrimSpace(current) == tree {
+			return "", nil
+		}
+	}
+
+	args := []string{"commit-tree", tree, "-m", message}
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+	out, err = r.git(nil, append(r.identity(), args...)...)
+	if err != nil {
+		return "", err
+	}
+	commit := strings.TrimSpace(out)
+	return commit, r.checkout(commit)
+}
+
+// checkout moves the branch to commit and makes the work tree match it. The
+// files of the workspace were written there already; the others may come
+// from the remote.
+func (r repo) checkout(commit string) error {
+	if _, err := r.git(nil, "update-ref", "HEAD", commit); err != nil {
+		return err
+	}
+	_, err := r.git(nil, "reset", "--quiet", "--hard", commit)
+	return err
+}
+
+// identity returns options naming the committer when git has no name or
+// email configured, which would make it refuse to commit
+func (r repo) identity() []string {
+	var args []string
+	if out, _ := r.git(nil, "config", "user.name"); strings.TrimSpace(out) == "" {
+		args = append(args, "-c", "user.name=cli_kanban")
+	}
+	if out, _ := r.git(nil, "config", "user.email"); strings.TrimSpace(out) == "" {
+		host, _ := os.Hostname()
+		if host == "" {
+			host = "localhost"
+		}
+		args = append(args, "-c", "user.email=cli_kanban@"+host)
+	}
+	return args
+}
@@ -0,0 +1,528 @@
+// Package gitsync syncs a board between machines through a git repository.
+// Each task is a JSON file in a folder named after the workspace. A sync
+// writes the board over the files, commits them, merges the commits of the
+// remote task by task, pushes the result and applies it back to the board.
+package gitsync
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// stateFile, in the .git folder of the clone so it is never committed,
+// maps the task files to the tasks of the database on this machine
+const stateFile = "cli_kanban-sync.json"
+
+// state is the content of stateFile
+type state struct {
+	Workspace string           `json:"workspace"` // the folder of the board in the repository
+	Board     string           `json:"board"`     // db.BoardID of the database the task IDs belong to
+	Tasks     map[string]int64 `json:"tasks"`     // task ID by file ID
+}
+
+// Options configure Sync
+type Options struct {
+	Dir       string // the local clone, made by the first sync
+	Remote    string // the repository to clone; only needed by the first sync
+	Workspace string // name of the workspace, and of its folder in the repository the first time
+	Status    bool   // only report the changes waiting on either side
+	Out       io.Writer
+}
+
+// Result counts what Sync did, or found waiting with Status
+type Result struct {
+	Local    int  // tasks changed on the board since the last sync
+	Remote   int  // tasks changed on the remote since the last sync
+	Merged   int  // tasks changed on both sides
+	Created  int  // tasks created on the board from the remote
+	Updated  int  // tasks updated on the board from the remote
+	Archived int  // tasks archived because the remote deleted them
+	Pushed   bool // the remote got new commits
+	Failed   int  // files or tasks that couldn't be synced, reported to Out
+}
+
+// Sync syncs the board with the remote through the clone at opts.Dir,
+// cloning opts.Remote there the first time.
+//
+// A task changed on one side only takes that change, and one deleted on one
+// side and changed on the other is kept with the change. A task changed on
+// both sides takes each field from the side that changed it; a field both
+// sides changed takes the side updated last, but for the title, which keeps
+// both titles. A task deleted on the remote is archived on the board.
+func Sync(database *db.DB, opts Options) (Result, error) {
+	s := &syncer{db: database, opts: opts, tasks: map[string]model.Task{}, broken: map[string]bool{}}
+	err := s.run()
+	return s.result, err
+}
+
+// syncer carries a sync in progress
+type syncer struct {
+	db      *db.DB
+	opts    Options
+	repo    repo
+	folder  string // the folder of the board in the repository
+	state   state
+	columns []model.Column
+	tasks   map[string]model.Task // the tasks on the board by file ID
+	broken  map[string]bool       // files that failed to parse, left alone
+	result  Result
+}
+
+// run syncs the board with the remote
+func (s *syncer) run() error {
+	if err := s.open(); err != nil {
+		return err
+	}
+	// A clone moved along with a renamed workspace keeps syncing the folder
+	// the other machines know the board by
+	s.folder = s.opts.Workspace
+	if s.state.Workspace != "" {
+		s.folder = s.state.Workspace
+	}
+	board, err := s.db.BoardID()
+	if err != nil {
+		return err
+	}
+	if s.state.Board != "" && s.state.Board != board && len(s.state.Tasks) > 0 {
+		// The task IDs are of another database, such as the one the board
+		// was recreated, imported over or cloned from: the board's tasks are
+		// synced as new ones and the remote's brought in, as a first sync
+		fmt.Fprintf(s.opts.Out, "workspace %s holds another board than at the last sync: syncing it as new\n", s.opts.Workspace)
+		s.state.Tasks = map[string]int64{}
+	}
+	s.state.Board = board
+	if _, err := s.repo.git(nil, "fetch", "--quiet", "origin"); err != nil {
+		return err
+	}
+	branch, err := s.repo.branch()
+	if err != nil {
+		return err
+	}
+	head := s.repo.resolve("HEAD")
+	remote := s.repo.resolve("refs/remotes/origin/" + branch)
+	if s.columns, err = s.db.GetBoard(); err != nil {
+		return err
+	}
+
+	heads, err := s.records(head)
+	if err != nil {
+		return err
+	}
+	ours := s.export(heads)
+	s.result.Local = s.report("local", heads, ours)
+
+	// The remote has commits this clone lacks unless it is behind HEAD
+	ahead := remote != "" && (head == "" || !s.repo.isAncestor(remote, head))
+	base, theirs := heads, heads
+	if ahead {
+		since := ""
+		if head != "" {
+			out, err := s.repo.git(nil, "merge-base", head, remote)
+			if err != nil {
+				return err
+			}
+			since = strings.TrimSpace(out)
+		}
+		if base, err = s.records(since); err != nil {
+			return err
+		}
+		if theirs, err = s.records(remote); err != nil {
+			return err
+		}
+	}
+	s.result.Remote = s.report("remote", base, theirs)
+	if s.opts.Status {
+		return nil
+	}
+
+	merged := s.merge(base, ours, theirs)
+	if err := s.write(heads, merged); err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	message := fmt.Sprintf("Sync %s from %s: %s changed", s.opts.Workspace, host, countOf(s.result.Local, "task"))
+	switch {
+	case ahead && sameRecords(merged, theirs):
+		// Nothing to add to the remote's commits
+		if err := s.repo.checkout(remote); err != nil {
+			return err
+		}
+	case ahead && (head == "" || s.repo.isAncestor(head, remote)):
+		if _, err := s.repo.commit(remote, s.folder, message, remote); err != nil {
+			return err
+		}
+	case ahead:
+		message = fmt.Sprintf("Merge %s into %s on %s", countOf(s.result.Remote, "remote change"), s.opts.Workspace, host)
+		if _, err := s.repo.commit(remote, s.folder, message, head, remote); err != nil {
+			return err
+		}
+	case head != "":
+		if _, err := s.repo.commit(head, s.folder, message, head); err != nil {
+			return err
+		}
+	case len(merged) > 0:
+		if _, err := s.repo.commit("", s.folder, message); err != nil {
+			return err
+		}
+	}
+
+	if err := s.apply(merged); err != nil {
+		return err
+	}
+	if err := s.saveState(); err != nil {
+		return err
+	}
+
+	if head = s.repo.resolve("HEAD"); head != "" && head != remote {
+		if _, err := s.repo.git(nil, "push", "--quiet", "origin", "HEAD:refs/heads/"+branch); err != nil {
+			return fmt.Errorf("%w; the board is synced with this clone, sync again to push", err)
+		}
+		s.result.Pushed = true
+	}
+	return nil
+}
+
+// open opens the clone, cloning the remote first when there is none yet, and
+// loads the state of the last sync
+func (s *syncer) open() error {
+	s.repo = repo{dir: s.opts.Dir}
+	if _, err := os.Stat(filepath.Join(s.opts.Dir, ".git")); err != nil {
+		if s.opts.Remote == "" {
+			return fmt.Errorf("workspace %s was never synced with git: give the repository with --remote", s.opts.Workspace)
+		}
+		if s.opts.Status {
+			return fmt.Errorf("workspace %s was never synced with git: sync it once without --status", s.opts.Workspace)
+		}
+		if err := os.MkdirAll(filepath.Dir(s.opts.Dir), 0o700); err != nil {
+			return err
+		}
+		if s.repo, err = clone(s.opts.Remote, s.opts.Dir); err != nil {
+			return err
+		}
+	} else if s.opts.Remote != "" {
+		out, err := s.repo.git(nil, "remote", "get-url", "origin")
+		if err != nil {
+			return err
+		}
+		if url := strings.TrimSpace(out); url != s.opts.Remote {
+			return fmt.Errorf("workspace %s syncs with %s, not %s: run without --remote, or remove %s to start over", s.opts.Workspace, url, s.opts.Remote, s.opts.Dir)
+		}
+	}
+
+	s.state = state{Tasks: map[string]int64{}}
+	data, err := os.ReadFile(filepath.Join(s.opts.Dir, ".git", stateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &s.state)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", stateFile, err)
+	}
+	if s.state.Tasks == nil {
+		s.state.Tasks = map[string]int64{}
+	}
+	return nil
+}
+
+// records reads the task files of the board at commit by file ID
+func (s *syncer) records(commit string) (map[string]record, error) {
+	files, err := s.repo.files(commit, s.folder)
+	if err != nil {
+		return nil, err
+	}
+	records := map[string]record{}
+	for name, data := range files {
+		id, ok := strings.CutSuffix(name, ".json")
+		if !ok || strings.Contains(id, "/") {
+			continue
+		}
+		r, err := decodeRecord(data)
+		if err != nil {
+			if !s.broken[name] {
+				s.fail(name, err)
+				s.broken[name] = true
+			}
+			continue
+		}
+		records[id] = r
+	}
+	return records, nil
+}
+
+// export returns the records of the board, starting from those at HEAD: a
+// task whose fields didn't change keeps its record as it is, timestamps
+// included, so applying a sync doesn't come back as a change. Tasks the
+// board hasn't got, such as ones that failed to apply, keep theirs.
+func (s *syncer) export(heads map[string]record) map[string]record {
+	ids := map[int64]string{}
+	for id, task := range s.state.Tasks {
+		ids[task] = id
+	}
+	ours := map[string]record{}
+	for id, r := range heads {
+		if _, ok := s.state.Tasks[id]; !ok {
+			ours[id] = r
+		}
+	}
+	for _, col := range s.columns {
+		for _, task := range col.Tasks {
+			id, ok := ids[task.ID]
+			if !ok {
+				id = newID()
+				s.state.Tasks[id] = task.ID
+			}
+			r := newRecord(task)
+			if old, ok := heads[id]; ok && sameFields(old, r) {
+				r = old
+			}
+			ours[id] = r
+			s.tasks[id] = task
+		}
+	}
+	return ours
+}
+
+// report writes the changes from one set of records to another to Out,
+// one line each, prefixed with side, and returns how many there are
+func (s *syncer) report(side string, from, to map[string]record) int {
+	var lines []string
+	for id, r := range to {
+		switch old, ok := from[id]; {
+		case !ok:
+			lines = append(lines, fmt.Sprintf("%s: new task %q", side, r.Title))
+		case !sameRecord(old, r):
+			lines = append(lines, fmt.Sprintf("%s: changed task %q", side, r.Title))
+		}
+	}
+	for id, r := range from {
+		if _, ok := to[id]; !ok {
+			lines = append(lines, fmt.Sprintf("%s: removed task %q", side, r.Title))
+		}
+	}
+	if s.opts.Status {
+		sort.Strings(lines)
+		for _, line := range lines {
+			fmt.Fprintln(s.opts.Out, line)
+		}
+	}
+	return len(lines)
+}
+
+// merge merges the records of the board and of the remote, both changed
+// since base, as Sync describes
+func (s *syncer) merge(base, ours, theirs map[string]record) map[string]record {
+	merged := map[string]record{}
+	ids := map[string]bool{}
+	for _, records := range []map[string]record{base, ours, theirs} {
+		for id := range records {
+			ids[id] = true
+		}
+	}
+	for id := range ids {
+		b, inBase := base[id]
+		o, inOurs := ours[id]
+		t, inTheirs := theirs[id]
+		oursKept := inOurs == inBase && (!inOurs || sameRecord(o, b))
+		theirsKept := inTheirs == inBase && (!inTheirs || sameRecord(t, b))
+		switch {
+		case inOurs == inTheirs && (!inOurs || sameRecord(o, t)):
+			if inOurs {
+				merged[id] = o
+			}
+		case oursKept:
+			// Added, changed or deleted on the remote only
+			if inTheirs {
+				merged[id] = t
+			}
+		case theirsKept:
+			// Added, changed or deleted on the board only
+			if inOurs {
+				merged[id] = o
+			}
+		case inOurs && inTheirs:
+			merged[id] = mergeRecords(b, o, t)
+			s.result.Merged++
+			fmt.Fprintf(s.opts.Out, "merged task %q: changed on both sides\n", merged[id].Title)
+		default:
+			// Deleted on one side and changed on the other: the change stays
+			if inOurs {
+				merged[id] = o
+			} else {
+				merged[id] = t
+			}
+			fmt.Fprintf(s.opts.Out, "kept task %q: deleted on one side, changed on the other\n", merged[id].Title)
+		}
+	}
+	return merged
+}
+
+// write writes the merged records over the files of the board in the
+// work tree, removing the files of the records that went away
+func (s *syncer) write(heads, merged map[string]record) error {
+	dir := filepath.Join(s.opts.Dir, s.folder)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for id := range heads {
+		if _, ok := merged[id]; !ok {
+			if err := os.Remove(filepath.Join(dir, id+".json")); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	for id, r := range merged {
+		if err := os.WriteFile(filepath.Join(dir, id+".json"), r.encode(), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply brings the board in line with the merged records: tasks the remote
+// added are created, the ones it changed updated and the ones it deleted
+// archived
+func (s *syncer) apply(merged map[string]record) error {
+	for id, task := range s.tasks {
+		if _, ok := merged[id]; !ok {
+			fmt.Fprintf(s.opts.Out, "archived task %d %q: deleted on the remote\n", task.ID, task.Title)
+			if err := s.db.ArchiveTask(task.ID); err != nil {
+				s.fail(id+".json", err)
+				continue
+			}
+			delete(s.state.Tasks, id)
+			s.result.Archived++
+		}
+	}
+
+	ids := make([]string, 0, len(merged))
+	for id := range merged {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := merged[ids[i]], merged[ids[j]]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		r := merged[id]
+		col, ok := model.FindColumn(s.columns, r.Column)
+		if !ok {
+			s.fail(id+".json", fmt.Errorf("task %q is in column %q, which this board hasn't got", r.Title, r.Column))
+			continue
+		}
+		r.Column = string(col.Status)
+
+		task, onBoard := s.tasks[id]
+		if !onBoard {
+			task, onBoard = s.restore(id)
+		}
+		if !onBoard {
+			created, err := s.db.CreateTaskFrom(r.apply(model.Task{}))
+			if err != nil {
+				s.fail(id+".json", err)
+				continue
+			}
+			s.state.Tasks[id] = created.ID
+			fmt.Fprintf(s.opts.Out, "created task %d %q from the remote\n", created.ID, created.Title)
+			s.result.Created++
+			continue
+		}
+		if sameFields(newRecord(task), r) {
+			continue
+		}
+		if _, err := s.db.UpdateTaskFields(r.apply(task)); err != nil {
+			s.fail(id+".json", err)
+			continue
+		}
+		fmt.Fprintf(s.opts.Out, "updated task %d %q from the remote\n", task.ID, r.Title)
+		s.result.Updated++
+	}
+	return nil
+}
+
+// restore brings back from the archive the task of a file the board
+// archived while the remote changed it, reporting whether there was one
+func (s *syncer) restore(id string) (model.Task, bool) {
+	taskID, ok := s.state.Tasks[id]
+	if !ok {
+		return model.Task{}, false
+	}
+	task, err := s.db.GetTask(taskID)
+	if err != nil || task.DeletedAt != nil || task.ArchivedAt == nil {
+		delete(s.state.Tasks, id)
+		return model.Task{}, false
+	}
+	if err := s.db.UnarchiveTask(taskID); err != nil {
+		delete(s.state.Tasks, id)
+		return model.Task{}, false
+	}
+	fmt.Fprintf(s.opts.Out, "unarchived task %d %q: changed on the remote\n", task.ID, task.Title)
+	if task, err = s.db.GetTask(taskID); err != nil {
+		return model.Task{}, false
+	}
+	return *task, true
+}
+
+// saveState writes the state for the next sync
+func (s *syncer) saveState() error {
+	s.state.Workspace = s.folder
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.opts.Dir, ".git", stateFile), append(data, '\n'), 0o600)
+}
+
+// fail reports a file or task that couldn't be synced
+func (s *syncer) fail(name string, err error) {
+	fmt.Fprintf(s.opts.Out, "skipped %s: %v\n", name, err)
+	s.result.Failed++
+}
+
+// newID returns a new random file ID
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// sameRecord reports whether two records are the same, timestamps included
+func sameRecord(a, b record) bool {
+	return string(a.encode()) == string(b.encode())
+}
+
+// sameRecords reports whether two sets of records are the same
+func sameRecords(a, b map[string]record) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id, r := range a {
+		if other, ok := b[id]; !ok || !sameRecord(r, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// countOf returns n with the noun, plural unless n is 1
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,203 @@
+package gitsync
+
+import (
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// machine is a board synced through its own clone, as on one computer
+type machine struct {
+	db  *db.DB
+	dir string
+}
+
+// newRemote returns a bare repository to sync through, in a home of its
+// own so the git config of the user running the tests plays no part
+func newRemote(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	remote := filepath.Join(t.TempDir(), "boards.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	return remote
+}
+
+// newMachine returns an empty board with its clone yet to be made
+func newMachine(t *testing.T) *machine {
+	t.Helper()
+	database, err := db.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return &machine{db: database, dir: filepath.Join(t.TempDir(), "git", "work")}
+}
+
+// sync syncs the board of m with remote
+func (m *machine) sync(t *testing.T, remote string) Result {
+	t.Helper()
+	result, err := Sync(m.db, Options{Dir: m.dir, Remote: remote, Workspace: "work", Out: io.Discard})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if result.Failed > 0 {
+		t.Fatalf("Sync failed on %d tasks", result.Failed)
+	}
+	return result
+}
+
+// task returns the task on the board of m whose title starts with prefix
+func (m *machine) task(t *testing.T, prefix string) model.Task {
+	t.Helper()
+	columns, err := m.db.GetBoard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, col := range columns {
+		for _, task := range col.Tasks {
+			if strings.HasPrefix(task.Title, prefix) {
+				return task
+			}
+		}
+	}
+	t.Fatalf("no task %q on the board", prefix)
+	return model.Task{}
+}
+
+// titles returns the titles of the tasks on the board of m, sorted
+func (m *machine) titles(t *testing.T) []string {
+	t.Helper()
+	tasks, err := m.db.GetAllTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, task := range tasks {
+		if task.ArchivedAt == nil && task.DeletedAt == nil {
+			titles = append(titles, task.Title)
+		}
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+// update changes a task on the board of m
+func (m *machine) update(t *testing.T, task model.Task) {
+	t.Helper()
+	if _, err := m.db.UpdateTaskFields(task); err != nil {
+		t.Fatalf("UpdateTaskFields: %v", err)
+	}
+}
+
+// newSyncedPair returns two machines synced with remote, holding the tasks
+// Alpha and Beta made on the first
+func newSyncedPair(t *testing.T, remote string) (*machine, *machine) {
+	t.Helper()
+	laptop, desktop := newMachine(t), newMachine(t)
+	if _, err := laptop.db.CreateTasks([]model.Task{
+		{Title: "Alpha", Status: model.StatusTodo},
+		{Title: "Beta", Status: model.StatusInProgress, Tags: []string{"docs"}},
+	}); err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+
+	if got := laptop.sync(t, remote); !got.Pushed || got.Local != 2 {
+		t.Fatalf("first sync = %+v, want 2 local changes pushed", got)
+	}
+	if got := desktop.sync(t, remote); got.Created != 2 || got.Pushed {
+		t.Fatalf("sync of a new board = %+v, want 2 tasks created and nothing pushed", got)
+	}
+	return laptop, desktop
+}
+
+func TestSyncPushPull(t *testing.T) {
+	remote := newRemote(t)
+	laptop, desktop := newSyncedPair(t, remote)
+	if got, want := strings.Join(desktop.titles(t), ","), "Alpha,Beta"; got != want {
+		t.Fatalf("desktop holds %s, want %s", got, want)
+	}
+	if beta := desktop.task(t, "Beta"); beta.Status != model.StatusInProgress || len(beta.Tags) != 1 || beta.Tags[0] != "docs" {
+		t.Errorf("Beta came over as %+v", beta)
+	}
+
+	// A change pushed from one side is pulled by the other
+	alpha := desktop.task(t, "Alpha")
+	alpha.Title, alpha.Status = "Alpha, reworded", model.StatusDone
+	desktop.update(t, alpha)
+	if got := desktop.sync(t, remote); !got.Pushed || got.Local != 1 {
+		t.Fatalf("sync after a change = %+v, want 1 local change pushed", got)
+	}
+	if got := laptop.sync(t, remote); got.Updated != 1 || got.Pushed {
+		t.Fatalf("sync of the other side = %+v, want 1 task updated", got)
+	}
+	if got := laptop.task(t, "Alpha"); got.Title != "Alpha, reworded" || got.Status != model.StatusDone {
+		t.Errorf("laptop has Alpha as %q in %s", got.Title, got.Status)
+	}
+
+	// A task deleted on one side is archived on the other
+	if err := laptop.db.DeleteTask(laptop.task(t, "Beta").ID); err != nil {
+		t.Fatal(err)
+	}
+	laptop.sync(t, remote)
+	if got := desktop.sync(t, remote); got.Archived != 1 {
+		t.Fatalf("sync after a deletion = %+v, want 1 task archived", got)
+	}
+	if got := strings.Join(desktop.titles(t), ","); got != "Alpha, reworded" {
+		t.Errorf("desktop holds %s, want only Alpha", got)
+	}
+
+	// Syncing again changes nothing
+	if got := desktop.sync(t, remote); got != (Result{}) {
+		t.Errorf("sync without changes = %+v", got)
+	}
+}
+
+func TestSyncConflict(t *testing.T) {
+	remote := newRemote(t)
+	laptop, desktop := newSyncedPair(t, remote)
+
+	alpha := laptop.task(t, "Alpha")
+	alpha.Title = "Alpha on the laptop"
+	laptop.update(t, alpha)
+	laptop.sync(t, remote)
+
+	// The desktop changed the same task before pulling
+	alpha = desktop.task(t, "Alpha")
+	alpha.Title, alpha.Priority = "Alpha on the desktop", model.PriorityHigh
+	desktop.update(t, alpha)
+	if got := desktop.sync(t, remote); got.Merged != 1 || !got.Pushed {
+		t.Fatalf("sync of a conflicting change = %+v, want 1 task merged and pushed", got)
+	}
+
+	merged := desktop.task(t, "Alpha")
+	if !strings.Contains(merged.Title, "Alpha on the laptop") || !strings.Contains(merged.Title, "Alpha on the desktop") {
+		t.Errorf("merged title = %q, want both titles", merged.Title)
+	}
+	if merged.Priority != model.PriorityHigh {
+		t.Errorf("merged priority = %q, want the desktop's high", merged.Priority)
+	}
+
+	if got := laptop.sync(t, remote); got.Updated != 1 {
+		t.Fatalf("sync of the merge = %+v, want 1 task updated", got)
+	}
+	if got := laptop.task(t, "Alpha"); got.Title != merged.Title || got.Priority != merged.Priority {
+		t.Errorf("laptop has Alpha as %q at %q, want the merge %q at %q", got.Title, got.Priority, merged.Title, merged.Priority)
+	}
+	if got := strings.Join(laptop.titles(t), "|"); got != strings.Join(desktop.titles(t), "|") {
+		t.Errorf("the boards differ after syncing: %s and %s", got, strings.Join(desktop.titles(t), "|"))
+	}
+}
@@ -0,0 +1,144 @@
+package gitsync
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// dueLayout is the layout of due dates in task files, in the local time
+// of the machine like the database keeps them
+const dueLayout = "2006-01-02T15:04:05"
+
+// record is the content of a task file: the fields sync carries between
+// machines and the timestamps its conflict policy goes by. Keys come out
+// in this order and tags sorted, so a task encodes the same way on every
+// machine and a change shows as a small diff.
+type record struct {
+	Title       string     `json:"title"`
+	Column      string     `json:"column"`
+	Priority    string     `json:"priority,omitempty"`
+	Due         string     `json:"due,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// newRecord returns the record of a task
+func newRecord(task model.Task) record {
+	r := record{
+		Title:       strings.TrimSpace(task.Title),
+		Column:      string(task.Status),
+		Priority:    string(task.Priority),
+		Tags:        cleanTags(task.Tags),
+		Description: strings.TrimSpace(task.Description),
+		CreatedAt:   task.CreatedAt.UTC().Truncate(time.Second),
+		UpdatedAt:   task.UpdatedAt.UTC().Truncate(time.Second),
+	}
+	if task.Due != nil {
+		r.Due = task.Due.Format(dueLayout)
+	}
+	if task.CompletedAt != nil {
+		completed := task.CompletedAt.UTC().Truncate(time.Second)
+		r.CompletedAt = &completed
+	}
+	return r
+}
+
+// apply returns task with the fields of the record
+func (r record) apply(task model.Task) model.Task {
+	task.Title = r.Title
+	task.Status = model.TaskStatus(r.Column)
+	task.Priority = model.TaskPriority(r.Priority)
+	task.Tags = r.Tags
+	task.Description = r.Description
+	task.Due = nil
+	if r.Due != "" {
+		if due, err := time.ParseInLocation(dueLayout, r.Due, time.Local); err == nil {
+			task.Due = &due
+		}
+	}
+	return task
+}
+
+// encode returns the file content of the record
+func (r record) encode() []byte {
+	data, _ := json.MarshalIndent(r, "", "  ")
+	return append(data, '\n')
+}
+
+// decodeRecord parses a task file
+func decodeRecord(data []byte) (record, error) {
+	var r record
+	err := json.Unmarshal(data, &r)
+	r.Tags = cleanTags(r.Tags)
+	return r, err
+}
+
+// sameFields reports whether a and b agree on every field sync carries,
+// leaving the timestamps aside
+func sameFields(a, b record) bool {
+	return a.Title == b.Title && a.Column == b.Column && a.Priority == b.Priority && a.Due == b.Due &&
+		strings.Join(a.Tags, "\n") == strings.Join(b.Tags, "\n") && a.Description == b.Description
+}
+
+// cleanTags lowercases tags, drops blank and repeated ones and sorts them
+func cleanTags(tags []string) []string {
+	var cleaned []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			cleaned = append(cleaned, tag)
+			seen[tag] = true
+		}
+	}
+	sort.Strings(cleaned)
+	return cleaned
+}
+
+// mergeRecords merges a task changed on both sides since base. A field
+// changed on one side takes that change. A field changed on both takes the
+// side updated last, but for the title, which keeps both: the newer title
+// first, then the older one.
+func mergeRecords(base, ours, theirs record) record {
+	newer, older := ours, theirs
+	if theirs.UpdatedAt.After(ours.UpdatedAt) {
+		newer, older = theirs, ours
+	}
+	merged := newer
+	pick := func(base, ours, theirs, newer string) string {
+		switch {
+		case ours == base:
+			return theirs
+		case theirs == base:
+			return ours
+		}
+		return newer
+	}
+	merged.Title = pick(base.Title, ours.Title, theirs.Title, newer.Title)
+	if ours.Title != base.Title && theirs.Title != base.Title && ours.Title != theirs.Title {
+		merged.Title = newer.Title + " / " + older.Title
+	}
+	merged.Column = pick(base.Column, ours.Column, theirs.Column, newer.Column)
+	merged.Priority = pick(base.Priority, ours.Priority, theirs.Priority, newer.Priority)
+	merged.Due = pick(base.Due, ours.Due, theirs.Due, newer.Due)
+	merged.Description = pick(base.Description, ours.Description, theirs.Description, newer.Description)
+	tags := pick(strings.Join(base.Tags, "\n"), strings.Join(ours.Tags, "\n"), strings.Join(theirs.Tags, "\n"), strings.Join(newer.Tags, "\n"))
+	merged.Tags = nil
+	if tags != "" {
+		merged.Tags = strings.Split(tags, "\n")
+	}
+	if older.CreatedAt.Before(merged.CreatedAt) {
+		merged.CreatedAt = older.CreatedAt
+	}
+	if merged.Column != newer.Column {
+		merged.CompletedAt = older.CompletedAt
+	}
+	return merged
+}
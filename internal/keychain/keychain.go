@@ -0,0 +1,98 @@
+// Package keychain keeps passphrases in the keychain of the operating
+// system: the login keychain through security on macOS, and the Secret
+// Service (GNOME Keyring, KWallet) through secret-tool elsewhere.
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// service names the entries of cli_kanban in the keychain
+const service = "cli_kanban"
+
+// ErrUnavailable reports that no keychain program could be run
+var ErrUnavailable = errors.New("no keychain found: install secret-tool (libsecret-tools) or use macOS")
+
+// tool returns the keychain program of the system, or "" when there is none
+func tool() string {
+	name := "secret-tool"
+	if runtime.GOOS == "darwin" {
+		name = "security"
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return ""
+	}
+	return name
+}
+
+// Get returns the secret saved for account, reporting whether there is one
+func Get(account string) (string, bool) {
+	var cmd *exec.Cmd
+	switch tool() {
+	case "security":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "secret-tool":
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	default:
+		return "", false
+	}
+	out, err := cmd.Output()
+	if err != nil || len(out) == 0 {
+		return "", false
+	}
+	return strings.TrimSuffix(string(out), "\n"), true
+}
+
+// Set saves secret for account, replacing the one saved before. The secret
+// goes to the program on its input, never on its command line.
+func Set(account, secret string) error {
+	var cmd *exec.Cmd
+	switch tool() {
+	case "security":
+		// security -i reads its commands from the input
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", service, quote(account), quote(secret)))
+	case "secret-tool":
+		cmd = exec.Command("secret-tool", "store", "--label", service+" "+account, "service", service, "account", account)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return ErrUnavailable
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to save the passphrase in the keychain: %s", strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return nil
+}
+
+// Delete removes the secret saved for account, if any
+func Delete(account string) error {
+	var cmd *exec.Cmd
+	switch tool() {
+	case "security":
+		cmd = exec.Command("security", "delete-generic-password", "-s", service, "-a", account)
+	case "secret-tool":
+		cmd = exec.Command("secret-tool", "clear", "service", service, "account", account)
+	default:
+		return ErrUnavailable
+	}
+	// Both fail when there was nothing to delete
+	if _, ok := Get(account); !ok {
+		return nil
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to remove the passphrase from the keychain: %w", err)
+	}
+	return nil
+}
+
+// quote quotes a word for the command line of security -i
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,75 @@
+package mdsync
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 2
+
+// writeDiff writes a line diff turning a into b, with the unchanged lines
+// away from any change left out
+func writeDiff(w io.Writer, from, to string, a, b []byte) {
+	before := strings.Split(strings.TrimRight(string(a), "\n"), "\n")
+	after := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// before[i:] and after[j:]
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			lines = append(lines, " "+before[i])
+			i++
+			j++
+		case i < len(before) && (j == len(after) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "-"+before[i])
+			i++
+		default:
+			lines = append(lines, "+"+after[j])
+			j++
+		}
+	}
+
+	fmt.Fprintf(w, "    --- %s\n    +++ %s\n", from, to)
+	skipped := false
+	for k, line := range lines {
+		if line[0] == ' ' && !nearChange(lines, k) {
+			if !skipped {
+				fmt.Fprintln(w, "    ...")
+				skipped = true
+			}
+			continue
+		}
+		skipped = false
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+// nearChange reports whether lines[k] is within diffContext lines of an
+// added or removed line
+func nearChange(lines []string, k int) bool {
+	for d := -diffContext; d <= diffContext; d++ {
+		if k+d >= 0 && k+d < len(lines) && lines[k+d][0] != ' ' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,350 @@
+// Package mdsync mirrors a board as a folder of markdown files, one per task,
+// such as a folder of an Obsidian vault, and syncs edits both ways.
+package mdsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// StateFile is the file in the folder remembering the workspace it mirrors
+// and the file of each task after the last sync
+const StateFile = ".cli_kanban-sync.json"
+
+// state is the content of StateFile
+type state struct {
+	Workspace string           `json:"workspace"`
+	Files     map[int64]string `json:"files"` // file name by task ID
+}
+
+// Options configure Sync
+type Options struct {
+	Dir       string
+	Workspace string    // name of the workspace, which the folder is tied to
+	DryRun    bool      // only report what would change, with diffs
+	Out       io.Writer // receives one line per change
+}
+
+// Result counts what Sync did, or would do in a dry run
+type Result struct {
+	Written  int // files written for new or changed tasks
+	Updated  int // tasks updated from their edited file
+	Created  int // tasks created from files without an id
+	Archived int // tasks archived because their file was deleted
+	Removed  int // files removed because their task left the board
+	Failed   int // files that couldn't be synced, reported to Out
+}
+
+// Sync brings the folder and the board in line. Each task on the board has a
+// file with its id, title, column, labels, due date and priority as YAML
+// frontmatter and its description as body. Where a file and its task
+// differ, the newer one wins: a file modified after the task was last updated
+// updates the task, otherwise the file is rewritten from the task. A file
+// without an id creates a task. A task whose file was deleted since the last
+// sync is archived, and the file of a task that left the board, archived or
+// deleted, is removed.
+func Sync(database *db.DB, opts Options) (Result, error) {
+	s := &syncer{db: database, opts: opts, unreadable: map[string]bool{}}
+	if err := s.run(); err != nil {
+		return s.result, err
+	}
+	return s.result, nil
+}
+
+// syncer carries a sync in progress
+type syncer struct {
+	db         *db.DB
+	opts       Options
+	columns    []model.Column
+	unreadable map[string]bool // files that failed to parse, already reported
+	result     Result
+}
+
+// run syncs the folder with the board
+func (s *syncer) run() error {
+	st, err := s.loadState()
+	if err != nil {
+		return err
+	}
+	if st.Workspace != "" && st.Workspace != s.opts.Workspace {
+		return fmt.Errorf("%s mirrors workspace %q, not %q: use another folder", s.opts.Dir, st.Workspace, s.opts.Workspace)
+	}
+	if s.columns, err = s.db.GetBoard(); err != nil {
+		return err
+	}
+	notes, fresh, err := s.readNotes()
+	if err != nil {
+		return err
+	}
+
+	kept := map[int64]string{}
+	for _, col := range s.columns {
+		for _, task := range col.Tasks {
+			n := notes[task.ID]
+			delete(notes, task.ID)
+			name, synced := st.Files[task.ID]
+			switch {
+			case n != nil:
+				s.reconcile(task, col.Name, n)
+				kept[task.ID] = n.name
+			case synced && fileExists(filepath.Join(s.opts.Dir, name)):
+				// The file is still there, so the task stays, but it
+				// couldn't be read or its id was changed
+				if !s.unreadable[name] {
+					s.fail(name, fmt.Errorf("no longer has the id of task %d, which is left alone", task.ID))
+				}
+				kept[task.ID] = name
+			case synced:
+				s.report("archived", "would archive", "task %d: its file was deleted", task.ID)
+				if !s.opts.DryRun {
+					if err := s.db.ArchiveTask(task.ID); err != nil {
+						return err
+					}
+				}
+				s.result.Archived++
+			default:
+				if name, ok := s.writeNew(task, col.Name); ok {
+					kept[task.ID] = name
+				}
+			}
+		}
+	}
+
+	ids := make([]int64, 0, len(notes))
+	for id := range notes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		n := notes[id]
+		if _, synced := st.Files[id]; !synced {
+			s.fail(n.name, fmt.Errorf("no task %d on the board", id))
+			continue
+		}
+		s.report("removed", "would remove", "%s: task %d left the board", n.name, id)
+		if !s.opts.DryRun {
+			if err := os.Remove(filepath.Join(s.opts.Dir, n.name)); err != nil {
+				return err
+			}
+		}
+		s.result.Removed++
+	}
+
+	for _, n := range fresh {
+		if id, ok := s.create(n); ok {
+			kept[id] = n.name
+		}
+	}
+
+	if s.opts.DryRun {
+		return nil
+	}
+	return s.saveState(state{Workspace: s.opts.Workspace, Files: kept})
+}
+
+// readNotes reads the markdown files of the folder: those with an id by
+// task ID, and those without one in name order. A missing folder is empty.
+func (s *syncer) readNotes() (map[int64]*note, []*note, error) {
+	notes := map[int64]*note{}
+	var fresh []*note
+	entries, err := os.ReadDir(s.opts.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return notes, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %q: %w", s.opts.Dir, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".md") || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		n, err := readNote(s.opts.Dir, e.Name())
+		if err != nil {
+			s.fail(e.Name(), err)
+			s.unreadable[e.Name()] = true
+			continue
+		}
+		switch other := notes[n.fields.ID]; {
+		case n.fields.ID == 0:
+			fresh = append(fresh, n)
+		case other != nil:
+			s.fail(n.name, fmt.Errorf("%s has id %d too", other.name, n.fields.ID))
+		default:
+			notes[n.fields.ID] = n
+		}
+	}
+	return notes, fresh, nil
+}
+
+// reconcile syncs a task with its file
+func (s *syncer) reconcile(task model.Task, column string, n *note) {
+	edited, err := n.task(task, s.columns)
+	if err != nil {
+		s.fail(n.name, err)
+		return
+	}
+	if sameTask(task, edited) {
+		return
+	}
+
+	if n.modTime.After(task.UpdatedAt) {
+		s.report("updated", "would update", "task %d from %s", task.ID, n.name)
+		if s.opts.DryRun {
+			before, _ := render(task, column, nil)
+			after, _ := render(edited, s.columnName(edited.Status), nil)
+			writeDiff(s.opts.Out, fmt.Sprintf("task %d", task.ID), n.name, before, after)
+		} else if _, err := s.db.UpdateTaskFields(edited); err != nil {
+			s.fail(n.name, err)
+			return
+		}
+		s.result.Updated++
+		return
+	}
+
+	data, err := render(task, column, n.meta)
+	if err != nil {
+		s.fail(n.name, err)
+		return
+	}
+	s.report("wrote", "would write", "%s: task %d changed", n.name, task.ID)
+	if s.opts.DryRun {
+		writeDiff(s.opts.Out, n.name, fmt.Sprintf("task %d", task.ID), n.data, data)
+	} else if err := os.WriteFile(filepath.Join(s.opts.Dir, n.name), data, 0o644); err != nil {
+		s.fail(n.name, err)
+		return
+	}
+	s.result.Written++
+}
+
+// writeNew writes the file of a task that has none, returning its name and
+// whether it was written
+func (s *syncer) writeNew(task model.Task, column string) (string, bool) {
+	name := fileName(task)
+	data, err := render(task, column, nil)
+	if err != nil {
+		s.fail(name, err)
+		return name, false
+	}
+	s.report("wrote", "would write", "%s: new task %d", name, task.ID)
+	if !s.opts.DryRun {
+		if err := os.MkdirAll(s.opts.Dir, 0o755); err != nil {
+			s.fail(name, err)
+			return name, false
+		}
+		f, err := os.OpenFile(filepath.Join(s.opts.Dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			s.fail(name, err)
+			return name, false
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			s.fail(name, err)
+			return name, false
+		}
+	}
+	s.result.Written++
+	return name, true
+}
+
+// create adds a task for a file without an id, at the top of its column or
+// of the first column, then writes the new id into the file
+func (s *syncer) create(n *note) (int64, bool) {
+	base := model.Task{Title: strings.TrimSuffix(n.name, filepath.Ext(n.name)), Status: s.columns[0].Status}
+	draft, err := n.task(base, s.columns)
+	if err != nil {
+		s.fail(n.name, err)
+		return 0, false
+	}
+	s.report("created", "would create", "a task from %s", n.name)
+	s.result.Created++
+	if s.opts.DryRun {
+		return 0, false
+	}
+
+	task, err := s.db.CreateTaskFrom(draft)
+	if err != nil {
+		s.fail(n.name, err)
+		return 0, false
+	}
+	data, err := render(*task, s.columnName(task.Status), n.meta)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(s.opts.Dir, n.name), data, 0o644)
+	}
+	if err != nil {
+		// Without its id the file would create the task again next time
+		s.fail(n.name, fmt.Errorf("created task %d but failed to write its id: %w", task.ID, err))
+		return task.ID, true
+	}
+	return task.ID, true
+}
+
+// columnName returns the name of the column with the given status
+func (s *syncer) columnName(status model.TaskStatus) string {
+	for _, col := range s.columns {
+		if col.Status == status {
+			return col.Name
+		}
+	}
+	return string(status)
+}
+
+// report writes one change, with the verb for a dry run when there is one
+func (s *syncer) report(done, dryRun, format string, args ...interface{}) {
+	verb := done
+	if s.opts.DryRun {
+		verb = dryRun
+	}
+	fmt.Fprintf(s.opts.Out, "%s %s\n", verb, fmt.Sprintf(format, args...))
+}
+
+// fail reports a file that couldn't be synced
+func (s *syncer) fail(name string, err error) {
+	fmt.Fprintf(s.opts.Out, "skipped %s: %v\n", name, err)
+	s.result.Failed++
+}
+
+// loadState reads the state file, empty before the first sync
+func (s *syncer) loadState() (state, error) {
+	var st state
+	data, err := os.ReadFile(filepath.Join(s.opts.Dir, StateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("failed to read %s: %w", StateFile, err)
+	}
+	return st, nil
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// saveState writes the state file for the next sync
+func (s *syncer) saveState(st state) error {
+	if err := os.MkdirAll(s.opts.Dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.opts.Dir, StateFile), append(data, '\n'), 0o644)
+}
@@ -0,0 +1,215 @@
+package mdsync
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// newSyncedBoard returns a board of three tasks, one in each column, and the
+// folder it was synced to
+func newSyncedBoard(t *testing.T) (*db.DB, string) {
+	t.Helper()
+	database, err := db.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	due := time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC)
+	if _, err := database.CreateTasks([]model.Task{
+		{Title: "Fix login", Status: model.StatusTodo, Priority: model.PriorityHigh, Due: &due,
+			Tags: []string{"bug", "auth"}, Description: "Users are logged out.\n\n- check the cookie"},
+		{Title: "Title: with a colon # and a hash", Status: model.StatusInProgress},
+		{Title: "Release", Status: model.StatusDone, Tags: []string{"ops"}},
+	}); err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "vault", "board")
+	if got := syncFolder(t, database, dir); got != (Result{Written: 3}) {
+		t.Fatalf("first sync = %+v, want 3 files written", got)
+	}
+	return database, dir
+}
+
+// syncFolder syncs database with dir and returns what it did, failing the
+// test on an error
+func syncFolder(t *testing.T, database *db.DB, dir string) Result {
+	t.Helper()
+	result, err := Sync(database, Options{Dir: dir, Workspace: "work", Out: io.Discard})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	return result
+}
+
+// tasks returns the tasks on the board by ID
+func tasks(t *testing.T, database *db.DB) map[int64]model.Task {
+	t.Helper()
+	columns, err := database.GetBoard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := map[int64]model.Task{}
+	for _, col := range columns {
+		for _, task := range col.Tasks {
+			byID[task.ID] = task
+		}
+	}
+	return byID
+}
+
+// edit writes data to the file name of dir, dated after any change of the
+// board so that the file wins
+func edit(t *testing.T, dir, name, data string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncRoundTrip(t *testing.T) {
+	database, dir := newSyncedBoard(t)
+	before := tasks(t, database)
+	columns, err := database.GetBoard()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each file reads back as the task it was written from
+	for id, task := range before {
+		n, err := readNote(dir, fileName(task))
+		if err != nil {
+			t.Fatalf("readNote: %v", err)
+		}
+		if n.fields.ID != id {
+			t.Errorf("%s has id %d, want %d", n.name, n.fields.ID, id)
+		}
+		got, err := n.task(model.Task{ID: id}, columns)
+		if err != nil {
+			t.Fatalf("%s: %v", n.name, err)
+		}
+		if !sameTask(got, task) {
+			t.Errorf("%s reads back as %+v, want %+v", n.name, got, task)
+		}
+	}
+
+	// Syncing again changes neither side
+	if got := syncFolder(t, database, dir); got != (Result{}) {
+		t.Errorf("sync without changes = %+v", got)
+	}
+	after := tasks(t, database)
+	for id, task := range before {
+		if !sameTask(after[id], task) || after[id].Version != task.Version {
+			t.Errorf("task %d changed to %+v", id, after[id])
+		}
+	}
+}
+
+func TestSyncEditedFile(t *testing.T) {
+	database, dir := newSyncedBoard(t)
+	var fix model.Task
+	for _, task := range tasks(t, database) {
+		if task.Title == "Fix login" {
+			fix = task
+		}
+	}
+
+	edit(t, dir, fileName(fix), "---\n"+
+		"id: "+strconv.FormatInt(fix.ID, 10)+"\n"+
+		"title: Fix the login\n"+
+		"column: done\n"+
+		"labels: [Bug, bug, security]\n"+
+		"due: 2026-04-15\n"+
+		"aliases: [login]\n"+
+		"---\n\nFixed by renewing the cookie.\n")
+	if got := syncFolder(t, database, dir); got != (Result{Updated: 1}) {
+		t.Fatalf("sync of an edited file = %+v, want 1 task updated", got)
+	}
+
+	got := tasks(t, database)[fix.ID]
+	if got.Title != "Fix the login" || got.Status != model.StatusDone || got.Priority != model.PriorityNone {
+		t.Errorf("task is %q in %s at %q, want the edited title, column and no priority", got.Title, got.Status, got.Priority)
+	}
+	if strings.Join(got.Tags, ",") != "bug,security" {
+		t.Errorf("tags = %v, want bug,security", got.Tags)
+	}
+	if got.Due == nil || got.Due.Format(dueLayout) != "2026-04-15" {
+		t.Errorf("due = %v, want 2026-04-15", got.Due)
+	}
+	if got.Description != "Fixed by renewing the cookie." {
+		t.Errorf("description = %q", got.Description)
+	}
+
+	// A file without an id creates a task and is given its id
+	edit(t, dir, "Call the bank.md", "---\ncolumn: in progress\n---\nAbout the card\n")
+	if got := syncFolder(t, database, dir); got != (Result{Created: 1}) {
+		t.Fatalf("sync of a new file = %+v, want 1 task created", got)
+	}
+	n, err := readNote(dir, "Call the bank.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, ok := tasks(t, database)[n.fields.ID]
+	if !ok || created.Title != "Call the bank" || created.Status != model.StatusInProgress || created.Description != "About the card" {
+		t.Errorf("file created %+v with id %d", created, n.fields.ID)
+	}
+}
+
+func TestSyncMalformedFile(t *testing.T) {
+	tests := []struct {
+		name string
+		rest string // the file after its id
+		want string
+	}{
+		{"unclosed frontmatter", "title: Open\n", "no closing ---"},
+		{"invalid yaml", "title: [Open\n---\n", "invalid frontmatter"},
+		{"wrong type", "labels: {a: b}\n---\n", "invalid frontmatter"},
+		{"unknown column", "column: Someday\n---\n", `no column "Someday"`},
+		{"invalid priority", "priority: asap\n---\n", `invalid priority "asap"`},
+		{"invalid due date", "due: next week\n---\n", `invalid due date "next week"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database, dir := newSyncedBoard(t)
+			before := tasks(t, database)
+			var release model.Task
+			for _, task := range before {
+				if task.Title == "Release" {
+					release = task
+				}
+			}
+			edit(t, dir, fileName(release), "---\nid: "+strconv.FormatInt(release.ID, 10)+"\n"+tt.rest)
+
+			var out strings.Builder
+			got, err := Sync(database, Options{Dir: dir, Workspace: "work", Out: &out})
+			if err != nil {
+				t.Fatalf("Sync: %v", err)
+			}
+			if got != (Result{Failed: 1}) {
+				t.Errorf("sync = %+v, want only the file failed", got)
+			}
+			if !strings.Contains(out.String(), "skipped "+fileName(release)) || !strings.Contains(out.String(), tt.want) {
+				t.Errorf("sync reported %q, want the file skipped with %q", out.String(), tt.want)
+			}
+			after := tasks(t, database)
+			for id, task := range before {
+				if !sameTask(after[id], task) || after[id].Version != task.Version {
+					t.Errorf("task %d changed to %+v", id, after[id])
+				}
+			}
+		})
+	}
+}
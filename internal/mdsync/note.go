@@ -0,0 +1,240 @@
+package mdsync
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// dueLayout is the layout of due dates in the frontmatter
+const dueLayout = "2006-01-02"
+
+// note is a task file read from the folder
+type note struct {
+	name    string // file name within the folder
+	modTime time.Time
+	data    []byte
+	meta    *yaml.Node // frontmatter mapping, keeping keys other than the task's
+	fields  fields
+	body    string
+}
+
+// fields are the frontmatter keys that mirror a task
+type fields struct {
+	ID       int64    `yaml:"id"`
+	Title    string   `yaml:"title"`
+	Column   string   `yaml:"column"`
+	Labels   []string `yaml:"labels"`
+	Due      string   `yaml:"due"`
+	Priority string   `yaml:"priority"`
+}
+
+// readNote reads and parses a task file
+func readNote(dir, name string) (*note, error) {
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	n := &note{name: name, modTime: info.ModTime(), data: data, meta: &yaml.Node{Kind: yaml.MappingNode}}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	n.body = text
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		end := strings.Index(rest, "\n---\n")
+		if end < 0 && strings.HasSuffix(rest, "\n---") {
+			end = len(rest) - len("\n---")
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("the frontmatter has no closing ---")
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(rest[:end+1]), &doc); err != nil {
+			return nil, fmt.Errorf("invalid frontmatter: %w", err)
+		}
+		if len(doc.Content) == 1 && doc.Content[0].Kind == yaml.MappingNode {
+			n.meta = doc.Content[0]
+			if err := n.meta.Decode(&n.fields); err != nil {
+				return nil, fmt.Errorf("invalid frontmatter: %w", err)
+			}
+		}
+		n.body = strings.TrimPrefix(rest[end+1:], "---")
+	}
+	n.body = strings.TrimSpace(n.body)
+	return n, nil
+}
+
+// task returns base with the fields of the note applied. A note without a
+// title keeps base's title, and one without a column base's column.
+func (n *note) task(base model.Task, columns []model.Column) (model.Task, error) {
+	task := base
+	if title := strings.TrimSpace(n.fields.Title); title != "" {
+		task.Title = title
+	}
+	if n.fields.Column != "" {
+		col, ok := model.FindColumn(columns, n.fields.Column)
+		if !ok {
+			return task, fmt.Errorf("no column %q on the board", n.fields.Column)
+		}
+		task.Status = col.Status
+	}
+	switch priority := model.TaskPriority(strings.ToLower(strings.TrimSpace(n.fields.Priority))); priority {
+	case model.PriorityNone, model.PriorityLow, model.PriorityMedium, model.PriorityHigh, model.PriorityUrgent:
+		task.Priority = priority
+	default:
+		return task, fmt.Errorf("invalid priority %q: use low, medium, high or urgent", n.fields.Priority)
+	}
+	task.Due = nil
+	if n.fields.Due != "" {
+		due, err := time.Parse(dueLayout, strings.TrimSpace(n.fields.Due))
+		if err != nil {
+			return task, fmt.Errorf("invalid due date %q: use YYYY-MM-DD", n.fields.Due)
+		}
+		task.Due = &due
+	}
+	task.Tags = cleanLabels(n.fields.Labels)
+	task.Description = n.body
+	return task, nil
+}
+
+// cleanLabels lowercases labels and drops blank and repeated ones, as the
+// database stores them
+func cleanLabels(labels []string) []string {
+	cleaned := []string{}
+	seen := map[string]bool{}
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label != "" && !seen[label] {
+			cleaned = append(cleaned, label)
+			seen[label] = true
+		}
+	}
+	return cleaned
+}
+
+// sameTask reports whether a and b agree on everything a task file mirrors
+func sameTask(a, b model.Task) bool {
+	if strings.TrimSpace(a.Title) != strings.TrimSpace(b.Title) || a.Status != b.Status || a.Priority != b.Priority ||
+		strings.TrimSpace(a.Description) != strings.TrimSpace(b.Description) || dueText(a.Due) != dueText(b.Due) {
+		return false
+	}
+	tagsA, tagsB := cleanLabels(a.Tags), cleanLabels(b.Tags)
+	sort.Strings(tagsA)
+	sort.Strings(tagsB)
+	return strings.Join(tagsA, "\n") == strings.Join(tagsB, "\n")
+}
+
+// dueText formats a due date for the frontmatter, "" without one
+func dueText(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	return due.Format(dueLayout)
+}
+
+// render returns the file for task in the column named column. The keys of
+// meta other than the task's are kept, so properties added to the file, such
+// as Obsidian aliases, survive a rewrite; meta may be nil.
+func render(task model.Task, column string, meta *yaml.Node) ([]byte, error) {
+	m := &yaml.Node{Kind: yaml.MappingNode}
+	if meta != nil {
+		copied := *meta
+		copied.Content = append([]*yaml.Node(nil), meta.Content...)
+		m = &copied
+	}
+	labels := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, tag := range task.Tags {
+		labels.Content = append(labels.Content, scalar(tag))
+	}
+	setKey(m, "id", scalar(task.ID))
+	setKey(m, "title", scalar(task.Title))
+	setKey(m, "column", scalar(column))
+	setKey(m, "labels", labels)
+	if task.Due != nil {
+		// Plain, so that Obsidian reads it as a date
+		setKey(m, "due", &yaml.Node{Kind: yaml.ScalarNode, Value: dueText(task.Due)})
+	} else {
+		deleteKey(m, "due")
+	}
+	if task.Priority != model.PriorityNone {
+		setKey(m, "priority", scalar(string(task.Priority)))
+	} else {
+		deleteKey(m, "priority")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(m); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("---\n")
+	if description := strings.TrimSpace(task.Description); description != "" {
+		buf.WriteString("\n" + description + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// scalar returns a YAML node holding v, quoted where needed to read back the same
+func scalar(v interface{}) *yaml.Node {
+	var n yaml.Node
+	_ = n.Encode(v)
+	return &n
+}
+
+// setKey sets key in the mapping m, adding it when missing: at the start for
+// the id, which heads the frontmatter, and at the end for other keys
+func setKey(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	if key == "id" {
+		m.Content = append([]*yaml.Node{scalar(key), value}, m.Content...)
+		return
+	}
+	m.Content = append(m.Content, scalar(key), value)
+}
+
+// deleteKey removes key from the mapping m
+func deleteKey(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// fileNameRe matches runs of characters left out of file names
+var fileNameRe = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// fileName returns the name of a new task's file, e.g. 42-fix-login.md
+func fileName(task model.Task) string {
+	slug := strings.Trim(fileNameRe.ReplaceAllString(strings.ToLower(task.Title), "-"), "-")
+	if runes := []rune(slug); len(runes) > 50 {
+		slug = strings.TrimRight(string(runes[:50]), "-")
+	}
+	if slug == "" {
+		return fmt.Sprintf("%d.md", task.ID)
+	}
+	return fmt.Sprintf("%d-%s.md", task.ID, slug)
+}
@@ -1,8 +1,12 @@
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
-// TaskStatus represents the status column of a task
+// TaskStatus identifies the column a task is in. Each workspace defines its
+// own columns; the constants below are the keys of the default columns.
 type TaskStatus string
 
 const (
@@ -11,54 +15,201 @@ const (
 	StatusDone       TaskStatus = "done"
 )
 
+// TaskPriority represents how urgent a task is
+type TaskPriority string
+
+const (
+	PriorityNone   TaskPriority = ""
+	PriorityLow    TaskPriority = "low"
+	PriorityMedium TaskPriority = "medium"
+	PriorityHigh   TaskPriority = "high"
+	PriorityUrgent TaskPriority = "urgent"
+)
+
 // Task represents a kanban task item
 type Task struct {
-	ID          int64      `json:"id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Tags        []string   `json:"tags"`
-	Due         *time.Time `json:"due,omitempty"`
-	Status      TaskStatus `json:"status"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID          int64        `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Tags        []string     `json:"tags"`
+	Due         *time.Time   `json:"due,omitempty"`
+	Recurrence  string       `json:"recurrence,omitempty"` // repeat rule, e.g. "weekly"; see dates.ParseRecurrence
+	Priority    TaskPriority `json:"priority"`
+	Pinned      bool         `json:"pinned,omitempty"` // shown above the other tasks of its column
+	Status      TaskStatus   `json:"status"`
+	Subtasks    []Subtask    `json:"subtasks"`
+	Source      string       `json:"source,omitempty"`     // where the task was imported from, e.g. "github:owner/name"
+	SourceID    string       `json:"source_id,omitempty"`  // the task's identifier at its source, e.g. the issue number
+	URL         string       `json:"url,omitempty"`        // the task's web page at its source
+	BlockedBy   []int64      `json:"blocked_by,omitempty"` // IDs of the tasks this one waits on
+	Parent      int64        `json:"parent,omitempty"`     // ID of the epic the task belongs to, 0 for none
+	Position    int          `json:"position"`
+	Version     int64        `json:"version"` // counts the changes to the task, its reorders aside
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	DeletedAt   *time.Time   `json:"deleted_at,omitempty"`
+	ArchivedAt  *time.Time   `json:"archived_at,omitempty"`
+	// SnoozedUntil is midnight of the day a snoozed task comes back to the
+	// board; it is kept once the task woke, at WokeAt
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	WokeAt       *time.Time `json:"woke_at,omitempty"`
+}
+
+// Subtask is a checklist item of a task
+type Subtask struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+// SubtaskProgress returns the number of completed and total subtasks
+func (t Task) SubtaskProgress() (done, total int) {
+	for _, st := range t.Subtasks {
+		if st.Done {
+			done++
+		}
+	}
+	return done, len(t.Subtasks)
+}
+
+// Snoozed reports whether the task is snoozed at now: off the board until
+// its wake day
+func (t Task) Snoozed(now time.Time) bool {
+	return t.SnoozedUntil != nil && t.WokeAt == nil && t.SnoozedUntil.After(now)
+}
+
+// WokeToday reports whether a snoozed task came back to the board on now's
+// day, or is due back and hasn't been woken yet
+func (t Task) WokeToday(now time.Time) bool {
+	if t.SnoozedUntil == nil {
+		return false
+	}
+	if t.WokeAt == nil {
+		return !t.SnoozedUntil.After(now)
+	}
+	y, m, d := t.WokeAt.In(now.Location()).Date()
+	ny, nm, nd := now.Date()
+	return y == ny && m == nm && d == nd
 }
 
 // Column represents a kanban column
 type Column struct {
-	Name   string
-	Status TaskStatus
-	Tasks  []Task
+	Name     string
+	Status   TaskStatus
+	WIPLimit int  // maximum number of tasks, 0 for no limit
+	Done     bool // tasks in the column count as completed
+	Tasks    []Task
+}
+
+// OverWIPLimit reports whether a column holding count tasks exceeds its WIP limit
+func (c Column) OverWIPLimit(count int) bool {
+	return c.WIPLimit > 0 && count > c.WIPLimit
 }
 
-// GetAllColumns returns all three columns in order
-func GetAllColumns() []Column {
+// DefaultColumns returns the columns a new workspace starts with, in order
+func DefaultColumns() []Column {
 	return []Column{
 		{Name: "Todo", Status: StatusTodo},
 		{Name: "In Progress", Status: StatusInProgress},
-		{Name: "Done", Status: StatusDone},
+		{Name: "Done", Status: StatusDone, Done: true},
 	}
 }
 
-// NextStatus returns the next status in the workflow
-func (s TaskStatus) Next() TaskStatus {
-	switch s {
-	case StatusTodo:
-		return StatusInProgress
-	case StatusInProgress:
-		return StatusDone
+// DoneStatuses returns the statuses of the done columns, in board order
+func DoneStatuses(columns []Column) []TaskStatus {
+	var statuses []TaskStatus
+	for _, col := range columns {
+		if col.Done {
+			statuses = append(statuses, col.Status)
+		}
+	}
+	return statuses
+}
+
+// IsDone reports whether status is one of the done columns
+func IsDone(columns []Column, status TaskStatus) bool {
+	for _, col := range columns {
+		if col.Status == status {
+			return col.Done
+		}
+	}
+	return false
+}
+
+// DoneColumn returns the index of the column tasks are moved into to
+// complete them: the leftmost done column, or the rightmost column when
+// none is marked done. It is -1 without columns.
+func DoneColumn(columns []Column) int {
+	for i, col := range columns {
+		if col.Done {
+			return i
+		}
+	}
+	return len(columns) - 1
+}
+
+// FindColumn looks up a column by its display name or status key.
+// Matching is case-insensitive and treats spaces, dashes and underscores alike,
+// so "In Progress", "in-progress" and "in_progress" all resolve to the same column.
+func FindColumn(columns []Column, name string) (Column, bool) {
+	key := normalizeColumnName(name)
+	if key == "" {
+		return Column{}, false
+	}
+	for _, col := range columns {
+		if normalizeColumnName(col.Name) == key || normalizeColumnName(string(col.Status)) == key {
+			return col, true
+		}
+	}
+	return Column{}, false
+}
+
+// normalizeColumnName lowercases a column name and collapses separators
+func normalizeColumnName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(name)
+}
+
+// Next returns the next priority when cycling: none → low → medium → high → urgent → none
+func (p TaskPriority) Next() TaskPriority {
+	switch p {
+	case PriorityNone:
+		return PriorityLow
+	case PriorityLow:
+		return PriorityMedium
+	case PriorityMedium:
+		return PriorityHigh
+	case PriorityHigh:
+		return PriorityUrgent
 	default:
-		return StatusDone
+		return PriorityNone
 	}
 }
 
-// PrevStatus returns the previous status in the workflow
-func (s TaskStatus) Prev() TaskStatus {
-	switch s {
-	case StatusDone:
-		return StatusInProgress
-	case StatusInProgress:
-		return StatusTodo
+// Rank returns a sortable weight for the priority, higher is more urgent
+func (p TaskPriority) Rank() int {
+	switch p {
+	case PriorityLow:
+		return 1
+	case PriorityMedium:
+		return 2
+	case PriorityHigh:
+		return 3
+	case PriorityUrgent:
+		return 4
 	default:
-		return StatusTodo
+		return 0
+	}
+}
+
+// ParsePriority parses a priority name case-insensitively; "" and "none" mean no priority
+func ParsePriority(s string) (TaskPriority, bool) {
+	switch p := TaskPriority(strings.ToLower(strings.TrimSpace(s))); p {
+	case PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent:
+		return p, true
+	case PriorityNone, "none":
+		return PriorityNone, true
 	}
+	return PriorityNone, false
 }
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Template is a saved task to start new ones from. Its title and description
+// may hold {{var}} placeholders filled in when a task is created.
+type Template struct {
+	Name        string       `json:"name"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Priority    TaskPriority `json:"priority"`
+	Tags        []string     `json:"tags"`
+	Subtasks    []string     `json:"subtasks"` // checklist item titles, in order
+}
+
+// templateVarRe matches a {{var}} placeholder, spaces inside the braces allowed
+var templateVarRe = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_-]+)\s*\}\}`)
+
+// TemplateFrom returns a template named name that recreates task: its title,
+// description, priority, tags and checklist, with every item unchecked
+func TemplateFrom(name string, task Task) Template {
+	t := Template{
+		Name:        name,
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		Tags:        append([]string{}, task.Tags...),
+		Subtasks:    []string{},
+	}
+	for _, st := range task.Subtasks {
+		t.Subtasks = append(t.Subtasks, st.Title)
+	}
+	return t
+}
+
+// Vars returns the names of the placeholders in the title and description,
+// in order of first appearance
+func (t Template) Vars() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, match := range templateVarRe.FindAllStringSubmatch(t.Title+"\n"+t.Description, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// Task returns a new task in the column status built from the template, with
+// the placeholders replaced by vars. Every placeholder needs a value, and
+// every value a placeholder, so a mistyped name is reported.
+func (t Template) Task(vars map[string]string, status TaskStatus) (Task, error) {
+	used := map[string]bool{}
+	var missing []string
+	for _, name := range t.Vars() {
+		used[name] = true
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return Task{}, fmt.Errorf("template %s needs a value for %s", t.Name, strings.Join(missing, ", "))
+	}
+	var unknown []string
+	for name := range vars {
+		if !used[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return Task{}, fmt.Errorf("template %s has no {{%s}}", t.Name, strings.Join(unknown, "}}, {{"))
+	}
+
+	return t.task(vars, status), nil
+}
+
+// Draft returns a task in the column status built from the template under a
+// title typed over the template's title. The description gets the values the
+// title fills in, as found by MatchTitle; other placeholders are kept as is.
+func (t Template) Draft(title string, status TaskStatus) Task {
+	vars, _ := t.MatchTitle(title)
+	task := t.task(vars, status)
+	task.Title = strings.TrimSpace(title)
+	return task
+}
+
+// task builds a task from the template, replacing the placeholders that have
+// a value in vars
+func (t Template) task(vars map[string]string, status TaskStatus) Task {
+	task := Task{
+		Title:       strings.TrimSpace(expandTemplate(t.Title, vars)),
+		Description: expandTemplate(t.Description, vars),
+		Priority:    t.Priority,
+		Tags:        append([]string{}, t.Tags...),
+		Status:      status,
+		Subtasks:    []Subtask{},
+	}
+	for _, title := range t.Subtasks {
+		task.Subtasks = append(task.Subtasks, Subtask{Title: title})
+	}
+	return task
+}
+
+// MatchTitle recovers the placeholder values from a title typed over the
+// template's title, e.g. "Release v1.4" over "Release v{{version}}" gives
+// version=1.4. It reports false when the title doesn't follow the template.
+func (t Template) MatchTitle(title string) (map[string]string, bool) {
+	var pattern strings.Builder
+	var names []string
+	last := 0
+	pattern.WriteString("^")
+	for _, loc := range templateVarRe.FindAllStringSubmatchIndex(t.Title, -1) {
+		pattern.WriteString(regexp.QuoteMeta(t.Title[last:loc[0]]))
+		pattern.WriteString("(.+?)")
+		names = append(names, t.Title[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(t.Title[last:]))
+	pattern.WriteString("$")
+
+	match := regexp.MustCompile(pattern.String()).FindStringSubmatch(strings.TrimSpace(title))
+	if match == nil {
+		return nil, false
+	}
+	vars := map[string]string{}
+	for i, name := range names {
+		if value, ok := vars[name]; ok && value != match[i+1] {
+			// The same placeholder typed two different ways
+			return nil, false
+		}
+		vars[name] = match[i+1]
+	}
+	return vars, true
+}
+
+// expandTemplate replaces the placeholders of text that have a value
+func expandTemplate(text string, vars map[string]string) string {
+	return templateVarRe.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := templateVarRe.FindStringSubmatch(placeholder)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
@@ -0,0 +1,35 @@
+package model
+
+// SavedView is a named combination of board filters, column sorts and
+// hidden columns, to switch the board to in one go
+type SavedView struct {
+	Name       string                `json:"name"`
+	Search     string                `json:"search,omitempty"` // search query, lowercased, with the title:, desc:, tag: and due: prefixes
+	Label      string                `json:"label,omitempty"`  // only tasks with this tag
+	UrgentOnly bool                  `json:"urgent_only,omitempty"`
+	StaleOnly  bool                  `json:"stale_only,omitempty"`
+	PinnedOnly bool                  `json:"pinned_only,omitempty"`
+	Sorts      map[TaskStatus]string `json:"sorts,omitempty"`  // sort mode by column status, for the sorted columns
+	Hidden     []TaskStatus          `json:"hidden,omitempty"` // statuses of the columns left off the board
+}
+
+// Hides reports whether the view leaves the column with the given status
+// off the board
+func (v SavedView) Hides(status TaskStatus) bool {
+	for _, hidden := range v.Hidden {
+		if hidden == status {
+			return true
+		}
+	}
+	return false
+}
+
+// BoardState is where the board was left in a workspace: its filters and
+// hidden columns, the focused column and selected task, and whether the
+// column was zoomed. The board opens there again next time.
+type BoardState struct {
+	View   SavedView  `json:"view"` // named after the view in use, if any
+	Column TaskStatus `json:"column,omitempty"`
+	TaskID int64      `json:"task_id,omitempty"`
+	Zoomed bool       `json:"zoomed,omitempty"`
+}
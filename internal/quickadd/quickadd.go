@@ -0,0 +1,114 @@
+// Package quickadd parses the inline task syntax accepted when adding a task,
+// e.g. "Fix login bug !high #backend @fri".
+package quickadd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// Result is a task title with the attributes parsed out of it
+type Result struct {
+	Title    string
+	Priority model.TaskPriority
+	Tags     []string
+	Due      *time.Time
+}
+
+// Parse splits the quick-add tokens off a task title, relative to now:
+//
+//	!high        priority (low, medium, high, urgent or none)
+//	#backend     tag, may be repeated
+//	@fri         due date in any form dates.Parse accepts (@tomorrow, @+2w, @2025-03-14)
+//
+// Tokens may appear anywhere in the input and are removed from the title. A
+// lone !, # or @ is kept, as is a token escaped with a backslash (\#1 keeps
+// "#1" in the title). When a priority or due date is given more than once the
+// last one wins; tags are lowercased and deduplicated.
+func Parse(input string, now time.Time) (Result, error) {
+	var r Result
+	var words []string
+	seen := make(map[string]bool)
+
+	for _, word := range strings.Fields(input) {
+		if len(word) < 2 {
+			words = append(words, word)
+			continue
+		}
+		value := word[1:]
+		switch word[0] {
+		case '\\':
+			words = append(words, value)
+		case '!':
+			priority, ok := model.ParsePriority(value)
+			if !ok {
+				return Result{}, fmt.Errorf("unknown priority %q: use !low, !medium, !high, !urgent or !none", word)
+			}
+			r.Priority = priority
+		case '#':
+			tag := strings.ToLower(value)
+			if !seen[tag] {
+				r.Tags = append(r.Tags, tag)
+				seen[tag] = true
+			}
+		case '@':
+			due, err := dates.Parse(value, now)
+			if err != nil {
+				return Result{}, fmt.Errorf("invalid due date %q: use e.g. @tomorrow, @+2w, @mon or @YYYY-MM-DD", word)
+			}
+			r.Due = &due
+		default:
+			words = append(words, word)
+		}
+	}
+
+	r.Title = strings.Join(words, " ")
+	if r.Title == "" {
+		return Result{}, fmt.Errorf("task title cannot be empty")
+	}
+	return r, nil
+}
+
+// Parsed reports whether any attribute was parsed out of the title
+func (r Result) Parsed() bool {
+	return r.Priority != model.PriorityNone || len(r.Tags) > 0 || r.Due != nil
+}
+
+// Task returns a new task for the given column with the parsed attributes
+func (r Result) Task(status model.TaskStatus) model.Task {
+	tags := append([]string{}, r.Tags...)
+	return model.Task{
+		Title:    r.Title,
+		Priority: r.Priority,
+		Tags:     tags,
+		Due:      r.Due,
+		Status:   status,
+	}
+}
+
+// Apply sets the parsed attributes on draft, such as a task started from a
+// template: the title replaces the draft's, the tags are added to its tags,
+// and a priority or due date given replaces its own
+func (r Result) Apply(draft model.Task) model.Task {
+	draft.Title = r.Title
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, tag := range append(append([]string{}, draft.Tags...), r.Tags...) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	draft.Tags = tags
+	if r.Priority != model.PriorityNone {
+		draft.Priority = r.Priority
+	}
+	if r.Due != nil {
+		draft.Due = r.Due
+	}
+	return draft
+}
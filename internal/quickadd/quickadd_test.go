@@ -0,0 +1,107 @@
+package quickadd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+func TestParse(t *testing.T) {
+	// A Wednesday afternoon
+	now := time.Date(2025, 3, 12, 15, 4, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		input    string
+		title    string
+		priority model.TaskPriority
+		tags     []string
+		due      string // YYYY-MM-DD, "" for none
+		err      string // part of the error, "" for none
+	}{
+		{name: "plain", input: "Fix login bug", title: "Fix login bug"},
+		{name: "all tokens", input: "Fix login bug !high #backend @fri", title: "Fix login bug",
+			priority: model.PriorityHigh, tags: []string{"backend"}, due: "2025-03-14"},
+		{name: "tokens anywhere", input: "#ops Rotate @tomorrow the keys !urgent", title: "Rotate the keys",
+			priority: model.PriorityUrgent, tags: []string{"ops"}, due: "2025-03-13"},
+
+		{name: "tags in order", input: "Deploy #web #api", title: "Deploy", tags: []string{"web", "api"}},
+		{name: "tags lowercased and deduplicated", input: "Deploy #Web #web #WEB #api", title: "Deploy", tags: []string{"web", "api"}},
+		{name: "priority case", input: "Deploy !LOW", title: "Deploy", priority: model.PriorityLow},
+		{name: "last priority wins", input: "Deploy !low !medium", title: "Deploy", priority: model.PriorityMedium},
+		{name: "priority none", input: "Deploy !high !none", title: "Deploy"},
+
+		{name: "due absolute", input: "Pay rent @2025-04-01", title: "Pay rent", due: "2025-04-01"},
+		{name: "due today", input: "Pay rent @today", title: "Pay rent", due: "2025-03-12"},
+		{name: "due offset", input: "Pay rent @+2w", title: "Pay rent", due: "2025-03-26"},
+		{name: "due weekday is never today", input: "Pay rent @wed", title: "Pay rent", due: "2025-03-19"},
+		{name: "last due wins", input: "Pay rent @tomorrow @+1m", title: "Pay rent", due: "2025-04-12"},
+
+		{name: "quoted text is kept", input: `Reply to "#1 fan" @ once`, title: `Reply to "#1 fan" @ once`},
+		{name: "escaped tokens are kept", input: `Close \#12 and \!important \@home`, title: "Close #12 and !important @home"},
+		{name: "lone marks are kept", input: "Fix ! # @ now", title: "Fix ! # @ now"},
+		{name: "marks inside words are kept", input: "Email bob@example.com re C# v2!", title: "Email bob@example.com re C# v2!"},
+		{name: "spaces collapse", input: "  Fix \t login   bug  ", title: "Fix login bug"},
+
+		{name: "unknown priority", input: "Deploy !asap", err: `unknown priority "!asap"`},
+		{name: "unknown due date", input: "Deploy @someday", err: `invalid due date "@someday"`},
+		{name: "unknown due unit", input: "Deploy @+3y", err: `invalid due date "@+3y"`},
+
+		{name: "empty", input: "", err: "title cannot be empty"},
+		{name: "blank", input: " \t ", err: "title cannot be empty"},
+		{name: "only tokens", input: "#backend !high @fri", err: "title cannot be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.input, now)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Parse(%q) = %+v, %v; want an error saying %q", tt.input, r, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if r.Title != tt.title {
+				t.Errorf("title = %q, want %q", r.Title, tt.title)
+			}
+			if r.Priority != tt.priority {
+				t.Errorf("priority = %q, want %q", r.Priority, tt.priority)
+			}
+			if strings.Join(r.Tags, ",") != strings.Join(tt.tags, ",") {
+				t.Errorf("tags = %q, want %q", r.Tags, tt.tags)
+			}
+			due := ""
+			if r.Due != nil {
+				due = r.Due.Format("2006-01-02")
+				if !r.Due.Equal(time.Date(r.Due.Year(), r.Due.Month(), r.Due.Day(), 0, 0, 0, 0, now.Location())) {
+					t.Errorf("due = %v, want midnight", r.Due)
+				}
+			}
+			if due != tt.due {
+				t.Errorf("due = %q, want %q", due, tt.due)
+			}
+			if r.Parsed() != (tt.priority != model.PriorityNone || len(tt.tags) > 0 || tt.due != "") {
+				t.Errorf("Parsed() = %v for %+v", r.Parsed(), r)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	due := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	draft := model.Task{Title: "Release", Description: "steps", Priority: model.PriorityLow, Tags: []string{"release", "web"}}
+
+	got := Result{Title: "Release 1.4", Tags: []string{"web", "urgent"}, Due: &due}.Apply(draft)
+	if got.Title != "Release 1.4" || got.Description != "steps" || got.Priority != model.PriorityLow || got.Due != &due {
+		t.Errorf("Apply = %+v", got)
+	}
+	if strings.Join(got.Tags, ",") != "release,web,urgent" {
+		t.Errorf("tags = %q, want the draft's then the new ones", got.Tags)
+	}
+	if got := (Result{Title: "Release", Priority: model.PriorityHigh}).Apply(draft); got.Priority != model.PriorityHigh {
+		t.Errorf("priority = %q, want the one given", got.Priority)
+	}
+}
@@ -0,0 +1,669 @@
+// Package server serves workspaces over a small JSON REST API, through the
+// same database layer as the TUI, so a board open at the same time shows the
+// changes as they are made.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/crypt"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+	"github.com/happytaoer/cli_kanban/internal/quickadd"
+	"github.com/happytaoer/cli_kanban/internal/webhook"
+	"github.com/happytaoer/cli_kanban/internal/workspace"
+)
+
+// maxBodySize is the largest request body read
+const maxBodySize = 1 << 20
+
+// Options configure a Server
+type Options struct {
+	// Workspace is the workspace served, and with All the one that paths
+	// without a workspace refer to
+	Workspace string
+	// Database, when set, is the database of Workspace, opened beforehand;
+	// Close closes it with the others
+	Database *db.DB
+	// All serves every workspace of the data directory
+	All bool
+	// Token, when set, must be sent as "Authorization: Bearer <token>"
+	Token string
+	// Webhooks are posted the changes made through the API, see webhook.URLs
+	Webhooks map[string][]string
+	// Warnings receives the webhooks that fail, once until they work again;
+	// nil discards them
+	Warnings io.Writer
+}
+
+// Server handles the API requests. Workspace databases are opened on first
+// use and stay open until Close.
+type Server struct {
+	opts Options
+
+	mu         sync.Mutex
+	dbs        map[string]*db.DB
+	delivering map[string]bool // workspaces with a webhook delivery running
+	pending    map[string]bool // workspaces changed while delivering
+	failing    map[string]bool // webhook URLs whose failure was reported
+	deliveries sync.WaitGroup
+}
+
+// New returns a server for opts
+func New(opts Options) *Server {
+	if opts.Workspace == "" {
+		opts.Workspace = workspace.Default
+	}
+	if opts.Warnings == nil {
+		opts.Warnings = io.Discard
+	}
+	s := &Server{
+		opts:       opts,
+		dbs:        map[string]*db.DB{},
+		delivering: map[string]bool{},
+		pending:    map[string]bool{},
+		failing:    map[string]bool{},
+	}
+	if opts.Database != nil {
+		s.dbs[opts.Workspace] = opts.Database
+	}
+	return s
+}
+
+// Close waits for the webhook deliveries running and closes the workspace
+// databases opened so far
+func (s *Server) Close() error {
+	s.deliveries.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for name, database := range s.dbs {
+		if err := database.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(s.dbs, name)
+	}
+	return errors.Join(errs...)
+}
+
+// apiError is an error answered with an HTTP status
+type apiError struct {
+	status int
+	msg    string
+}
+
+func (e *apiError) Error() string {
+	return e.msg
+}
+
+// errorf returns an apiError with the given status
+func errorf(status int, format string, args ...interface{}) error {
+	return &apiError{status: status, msg: fmt.Sprintf(format, args...)}
+}
+
+// ServeHTTP routes a request:
+//
+//	GET    /api/workspaces
+//	GET    /api/[workspaces/{ws}/]columns
+//	GET    /api/[workspaces/{ws}/]tasks[?column=...]
+//	POST   /api/[workspaces/{ws}/]tasks
+//	GET    /api/[workspaces/{ws}/]tasks/{id}
+//	PATCH  /api/[workspaces/{ws}/]tasks/{id}
+//	DELETE /api/[workspaces/{ws}/]tasks/{id}
+//	POST   /api/[workspaces/{ws}/]tasks/{id}/move
+//
+// Paths without a workspace use the one from Options.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cli_kanban"`)
+			writeError(w, errorf(http.StatusUnauthorized, "missing or wrong bearer token"))
+			return
+		}
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 0 || parts[0] != "api" {
+		writeError(w, errorf(http.StatusNotFound, "not found: the API is under /api"))
+		return
+	}
+	parts = parts[1:]
+	if len(parts) == 1 && parts[0] == "workspaces" {
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+		s.listWorkspaces(w)
+		return
+	}
+
+	ws := s.opts.Workspace
+	if len(parts) >= 2 && parts[0] == "workspaces" {
+		ws, parts = parts[1], parts[2:]
+	}
+	database, err := s.open(ws)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && parts[0] == "columns":
+		if allow(w, r, http.MethodGet) {
+			s.listColumns(w, database)
+		}
+	case len(parts) == 1 && parts[0] == "tasks":
+		switch r.Method {
+		case http.MethodGet:
+			s.listTasks(w, r, database)
+		case http.MethodPost:
+			s.createTask(w, r, database)
+		default:
+			allow(w, r, http.MethodGet, http.MethodPost)
+		}
+	case len(parts) == 2 && parts[0] == "tasks", len(parts) == 3 && parts[0] == "tasks" && parts[2] == "move":
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			writeError(w, errorf(http.StatusNotFound, "invalid task id %q", parts[1]))
+			return
+		}
+		task, err := getTask(database, id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if len(parts) == 3 {
+			if allow(w, r, http.MethodPost) {
+				s.moveTask(w, r, database, task)
+			}
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, task)
+		case http.MethodPatch:
+			s.updateTask(w, r, database, task)
+		case http.MethodDelete:
+			if err := database.DeleteTask(task.ID); err != nil {
+				writeError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			allow(w, r, http.MethodGet, http.MethodPatch, http.MethodDelete)
+		}
+	default:
+		writeError(w, errorf(http.StatusNotFound, "not found: %s", r.URL.Path))
+	}
+	if r.Method != http.MethodGet {
+		s.deliver(ws, database)
+	}
+}
+
+// deliver posts the new changes of a workspace to its webhooks in the
+// background, so responses don't wait for them. A change made while a
+// delivery runs is picked up by another round once it ends.
+func (s *Server) deliver(ws string, database *db.DB) {
+	urls := webhook.URLs(s.opts.Webhooks, ws)
+	if len(urls) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.delivering[ws] {
+		s.pending[ws] = true
+		return
+	}
+	s.delivering[ws] = true
+	s.deliveries.Add(1)
+	go func() {
+		defer s.deliveries.Done()
+		for {
+			result, err := webhook.Deliver(context.Background(), database, ws, urls)
+			s.mu.Lock()
+			if err != nil {
+				fmt.Fprintf(s.opts.Warnings, "Warning: webhooks of workspace %s: %v\n", ws, err)
+			}
+			for _, u := range urls {
+				if err, failed := result.Failed[u]; failed && !s.failing[u] {
+					fmt.Fprintf(s.opts.Warnings, "Warning: webhook failed: %v\n", err)
+					s.failing[u] = true
+				} else if !failed && result.Events > 0 {
+					delete(s.failing, u)
+				}
+			}
+			if !s.pending[ws] {
+				delete(s.delivering, ws)
+				s.mu.Unlock()
+				return
+			}
+			delete(s.pending, ws)
+			s.mu.Unlock()
+		}
+	}()
+}
+
+// open returns the database of a served workspace. The lock isn't held
+// while a database opens, so a slow one doesn't hold up other requests.
+// Encrypted workspaces are only served when opened beforehand, as
+// Options.Database: opening one would ask for its passphrase.
+func (s *Server) open(ws string) (*db.DB, error) {
+	if ws != s.opts.Workspace && !s.opts.All {
+		return nil, errorf(http.StatusNotFound, "workspace %q is not served", ws)
+	}
+	if err := workspace.Validate(ws); err != nil {
+		return nil, errorf(http.StatusNotFound, "%v", err)
+	}
+
+	s.mu.Lock()
+	database, ok := s.dbs[ws]
+	s.mu.Unlock()
+	if ok {
+		return database, nil
+	}
+	path, err := workspace.Path(ws)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, errorf(http.StatusNotFound, "workspace %q not found", ws)
+	}
+	encrypted, err := crypt.IsEncrypted(path)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return nil, errorf(http.StatusForbidden, "workspace %q is encrypted: serve it with --workspace %s to give its passphrase", ws, ws)
+	}
+	database, err = db.New(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if opened, ok := s.dbs[ws]; ok {
+		// Another request opened it meanwhile
+		_ = database.Close()
+		return opened, nil
+	}
+	s.dbs[ws] = database
+	return database, nil
+}
+
+// listWorkspaces answers the names of the served workspaces
+func (s *Server) listWorkspaces(w http.ResponseWriter) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	items := []item{}
+	if !s.opts.All {
+		items = append(items, item{s.opts.Workspace})
+	} else {
+		workspaces, err := workspace.List()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		for _, ws := range workspaces {
+			items = append(items, item{ws.Name})
+		}
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// column is a board column as the API answers it
+type column struct {
+	Name     string           `json:"name"`
+	Status   model.TaskStatus `json:"status"`
+	WIPLimit int              `json:"wip_limit"`
+	Done     bool             `json:"done"`
+	Tasks    int              `json:"tasks"`
+}
+
+// listColumns answers the columns of the board in order
+func (s *Server) listColumns(w http.ResponseWriter, database *db.DB) {
+	board, err := database.GetColumns()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	counts, err := database.CountTasksByStatus()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	columns := make([]column, len(board))
+	for i, col := range board {
+		columns[i] = column{Name: col.Name, Status: col.Status, WIPLimit: col.WIPLimit, Done: col.Done, Tasks: counts[col.Status]}
+	}
+	writeJSON(w, http.StatusOK, columns)
+}
+
+// listTasks answers the tasks on the board in board order, or those of the
+// column given as ?column=, paged with ?offset= and ?limit=
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request, database *db.DB) {
+	query := r.URL.Query()
+	offset, err := pageArg(query.Get("offset"), "offset")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	limit, err := pageArg(query.Get("limit"), "limit")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if name := query.Get("column"); name != "" {
+		s.listColumnTasks(w, database, name, offset, limit)
+		return
+	}
+	if offset > 0 || limit > 0 {
+		writeError(w, errorf(http.StatusBadRequest, "offset and limit page a column: give column too"))
+		return
+	}
+
+	board, err := database.GetBoard()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	tasks := []model.Task{}
+	for _, col := range board {
+		tasks = append(tasks, col.Tasks...)
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// listColumnTasks lists the tasks of one column, limit of them from offset
+// on, or all of them from offset on for a limit of 0. Only that page is
+// read; the X-Total-Count header tells how many tasks the column holds.
+func (s *Server) listColumnTasks(w http.ResponseWriter, database *db.DB, name string, offset, limit int) {
+	columns, err := database.GetColumns()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	col, ok := model.FindColumn(columns, name)
+	if !ok {
+		writeError(w, errorf(http.StatusBadRequest, "unknown column %q", name))
+		return
+	}
+	counts, err := database.CountTasksByStatus()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	tasks, err := database.GetTasksByStatus(col.Status, offset, limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if tasks == nil {
+		tasks = []model.Task{}
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(counts[col.Status]))
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// pageArg parses the offset or limit of a page of tasks; none is 0
+func pageArg(value, name string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, errorf(http.StatusBadRequest, "invalid %s %q: want a number of tasks", name, value)
+	}
+	return n, nil
+}
+
+// taskInput is the body of requests creating or updating a task. Fields left
+// out of an update keep their value.
+type taskInput struct {
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	Column      *string   `json:"column"`   // name or status key
+	Priority    *string   `json:"priority"` // low, medium, high, urgent or "" for none
+	Tags        *[]string `json:"tags"`
+	Due         *string   `json:"due"` // e.g. 2025-03-14, tomorrow or +2w; "" clears it
+	// QuickAdd parses !priority, #tag and @due out of the title
+	QuickAdd bool `json:"quick_add"`
+	// Version is the version of the task the update was made on; when the
+	// task changed since, nothing is written
+	Version *int64 `json:"version"`
+}
+
+// apply sets the fields of in on task, resolving the column on the board
+func (in taskInput) apply(task model.Task, board []model.Column) (model.Task, error) {
+	now := time.Now()
+	if in.Title != nil {
+		title := strings.TrimSpace(*in.Title)
+		if in.QuickAdd {
+			parsed, err := quickadd.Parse(title, now)
+			if err != nil {
+				return task, errorf(http.StatusBadRequest, "%v", err)
+			}
+			task = parsed.Apply(task)
+			title = task.Title
+		}
+		if title == "" {
+			return task, errorf(http.StatusBadRequest, "task title cannot be empty")
+		}
+		task.Title = title
+	}
+	if in.Description != nil {
+		task.Description = *in.Description
+	}
+	if in.Column != nil {
+		col, ok := model.FindColumn(board, *in.Column)
+		if !ok {
+			return task, errorf(http.StatusBadRequest, "unknown column %q", *in.Column)
+		}
+		task.Status = col.Status
+	}
+	if in.Priority != nil {
+		priority, ok := model.ParsePriority(*in.Priority)
+		if !ok && *in.Priority != "" {
+			return task, errorf(http.StatusBadRequest, "invalid priority %q: use low, medium, high, urgent or none", *in.Priority)
+		}
+		task.Priority = priority
+	}
+	if in.Tags != nil {
+		task.Tags = append([]string{}, *in.Tags...)
+	}
+	if in.Due != nil {
+		task.Due = nil
+		if *in.Due != "" {
+			due, err := dates.Parse(*in.Due, now)
+			if err != nil {
+				return task, errorf(http.StatusBadRequest, "invalid due date %q", *in.Due)
+			}
+			task.Due = &due
+		}
+	}
+	return task, nil
+}
+
+// createTask creates a task at the top of the column given, or of the first
+// column, and answers it
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request, database *db.DB) {
+	var in taskInput
+	if err := readJSON(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	if in.Title == nil {
+		writeError(w, errorf(http.StatusBadRequest, "title is required"))
+		return
+	}
+	board, err := database.GetColumns()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	draft, err := in.apply(model.Task{Status: board[0].Status}, board)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	task, err := database.CreateTaskFrom(draft)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, task)
+}
+
+// updateTask changes the fields given of a task, moving it when the column
+// changes, and answers the updated task. An update made on an older version
+// of the task is answered 409 Conflict with the task as it is now, and so
+// is a move of an archived task.
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request, database *db.DB, task *model.Task) {
+	var in taskInput
+	if err := readJSON(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	board, err := database.GetColumns()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	updated, err := in.apply(*task, board)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if updated.Status != task.Status && task.ArchivedAt != nil {
+		writeError(w, errorf(http.StatusConflict, "task %d is in the archive", task.ID))
+		return
+	}
+	if in.Version != nil {
+		// Version 0 would skip the check it asks for
+		if *in.Version < 1 {
+			writeError(w, errorf(http.StatusBadRequest, "invalid version %d: versions start at 1", *in.Version))
+			return
+		}
+		updated.Version = *in.Version
+	}
+	if _, err := database.UpdateTaskFields(updated); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.answerTask(w, database, task.ID)
+}
+
+// moveTask moves a task to the bottom of the column named in the body, as
+// the move command does. An archived task is answered 409 Conflict.
+func (s *Server) moveTask(w http.ResponseWriter, r *http.Request, database *db.DB, task *model.Task) {
+	var in struct {
+		Column string `json:"column"`
+	}
+	if err := readJSON(r, &in); err != nil {
+		writeError(w, err)
+		return
+	}
+	board, err := database.GetColumns()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	col, ok := model.FindColumn(board, in.Column)
+	if !ok {
+		writeError(w, errorf(http.StatusBadRequest, "unknown column %q", in.Column))
+		return
+	}
+	if task.ArchivedAt != nil {
+		writeError(w, errorf(http.StatusConflict, "task %d is in the archive", task.ID))
+		return
+	}
+	if _, err := database.UpdateTaskStatus(task.ID, col.Status); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.answerTask(w, database, task.ID)
+}
+
+// answerTask answers the current state of a task
+func (s *Server) answerTask(w http.ResponseWriter, database *db.DB, id int64) {
+	task, err := getTask(database, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, task)
+}
+
+// getTask returns a task on the board or in the archive, not in the trash
+func getTask(database *db.DB, id int64) (*model.Task, error) {
+	task, err := database.GetTask(id)
+	if err != nil || task.DeletedAt != nil {
+		return nil, errorf(http.StatusNotFound, "task %d not found", id)
+	}
+	return task, nil
+}
+
+// allow reports whether the request uses one of methods, answering 405
+// Method Not Allowed otherwise
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, errorf(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+	return false
+}
+
+// readJSON decodes the JSON body of a request into v
+func readJSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// writeJSON answers v as JSON with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeError answers err as {"error": "..."}, with the status of an
+// apiError, 409 Conflict for a full column under strict WIP limits or an
+// edit of a task changed since, and 500 otherwise. A conflicting edit also
+// answers the task as it is now under "task".
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var apiErr *apiError
+	var conflict *db.ConflictError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.status
+	case errors.As(err, &conflict):
+		writeJSON(w, http.StatusConflict, map[string]interface{}{"error": err.Error(), "task": conflict.Task})
+		return
+	case errors.Is(err, db.ErrWIPLimitExceeded):
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
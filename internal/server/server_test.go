@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/happytaoer/cli_kanban/internal/crypt"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+	"github.com/happytaoer/cli_kanban/internal/workspace"
+)
+
+// newTestServer returns a server of the workspace "work", held in memory,
+// with opts, and the database it serves
+func newTestServer(t *testing.T, opts Options) (*Server, *db.DB) {
+	t.Helper()
+	database, err := db.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory: %v", err)
+	}
+	opts.Workspace, opts.Database = "work", database
+	s := New(opts)
+	t.Cleanup(func() { s.Close() })
+	return s, database
+}
+
+// request sends a request to s with the bearer token, when one is given,
+// and returns the status and the body decoded into out, when out isn't nil
+func request(t *testing.T, s *Server, method, path, token, body string, out interface{}) int {
+	t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s answered %d %q: %v", method, path, w.Code, w.Body, err)
+		}
+	}
+	return w.Code
+}
+
+func TestToken(t *testing.T) {
+	s, _ := newTestServer(t, Options{Token: "s3cret"})
+	tests := []struct {
+		name, token string
+		want        int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "s3cre", http.StatusUnauthorized},
+		{"right", "s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := request(t, s, http.MethodGet, "/api/tasks", tt.token, "", nil); got != tt.want {
+				t.Errorf("GET /api/tasks = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateTaskVersion(t *testing.T) {
+	s, _ := newTestServer(t, Options{})
+	var created model.Task
+	if got := request(t, s, http.MethodPost, "/api/tasks", "", `{"title": "Fix login"}`, &created); got != http.StatusCreated {
+		t.Fatalf("POST /api/tasks = %d", got)
+	}
+	if created.Version != 1 {
+		t.Fatalf("created task at version %d, want 1", created.Version)
+	}
+	path := "/api/tasks/" + strconv.FormatInt(created.ID, 10)
+
+	var updated model.Task
+	if got := request(t, s, http.MethodPatch, path, "", `{"title": "Fix the login", "version": 1}`, &updated); got != http.StatusOK {
+		t.Fatalf("PATCH at the current version = %d, want 200", got)
+	}
+	if updated.Title != "Fix the login" || updated.Version != 2 {
+		t.Errorf("PATCH answered %q at version %d, want the new title at version 2", updated.Title, updated.Version)
+	}
+
+	var conflict struct {
+		Error string     `json:"error"`
+		Task  model.Task `json:"task"`
+	}
+	if got := request(t, s, http.MethodPatch, path, "", `{"title": "Stale", "version": 1}`, &conflict); got != http.StatusConflict {
+		t.Errorf("PATCH at a stale version = %d, want 409", got)
+	}
+	if conflict.Task.Title != "Fix the login" {
+		t.Errorf("409 answered the task as %q, want it as it is now", conflict.Task.Title)
+	}
+
+	if got := request(t, s, http.MethodPatch, path, "", `{"title": "Unchecked", "version": 0}`, nil); got != http.StatusBadRequest {
+		t.Errorf("PATCH at version 0 = %d, want 400", got)
+	}
+	var task model.Task
+	request(t, s, http.MethodGet, path, "", "", &task)
+	if task.Title != "Fix the login" {
+		t.Errorf("refused updates changed the title to %q", task.Title)
+	}
+}
+
+func TestMoveArchivedTask(t *testing.T) {
+	s, database := newTestServer(t, Options{})
+	task, err := database.CreateTask("Old", model.StatusTodo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.ArchiveTasks([]int64{task.ID}); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/tasks/" + strconv.FormatInt(task.ID, 10) + "/move"
+	if got := request(t, s, http.MethodPost, path, "", `{"column": "done"}`, nil); got != http.StatusConflict {
+		t.Errorf("moving an archived task = %d, want 409", got)
+	}
+	if got, err := database.GetTask(task.ID); err != nil || got.Status != model.StatusTodo {
+		t.Errorf("archived task moved: %+v, %v", got, err)
+	}
+}
+
+func TestWorkspaceNotServed(t *testing.T) {
+	dir := t.TempDir()
+	workspace.SetDataDir(dir)
+	t.Cleanup(func() { workspace.SetDataDir("") })
+	// An encrypted workspace starts with the magic of crypt
+	if err := os.WriteFile(workspace.File(dir, "locked"), []byte(crypt.Magic), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	one, _ := newTestServer(t, Options{})
+	all, _ := newTestServer(t, Options{All: true})
+	tests := []struct {
+		name string
+		s    *Server
+		path string
+		want int
+	}{
+		{"another workspace", one, "/api/workspaces/home/tasks", http.StatusNotFound},
+		{"missing workspace", all, "/api/workspaces/home/tasks", http.StatusNotFound},
+		{"invalid name", all, "/api/workspaces/..%2Fwork/tasks", http.StatusNotFound},
+		{"encrypted workspace", all, "/api/workspaces/locked/tasks", http.StatusForbidden},
+		{"served workspace", one, "/api/workspaces/work/tasks", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := request(t, tt.s, http.MethodGet, tt.path, "", "", nil); got != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, got, tt.want)
+			}
+		})
+	}
+}
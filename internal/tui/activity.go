@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/db"
+)
+
+// activityLoadedMsg carries the activity log and the time logged by stopped
+// timers of the task shown in the detail view
+type activityLoadedMsg struct {
+	taskID  int64
+	entries []db.Activity
+	logged  time.Duration
+}
+
+// loadActivity loads the activity log of a task for its History section
+func (m Model) loadActivity(taskID int64) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := m.db.GetTaskActivity(taskID)
+		if err != nil {
+			return errMsg{err}
+		}
+		logged, err := m.db.TaskTimeLogged(taskID)
+		if err != nil {
+			return errMsg{err}
+		}
+		return activityLoadedMsg{taskID, entries, logged}
+	}
+}
+
+// loadDetailActivity reloads the History section when the detail view is open
+func (m Model) loadDetailActivity() tea.Cmd {
+	if m.viewMode != ViewModeDetail && m.viewMode != ViewModeAddSubtask {
+		return nil
+	}
+	task := m.getCurrentTask()
+	if task == nil {
+		return nil
+	}
+	return m.loadActivity(task.ID)
+}
+
+// renderHistory renders the activity log of a task, newest first
+func (m Model) renderHistory(taskID int64, width int) string {
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(colorSecondary).Render("History"))
+	b.WriteString("\n")
+	if m.activityTask != taskID || len(m.activity) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("No recorded changes"))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	whenStyle := lipgloss.NewStyle().Foreground(colorMuted).Width(16)
+	for _, a := range m.activity {
+		b.WriteString(whenStyle.Render(dates.Relative(a.At, m.currentTime)))
+		b.WriteString(truncateText(a.Describe(m.columns), width-16))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,193 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/agenda"
+)
+
+// agendaLoadedMsg carries the agenda of every workspace
+type agendaLoadedMsg struct {
+	sections []agenda.Section
+}
+
+// agendaChangedMsg reports a task of another workspace completed from the agenda
+type agendaChangedMsg struct {
+	title string
+}
+
+// agendaEntry is a task listed in the agenda, with its workspace
+type agendaEntry struct {
+	workspace string
+	item      agenda.Item
+}
+
+// loadAgenda loads the agenda of every workspace
+func (m Model) loadAgenda() tea.Cmd {
+	now := m.currentTime
+	return func() tea.Msg {
+		sections, err := agenda.Load(now)
+		if err != nil {
+			return errMsg{err}
+		}
+		return agendaLoadedMsg{sections}
+	}
+}
+
+// completeAgendaTask moves a task of another workspace to its done column
+func (m Model) completeAgendaTask(entry agendaEntry) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := agenda.Complete(entry.workspace, entry.item.Task.ID); err != nil {
+			return errMsg{err}
+		}
+		return agendaChangedMsg{entry.item.Task.Title}
+	}
+}
+
+// showAgenda opens the agenda
+func (m Model) showAgenda() (tea.Model, tea.Cmd) {
+	m.viewMode = ViewModeAgenda
+	m.agendaSections = nil
+	m.agendaCursor = 0
+	m.err = nil
+	return m, m.loadAgenda()
+}
+
+// agendaEntries returns the tasks listed in the agenda, in order
+func (m Model) agendaEntries() []agendaEntry {
+	var entries []agendaEntry
+	for _, section := range m.agendaSections {
+		for _, item := range section.Items {
+			entries = append(entries, agendaEntry{section.Workspace, item})
+		}
+	}
+	return entries
+}
+
+// handleAgendaKeys handles keyboard input in the agenda
+func (m Model) handleAgendaKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	entries := m.agendaEntries()
+
+	switch {
+	case key.Matches(msg, m.keys.Agenda):
+		m.viewMode = ViewModeBoard
+		m.agendaSections = nil
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if m.agendaCursor > 0 {
+			m.agendaCursor--
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		if m.agendaCursor < len(entries)-1 {
+			m.agendaCursor++
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Details):
+		if m.agendaCursor >= len(entries) {
+			return m, nil
+		}
+		entry := entries[m.agendaCursor]
+		if entry.workspace != m.workspace {
+			// Show it on its board, selected once the board has loaded
+			m.agendaFollow = entry.item.Task.ID
+			return m, m.openWorkspace(entry.workspace)
+		}
+		if m.selectTask(entry.item.Task.ID) {
+			cmd := m.openDetail()
+			m.detailReturn = ViewModeAgenda
+			return m, cmd
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.MarkDone):
+		if m.agendaCursor >= len(entries) {
+			return m, nil
+		}
+		entry := entries[m.agendaCursor]
+		if entry.workspace != m.workspace {
+			return m, m.completeAgendaTask(entry)
+		}
+		if len(m.columns) > 0 {
+			// Through the board, so u undoes it
+			task := entry.item.Task
+			return m, m.moveTask(&task, m.doneColumn())
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// viewAgenda renders the overdue, due and in-progress tasks of every
+// workspace, grouped by workspace
+func (m Model) viewAgenda() string {
+	var b strings.Builder
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, titleStyle.Render("☀ Today"), statsStyle.Render(" "+m.currentTime.Format("Monday, January 2"))))
+	b.WriteString("\n\n")
+
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	switch {
+	case m.agendaSections == nil:
+		b.WriteString(mutedStyle.Render("Loading…"))
+		b.WriteString("\n\n")
+	case len(m.agendaSections) == 0:
+		b.WriteString(mutedStyle.Italic(true).Render("Nothing due and nothing in progress in any workspace"))
+		b.WriteString("\n\n")
+	}
+
+	headingStyle := lipgloss.NewStyle().Bold(true).Foreground(colorSecondary)
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	kindStyles := map[string]lipgloss.Style{
+		agenda.Overdue: lipgloss.NewStyle().Foreground(colorOverdue),
+		agenda.Today:   lipgloss.NewStyle().Foreground(colorWarning),
+		agenda.Waking:  lipgloss.NewStyle().Foreground(colorWarning).Bold(true),
+		agenda.Doing:   lipgloss.NewStyle().Foreground(colorPrimary),
+	}
+	i := 0
+	for _, section := range m.agendaSections {
+		heading := section.Workspace
+		if section.Workspace == m.workspace {
+			heading += " (open)"
+		}
+		b.WriteString(headingStyle.Render(heading))
+		b.WriteString("\n")
+		if section.Err != nil {
+			b.WriteString(errorStyle.Render("  " + section.Err.Error()))
+			b.WriteString("\n")
+		}
+		for _, item := range section.Items {
+			when := item.Column
+			if item.Kind == agenda.Overdue || item.Kind == agenda.Today {
+				when = item.Task.Due.Format("Mon Jan 2")
+			}
+			label := kindStyles[item.Kind].Render(fmt.Sprintf("%-7s %-12s", item.Kind, truncateText(when, 12)))
+			title := truncateText(item.Task.Title, 50)
+			if i == m.agendaCursor {
+				b.WriteString(selectedStyle.Render("> ") + label + " " + selectedStyle.Render(title))
+			} else {
+				b.WriteString("  " + label + " " + title)
+			}
+			b.WriteString("\n")
+			i++
+		}
+		b.WriteString("\n")
+	}
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+	if notice := m.renderNotice(); notice != "" {
+		b.WriteString(notice + "  ")
+	}
+	b.WriteString(helpStyle.Render(joinHints("↑ ↓: Select", hint(m.keys.Details, "Open"), hint(m.keys.MarkDone, "Done"), closeHint(m.keys.Agenda)+": Back")))
+	return b.String()
+}
@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// archiveLoadedMsg carries the tasks listed in the archive view
+type archiveLoadedMsg struct {
+	tasks []model.Task
+}
+
+// archiveUpdatedMsg reports tasks moved into or out of the archive
+type archiveUpdatedMsg struct{}
+
+// loadArchive loads the archived tasks shown in the archive view
+func (m Model) loadArchive() tea.Cmd {
+	return func() tea.Msg {
+		tasks, err := m.db.GetArchive()
+		if err != nil {
+			return errMsg{err}
+		}
+		return archiveLoadedMsg{tasks}
+	}
+}
+
+// archiveTask takes a task off the board; it can be undone
+func (m Model) archiveTask(task *model.Task) tea.Cmd {
+	return m.recordChange(opArchive, task, func() error {
+		return m.db.ArchiveTask(task.ID)
+	})
+}
+
+// archiveColumn archives every task of a column
+func (m Model) archiveColumn(status model.TaskStatus) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := m.db.ArchiveColumn(status); err != nil {
+			return errMsg{err}
+		}
+		return archiveUpdatedMsg{}
+	}
+}
+
+// unarchiveTask brings an archived task back to its column
+func (m Model) unarchiveTask(id int64) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.db.UnarchiveTask(id); err != nil {
+			return errMsg{err}
+		}
+		return archiveUpdatedMsg{}
+	}
+}
+
+// showArchive opens the archive view
+func (m Model) showArchive() (tea.Model, tea.Cmd) {
+	m.viewMode = ViewModeArchive
+	m.archiveCursor = 0
+	m.err = nil
+	return m, m.loadArchive()
+}
+
+// archiveItems returns the archived tasks matching the archive search
+func (m Model) archiveItems() []model.Task {
+	if m.archiveQuery == "" {
+		return m.archive
+	}
+	items := make([]model.Task, 0, len(m.archive))
+	for _, task := range m.archive {
+		if matchesQuery(task, m.archiveQuery) {
+			items = append(items, task)
+		}
+	}
+	return items
+}
+
+// clampArchiveCursor keeps the archive cursor on a listed task
+func (m *Model) clampArchiveCursor() {
+	if count := len(m.archiveItems()); m.archiveCursor >= count {
+		m.archiveCursor = count - 1
+	}
+	if m.archiveCursor < 0 {
+		m.archiveCursor = 0
+	}
+}
+
+// handleArchiveKeys handles keyboard input in the archive view
+func (m Model) handleArchiveKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.archiveItems()
+
+	switch {
+	case key.Matches(msg, m.keys.ArchiveView):
+		m.viewMode = ViewModeBoard
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if m.archiveCursor > 0 {
+			m.archiveCursor--
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		if m.archiveCursor < len(items)-1 {
+			m.archiveCursor++
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Search):
+		m.viewMode = ViewModeArchiveSearch
+		m.archiveInput.SetValue(m.archiveQuery)
+		m.archiveInput.CursorEnd()
+		m.archiveInput.Focus()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Unarchive):
+		if m.archiveCursor < len(items) {
+			return m, m.unarchiveTask(items[m.archiveCursor].ID)
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handleArchiveSearchKeys handles keyboard input while searching the archive
+func (m Model) handleArchiveSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "enter" {
+		// The filter is already applied while typing; keep it and close the input
+		m.viewMode = ViewModeArchive
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.archiveInput, cmd = m.archiveInput.Update(msg)
+	if query := strings.ToLower(strings.TrimSpace(m.archiveInput.Value())); query != m.archiveQuery {
+		m.archiveQuery = query
+		m.archiveCursor = 0
+	}
+	return m, cmd
+}
+
+// handleConfirmArchiveColumnKeys handles the prompt shown before archiving
+// every task of the current column
+func (m Model) handleConfirmArchiveColumnKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.viewMode = ViewModeBoard
+		return m, m.archiveColumn(m.columns[m.currentColumn].Status)
+
+	case "n", "N":
+		m.viewMode = ViewModeBoard
+		return m, nil
+	}
+
+	return m, nil
+}
@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// frameBudget is the longest a keypress may take to show on the board: one
+// frame at 60 frames per second
+const frameBudget = 16 * time.Millisecond
+
+// newLargeBoard returns the board of a workspace of n tasks spread over its
+// columns, loaded in a 120x40 terminal
+func newLargeBoard(b *testing.B, n int) Model {
+	b.Helper()
+	database, err := db.NewMemory()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { database.Close() })
+
+	statuses := []model.TaskStatus{model.StatusTodo, model.StatusInProgress, model.StatusDone}
+	drafts := make([]model.Task, n)
+	for i := range drafts {
+		drafts[i] = model.Task{
+			Title:       fmt.Sprintf("Task %d with a title", i),
+			Description: "some **description**",
+			Status:      statuses[i%len(statuses)],
+			Priority:    model.PriorityMedium,
+			Tags:        []string{fmt.Sprintf("tag%d", i%20)},
+		}
+	}
+	if _, err := database.CreateTasks(drafts); err != nil {
+		b.Fatal(err)
+	}
+
+	var m tea.Model = NewModel(database, "bench", DefaultTheme(), DefaultOptions())
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = m.Update(m.(Model).loadTasks()())
+	board := m.(Model)
+	if got := len(board.allTasks()); got != n {
+		b.Fatalf("board holds %d tasks, want %d", got, n)
+	}
+	return board
+}
+
+// benchmarkKeypress measures a keypress on a board of 10,000 tasks, from
+// the key to the rendered frame, and fails over frameBudget. setup puts
+// the board in the state measured.
+func benchmarkKeypress(b *testing.B, setup func(m Model) Model, keys ...tea.KeyMsg) {
+	var m tea.Model = setup(newLargeBoard(b, 10000))
+	_ = m.View() // the first frame fills the caches
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m, _ = m.Update(keys[i%len(keys)])
+		_ = m.View()
+	}
+	b.StopTimer()
+	if per := b.Elapsed() / time.Duration(b.N); per > frameBudget {
+		b.Errorf("a keypress takes %v to show, over the %v of a frame", per, frameBudget)
+	}
+}
+
+var (
+	keyDown  = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")}
+	keyUp    = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")}
+	keyRight = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")}
+	keyLeft  = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")}
+)
+
+func BenchmarkKeypress(b *testing.B) {
+	benchmarkKeypress(b, func(m Model) Model { return m }, keyDown, keyDown, keyUp, keyRight, keyLeft)
+}
+
+func BenchmarkKeypressFiltered(b *testing.B) {
+	benchmarkKeypress(b, func(m Model) Model {
+		m.searchQuery = "task 1"
+		m.labelFilter = "tag3"
+		return m
+	}, keyDown, keyUp, keyRight, keyLeft)
+}
+
+func BenchmarkKeypressLanes(b *testing.B) {
+	benchmarkKeypress(b, func(m Model) Model {
+		m.lanes = laneTag
+		return m
+	}, keyDown, keyDown, keyUp)
+}
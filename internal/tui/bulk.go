@@ -0,0 +1,322 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// bulkPriorities are the choices of the bulk priority picker
+var bulkPriorities = []model.TaskPriority{model.PriorityNone, model.PriorityLow, model.PriorityMedium, model.PriorityHigh, model.PriorityUrgent}
+
+// bulkAppliedMsg reports a bulk action that was stored and can be undone
+type bulkAppliedMsg struct {
+	op     operation
+	notice string
+}
+
+// toggleSelected adds the selected task to the bulk selection, or takes it out
+func (m *Model) toggleSelected() {
+	task := m.getCurrentTask()
+	if task == nil {
+		return
+	}
+	if m.selected[task.ID] {
+		delete(m.selected, task.ID)
+		return
+	}
+	if m.selected == nil {
+		m.selected = map[int64]bool{}
+	}
+	m.selected[task.ID] = true
+}
+
+// selectedTasks returns the selected tasks in board order, column by column.
+// Tasks hidden by a filter stay selected.
+func (m Model) selectedTasks() []model.Task {
+	var tasks []model.Task
+	for _, col := range m.columns {
+		for _, task := range col.Tasks {
+			if m.selected[task.ID] {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	return tasks
+}
+
+// pruneSelection drops selected tasks that are no longer on the board
+func (m *Model) pruneSelection() {
+	if len(m.selected) == 0 {
+		return
+	}
+	onBoard := map[int64]bool{}
+	for _, col := range m.columns {
+		for _, task := range col.Tasks {
+			onBoard[task.ID] = true
+		}
+	}
+	for id := range m.selected {
+		if !onBoard[id] {
+			delete(m.selected, id)
+		}
+	}
+}
+
+// handleBulkKeys turns the action keys into bulk actions while tasks are
+// selected, reporting whether the key was one of them
+func (m Model) handleBulkKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, m.keys.Move):
+		m.viewMode = ViewModeBulkMove
+		m.bulkCursor = m.currentColumn
+	case key.Matches(msg, m.keys.Priority):
+		m.viewMode = ViewModeBulkPriority
+		m.bulkCursor = 0
+	case key.Matches(msg, m.keys.Tags):
+		m.viewMode = ViewModeBulkTag
+		m.bulkInput.SetValue("")
+		m.bulkInput.Focus()
+	case key.Matches(msg, m.keys.Archive):
+		return m, m.bulkArchive(), true
+	case key.Matches(msg, m.keys.Delete):
+		m.viewMode = ViewModeConfirmBulkDelete
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// handleBulkPickerKeys handles keyboard input in the bulk column and
+// priority pickers
+func (m Model) handleBulkPickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	count := len(bulkPriorities)
+	if m.viewMode == ViewModeBulkMove {
+		count = len(m.columns)
+	}
+	switch msg.String() {
+	case "up", "k":
+		if m.bulkCursor > 0 {
+			m.bulkCursor--
+		}
+	case "down", "j":
+		if m.bulkCursor < count-1 {
+			m.bulkCursor++
+		}
+	case "enter":
+		mode := m.viewMode
+		m.viewMode = ViewModeBoard
+		if mode == ViewModeBulkMove {
+			return m, m.bulkMove(m.columns[m.bulkCursor])
+		}
+		return m, m.bulkPriority(bulkPriorities[m.bulkCursor])
+	}
+	return m, nil
+}
+
+// handleBulkTagKeys handles keyboard input when adding a tag to the selection
+func (m Model) handleBulkTagKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "enter" {
+		m.viewMode = ViewModeBoard
+		tag := strings.TrimSpace(m.bulkInput.Value())
+		if tag == "" {
+			return m, nil
+		}
+		return m, m.bulkTag(tag)
+	}
+	var cmd tea.Cmd
+	m.bulkInput, cmd = m.bulkInput.Update(msg)
+	return m, cmd
+}
+
+// handleConfirmBulkDeleteKeys handles keyboard input when confirming the
+// deletion of the selected tasks
+func (m Model) handleConfirmBulkDeleteKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.viewMode = ViewModeBoard
+		return m, m.bulkDelete()
+	case "n", "N":
+		m.viewMode = ViewModeBoard
+	}
+	return m, nil
+}
+
+// applyBulk runs apply on the selected tasks and records the change for undo
+// as one operation, with a snapshot of every task before and after it.
+// apply returns the tasks it created, which undo puts in the trash.
+func (m Model) applyBulk(notice string, apply func(ids []int64) ([]model.Task, error)) tea.Cmd {
+	return m.applyTo(m.selectedTasks(), notice, apply)
+}
+
+// applyTo is applyBulk for the given tasks rather than the selected ones
+func (m Model) applyTo(tasks []model.Task, notice string, apply func(ids []int64) ([]model.Task, error)) tea.Cmd {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(tasks))
+	before := make(map[int64]*model.Task, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+		before[tasks[i].ID] = snapshot(&tasks[i])
+	}
+
+	return func() tea.Msg {
+		created, err := apply(ids)
+		if err != nil {
+			return errMsg{err}
+		}
+		after, err := m.db.GetTasks(ids)
+		if err != nil {
+			return errMsg{err}
+		}
+		op := operation{kind: opBulk}
+		for i := range after {
+			op.parts = append(op.parts, operation{kind: opEdit, before: before[after[i].ID], after: &after[i]})
+		}
+		for i := range created {
+			op.parts = append(op.parts, operation{kind: opCreate, after: &created[i]})
+		}
+		if len(created) > 0 {
+			notice += ", " + pluralize(len(created), "next occurrence", "next occurrences") + " created"
+		}
+		return bulkAppliedMsg{op, notice}
+	}
+}
+
+// bulkMove moves the selected tasks to the bottom of a column
+func (m Model) bulkMove(target model.Column) tea.Cmd {
+	n := len(m.selectedTasks())
+	notice := fmt.Sprintf("moved %s to %s", pluralize(n, "task", "tasks"), target.Name)
+	moving := 0
+	for _, task := range m.selectedTasks() {
+		if task.Status != target.Status {
+			moving++
+		}
+	}
+	if target.OverWIPLimit(len(target.Tasks) + moving) {
+		// Strict limits make the database refuse the move instead
+		notice += fmt.Sprintf(" (WIP limit %d exceeded)", target.WIPLimit)
+	}
+	return m.applyBulk(notice, func(ids []int64) ([]model.Task, error) {
+		return m.db.MoveTasks(ids, target.Status)
+	})
+}
+
+// bulkPriority sets the priority of the selected tasks
+func (m Model) bulkPriority(priority model.TaskPriority) tea.Cmd {
+	tasks := pluralize(len(m.selectedTasks()), "task", "tasks")
+	notice := fmt.Sprintf("set %s to %s priority", tasks, priority)
+	if priority == model.PriorityNone {
+		notice = "cleared the priority of " + tasks
+	}
+	return m.applyBulk(notice, func(ids []int64) ([]model.Task, error) {
+		return nil, m.db.SetTasksPriority(ids, priority)
+	})
+}
+
+// bulkTag adds a tag to the selected tasks
+func (m Model) bulkTag(tag string) tea.Cmd {
+	notice := fmt.Sprintf("tagged %s %s", pluralize(len(m.selectedTasks()), "task", "tasks"), tag)
+	return m.applyBulk(notice, func(ids []int64) ([]model.Task, error) {
+		return nil, m.db.AddTasksTag(ids, tag)
+	})
+}
+
+// bulkArchive archives the selected tasks
+func (m Model) bulkArchive() tea.Cmd {
+	notice := fmt.Sprintf("archived %s", pluralize(len(m.selectedTasks()), "task", "tasks"))
+	return m.applyBulk(notice, func(ids []int64) ([]model.Task, error) {
+		return nil, m.db.ArchiveTasks(ids)
+	})
+}
+
+// bulkDelete moves the selected tasks to the trash
+func (m Model) bulkDelete() tea.Cmd {
+	notice := fmt.Sprintf("moved %s to the trash", pluralize(len(m.selectedTasks()), "task", "tasks"))
+	return m.applyBulk(notice, func(ids []int64) ([]model.Task, error) {
+		return nil, m.db.DeleteTasks(ids)
+	})
+}
+
+// bulkTargets returns the snapshots undoing or redoing a bulk operation
+// writes back. Tasks it created go to the trash on undo.
+func (op operation) bulkTargets(undo bool, now time.Time) []model.Task {
+	tasks := make([]model.Task, 0, len(op.parts))
+	for _, part := range op.parts {
+		target, other := part.after, part.before
+		if undo {
+			target, other = part.before, part.after
+		}
+		if target == nil {
+			trashed := *other
+			trashed.DeletedAt = &now
+			target = &trashed
+		}
+		tasks = append(tasks, *target)
+	}
+	return tasks
+}
+
+// renderSelection renders the selection count and the bulk action keys for
+// the footer
+func (m Model) renderSelection() string {
+	count := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render(fmt.Sprintf("%d selected", len(m.selected)))
+	return count + "  m move | p priority | t tag | x archive | d delete | Esc clear"
+}
+
+// viewBulk renders the bulk column picker, priority picker and tag input
+func (m Model) viewBulk() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("☑ Bulk Action"))
+	b.WriteString("\n\n")
+
+	tasks := pluralize(len(m.selectedTasks()), "selected task", "selected tasks")
+	info := lipgloss.NewStyle().Foreground(colorSecondary)
+	cursor := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	var options []string
+	switch m.viewMode {
+	case ViewModeBulkMove:
+		b.WriteString(info.Render(fmt.Sprintf("Move %s to:", tasks)))
+		for _, col := range m.columns {
+			options = append(options, col.Name)
+		}
+	case ViewModeBulkPriority:
+		b.WriteString(info.Render(fmt.Sprintf("Set the priority of %s:", tasks)))
+		for _, p := range bulkPriorities {
+			name := string(p)
+			if p == model.PriorityNone {
+				name = "none"
+			}
+			if marker := priorityMarker(p); marker != "" {
+				name = lipgloss.NewStyle().Foreground(priorityColor(p)).Render(marker) + " " + name
+			}
+			options = append(options, name)
+		}
+	case ViewModeBulkTag:
+		b.WriteString(info.Render(fmt.Sprintf("Add a tag to %s:", tasks)))
+		b.WriteString("\n\n")
+		b.WriteString(inputStyle.Render(m.bulkInput.View()))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render("Enter: Add tag | Esc: Cancel"))
+		return b.String()
+	}
+
+	b.WriteString("\n\n")
+	for i, option := range options {
+		if i == m.bulkCursor {
+			b.WriteString(cursor.Render("> ") + option)
+		} else {
+			b.WriteString("  " + option)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑ ↓: Choose | Enter: Apply | Esc: Cancel"))
+	return b.String()
+}
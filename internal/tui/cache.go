@@ -0,0 +1,209 @@
+package tui
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// boardCache keeps what is worked out over and over about the tasks on the
+// board, so boards with thousands of tasks redraw quickly: the tasks visible
+// in each column, the heights of the cards and where each task is. Every
+// entry carries or checks what it was worked out from, so the cache stays
+// valid across reloads; copies of the model share it.
+type boardCache struct {
+	mu       sync.Mutex
+	entries  map[int]visibleEntry // by column index
+	stamp    cardStamp
+	heights  map[loadedCard]int  // since the tasks were loaded
+	measured map[cardKey]int     // across reloads
+	places   map[int64]taskPlace // by task ID
+}
+
+// newBoardCache returns an empty cache
+func newBoardCache() *boardCache {
+	return &boardCache{}
+}
+
+// reloaded drops what the tasks just loaded make useless: where the tasks
+// were, the heights by task, and the heights of cards that are gone once
+// they pile up
+func (c *boardCache) reloaded(tasks int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.places = nil
+	c.heights = nil
+	if len(c.measured) > 2*tasks+100 {
+		c.measured = nil
+	}
+}
+
+// visibleKey is what the visible tasks of a column depend on
+type visibleKey struct {
+	tasks  *model.Task // the column's tasks; reloading or reordering them makes a new slice
+	count  int
+	search string
+	urgent bool
+	label  string
+	stale  bool
+	pinned bool
+	lanes  laneMode
+	minute int64 // stale tasks and due: searches change with the time
+}
+
+// visibleEntry holds the visible tasks of a column and what they were
+// worked out from
+type visibleEntry struct {
+	key     visibleKey
+	indices []int
+}
+
+// visible returns the visible tasks of the column at index, working them
+// out with compute unless they were worked out from the same key. A nil
+// cache always computes them.
+func (c *boardCache) visible(index int, key visibleKey, compute func() []int) []int {
+	if c == nil {
+		return compute()
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[index]
+	c.mu.Unlock()
+	if ok && entry.key == key {
+		return entry.indices
+	}
+	indices := compute()
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[int]visibleEntry)
+	}
+	c.entries[index] = visibleEntry{key, indices}
+	c.mu.Unlock()
+	return indices
+}
+
+// loadedCard is what the height of a card depends on while the tasks stay
+// as they were loaded; it is quick to make, so it is checked first
+type loadedCard struct {
+	id       int64
+	width    int
+	selected bool
+	timed    bool
+}
+
+// cardKey is what the height of a card depends on: the parts of the task it
+// shows and how the board shows them
+type cardKey struct {
+	id          int64
+	width       int
+	selected    bool
+	timed       bool
+	blocked     bool
+	title       string
+	tags        string
+	priority    model.TaskPriority
+	pinned      bool
+	description bool
+	recurring   bool
+	due         bool
+	stale       string // the age shown, "" when the task isn't stale
+	woke        bool
+	subtasks    int
+	epic        epicProgress
+}
+
+// cardStamp is what the heights of all cards depend on; the heights
+// measured under another stamp are dropped
+type cardStamp struct {
+	titleLines int
+}
+
+// cardKey returns the full key of the card of a task
+func (m *Model) cardKey(task model.Task, loaded loadedCard) cardKey {
+	key := cardKey{
+		id:          task.ID,
+		width:       loaded.width,
+		selected:    loaded.selected,
+		timed:       loaded.timed,
+		blocked:     !m.isDoneStatus(task.Status) && len(m.openBlockers(task)) > 0,
+		title:       task.Title,
+		tags:        strings.Join(task.Tags, "\x00"),
+		priority:    task.Priority,
+		pinned:      task.Pinned,
+		description: strings.TrimSpace(task.Description) != "",
+		recurring:   task.Recurrence != "",
+		due:         task.Due != nil,
+		woke:        task.WokeToday(m.currentTime),
+		subtasks:    len(task.Subtasks),
+		epic:        m.epics[task.ID],
+	}
+	if m.isStale(task) {
+		key.stale = m.staleAge(task)
+	}
+	return key
+}
+
+// cardHeight returns the height of a card, measuring it with measure
+// unless it was measured under the same key and stamp. The full key is
+// only made, with key, when the card wasn't measured since the tasks were
+// loaded.
+func (c *boardCache) cardHeight(loaded loadedCard, key func() cardKey, stamp cardStamp, measure func() int) int {
+	c.mu.Lock()
+	if c.stamp != stamp {
+		c.stamp = stamp
+		c.heights, c.measured = nil, nil
+	}
+	height, ok := c.heights[loaded]
+	c.mu.Unlock()
+	if ok {
+		return height
+	}
+
+	full := key()
+	c.mu.Lock()
+	height, ok = c.measured[full]
+	c.mu.Unlock()
+	if !ok {
+		height = measure()
+	}
+	c.mu.Lock()
+	if c.heights == nil {
+		c.heights = make(map[loadedCard]int)
+	}
+	if c.measured == nil {
+		c.measured = make(map[cardKey]int)
+	}
+	c.heights[loaded] = height
+	c.measured[full] = height
+	c.mu.Unlock()
+	return height
+}
+
+// taskPlace is where a task is on the board
+type taskPlace struct {
+	column int
+	index  int
+}
+
+// place returns where the task with the given ID was last seen among
+// columns; the caller checks the task is still there
+func (c *boardCache) place(id int64, columns []model.Column) (taskPlace, bool) {
+	if c == nil {
+		return taskPlace{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.places == nil {
+		c.places = make(map[int64]taskPlace)
+		for col := range columns {
+			for i, task := range columns[col].Tasks {
+				c.places[task.ID] = taskPlace{col, i}
+			}
+		}
+	}
+	p, ok := c.places[id]
+	return p, ok
+}
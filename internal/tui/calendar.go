@@ -0,0 +1,360 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+const (
+	// calendarCellWidth is the width of a day in the month grid
+	calendarCellWidth = 7
+	// calendarListWidth is the width of the list of the selected day's tasks
+	calendarListWidth = 34
+	// calendarOverdue is how many overdue tasks the summary names
+	calendarOverdue = 3
+)
+
+// calendarData holds the tasks shown by the calendar
+type calendarData struct {
+	from, to time.Time    // days loaded, to excluded
+	tasks    []model.Task // tasks due on the loaded days, by due date
+	overdue  []model.Task // tasks overdue outside the done column, most overdue first
+}
+
+// calendarLoadedMsg carries the tasks shown by the calendar
+type calendarLoadedMsg struct {
+	data calendarData
+}
+
+// calendarRange returns the days of the month grid holding day: the weeks,
+// Monday to Sunday, that overlap its month
+func calendarRange(day time.Time) (time.Time, time.Time) {
+	first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	from := first.AddDate(0, 0, -((int(first.Weekday()) + 6) % 7))
+	last := first.AddDate(0, 1, -1)
+	to := last.AddDate(0, 0, 7-(int(last.Weekday())+6)%7)
+	return from, to
+}
+
+// loadCalendar loads the tasks due in the month of the selected day and the
+// overdue ones
+func (m Model) loadCalendar() tea.Cmd {
+	from, to := calendarRange(m.calendarDay)
+	now := m.currentTime
+	return func() tea.Msg {
+		tasks, err := m.db.GetTasksDueBetween(from, to)
+		if err != nil {
+			return errMsg{err}
+		}
+		due, err := m.db.DueTasks(now)
+		if err != nil {
+			return errMsg{err}
+		}
+		today := dates.StartOfDay(now)
+		var overdue []model.Task
+		for _, task := range due {
+			if dates.Day(*task.Due, now.Location()).Before(today) {
+				overdue = append(overdue, task)
+			}
+		}
+		return calendarLoadedMsg{calendarData{from: from, to: to, tasks: tasks, overdue: overdue}}
+	}
+}
+
+// showCalendar opens the calendar on today
+func (m Model) showCalendar() (tea.Model, tea.Cmd) {
+	m.viewMode = ViewModeCalendar
+	m.calendarDay = dates.StartOfDay(m.currentTime)
+	m.calendarCursor = 0
+	m.calendar = nil
+	m.err = nil
+	return m, m.loadCalendar()
+}
+
+// setCalendarDay selects another day, loading its month when it isn't yet
+func (m *Model) setCalendarDay(day time.Time) tea.Cmd {
+	m.calendarDay = dates.StartOfDay(day)
+	m.calendarCursor = 0
+	if m.calendar != nil && !day.Before(m.calendar.from) && day.Before(m.calendar.to) {
+		return nil
+	}
+	return m.loadCalendar()
+}
+
+// calendarDayTasks returns the tasks due on the selected day
+func (m Model) calendarDayTasks() []model.Task {
+	if m.calendar == nil {
+		return nil
+	}
+	var tasks []model.Task
+	for _, task := range m.calendar.tasks {
+		if dates.Day(*task.Due, m.calendarDay.Location()).Equal(m.calendarDay) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// handleCalendarKeys handles keyboard input in the calendar: the arrows
+// move between days, PgUp/PgDn between months, Tab through the day's tasks
+func (m Model) handleCalendarKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	tasks := m.calendarDayTasks()
+
+	switch {
+	case key.Matches(msg, m.keys.Calendar):
+		m.viewMode = ViewModeBoard
+		m.calendar = nil
+		return m, nil
+
+	case key.Matches(msg, m.keys.Left):
+		return m, m.setCalendarDay(m.calendarDay.AddDate(0, 0, -1))
+	case key.Matches(msg, m.keys.Right):
+		return m, m.setCalendarDay(m.calendarDay.AddDate(0, 0, 1))
+	case key.Matches(msg, m.keys.Up):
+		return m, m.setCalendarDay(m.calendarDay.AddDate(0, 0, -7))
+	case key.Matches(msg, m.keys.Down):
+		return m, m.setCalendarDay(m.calendarDay.AddDate(0, 0, 7))
+	case key.Matches(msg, m.keys.PageUp):
+		return m, m.setCalendarDay(m.calendarDay.AddDate(0, -1, 0))
+	case key.Matches(msg, m.keys.PageDown):
+		return m, m.setCalendarDay(m.calendarDay.AddDate(0, 1, 0))
+	case key.Matches(msg, m.keys.Top):
+		return m, m.setCalendarDay(m.currentTime)
+
+	case msg.String() == "tab":
+		if len(tasks) > 0 {
+			m.calendarCursor = (m.calendarCursor + 1) % len(tasks)
+		}
+		return m, nil
+	case msg.String() == "shift+tab":
+		if len(tasks) > 0 {
+			m.calendarCursor = (m.calendarCursor + len(tasks) - 1) % len(tasks)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Details):
+		if m.calendarCursor < len(tasks) && m.selectTask(tasks[m.calendarCursor].ID) {
+			cmd := m.openDetail()
+			m.detailReturn = ViewModeCalendar
+			return m, cmd
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.MarkDone):
+		if m.calendarCursor < len(tasks) && len(m.columns) > 0 {
+			task := tasks[m.calendarCursor]
+			if m.isDoneStatus(task.Status) {
+				m.showNotice("already done")
+				return m, nil
+			}
+			return m, m.moveTask(&task, m.doneColumn())
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// selectTask selects a task on the board by ID, clearing the filters that
+// hide it, and reports whether it is on the board
+func (m *Model) selectTask(id int64) bool {
+	for c, col := range m.columns {
+		for _, task := range col.Tasks {
+			if task.ID != id {
+				continue
+			}
+			if !m.taskVisible(task) || m.columnHidden(c) {
+				m.clearFilters()
+			}
+			m.currentColumn = c
+			for i, idx := range m.visibleTaskIndices(c) {
+				if col.Tasks[idx].ID == id {
+					m.currentTask = i
+				}
+			}
+			m.ensureColumnVisible()
+			m.ensureTaskVisible()
+			return true
+		}
+	}
+	return false
+}
+
+// viewCalendar renders the overdue summary, the month grid, or the week of
+// the selected day on narrow terminals, and the selected day's tasks
+func (m Model) viewCalendar() string {
+	var b strings.Builder
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, titleStyle.Render("📅 Calendar"), statsStyle.Render(" "+m.workspace)))
+	b.WriteString("\n\n")
+
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	if m.calendar == nil {
+		b.WriteString(mutedStyle.Render("Loading…"))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(closeHint(m.keys.Calendar) + ": Back"))
+		return b.String()
+	}
+
+	if overdue := m.calendar.overdue; len(overdue) > 0 {
+		names := make([]string, 0, calendarOverdue)
+		for i, task := range overdue {
+			if i == calendarOverdue {
+				names = append(names, fmt.Sprintf("%d more", len(overdue)-i))
+				break
+			}
+			names = append(names, fmt.Sprintf("%q (%s)", truncateText(task.Title, 24), task.Due.Format("Jan 2")))
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(colorOverdue).Bold(true).Render(fmt.Sprintf("⚠ %d overdue: ", len(overdue))))
+		b.WriteString(lipgloss.NewStyle().Foreground(colorOverdue).Render(strings.Join(names, ", ")))
+		b.WriteString("\n\n")
+	}
+
+	width := m.width
+	if width <= 0 {
+		width = 80
+	}
+	list := m.viewCalendarDay()
+	if width >= 7*calendarCellWidth+2+calendarListWidth {
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, m.viewCalendarMonth(), "  ", list))
+	} else {
+		b.WriteString(m.viewCalendarWeek())
+		b.WriteString("\n")
+		b.WriteString(list)
+	}
+	b.WriteString("\n\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+	if notice := m.renderNotice(); notice != "" {
+		b.WriteString(notice + "  ")
+	}
+	b.WriteString(helpStyle.Render(joinHints("←→↑↓: Day", "PgUp/PgDn: Month", hint(m.keys.Top, "Today"), "Tab: Task", hint(m.keys.Details, "Details"), hint(m.keys.MarkDone, "Done"), closeHint(m.keys.Calendar)+": Back")))
+	return b.String()
+}
+
+// calendarCounts returns the number of tasks due on each loaded day and
+// whether any of them is open, by date
+func (m Model) calendarCounts() (map[string]int, map[string]bool) {
+	counts := map[string]int{}
+	open := map[string]bool{}
+	for _, task := range m.calendar.tasks {
+		day := task.Due.Format(dates.DateFormat)
+		counts[day]++
+		if !m.isDoneStatus(task.Status) {
+			open[day] = true
+		}
+	}
+	return counts, open
+}
+
+// calendarCell renders one day of the grid: its number and task count,
+// highlighted when selected, red when it has overdue tasks and bold today
+func (m Model) calendarCell(day time.Time, label string, counts map[string]int, open map[string]bool, width int) string {
+	date := day.Format(dates.DateFormat)
+	count := ""
+	if n := counts[date]; n > 0 {
+		count = fmt.Sprintf("·%d", n)
+	}
+	text := fmt.Sprintf("%s %-3s", label, count)
+	style := lipgloss.NewStyle().Width(width)
+	today := dates.StartOfDay(m.currentTime)
+	switch {
+	case day.Equal(m.calendarDay):
+		style = style.Background(colorSelected).Foreground(colorSelectedText).Bold(true)
+	case open[date] && day.Before(today):
+		style = style.Foreground(colorOverdue)
+	case counts[date] == 0:
+		style = style.Foreground(colorMuted)
+	}
+	if day.Equal(today) {
+		style = style.Bold(true).Underline(true)
+	}
+	return style.Render(text)
+}
+
+// viewCalendarMonth renders the month of the selected day as a grid
+func (m Model) viewCalendarMonth() string {
+	var b strings.Builder
+	headingStyle := lipgloss.NewStyle().Bold(true).Foreground(colorSecondary)
+	b.WriteString(headingStyle.Render(m.calendarDay.Format("January 2006")))
+	b.WriteString("\n")
+	for _, name := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		b.WriteString(lipgloss.NewStyle().Width(calendarCellWidth).Foreground(colorMuted).Render(name))
+	}
+	b.WriteString("\n")
+
+	counts, open := m.calendarCounts()
+	from, to := calendarRange(m.calendarDay)
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if day.Month() != m.calendarDay.Month() {
+			b.WriteString(strings.Repeat(" ", calendarCellWidth))
+		} else {
+			b.WriteString(m.calendarCell(day, fmt.Sprintf("%2d", day.Day()), counts, open, calendarCellWidth))
+		}
+		if day.Weekday() == time.Sunday {
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// viewCalendarWeek renders the week of the selected day, one day per line
+func (m Model) viewCalendarWeek() string {
+	var b strings.Builder
+	headingStyle := lipgloss.NewStyle().Bold(true).Foreground(colorSecondary)
+	monday := m.calendarDay.AddDate(0, 0, -((int(m.calendarDay.Weekday()) + 6) % 7))
+	b.WriteString(headingStyle.Render("Week of " + monday.Format("Jan 2, 2006")))
+	b.WriteString("\n")
+	counts, open := m.calendarCounts()
+	for i := 0; i < 7; i++ {
+		day := monday.AddDate(0, 0, i)
+		b.WriteString(m.calendarCell(day, day.Format("Mon Jan _2"), counts, open, 18))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// viewCalendarDay renders the tasks due on the selected day
+func (m Model) viewCalendarDay() string {
+	var b strings.Builder
+	headingStyle := lipgloss.NewStyle().Bold(true).Foreground(colorSecondary)
+	b.WriteString(headingStyle.Render(m.calendarDay.Format("Monday, January 2")))
+	b.WriteString("\n")
+	tasks := m.calendarDayTasks()
+	if len(tasks) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("Nothing due"))
+		return b.String()
+	}
+
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	infoStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	for i, task := range tasks {
+		column := string(task.Status)
+		if col, ok := model.FindColumn(m.columns, column); ok {
+			column = col.Name
+		}
+		title := task.Title
+		if marker := priorityMarker(task.Priority); marker != "" {
+			title = marker + " " + title
+		}
+		line := truncateText(title, calendarListWidth-4)
+		if i == m.calendarCursor {
+			b.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+		b.WriteString(infoStyle.Render("    " + truncateText(column, calendarListWidth-4)))
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
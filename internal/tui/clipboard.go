@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// copyTools are the programs tried in turn to write the system clipboard
+var copyTools = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// pasteTools are the programs tried in turn to read the system clipboard.
+// Terminals don't hand the clipboard out through OSC 52, so pasting needs one.
+var pasteTools = [][]string{
+	{"pbpaste"},
+	{"wl-paste", "--no-newline"},
+	{"xclip", "-selection", "clipboard", "-o"},
+	{"xsel", "--clipboard", "--output"},
+	{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard"},
+}
+
+// errNoClipboard reports that no clipboard program could be run
+var errNoClipboard = errors.New("no clipboard program found: install xclip, xsel or wl-clipboard (pbcopy and pbpaste come with macOS)")
+
+// clipboardCopiedMsg reports text copied to the clipboard
+type clipboardCopiedMsg struct {
+	what string // what was copied, e.g. "title"
+	tool string // program that took the text, "" when only OSC 52 was sent
+}
+
+// clipboardPastedMsg carries the task titles read from the clipboard
+type clipboardPastedMsg struct {
+	titles []string
+}
+
+// tasksPastedMsg reports the tasks created from the clipboard
+type tasksPastedMsg struct {
+	op operation // a bulk operation made of one creation per task
+}
+
+// copyToClipboard copies text to the clipboard, both through OSC 52, which
+// also reaches the local clipboard over SSH in terminals that support it,
+// and through the first clipboard program that runs
+func copyToClipboard(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString([]byte(text)))
+		for _, tool := range copyTools {
+			cmd := exec.Command(tool[0], tool[1:]...)
+			cmd.Stdin = strings.NewReader(text)
+			if cmd.Run() == nil {
+				return clipboardCopiedMsg{what: what, tool: tool[0]}
+			}
+		}
+		return clipboardCopiedMsg{what: what}
+	}
+}
+
+// readClipboard reads the clipboard through the first clipboard program
+// that runs
+func readClipboard() (string, error) {
+	for _, tool := range pasteTools {
+		var out bytes.Buffer
+		cmd := exec.Command(tool[0], tool[1:]...)
+		cmd.Stdout = &out
+		if cmd.Run() == nil {
+			return out.String(), nil
+		}
+	}
+	return "", errNoClipboard
+}
+
+// pasteClipboard reads the clipboard for the tasks to create from it
+func pasteClipboard() tea.Cmd {
+	return func() tea.Msg {
+		text, err := readClipboard()
+		if err != nil {
+			return errMsg{err}
+		}
+		return clipboardPastedMsg{clipboardTitles(text)}
+	}
+}
+
+// clipboardTitles returns one task title per non-empty line of text. List
+// markers and checkboxes are dropped, so a pasted markdown list gives the
+// titles of its items.
+func clipboardTitles(text string) []string {
+	var titles []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		for _, marker := range []string{"- ", "* ", "+ "} {
+			if strings.HasPrefix(line, marker) {
+				line = strings.TrimSpace(line[len(marker):])
+				break
+			}
+		}
+		for _, box := range []string{"[ ] ", "[x] ", "[X] "} {
+			if strings.HasPrefix(line, box) {
+				line = strings.TrimSpace(line[len(box):])
+				break
+			}
+		}
+		if line != "" {
+			titles = append(titles, line)
+		}
+	}
+	return titles
+}
+
+// copiedNotice describes a copy to the clipboard. Without a clipboard
+// program, only a terminal supporting OSC 52 receives the text, and there is
+// no telling whether it did.
+func copiedNotice(msg clipboardCopiedMsg) string {
+	if msg.tool != "" {
+		return fmt.Sprintf("copied %s to the clipboard (%s)", msg.what, msg.tool)
+	}
+	return fmt.Sprintf("copied %s through OSC 52 only: if it is missing, install xclip, xsel or wl-clipboard", msg.what)
+}
+
+// taskMarkdown renders a task as markdown for the clipboard: its title as a
+// heading followed by its description
+func taskMarkdown(task *model.Task) string {
+	text := "# " + task.Title + "\n"
+	if description := strings.TrimSpace(task.Description); description != "" {
+		text += "\n" + description + "\n"
+	}
+	return text
+}
+
+// createPastedTasks creates one task per title on top of a column, in order,
+// recorded as one change so a single undo takes them all back
+func (m Model) createPastedTasks(titles []string, status model.TaskStatus) tea.Cmd {
+	return func() tea.Msg {
+		drafts := make([]model.Task, len(titles))
+		for i, title := range titles {
+			drafts[i] = model.Task{Title: title, Status: status}
+		}
+		created, err := m.db.CreateTasks(drafts)
+		if err != nil {
+			return errMsg{err}
+		}
+		op := operation{kind: opBulk}
+		for i := range created {
+			op.parts = append(op.parts, operation{kind: opCreate, after: &created[i]})
+		}
+		return tasksPastedMsg{op}
+	}
+}
+
+// handleConfirmPasteKeys handles keyboard input when confirming the tasks to
+// create from several lines of clipboard text
+func (m Model) handleConfirmPasteKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y", "enter":
+		titles := m.pasteTitles
+		m.pasteTitles = nil
+		m.viewMode = ViewModeBoard
+		return m, m.createPastedTasks(titles, m.columns[m.currentColumn].Status)
+	case "n", "N":
+		m.pasteTitles = nil
+		m.viewMode = ViewModeBoard
+	}
+	return m, nil
+}
@@ -0,0 +1,164 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+const (
+	// collapsedColumnWidth is the on-screen width of a collapsed column,
+	// border included
+	collapsedColumnWidth = 5
+	// expandDuration is how long a collapsed column opens up after a task
+	// is moved into it
+	expandDuration = 1500 * time.Millisecond
+)
+
+// columnSet holds a flag per column by status; columns without it aren't in it
+type columnSet map[model.TaskStatus]bool
+
+// isCollapsed reports whether the column at index shows as a narrow strip:
+// it is collapsed and hasn't briefly opened up for a task moved into it
+func (m *Model) isCollapsed(index int) bool {
+	if index < 0 || index >= len(m.columns) {
+		return false
+	}
+	status := m.columns[index].Status
+	if !m.collapsedColumns[status] {
+		return false
+	}
+	return status != m.expandedColumn || m.currentTime.Sub(m.expandedAt) >= expandDuration
+}
+
+// collapsedFocus reports whether the focused column is a strip, so none of
+// its tasks is selected. The single-column layout always shows the focused
+// column in full.
+func (m Model) collapsedFocus() bool {
+	return m.isCollapsed(m.currentColumn) && !m.layout().single
+}
+
+// toggleCollapse collapses the focused column to a strip or opens it back
+// up, and saves that for the workspace
+func (m Model) toggleCollapse() (tea.Model, tea.Cmd) {
+	return m.collapseColumn(m.currentColumn)
+}
+
+// collapseColumn collapses the column at index to a strip or opens it back
+// up, and saves that for the workspace
+func (m Model) collapseColumn(index int) (tea.Model, tea.Cmd) {
+	if index < 0 || index >= len(m.columns) {
+		return m, nil
+	}
+	col := m.columns[index]
+	collapsed := !m.collapsedColumns[col.Status]
+
+	columns := make(columnSet, len(m.collapsedColumns)+1)
+	for status := range m.collapsedColumns {
+		columns[status] = true
+	}
+	if collapsed {
+		columns[col.Status] = true
+	} else {
+		delete(columns, col.Status)
+	}
+	m.collapsedColumns = columns
+	m.ensureColumnVisible()
+	m.ensureTaskVisible()
+	if collapsed {
+		m.showNotice(fmt.Sprintf("%s collapsed, %s to open it", col.Name, m.keys.Collapse.Help().Key))
+	} else {
+		m.showNotice(col.Name + " expanded")
+	}
+
+	// A read-only board collapses for this window only
+	if m.readOnly() {
+		return m, nil
+	}
+	return m, func() tea.Msg {
+		if err := m.db.SetColumnCollapsed(col.Status, collapsed); err != nil {
+			return errMsg{err}
+		}
+		return nil
+	}
+}
+
+// expandBriefly opens up a collapsed column for a moment, to show the task
+// just moved into it
+func (m *Model) expandBriefly(index int) {
+	m.expandedColumn = m.columns[index].Status
+	m.expandedAt = m.currentTime
+}
+
+// pageEnd returns the end (exclusive) of the shown columns on screen when
+// the board is scrolled to start: the page holds as many full columns as
+// the layout fits, and the strips of the collapsed ones among them
+func (m *Model) pageEnd(start int) int {
+	shown := m.shownColumns()
+	layout := m.layout()
+	if layout.single {
+		return min(start+1, len(shown))
+	}
+	end, full := start, 0
+	for end < len(shown) {
+		if !m.isCollapsed(shown[end]) {
+			if full == layout.perPage {
+				break
+			}
+			full++
+		}
+		end++
+	}
+	return end
+}
+
+// columnWidth returns the on-screen width of the column at index
+func (m *Model) columnWidth(index int) int {
+	if m.isCollapsed(index) && !m.layout().single {
+		return collapsedColumnWidth
+	}
+	return m.renderedColumnWidth(index)
+}
+
+// renderCollapsedColumn renders a collapsed column as a strip: the number
+// of tasks, then the name running down
+func (m Model) renderCollapsedColumn(index int, col model.Column) string {
+	count := 0
+	for _, task := range col.Tasks {
+		if m.taskVisible(task) {
+			count++
+		}
+	}
+	countStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	if col.OverWIPLimit(len(col.Tasks)) {
+		countStyle = countStyle.Copy().Foreground(colorDanger).Bold(true)
+	}
+
+	lines := []string{countStyle.Render(fmt.Sprint(count)), ""}
+	nameStyle := lipgloss.NewStyle().Bold(true).Foreground(m.columnColor(index))
+	if index == m.currentColumn {
+		nameStyle = nameStyle.Copy().Underline(true)
+	}
+	height := max(m.cardsHeight(), 1)
+	for _, c := range clusters(col.Name) {
+		if len(lines)-2 == height {
+			lines[len(lines)-1] = nameStyle.Render("…")
+			break
+		}
+		lines = append(lines, nameStyle.Render(c))
+	}
+
+	style := columnStyle.Copy().
+		Width(collapsedColumnWidth-2).
+		Padding(1, 0).
+		Align(lipgloss.Center).
+		BorderForeground(m.columnColor(index))
+	if index == m.currentColumn {
+		style = style.Copy().Bold(true).BorderStyle(lipgloss.ThickBorder())
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
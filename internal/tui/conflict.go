@@ -0,0 +1,252 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// editConflict is an edit refused because the task changed elsewhere since
+// the board loaded it: the task as loaded, with the edit, and as stored now
+type editConflict struct {
+	before model.Task
+	mine   model.Task
+	theirs model.Task
+}
+
+// conflictMsg reports an edit refused for a conflict
+type conflictMsg struct {
+	conflict editConflict
+}
+
+// editFields saves the edit change makes to the fields of task, as the board
+// loaded it. When the task changed elsewhere since, nothing is written and
+// the board asks what to do.
+func (m Model) editFields(task *model.Task, change func(t *model.Task)) tea.Cmd {
+	mine := snapshot(task)
+	change(mine)
+	return m.saveEdit(*snapshot(task), *mine)
+}
+
+// saveEdit writes mine over the task, made on the version of before, and
+// records the change for undo
+func (m Model) saveEdit(before, mine model.Task) tea.Cmd {
+	record := m.recordChange(opEdit, &before, func() error {
+		_, err := m.db.UpdateTaskFields(mine)
+		return err
+	})
+	return func() tea.Msg {
+		msg := record()
+		var conflict *db.ConflictError
+		if e, ok := msg.(errMsg); ok && errors.As(e.err, &conflict) {
+			return conflictMsg{editConflict{before: before, mine: mine, theirs: conflict.Task}}
+		}
+		return msg
+	}
+}
+
+// merged returns the task as stored now with the edit applied. A field
+// changed on one side only takes that change; a title or description
+// changed on both keeps both texts, and tags added or removed on either side
+// are added or removed.
+func (c editConflict) merged() model.Task {
+	merged := *snapshot(&c.theirs)
+	pick := func(before, mine, theirs, sep string) string {
+		switch {
+		case mine == before || mine == theirs:
+			return theirs
+		case theirs == before:
+			return mine
+		}
+		return theirs + sep + mine
+	}
+	merged.Title = pick(c.before.Title, c.mine.Title, c.theirs.Title, " / ")
+	merged.Description = pick(c.before.Description, c.mine.Description, c.theirs.Description, "\n\n")
+	if c.mine.Priority != c.before.Priority {
+		merged.Priority = c.mine.Priority
+	}
+	if !sameDue(c.mine.Due, c.before.Due) {
+		merged.Due = c.mine.Due
+	}
+
+	had := map[string]bool{}
+	for _, tag := range c.before.Tags {
+		had[strings.ToLower(tag)] = true
+	}
+	kept := map[string]bool{}
+	var added []string
+	for _, tag := range c.mine.Tags {
+		kept[strings.ToLower(tag)] = true
+		if !had[strings.ToLower(tag)] {
+			added = append(added, tag)
+		}
+	}
+	var tags []string
+	for _, tag := range c.theirs.Tags {
+		if kept[strings.ToLower(tag)] || !had[strings.ToLower(tag)] {
+			tags = append(tags, tag)
+		}
+	}
+	merged.Tags = mergeLabels(tags, added)
+	return merged
+}
+
+// sameDue reports whether two due dates are the same, or both unset
+func sameDue(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// handleConflictKeys handles the prompt shown when an edit conflicts with a
+// change made elsewhere: keep yours, reload theirs or merge both
+func (m Model) handleConflictKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.conflict
+	if c == nil {
+		m.viewMode = ViewModeBoard
+		return m, nil
+	}
+	switch msg.String() {
+	case "o", "O":
+		mine := c.mine
+		mine.Version = c.theirs.Version
+		return m.resolveConflict("kept your edit", m.saveEdit(c.theirs, mine))
+
+	case "m", "M":
+		return m.resolveConflict("merged both edits", m.saveEdit(c.theirs, c.merged()))
+
+	case "r", "R", "esc":
+		return m.resolveConflict("reloaded the task", m.loadTasks())
+	}
+	return m, nil
+}
+
+// resolveConflict closes the conflict prompt and runs cmd
+func (m Model) resolveConflict(notice string, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	m.followTaskID = m.conflict.theirs.ID
+	m.conflict = nil
+	m.viewMode = ViewModeBoard
+	m.showNotice(notice)
+	return m, cmd
+}
+
+// viewConflict renders the conflict prompt: the fields your edit and the
+// change made elsewhere disagree on, yours as removed and theirs as added
+func (m Model) viewConflict() string {
+	c := m.conflict
+	if c == nil {
+		return m.viewBoard()
+	}
+	fieldStyle := lipgloss.NewStyle().Foreground(colorSecondary).Bold(true)
+	mineStyle := lipgloss.NewStyle().Foreground(colorDanger)
+	theirsStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	keyStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	descStyle := lipgloss.NewStyle().Foreground(colorText)
+
+	lineWidth := m.width - 8
+	if lineWidth <= 0 || lineWidth > 72 {
+		lineWidth = 72
+	}
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("⚠ Task %d changed elsewhere while you edited it", c.theirs.ID)))
+	differences := c.differences(m.columns)
+	if len(differences) == 0 {
+		b.WriteString("\n")
+		b.WriteString(descStyle.Render("Your edit matches it; its checklist, link or other details changed."))
+		b.WriteString("\n")
+	}
+	for _, d := range differences {
+		b.WriteString("\n")
+		b.WriteString(fieldStyle.Render(d.field))
+		b.WriteString("\n")
+		b.WriteString(mineStyle.Render(truncateText("- "+d.mine, lineWidth)))
+		b.WriteString(descStyle.Render("  (yours)"))
+		b.WriteString("\n")
+		b.WriteString(theirsStyle.Render(truncateText("+ "+d.theirs, lineWidth)))
+		b.WriteString(descStyle.Render("  (theirs)"))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	for _, k := range [][2]string{{"o", "keep yours"}, {"r", "reload theirs"}, {"m", "merge both"}} {
+		b.WriteString(keyStyle.Render(k[0]) + " " + descStyle.Render(k[1]) + "   ")
+	}
+
+	box := inputStyle.Copy().Width(0).Render(strings.TrimRight(b.String(), " "))
+	width, height := m.width, m.height
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// conflictField is a field of the task your edit and theirs disagree on,
+// as shown in the prompt
+type conflictField struct {
+	field, mine, theirs string
+}
+
+// differences returns the fields of the task that differ between your edit
+// and the task as stored now
+func (c editConflict) differences(columns []model.Column) []conflictField {
+	var fields []conflictField
+	add := func(field, mine, theirs string) {
+		if mine != theirs {
+			fields = append(fields, conflictField{field, mine, theirs})
+		}
+	}
+	firstLine := func(text string) string {
+		line, _, more := strings.Cut(strings.TrimSpace(text), "\n")
+		if more {
+			line += " …"
+		}
+		if line == "" {
+			return "(none)"
+		}
+		return line
+	}
+	column := func(status model.TaskStatus) string {
+		if col, ok := model.FindColumn(columns, string(status)); ok {
+			return col.Name
+		}
+		return string(status)
+	}
+	due := func(t *time.Time) string {
+		if t == nil {
+			return "(none)"
+		}
+		return t.Format(dates.DateFormat)
+	}
+	priority := func(p model.TaskPriority) string {
+		if p == model.PriorityNone {
+			return "(none)"
+		}
+		return string(p)
+	}
+	tags := func(tags []string) string {
+		if len(tags) == 0 {
+			return "(none)"
+		}
+		return "#" + strings.Join(tags, " #")
+	}
+
+	add("title", c.mine.Title, c.theirs.Title)
+	if c.mine.Description != c.theirs.Description {
+		fields = append(fields, conflictField{"description", firstLine(c.mine.Description), firstLine(c.theirs.Description)})
+	}
+	add("column", column(c.mine.Status), column(c.theirs.Status))
+	add("priority", priority(c.mine.Priority), priority(c.theirs.Priority))
+	add("due", due(c.mine.Due), due(c.theirs.Due))
+	add("tags", tags(c.mine.Tags), tags(c.theirs.Tags))
+	return fields
+}
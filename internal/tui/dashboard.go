@@ -0,0 +1,246 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/db"
+)
+
+const (
+	// dashboardWeeks is how many weeks the burn-up chart covers at most
+	dashboardWeeks = 8
+	// burnUpHeight is the number of rows of the burn-up chart
+	burnUpHeight = 6
+	// dashboardTags is how many tags the tag distribution lists
+	dashboardTags = 8
+	// maxBarWidth keeps bar charts readable on wide terminals
+	maxBarWidth = 60
+)
+
+// dashboardLoadedMsg carries the statistics shown on the dashboard
+type dashboardLoadedMsg struct {
+	stats db.Stats
+}
+
+// loadDashboard loads the completions of the weeks shown on the dashboard
+func (m Model) loadDashboard() tea.Cmd {
+	return func() tea.Msg {
+		now := time.Now()
+		stats, err := m.db.Stats(now.AddDate(0, 0, -7*(dashboardWeeks-1)), m.staleBefore(), now)
+		if err != nil {
+			return errMsg{err}
+		}
+		return dashboardLoadedMsg{stats}
+	}
+}
+
+// showDashboard opens the statistics dashboard
+func (m Model) showDashboard() (tea.Model, tea.Cmd) {
+	m.viewMode = ViewModeDashboard
+	m.err = nil
+	return m, m.loadDashboard()
+}
+
+// handleDashboardKeys handles keyboard input on the dashboard
+func (m Model) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, m.keys.Dashboard) {
+		m.viewMode = ViewModeBoard
+		m.dashboard = nil
+	}
+	return m, nil
+}
+
+// viewDashboard renders the column counts, the burn-up of completed tasks,
+// the tag distribution and the overdue count
+func (m Model) viewDashboard() string {
+	var b strings.Builder
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, titleStyle.Render("📊 Dashboard"), statsStyle.Render(" "+m.workspace)))
+	b.WriteString("\n\n")
+
+	width := m.width
+	if width <= 0 {
+		width = 80
+	}
+	headingStyle := lipgloss.NewStyle().Bold(true).Foreground(colorSecondary)
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted)
+
+	// Tasks per column
+	b.WriteString(headingStyle.Render("Tasks per column"))
+	b.WriteString("\n")
+	rows := make([]barRow, len(m.columns))
+	for i, col := range m.columns {
+		rows[i] = barRow{col.Name, len(col.Tasks), m.columnColor(i)}
+	}
+	b.WriteString(renderBars(rows, width))
+	b.WriteString("\n")
+
+	// Overdue tasks and cycle time
+	overdue, stale := 0, 0
+	tags := make(map[string]int)
+	for _, col := range m.columns {
+		for _, task := range col.Tasks {
+			if task.Due != nil && dueUrgency(*task.Due, m.currentTime) == dueOverdue {
+				overdue++
+			}
+			if m.isStale(task) {
+				stale++
+			}
+			for _, tag := range task.Tags {
+				tags[tag]++
+			}
+		}
+	}
+	overdueStyle := mutedStyle
+	if overdue > 0 {
+		overdueStyle = lipgloss.NewStyle().Foreground(colorOverdue).Bold(true)
+	}
+	b.WriteString(overdueStyle.Render(fmt.Sprintf("Overdue: %d", overdue)))
+	b.WriteString(mutedStyle.Render(fmt.Sprintf("  |  Stale: %d", stale)))
+	if m.dashboard != nil && m.dashboard.Completed > 0 {
+		b.WriteString(mutedStyle.Render(fmt.Sprintf("  |  Average cycle time: %s", dates.Duration(m.dashboard.CycleTime))))
+	}
+	b.WriteString("\n\n")
+
+	// Burn-up of completed tasks
+	b.WriteString(headingStyle.Render("Completed tasks (cumulative, by week)"))
+	b.WriteString("\n")
+	if m.dashboard == nil {
+		b.WriteString(mutedStyle.Render("Loading…"))
+		b.WriteString("\n")
+	} else {
+		b.WriteString(renderBurnUp(m.dashboard.Weeks, width))
+	}
+	b.WriteString("\n")
+
+	// Tag distribution
+	b.WriteString(headingStyle.Render("Tags"))
+	b.WriteString("\n")
+	if len(tags) == 0 {
+		b.WriteString(mutedStyle.Italic(true).Render("No tagged tasks"))
+		b.WriteString("\n")
+	} else {
+		names := make([]string, 0, len(tags))
+		for name := range tags {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if tags[names[i]] != tags[names[j]] {
+				return tags[names[i]] > tags[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		if len(names) > dashboardTags {
+			names = names[:dashboardTags]
+		}
+		rows := make([]barRow, len(names))
+		for i, name := range names {
+			rows[i] = barRow{name, tags[name], getTagColor(name)}
+		}
+		b.WriteString(renderBars(rows, width))
+	}
+	b.WriteString("\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+	b.WriteString(helpStyle.Render(closeHint(m.keys.Dashboard) + ": Back"))
+	return b.String()
+}
+
+// barRow is one labelled bar of a horizontal bar chart
+type barRow struct {
+	label string
+	value int
+	color lipgloss.Color
+}
+
+// renderBars renders a horizontal bar chart scaled to fit width
+func renderBars(rows []barRow, width int) string {
+	labelWidth, max := 0, 0
+	for _, r := range rows {
+		if w := lipgloss.Width(r.label); w > labelWidth {
+			labelWidth = w
+		}
+		if r.value > max {
+			max = r.value
+		}
+	}
+	if labelWidth > 20 {
+		labelWidth = 20
+	}
+	barWidth := width - labelWidth - 10
+	if barWidth > maxBarWidth {
+		barWidth = maxBarWidth
+	}
+	if barWidth < 5 {
+		barWidth = 5
+	}
+
+	var b strings.Builder
+	for _, r := range rows {
+		n := 0
+		if max > 0 {
+			n = r.value * barWidth / max
+		}
+		if n == 0 && r.value > 0 {
+			n = 1
+		}
+		label := lipgloss.NewStyle().Width(labelWidth).Render(truncateText(r.label, labelWidth))
+		bar := lipgloss.NewStyle().Foreground(r.color).Render(strings.Repeat("█", n))
+		fmt.Fprintf(&b, "  %s %s %d\n", label, bar, r.value)
+	}
+	return b.String()
+}
+
+// renderBurnUp renders the running total of completed tasks over the weeks
+// as a column chart, with as many of the latest weeks as fit width
+func renderBurnUp(weeks []db.WeekStats, width int) string {
+	const cellWidth = 6
+	if fit := (width - 8) / cellWidth; len(weeks) > fit && fit > 0 {
+		weeks = weeks[len(weeks)-fit:]
+	}
+	totals := make([]int, len(weeks))
+	sum := 0
+	for i, w := range weeks {
+		sum += w.Completed
+		totals[i] = sum
+	}
+	if sum == 0 {
+		return lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("  No tasks completed in the last weeks") + "\n"
+	}
+
+	barStyle := lipgloss.NewStyle().Foreground(colorColumnLast)
+	var b strings.Builder
+	for row := burnUpHeight; row >= 1; row-- {
+		// Label the top row with the total
+		axis := "      "
+		if row == burnUpHeight {
+			axis = fmt.Sprintf("%5d ", sum)
+		}
+		b.WriteString(axis)
+		for _, total := range totals {
+			// Round up so every completed task shows at least one cell
+			height := (total*burnUpHeight + sum - 1) / sum
+			cell := strings.Repeat(" ", cellWidth)
+			if height >= row {
+				cell = " " + barStyle.Render(strings.Repeat("█", cellWidth-2)) + " "
+			}
+			b.WriteString(cell)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("      ")
+	for _, w := range weeks {
+		b.WriteString(lipgloss.NewStyle().Width(cellWidth).Align(lipgloss.Center).Render(w.Start.Format("01-02")))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// DemoWorkspace names the board of --demo
+const DemoWorkspace = "demo"
+
+// SeedDemo fills the database of --demo with the board of a small team
+// shipping a website: five columns, tags, priorities, checklists, an epic,
+// a dependency, and due dates around now, a few of them overdue
+func SeedDemo(database *db.DB, now time.Time) error {
+	_, err := database.ImportTasks(demoColumns(now), true)
+	return err
+}
+
+// demoColumns returns the columns and tasks of the demo board, dated
+// relative to now
+func demoColumns(now time.Time) []model.Column {
+	day := func(days int) time.Time {
+		return now.AddDate(0, 0, days).Truncate(time.Minute)
+	}
+	due := func(days int) *time.Time {
+		d := time.Date(now.Year(), now.Month(), now.Day()+days, 17, 0, 0, 0, now.Location())
+		return &d
+	}
+	done := func(days int) *time.Time {
+		d := day(days)
+		return &d
+	}
+	steps := func(done int, titles ...string) []model.Subtask {
+		subtasks := make([]model.Subtask, len(titles))
+		for i, title := range titles {
+			subtasks[i] = model.Subtask{Title: title, Done: i < done}
+		}
+		return subtasks
+	}
+
+	return []model.Column{
+		{Name: "Backlog", Status: "backlog", Tasks: []model.Task{
+			{ID: 1, Title: "Dark mode for the dashboard", Tags: []string{"frontend", "design"}, Priority: model.PriorityLow,
+				Description: "Users keep asking for it. Follow the system setting, with a toggle in the account menu.",
+				CreatedAt:   day(-30), UpdatedAt: day(-30)},
+			{ID: 2, Title: "Evaluate a CDN for images", Tags: []string{"ops"},
+				CreatedAt: day(-21), UpdatedAt: day(-21)},
+			{ID: 3, Title: "Write the API changelog", Tags: []string{"docs"}, Priority: model.PriorityLow,
+				CreatedAt: day(-9), UpdatedAt: day(-9)},
+		}},
+		{Name: "Todo", Status: model.StatusTodo, Tasks: []model.Task{
+			{ID: 4, Title: "Fix the signup form on Safari", Tags: []string{"frontend", "bug"}, Priority: model.PriorityUrgent, Due: due(-2),
+				Description: "The submit button does nothing on Safari 17.\n\nSteps:\n1. Open /signup\n2. Fill in the form\n3. Press **Create account**",
+				URL:         "https://example.com/issues/142",
+				CreatedAt:   day(-6), UpdatedAt: day(-3)},
+			{ID: 5, Title: "Migrate the newsletter list", Tags: []string{"backend"}, Priority: model.PriorityHigh, Due: due(-1),
+				Subtasks:  steps(1, "Export the old list", "Import it into the new tool", "Send a test issue"),
+				CreatedAt: day(-12), UpdatedAt: day(-4)},
+			{ID: 6, Title: "Launch announcement blog post", Tags: []string{"docs"}, Priority: model.PriorityMedium, Due: due(5),
+				BlockedBy: []int64{9}, Parent: 13,
+				CreatedAt: day(-5), UpdatedAt: day(-5)},
+			{ID: 7, Title: "Weekly dependency updates", Tags: []string{"ops"}, Due: due(2), Recurrence: "weekly",
+				CreatedAt: day(-40), UpdatedAt: day(-5)},
+		}},
+		{Name: "In Progress", Status: model.StatusInProgress, Tasks: []model.Task{
+			{ID: 13, Title: "Website relaunch", Tags: []string{"design"}, Priority: model.PriorityHigh, Due: due(12),
+				Description: "Everything that ships with the new site.",
+				CreatedAt:   day(-20), UpdatedAt: day(-2)},
+			{ID: 8, Title: "New pricing page", Tags: []string{"frontend", "design"}, Priority: model.PriorityHigh, Due: due(0), Parent: 13,
+				Description: "Three plans side by side, yearly billing toggle, FAQ below.",
+				Subtasks:    steps(3, "Wireframes", "Copy from marketing", "Plan cards", "Billing toggle", "FAQ section"),
+				CreatedAt:   day(-10), UpdatedAt: day(-1)},
+			{ID: 9, Title: "Payment webhooks", Tags: []string{"backend"}, Priority: model.PriorityUrgent, Due: due(1), Parent: 13,
+				Subtasks:  steps(1, "Verify signatures", "Handle refunds", "Retry failed deliveries"),
+				CreatedAt: day(-8), UpdatedAt: day(0)},
+			{ID: 10, Title: "Load test the search endpoint", Tags: []string{"backend", "ops"}, Priority: model.PriorityMedium,
+				CreatedAt: day(-25), UpdatedAt: day(-18)},
+		}},
+		{Name: "Review", Status: "review", Tasks: []model.Task{
+			{ID: 11, Title: "Onboarding emails", Tags: []string{"backend", "docs"}, Priority: model.PriorityMedium, Due: due(3),
+				CreatedAt: day(-7), UpdatedAt: day(-1)},
+			{ID: 12, Title: "Accessibility audit fixes", Tags: []string{"frontend"}, Priority: model.PriorityHigh,
+				Subtasks:  steps(4, "Contrast", "Focus outlines", "Alt texts", "Form labels"),
+				CreatedAt: day(-14), UpdatedAt: day(-2)},
+		}},
+		{Name: "Done", Status: model.StatusDone, Done: true, Tasks: []model.Task{
+			{ID: 14, Title: "Pick the new logo", Tags: []string{"design"},
+				CreatedAt: day(-20), UpdatedAt: day(-4), CompletedAt: done(-4)},
+			{ID: 15, Title: "Set up staging", Tags: []string{"ops"}, Priority: model.PriorityMedium,
+				CreatedAt: day(-18), UpdatedAt: day(-9), CompletedAt: done(-9)},
+			{ID: 16, Title: "Password reset flow", Tags: []string{"backend"}, Priority: model.PriorityHigh,
+				CreatedAt: day(-16), UpdatedAt: day(-1), CompletedAt: done(-1)},
+		}},
+	}
+}
@@ -0,0 +1,383 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// blockedMarker marks the cards of tasks waiting on an open blocker
+const blockedMarker = "⛔"
+
+// dependency is a line of the dependency view: a task blocking the
+// selected one, or one the selected task blocks
+type dependency struct {
+	id      int64
+	task    *model.Task // nil when the task isn't on the board (archived or in the trash)
+	column  string
+	blocker bool // the task blocks the selected one, rather than the other way round
+}
+
+// boardTask returns the task with the given ID and the name of its column,
+// or nil when it isn't on the board
+func (m *Model) boardTask(id int64) (*model.Task, string) {
+	if p, ok := m.cache.place(id, m.columns); ok && p.column < len(m.columns) && p.index < len(m.columns[p.column].Tasks) {
+		if task := &m.columns[p.column].Tasks[p.index]; task.ID == id {
+			return task, m.columns[p.column].Name
+		}
+	}
+	// Not where it was: the tasks were reordered since
+	for c := range m.columns {
+		for i := range m.columns[c].Tasks {
+			if m.columns[c].Tasks[i].ID == id {
+				return &m.columns[c].Tasks[i], m.columns[c].Name
+			}
+		}
+	}
+	return nil, ""
+}
+
+// openBlockers returns the tasks blocking task that are still to do: on the
+// board and outside the done column. Archived and trashed blockers don't
+// block.
+func (m *Model) openBlockers(task model.Task) []model.Task {
+	var open []model.Task
+	for _, id := range task.BlockedBy {
+		if blocker, _ := m.boardTask(id); blocker != nil && !m.isDoneStatus(blocker.Status) {
+			open = append(open, *blocker)
+		}
+	}
+	return open
+}
+
+// dependencies lists the tasks blocking a task, then the ones it blocks
+func (m Model) dependencies(task model.Task) []dependency {
+	var deps []dependency
+	for _, id := range task.BlockedBy {
+		blocker, column := m.boardTask(id)
+		deps = append(deps, dependency{id: id, task: blocker, column: column, blocker: true})
+	}
+	for c := range m.columns {
+		for i, other := range m.columns[c].Tasks {
+			for _, id := range other.BlockedBy {
+				if id == task.ID {
+					deps = append(deps, dependency{id: other.ID, task: &m.columns[c].Tasks[i], column: m.columns[c].Name})
+				}
+			}
+		}
+	}
+	return deps
+}
+
+// isDoingColumn reports whether a column holds tasks being worked on:
+// any column but the first and the done one
+func (m Model) isDoingColumn(index int) bool {
+	return index > 0 && index < len(m.columns) && !m.isDoneStatus(m.columns[index].Status)
+}
+
+// blockedNotice describes what a task waits on, e.g. `blocked by #3 "Design"`
+func blockedNotice(blockers []model.Task) string {
+	if len(blockers) == 1 {
+		return fmt.Sprintf("blocked by #%d %q", blockers[0].ID, truncateText(blockers[0].Title, 30))
+	}
+	ids := make([]string, len(blockers))
+	for i, b := range blockers {
+		ids[i] = fmt.Sprintf("#%d", b.ID)
+	}
+	return "blocked by " + strings.Join(ids, ", ")
+}
+
+// flashUnblocked flashes the cards of the tasks a change unblocked: tasks
+// whose last open blocker just moved into the done column. It returns a
+// notice naming them, or "".
+func (m *Model) flashUnblocked(op operation) string {
+	completed := m.completedTasks(op)
+	if len(completed) == 0 {
+		return ""
+	}
+
+	isCompleted := func(id int64) bool {
+		for _, c := range completed {
+			if c.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+	var unblocked []model.Task
+	for _, col := range m.columns {
+		for _, task := range col.Tasks {
+			open := m.openBlockers(task)
+			if len(open) == 0 || m.isDoneStatus(task.Status) {
+				continue
+			}
+			waiting := false
+			for _, b := range open {
+				if !isCompleted(b.ID) {
+					waiting = true
+				}
+			}
+			if !waiting {
+				unblocked = append(unblocked, task)
+			}
+		}
+	}
+	if len(unblocked) == 0 {
+		return ""
+	}
+
+	m.flashed = make(map[int64]bool, len(unblocked))
+	for _, task := range unblocked {
+		m.flashed[task.ID] = true
+	}
+	m.flashedAt = m.currentTime
+	if len(unblocked) == 1 {
+		return fmt.Sprintf("unblocked #%d %q", unblocked[0].ID, truncateText(unblocked[0].Title, 30))
+	}
+	return fmt.Sprintf("unblocked %d tasks", len(unblocked))
+}
+
+// isFlashed reports whether a task's card is flashing because it was just
+// unblocked
+func (m Model) isFlashed(id int64) bool {
+	return m.flashed[id] && m.currentTime.Sub(m.flashedAt) < noticeDuration
+}
+
+// openDependencies opens the dependency view of the selected task
+func (m *Model) openDependencies() {
+	if m.getCurrentTask() == nil {
+		return
+	}
+	m.dependencyReturn = m.viewMode
+	m.viewMode = ViewModeDependencies
+	m.dependencyCursor = 0
+	m.dependencyInput.SetValue("")
+	m.dependencyInput.Focus()
+	m.err = nil
+}
+
+// closeDependencies goes back to where the dependency view was opened
+func (m *Model) closeDependencies() {
+	m.viewMode = m.dependencyReturn
+	m.dependencyInput.SetValue("")
+	m.refreshDetail()
+}
+
+// addBlocker records that a task is blocked by another one
+func (m Model) addBlocker(task *model.Task, blockerID int64) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.AddDependency(task.ID, blockerID)
+	})
+}
+
+// removeBlocker drops a task's dependency on a blocker
+func (m Model) removeBlocker(task *model.Task, blockerID int64) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.RemoveDependency(task.ID, blockerID)
+	})
+}
+
+// handleDependencyKeys handles keyboard input in the dependency view.
+// Typing a task ID and Enter adds it as a blocker; otherwise Enter goes to
+// the selected task and d removes the selected dependency.
+func (m Model) handleDependencyKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	task := m.getCurrentTask()
+	if task == nil {
+		m.closeDependencies()
+		return m, nil
+	}
+	deps := m.dependencies(*task)
+	typed := strings.TrimPrefix(strings.TrimSpace(m.dependencyInput.Value()), "#")
+
+	switch {
+	case msg.String() == "esc" || key.Matches(msg, m.keys.Dependencies):
+		m.closeDependencies()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if m.dependencyCursor > 0 {
+			m.dependencyCursor--
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		if m.dependencyCursor < len(deps)-1 {
+			m.dependencyCursor++
+		}
+		return m, nil
+
+	case msg.String() == "enter" && typed != "":
+		id, err := strconv.ParseInt(typed, 10, 64)
+		if err != nil || id <= 0 {
+			m.err = fmt.Errorf("%q is not a task ID", typed)
+			return m, nil
+		}
+		if m.refuseReadOnly() {
+			return m, nil
+		}
+		m.dependencyInput.SetValue("")
+		m.err = nil
+		m.followTaskID = task.ID
+		return m, m.addBlocker(task, id)
+
+	case msg.String() == "enter":
+		if m.dependencyCursor >= len(deps) {
+			return m, nil
+		}
+		dep := deps[m.dependencyCursor]
+		if dep.task == nil {
+			m.err = fmt.Errorf("task #%d is archived or in the trash", dep.id)
+			return m, nil
+		}
+		back := m.dependencyReturn
+		m.closeDependencies()
+		m.viewMode = ViewModeBoard
+		m.goToTask(dep.id)
+		if back == ViewModeDetail {
+			return m, m.openDetail()
+		}
+		return m, nil
+
+	case typed == "" && key.Matches(msg, m.keys.DeleteSubtask):
+		if m.dependencyCursor >= len(deps) || m.refuseReadOnly() {
+			return m, nil
+		}
+		dep := deps[m.dependencyCursor]
+		m.dependencyCursor = max(0, min(m.dependencyCursor, len(deps)-2))
+		m.followTaskID = task.ID
+		if dep.blocker {
+			return m, m.removeBlocker(task, dep.id)
+		}
+		return m, m.removeBlocker(dep.task, task.ID)
+	}
+
+	// Only task IDs go into the input
+	if msg.Type == tea.KeyRunes {
+		for _, r := range msg.Runes {
+			if (r < '0' || r > '9') && r != '#' {
+				return m, nil
+			}
+		}
+	}
+	var cmd tea.Cmd
+	m.dependencyInput, cmd = m.dependencyInput.Update(msg)
+	return m, cmd
+}
+
+// renderDependencies renders the detail view's lists of the tasks blocking
+// the task and the ones it blocks, or "" when it has none
+func (m Model) renderDependencies(task model.Task, width int) string {
+	deps := m.dependencies(task)
+	if len(deps) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	heading := lipgloss.NewStyle().Bold(true).Foreground(colorSecondary)
+	for _, blockers := range []bool{true, false} {
+		title := "Blocking"
+		if blockers {
+			title = "Blocked by"
+		}
+		wrote := false
+		for _, dep := range deps {
+			if dep.blocker != blockers {
+				continue
+			}
+			if !wrote {
+				b.WriteString(heading.Render(title))
+				b.WriteString("\n")
+				wrote = true
+			}
+			b.WriteString("  ")
+			b.WriteString(m.dependencyLine(dep, width-2))
+			b.WriteString("\n")
+		}
+		if wrote {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// dependencyLine renders a dependency as its ID, title and column, dimmed
+// once it no longer holds anything up
+func (m Model) dependencyLine(dep dependency, width int) string {
+	if dep.task == nil {
+		return lipgloss.NewStyle().Foreground(colorMuted).Render(fmt.Sprintf("#%d (archived or in the trash)", dep.id))
+	}
+	done := m.isDoneStatus(dep.task.Status)
+	mark := blockedMarker + " "
+	if done {
+		mark = "✓ "
+	} else if !dep.blocker {
+		mark = "→ "
+	}
+	column := " (" + dep.column + ")"
+	line := mark + fmt.Sprintf("#%d ", dep.id) + truncateText(dep.task.Title, max(width-lipgloss.Width(mark+column)-8, 10)) + column
+	if done {
+		return lipgloss.NewStyle().Foreground(colorMuted).Render(line)
+	}
+	return lipgloss.NewStyle().Foreground(colorText).Render(line)
+}
+
+// viewDependencies renders the dependency view of the selected task
+func (m Model) viewDependencies() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(blockedMarker + " Dependencies"))
+	b.WriteString("\n\n")
+
+	task := m.getCurrentTask()
+	if task == nil {
+		return b.String()
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(fmt.Sprintf("Task: #%d %s", task.ID, task.Title)))
+	b.WriteString("\n\n")
+
+	width := max(m.width-4, 30)
+	deps := m.dependencies(*task)
+	if len(deps) == 0 {
+		b.WriteString(helpStyle.Render("No dependencies yet: type the ID of a task this one waits on"))
+		b.WriteString("\n")
+	}
+	cursor := min(m.dependencyCursor, len(deps)-1)
+	for i, dep := range deps {
+		if i == 0 || dep.blocker != deps[i-1].blocker {
+			title := "Blocking"
+			if dep.blocker {
+				title = "Blocked by"
+			}
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(lipgloss.NewStyle().Bold(true).Render(title))
+			b.WriteString("\n")
+		}
+		if i == cursor {
+			b.WriteString(lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render("▸ "))
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(m.dependencyLine(dep, width))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render("Add a task this one is blocked by, e.g. 42"))
+	b.WriteString("\n")
+	b.WriteString(inputStyle.Render(m.dependencyInput.View()))
+	b.WriteString("\n\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(helpStyle.Render("Type an ID + Enter: Add blocker | ↑/↓: Pick | Enter: Go to task | d: Remove | Esc: Back"))
+	return b.String()
+}
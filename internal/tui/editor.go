@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// maxEditorDescription caps the size of a description read back from the
+// editor, so a stray paste of a huge file isn't stored in the database
+const maxEditorDescription = 1 << 20
+
+// editorFinishedMsg carries the description written in the external editor
+type editorFinishedMsg struct {
+	task        model.Task // the task as it was when the editor opened
+	description string
+	err         error
+}
+
+// editorCommand returns the editor to run: $EDITOR (which may carry
+// arguments, e.g. "code --wait"), else vi, else nano
+func editorCommand() ([]string, error) {
+	if fields := strings.Fields(os.Getenv("EDITOR")); len(fields) > 0 {
+		return fields, nil
+	}
+	for _, name := range []string{"vi", "nano"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return []string{path}, nil
+		}
+	}
+	return nil, errors.New("no editor found: set $EDITOR")
+}
+
+// editInEditor suspends the TUI and opens text as the description of task in
+// the external editor. Changes are discarded when the editor exits with an error.
+func (m Model) editInEditor(task model.Task, text string) tea.Cmd {
+	editor, err := editorCommand()
+	if err != nil {
+		return func() tea.Msg { return errMsg{err} }
+	}
+	f, err := os.CreateTemp("", "cli_kanban-*.md")
+	if err != nil {
+		return func() tea.Msg { return errMsg{fmt.Errorf("failed to create temp file: %w", err)} }
+	}
+	path := f.Name()
+	_, err = f.WriteString(text)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg { return errMsg{fmt.Errorf("failed to write temp file: %w", err)} }
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editorFinishedMsg{err: fmt.Errorf("%s failed, description unchanged: %w", editor[0], err)}
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return editorFinishedMsg{err: fmt.Errorf("failed to read edited description: %w", err)}
+		}
+		if info.Size() > maxEditorDescription {
+			return editorFinishedMsg{err: fmt.Errorf("description is too large (%d bytes, at most %d), unchanged", info.Size(), maxEditorDescription)}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return editorFinishedMsg{err: fmt.Errorf("failed to read edited description: %w", err)}
+		}
+		// Editors end the file with a newline the description didn't have
+		description := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		return editorFinishedMsg{task: task, description: description}
+	})
+}
+
+// finishEditor saves the description written in the external editor
+func (m Model) finishEditor(msg editorFinishedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		return m, nil
+	}
+	if msg.description == msg.task.Description {
+		return m, nil
+	}
+	return m, m.updateDescription(&msg.task, msg.description)
+}
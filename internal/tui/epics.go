@@ -0,0 +1,421 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// epicMarker marks the progress line on the card of an epic
+const epicMarker = "◆"
+
+// epicProgress counts the children of an epic on the board, across all columns
+type epicProgress struct {
+	done  int
+	total int
+}
+
+// countEpics tallies the children of every epic on the board
+func (m *Model) countEpics() {
+	m.epics = map[int64]epicProgress{}
+	for _, col := range m.columns {
+		for _, task := range col.Tasks {
+			if task.Parent == 0 {
+				continue
+			}
+			p := m.epics[task.Parent]
+			p.total++
+			if m.isDoneStatus(task.Status) {
+				p.done++
+			}
+			m.epics[task.Parent] = p
+		}
+	}
+}
+
+// children returns the tasks on the board under an epic, in board order
+func (m Model) children(id int64) []model.Task {
+	var children []model.Task
+	for _, col := range m.columns {
+		for _, task := range col.Tasks {
+			if task.Parent == id {
+				children = append(children, task)
+			}
+		}
+	}
+	return children
+}
+
+// epicEntry is a line of the epic view: the epic the selected task belongs
+// to, or one of its children
+type epicEntry struct {
+	id     int64
+	task   *model.Task // nil when the task isn't on the board (archived or in the trash)
+	column string
+	parent bool // the selected task's epic, rather than a child of it
+}
+
+// epicEntries lists the epic of a task, then its children
+func (m Model) epicEntries(task model.Task) []epicEntry {
+	var entries []epicEntry
+	if task.Parent != 0 {
+		parent, column := m.boardTask(task.Parent)
+		entries = append(entries, epicEntry{id: task.Parent, task: parent, column: column, parent: true})
+	}
+	for c := range m.columns {
+		for i, other := range m.columns[c].Tasks {
+			if other.Parent == task.ID {
+				entries = append(entries, epicEntry{id: other.ID, task: &m.columns[c].Tasks[i], column: m.columns[c].Name})
+			}
+		}
+	}
+	return entries
+}
+
+// completedTasks returns the tasks a change moved into the done column
+func (m Model) completedTasks(op operation) []model.Task {
+	var completed []model.Task
+	var collect func(op operation)
+	collect = func(op operation) {
+		if op.kind == opMove && op.before != nil && op.after != nil &&
+			m.isDoneStatus(op.after.Status) && !m.isDoneStatus(op.before.Status) {
+			completed = append(completed, *op.after)
+		}
+		for _, part := range op.parts {
+			collect(part)
+		}
+	}
+	collect(op)
+	return completed
+}
+
+// finishedEpic returns the epic whose last open child a change just moved
+// into the done column, or nil. The board isn't reloaded yet, so the
+// completed children are still where they were.
+func (m Model) finishedEpic(op operation) *model.Task {
+	completed := m.completedTasks(op)
+	isCompleted := func(id int64) bool {
+		for _, task := range completed {
+			if task.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+	for _, child := range completed {
+		epic, _ := m.boardTask(child.Parent)
+		if child.Parent == 0 || epic == nil || m.isDoneStatus(epic.Status) || isCompleted(epic.ID) {
+			continue
+		}
+		open := false
+		for _, other := range m.children(epic.ID) {
+			if !m.isDoneStatus(other.Status) && !isCompleted(other.ID) {
+				open = true
+			}
+		}
+		if !open {
+			return epic
+		}
+	}
+	return nil
+}
+
+// askCompleteEpic asks whether to complete the epic a change finished off.
+// Outside the board it only says so in notice.
+func (m *Model) askCompleteEpic(op operation, notice *string) {
+	epic := m.finishedEpic(op)
+	if epic == nil {
+		return
+	}
+	if m.viewMode != ViewModeBoard || m.readOnly() {
+		if *notice != "" {
+			*notice += ", "
+		}
+		*notice += fmt.Sprintf("all children of #%d are done", epic.ID)
+		return
+	}
+	m.pendingEpicID = epic.ID
+	m.viewMode = ViewModeConfirmEpic
+}
+
+// handleConfirmEpicKeys handles the prompt to complete an epic once its
+// last child is done
+func (m Model) handleConfirmEpicKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.viewMode = ViewModeBoard
+		epic, _ := m.boardTask(m.pendingEpicID)
+		m.pendingEpicID = 0
+		if epic == nil || m.isDoneStatus(epic.Status) {
+			return m, nil
+		}
+		return m, m.moveTask(epic, m.doneColumn())
+
+	case "n", "N", "esc":
+		m.pendingEpicID = 0
+		m.viewMode = ViewModeBoard
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// deleteEpic moves an epic to the trash with its children, or with children
+// false keeps them on the board outside any epic. Undo puts the epic and
+// every child back as they were.
+func (m Model) deleteEpic(epic *model.Task, children bool) tea.Cmd {
+	tasks := append([]model.Task{*epic}, m.children(epic.ID)...)
+	n := pluralize(len(tasks)-1, "child", "children")
+	notice := fmt.Sprintf("deleted %q and its %s", truncateText(epic.Title, 30), n)
+	if !children {
+		notice = fmt.Sprintf("deleted %q, kept its %s", truncateText(epic.Title, 30), n)
+	}
+	return m.applyTo(tasks, notice, func([]int64) ([]model.Task, error) {
+		return nil, m.db.DeleteEpic(epic.ID, children)
+	})
+}
+
+// setParent puts a task under an epic, or takes it out of its epic for 0
+func (m Model) setParent(task *model.Task, parent int64) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.SetTaskParent(task.ID, parent)
+	})
+}
+
+// openEpic opens the epic view of the selected task
+func (m *Model) openEpic() {
+	if m.getCurrentTask() == nil {
+		return
+	}
+	m.epicReturn = m.viewMode
+	m.viewMode = ViewModeEpic
+	m.epicCursor = 0
+	m.epicInput.SetValue("")
+	m.epicInput.Focus()
+	m.err = nil
+}
+
+// closeEpic goes back to where the epic view was opened
+func (m *Model) closeEpic() {
+	m.viewMode = m.epicReturn
+	m.epicInput.SetValue("")
+	m.refreshDetail()
+}
+
+// handleEpicKeys handles keyboard input in the epic view. Typing a task ID
+// and Enter makes that task a child of the selected one, ^ before the ID
+// puts the selected task under it; otherwise Enter goes to the highlighted
+// task and d takes it out of the epic.
+func (m Model) handleEpicKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	task := m.getCurrentTask()
+	if task == nil {
+		m.closeEpic()
+		return m, nil
+	}
+	entries := m.epicEntries(*task)
+	typed := strings.TrimSpace(m.epicInput.Value())
+
+	switch {
+	case msg.String() == "esc" || key.Matches(msg, m.keys.Epic):
+		m.closeEpic()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if m.epicCursor > 0 {
+			m.epicCursor--
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		if m.epicCursor < len(entries)-1 {
+			m.epicCursor++
+		}
+		return m, nil
+
+	case msg.String() == "enter" && typed != "":
+		under := strings.HasPrefix(typed, "^")
+		digits := strings.TrimPrefix(strings.TrimPrefix(typed, "^"), "#")
+		id, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil || id <= 0 {
+			m.err = fmt.Errorf("%q is not a task ID", typed)
+			return m, nil
+		}
+		if m.refuseReadOnly() {
+			return m, nil
+		}
+		m.err = nil
+		if under {
+			m.epicInput.SetValue("")
+			m.followTaskID = task.ID
+			return m, m.setParent(task, id)
+		}
+		child, _ := m.boardTask(id)
+		if child == nil {
+			m.err = fmt.Errorf("task #%d isn't on the board", id)
+			return m, nil
+		}
+		m.epicInput.SetValue("")
+		m.followTaskID = task.ID
+		return m, m.setParent(child, task.ID)
+
+	case msg.String() == "enter":
+		if m.epicCursor >= len(entries) {
+			return m, nil
+		}
+		entry := entries[m.epicCursor]
+		if entry.task == nil {
+			m.err = fmt.Errorf("task #%d is archived or in the trash", entry.id)
+			return m, nil
+		}
+		back := m.epicReturn
+		m.closeEpic()
+		m.viewMode = ViewModeBoard
+		m.goToTask(entry.id)
+		if back == ViewModeDetail {
+			return m, m.openDetail()
+		}
+		return m, nil
+
+	case typed == "" && key.Matches(msg, m.keys.DeleteSubtask):
+		if m.epicCursor >= len(entries) || m.refuseReadOnly() {
+			return m, nil
+		}
+		entry := entries[m.epicCursor]
+		m.epicCursor = max(0, min(m.epicCursor, len(entries)-2))
+		m.followTaskID = task.ID
+		if entry.parent {
+			return m, m.setParent(task, 0)
+		}
+		return m, m.setParent(entry.task, 0)
+	}
+
+	// Only task IDs go into the input
+	if msg.Type == tea.KeyRunes {
+		for _, r := range msg.Runes {
+			if (r < '0' || r > '9') && r != '#' && r != '^' {
+				return m, nil
+			}
+		}
+	}
+	var cmd tea.Cmd
+	m.epicInput, cmd = m.epicInput.Update(msg)
+	return m, cmd
+}
+
+// epicProgressText describes an epic's progress, e.g. "5/9 done"
+func epicProgressText(p epicProgress) string {
+	return fmt.Sprintf("%d/%d done", p.done, p.total)
+}
+
+// renderEpic renders the detail view's epic of the task and its children,
+// or "" when it has neither
+func (m Model) renderEpic(task model.Task, width int) string {
+	entries := m.epicEntries(task)
+	if len(entries) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	heading := lipgloss.NewStyle().Bold(true).Foreground(colorSecondary)
+	for i, entry := range entries {
+		if i == 0 || entry.parent != entries[i-1].parent {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			title := "Epic"
+			if !entry.parent {
+				title = "Children " + epicProgressText(m.epics[task.ID])
+			}
+			b.WriteString(heading.Render(title))
+			b.WriteString("\n")
+		}
+		b.WriteString("  ")
+		b.WriteString(m.epicLine(entry, width-2))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// epicLine renders an epic or child as its ID, title and column, dimmed
+// once it is done
+func (m Model) epicLine(entry epicEntry, width int) string {
+	if entry.task == nil {
+		return lipgloss.NewStyle().Foreground(colorMuted).Render(fmt.Sprintf("#%d (archived or in the trash)", entry.id))
+	}
+	done := m.isDoneStatus(entry.task.Status)
+	mark := "· "
+	if entry.parent {
+		mark = epicMarker + " "
+	}
+	if done {
+		mark = "✓ "
+	}
+	column := " (" + entry.column + ")"
+	line := mark + fmt.Sprintf("#%d ", entry.id) + truncateText(entry.task.Title, max(width-lipgloss.Width(mark+column)-8, 10)) + column
+	if done {
+		return lipgloss.NewStyle().Foreground(colorMuted).Render(line)
+	}
+	return lipgloss.NewStyle().Foreground(colorText).Render(line)
+}
+
+// viewEpic renders the epic view of the selected task
+func (m Model) viewEpic() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(epicMarker + " Epic"))
+	b.WriteString("\n\n")
+
+	task := m.getCurrentTask()
+	if task == nil {
+		return b.String()
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(fmt.Sprintf("Task: #%d %s", task.ID, task.Title)))
+	b.WriteString("\n\n")
+
+	width := max(m.width-4, 30)
+	entries := m.epicEntries(*task)
+	if len(entries) == 0 {
+		b.WriteString(helpStyle.Render("No epic or children yet: type the ID of a task that is part of this one"))
+		b.WriteString("\n")
+	}
+	cursor := min(m.epicCursor, len(entries)-1)
+	for i, entry := range entries {
+		if i == 0 || entry.parent != entries[i-1].parent {
+			title := "Part of"
+			if !entry.parent {
+				title = "Children " + epicProgressText(m.epics[task.ID])
+			}
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(lipgloss.NewStyle().Bold(true).Render(title))
+			b.WriteString("\n")
+		}
+		if i == cursor {
+			b.WriteString(lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render("▸ "))
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(m.epicLine(entry, width))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render("Add a child task, e.g. 42, or put this task under an epic with ^42"))
+	b.WriteString("\n")
+	b.WriteString(inputStyle.Render(m.epicInput.View()))
+	b.WriteString("\n\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(helpStyle.Render("Type an ID + Enter: Add child | ^ID: Set epic | ↑/↓: Pick | Enter: Go to task | d: Detach | Esc: Back"))
+	return b.String()
+}
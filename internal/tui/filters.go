@@ -0,0 +1,231 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// Filters offered by the filter picker (F), by the name typed to pick them
+const (
+	filterStale  = "stale"
+	filterUrgent = "urgent"
+	filterPinned = "pinned"
+)
+
+// boardFilters lists the filter picker's filters in order
+var boardFilters = []string{filterStale, filterUrgent, filterPinned}
+
+// staleBefore returns the time before which untouched tasks are stale, or
+// the zero time when staleness is turned off
+func (m Model) staleBefore() time.Time {
+	before, _ := db.StaleBefore(m.staleAfter, m.currentTime)
+	return before
+}
+
+// isStale reports whether a task outside the done column has gone untouched
+// for longer than the configured age
+func (m *Model) isStale(task model.Task) bool {
+	return db.IsStale(task, m.isDoneStatus(task.Status), m.staleBefore())
+}
+
+// staleAge formats how long a stale task has been untouched, e.g. 21d
+func (m *Model) staleAge(task model.Task) string {
+	return fmt.Sprintf("%dd", int(m.currentTime.Sub(task.UpdatedAt).Hours()/24))
+}
+
+// filterActive reports whether the named filter narrows the board
+func (m Model) filterActive(name string) bool {
+	switch name {
+	case filterStale:
+		return m.staleOnly
+	case filterUrgent:
+		return m.urgentOnly
+	case filterPinned:
+		return m.pinnedOnly
+	}
+	return false
+}
+
+// openFilterPicker opens the filter picker on its first filter
+func (m *Model) openFilterPicker() {
+	m.viewMode = ViewModeFilter
+	m.filterCursor = 0
+	m.filterInput.SetValue("")
+	m.filterInput.Focus()
+	m.err = nil
+}
+
+// handleFilterKeys handles keyboard input in the filter picker: up and down
+// pick a filter, typing picks the first whose name starts with the input,
+// and Enter turns the picked filter on or off
+func (m Model) handleFilterKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "down":
+		if msg.String() == "up" {
+			m.filterCursor--
+		} else {
+			m.filterCursor++
+		}
+		m.filterCursor = (m.filterCursor + len(boardFilters)) % len(boardFilters)
+		m.filterInput.SetValue(boardFilters[m.filterCursor])
+		m.filterInput.CursorEnd()
+		return m, nil
+
+	case "enter":
+		if m.filterCursor < 0 {
+			m.err = fmt.Errorf("unknown filter %q: use %s", strings.TrimSpace(m.filterInput.Value()), strings.Join(boardFilters, ", "))
+			return m, nil
+		}
+		switch boardFilters[m.filterCursor] {
+		case filterStale:
+			if m.staleBefore().IsZero() {
+				m.err = fmt.Errorf("no task is stale with stale.after set to never")
+				return m, nil
+			}
+			m.staleOnly = !m.staleOnly
+		case filterUrgent:
+			m.urgentOnly = !m.urgentOnly
+		case filterPinned:
+			m.pinnedOnly = !m.pinnedOnly
+		}
+		m.viewMode = ViewModeBoard
+		m.filterInput.SetValue("")
+		m.err = nil
+		m.currentTask = 0
+		m.ensureTaskVisible()
+		return m, nil
+
+	case "esc":
+		m.viewMode = ViewModeBoard
+		m.filterInput.SetValue("")
+		m.err = nil
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	m.filterCursor = -1
+	input := strings.ToLower(strings.TrimSpace(m.filterInput.Value()))
+	for i, name := range boardFilters {
+		if strings.HasPrefix(name, input) {
+			m.filterCursor = i
+			break
+		}
+	}
+	return m, cmd
+}
+
+// viewFilter renders the filter picker
+func (m Model) viewFilter() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("⏷ Filter Board"))
+	b.WriteString("\n\n")
+
+	stale := "Only stale tasks"
+	if after := m.staleAfter; after != "never" {
+		if after == "" {
+			after = db.DefaultStaleAfter
+		}
+		stale += ", untouched for over " + after
+	}
+	labels := map[string]string{
+		filterStale:  stale,
+		filterUrgent: "Only high and urgent tasks",
+		filterPinned: "Only pinned tasks, in every column",
+	}
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	normalStyle := lipgloss.NewStyle().Foreground(colorText)
+	for i, name := range boardFilters {
+		check := "[ ]"
+		if m.filterActive(name) {
+			check = "[✓]"
+		}
+		line := fmt.Sprintf("%s %-7s %s", check, name, labels[name])
+		if i == m.filterCursor {
+			b.WriteString(selectedStyle.Render("▸ " + line))
+		} else {
+			b.WriteString(normalStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString(inputStyle.Render(m.filterInput.View()))
+	b.WriteString("\n\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓ or type: Pick | Enter: Turn on/off | Esc: Cancel"))
+
+	return b.String()
+}
+
+// filterByTaskTag filters the board by a tag of the selected task (#): by
+// its only tag right away, or by the one picked among its tags. With a tag
+// filter on, it clears it instead, like L.
+func (m Model) filterByTaskTag() (tea.Model, tea.Cmd) {
+	if m.labelFilter != "" {
+		m.labelFilter = ""
+		m.quickLabel = ""
+		m.ensureTaskVisible()
+		return m, nil
+	}
+	task := m.getCurrentTask()
+	if task == nil {
+		return m, nil
+	}
+	switch len(task.Tags) {
+	case 0:
+		m.showNotice(fmt.Sprintf("#%d has no tags to filter by", task.ID))
+		return m, nil
+	case 1:
+		m.setQuickLabel(task.Tags[0], task.ID)
+		return m, nil
+	}
+	m.viewMode = ViewModeFilterLabel
+	m.labelTask = task.ID
+	m.labelSelected = nil
+	m.labelOptions = append([]string{}, task.Tags...)
+	m.labelCursor = 0
+	m.labelInput.SetValue("")
+	m.labelInput.Focus()
+	return m, nil
+}
+
+// setQuickLabel filters the board by a tag of the task with the given ID,
+// keeping that task selected. The other filters stay on, so a search
+// narrows the tagged tasks further.
+func (m *Model) setQuickLabel(tag string, id int64) {
+	m.labelFilter = tag
+	m.quickLabel = tag
+	m.currentTask = 0
+	if !m.selectTask(id) {
+		m.ensureTaskVisible()
+	}
+}
+
+// labelPickerTask returns the task whose tags the tag filter picker
+// offers, or nil when it offers every known tag
+func (m Model) labelPickerTask() *model.Task {
+	if m.labelTask == 0 {
+		return nil
+	}
+	for _, col := range m.columns {
+		for i := range col.Tasks {
+			if col.Tasks[i].ID == m.labelTask {
+				return &col.Tasks[i]
+			}
+		}
+	}
+	return nil
+}
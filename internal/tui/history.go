@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// maxHistory caps the number of operations kept for undo
+const maxHistory = 50
+
+// opKind identifies the kind of task change recorded for undo
+type opKind int
+
+const (
+	opCreate opKind = iota
+	opDelete
+	opMove
+	opEdit
+	opReorder
+	opArchive
+	opBulk
+)
+
+// operation is a task change that can be undone and redone. before and after
+// are full snapshots of the task (tags and checklist included) around the
+// change; before is nil for a created task and after is nil for a deleted
+// one. A reorder is reverted by swapping the task with otherID again.
+// repeat is the next occurrence created by completing a recurring task; it
+// is deleted again when the move is undone. A bulk operation changes several
+// tasks at once and holds one part per task, undone and redone together.
+type operation struct {
+	kind    opKind
+	before  *model.Task
+	after   *model.Task
+	otherID int64
+	repeat  *model.Task
+	parts   []operation
+}
+
+// taskID returns the ID of the task the operation changed
+func (op operation) taskID() int64 {
+	if op.after != nil {
+		return op.after.ID
+	}
+	return op.before.ID
+}
+
+// history holds the undo and redo stacks of the session
+type history struct {
+	undo []operation
+	redo []operation
+}
+
+// record pushes a new operation, dropping the oldest past maxHistory.
+// Anything that was undone can no longer be redone.
+func (h *history) record(op operation) {
+	h.undo = append(h.undo, op)
+	if len(h.undo) > maxHistory {
+		h.undo = append([]operation(nil), h.undo[len(h.undo)-maxHistory:]...)
+	}
+	h.redo = nil
+}
+
+// taskChangedMsg reports a task change that was stored and can be undone.
+// follow selects the task once the board is reloaded.
+type taskChangedMsg struct {
+	op     operation
+	follow bool
+}
+
+// historyAppliedMsg reports an operation that was undone or redone
+type historyAppliedMsg struct {
+	op   operation
+	undo bool
+}
+
+// snapshot copies a task so later board reloads don't affect it
+func snapshot(task *model.Task) *model.Task {
+	if task == nil {
+		return nil
+	}
+	t := *task
+	t.Tags = append([]string{}, task.Tags...)
+	t.Subtasks = append([]model.Subtask{}, task.Subtasks...)
+	return &t
+}
+
+// recordChange runs apply and records the change to the task for undo. The
+// task is read back afterwards to get its new state, unless it was deleted.
+func (m Model) recordChange(kind opKind, task *model.Task, apply func() error) tea.Cmd {
+	before := snapshot(task)
+	return func() tea.Msg {
+		if err := apply(); err != nil {
+			return errMsg{err}
+		}
+		op := operation{kind: kind, before: before}
+		if kind != opDelete {
+			after, err := m.db.GetTask(before.ID)
+			if err != nil {
+				return errMsg{err}
+			}
+			op.after = after
+		}
+		return taskChangedMsg{op: op}
+	}
+}
+
+// applyHistory reverts (undo) or re-applies an operation by writing back the
+// matching task snapshot. The task and the occurrence its move repeated are
+// written in one transaction, so a failure leaves both as they were.
+func (m Model) applyHistory(op operation, undo bool) tea.Cmd {
+	return func() tea.Msg {
+		target := op.after
+		if undo {
+			target = op.before
+		}
+
+		err := m.db.WithTx(func(tx *db.Tx) error {
+			var err error
+			switch {
+			case op.kind == opBulk:
+				err = tx.RestoreTasks(op.bulkTargets(undo, time.Now()))
+			case op.kind == opReorder:
+				err = tx.SwapTaskPositions(op.taskID(), op.otherID)
+			case target == nil:
+				err = tx.DeleteTask(op.taskID())
+			default:
+				err = tx.RestoreTasks([]model.Task{*target})
+			}
+			if err != nil || op.repeat == nil {
+				return err
+			}
+			if undo {
+				return tx.DeleteTask(op.repeat.ID)
+			}
+			return tx.RestoreTasks([]model.Task{*op.repeat})
+		})
+		if err != nil {
+			return errMsg{err}
+		}
+		return historyAppliedMsg{op, undo}
+	}
+}
+
+// undo reverts the most recent operation. Requests are applied one at a
+// time so repeated keypresses restore snapshots in order.
+func (m Model) undo() (tea.Model, tea.Cmd) {
+	if m.historyBusy {
+		return m, nil
+	}
+	if len(m.history.undo) == 0 {
+		m.err = fmt.Errorf("nothing to undo")
+		return m, nil
+	}
+	op := m.history.undo[len(m.history.undo)-1]
+	m.history.undo = m.history.undo[:len(m.history.undo)-1]
+	m.err = nil
+	m.historyBusy = true
+	return m, m.applyHistory(op, true)
+}
+
+// redo re-applies the most recently undone operation
+func (m Model) redo() (tea.Model, tea.Cmd) {
+	if m.historyBusy {
+		return m, nil
+	}
+	if len(m.history.redo) == 0 {
+		m.err = fmt.Errorf("nothing to redo")
+		return m, nil
+	}
+	op := m.history.redo[len(m.history.redo)-1]
+	m.history.redo = m.history.redo[:len(m.history.redo)-1]
+	m.err = nil
+	m.historyBusy = true
+	return m, m.applyHistory(op, false)
+}
+
+// followOperation selects the task an undone or redone operation touched
+func (m *Model) followOperation(op operation, undo bool) {
+	target := op.after
+	if undo {
+		target = op.before
+	}
+	if target != nil {
+		m.followColumn = target.Status
+		m.followTaskID = target.ID
+	}
+}
@@ -0,0 +1,266 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap holds the key bindings of the board and the task detail view. Key
+// handling, the help overlay and the footer hints are all driven by it.
+type keyMap struct {
+	// Navigation
+	Left     key.Binding
+	Right    key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	Column   key.Binding
+	GoTo     key.Binding
+
+	// Vim layer, on unless the config turns it off; gg repeats GoTo's key
+	GoTop key.Binding
+
+	// Task actions
+	Add          key.Binding
+	Details      key.Binding
+	Edit         key.Binding
+	Description  key.Binding
+	Editor       key.Binding
+	Tags         key.Binding
+	Due          key.Binding
+	Repeat       key.Binding
+	Timer        key.Binding
+	Pomodoro     key.Binding
+	Priority     key.Binding
+	Pin          key.Binding
+	Delete       key.Binding
+	Move         key.Binding
+	MoveToWS     key.Binding
+	MoveTaskUp   key.Binding
+	MoveTaskDown key.Binding
+	Undo         key.Binding
+	Redo         key.Binding
+	Duplicate    key.Binding
+	SaveTemplate key.Binding
+	Select       key.Binding
+	Copy         key.Binding
+	CopyMarkdown key.Binding
+	Paste        key.Binding
+	EditLink     key.Binding
+	OpenLink     key.Binding
+	Dependencies key.Binding
+	Epic         key.Binding
+
+	// Task details
+	AddSubtask    key.Binding
+	ToggleSubtask key.Binding
+	DeleteSubtask key.Binding
+	RawMarkdown   key.Binding
+	Back          key.Binding
+
+	// Board
+	Search       key.Binding
+	UrgentOnly   key.Binding
+	FilterLabel  key.Binding
+	QuickLabel   key.Binding
+	Filter       key.Binding
+	Sort         key.Binding
+	Views        key.Binding
+	Settings     key.Binding
+	AddColumn    key.Binding
+	RenameColumn key.Binding
+	DeleteColumn key.Binding
+	ColumnLeft   key.Binding
+	ColumnRight  key.Binding
+	FocusLeft    key.Binding
+	FocusRight   key.Binding
+	Collapse     key.Binding
+	Lanes        key.Binding
+	WrapTitles   key.Binding
+	WIPLimit     key.Binding
+	Trash        key.Binding
+	Dashboard    key.Binding
+	Zoom         key.Binding
+	Palette      key.Binding
+
+	// Calendar and agenda
+	Calendar key.Binding
+	Agenda   key.Binding
+	MarkDone key.Binding
+
+	// Archive
+	Archive       key.Binding
+	ArchiveColumn key.Binding
+	ArchiveView   key.Binding
+	Unarchive     key.Binding
+
+	// Snooze
+	Snooze      key.Binding
+	SnoozedView key.Binding
+	Wake        key.Binding
+
+	// Trash view
+	RestoreTask key.Binding
+	PurgeTask   key.Binding
+
+	// Workspace
+	Workspace key.Binding
+	Refresh   key.Binding
+	Help      key.Binding
+	Quit      key.Binding
+}
+
+// defaultKeyMap returns the built-in key bindings
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Left:     key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("← / h", "Previous column")),
+		Right:    key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→ / l", "Next column")),
+		Up:       key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑ / k", "Previous task (checklist item in details)")),
+		Down:     key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓ / j", "Next task (checklist item in details)")),
+		PageUp:   key.NewBinding(key.WithKeys("pgup"), key.WithHelp("PgUp", "Page up in the column (scroll in details)")),
+		PageDown: key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("PgDn", "Page down in the column (scroll in details)")),
+		Top:      key.NewBinding(key.WithKeys("home"), key.WithHelp("Home", "First task in the column")),
+		Bottom:   key.NewBinding(key.WithKeys("end", "G"), key.WithHelp("End / G", "Last task in the column")),
+		Column:   key.NewBinding(key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"), key.WithHelp("1-9", "Jump to the Nth column; 3m moves the task to the 3rd")),
+		GoTo:     key.NewBinding(key.WithKeys("g"), key.WithHelp("g 42 Enter", "Go to task #42, wherever it is on the board")),
+
+		GoTop: key.NewBinding(key.WithKeys("g"), key.WithHelp("g g", "First task in the column")),
+
+		Add:          key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "Add task to current column")),
+		Details:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("Enter", "Open task details and checklist")),
+		Edit:         key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "Edit title")),
+		Description:  key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "Edit description (multi-line, Ctrl+S saves)")),
+		Editor:       key.NewBinding(key.WithKeys("E"), key.WithHelp("E", "Edit description in $EDITOR (Ctrl+E while editing)")),
+		Tags:         key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "Pick tags (create inline, toggle existing)")),
+		Due:          key.NewBinding(key.WithKeys("@"), key.WithHelp("@", "Edit due date")),
+		Repeat:       key.NewBinding(key.WithKeys("%"), key.WithHelp("%", "Set how the task repeats when done (daily, weekly, …)")),
+		Timer:        key.NewBinding(key.WithKeys("ctrl+t"), key.WithHelp("Ctrl+T", "Start or stop the timer on the task (one runs at a time)")),
+		Pomodoro:     key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "Start or stop pomodoro focus mode on the task")),
+		Priority:     key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "Cycle priority (none, low, medium, high, urgent)")),
+		Pin:          key.NewBinding(key.WithKeys("*"), key.WithHelp("*", "Pin or unpin the task above the others in its column")),
+		Delete:       key.NewBinding(key.WithKeys("d", "delete"), key.WithHelp("d / Delete", "Move task to the trash (asks first)")),
+		Move:         key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "Move task to next column")),
+		MoveToWS:     key.NewBinding(key.WithKeys("M"), key.WithHelp("M", "Move task to another workspace")),
+		MoveTaskUp:   key.NewBinding(key.WithKeys("K", "shift+up"), key.WithHelp("K / Shift+↑", "Move task (or checklist item) up")),
+		MoveTaskDown: key.NewBinding(key.WithKeys("J", "shift+down"), key.WithHelp("J / Shift+↓", "Move task (or checklist item) down")),
+		Undo:         key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "Undo last task change (last 50 are kept)")),
+		Redo:         key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("Ctrl+R", "Redo last undone change")),
+		Duplicate:    key.NewBinding(key.WithKeys("y"), key.WithHelp("y p", "Duplicate task below itself, checklist unticked")),
+		SaveTemplate: key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("Ctrl+S", "Save task as a template (Ctrl+T in the task input starts from one)")),
+		Select:       key.NewBinding(key.WithKeys(" "), key.WithHelp("Space", "Select task; m, p, t, x and d then act on all selected (Esc clears)")),
+		Copy:         key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "Copy the task title to the clipboard")),
+		CopyMarkdown: key.NewBinding(key.WithKeys("Y"), key.WithHelp("Y", "Copy the task as markdown, title and description")),
+		Paste:        key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "Paste: one task per clipboard line in the current column")),
+		EditLink:     key.NewBinding(key.WithKeys("U"), key.WithHelp("U", "Set the task's link (a web page, e.g. its issue)")),
+		OpenLink:     key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "Open the task's link in the browser (picks one when the description has more)")),
+		Dependencies: key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "Dependencies: the tasks this one is blocked by, and the ones it blocks")),
+		Epic:         key.NewBinding(key.WithKeys("H"), key.WithHelp("H", "Epic: the task's epic and its children")),
+
+		AddSubtask:    key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "Add checklist item")),
+		ToggleSubtask: key.NewBinding(key.WithKeys(" ", "x"), key.WithHelp("Space / x", "Toggle checklist item")),
+		DeleteSubtask: key.NewBinding(key.WithKeys("d", "delete"), key.WithHelp("d / Delete", "Delete checklist item")),
+		RawMarkdown:   key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "Show the description as raw text or rendered markdown")),
+		Back:          key.NewBinding(key.WithKeys("enter", "q"), key.WithHelp("Enter / Esc", "Back to board")),
+
+		Search:       key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "Search (filters as you type, Esc clears)")),
+		UrgentOnly:   key.NewBinding(key.WithKeys("!"), key.WithHelp("!", "Toggle showing only high and urgent tasks")),
+		FilterLabel:  key.NewBinding(key.WithKeys("L"), key.WithHelp("L", "Filter by a tag (press again to clear)")),
+		QuickLabel:   key.NewBinding(key.WithKeys("#"), key.WithHelp("#", "Filter by a tag of the selected task (press again to clear)")),
+		Filter:       key.NewBinding(key.WithKeys("F"), key.WithHelp("F", "Pick a filter: stale (untouched) or urgent tasks")),
+		Sort:         key.NewBinding(key.WithKeys("S"), key.WithHelp("S", "Sort the column: manual order, priority, due date, creation date, title")),
+		Views:        key.NewBinding(key.WithKeys("V"), key.WithHelp("V", "Saved views: switch to one, save the filters, sorts and hidden columns as one")),
+		Settings:     key.NewBinding(key.WithKeys("B"), key.WithHelp("B", "Board settings: rename, reorder, limit and show or hide the columns")),
+		AddColumn:    key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "Add a column right of the current one")),
+		RenameColumn: key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "Rename current column")),
+		DeleteColumn: key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "Delete current column (tasks can be moved elsewhere)")),
+		ColumnLeft:   key.NewBinding(key.WithKeys("shift+left", "<"), key.WithHelp("Shift+← / <", "Move current column left")),
+		ColumnRight:  key.NewBinding(key.WithKeys("shift+right", ">"), key.WithHelp("Shift+→ / >", "Move current column right")),
+		FocusLeft:    key.NewBinding(key.WithKeys("alt+left", "alt+h"), key.WithHelp("Alt+← / Alt+h", "Previous column, collapsed ones included")),
+		FocusRight:   key.NewBinding(key.WithKeys("alt+right", "alt+l"), key.WithHelp("Alt+→ / Alt+l", "Next column, collapsed ones included")),
+		Collapse:     key.NewBinding(key.WithKeys("-"), key.WithHelp("-", "Collapse the column to a strip, or expand it")),
+		Lanes:        key.NewBinding(key.WithKeys("="), key.WithHelp("=", "Swimlanes: group the tasks by tag, by priority, or not")),
+		WrapTitles:   key.NewBinding(key.WithKeys("ctrl+w"), key.WithHelp("Ctrl+W", "Show whole task titles, or cut them to a few lines")),
+		WIPLimit:     key.NewBinding(key.WithKeys("W"), key.WithHelp("W", "Set current column's WIP limit (0 to remove)")),
+		Trash:        key.NewBinding(key.WithKeys("T"), key.WithHelp("T", "Open or close the trash (deleted tasks)")),
+		Dashboard:    key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "Open or close the statistics dashboard")),
+		Zoom:         key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "Zoom the column to full width (z or Esc zooms out)")),
+		Palette:      key.NewBinding(key.WithKeys(":"), key.WithHelp(":", "Command palette: find any action by name and run it")),
+
+		Calendar: key.NewBinding(key.WithKeys("O"), key.WithHelp("O", "Open or close the calendar of due dates")),
+		Agenda:   key.NewBinding(key.WithKeys("N"), key.WithHelp("N", "Open or close today's agenda across all workspaces")),
+		MarkDone: key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "Move the task picked in the calendar or agenda to the done column")),
+
+		Archive:       key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "Archive task (off the board, undo with u)")),
+		ArchiveColumn: key.NewBinding(key.WithKeys("X"), key.WithHelp("X", "Archive all tasks in the current column (asks first)")),
+		ArchiveView:   key.NewBinding(key.WithKeys("A"), key.WithHelp("A", "Open or close the archive (/ searches it)")),
+		Unarchive:     key.NewBinding(key.WithKeys("r", "enter"), key.WithHelp("r / Enter", "Unarchive task back to its column")),
+
+		Snooze:      key.NewBinding(key.WithKeys("Z"), key.WithHelp("Z", "Snooze the task until tomorrow, next week or a date")),
+		SnoozedView: key.NewBinding(key.WithKeys("ctrl+z"), key.WithHelp("Ctrl+Z", "Open or close the snoozed tasks")),
+		Wake:        key.NewBinding(key.WithKeys("r", "enter"), key.WithHelp("r / Enter", "Wake the snoozed task now")),
+
+		RestoreTask: key.NewBinding(key.WithKeys("r", "enter"), key.WithHelp("r / Enter", "Restore task to its column")),
+		PurgeTask:   key.NewBinding(key.WithKeys("d", "delete"), key.WithHelp("d / Delete", "Delete task permanently (asks first)")),
+
+		Workspace: key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "Switch to another workspace or create one")),
+		Refresh:   key.NewBinding(key.WithKeys("f5"), key.WithHelp("F5", "Reload the board")),
+		Help:      key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "Toggle this help")),
+		Quit:      key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q / Ctrl+C", "Quit")),
+	}
+}
+
+// helpSection is a titled group of bindings in the help overlay
+type helpSection struct {
+	title    string
+	bindings []key.Binding
+}
+
+// sections groups the bindings for the help overlay
+func (k keyMap) sections() []helpSection {
+	return []helpSection{
+		{"Navigation", []key.Binding{k.Left, k.Right, k.FocusLeft, k.FocusRight, k.Up, k.Down, k.PageUp, k.PageDown, k.Top, k.Bottom, k.Column, k.GoTo}},
+		{"Vim (h/j/k/l and G above too; a count repeats a motion, 3j, or picks a task, 5G; vim: false turns them off)", []key.Binding{k.GoTop}},
+		{"Task actions", []key.Binding{k.Add, k.Details, k.Edit, k.Description, k.Editor, k.Tags, k.Due, k.Repeat, k.Timer, k.Pomodoro, k.Priority, k.Pin, k.Delete, k.Move, k.MoveToWS, k.MoveTaskUp, k.MoveTaskDown, k.Undo, k.Redo, k.Duplicate, k.SaveTemplate, k.Select, k.Copy, k.CopyMarkdown, k.Paste, k.EditLink, k.OpenLink, k.Dependencies, k.Epic}},
+		{"Task details", []key.Binding{k.AddSubtask, k.ToggleSubtask, k.DeleteSubtask, k.RawMarkdown, k.Back}},
+		{"Board", []key.Binding{k.Search, k.UrgentOnly, k.FilterLabel, k.QuickLabel, k.Filter, k.Sort, k.Views, k.Settings, k.AddColumn, k.RenameColumn, k.DeleteColumn, k.ColumnLeft, k.ColumnRight, k.WIPLimit, k.Trash, k.Dashboard, k.Zoom, k.Collapse, k.Lanes, k.WrapTitles, k.Palette}},
+		{"Calendar and agenda", []key.Binding{k.Calendar, k.Agenda, k.MarkDone}},
+		{"Archive", []key.Binding{k.Archive, k.ArchiveColumn, k.ArchiveView, k.Unarchive}},
+		{"Snooze", []key.Binding{k.Snooze, k.SnoozedView, k.Wake}},
+		{"Trash", []key.Binding{k.RestoreTask, k.PurgeTask}},
+		{"Workspace", []key.Binding{k.Workspace, k.Refresh, k.Help, k.Quit}},
+	}
+}
+
+// coreKey is one of the few most important keys, with its short name
+type coreKey struct {
+	binding key.Binding
+	desc    string
+}
+
+// coreKeys returns the keys a new user needs first: the board footer and
+// the welcome overlay show them
+func (k keyMap) coreKeys() []coreKey {
+	return []coreKey{
+		{k.Add, "add"},
+		{k.Details, "details"},
+		{k.Move, "move"},
+		{k.Search, "search"},
+		{k.Help, "help"},
+	}
+}
+
+// footerHints returns the few most important keys, shown in the board footer
+func (k keyMap) footerHints() string {
+	hints := k.coreKeys()
+	parts := make([]string, 0, len(hints))
+	for _, h := range hints {
+		if h.binding.Enabled() {
+			parts = append(parts, h.binding.Help().Key+" "+h.desc)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,369 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// KeyDisabled is the value that removes an action's binding in the keys
+// section of the config
+const KeyDisabled = "disabled"
+
+// keyAction names a binding for the keys section of the config
+type keyAction struct {
+	name    string
+	binding *key.Binding
+}
+
+// actions returns every binding by the action name the config uses for it
+func (k *keyMap) actions() []keyAction {
+	return []keyAction{
+		{"prevColumn", &k.Left},
+		{"nextColumn", &k.Right},
+		{"prevColumnAll", &k.FocusLeft},
+		{"nextColumnAll", &k.FocusRight},
+		{"prevTask", &k.Up},
+		{"nextTask", &k.Down},
+		{"pageUp", &k.PageUp},
+		{"pageDown", &k.PageDown},
+		{"firstTask", &k.Top},
+		{"lastTask", &k.Bottom},
+		{"jumpColumn", &k.Column},
+		{"goToTask", &k.GoTo},
+
+		{"newTask", &k.Add},
+		{"openTask", &k.Details},
+		{"editTitle", &k.Edit},
+		{"editDescription", &k.Description},
+		{"externalEditor", &k.Editor},
+		{"editTags", &k.Tags},
+		{"editDue", &k.Due},
+		{"editRecurrence", &k.Repeat},
+		{"toggleTimer", &k.Timer},
+		{"togglePomodoro", &k.Pomodoro},
+		{"cyclePriority", &k.Priority},
+		{"pin", &k.Pin},
+		{"deleteTask", &k.Delete},
+		{"moveTaskRight", &k.Move},
+		{"moveTaskToWorkspace", &k.MoveToWS},
+		{"moveTaskUp", &k.MoveTaskUp},
+		{"moveTaskDown", &k.MoveTaskDown},
+		{"undo", &k.Undo},
+		{"redo", &k.Redo},
+		{"duplicateTask", &k.Duplicate},
+		{"saveTemplate", &k.SaveTemplate},
+		{"selectTask", &k.Select},
+		{"copyTitle", &k.Copy},
+		{"copyMarkdown", &k.CopyMarkdown},
+		{"paste", &k.Paste},
+		{"editLink", &k.EditLink},
+		{"openLink", &k.OpenLink},
+		{"dependencies", &k.Dependencies},
+		{"epic", &k.Epic},
+
+		{"addChecklistItem", &k.AddSubtask},
+		{"toggleChecklistItem", &k.ToggleSubtask},
+		{"deleteChecklistItem", &k.DeleteSubtask},
+		{"toggleRawMarkdown", &k.RawMarkdown},
+		{"back", &k.Back},
+
+		{"search", &k.Search},
+		{"urgentOnly", &k.UrgentOnly},
+		{"filterTag", &k.FilterLabel},
+		{"filterTaskTag", &k.QuickLabel},
+		{"filter", &k.Filter},
+		{"sort", &k.Sort},
+		{"views", &k.Views},
+		{"boardSettings", &k.Settings},
+		{"addColumn", &k.AddColumn},
+		{"renameColumn", &k.RenameColumn},
+		{"deleteColumn", &k.DeleteColumn},
+		{"moveColumnLeft", &k.ColumnLeft},
+		{"moveColumnRight", &k.ColumnRight},
+		{"wipLimit", &k.WIPLimit},
+		{"trash", &k.Trash},
+		{"dashboard", &k.Dashboard},
+		{"zoom", &k.Zoom},
+		{"collapseColumn", &k.Collapse},
+		{"swimlanes", &k.Lanes},
+		{"wrapTitles", &k.WrapTitles},
+		{"palette", &k.Palette},
+
+		{"calendar", &k.Calendar},
+		{"agenda", &k.Agenda},
+		{"markDone", &k.MarkDone},
+
+		{"archiveTask", &k.Archive},
+		{"archiveColumn", &k.ArchiveColumn},
+		{"archive", &k.ArchiveView},
+		{"unarchive", &k.Unarchive},
+
+		{"snooze", &k.Snooze},
+		{"snoozed", &k.SnoozedView},
+		{"wake", &k.Wake},
+
+		{"restoreTask", &k.RestoreTask},
+		{"purgeTask", &k.PurgeTask},
+
+		{"switchWorkspace", &k.Workspace},
+		{"refresh", &k.Refresh},
+		{"help", &k.Help},
+		{"quit", &k.Quit},
+	}
+}
+
+// keyScope is a view and the bindings it reads keys for; no two of them may
+// share a key
+type keyScope struct {
+	name     string
+	bindings []*key.Binding
+}
+
+// scopes returns the views whose bindings must not clash
+func (k *keyMap) scopes() []keyScope {
+	return []keyScope{
+		{"board", []*key.Binding{
+			&k.Left, &k.Right, &k.FocusLeft, &k.FocusRight, &k.Up, &k.Down, &k.PageUp, &k.PageDown, &k.Top, &k.Bottom, &k.Column, &k.GoTo,
+			&k.Add, &k.Details, &k.Edit, &k.Description, &k.Editor, &k.Tags, &k.Due, &k.Repeat, &k.Timer, &k.Pomodoro,
+			&k.Priority, &k.Pin, &k.Delete, &k.Move, &k.MoveToWS, &k.MoveTaskUp, &k.MoveTaskDown, &k.Undo, &k.Redo,
+			&k.Duplicate, &k.SaveTemplate, &k.Select, &k.Copy, &k.CopyMarkdown, &k.Paste, &k.EditLink, &k.OpenLink, &k.Dependencies, &k.Epic,
+			&k.Search, &k.UrgentOnly, &k.FilterLabel, &k.QuickLabel, &k.Filter, &k.Sort, &k.Views, &k.Settings, &k.AddColumn, &k.RenameColumn,
+			&k.DeleteColumn, &k.ColumnLeft, &k.ColumnRight, &k.WIPLimit, &k.Trash, &k.Dashboard, &k.Zoom, &k.Collapse, &k.Lanes, &k.WrapTitles, &k.Palette,
+			&k.Calendar, &k.Agenda, &k.Archive, &k.ArchiveColumn, &k.ArchiveView, &k.Snooze, &k.SnoozedView,
+			&k.Workspace, &k.Refresh, &k.Help, &k.Quit,
+		}},
+		{"task details", []*key.Binding{
+			&k.Up, &k.Down, &k.Edit, &k.Description, &k.Editor, &k.Tags, &k.Due, &k.Repeat, &k.Timer, &k.Pomodoro,
+			&k.MoveTaskUp, &k.MoveTaskDown, &k.Undo, &k.Redo, &k.SaveTemplate, &k.Copy, &k.CopyMarkdown, &k.EditLink, &k.OpenLink, &k.Dependencies, &k.Epic,
+			&k.AddSubtask, &k.ToggleSubtask, &k.DeleteSubtask, &k.RawMarkdown, &k.Back,
+		}},
+		{"calendar", []*key.Binding{&k.Left, &k.Right, &k.Up, &k.Down, &k.PageUp, &k.PageDown, &k.Top, &k.Details, &k.Calendar, &k.MarkDone}},
+		{"agenda", []*key.Binding{&k.Up, &k.Down, &k.Details, &k.Agenda, &k.MarkDone}},
+		{"archive", []*key.Binding{&k.Up, &k.Down, &k.Search, &k.ArchiveView, &k.Unarchive}},
+		{"trash", []*key.Binding{&k.Up, &k.Down, &k.Trash, &k.RestoreTask, &k.PurgeTask}},
+		{"snoozed tasks", []*key.Binding{&k.Up, &k.Down, &k.Snooze, &k.SnoozedView, &k.Wake}},
+		{"board settings", []*key.Binding{&k.Up, &k.Down, &k.MoveTaskUp, &k.MoveTaskDown, &k.Settings}},
+	}
+}
+
+// namedKeys maps the key names the config accepts, besides single
+// characters, to how they are shown
+var namedKeys = map[string]string{
+	"enter": "Enter", "tab": "Tab", "esc": "Esc", "backspace": "Backspace", "delete": "Delete", "insert": "Insert",
+	"up": "↑", "down": "↓", "left": "←", "right": "→", "home": "Home", "end": "End", "pgup": "PgUp", "pgdown": "PgDn",
+	"f1": "F1", "f2": "F2", "f3": "F3", "f4": "F4", "f5": "F5", "f6": "F6",
+	"f7": "F7", "f8": "F8", "f9": "F9", "f10": "F10", "f11": "F11", "f12": "F12",
+}
+
+// parseKey checks a key as written in the config, e.g. x, X, ctrl+d,
+// shift+up, space or f2, and returns it as Bubble Tea reports it
+func parseKey(input string) (string, error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return "", fmt.Errorf("empty key")
+	}
+	if utf8.RuneCountInString(s) == 1 {
+		return s, nil
+	}
+	s = strings.ToLower(s)
+	if s == "space" {
+		return " ", nil
+	}
+	if _, ok := namedKeys[s]; ok {
+		return s, nil
+	}
+	for _, mod := range []string{"ctrl+", "alt+", "shift+"} {
+		rest, ok := strings.CutPrefix(s, mod)
+		if !ok {
+			continue
+		}
+		if utf8.RuneCountInString(rest) == 1 && mod != "shift+" {
+			return s, nil
+		}
+		if _, named := namedKeys[rest]; named {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown key %q: use a character, a name such as enter, space, pgup or f5, or ctrl+, alt+ or shift+ with one", input)
+}
+
+// keyLabel shows a key the way the help overlay does, e.g. Ctrl+D or Shift+↑
+func keyLabel(k string) string {
+	if k == " " {
+		return "Space"
+	}
+	if name, ok := namedKeys[k]; ok {
+		return name
+	}
+	if rest, ok := strings.CutPrefix(k, "ctrl+"); ok && rest != "" {
+		return "Ctrl+" + strings.ToUpper(keyLabel(rest))
+	}
+	if rest, ok := strings.CutPrefix(k, "alt+"); ok && rest != "" {
+		return "Alt+" + keyLabel(rest)
+	}
+	if rest, ok := strings.CutPrefix(k, "shift+"); ok && rest != "" {
+		return "Shift+" + keyLabel(rest)
+	}
+	return k
+}
+
+// applyKeys returns the built-in bindings, without the vim layer unless
+// vim is set, with the config's changes: each action named in keys gets
+// those keys instead, or none for KeyDisabled. Unknown actions and keys, or
+// two actions of one view sharing a key, are errors, all of them reported
+// at once.
+func applyKeys(keys map[string][]string, vim bool) (keyMap, error) {
+	k := defaultKeyMap()
+	if !vim {
+		k.withoutVim()
+	}
+	if len(keys) == 0 {
+		return k, nil
+	}
+
+	actions := k.actions()
+	byName := make(map[string]*key.Binding, len(actions))
+	for _, a := range actions {
+		byName[a.name] = a.binding
+	}
+	// The sort key only sorted by due date before it had modes
+	byName["sortByDue"] = &k.Sort
+
+	var problems []string
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		binding, ok := byName[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown action %q", name))
+			continue
+		}
+		values := keys[name]
+		if len(values) == 1 && strings.EqualFold(strings.TrimSpace(values[0]), KeyDisabled) {
+			if binding == &k.Quit {
+				problems = append(problems, "quit can't be disabled")
+				continue
+			}
+			binding.Unbind()
+			continue
+		}
+		if len(values) == 0 {
+			problems = append(problems, fmt.Sprintf("%s: no keys given (use %q to remove the binding)", name, KeyDisabled))
+			continue
+		}
+		parsed := make([]string, 0, len(values))
+		labels := make([]string, 0, len(values))
+		for _, v := range values {
+			p, err := parseKey(v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", name, err))
+				continue
+			}
+			parsed = append(parsed, p)
+			labels = append(labels, keyLabel(p))
+		}
+		if len(parsed) < len(values) {
+			continue
+		}
+		label := strings.Join(labels, " / ")
+		switch binding {
+		case &k.Duplicate:
+			label += " p" // the second key of the chord is fixed
+		case &k.GoTo:
+			label += " 42 Enter"
+		}
+		*binding = key.NewBinding(key.WithKeys(parsed...), key.WithHelp(label, binding.Help().Desc))
+	}
+
+	problems = append(problems, k.conflicts(actions)...)
+	if len(problems) > 0 {
+		k = defaultKeyMap()
+		if !vim {
+			k.withoutVim()
+		}
+		return k, fmt.Errorf("keys:\n  %s", strings.Join(problems, "\n  "))
+	}
+	if vim {
+		k.mirrorGoTop()
+	}
+	return k, nil
+}
+
+// conflicts describes every key bound to more than one action of a view
+func (k *keyMap) conflicts(actions []keyAction) []string {
+	names := make(map[*key.Binding]string, len(actions))
+	for _, a := range actions {
+		names[a.binding] = a.name
+	}
+
+	var problems []string
+	reported := make(map[string]bool)
+	for _, scope := range k.scopes() {
+		owners := make(map[string][]string)
+		var order []string
+		for _, b := range scope.bindings {
+			for _, key := range b.Keys() {
+				if len(owners[key]) == 0 {
+					order = append(order, key)
+				}
+				owners[key] = append(owners[key], names[b])
+			}
+		}
+		for _, key := range order {
+			if len(owners[key]) < 2 {
+				continue
+			}
+			// The same clash can show up in several views
+			clash := keyLabel(key) + ": " + strings.Join(owners[key], ", ")
+			if reported[clash] {
+				continue
+			}
+			reported[clash] = true
+			problems = append(problems, fmt.Sprintf("%s is bound to %s in the %s", keyLabel(key), strings.Join(owners[key], " and "), scope.name))
+		}
+	}
+	return problems
+}
+
+// CheckKeys reports what is wrong with the keys section of the config,
+// which maps action names to keys, with the vim layer on or off, or nil
+func CheckKeys(keys map[string][]string, vim bool) error {
+	_, err := applyKeys(keys, vim)
+	return err
+}
+
+// closeHint is the hint for the key that closes a view, along with Esc, e.g.
+// "O/Esc"
+func closeHint(b key.Binding) string {
+	if !b.Enabled() {
+		return "Esc"
+	}
+	return b.Help().Key + "/Esc"
+}
+
+// hint is the footer hint for a binding, e.g. "m: Done", or "" when it is
+// disabled
+func hint(b key.Binding, desc string) string {
+	if !b.Enabled() {
+		return ""
+	}
+	return b.Help().Key + ": " + desc
+}
+
+// joinHints joins footer hints, leaving out the empty ones
+func joinHints(hints ...string) string {
+	parts := make([]string, 0, len(hints))
+	for _, h := range hints {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,386 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// laneMode is how the board groups its tasks into swimlanes, by the name it
+// is saved under in the settings table
+type laneMode string
+
+const (
+	laneNone     laneMode = "" // the flat board
+	laneTag      laneMode = "tag"
+	lanePriority laneMode = "priority"
+)
+
+// laneCycle is the order the swimlane key goes through the modes
+var laneCycle = []laneMode{laneNone, laneTag, lanePriority}
+
+// laneGroups are the priorities in the order of their lanes, most urgent first
+var laneGroups = []model.TaskPriority{model.PriorityUrgent, model.PriorityHigh, model.PriorityMedium, model.PriorityLow, model.PriorityNone}
+
+// parseLaneMode returns the mode saved under a name; unknown names give the
+// flat board
+func parseLaneMode(name string) laneMode {
+	for _, mode := range laneCycle {
+		if string(mode) == name {
+			return mode
+		}
+	}
+	return laneNone
+}
+
+// next returns the mode the swimlane key switches to
+func (l laneMode) next() laneMode {
+	for i, mode := range laneCycle {
+		if mode == l {
+			return laneCycle[(i+1)%len(laneCycle)]
+		}
+	}
+	return laneNone
+}
+
+// lane is one row of swimlanes: the tag or priority its tasks share, ""
+// for tasks without one
+type lane struct {
+	key   string
+	title string
+}
+
+// laneKey returns the lane a task goes in. A task with several tags goes in
+// the lane of the first of them.
+func (m *Model) laneKey(task model.Task) string {
+	if m.lanes == lanePriority {
+		return string(task.Priority)
+	}
+	key := ""
+	for _, tag := range task.Tags {
+		tag = strings.ToLower(tag)
+		if key == "" || tag < key {
+			key = tag
+		}
+	}
+	return key
+}
+
+// laneLess reports whether task a's lane comes before task b's: priority
+// lanes run from urgent down, tag lanes by name with untagged tasks last
+func (m *Model) laneLess(a, b model.Task) bool {
+	if m.lanes == lanePriority {
+		return a.Priority.Rank() > b.Priority.Rank()
+	}
+	ka, kb := m.laneKey(a), m.laneKey(b)
+	if ka == "" || kb == "" {
+		return ka != "" && kb == ""
+	}
+	return ka < kb
+}
+
+// boardLanes returns the lanes of the board: every priority, or every tag
+// of the visible tasks and a last lane for untagged ones when there are any
+func (m Model) boardLanes() []lane {
+	if m.lanes == lanePriority {
+		lanes := make([]lane, len(laneGroups))
+		for i, p := range laneGroups {
+			title := string(p)
+			if p == model.PriorityNone {
+				title = "no priority"
+			}
+			lanes[i] = lane{key: string(p), title: title}
+		}
+		return lanes
+	}
+
+	keys := map[string]bool{}
+	for _, i := range m.shownColumns() {
+		for _, task := range m.columns[i].Tasks {
+			if m.taskVisible(task) {
+				keys[m.laneKey(task)] = true
+			}
+		}
+	}
+	var lanes []lane
+	for key := range keys {
+		if key != "" {
+			lanes = append(lanes, lane{key: key, title: "#" + key})
+		}
+	}
+	sort.Slice(lanes, func(i, j int) bool { return lanes[i].key < lanes[j].key })
+	if keys[""] || len(lanes) == 0 {
+		lanes = append(lanes, lane{key: "", title: "no tag"})
+	}
+	return lanes
+}
+
+// laneRow places a card in the rows of a column grouped into lanes
+type laneRow struct {
+	visible int // index among the visible tasks of the column
+	start   int // first row of the card
+	height  int
+	first   bool // the card is the first of its lane
+}
+
+// laneLayout is where the lanes of the columns on screen fall: each lane
+// is as tall as its tallest column, so the lanes line up across the board
+type laneLayout struct {
+	lanes   []lane
+	heights []int // rows of each lane, its header included
+	total   int
+}
+
+// layoutLanes measures the lanes of the columns on screen
+func (m Model) layoutLanes() laneLayout {
+	l := laneLayout{lanes: m.boardLanes()}
+	l.heights = make([]int, len(l.lanes))
+	for i := range l.heights {
+		l.heights[i] = 2 // the header and the empty lane marker
+	}
+	for _, index := range m.laneColumns() {
+		heights := make([]int, len(l.lanes))
+		for i := range heights {
+			heights[i] = 1
+		}
+		col := m.columns[index]
+		width := m.taskWidth(index)
+		for _, idx := range m.visibleTaskIndices(index) {
+			if n := l.find(m.laneKey(col.Tasks[idx])); n >= 0 {
+				heights[n] += m.cardHeightAt(index, idx, width)
+			}
+		}
+		for i, h := range heights {
+			l.heights[i] = max(l.heights[i], h)
+		}
+	}
+	for _, h := range l.heights {
+		l.total += h
+	}
+	return l
+}
+
+// find returns the index of the lane with the key, or -1
+func (l laneLayout) find(key string) int {
+	for i, ln := range l.lanes {
+		if ln.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// laneColumns returns the indices of the columns on screen shown in full
+func (m Model) laneColumns() []int {
+	if m.layout().single {
+		return []int{m.currentColumn}
+	}
+	var columns []int
+	for _, i := range m.shownColumns()[m.columnOffset:m.pageEnd(m.columnOffset)] {
+		if !m.isCollapsed(i) {
+			columns = append(columns, i)
+		}
+	}
+	return columns
+}
+
+// laneRows returns where the cards of a column fall in the lane layout
+func (m Model) laneRows(index int, l laneLayout) []laneRow {
+	col := m.columns[index]
+	visible := m.visibleTaskIndices(index)
+	rows := make([]laneRow, 0, len(visible))
+	width := m.taskWidth(index)
+	start, n := 0, 0
+	for i, ln := range l.lanes {
+		row := start + 1
+		first := true
+		for n < len(visible) && m.laneKey(col.Tasks[visible[n]]) == ln.key {
+			height := m.cardHeightAt(index, visible[n], width)
+			rows = append(rows, laneRow{visible: n, start: row, height: height, first: first})
+			row += height
+			first = false
+			n++
+		}
+		start += l.heights[i]
+	}
+	return rows
+}
+
+// ensureLaneVisible scrolls the lanes so the selected card is in view,
+// with the header of its lane when it is the lane's first card
+func (m *Model) ensureLaneVisible() {
+	l := m.layoutLanes()
+	budget := m.cardsHeight()
+	for _, row := range m.laneRows(m.currentColumn, l) {
+		if row.visible != m.currentTask {
+			continue
+		}
+		top := row.start
+		if row.first {
+			top--
+		}
+		if top < m.laneOffset {
+			m.laneOffset = top
+		}
+		if end := row.start + row.height; end > m.laneOffset+budget {
+			m.laneOffset = min(end-budget, top)
+		}
+	}
+	m.laneOffset = max(min(m.laneOffset, l.total-budget), 0)
+}
+
+// renderLaneColumn renders a column grouped into lanes, scrolled to the
+// same rows as the others. Only the cards in view are rendered; the others
+// are measured, which is cached.
+func (m Model) renderLaneColumn(index int, col model.Column, l laneLayout) string {
+	visible := m.visibleTaskIndices(index)
+	headerStyle := lipgloss.NewStyle().Foreground(colorSecondary).Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted)
+
+	budget := m.cardsHeight()
+	offset := min(m.laneOffset, max(l.total-budget, 0))
+	end := min(offset+budget, l.total)
+	width := m.taskWidth(index)
+	var lines []string
+	put := func(row int, line string) {
+		if row >= offset && row < end {
+			lines = append(lines, line)
+		}
+	}
+
+	n, start := 0, 0
+	for i, ln := range l.lanes {
+		count := 0
+		for j := n; j < len(visible) && m.laneKey(col.Tasks[visible[j]]) == ln.key; j++ {
+			count++
+		}
+		row := start
+		put(row, headerStyle.Render(truncateText(fmt.Sprintf("─ %s %d", ln.title, count), width)))
+		row++
+		if count == 0 {
+			put(row, mutedStyle.Render("  ·"))
+			row++
+		}
+		for ; count > 0; count-- {
+			height := m.cardHeightAt(index, visible[n], width)
+			if row < end && row+height > offset {
+				isActive := index == m.currentColumn && n == m.currentTask
+				for k, line := range strings.Split(m.renderTask(index, col.Tasks[visible[n]], isActive), "\n") {
+					put(row+k, line)
+				}
+			}
+			row += height
+			n++
+		}
+		start += l.heights[i]
+		for ; row < start; row++ {
+			put(row, "")
+		}
+	}
+
+	up, down := "", ""
+	if offset > 0 {
+		up = mutedStyle.Render("  ▲")
+	}
+	if end < l.total {
+		down = mutedStyle.Render("  ▼")
+	}
+
+	content := m.renderColumnTitle(index, col) + "\n" + up + "\n" + strings.Join(lines, "\n") + "\n" + down
+	style := columnStyle.Copy().Width(m.renderedColumnWidth(index) - 2).BorderForeground(m.columnColor(index))
+	if index == m.currentColumn {
+		style = style.Copy().Bold(true)
+	}
+	return style.Render(content)
+}
+
+// laneTaskAt returns the visible task index of the card at a row of a
+// column grouped into lanes, counted from the first row under the title
+func (m Model) laneTaskAt(index, row int) (int, bool) {
+	row += m.laneOffset - 1 // the scroll indicator
+	for _, r := range m.laneRows(index, m.layoutLanes()) {
+		if row >= r.start && row < r.start+r.height {
+			return r.visible, true
+		}
+	}
+	return 0, false
+}
+
+// cycleLanes switches the board to the next swimlane mode and saves it for
+// the workspace
+func (m Model) cycleLanes() (tea.Model, tea.Cmd) {
+	mode := m.lanes.next()
+	task := m.getCurrentTask()
+	m.lanes = mode
+	m.laneOffset = 0
+	// The visible order changed: keep the selected task selected
+	if task == nil || !m.selectTask(task.ID) {
+		m.ensureTaskVisible()
+	}
+	switch mode {
+	case laneNone:
+		m.showNotice("flat board")
+	default:
+		m.showNotice("swimlanes by " + string(mode))
+	}
+
+	// A read-only board groups for this window only
+	if m.readOnly() {
+		return m, nil
+	}
+	return m, func() tea.Msg {
+		if err := m.db.SetSwimlanes(string(mode)); err != nil {
+			return errMsg{err}
+		}
+		return nil
+	}
+}
+
+// moveAcrossLane moves the selected task into the lane above (delta < 0)
+// or below its own by changing its priority or tag, reporting whether it
+// is at that edge of its lane
+func (m Model) moveAcrossLane(delta int) (bool, tea.Model, tea.Cmd) {
+	task := m.getCurrentTask()
+	if m.lanes == laneNone || task == nil {
+		return false, m, nil
+	}
+	visible := m.visibleTaskIndices(m.currentColumn)
+	col := m.columns[m.currentColumn]
+	if target := m.currentTask + delta; target >= 0 && target < len(visible) && m.laneKey(col.Tasks[visible[target]]) == m.laneKey(*task) {
+		return false, m, nil
+	}
+
+	lanes := m.boardLanes()
+	current := -1
+	for i, ln := range lanes {
+		if ln.key == m.laneKey(*task) {
+			current = i
+		}
+	}
+	target := current + delta
+	if current < 0 || target < 0 || target >= len(lanes) {
+		return true, m, nil
+	}
+	to := lanes[target]
+	m.followTaskID = task.ID
+	m.showNotice(fmt.Sprintf("moved %q to %s", truncateText(task.Title, 30), to.title))
+	if m.lanes == lanePriority {
+		return true, m, m.updatePriority(task, model.TaskPriority(to.key))
+	}
+
+	// Swap the tag of the lane for the tag of the other one
+	var tags []string
+	for _, tag := range task.Tags {
+		if !strings.EqualFold(tag, m.laneKey(*task)) {
+			tags = append(tags, tag)
+		}
+	}
+	if to.key != "" {
+		tags = mergeLabels(tags, []string{to.key})
+	}
+	return true, m, m.updateTags(task, tags)
+}
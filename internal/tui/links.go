@@ -0,0 +1,247 @@
+package tui
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// linkPattern finds web links in descriptions
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// linkOpenedMsg reports the outcome of opening a link in the browser
+type linkOpenedMsg struct {
+	url string
+	err error
+}
+
+// taskLinks returns the links of a task: its own link first, then the ones
+// found in its description, each once
+func taskLinks(task *model.Task) []string {
+	var links []string
+	seen := make(map[string]bool)
+	add := func(link string) {
+		if link != "" && !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+	add(task.URL)
+	for _, link := range linkPattern.FindAllString(task.Description, -1) {
+		// Punctuation closing a sentence or a markdown link isn't part of it
+		link = strings.TrimRight(link, ".,;:!?")
+		if strings.HasSuffix(link, ")") && !strings.Contains(link, "(") {
+			link = strings.TrimRight(link, ")")
+		}
+		add(link)
+	}
+	return links
+}
+
+// normalizeLink checks a typed link and completes it: a link without a
+// scheme, e.g. example.com/issue/1, is taken as https
+func normalizeLink(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	if !strings.Contains(s, "://") && !strings.HasPrefix(s, "mailto:") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || (u.Host == "" && u.Scheme != "mailto" && u.Scheme != "file") || strings.ContainsAny(s, " \t") {
+		return "", fmt.Errorf("%q is not a link", s)
+	}
+	return s, nil
+}
+
+// openerCommand returns the command that opens a link in the default
+// browser on this platform
+func openerCommand(link string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", link)
+	case "windows":
+		// start treats & as a command separator unless escaped
+		return exec.Command("cmd", "/c", "start", "", strings.ReplaceAll(link, "&", "^&"))
+	default:
+		return exec.Command("xdg-open", link)
+	}
+}
+
+// openLink opens a link in the default browser in the background, so a
+// slow opener doesn't hold up the board
+func openLink(link string) tea.Cmd {
+	return func() tea.Msg {
+		out, err := openerCommand(link).CombinedOutput()
+		if err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				err = fmt.Errorf("%w: %s", err, msg)
+			}
+		}
+		return linkOpenedMsg{url: link, err: err}
+	}
+}
+
+// openTaskLinks opens the task's only link, or lets the user pick one of
+// its links
+func (m Model) openTaskLinks(task *model.Task) (tea.Model, tea.Cmd) {
+	if task == nil {
+		return m, nil
+	}
+	links := taskLinks(task)
+	switch len(links) {
+	case 0:
+		hint := ""
+		if m.keys.EditLink.Enabled() {
+			hint = ", press " + m.keys.EditLink.Help().Key + " to add one"
+		}
+		m.showNotice("no link on this task" + hint)
+		return m, nil
+	case 1:
+		return m, openLink(links[0])
+	}
+	m.links = links
+	m.linkCursor = 0
+	m.linkReturn = m.viewMode
+	m.viewMode = ViewModeLinks
+	return m, nil
+}
+
+// startEditLink opens the input for the task's link
+func (m *Model) startEditLink(task *model.Task) {
+	if task == nil {
+		return
+	}
+	m.viewMode = ViewModeEditLink
+	m.linkInput.SetValue(task.URL)
+	m.linkInput.CursorEnd()
+	m.linkInput.Focus()
+}
+
+// updateLink sets a task's link
+func (m Model) updateLink(task *model.Task, link string) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.UpdateTaskURL(task.ID, link)
+	})
+}
+
+// handleEditLinkKeys handles keyboard input while editing a task's link
+func (m Model) handleEditLinkKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		task := m.getCurrentTask()
+		if task == nil {
+			m.viewMode = ViewModeBoard
+			return m, nil
+		}
+		link, err := normalizeLink(m.linkInput.Value())
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.viewMode = ViewModeBoard
+		m.linkInput.SetValue("")
+		m.err = nil
+		if link == task.URL {
+			return m, nil
+		}
+		return m, m.updateLink(task, link)
+
+	case "esc":
+		m.viewMode = ViewModeBoard
+		m.linkInput.SetValue("")
+		m.err = nil
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.linkInput, cmd = m.linkInput.Update(msg)
+	return m, cmd
+}
+
+// handleLinkPickerKeys handles keyboard input in the link picker: up and
+// down pick a link, Enter opens it and Esc goes back
+func (m Model) handleLinkPickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "esc" || key.Matches(msg, m.keys.OpenLink):
+		m.viewMode = m.linkReturn
+		return m, nil
+	case key.Matches(msg, m.keys.Up):
+		if m.linkCursor > 0 {
+			m.linkCursor--
+		}
+		return m, nil
+	case key.Matches(msg, m.keys.Down):
+		if m.linkCursor < len(m.links)-1 {
+			m.linkCursor++
+		}
+		return m, nil
+	case msg.String() == "enter":
+		m.viewMode = m.linkReturn
+		if m.linkCursor < len(m.links) {
+			return m, openLink(m.links[m.linkCursor])
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+// viewEditLink renders the input for a task's link
+func (m Model) viewEditLink() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("🔗 Task Link"))
+	b.WriteString("\n\n")
+
+	if task := m.getCurrentTask(); task != nil {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render("Task: " + task.Title))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render("A web page for the task, e.g. https://github.com/owner/repo/issues/42 (leave empty to remove)"))
+	b.WriteString("\n\n")
+	b.WriteString(inputStyle.Render(m.linkInput.View()))
+	b.WriteString("\n\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(helpStyle.Render("Enter: Save | Esc: Cancel"))
+	return b.String()
+}
+
+// viewLinks renders the link picker
+func (m Model) viewLinks() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("🔗 Open Link"))
+	b.WriteString("\n\n")
+
+	width := max(m.width-4, 20)
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	normalStyle := lipgloss.NewStyle().Foreground(colorText)
+	for i, link := range m.links {
+		line := truncateText(link, width)
+		if i == m.linkCursor {
+			b.WriteString(selectedStyle.Render("▸ " + line))
+		} else {
+			b.WriteString(normalStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString(helpStyle.Render("↑/↓: Pick | Enter: Open in the browser | Esc: Back"))
+	return b.String()
+}
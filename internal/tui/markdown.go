@@ -0,0 +1,223 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	mdHeadingRe = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdRuleRe    = regexp.MustCompile(`^(-{3,}|\*{3,}|_{3,})\s*$`)
+	mdListRe    = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	mdTaskRe    = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+)
+
+// mdWord is a word of rendered text with its inline style. space reports
+// whether it follows whitespace, so punctuation after a styled span stays attached.
+type mdWord struct {
+	text  string
+	style lipgloss.Style
+	space bool
+}
+
+// renderMarkdown renders the subset of markdown used in task descriptions,
+// wrapped to width: headings, lists and task lists, block quotes, rules,
+// fenced code blocks, and inline code, bold, italic and links. Line breaks
+// are kept as typed; code lines and words too long for the width are broken.
+func renderMarkdown(text string, width int) string {
+	if width < 10 {
+		width = 10
+	}
+	textStyle := lipgloss.NewStyle().Foreground(colorText)
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	codeStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	gutter := lipgloss.NewStyle().Foreground(colorBorder).Render("▎ ")
+
+	var lines []string
+	inFence := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			for _, part := range strings.Split(wrapText(strings.TrimRight(line, " "), width-2), "\n") {
+				lines = append(lines, gutter+codeStyle.Render(part))
+			}
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			lines = append(lines, "")
+
+		case mdRuleRe.MatchString(trimmed):
+			rule := width
+			if rule > 40 {
+				rule = 40
+			}
+			lines = append(lines, mutedStyle.Render(strings.Repeat("─", rule)))
+
+		case mdHeadingRe.MatchString(trimmed):
+			heading := lipgloss.NewStyle().Bold(true).Foreground(colorSecondary)
+			content := mdHeadingRe.FindStringSubmatch(trimmed)[2]
+			lines = append(lines, wrapWords(parseInline(content, heading, codeStyle), width, "", "")...)
+
+		case strings.HasPrefix(trimmed, ">"):
+			quote := mutedStyle.Copy().Italic(true)
+			content := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			prefix := mutedStyle.Render("│ ")
+			lines = append(lines, wrapWords(parseInline(content, quote, codeStyle), width, prefix, prefix)...)
+
+		case mdListRe.MatchString(line):
+			match := mdListRe.FindStringSubmatch(line)
+			indent := strings.Repeat(" ", len(match[1])/2*2)
+			marker, content := "• ", match[3]
+			if c := match[2][0]; c >= '0' && c <= '9' {
+				marker = match[2] + " "
+			}
+			if task := mdTaskRe.FindStringSubmatch(content); task != nil {
+				marker, content = "[ ] ", task[2]
+				if task[1] != " " {
+					marker = "[x] "
+				}
+			}
+			first := indent + mutedStyle.Render(marker)
+			hanging := indent + strings.Repeat(" ", lipgloss.Width(marker))
+			lines = append(lines, wrapWords(parseInline(content, textStyle, codeStyle), width, first, hanging)...)
+
+		default:
+			lines = append(lines, wrapWords(parseInline(trimmed, textStyle, codeStyle), width, "", "")...)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseInline splits a line into words styled by its inline markup: `code`,
+// **bold**, *italic* and [links](url). Markers without a closing partner are
+// kept as text.
+func parseInline(text string, base, code lipgloss.Style) []mdWord {
+	var words []mdWord
+	space := false
+	add := func(s string, style lipgloss.Style) {
+		if s == "" {
+			return
+		}
+		for i, field := range strings.Fields(s) {
+			words = append(words, mdWord{field, style, space || i > 0 || strings.HasPrefix(s, " ")})
+			space = false
+		}
+		space = space || strings.HasSuffix(s, " ")
+	}
+
+	bold, italic := false, false
+	style := func() lipgloss.Style {
+		s := base.Copy()
+		if bold {
+			s = s.Bold(true)
+		}
+		if italic {
+			s = s.Italic(true)
+		}
+		return s
+	}
+
+	var plain strings.Builder
+	flush := func() {
+		add(plain.String(), style())
+		plain.Reset()
+	}
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				flush()
+				add(rest[1:end+1], code)
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**"):
+			if bold || opensEmphasis(rest[2:], "**") {
+				flush()
+				bold = !bold
+				i += 2
+				continue
+			}
+		case rest[0] == '*':
+			if italic || opensEmphasis(rest[1:], "*") {
+				flush()
+				italic = !italic
+				i++
+				continue
+			}
+		case rest[0] == '[':
+			if close := strings.Index(rest, "]("); close > 0 {
+				if end := strings.IndexByte(rest[close:], ')'); end > 0 {
+					flush()
+					add(rest[1:close], style().Foreground(colorPrimary).Underline(true))
+					url := rest[close+2 : close+end]
+					if url != rest[1:close] {
+						add(" ("+url+")", lipgloss.NewStyle().Foreground(colorMuted))
+					}
+					i += close + end + 1
+					continue
+				}
+			}
+		}
+		plain.WriteByte(rest[0])
+		i++
+	}
+	flush()
+	return words
+}
+
+// opensEmphasis reports whether a marker followed by rest opens emphasis: it
+// must be followed by text, as in *word* but not 5 * 3, and be closed later
+func opensEmphasis(rest, marker string) bool {
+	return rest != "" && rest[0] != ' ' && strings.Contains(rest, marker)
+}
+
+// wrapWords lays words out in lines of at most width columns. The first line
+// starts with first and the others with hanging, which must be as wide.
+func wrapWords(words []mdWord, width int, first, hanging string) []string {
+	indent := lipgloss.Width(hanging)
+	var lines []string
+	var line strings.Builder
+	line.WriteString(first)
+	lineWidth := indent
+	for _, w := range words {
+		wordWidth := lipgloss.Width(w.text)
+		sep := 0
+		if w.space && lineWidth > indent {
+			sep = 1
+		}
+		if lineWidth+sep+wordWidth > width && lineWidth > indent {
+			lines = append(lines, line.String())
+			line.Reset()
+			line.WriteString(hanging)
+			lineWidth, sep = indent, 0
+		}
+		if sep == 1 {
+			line.WriteString(" ")
+			lineWidth++
+		}
+		// A word longer than a whole line is broken across lines
+		for wordWidth > width-lineWidth && width-lineWidth > 0 {
+			part := truncateClusters(w.text, width-lineWidth)
+			line.WriteString(w.style.Render(part))
+			lines = append(lines, line.String())
+			line.Reset()
+			line.WriteString(hanging)
+			lineWidth = indent
+			w.text = w.text[len(part):]
+			wordWidth = lipgloss.Width(w.text)
+		}
+		line.WriteString(w.style.Render(w.text))
+		lineWidth += wordWidth
+	}
+	return append(lines, line.String())
+}
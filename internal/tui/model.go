@@ -1,14 +1,18 @@
 package tui
 
 import (
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/agenda"
 	"github.com/happytaoer/cli_kanban/internal/db"
 	"github.com/happytaoer/cli_kanban/internal/model"
+	"github.com/happytaoer/cli_kanban/internal/workspace"
 )
 
 // ViewMode represents the current view mode
@@ -24,29 +28,207 @@ const (
 	ViewModeConfirmDelete
 	ViewModeHelp
 	ViewModeSearch
+	ViewModeFilterLabel
+	ViewModeDetail
+	ViewModeAddSubtask
+	ViewModeAddColumn
+	ViewModeRenameColumn
+	ViewModeDeleteColumn
+	ViewModeEditWIP
+	ViewModeConfirmWIP
+	ViewModeTrash
+	ViewModeConfirmPurge
+	ViewModeWorkspaces
+	ViewModeArchive
+	ViewModeArchiveSearch
+	ViewModeConfirmArchiveColumn
+	ViewModeDashboard
+	ViewModeEditRecurrence
+	ViewModeBulkMove
+	ViewModeBulkPriority
+	ViewModeBulkTag
+	ViewModeConfirmBulkDelete
+	ViewModeSaveTemplate
+	ViewModeTemplates
+	ViewModeConfirmPaste
+	ViewModeCalendar
+	ViewModeAgenda
+	ViewModeFilter
+	ViewModePalette
+	ViewModeEditLink
+	ViewModeLinks
+	ViewModeDependencies
+	ViewModeConfirmBlocked
+	ViewModeViews
+	ViewModeSaveView
+	ViewModeEpic
+	ViewModeConfirmEpic
+	ViewModeWelcome
+	ViewModeConflict
+	ViewModeBoardSettings
+	ViewModeSnooze
+	ViewModeSnoozed
 )
 
 // Model is the main TUI model
 type Model struct {
-	db              *db.DB
-	columns         []model.Column
-	currentColumn   int
-	currentTask     int
-	scrollOffsets   []int // scroll offset per column
-	viewMode        ViewMode
-	currentTime     time.Time
-	pendingDeleteID int64 // task ID pending deletion confirmation
-	followTaskID    int64 // task ID to follow after reload
-	textInput       textinput.Model
-	textArea        textarea.Model
-	searchInput     textinput.Model
-	dueInput        textinput.Model
-	searchQuery     string // active search filter
-	viewport        viewport.Model
-	width           int
-	height          int
-	ready           bool // viewport ready flag
-	err             error
+	db               *db.DB
+	workspace        string // name of the open workspace
+	lastWorkspace    string // workspace open before the current one, preselected in the switcher
+	workspaces       []workspace.Workspace
+	workspaceCursor  int   // selected entry in the workspace switcher
+	movingTask       int64 // task the switcher moves to another workspace, 0 when it switches
+	workspaceInput   textinput.Model
+	columns          []model.Column
+	currentColumn    int
+	currentTask      int
+	scrollOffsets    []int // scroll offset per column
+	columnOffset     int   // index of the leftmost column on screen
+	viewMode         ViewMode
+	currentTime      time.Time
+	pendingDeleteID  int64        // task ID pending deletion confirmation
+	trash            []model.Task // deleted tasks listed in the trash view
+	trashCursor      int          // selected task in the trash view
+	archive          []model.Task // archived tasks listed in the archive view
+	archiveCursor    int          // selected task among the archive search results
+	archiveQuery     string       // active filter of the archive view
+	archiveInput     textinput.Model
+	snoozed          []model.Task // snoozed tasks, off the board until they wake, by wake day
+	snoozedCursor    int          // selected task in the snoozed view
+	snoozeTask       *model.Task  // task the snooze picker snoozes
+	snoozeReturn     ViewMode     // view the snooze picker goes back to
+	snoozeCursor     int          // highlighted preset in the snooze picker, -1 when the input matches none
+	snoozeInput      textinput.Model
+	followTaskID     int64            // task ID to follow after reload
+	followColumn     model.TaskStatus // column to select after reload
+	expandedColumn   model.TaskStatus // collapsed column opened up for a task moved into it
+	columnTarget     int              // column receiving the tasks of a deleted column
+	settingsColumn   model.TaskStatus // column picked on the board settings screen
+	settingsEdit     settingsField    // setting of that column being typed, if any
+	pendingMoveID    int64            // task ID waiting for confirmation to move past a WIP limit
+	pendingMoveTo    int              // column that task moves into
+	pendingEpicID    int64            // epic waiting for confirmation to be completed with its last child
+	conflict         *editConflict    // edit refused because the task changed elsewhere, waiting to be settled
+	strictWIP        bool             // WIP limits block moves instead of asking
+	sortModes        columnSorts      // how the tasks of each column are sorted
+	collapsedColumns columnSet        // columns shown as narrow strips
+	history          history          // task changes that can be undone and redone
+	historyBusy      bool             // an undo or redo is being written
+	sessionID        int64            // session registered on the workspace
+	sessionOwner     int              // pid of an older TUI holding the workspace; makes this one read-only
+	locked           bool             // opened with --read-only: no session, no writes
+	sample           bool             // workspaces created from the switcher start with example tasks
+	welcomeShown     bool             // the welcome overlay was shown in this window
+	lastHeartbeat    time.Time        // time of the last session heartbeat
+	revision         int64            // database revision the board was loaded at
+	lastRefreshCheck time.Time        // time the revision was last checked
+	dashboard        *db.Stats        // statistics shown on the dashboard, nil while loading
+	calendar         *calendarData    // tasks shown by the calendar, nil while loading
+	calendarDay      time.Time        // day selected in the calendar
+	calendarCursor   int              // selected task among the selected day's
+	agendaSections   []agenda.Section // agenda of every workspace, nil while loading
+	agendaCursor     int              // selected task in the agenda
+	agendaFollow     int64            // task to select once the workspace opened from the agenda loads
+	detailReturn     ViewMode         // view the detail view goes back to
+	activity         []db.Activity    // history of the task shown in the detail view
+	activityTask     int64            // task the activity belongs to
+	rawDescription   bool             // show descriptions as typed instead of rendered markdown
+	notice           string           // message flashed in the footer
+	noticeAt         time.Time        // time the notice was shown
+	textInput        textinput.Model
+	textArea         textarea.Model
+	searchInput      textinput.Model
+	dueInput         textinput.Model
+	recurrenceInput  textinput.Model
+	filterInput      textinput.Model
+	paletteInput     textinput.Model
+	recurrenceCursor int                 // highlighted preset in the recurrence picker
+	filterCursor     int                 // highlighted filter in the filter picker, -1 when the input matches none
+	paletteCursor    int                 // highlighted command in the command palette
+	paletteRecent    []string            // names of the palette commands run this session, latest first
+	timer            *db.TimeEntry       // running timer, nil when none runs
+	detailLogged     time.Duration       // time logged on the detail view's task by stopped timers
+	pomodoro         Pomodoro            // focus mode settings
+	remind           string              // how tasks that become due are announced
+	lastDueCheck     time.Time           // time due tasks were last checked for reminders
+	webhooks         map[string][]string // webhook URLs by workspace, see webhook.URLs
+	delivering       bool                // a webhook delivery is running
+	webhookFailing   map[string]bool     // webhook URLs whose failure was shown, "" for the log itself
+	focus            *focus              // running focus mode, nil when off
+	selected         map[int64]bool      // task IDs selected for a bulk action
+	bulkCursor       int                 // highlighted choice in the bulk pickers
+	bulkInput        textinput.Model
+	templates        []model.Template // templates listed in the template picker, nil while loading
+	templateCursor   int              // highlighted template in the picker
+	templateDraft    *model.Template  // template being saved from a task; named once Name is set
+	fromTemplate     *model.Template  // template the add task input started from
+	pendingYank      bool             // y was pressed; p next duplicates the task
+	pendingCount     int              // count typed before a motion or m, or the column digit just pressed
+	pendingG         bool             // g was pressed; a task ID and Enter, or vim's g, follow
+	gotoID           string           // digits of the task ID typed after g
+	countColumn      int              // column selected before the digit jump, taken back when a motion follows
+	countTask        int              // task selected before the digit jump
+	vim              bool             // vim layer on: counts repeat motions
+	zoomed           bool             // only the focused column is shown, at full width
+	lanes            laneMode         // what the tasks are grouped into swimlanes by
+	titleLines       int              // lines a title wraps onto on its card, 0 for whole titles
+	titleSetting     int              // titleLines as the config sets it
+	laneOffset       int              // rows the swimlanes are scrolled by
+	columnWidths     columnWidths     // widths the settings give columns, by status
+	cache            *boardCache      // what was worked out about the tasks since they were loaded, shared by copies of the model
+	pasteTitles      []string         // clipboard lines waiting for confirmation to become tasks
+	linkInput        textinput.Model
+	links            []string // links of the task listed in the link picker
+	linkCursor       int      // highlighted link in the picker
+	linkReturn       ViewMode // view the link picker goes back to
+	dependencyInput  textinput.Model
+	epicInput        textinput.Model
+	dependencyCursor int            // highlighted task in the dependency view
+	dependencyReturn ViewMode       // view the dependency view goes back to
+	epicCursor       int            // highlighted task in the epic view
+	epicReturn       ViewMode       // view the epic view goes back to
+	flashed          map[int64]bool // tasks just unblocked, flashing on the board
+	flashedAt        time.Time      // time the unblocked tasks started flashing
+	expandedAt       time.Time      // time the collapsed column opened up
+	savedViews       []model.SavedView
+	viewCursor       int // highlighted view in the view picker
+	viewInput        textinput.Model
+	defaultView      string                    // name of the view the board opens with
+	activeView       string                    // name of the view last switched to, until the filters are cleared
+	hiddenColumns    map[model.TaskStatus]bool // columns the view leaves off the board
+	epics            map[int64]epicProgress    // children of the epics on the board
+	openWithView     bool                      // the next load switches to the default view
+	restore          bool                      // the next load puts the board back where it was left, not in the default view
+	savedState       *model.BoardState         // where the board was last saved to be, nil before it is
+	templateInput    textinput.Model
+	searchQuery      string   // active search filter
+	urgentOnly       bool     // only show high and urgent priority tasks
+	labelFilter      string   // only show tasks with this tag
+	quickLabel       string   // the tag filter set from a task with #, which Esc clears alone
+	staleOnly        bool     // only show stale tasks
+	pinnedOnly       bool     // only show pinned tasks
+	staleAfter       string   // how long a task may go untouched before it is stale; see db.StaleBefore
+	labelOptions     []string // all known tags, listed in the tag picker
+	labelSelected    []string // tags checked in the tag picker, in order
+	labelCursor      int      // cursor position in the tag picker
+	labelTask        int64    // the task whose tags the tag filter picker offers (#), or 0
+	labelInput       textinput.Model
+	viewport         viewport.Model
+	detailViewport   viewport.Model // scrollable content of the task detail view
+	helpViewport     viewport.Model // scrollable content of the help overlay
+	keys             keyMap         // bindings for the board and detail view
+	checklistLine    int            // first line of the checklist in the detail view content
+	subtaskCursor    int            // selected checklist item in the detail view
+	lastClick        time.Time      // time of the last click on a task, to detect double-clicks
+	lastClickColumn  int            // column of the last clicked task
+	lastClickTask    int            // visible index of the last clicked task
+	subtaskInput     textinput.Model
+	columnInput      textinput.Model
+	wipInput         textinput.Model
+	width            int
+	height           int
+	ready            bool // viewport ready flag
+	err              error
 }
 
 // clockTickCmd creates a command that emits time ticks every second
@@ -56,8 +238,30 @@ func clockTickCmd() tea.Cmd {
 	})
 }
 
-// NewModel creates a new TUI model
-func NewModel(database *db.DB) Model {
+// Options are the TUI settings read from the config file
+type Options struct {
+	Pomodoro   Pomodoro            // focus mode
+	Remind     string              // how tasks that become due are announced, a Notify value
+	Webhooks   map[string][]string // URLs posted each task change, by workspace; see webhook.URLs
+	StaleAfter string              // how long a task may go untouched before it is stale; see db.StaleBefore
+	Keys       map[string][]string // keys by action name replacing the built-in ones; see CheckKeys
+	Vim        bool                // h/j/k/l, gg/G and counts on the board
+	ReadOnly   bool                // the database was opened read-only, so nothing is written
+	Sample     bool                // new workspaces start with example tasks and a welcome overlay; see SeedSample
+	TitleLines int                 // lines a title wraps onto on its card before it is cut, 0 for whole titles
+	Restore    bool                // each workspace opens where the TUI left it; see model.BoardState
+}
+
+// DefaultOptions returns the settings used when the config sets none
+func DefaultOptions() Options {
+	return Options{Pomodoro: DefaultPomodoro(), Remind: NotifyBell, Vim: true, Sample: true, Restore: true}
+}
+
+// NewModel creates a new TUI model for the named workspace, drawn with the
+// given theme
+func NewModel(database *db.DB, workspaceName string, theme Theme, opts Options) Model {
+	applyTheme(theme)
+
 	ti := textinput.New()
 	ti.Placeholder = "Enter task title..."
 	ti.Focus()
@@ -76,60 +280,260 @@ func NewModel(database *db.DB) Model {
 	si.CharLimit = 100
 	si.Width = 30
 
+	li := textinput.New()
+	li.Placeholder = "Type to filter or create a tag..."
+	li.CharLimit = 50
+	li.Width = 40
+
+	sti := textinput.New()
+	sti.Placeholder = "New checklist item..."
+	sti.CharLimit = 200
+	sti.Width = 50
+
+	ci := textinput.New()
+	ci.Placeholder = "Column name..."
+	ci.CharLimit = 40
+	ci.Width = 40
+
+	wi := textinput.New()
+	wi.Placeholder = "Max tasks (0 or empty for no limit)"
+	wi.CharLimit = 4
+	wi.Width = 40
+
+	wsi := textinput.New()
+	wsi.Placeholder = "Type to find or create a workspace..."
+	wsi.CharLimit = 32
+	wsi.Width = 40
+
+	ai := textinput.New()
+	ai.Placeholder = "Search the archive..."
+	ai.CharLimit = 100
+	ai.Width = 30
+
+	bi := textinput.New()
+	bi.Placeholder = "Tag name..."
+	bi.CharLimit = 50
+	bi.Width = 40
+
+	tpi := textinput.New()
+	tpi.CharLimit = 200
+	tpi.Width = 50
+
 	di := textinput.New()
-	di.Placeholder = "YYYY-MM-DD (leave empty to clear)"
+	di.Placeholder = "YYYY-MM-DD, +3d, fri (leave empty to clear)"
 	di.CharLimit = 20
 	di.Width = 30
 
+	ui := textinput.New()
+	ui.Placeholder = "https://… (leave empty to remove)"
+	ui.CharLimit = 2048
+	ui.Width = 60
+
+	ki := textinput.New()
+	ki.Placeholder = "ID of the blocking task"
+	ki.CharLimit = 10
+	ki.Width = 30
+
+	ei := textinput.New()
+	ei.Placeholder = "ID of a child task, or ^ID of the epic"
+	ei.CharLimit = 11
+	ei.Width = 40
+
+	vi := textinput.New()
+	vi.Placeholder = "View name, e.g. morning"
+	vi.CharLimit = 40
+	vi.Width = 40
+
+	ri := textinput.New()
+	ri.Placeholder = "e.g. weekly on mon,thu (leave empty to stop repeating)"
+	ri.CharLimit = 60
+	ri.Width = 50
+
+	zi := textinput.New()
+	zi.Placeholder = "YYYY-MM-DD, +3d, fri"
+	zi.CharLimit = 20
+	zi.Width = 30
+
+	fi := textinput.New()
+	fi.Placeholder = "Filter name..."
+	fi.CharLimit = 20
+	fi.Width = 30
+
+	pi := textinput.New()
+	pi.Placeholder = "Type a command..."
+	pi.CharLimit = 60
+	pi.Width = 50
+
+	// Checked with CheckKeys on startup; the defaults stand in for invalid keys
+	keys, _ := applyKeys(opts.Keys, opts.Vim)
+
 	return Model{
-		db:            database,
-		columns:       model.GetAllColumns(),
-		currentColumn: 0,
-		currentTask:   0,
-		scrollOffsets: make([]int, 3), // one per column
-		currentTime:   time.Now(),
-		viewMode:      ViewModeBoard,
-		textInput:     ti,
-		textArea:      ta,
-		searchInput:   si,
-		dueInput:      di,
+		db:              database,
+		workspace:       workspaceName,
+		currentColumn:   0,
+		currentTask:     0,
+		currentTime:     time.Now(),
+		viewMode:        ViewModeBoard,
+		textInput:       ti,
+		textArea:        ta,
+		searchInput:     si,
+		dueInput:        di,
+		linkInput:       ui,
+		dependencyInput: ki,
+		epicInput:       ei,
+		viewInput:       vi,
+		openWithView:    true,
+		recurrenceInput: ri,
+		snoozeInput:     zi,
+		pomodoro:        opts.Pomodoro,
+		remind:          opts.Remind,
+		webhooks:        opts.Webhooks,
+		staleAfter:      opts.StaleAfter,
+		vim:             opts.Vim,
+		filterInput:     fi,
+		paletteInput:    pi,
+		webhookFailing:  map[string]bool{},
+		locked:          opts.ReadOnly,
+		sample:          opts.Sample,
+		restore:         opts.Restore,
+		cache:           newBoardCache(),
+		titleLines:      opts.TitleLines,
+		titleSetting:    opts.TitleLines,
+		labelInput:      li,
+		subtaskInput:    sti,
+		columnInput:     ci,
+		wipInput:        wi,
+		workspaceInput:  wsi,
+		archiveInput:    ai,
+		bulkInput:       bi,
+		templateInput:   tpi,
+		detailViewport:  viewport.New(80, 20),
+		helpViewport:    viewport.New(80, 20),
+		keys:            keys,
 	}
 }
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(m.loadTasks(), clockTickCmd())
+	return tea.Batch(m.loadTasks(), m.openSession(), clockTickCmd())
+}
+
+// loadLabels loads all tag names from the database
+func (m Model) loadLabels() tea.Cmd {
+	return func() tea.Msg {
+		labels, err := m.db.GetLabels()
+		if err != nil {
+			return errMsg{err}
+		}
+		return labelsLoadedMsg{labels}
+	}
 }
 
-// loadTasks loads all tasks from the database
+// loadTasks loads the columns and all tasks from the database
 func (m Model) loadTasks() tea.Cmd {
 	return func() tea.Msg {
+		// Snoozed tasks whose day has come go back on the board first, so
+		// the revision read next includes them
+		woken := 0
+		if !m.readOnly() {
+			var err error
+			if woken, err = m.db.WakeTasks(time.Now()); err != nil {
+				return errMsg{err}
+			}
+		}
+		// Read the revision first so a change made while loading is caught by the next check
+		revision, err := m.db.Revision()
+		if err != nil {
+			return errMsg{err}
+		}
+		columns, err := m.db.GetColumns()
+		if err != nil {
+			return errMsg{err}
+		}
 		tasks, err := m.db.GetAllTasks()
 		if err != nil {
 			return errMsg{err}
 		}
-		return tasksLoadedMsg{tasks}
+		strict, err := m.db.StrictWIP()
+		if err != nil {
+			return errMsg{err}
+		}
+		sorts, err := m.db.SortModes()
+		if err != nil {
+			return errMsg{err}
+		}
+		collapsed, err := m.db.CollapsedColumns()
+		if err != nil {
+			return errMsg{err}
+		}
+		lanes, err := m.db.GetString(db.SettingSwimlanes, "")
+		if err != nil {
+			return errMsg{err}
+		}
+		timer, err := m.db.RunningTimer()
+		if err != nil {
+			return errMsg{err}
+		}
+		welcomed, err := m.db.GetBool(db.SettingWelcomed, true)
+		if err != nil {
+			return errMsg{err}
+		}
+		widths, err := m.db.ColumnWidths()
+		if err != nil {
+			return errMsg{err}
+		}
+		modes := make(columnSorts, len(sorts))
+		for status, name := range sorts {
+			if mode := parseSortMode(name); mode != sortManual {
+				modes[status] = mode
+			}
+		}
+		var view *model.SavedView
+		var state *model.BoardState
+		if m.openWithView {
+			if view, err = m.db.DefaultView(); err != nil {
+				return errMsg{err}
+			}
+			if m.restore {
+				if state, err = m.db.BoardState(); err != nil {
+					return errMsg{err}
+				}
+			}
+		}
+		return tasksLoadedMsg{columns, tasks, strict, modes, collapsed, parseLaneMode(lanes), view, revision, timer, !welcomed, widths, state, woken}
 	}
 }
 
 // Messages
 type tasksLoadedMsg struct {
-	tasks []model.Task
+	columns   []model.Column
+	tasks     []model.Task
+	strictWIP bool
+	sortModes columnSorts
+	collapsed columnSet
+	lanes     laneMode
+	view      *model.SavedView // the default view, when the board opens with it
+	revision  int64
+	timer     *db.TimeEntry // running timer, nil when none runs
+	welcome   bool          // the welcome overlay of the sample board is still to be shown
+	widths    columnWidths
+	state     *model.BoardState // where the board was left, when it opens there
+	woken     int               // snoozed tasks that woke up as the board loaded
 }
 
-type taskCreatedMsg struct {
-	task *model.Task
+type trashLoadedMsg struct {
+	tasks []model.Task
 }
 
-type taskUpdatedMsg struct{}
-
-type taskDeletedMsg struct{}
-
-type descriptionUpdatedMsg struct{}
+type trashUpdatedMsg struct{}
 
-type tagsUpdatedMsg struct{}
+type columnsUpdatedMsg struct {
+	status model.TaskStatus // column to select once reloaded
+}
 
-type dueUpdatedMsg struct{}
+type labelsLoadedMsg struct {
+	labels []string
+}
 
 type clockTickMsg time.Time
 
@@ -137,14 +541,15 @@ type errMsg struct {
 	err error
 }
 
-// maxVisibleTasks is the maximum number of tasks visible per column
-const maxVisibleTasks = 10
-
 // getCurrentTask returns the currently selected task (respecting active filters)
 func (m *Model) getCurrentTask() *model.Task {
 	if len(m.columns) == 0 || m.currentColumn < 0 || m.currentColumn >= len(m.columns) {
 		return nil
 	}
+	// A collapsed column doesn't show the task that would be selected
+	if m.collapsedFocus() {
+		return nil
+	}
 
 	visibleIndices := m.visibleTaskIndices(m.currentColumn)
 	if len(visibleIndices) == 0 || m.currentTask < 0 || m.currentTask >= len(visibleIndices) {
@@ -160,56 +565,50 @@ func (m *Model) getCurrentTask() *model.Task {
 	return &col.Tasks[actualIdx]
 }
 
-// ensureTaskVisible adjusts scroll offset to keep current task visible
-func (m *Model) ensureTaskVisible() {
-	if len(m.columns) == 0 {
-		return
+// organizeTasks lays out the given columns and sorts the tasks into them by status
+func (m *Model) organizeTasks(columns []model.Column, tasks []model.Task) {
+	m.columns = make([]model.Column, len(columns))
+	for i, col := range columns {
+		m.columns[i] = model.Column{Name: col.Name, Status: col.Status, WIPLimit: col.WIPLimit, Done: col.Done, Tasks: []model.Task{}}
 	}
-
-	visibleIndices := m.visibleTaskIndices(m.currentColumn)
-	visibleCount := len(visibleIndices)
-
-	if visibleCount == 0 {
-		m.currentTask = 0
-		m.scrollOffsets[m.currentColumn] = 0
-		return
+	if len(m.scrollOffsets) != len(m.columns) {
+		offsets := make([]int, len(m.columns))
+		copy(offsets, m.scrollOffsets)
+		m.scrollOffsets = offsets
 	}
 
-	if m.currentTask >= visibleCount {
-		m.currentTask = visibleCount - 1
-	}
-	if m.currentTask < 0 {
-		m.currentTask = 0
-	}
-
-	offset := m.scrollOffsets[m.currentColumn]
-	maxOffset := visibleCount - maxVisibleTasks
-	if maxOffset < 0 {
-		maxOffset = 0
-	}
-	if offset > maxOffset {
-		offset = maxOffset
+	// Select a column that was just created or moved
+	if m.followColumn != "" {
+		for i, col := range m.columns {
+			if col.Status == m.followColumn {
+				m.currentColumn = i
+				m.currentTask = 0
+			}
+		}
+		m.followColumn = ""
 	}
-
-	if m.currentTask < offset {
-		offset = m.currentTask
+	if m.currentColumn >= len(m.columns) {
+		m.currentColumn = len(m.columns) - 1
 	}
-	if m.currentTask >= offset+maxVisibleTasks {
-		offset = m.currentTask - maxVisibleTasks + 1
+	if m.currentColumn < 0 {
+		m.currentColumn = 0
 	}
-
-	m.scrollOffsets[m.currentColumn] = offset
-}
-
-// organizeTasks organizes tasks into columns by status
-func (m *Model) organizeTasks(tasks []model.Task) {
-	// Reset all columns
-	for i := range m.columns {
-		m.columns[i].Tasks = []model.Task{}
+	if m.columnHidden(m.currentColumn) {
+		if shown := m.shownColumns(); len(shown) > 0 {
+			m.currentColumn = shown[0]
+			m.currentTask = 0
+		}
 	}
+	m.ensureColumnVisible()
 
-	// Organize tasks by status
+	// Organize tasks by status, keeping snoozed ones off the board
+	m.snoozed = nil
+	now := time.Now()
 	for _, task := range tasks {
+		if task.Snoozed(now) {
+			m.snoozed = append(m.snoozed, task)
+			continue
+		}
 		for i := range m.columns {
 			if m.columns[i].Status == task.Status {
 				m.columns[i].Tasks = append(m.columns[i].Tasks, task)
@@ -218,11 +617,20 @@ func (m *Model) organizeTasks(tasks []model.Task) {
 		}
 	}
 
+	for i := range m.columns {
+		sortTasks(m.columns[i].Tasks, m.sortModes[m.columns[i].Status])
+	}
+	sortSnoozed(m.snoozed)
+	m.clampSnoozedCursor()
+	m.countEpics()
+	m.cache.reloaded(len(tasks))
+
 	// If we're following a task after move, find its position
-	if m.followTaskID != 0 {
+	if m.followTaskID != 0 && len(m.columns) > 0 {
 		found := false
-		for i, task := range m.columns[m.currentColumn].Tasks {
-			if task.ID == m.followTaskID {
+		col := m.columns[m.currentColumn]
+		for i, idx := range m.visibleTaskIndices(m.currentColumn) {
+			if col.Tasks[idx].ID == m.followTaskID {
 				m.currentTask = i
 				found = true
 				break
@@ -239,27 +647,287 @@ func (m *Model) organizeTasks(tasks []model.Task) {
 	m.ensureTaskVisible()
 }
 
+// ensureColumnVisible adjusts the horizontal scroll so the current column is on screen
+func (m *Model) ensureColumnVisible() {
+	// The offset counts the columns the view shows
+	current := max(m.shownPosition(m.currentColumn), 0)
+	if current < m.columnOffset {
+		m.columnOffset = current
+	}
+	for m.columnOffset < current && current >= m.pageEnd(m.columnOffset) {
+		m.columnOffset++
+	}
+	// Don't leave room at the end that columns further left could fill
+	shown := len(m.shownColumns())
+	for m.columnOffset > 0 && m.pageEnd(m.columnOffset-1) >= shown {
+		m.columnOffset--
+	}
+	if m.columnOffset < 0 {
+		m.columnOffset = 0
+	}
+}
+
+// toggleZoom zooms the focused column to full width or back out, keeping
+// the selection in view
+func (m *Model) toggleZoom() {
+	m.zoomed = !m.zoomed
+	m.ensureColumnVisible()
+	m.ensureTaskVisible()
+}
+
+const (
+	// defaultColumnWidth is the on-screen width of a column, border included,
+	// when all columns fit side by side
+	defaultColumnWidth = 32
+	// minColumnWidth is the narrowest a column gets before fewer columns are shown
+	minColumnWidth = 26
+	// narrowWidth is the terminal width below which only the focused column is shown
+	narrowWidth = 60
+)
+
+// boardLayout describes how the columns are placed in the terminal
+type boardLayout struct {
+	perPage int         // columns shown side by side
+	width   int         // on-screen width of each column, border included
+	widths  map[int]int // widths of the columns given one in the settings, by index
+	single  bool        // only the focused column is shown, with a breadcrumb of the others
+}
+
+// widthOf returns the on-screen width of the column at index
+func (l boardLayout) widthOf(index int) int {
+	if width, ok := l.widths[index]; ok {
+		return width
+	}
+	return l.width
+}
+
+// layout fits the columns to the terminal width: all of them when they fit,
+// with the widths the settings give them when those fit too, a page of two
+// or more narrower ones of the same width when they don't, and a single
+// column on narrow terminals or when zoomed
+func (m *Model) layout() boardLayout {
+	width := m.width
+	if width <= 0 {
+		width = 80
+	}
+	shown := m.shownColumns()
+	if m.zoomed && len(shown) > 1 {
+		return boardLayout{perPage: 1, width: width, single: true}
+	}
+	// Collapsed columns take a strip each, leaving the rest to the others
+	n, available := 0, width
+	for _, i := range shown {
+		if m.isCollapsed(i) {
+			available -= collapsedColumnWidth
+		} else {
+			n++
+		}
+	}
+	if widths, ok := m.fitWidths(shown, available); ok {
+		return boardLayout{perPage: n, width: defaultColumnWidth, widths: widths}
+	}
+	switch {
+	case n == 0 || available >= n*defaultColumnWidth:
+		return boardLayout{perPage: n, width: defaultColumnWidth}
+	case available/minColumnWidth >= n:
+		return boardLayout{perPage: n, width: available / n}
+	}
+
+	// Leave room for the scroll indicators on both sides
+	available -= 2 * columnIndicatorWidth
+	perPage := available / minColumnWidth
+	if width < narrowWidth || perPage <= 1 {
+		return boardLayout{perPage: 1, width: width, single: true}
+	}
+	return boardLayout{perPage: perPage, width: available / perPage}
+}
+
+// columnWidths holds the widths the settings give columns by status;
+// columns of the default width aren't in it
+type columnWidths map[model.TaskStatus]db.ColumnWidth
+
+// fitWidths shares out the width available to the expanded columns among
+// them by the settings: a column given a number of cells gets that many, the
+// others split what is left by their share, up to defaultColumnWidth a
+// share. It reports false when no column on the board has a width set, or
+// when one would come out narrower than minColumnWidth, so the board falls
+// back to columns of the same width.
+func (m *Model) fitWidths(shown []int, available int) (map[int]int, bool) {
+	parts, configured := 0, false
+	for _, i := range shown {
+		if m.isCollapsed(i) {
+			continue
+		}
+		width, ok := m.columnWidths[m.columns[i].Status]
+		configured = configured || ok
+		if ok && width.Cells > 0 {
+			available -= max(width.Cells, minColumnWidth)
+		} else {
+			parts += max(width.Parts, 1)
+		}
+	}
+	if !configured || available < 0 {
+		return nil, false
+	}
+	unit := defaultColumnWidth
+	if parts > 0 {
+		unit = min(unit, available/parts)
+	}
+
+	widths := make(map[int]int)
+	for _, i := range shown {
+		if m.isCollapsed(i) {
+			continue
+		}
+		width := m.columnWidths[m.columns[i].Status]
+		if width.Cells > 0 {
+			widths[i] = max(width.Cells, minColumnWidth)
+			continue
+		}
+		if widths[i] = max(width.Parts, 1) * unit; widths[i] < minColumnWidth {
+			return nil, false
+		}
+	}
+	return widths, true
+}
+
+// isDoneStatus reports whether status is a done column, where tasks count as done
+func (m *Model) isDoneStatus(status model.TaskStatus) bool {
+	return model.IsDone(m.columns, status)
+}
+
+// doneColumn returns the index of the column tasks are moved into to be
+// completed, the leftmost done one
+func (m *Model) doneColumn() int {
+	return model.DoneColumn(m.columns)
+}
+
+// hasTag reports whether the task carries the tag (case-insensitive)
+func hasTag(task model.Task, tag string) bool {
+	for _, t := range task.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// pickerItems returns the tag picker entries matching the typed filter text
+func (m Model) pickerItems() []string {
+	query := strings.ToLower(strings.TrimSpace(m.labelInput.Value()))
+	items := make([]string, 0, len(m.labelOptions))
+	for _, label := range m.labelOptions {
+		if query == "" || strings.Contains(label, query) {
+			items = append(items, label)
+		}
+	}
+	return items
+}
+
+// allTasks returns the tasks of every column, snoozed ones too, in their
+// stored order
+func (m *Model) allTasks() []model.Task {
+	tasks := append([]model.Task(nil), m.snoozed...)
+	for _, col := range m.columns {
+		tasks = append(tasks, col.Tasks...)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Position < tasks[j].Position
+	})
+	return tasks
+}
+
 // visibleTaskIndices returns the indices of tasks visible in the given column
-// after applying the current search filter.
-func (m Model) visibleTaskIndices(columnIndex int) []int {
+// after applying the current search filter. The slice is shared: don't
+// change it.
+func (m *Model) visibleTaskIndices(columnIndex int) []int {
 	if columnIndex < 0 || columnIndex >= len(m.columns) {
 		return nil
 	}
 
 	col := m.columns[columnIndex]
-	if m.searchQuery == "" {
-		indices := make([]int, len(col.Tasks))
-		for i := range col.Tasks {
-			indices[i] = i
-		}
-		return indices
+	if len(col.Tasks) == 0 {
+		return []int{}
 	}
+	key := visibleKey{
+		tasks:  &col.Tasks[0],
+		count:  len(col.Tasks),
+		search: m.searchQuery,
+		urgent: m.urgentOnly,
+		label:  m.labelFilter,
+		stale:  m.staleOnly,
+		pinned: m.pinnedOnly,
+		lanes:  m.lanes,
+		minute: m.currentTime.Unix() / 60,
+	}
+	return m.cache.visible(columnIndex, key, func() []int {
+		if m.searchQuery == "" && !m.urgentOnly && m.labelFilter == "" && !m.staleOnly && !m.pinnedOnly {
+			indices := make([]int, len(col.Tasks))
+			for i := range col.Tasks {
+				indices[i] = i
+			}
+			return m.inLanes(col, indices)
+		}
 
-	indices := make([]int, 0, len(col.Tasks))
-	for i, task := range col.Tasks {
-		if m.matchesSearch(task) {
-			indices = append(indices, i)
+		indices := make([]int, 0, len(col.Tasks))
+		for i, task := range col.Tasks {
+			if m.taskVisible(task) {
+				indices = append(indices, i)
+			}
 		}
+		return m.inLanes(col, indices)
+	})
+}
+
+// inLanes orders the visible tasks of a column lane by lane when the board
+// has swimlanes, keeping their order within each lane
+func (m *Model) inLanes(col model.Column, indices []int) []int {
+	if m.lanes != laneNone {
+		sort.SliceStable(indices, func(i, j int) bool { return m.laneLess(col.Tasks[indices[i]], col.Tasks[indices[j]]) })
 	}
 	return indices
 }
+
+// matchCount returns the number of tasks on the board that pass all active filters
+func (m Model) matchCount() int {
+	count := 0
+	for _, col := range m.columns {
+		for _, task := range col.Tasks {
+			if m.taskVisible(task) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// tagCount returns how many tasks on the board have a tag, whatever the
+// other filters
+func (m Model) tagCount(tag string) int {
+	count := 0
+	for _, col := range m.columns {
+		for _, task := range col.Tasks {
+			if hasTag(task, tag) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// taskVisible reports whether a task passes all active filters
+func (m *Model) taskVisible(task model.Task) bool {
+	if m.urgentOnly && task.Priority.Rank() < model.PriorityHigh.Rank() {
+		return false
+	}
+	if m.labelFilter != "" && !hasTag(task, m.labelFilter) {
+		return false
+	}
+	if m.staleOnly && !m.isStale(task) {
+		return false
+	}
+	if m.pinnedOnly && !task.Pinned {
+		return false
+	}
+	return m.matchesSearch(task)
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// doubleClickInterval is the longest gap between two clicks on the same task
+// that still counts as a double-click
+const doubleClickInterval = 400 * time.Millisecond
+
+// handleMouse handles mouse events: clicks select tasks, a double-click opens
+// the task details and the wheel scrolls the column, detail view or help
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	switch m.viewMode {
+	case ViewModeDetail:
+		var cmd tea.Cmd
+		m.detailViewport, cmd = m.detailViewport.Update(msg)
+		return m, cmd
+	case ViewModeHelp:
+		var cmd tea.Cmd
+		m.helpViewport, cmd = m.helpViewport.Update(msg)
+		return m, cmd
+	case ViewModeBoard:
+	default:
+		return m, nil
+	}
+
+	if msg.Action != tea.MouseActionPress || len(m.columns) == 0 {
+		return m, nil
+	}
+
+	colIndex, ok := m.columnAt(msg.X)
+	if !ok {
+		return m, nil
+	}
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.scrollColumn(colIndex, -1)
+		return m, nil
+
+	case tea.MouseButtonWheelDown:
+		m.scrollColumn(colIndex, 1)
+		return m, nil
+
+	case tea.MouseButtonLeft:
+		taskIndex, ok := m.taskAt(colIndex, msg.Y)
+		if !ok {
+			return m, nil
+		}
+		now := time.Now()
+		double := m.lastClickColumn == colIndex && m.lastClickTask == taskIndex &&
+			now.Sub(m.lastClick) <= doubleClickInterval
+		m.currentColumn = colIndex
+		m.currentTask = taskIndex
+		m.ensureColumnVisible()
+		m.ensureTaskVisible()
+		if double {
+			m.lastClick = time.Time{}
+			return m, m.openDetail()
+		}
+		m.lastClick = now
+		m.lastClickColumn = colIndex
+		m.lastClickTask = taskIndex
+	}
+
+	return m, nil
+}
+
+// columnAt returns the index of the on-screen column at the given x position
+func (m Model) columnAt(x int) (int, bool) {
+	if m.columnOffset > 0 && !m.layout().single {
+		x -= columnIndicatorWidth
+	}
+	if x < 0 {
+		return 0, false
+	}
+	shown := m.shownColumns()
+	end := m.pageEnd(m.columnOffset)
+	for _, i := range shown[min(m.columnOffset, end):end] {
+		width := m.columnWidth(i)
+		if x < width {
+			return i, true
+		}
+		x -= width
+	}
+	return 0, false
+}
+
+// taskAt returns the visible task index of the card at screen row y in a
+// column, following the layout of renderColumn
+func (m Model) taskAt(colIndex, y int) (int, bool) {
+	if colIndex == m.currentColumn && m.collapsedFocus() {
+		return 0, false
+	}
+	col := m.columns[colIndex]
+	visible := m.visibleTaskIndices(colIndex)
+	offset := m.scrollOffsets[colIndex]
+	if offset >= len(visible) {
+		offset = 0
+	}
+
+	// Border and top padding, then the title and the "more above" marker
+	row := m.viewport.YPosition + 2 + lipgloss.Height(m.renderColumnTitle(colIndex, col))
+	if m.layout().single && len(m.columns) > 1 {
+		row++ // breadcrumb
+	}
+	if m.lanes != laneNone {
+		return m.laneTaskAt(colIndex, y-row)
+	}
+	if offset > 0 {
+		row++
+	}
+	end := m.visibleEnd(colIndex, visible, offset)
+	for i := offset; i < end; i++ {
+		isActive := colIndex == m.currentColumn && i == m.currentTask
+		height := lipgloss.Height(m.renderTask(colIndex, col.Tasks[visible[i]], isActive))
+		if y >= row && y < row+height {
+			return i, true
+		}
+		row += height
+	}
+	return 0, false
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// Ways of announcing the end of a pomodoro or a break, and tasks that
+// become due
+const (
+	NotifyBell   = "bell"        // ring the terminal bell
+	NotifyOSC    = "osc"         // desktop notification through OSC 9, understood by e.g. iTerm2, kitty and Windows Terminal
+	NotifyOSC777 = "osc777"      // desktop notification through OSC 777, understood by e.g. foot, Ghostty, rxvt-unicode and WezTerm
+	NotifySend   = "notify-send" // desktop notification through notify-send, or the bell when it isn't installed
+	NotifyNone   = "none"
+)
+
+// notifyMethods lists the accepted notification methods
+var notifyMethods = []string{NotifyBell, NotifyOSC, NotifyOSC777, NotifySend, NotifyNone}
+
+// ParseNotify checks a notification method read from the config. Empty
+// means NotifyBell.
+func ParseNotify(value string) (string, error) {
+	method := strings.ToLower(strings.TrimSpace(value))
+	if method == "" {
+		return NotifyBell, nil
+	}
+	for _, m := range notifyMethods {
+		if method == m {
+			return method, nil
+		}
+	}
+	return NotifyBell, fmt.Errorf("invalid notify %q: use %s", value, strings.Join(notifyMethods, ", "))
+}
+
+// announce alerts the user with text in the way notify selects
+func announce(notify, text string) {
+	if text == "" {
+		return
+	}
+	// Control characters would end an escape sequence early
+	text = strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, text)
+
+	switch notify {
+	case NotifyBell:
+		fmt.Fprint(os.Stdout, "\a")
+	case NotifyOSC:
+		fmt.Fprintf(os.Stdout, "\x1b]9;cli_kanban: %s\x07", text)
+	case NotifyOSC777:
+		fmt.Fprintf(os.Stdout, "\x1b]777;notify;cli_kanban;%s\x07", text)
+	case NotifySend:
+		if err := exec.Command("notify-send", "cli_kanban", text).Run(); err != nil {
+			fmt.Fprint(os.Stdout, "\a")
+		}
+	}
+}
+
+// dueCheckInterval is how often the board looks for tasks that became due
+const dueCheckInterval = time.Minute
+
+// remindersMsg carries the tasks that became due since the last check
+type remindersMsg struct {
+	tasks []model.Task
+}
+
+// checkReminders takes the due tasks not announced yet and announces them
+func (m Model) checkReminders() tea.Cmd {
+	notify, now := m.remind, m.currentTime
+	return func() tea.Msg {
+		tasks, err := m.db.TakeDueReminders(now)
+		if err != nil {
+			return errMsg{err}
+		}
+		if len(tasks) > 0 {
+			announce(notify, reminderText(tasks, now))
+		}
+		return remindersMsg{tasks}
+	}
+}
+
+// remindersDue returns the reminder check when the last one is
+// dueCheckInterval old, or nil. A read-only board leaves the reminders to
+// the TUI holding the workspace.
+func (m *Model) remindersDue() tea.Cmd {
+	if m.columns == nil || m.readOnly() || m.currentTime.Sub(m.lastDueCheck) < dueCheckInterval {
+		return nil
+	}
+	m.lastDueCheck = m.currentTime
+	return m.checkReminders()
+}
+
+// reminderText describes the tasks that became due, e.g. `"Fix login bug" is
+// due today` or `3 tasks are due: "Fix login bug" and 2 more`
+func reminderText(tasks []model.Task, now time.Time) string {
+	first := tasks[0]
+	title := fmt.Sprintf("%q", truncateText(first.Title, 40))
+	if len(tasks) > 1 {
+		return fmt.Sprintf("%d tasks are due: %s and %d more", len(tasks), title, len(tasks)-1)
+	}
+	if dates.Day(*first.Due, now.Location()).Before(dates.StartOfDay(now)) {
+		return title + " is overdue"
+	}
+	return title + " is due today"
+}
@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// sampleTag tags the example tasks of a sample board
+const sampleTag = "getting-started"
+
+// SeedSample fills a workspace just created with example tasks that name
+// the keys as the config binds them, and marks its welcome overlay to be
+// shown. A workspace with tasks is left alone.
+func SeedSample(database *db.DB, opts Options) error {
+	keys, _ := applyKeys(opts.Keys, opts.Vim)
+	return seedSample(database, keys)
+}
+
+// seedSample fills a new workspace with the example tasks for a keymap
+func seedSample(database *db.DB, keys keyMap) error {
+	columns, err := database.GetColumns()
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	return database.SeedSampleBoard(sampleTasks(keys, columns))
+}
+
+// sampleTasks returns the example tasks of a sample board: most in the
+// first column, one in the second and one in the last
+func sampleTasks(k keyMap, columns []model.Column) []model.Task {
+	first, last := columns[0].Status, columns[model.DoneColumn(columns)].Status
+	middle := first
+	if len(columns) > 2 {
+		middle = columns[1].Status
+	}
+	tags := []string{sampleTag}
+	return []model.Task{
+		{
+			Title:       pressTo(k.Add, "add a task to this column"),
+			Description: "New tasks go on top of the focused column. These examples only show you around: delete them whenever you like.",
+			Status:      first,
+			Priority:    model.PriorityMedium,
+			Tags:        tags,
+		},
+		{
+			Title:       pressTo(k.Move, "move me to the next column"),
+			Description: "The arrow keys pick another column or task. " + pressTo(k.MoveTaskUp, "move a task up its column") + ".",
+			Status:      first,
+			Tags:        tags,
+		},
+		{
+			Title:       pressTo(k.Details, "see my details and checklist"),
+			Description: "Descriptions are **markdown**. " + pressTo(k.Description, "edit this one") + ".",
+			Status:      first,
+			Priority:    model.PriorityHigh,
+			Tags:        tags,
+			Subtasks: []model.Subtask{
+				{Title: pressTo(k.ToggleSubtask, "tick me off")},
+				{Title: pressTo(k.AddSubtask, "add an item")},
+			},
+		},
+		{
+			Title:       pressTo(k.Help, "see every key"),
+			Description: pressTo(k.Search, "search the board") + ". " + pressTo(k.Palette, "find any action by name") + ".",
+			Status:      middle,
+			Tags:        tags,
+		},
+		{
+			Title:       pressTo(k.Delete, "move me to the trash"),
+			Description: pressTo(k.Undo, "bring me back") + ".",
+			Status:      last,
+			Tags:        tags,
+		},
+	}
+}
+
+// pressTo is a sample text asking to press the first key of a binding, or
+// to use the command palette when the config disabled the binding
+func pressTo(b key.Binding, action string) string {
+	if !b.Enabled() {
+		return "Use the command palette to " + action
+	}
+	first, _, _ := strings.Cut(b.Help().Key, " / ")
+	return "Press " + first + " to " + action
+}
+
+// dismissWelcome closes the welcome overlay for good
+func (m Model) dismissWelcome() (tea.Model, tea.Cmd) {
+	m.viewMode = ViewModeBoard
+	// A read-only board shows it again next time
+	if m.readOnly() {
+		return m, nil
+	}
+	return m, func() tea.Msg {
+		if err := m.db.SetBool(db.SettingWelcomed, true); err != nil {
+			return errMsg{err}
+		}
+		return nil
+	}
+}
+
+// viewWelcome renders the welcome overlay of a sample board: the core keys
+// in a box over the middle of the screen
+func (m Model) viewWelcome() string {
+	keyStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	descStyle := lipgloss.NewStyle().Foreground(colorText)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("👋 Welcome to cli_kanban"))
+	b.WriteString("\n")
+	b.WriteString(descStyle.Render("The board holds a few example tasks to try these on:"))
+	b.WriteString("\n\n")
+	for _, c := range m.keys.coreKeys() {
+		if !c.binding.Enabled() {
+			continue
+		}
+		b.WriteString(keyStyle.Render("  " + padRight(c.binding.Help().Key, 8)))
+		b.WriteString(descStyle.Render(c.binding.Help().Desc))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("Press any key to start"))
+
+	box := inputStyle.Copy().Width(0).Render(b.String())
+	width, height := m.width, m.height
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
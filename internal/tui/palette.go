@@ -0,0 +1,379 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/export"
+)
+
+const (
+	// paletteRows is the number of matching commands the palette lists
+	paletteRows = 12
+	// maxRecentCommands is the number of palette commands remembered as
+	// recently used
+	maxRecentCommands = 10
+)
+
+// paletteCommand is an action of the command palette
+type paletteCommand struct {
+	name string                             // what the palette shows and matches, e.g. "Set due date"
+	keys string                             // the key that does the same on the board, or ""
+	run  func(m Model) (tea.Model, tea.Cmd) // runs the action on the board
+}
+
+// paletteActions names the board actions the palette offers, by the action
+// name of their binding
+var paletteActions = []struct {
+	name   string
+	action string
+}{
+	{"New task", "newTask"},
+	{"Open task details", "openTask"},
+	{"Edit title", "editTitle"},
+	{"Edit description", "editDescription"},
+	{"Edit description in $EDITOR", "externalEditor"},
+	{"Edit tags", "editTags"},
+	{"Set due date", "editDue"},
+	{"Set recurrence", "editRecurrence"},
+	{"Set link", "editLink"},
+	{"Open link in the browser", "openLink"},
+	{"Edit dependencies (blocked by / blocking)", "dependencies"},
+	{"Edit epic and children", "epic"},
+	{"Start or stop the timer", "toggleTimer"},
+	{"Start or stop a pomodoro", "togglePomodoro"},
+	{"Cycle priority", "cyclePriority"},
+	{"Pin or unpin task", "pin"},
+	{"Delete task", "deleteTask"},
+	{"Move task to the next column", "moveTaskRight"},
+	{"Move task to another workspace", "moveTaskToWorkspace"},
+	{"Move task up", "moveTaskUp"},
+	{"Move task down", "moveTaskDown"},
+	{"Undo", "undo"},
+	{"Redo", "redo"},
+	{"Save task as template", "saveTemplate"},
+	{"Select task", "selectTask"},
+	{"Copy title", "copyTitle"},
+	{"Copy task as markdown", "copyMarkdown"},
+	{"Paste tasks from the clipboard", "paste"},
+	{"Archive task", "archiveTask"},
+	{"Archive column", "archiveColumn"},
+	{"Snooze task", "snooze"},
+	{"Search", "search"},
+	{"Show only high and urgent tasks", "urgentOnly"},
+	{"Filter by tag", "filterTag"},
+	{"Filter by a tag of the task", "filterTaskTag"},
+	{"Pick a filter", "filter"},
+	{"Sort the column (manual, priority, due, created, title)", "sort"},
+	{"Saved views", "views"},
+	{"Board settings", "boardSettings"},
+	{"Add column", "addColumn"},
+	{"Rename column", "renameColumn"},
+	{"Delete column", "deleteColumn"},
+	{"Move column left", "moveColumnLeft"},
+	{"Move column right", "moveColumnRight"},
+	{"Set WIP limit", "wipLimit"},
+	{"Zoom column", "zoom"},
+	{"Collapse or expand column", "collapseColumn"},
+	{"Swimlanes (by tag, by priority, off)", "swimlanes"},
+	{"Wrap or cut long task titles", "wrapTitles"},
+	{"Open trash", "trash"},
+	{"Open archive", "archive"},
+	{"Open snoozed tasks", "snoozed"},
+	{"Open dashboard", "dashboard"},
+	{"Open calendar", "calendar"},
+	{"Open today's agenda", "agenda"},
+	{"Switch workspace", "switchWorkspace"},
+	{"Refresh", "refresh"},
+	{"Show key bindings", "help"},
+	{"Quit", "quit"},
+}
+
+// paletteCommands returns every command of the palette: the board actions,
+// then moving the task to and focusing each column, the themes and the
+// markdown export
+func (m Model) paletteCommands() []paletteCommand {
+	current := make(map[string]*key.Binding)
+	for _, a := range m.keys.actions() {
+		current[a.name] = a.binding
+	}
+
+	var commands []paletteCommand
+	for _, a := range paletteActions {
+		action := a.action
+		keys := ""
+		if b := current[action]; b.Enabled() {
+			keys = b.Help().Key
+		}
+		commands = append(commands, paletteCommand{a.name, keys, func(m Model) (tea.Model, tea.Cmd) {
+			return m.runAction(action)
+		}})
+	}
+	commands = append(commands, paletteCommand{"Duplicate task", m.keys.Duplicate.Help().Key, func(m Model) (tea.Model, tea.Cmd) {
+		if task := m.getCurrentTask(); task != nil {
+			return m, m.duplicateTask(task)
+		}
+		return m, nil
+	}})
+
+	for i, col := range m.columns {
+		if i == m.currentColumn {
+			continue
+		}
+		i := i
+		commands = append(commands, paletteCommand{"Move task to " + col.Name, "", func(m Model) (tea.Model, tea.Cmd) {
+			return m.startMove(i)
+		}})
+	}
+	for i, col := range m.columns {
+		i := i
+		keys := ""
+		if columnKeys := m.keys.Column.Keys(); i < len(columnKeys) {
+			keys = columnKeys[i]
+		}
+		commands = append(commands, paletteCommand{"Go to column " + col.Name, keys, func(m Model) (tea.Model, tea.Cmd) {
+			m.jumpToColumn(i)
+			return m, nil
+		}})
+	}
+	for _, name := range ThemeNames() {
+		name := name
+		commands = append(commands, paletteCommand{"Switch theme to " + name, "", func(m Model) (tea.Model, tea.Cmd) {
+			applyTheme(builtinThemes[name])
+			m.showNotice("theme " + name + " (until the board closes)")
+			return m, nil
+		}})
+	}
+	commands = append(commands, paletteCommand{"Export board as markdown to the clipboard", "", func(m Model) (tea.Model, tea.Cmd) {
+		var buf bytes.Buffer
+		if err := export.WriteMarkdown(&buf, export.NewDocument(m.workspace, m.columns, m.currentTime)); err != nil {
+			m.err = err
+			return m, nil
+		}
+		return m, copyToClipboard("board as markdown", buf.String())
+	}})
+	return commands
+}
+
+// runAction does what the action's built-in key does on the board, even
+// when the config moved or disabled that key
+func (m Model) runAction(action string) (tea.Model, tea.Cmd) {
+	defaults := defaultKeyMap()
+	for _, a := range defaults.actions() {
+		if a.name != action {
+			continue
+		}
+		keys := m.keys
+		m.keys = defaults
+		next, cmd := m.handleKeyPress(keyMsg(a.binding.Keys()[0]))
+		done := next.(Model)
+		done.keys = keys
+		return done, cmd
+	}
+	return m, nil
+}
+
+// keyMsg returns the key press Bubble Tea reports as k, e.g. "x", "enter"
+// or "ctrl+t"
+func keyMsg(k string) tea.KeyMsg {
+	for t := tea.KeyType(-128); t < 128; t++ {
+		if t != tea.KeyRunes && (tea.Key{Type: t}).String() == k {
+			msg := tea.KeyMsg{Type: t}
+			if t == tea.KeySpace {
+				msg.Runes = []rune{' '}
+			}
+			return msg
+		}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+// openPalette opens the command palette with an empty query
+func (m *Model) openPalette() {
+	m.viewMode = ViewModePalette
+	m.paletteCursor = 0
+	m.paletteInput.SetValue("")
+	m.paletteInput.Focus()
+	m.err = nil
+}
+
+// paletteMatches returns the commands matching the typed query, best first.
+// Recently used commands come first while nothing is typed, and get ahead
+// of equally good matches otherwise; a task ID, e.g. "go 42", puts going to
+// that task on top.
+func (m Model) paletteMatches() []paletteCommand {
+	query := strings.ToLower(strings.TrimSpace(m.paletteInput.Value()))
+	recent := make(map[string]int, len(m.paletteRecent))
+	for i, name := range m.paletteRecent {
+		recent[name] = len(m.paletteRecent) - i
+	}
+
+	type match struct {
+		command paletteCommand
+		score   int
+	}
+	var matches []match
+	for _, c := range m.paletteCommands() {
+		score, ok := fuzzyScore(query, strings.ToLower(c.name))
+		if !ok {
+			continue
+		}
+		matches = append(matches, match{c, score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return recent[matches[i].command.name] > recent[matches[j].command.name]
+	})
+
+	var commands []paletteCommand
+	if id, ok := goToQuery(query); ok {
+		commands = append(commands, paletteCommand{fmt.Sprintf("Go to task #%d", id), m.keys.GoTo.Help().Key, func(m Model) (tea.Model, tea.Cmd) {
+			m.goToTask(id)
+			return m, nil
+		}})
+	}
+	for _, mt := range matches {
+		commands = append(commands, mt.command)
+	}
+	return commands
+}
+
+// goToQuery reads a query like "go 42", "#42" or "42" as the ID of a task
+// to go to
+func goToQuery(query string) (int64, bool) {
+	query = strings.TrimSpace(strings.TrimPrefix(query, "go"))
+	id, err := strconv.ParseInt(strings.TrimPrefix(query, "#"), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// fuzzyScore reports whether the letters of query appear in name in order,
+// and how well: runs of consecutive letters and letters that start a word
+// score higher. An empty query matches everything equally.
+func fuzzyScore(query, name string) (int, bool) {
+	score := 0
+	prev := -2
+	start := 0
+	for _, q := range query {
+		if unicode.IsSpace(q) {
+			continue
+		}
+		i := strings.IndexRune(name[start:], q)
+		if i < 0 {
+			return 0, false
+		}
+		i += start
+		score++
+		if i == prev+1 {
+			score += 3
+		}
+		if i == 0 || name[i-1] == ' ' {
+			score += 5
+		}
+		prev = i
+		start = i + utf8.RuneLen(q)
+	}
+	return score, true
+}
+
+// rememberCommand moves a command to the front of the recently used ones
+func (m *Model) rememberCommand(name string) {
+	recent := []string{name}
+	for _, r := range m.paletteRecent {
+		if r != name && len(recent) < maxRecentCommands {
+			recent = append(recent, r)
+		}
+	}
+	m.paletteRecent = recent
+}
+
+// handlePaletteKeys handles keyboard input in the command palette: typing
+// narrows the commands, up and down pick one and Enter runs it on the board
+func (m Model) handlePaletteKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "down":
+		count := len(m.paletteMatches())
+		if count == 0 {
+			return m, nil
+		}
+		if msg.String() == "up" {
+			m.paletteCursor--
+		} else {
+			m.paletteCursor++
+		}
+		m.paletteCursor = (m.paletteCursor + count) % count
+		return m, nil
+
+	case "enter":
+		matches := m.paletteMatches()
+		if len(matches) == 0 {
+			m.err = fmt.Errorf("no command matches %q", strings.TrimSpace(m.paletteInput.Value()))
+			return m, nil
+		}
+		command := matches[min(m.paletteCursor, len(matches)-1)]
+		m.rememberCommand(command.name)
+		m.viewMode = ViewModeBoard
+		m.paletteInput.SetValue("")
+		m.err = nil
+		return command.run(m)
+	}
+
+	var cmd tea.Cmd
+	m.paletteInput, cmd = m.paletteInput.Update(msg)
+	m.paletteCursor = 0
+	return m, cmd
+}
+
+// viewPalette renders the command palette
+func (m Model) viewPalette() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(": Command Palette"))
+	b.WriteString("\n\n")
+	b.WriteString(inputStyle.Render(m.paletteInput.View()))
+	b.WriteString("\n\n")
+
+	matches := m.paletteMatches()
+	if len(matches) == 0 {
+		b.WriteString(helpStyle.Render("No matching command"))
+		b.WriteString("\n")
+	}
+	cursor := min(m.paletteCursor, max(len(matches)-1, 0))
+	first := max(0, min(cursor-paletteRows+1, len(matches)-paletteRows))
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	normalStyle := lipgloss.NewStyle().Foreground(colorText)
+	for i := first; i < len(matches) && i < first+paletteRows; i++ {
+		c := matches[i]
+		line := padRight(truncateText(c.name, 44), 44)
+		keys := helpStyle.Render(c.keys)
+		if i == cursor {
+			b.WriteString(selectedStyle.Render("▸ "+line) + " " + keys)
+		} else {
+			b.WriteString(normalStyle.Render("  "+line) + " " + keys)
+		}
+		b.WriteString("\n")
+	}
+	if len(matches) > paletteRows {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  … %d of %d commands", paletteRows, len(matches))))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(helpStyle.Render("Type to find | ↑/↓: Pick | Enter: Run | Esc: Cancel"))
+
+	return b.String()
+}
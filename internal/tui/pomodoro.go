@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// Pomodoro lengths used unless the config sets others
+const (
+	DefaultPomodoroWork  = 25 * time.Minute
+	DefaultPomodoroBreak = 5 * time.Minute
+)
+
+// Pomodoro holds the settings of focus mode
+type Pomodoro struct {
+	Work   time.Duration
+	Break  time.Duration
+	Notify string
+}
+
+// DefaultPomodoro returns the built-in focus mode settings: 25 minutes of
+// work, a 5 minute break and the terminal bell
+func DefaultPomodoro() Pomodoro {
+	return Pomodoro{Work: DefaultPomodoroWork, Break: DefaultPomodoroBreak, Notify: NotifyBell}
+}
+
+// NewPomodoro builds the focus mode settings from config values such as
+// "25m"; empty values keep the defaults. Invalid values are reported in the
+// returned error and replaced by their default.
+func NewPomodoro(work, brk, notify string) (Pomodoro, error) {
+	p := DefaultPomodoro()
+	var errs []error
+	parse := func(name, value string, into *time.Duration) {
+		if strings.TrimSpace(value) == "" {
+			return
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d < time.Minute {
+			errs = append(errs, fmt.Errorf("invalid pomodoro %s %q: use a duration of at least 1m, e.g. 25m", name, value))
+			return
+		}
+		*into = d
+	}
+	parse("work", work, &p.Work)
+	parse("break", brk, &p.Break)
+
+	var err error
+	if p.Notify, err = ParseNotify(notify); err != nil {
+		errs = append(errs, fmt.Errorf("pomodoro: %w", err))
+	}
+	return p, errors.Join(errs...)
+}
+
+// focus is a running pomodoro cycle on a task
+type focus struct {
+	taskID  int64
+	title   string
+	onBreak bool
+	ends    time.Time // end of the current pomodoro or break
+	done    int       // pomodoros completed so far
+}
+
+// pomodoroMsg reports a focus mode change that was stored
+type pomodoroMsg struct {
+	timer  *db.TimeEntry // the running timer, nil during a break or once stopped
+	notice string
+	err    error
+}
+
+// togglePomodoro stops focus mode when it runs, and otherwise starts it on
+// task. A pomodoro runs the task's timer, so it is logged as a time entry.
+func (m Model) togglePomodoro(task *model.Task) (tea.Model, tea.Cmd) {
+	if f := m.focus; f != nil {
+		m.focus = nil
+		notice := fmt.Sprintf("focus stopped after %d pomodoro(s)", f.done)
+		if f.onBreak {
+			return m, func() tea.Msg { return pomodoroMsg{notice: notice} }
+		}
+		return m, m.stopPomodoroTimer(notice, "")
+	}
+	if task == nil {
+		return m, nil
+	}
+	m.focus = &focus{taskID: task.ID, title: task.Title, ends: m.currentTime.Add(m.pomodoro.Work)}
+	return m, m.startPomodoroTimer(task.ID, fmt.Sprintf("focus on %q for %s", truncateText(task.Title, 30), dates.HoursMinutes(m.pomodoro.Work)), "")
+}
+
+// advancePomodoro moves focus mode to the break or the next pomodoro once
+// the current one has ended
+func (m *Model) advancePomodoro() tea.Cmd {
+	f := m.focus
+	if f == nil || m.currentTime.Before(f.ends) {
+		return nil
+	}
+	if f.onBreak {
+		f.onBreak = false
+		f.ends = m.currentTime.Add(m.pomodoro.Work)
+		title := truncateText(f.title, 30)
+		return m.startPomodoroTimer(f.taskID, fmt.Sprintf("break over, back to %q", title), fmt.Sprintf("Break over: back to %q", title))
+	}
+	f.done++
+	f.onBreak = true
+	f.ends = m.currentTime.Add(m.pomodoro.Break)
+	brk := dates.HoursMinutes(m.pomodoro.Break)
+	return m.stopPomodoroTimer(fmt.Sprintf("pomodoro %d done, take a %s break", f.done, brk), fmt.Sprintf("Pomodoro %d done: take a %s break", f.done, brk))
+}
+
+// startPomodoroTimer starts the timer of a pomodoro, announcing alert when set
+func (m Model) startPomodoroTimer(taskID int64, notice, alert string) tea.Cmd {
+	notify := m.pomodoro.Notify
+	return func() tea.Msg {
+		timer, err := m.db.StartTimer(taskID)
+		if err != nil {
+			return pomodoroMsg{err: fmt.Errorf("focus stopped: %w", err)}
+		}
+		announce(notify, alert)
+		return pomodoroMsg{timer: timer, notice: notice}
+	}
+}
+
+// stopPomodoroTimer stops the timer of a pomodoro, logging its time, and
+// announces alert when set
+func (m Model) stopPomodoroTimer(notice, alert string) tea.Cmd {
+	notify := m.pomodoro.Notify
+	return func() tea.Msg {
+		if _, err := m.db.StopTimer(); err != nil {
+			return pomodoroMsg{err: err}
+		}
+		announce(notify, alert)
+		return pomodoroMsg{notice: notice}
+	}
+}
+
+// endFocus stops focus mode right away, logging the part of the pomodoro
+// done so far. It runs before the database is closed.
+func (m *Model) endFocus() error {
+	f := m.focus
+	m.focus = nil
+	if f == nil || f.onBreak || m.db == nil {
+		return nil
+	}
+	m.timer = nil
+	_, err := m.db.StopTimer()
+	return err
+}
+
+// renderFocus renders the pomodoro countdown for the status bar
+func (m Model) renderFocus() string {
+	f := m.focus
+	left := f.ends.Sub(m.currentTime)
+	if left < 0 {
+		left = 0
+	}
+	s := int((left + time.Second - 1) / time.Second)
+	countdown := fmt.Sprintf("%d:%02d", s/60, s%60)
+
+	text := fmt.Sprintf("🍅 %s %s", truncateText(f.title, 24), countdown)
+	if f.onBreak {
+		text = "☕ break " + countdown
+	}
+	if f.done > 0 {
+		text += fmt.Sprintf(" · %d done", f.done)
+	}
+	return lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render(text)
+}
@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// recurrencePresets are the rules offered by the recurrence picker; any
+// other rule can be typed in
+var recurrencePresets = []string{"", "daily", "weekdays", "weekly", "every 2 weeks", "monthly"}
+
+// openRecurrencePicker opens the recurrence picker on the task's rule
+func (m *Model) openRecurrencePicker(task *model.Task) {
+	m.viewMode = ViewModeEditRecurrence
+	m.recurrenceInput.SetValue(task.Recurrence)
+	m.recurrenceInput.CursorEnd()
+	m.recurrenceInput.Focus()
+	m.recurrenceCursor = -1
+	for i, preset := range recurrencePresets {
+		if preset == task.Recurrence {
+			m.recurrenceCursor = i
+		}
+	}
+}
+
+// handleEditRecurrenceKeys handles keyboard input in the recurrence picker:
+// up and down pick a preset, anything else edits the rule
+func (m Model) handleEditRecurrenceKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "down":
+		if msg.String() == "up" {
+			m.recurrenceCursor--
+		} else {
+			m.recurrenceCursor++
+		}
+		m.recurrenceCursor = (m.recurrenceCursor + len(recurrencePresets)) % len(recurrencePresets)
+		m.recurrenceInput.SetValue(recurrencePresets[m.recurrenceCursor])
+		m.recurrenceInput.CursorEnd()
+		return m, nil
+
+	case "enter":
+		task := m.getCurrentTask()
+		if task == nil {
+			m.viewMode = ViewModeBoard
+			return m, nil
+		}
+		rule, err := dates.ParseRecurrence(m.recurrenceInput.Value())
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.viewMode = ViewModeBoard
+		m.recurrenceInput.SetValue("")
+		m.err = nil
+		if rule.String() == task.Recurrence {
+			return m, nil
+		}
+		return m, m.updateRecurrence(task, rule.String())
+
+	case "esc":
+		m.viewMode = ViewModeBoard
+		m.recurrenceInput.SetValue("")
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.recurrenceInput, cmd = m.recurrenceInput.Update(msg)
+	m.recurrenceCursor = -1
+	for i, preset := range recurrencePresets {
+		if preset == strings.TrimSpace(m.recurrenceInput.Value()) {
+			m.recurrenceCursor = i
+		}
+	}
+	return m, cmd
+}
+
+// updateRecurrence sets how a task repeats
+func (m Model) updateRecurrence(task *model.Task, rule string) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.UpdateTaskRecurrence(task.ID, rule)
+	})
+}
+
+// viewEditRecurrence renders the recurrence picker
+func (m Model) viewEditRecurrence() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("↻ Repeat Task"))
+	b.WriteString("\n\n")
+
+	task := m.getCurrentTask()
+	if task != nil {
+		info := fmt.Sprintf("Task: %s", task.Title)
+		b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(info))
+		b.WriteString("\n\n")
+	}
+
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	normalStyle := lipgloss.NewStyle().Foreground(colorText)
+	for i, preset := range recurrencePresets {
+		label := "Doesn't repeat"
+		if rule, err := dates.ParseRecurrence(preset); err == nil && !rule.IsZero() {
+			label = rule.Describe()
+		}
+		if i == m.recurrenceCursor {
+			b.WriteString(selectedStyle.Render("▸ " + label))
+		} else {
+			b.WriteString(normalStyle.Render("  " + label))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	hint := "When the task is moved to the done column, a copy due on the next date is added to the first column.\nOther rules: every 3 days, weekly on mon,thu, monthly on 15"
+	b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render(hint))
+	b.WriteString("\n\n")
+
+	b.WriteString(inputStyle.Render(m.recurrenceInput.View()))
+	b.WriteString("\n\n")
+
+	b.WriteString(helpStyle.Render("↑/↓: Pick | Enter: Save | Esc: Cancel"))
+
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// refreshInterval is how often the board checks for changes made by
+	// other processes
+	refreshInterval = 2 * time.Second
+	// noticeDuration is how long a notice stays in the footer
+	noticeDuration = 3 * time.Second
+)
+
+// revisionCheckedMsg carries the database revision found by a refresh check
+type revisionCheckedMsg struct {
+	revision int64
+}
+
+// checkRevision reads the database revision
+func (m Model) checkRevision() tea.Cmd {
+	return func() tea.Msg {
+		revision, err := m.db.Revision()
+		if err != nil {
+			return errMsg{err}
+		}
+		return revisionCheckedMsg{revision}
+	}
+}
+
+// refreshDue returns the revision check when the last one is refreshInterval
+// old, or nil. Only the board, the detail view, the dashboard, the
+// calendar and the agenda refresh; other modes are editing something and pick up changes
+// once they return.
+func (m *Model) refreshDue() tea.Cmd {
+	if m.viewMode != ViewModeBoard && m.viewMode != ViewModeDetail && m.viewMode != ViewModeDashboard && m.viewMode != ViewModeCalendar && m.viewMode != ViewModeAgenda {
+		return nil
+	}
+	if m.columns == nil || m.currentTime.Sub(m.lastRefreshCheck) < refreshInterval {
+		return nil
+	}
+	m.lastRefreshCheck = m.currentTime
+	return m.checkRevision()
+}
+
+// refreshBoard reloads the board after another process changed it, keeping
+// the selected task
+func (m *Model) refreshBoard() tea.Cmd {
+	if task := m.getCurrentTask(); task != nil {
+		m.followTaskID = task.ID
+	}
+	m.showNotice("board updated")
+	return m.loadTasks()
+}
+
+// showNotice flashes a message in the footer of the board and the task views
+func (m *Model) showNotice(text string) {
+	m.notice = text
+	m.noticeAt = m.currentTime
+}
+
+// renderNotice renders the current notice, or "" once it expired
+func (m Model) renderNotice() string {
+	if m.notice == "" || m.currentTime.Sub(m.noticeAt) >= noticeDuration {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render(m.notice)
+}
@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"reflect"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// boardState returns where the board is, to open there next time. The
+// column sorts are left out: they are saved as settings when they change.
+func (m *Model) boardState() model.BoardState {
+	state := model.BoardState{View: m.currentView(m.activeView), Zoomed: m.zoomed}
+	state.View.Sorts = nil
+	if m.currentColumn < len(m.columns) {
+		state.Column = m.columns[m.currentColumn].Status
+	}
+	if task := m.getCurrentTask(); task != nil {
+		state.TaskID = task.ID
+	}
+	return state
+}
+
+// keepsState reports whether the board saves where it is: not when it is
+// read-only, and not before its tasks are loaded
+func (m *Model) keepsState() bool {
+	return m.restore && m.db != nil && !m.readOnly() && m.columns != nil
+}
+
+// boardStateDue returns the command saving where the board is when that
+// changed since it was last saved, or nil
+func (m *Model) boardStateDue() tea.Cmd {
+	if !m.keepsState() {
+		return nil
+	}
+	state := m.boardState()
+	if m.savedState != nil && reflect.DeepEqual(*m.savedState, state) {
+		return nil
+	}
+	m.savedState = &state
+	database := m.db
+	return func() tea.Msg {
+		if err := database.SaveBoardState(state); err != nil {
+			return errMsg{err}
+		}
+		return nil
+	}
+}
+
+// saveBoardState saves where the board is right away, for when the TUI
+// quits or leaves the workspace
+func (m *Model) saveBoardState() error {
+	if !m.keepsState() {
+		return nil
+	}
+	state := m.boardState()
+	if m.savedState != nil && reflect.DeepEqual(*m.savedState, state) {
+		return nil
+	}
+	m.savedState = &state
+	return m.db.SaveBoardState(state)
+}
+
+// restoreBoardState puts the board back where it was left: its filters,
+// hidden columns and zoom, and the selected task. A task gone since, or
+// that the filters hide now, leaves the focus on its old column.
+func (m *Model) restoreBoardState(state model.BoardState) {
+	sorts := m.sortModes
+	m.setView(state.View)
+	m.sortModes = sorts
+	m.zoomed = state.Zoomed
+	m.organizeTasks(m.columns, m.allTasks())
+	m.savedState = &state
+
+	found := false
+	for c, col := range m.columns {
+		if col.Status == state.Column && !m.columnHidden(c) && !found {
+			m.currentColumn = c
+			m.currentTask = 0
+		}
+		if state.TaskID == 0 || m.columnHidden(c) {
+			continue
+		}
+		// The task may have moved to another column in the meantime
+		for i, idx := range m.visibleTaskIndices(c) {
+			if col.Tasks[idx].ID == state.TaskID {
+				m.currentColumn = c
+				m.currentTask = i
+				found = true
+			}
+		}
+	}
+	m.ensureColumnVisible()
+	m.ensureTaskVisible()
+}
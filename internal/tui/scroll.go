@@ -0,0 +1,185 @@
+package tui
+
+import "github.com/charmbracelet/lipgloss"
+
+// scrollMargin is how many cards are kept in view beyond the selection when
+// a column scrolls
+const scrollMargin = 1
+
+// cardsHeight returns the rows available for task cards in a column
+func (m *Model) cardsHeight() int {
+	height := m.viewport.Height
+	if !m.ready {
+		height = 19 // a 24-line terminal
+	}
+	// Column border and padding, the title and the two scroll indicators
+	height -= 2 + columnStyle.GetVerticalPadding() + lipgloss.Height(columnTitleStyle.Render(" ")) + 2
+	if m.layout().single && len(m.columns) > 1 {
+		height-- // breadcrumb
+	}
+	if height < 1 {
+		height = 1
+	}
+	return height
+}
+
+// cardHeight returns the rows taken by the card of a task in a column
+func (m *Model) cardHeight(colIndex, taskIndex int) int {
+	return m.cardHeightAt(colIndex, taskIndex, m.taskWidth(colIndex))
+}
+
+// cardHeightAt is cardHeight for a column whose cards are width wide, for
+// loops over many cards of a column
+func (m *Model) cardHeightAt(colIndex, taskIndex, width int) int {
+	task := m.columns[colIndex].Tasks[taskIndex]
+	if m.cache == nil {
+		return lipgloss.Height(m.renderTask(colIndex, task, false))
+	}
+	loaded := loadedCard{
+		id:       task.ID,
+		width:    width,
+		selected: m.selected[task.ID],
+		timed:    m.timer != nil && m.timer.TaskID == task.ID,
+	}
+	key := func() cardKey { return m.cardKey(task, loaded) }
+	return m.cache.cardHeight(loaded, key, cardStamp{titleLines: m.titleLines}, func() int {
+		return lipgloss.Height(m.renderTask(colIndex, task, false))
+	})
+}
+
+// visibleEnd returns the end (exclusive) of the visible tasks that fit in the
+// column when it is scrolled to offset. The first card is always shown, even
+// when it is taller than the column.
+func (m *Model) visibleEnd(colIndex int, visible []int, offset int) int {
+	budget := m.cardsHeight()
+	end := offset
+	for end < len(visible) {
+		height := m.cardHeight(colIndex, visible[end])
+		if end > offset && height > budget {
+			break
+		}
+		budget -= height
+		end++
+	}
+	return end
+}
+
+// offsetEndingAt returns the smallest offset at which the visible task last is
+// still the bottom card in view
+func (m *Model) offsetEndingAt(colIndex int, visible []int, last int) int {
+	budget := m.cardsHeight()
+	start := last + 1
+	for start > 0 {
+		height := m.cardHeight(colIndex, visible[start-1])
+		if start <= last && height > budget {
+			break
+		}
+		budget -= height
+		start--
+	}
+	return start
+}
+
+// ensureTaskVisible adjusts scroll offset to keep current task visible, with
+// scrollMargin cards around it where they exist
+func (m *Model) ensureTaskVisible() {
+	if len(m.columns) == 0 {
+		return
+	}
+
+	visibleIndices := m.visibleTaskIndices(m.currentColumn)
+	visibleCount := len(visibleIndices)
+
+	if visibleCount == 0 {
+		m.currentTask = 0
+		m.scrollOffsets[m.currentColumn] = 0
+		return
+	}
+
+	if m.currentTask >= visibleCount {
+		m.currentTask = visibleCount - 1
+	}
+	if m.currentTask < 0 {
+		m.currentTask = 0
+	}
+
+	offset := m.scrollOffsets[m.currentColumn]
+	if maxOffset := m.offsetEndingAt(m.currentColumn, visibleIndices, visibleCount-1); offset > maxOffset {
+		offset = maxOffset
+	}
+
+	top := m.currentTask - scrollMargin
+	if top < 0 {
+		top = 0
+	}
+	if top < offset {
+		offset = top
+	}
+	bottom := m.currentTask + scrollMargin
+	if bottom > visibleCount-1 {
+		bottom = visibleCount - 1
+	}
+	if m.visibleEnd(m.currentColumn, visibleIndices, offset) <= bottom {
+		offset = m.offsetEndingAt(m.currentColumn, visibleIndices, bottom)
+	}
+	// Cards too tall for the margin: the selection itself must stay in view
+	if offset > m.currentTask {
+		offset = m.currentTask
+	}
+
+	m.scrollOffsets[m.currentColumn] = offset
+	if m.lanes != laneNone {
+		m.ensureLaneVisible()
+	}
+}
+
+// scrollColumn scrolls a column's task list by delta cards, keeping the
+// selection inside the visible range when it is the current column
+func (m *Model) scrollColumn(colIndex, delta int) {
+	if m.lanes != laneNone {
+		// The lanes scroll together, following the selection
+		if colIndex == m.currentColumn {
+			m.currentTask += delta
+			m.ensureTaskVisible()
+		}
+		return
+	}
+	visible := m.visibleTaskIndices(colIndex)
+	if len(visible) == 0 {
+		return
+	}
+	maxOffset := m.offsetEndingAt(colIndex, visible, len(visible)-1)
+	offset := m.scrollOffsets[colIndex] + delta
+	if offset > maxOffset {
+		offset = maxOffset
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	m.scrollOffsets[colIndex] = offset
+
+	if colIndex == m.currentColumn {
+		if m.currentTask < offset {
+			m.currentTask = offset
+		}
+		if end := m.visibleEnd(colIndex, visible, offset); m.currentTask >= end {
+			m.currentTask = end - 1
+		}
+	}
+}
+
+// pageTasks moves the selection by a page of cards (the number currently in
+// view) in the focused column
+func (m *Model) pageTasks(direction int) {
+	visible := m.visibleTaskIndices(m.currentColumn)
+	if len(visible) == 0 {
+		return
+	}
+	offset := m.scrollOffsets[m.currentColumn]
+	page := m.visibleEnd(m.currentColumn, visible, offset) - offset
+	if page < 1 {
+		page = 1
+	}
+	m.currentTask += direction * page
+	m.ensureTaskVisible()
+}
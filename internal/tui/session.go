@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/db"
+)
+
+// sessionOpenedMsg carries the session registered for this TUI and the
+// process ID of an older session on the workspace, if any
+type sessionOpenedMsg struct {
+	id    int64
+	owner int
+}
+
+// sessionCheckedMsg carries the owner found when sending a heartbeat
+type sessionCheckedMsg struct {
+	owner int
+}
+
+// openSession registers the TUI as a session on the workspace
+func (m Model) openSession() tea.Cmd {
+	if m.locked {
+		// Registering the session would write to the database
+		return nil
+	}
+	return func() tea.Msg {
+		id, err := m.db.OpenSession()
+		if err != nil {
+			return errMsg{err}
+		}
+		owner, err := m.db.OlderSession(id)
+		if err != nil {
+			return errMsg{err}
+		}
+		return sessionOpenedMsg{id, owner}
+	}
+}
+
+// heartbeat keeps the session live and checks whether an older session still
+// holds the workspace
+func (m Model) heartbeat() tea.Cmd {
+	id := m.sessionID
+	return func() tea.Msg {
+		if err := m.db.Heartbeat(id); err != nil {
+			return errMsg{err}
+		}
+		owner, err := m.db.OlderSession(id)
+		if err != nil {
+			return errMsg{err}
+		}
+		return sessionCheckedMsg{owner}
+	}
+}
+
+// heartbeatDue returns the heartbeat command when the last one is
+// db.HeartbeatInterval old, or nil
+func (m *Model) heartbeatDue() tea.Cmd {
+	if m.sessionID == 0 || m.currentTime.Sub(m.lastHeartbeat) < db.HeartbeatInterval {
+		return nil
+	}
+	m.lastHeartbeat = m.currentTime
+	return m.heartbeat()
+}
+
+// readOnly reports whether the board was opened with --read-only or another
+// TUI holds the workspace, in which case this one only shows the board
+func (m Model) readOnly() bool {
+	return m.locked || m.sessionOwner != 0
+}
+
+// closeSession removes the session of the open workspace
+func (m *Model) closeSession() error {
+	if m.db == nil || m.sessionID == 0 {
+		return nil
+	}
+	id := m.sessionID
+	m.sessionID = 0
+	m.sessionOwner = 0
+	return m.db.CloseSession(id)
+}
+
+// writeKeys returns the bindings that change the workspace in a view mode
+func (k keyMap) writeKeys(mode ViewMode) []key.Binding {
+	switch mode {
+	case ViewModeBoard:
+		return []key.Binding{
+			k.Add, k.Edit, k.Description, k.Editor, k.Tags, k.Due, k.Repeat, k.Timer, k.Pomodoro, k.Priority, k.Pin, k.Delete, k.Move, k.MoveToWS,
+			k.MoveTaskUp, k.MoveTaskDown, k.Undo, k.Redo, k.Duplicate, k.SaveTemplate, k.Paste, k.EditLink, k.AddColumn, k.RenameColumn,
+			k.DeleteColumn, k.ColumnLeft, k.ColumnRight, k.WIPLimit, k.Archive, k.ArchiveColumn, k.Snooze,
+		}
+	case ViewModeDetail:
+		return []key.Binding{
+			k.Edit, k.Description, k.Editor, k.Tags, k.Due, k.Repeat, k.EditLink, k.Timer, k.Pomodoro, k.Undo, k.Redo, k.SaveTemplate,
+			k.AddSubtask, k.ToggleSubtask, k.DeleteSubtask, k.MoveTaskUp, k.MoveTaskDown,
+		}
+	case ViewModeDependencies, ViewModeEpic:
+		return []key.Binding{k.DeleteSubtask}
+	case ViewModeTrash:
+		return []key.Binding{k.RestoreTask, k.PurgeTask}
+	case ViewModeBoardSettings:
+		return []key.Binding{k.MoveTaskUp, k.MoveTaskDown}
+	case ViewModeArchive:
+		return []key.Binding{k.Unarchive}
+	case ViewModeSnoozed:
+		return []key.Binding{k.Snooze, k.Wake}
+	case ViewModeCalendar, ViewModeAgenda:
+		return []key.Binding{k.MarkDone}
+	}
+	return nil
+}
+
+// blockReadOnly refuses keys that would change a read-only workspace,
+// reporting whether the key was refused
+func (m *Model) blockReadOnly(msg tea.KeyMsg) bool {
+	if !key.Matches(msg, m.keys.writeKeys(m.viewMode)...) {
+		return false
+	}
+	return m.refuseReadOnly()
+}
+
+// refuseReadOnly reports whether the workspace is read-only, telling the
+// user why a change was refused
+func (m *Model) refuseReadOnly() bool {
+	if !m.readOnly() {
+		return false
+	}
+	if m.locked {
+		m.showNotice("🔒 read-only")
+		return true
+	}
+	m.err = fmt.Errorf("read-only: workspace %s is open in another cli_kanban (pid %d)", m.workspace, m.sessionOwner)
+	return true
+}
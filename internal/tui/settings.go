@@ -0,0 +1,308 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// settingsField is the setting of a column typed on the board settings
+// screen
+type settingsField int
+
+const (
+	settingsNone settingsField = iota
+	settingsName
+	settingsWIP
+)
+
+// openBoardSettings opens the board settings screen on the focused column
+func (m *Model) openBoardSettings() {
+	m.viewMode = ViewModeBoardSettings
+	m.settingsEdit = settingsNone
+	m.settingsColumn = ""
+	if len(m.columns) > 0 {
+		m.settingsColumn = m.columns[m.currentColumn].Status
+	}
+	m.err = nil
+}
+
+// closeBoardSettings drops the setting being typed, or else goes back to
+// the board, focusing a column it shows
+func (m *Model) closeBoardSettings() {
+	m.err = nil
+	if m.settingsEdit != settingsNone {
+		m.settingsEdit = settingsNone
+		m.columnInput.SetValue("")
+		m.wipInput.SetValue("")
+		return
+	}
+	m.viewMode = ViewModeBoard
+	if m.columnHidden(m.currentColumn) {
+		if shown := m.shownColumns(); len(shown) > 0 {
+			m.currentColumn = shown[0]
+			m.currentTask = 0
+		}
+	}
+	m.ensureColumnVisible()
+	m.ensureTaskVisible()
+}
+
+// settingsIndex returns the index of the column picked on the settings
+// screen. The pick is kept by status so it follows the column when it
+// moves; when the column is gone, the first one is picked.
+func (m *Model) settingsIndex() int {
+	for i, col := range m.columns {
+		if col.Status == m.settingsColumn {
+			return i
+		}
+	}
+	if len(m.columns) == 0 {
+		return -1
+	}
+	m.settingsColumn = m.columns[0].Status
+	return 0
+}
+
+// handleBoardSettingsKeys handles keyboard input on the board settings
+// screen: Shift+↑/↓ move the picked column, Enter or r renames it, w sets
+// its WIP limit, d marks it done or not, Space hides or shows it and c
+// collapses it
+func (m Model) handleBoardSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.settingsEdit != settingsNone {
+		return m.handleSettingsInputKeys(msg)
+	}
+	index := m.settingsIndex()
+	if key.Matches(msg, m.keys.Settings) {
+		m.closeBoardSettings()
+		return m, nil
+	}
+	if index < 0 {
+		return m, nil
+	}
+	col := m.columns[index]
+
+	switch {
+	case key.Matches(msg, m.keys.MoveTaskUp):
+		if index > 0 {
+			return m, m.moveColumn(col.Status, -1)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.MoveTaskDown):
+		if index < len(m.columns)-1 {
+			return m, m.moveColumn(col.Status, 1)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if index > 0 {
+			m.settingsColumn = m.columns[index-1].Status
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		if index < len(m.columns)-1 {
+			m.settingsColumn = m.columns[index+1].Status
+		}
+		return m, nil
+
+	case msg.String() == "enter" || msg.String() == "r":
+		if m.refuseReadOnly() {
+			return m, nil
+		}
+		m.settingsEdit = settingsName
+		m.columnInput.SetValue(col.Name)
+		m.columnInput.CursorEnd()
+		m.columnInput.Focus()
+		m.err = nil
+		return m, nil
+
+	case msg.String() == "w":
+		if m.refuseReadOnly() {
+			return m, nil
+		}
+		m.settingsEdit = settingsWIP
+		m.wipInput.SetValue("")
+		if col.WIPLimit > 0 {
+			m.wipInput.SetValue(fmt.Sprint(col.WIPLimit))
+		}
+		m.wipInput.CursorEnd()
+		m.wipInput.Focus()
+		m.err = nil
+		return m, nil
+
+	case msg.String() == "d":
+		if m.refuseReadOnly() {
+			return m, nil
+		}
+		return m, m.setColumnDone(col.Status, !col.Done)
+
+	case msg.String() == " ":
+		m.toggleHidden(col.Status)
+		return m, nil
+
+	case msg.String() == "c":
+		return m.collapseColumn(index)
+	}
+	return m, nil
+}
+
+// handleSettingsInputKeys handles keyboard input while a column's name or
+// WIP limit is typed on the settings screen; Enter saves it
+func (m Model) handleSettingsInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "enter" {
+		return m.updateSettingsInput(msg)
+	}
+	index := m.settingsIndex()
+	if index < 0 {
+		m.settingsEdit = settingsNone
+		return m, nil
+	}
+	status := m.columns[index].Status
+
+	if m.settingsEdit == settingsName {
+		name := strings.TrimSpace(m.columnInput.Value())
+		if name == "" {
+			return m, nil
+		}
+		m.settingsEdit = settingsNone
+		m.columnInput.SetValue("")
+		return m, m.renameColumn(status, name)
+	}
+	limit, err := parseWIPLimit(m.wipInput.Value())
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.settingsEdit = settingsNone
+	m.wipInput.SetValue("")
+	m.err = nil
+	return m, m.setWIPLimit(status, limit)
+}
+
+// updateSettingsInput passes a message to the input the settings screen
+// is typing into
+func (m Model) updateSettingsInput(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch m.settingsEdit {
+	case settingsName:
+		m.columnInput, cmd = m.columnInput.Update(msg)
+	case settingsWIP:
+		m.wipInput, cmd = m.wipInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// toggleHidden hides a column from the board or shows it again. Like the
+// columns a saved view hides, it is part of the board's filters: Esc on the
+// board shows every column again, and saving a view keeps it.
+func (m *Model) toggleHidden(status model.TaskStatus) {
+	if m.hiddenColumns[status] {
+		delete(m.hiddenColumns, status)
+		if len(m.hiddenColumns) == 0 {
+			m.hiddenColumns = nil
+		}
+		return
+	}
+	if len(m.hiddenColumns) == len(m.columns)-1 {
+		m.err = errors.New("cannot hide every column")
+		return
+	}
+	if m.hiddenColumns == nil {
+		m.hiddenColumns = make(map[model.TaskStatus]bool)
+	}
+	m.hiddenColumns[status] = true
+	m.err = nil
+}
+
+// viewBoardSettings renders the board settings screen: the columns in
+// board order with their settings, and under it the input of the setting
+// being typed
+func (m Model) viewBoardSettings() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("⚙ Board Settings"))
+	b.WriteString("\n\n")
+
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	normalStyle := lipgloss.NewStyle().Foreground(colorText)
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted)
+
+	nameWidth := 0
+	for _, col := range m.columns {
+		nameWidth = max(nameWidth, lipgloss.Width(col.Name))
+	}
+	picked := m.settingsIndex()
+	for i, col := range m.columns {
+		name := col.Name + strings.Repeat(" ", nameWidth-lipgloss.Width(col.Name))
+		wip := "no WIP limit"
+		if col.WIPLimit > 0 {
+			wip = fmt.Sprintf("WIP limit %d", col.WIPLimit)
+		}
+		line := fmt.Sprintf("%s  %-9s  %-12s", name, pluralize(len(col.Tasks), "task", "tasks"), wip)
+
+		var marks []string
+		if m.hiddenColumns[col.Status] {
+			marks = append(marks, "hidden")
+		}
+		if m.collapsedColumns[col.Status] {
+			marks = append(marks, "collapsed")
+		}
+		if col.Done {
+			marks = append(marks, "done: tasks moved here are completed")
+		}
+		detail := ""
+		if len(marks) > 0 {
+			detail = "  " + strings.Join(marks, ", ")
+		}
+
+		switch {
+		case i == picked:
+			b.WriteString(selectedStyle.Render("▸ " + line))
+		case m.hiddenColumns[col.Status]:
+			b.WriteString(mutedStyle.Render("  " + line))
+		default:
+			b.WriteString(normalStyle.Render("  " + line))
+		}
+		b.WriteString(mutedStyle.Render(detail))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if picked >= 0 && m.settingsEdit != settingsNone {
+		col := m.columns[picked]
+		switch m.settingsEdit {
+		case settingsName:
+			b.WriteString(mutedStyle.Render("Rename " + col.Name + " to"))
+			b.WriteString("\n")
+			b.WriteString(inputStyle.Render(m.columnInput.View()))
+		case settingsWIP:
+			info := fmt.Sprintf("WIP limit of %s (%s)", col.Name, pluralize(len(col.Tasks), "task", "tasks"))
+			if m.strictWIP {
+				info += " | strict: moves into a full column are blocked"
+			}
+			b.WriteString(mutedStyle.Render(info))
+			b.WriteString("\n")
+			b.WriteString(inputStyle.Render(m.wipInput.View()))
+		}
+		b.WriteString("\n\n")
+	}
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+
+	if m.settingsEdit != settingsNone {
+		b.WriteString(helpStyle.Render("Enter: Save | Esc: Cancel"))
+	} else {
+		b.WriteString(helpStyle.Render("↑/↓: Pick | Shift+↑/↓: Move | Enter/r: Rename | w: WIP limit | d: Done or not | Space: Hide/show | c: Collapse | Esc: Back to the board"))
+	}
+	return b.String()
+}
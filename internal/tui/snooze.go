@@ -0,0 +1,288 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// snoozePreset is a wake day offered by the snooze picker
+type snoozePreset struct {
+	label string
+	input string // what it types in the input
+}
+
+// snoozePresets are the wake days offered by the snooze picker; any other
+// day can be typed in
+var snoozePresets = []snoozePreset{
+	{"Tomorrow", "tomorrow"},
+	{"Next week", "mon"},
+}
+
+// openSnoozePicker opens the snooze picker on a task; it goes back to from
+// once the task is snoozed or the picker is closed
+func (m *Model) openSnoozePicker(task *model.Task, from ViewMode) {
+	picked := *task
+	m.snoozeTask = &picked
+	m.snoozeReturn = from
+	m.viewMode = ViewModeSnooze
+	m.snoozeCursor = 0
+	m.snoozeInput.SetValue(snoozePresets[0].input)
+	m.snoozeInput.CursorEnd()
+	m.snoozeInput.Focus()
+	m.err = nil
+}
+
+// closeSnoozePicker closes the snooze picker without snoozing
+func (m *Model) closeSnoozePicker() {
+	m.viewMode = m.snoozeReturn
+	m.snoozeTask = nil
+	m.snoozeInput.SetValue("")
+	m.err = nil
+}
+
+// handleSnoozeKeys handles keyboard input in the snooze picker: up and down
+// pick a preset, anything else edits the day
+func (m Model) handleSnoozeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "down":
+		if msg.String() == "up" {
+			m.snoozeCursor--
+		} else {
+			m.snoozeCursor++
+		}
+		m.snoozeCursor = (m.snoozeCursor + len(snoozePresets)) % len(snoozePresets)
+		m.snoozeInput.SetValue(snoozePresets[m.snoozeCursor].input)
+		m.snoozeInput.CursorEnd()
+		return m, nil
+
+	case "enter":
+		task := m.snoozeTask
+		if task == nil {
+			m.closeSnoozePicker()
+			return m, nil
+		}
+		day, err := dates.Parse(m.snoozeInput.Value(), m.currentTime)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		if !day.After(m.currentTime) {
+			m.err = errors.New("pick a day after today")
+			return m, nil
+		}
+		m.closeSnoozePicker()
+		return m, m.recordChange(opEdit, task, func() error {
+			return m.db.SnoozeTask(task.ID, day)
+		})
+	}
+
+	var cmd tea.Cmd
+	m.snoozeInput, cmd = m.snoozeInput.Update(msg)
+	m.snoozeCursor = -1
+	for i, preset := range snoozePresets {
+		if preset.input == strings.ToLower(strings.TrimSpace(m.snoozeInput.Value())) {
+			m.snoozeCursor = i
+		}
+	}
+	return m, cmd
+}
+
+// viewSnooze renders the snooze picker
+func (m Model) viewSnooze() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("💤 Snooze Task"))
+	b.WriteString("\n\n")
+
+	if m.snoozeTask != nil {
+		info := fmt.Sprintf("Task: %s", m.snoozeTask.Title)
+		b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(info))
+		b.WriteString("\n\n")
+	}
+
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	normalStyle := lipgloss.NewStyle().Foreground(colorText)
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	for i, preset := range snoozePresets {
+		if i == m.snoozeCursor {
+			b.WriteString(selectedStyle.Render("▸ " + preset.label))
+		} else {
+			b.WriteString(normalStyle.Render("  " + preset.label))
+		}
+		if day, err := dates.Parse(preset.input, m.currentTime); err == nil {
+			b.WriteString(mutedStyle.Render("  " + day.Format("Mon "+dates.DateFormat)))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	hint := "The task leaves the board until that day, then comes back at the top of its column.\nOther days: 2025-03-14, +3d, +2w, fri"
+	b.WriteString(mutedStyle.Render(hint))
+	b.WriteString("\n\n")
+
+	b.WriteString(inputStyle.Render(m.snoozeInput.View()))
+	b.WriteString("\n\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓: Pick | Enter: Snooze | Esc: Cancel"))
+
+	return b.String()
+}
+
+// showSnoozed opens the snoozed tasks view, or closes it
+func (m *Model) showSnoozed() {
+	if m.viewMode == ViewModeSnoozed {
+		m.viewMode = ViewModeBoard
+		return
+	}
+	m.viewMode = ViewModeSnoozed
+	m.snoozedCursor = 0
+	m.err = nil
+}
+
+// sortSnoozed orders snoozed tasks by the day they wake
+func sortSnoozed(tasks []model.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i].SnoozedUntil, tasks[j].SnoozedUntil
+		if !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+}
+
+// clampSnoozedCursor keeps the snoozed view cursor on a listed task
+func (m *Model) clampSnoozedCursor() {
+	if m.snoozedCursor >= len(m.snoozed) {
+		m.snoozedCursor = len(m.snoozed) - 1
+	}
+	if m.snoozedCursor < 0 {
+		m.snoozedCursor = 0
+	}
+}
+
+// snoozedCount returns how many tasks of a column are snoozed
+func (m Model) snoozedCount(status model.TaskStatus) int {
+	count := 0
+	for _, task := range m.snoozed {
+		if task.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// snoozeDue reloads the board once a snoozed task's day has come, so it
+// wakes while the board stays open, or returns nil. Like refreshDue, it
+// leaves the modes that are editing something alone.
+func (m *Model) snoozeDue() tea.Cmd {
+	if m.viewMode != ViewModeBoard && m.viewMode != ViewModeDetail && m.viewMode != ViewModeDashboard &&
+		m.viewMode != ViewModeCalendar && m.viewMode != ViewModeAgenda && m.viewMode != ViewModeSnoozed {
+		return nil
+	}
+	for _, task := range m.snoozed {
+		if !task.Snoozed(m.currentTime) {
+			if task := m.getCurrentTask(); task != nil {
+				m.followTaskID = task.ID
+			}
+			return m.loadTasks()
+		}
+	}
+	return nil
+}
+
+// handleSnoozedKeys handles keyboard input in the snoozed tasks view
+func (m Model) handleSnoozedKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.SnoozedView):
+		m.viewMode = ViewModeBoard
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if m.snoozedCursor > 0 {
+			m.snoozedCursor--
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		if m.snoozedCursor < len(m.snoozed)-1 {
+			m.snoozedCursor++
+		}
+		return m, nil
+	}
+
+	if m.snoozedCursor >= len(m.snoozed) {
+		return m, nil
+	}
+	task := m.snoozed[m.snoozedCursor]
+
+	switch {
+	case key.Matches(msg, m.keys.Snooze):
+		m.openSnoozePicker(&task, ViewModeSnoozed)
+		return m, nil
+
+	case key.Matches(msg, m.keys.Wake):
+		return m, m.recordChange(opEdit, &task, func() error {
+			return m.db.WakeTask(task.ID)
+		})
+	}
+	return m, nil
+}
+
+// viewSnoozed renders the snoozed tasks view: the tasks off the board, by
+// the day they wake
+func (m Model) viewSnoozed() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("💤 Snoozed"))
+	b.WriteString("\n\n")
+
+	if len(m.snoozed) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("No task is snoozed"))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(closeHint(m.keys.SnoozedView) + ": Back"))
+		return b.String()
+	}
+
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	infoStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	for i, task := range m.snoozed {
+		column := string(task.Status)
+		if col, ok := model.FindColumn(m.columns, column); ok {
+			column = col.Name
+		}
+		until := *task.SnoozedUntil
+		line := truncateText(task.Title, 50)
+		info := infoStyle.Render(fmt.Sprintf("  (%s, wakes %s, %s)", column, until.Format("Mon "+dates.DateFormat), dates.Relative(until, m.currentTime)))
+		if i == m.snoozedCursor {
+			b.WriteString(selectedStyle.Render("> "+line) + info)
+		} else {
+			b.WriteString("  " + line + info)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+	if notice := m.renderNotice(); notice != "" {
+		b.WriteString(notice + "  ")
+	}
+	b.WriteString(helpStyle.Render(joinHints("↑ ↓: Select", hint(m.keys.Wake, "Wake now"), hint(m.keys.Snooze, "Snooze until another day"), closeHint(m.keys.SnoozedView)+": Back")))
+
+	return b.String()
+}
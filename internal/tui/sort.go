@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// sortMode is how the tasks of a column are ordered, by the name it is
+// saved under in the settings table
+type sortMode string
+
+const (
+	sortManual   sortMode = "" // the order tasks were put in by hand
+	sortPriority sortMode = "priority"
+	sortDue      sortMode = "due"
+	sortCreated  sortMode = "created"
+	sortTitle    sortMode = "title"
+)
+
+// columnSorts holds the sort modes of the columns by status; columns in
+// manual order aren't in it
+type columnSorts map[model.TaskStatus]sortMode
+
+// sortCycle is the order the sort key goes through the modes
+var sortCycle = []sortMode{sortManual, sortPriority, sortDue, sortCreated, sortTitle}
+
+// parseSortMode returns the sort mode saved under a name. Unknown names,
+// e.g. from a newer cli_kanban, fall back to the manual order.
+func parseSortMode(name string) sortMode {
+	for _, mode := range sortCycle {
+		if string(mode) == name {
+			return mode
+		}
+	}
+	return sortManual
+}
+
+// next returns the mode the sort key switches to
+func (s sortMode) next() sortMode {
+	for i, mode := range sortCycle {
+		if mode == s {
+			return sortCycle[(i+1)%len(sortCycle)]
+		}
+	}
+	return sortManual
+}
+
+// indicator returns the mark shown after the column name, or "" for the
+// manual order
+func (s sortMode) indicator() string {
+	switch s {
+	case sortPriority:
+		return "↓prio"
+	case sortDue:
+		return "↓due"
+	case sortCreated:
+		return "↓age"
+	case sortTitle:
+		return "↓a-z"
+	}
+	return ""
+}
+
+// String describes the mode for notices, e.g. "due date"
+func (s sortMode) String() string {
+	switch s {
+	case sortPriority:
+		return "priority"
+	case sortDue:
+		return "due date"
+	case sortCreated:
+		return "creation date"
+	case sortTitle:
+		return "title"
+	}
+	return "manual order"
+}
+
+// sortTasks orders tasks for a sort mode, keeping the manual order among
+// tasks that compare equal. Pinned tasks come first whatever the mode; the
+// manual order leaves the tasks as they are otherwise.
+func sortTasks(tasks []model.Task, mode sortMode) {
+	var less func(a, b model.Task) bool
+	switch mode {
+	case sortPriority:
+		less = func(a, b model.Task) bool { return a.Priority.Rank() > b.Priority.Rank() }
+	case sortDue:
+		// Tasks without a due date go last
+		less = func(a, b model.Task) bool {
+			if a.Due == nil || b.Due == nil {
+				return a.Due != nil && b.Due == nil
+			}
+			return a.Due.Before(*b.Due)
+		}
+	case sortCreated:
+		// Oldest first, so the tasks waiting longest come up top
+		less = func(a, b model.Task) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case sortTitle:
+		less = func(a, b model.Task) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) }
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if a, b := tasks[i], tasks[j]; a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		return less != nil && less(tasks[i], tasks[j])
+	})
+}
+
+// columnSort returns the sort mode of a column
+func (m Model) columnSort(index int) sortMode {
+	if index < 0 || index >= len(m.columns) {
+		return sortManual
+	}
+	return m.sortModes[m.columns[index].Status]
+}
+
+// cycleSort switches the focused column to the next sort mode, keeping the
+// selected task selected, and saves the mode for the workspace
+func (m Model) cycleSort() (tea.Model, tea.Cmd) {
+	if len(m.columns) == 0 {
+		return m, nil
+	}
+	col := m.columns[m.currentColumn]
+	mode := m.columnSort(m.currentColumn).next()
+
+	modes := make(columnSorts, len(m.sortModes)+1)
+	for status, other := range m.sortModes {
+		modes[status] = other
+	}
+	if mode == sortManual {
+		delete(modes, col.Status)
+	} else {
+		modes[col.Status] = mode
+	}
+	m.sortModes = modes
+
+	if task := m.getCurrentTask(); task != nil {
+		m.followTaskID = task.ID
+	}
+	// Back to manual, the tasks come back in the order they are stored in
+	m.organizeTasks(m.columns, m.allTasks())
+	if mode == sortManual {
+		m.showNotice(col.Name + " back in manual order")
+	} else {
+		m.showNotice(fmt.Sprintf("%s sorted by %s", col.Name, mode))
+	}
+
+	// A read-only board sorts for this window only
+	if m.readOnly() {
+		return m, nil
+	}
+	return m, m.saveSortMode(col.Status, mode)
+}
+
+// saveSortMode stores the sort mode of a column
+func (m Model) saveSortMode(status model.TaskStatus, mode sortMode) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.db.SetSortMode(status, string(mode)); err != nil {
+			return errMsg{err}
+		}
+		return nil
+	}
+}
+
+// refuseReorder reports whether the focused column is sorted, so its tasks
+// can't be reordered by hand, telling the user how to go back to manual
+func (m *Model) refuseReorder() bool {
+	mode := m.columnSort(m.currentColumn)
+	if mode == sortManual {
+		return false
+	}
+	hint := ""
+	if m.keys.Sort.Enabled() {
+		hint = fmt.Sprintf(": press %s until it's back to manual order", m.keys.Sort.Help().Key)
+	}
+	m.showNotice(fmt.Sprintf("%s is sorted by %s%s", m.columns[m.currentColumn].Name, mode, hint))
+	return true
+}
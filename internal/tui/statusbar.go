@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+)
+
+// statusBarHeight is the number of lines the status bar takes under the
+// board footer; it never grows, so toasts don't move the board
+const statusBarHeight = 1
+
+// renderStatusBar renders the status bar of the board: the workspace, how
+// many tasks there are and show, the filters and sort in effect, warnings,
+// and on the right the current toast, cut to one line of the given width
+func (m Model) renderStatusBar(width int) string {
+	muted := lipgloss.NewStyle().Foreground(colorMuted)
+	warning := lipgloss.NewStyle().Foreground(colorWarning).Bold(true)
+
+	name := m.workspace
+	if name == "" {
+		name = "default"
+	}
+	parts := []string{lipgloss.NewStyle().Foreground(colorText).Bold(true).Render(name)}
+
+	total, visible := 0, 0
+	for _, col := range m.columns {
+		for _, task := range col.Tasks {
+			total++
+			if m.taskVisible(task) {
+				visible++
+			}
+		}
+	}
+	counts := pluralize(total, "task", "tasks")
+	if visible != total {
+		counts = fmt.Sprintf("%d of %s", visible, counts)
+	}
+	parts = append(parts, muted.Render(counts))
+
+	var shown []string
+	if m.activeView != "" {
+		shown = append(shown, "view: "+m.activeView)
+	}
+	if m.searchQuery != "" {
+		shown = append(shown, fmt.Sprintf("filter: %s (%s)", m.searchQuery, pluralize(m.matchCount(), "match", "matches")))
+	}
+	if m.urgentOnly {
+		shown = append(shown, "priority: high+")
+	}
+	if m.labelFilter != "" {
+		shown = append(shown, fmt.Sprintf("tag: %s (%s)", m.labelFilter, pluralize(m.tagCount(m.labelFilter), "task", "tasks")))
+	}
+	if m.staleOnly {
+		shown = append(shown, "stale")
+	}
+	if m.pinnedOnly {
+		shown = append(shown, "pinned")
+	}
+	if mode := m.columnSort(m.currentColumn); mode != sortManual {
+		shown = append(shown, "sorted by "+mode.String())
+	}
+	if len(m.hiddenColumns) > 0 {
+		var names []string
+		for _, col := range m.columns {
+			if m.hiddenColumns[col.Status] {
+				names = append(names, col.Name)
+			}
+		}
+		shown = append(shown, "hiding "+strings.Join(names, ", "))
+	}
+	if m.lanes != laneNone {
+		shown = append(shown, "lanes by "+string(m.lanes))
+	}
+	if m.zoomed {
+		shown = append(shown, "zoomed ("+closeHint(m.keys.Zoom)+")")
+	}
+	if len(shown) > 0 {
+		parts = append(parts, lipgloss.NewStyle().Foreground(colorPrimary).Render(strings.Join(shown, ", ")))
+	}
+
+	if m.locked {
+		parts = append(parts, warning.Render("read-only"))
+	} else if m.readOnly() {
+		parts = append(parts, warning.Render("read-only: open in another window"))
+	}
+	for _, col := range m.columns {
+		if col.OverWIPLimit(len(col.Tasks)) {
+			parts = append(parts, warning.Render(fmt.Sprintf("%s over WIP limit %d/%d", col.Name, len(col.Tasks), col.WIPLimit)))
+		}
+	}
+	if len(m.webhookFailing) > 0 {
+		parts = append(parts, warning.Render("webhooks failing"))
+	}
+
+	left := strings.Join(parts, muted.Render(" · "))
+	toast := m.renderNotice()
+	room := width
+	if toast != "" {
+		toast = clipWidth(toast, width/2)
+		room = width - lipgloss.Width(toast) - 2
+	}
+	left = clipWidth(left, max(room, 0))
+	gap := max(width-lipgloss.Width(left)-lipgloss.Width(toast), 0)
+	return left + strings.Repeat(" ", gap) + toast
+}
+
+// changeNotice is the toast for a stored task change: a move says where the
+// task went, a delete or archive how to take it back; other changes have none
+func (m Model) changeNotice(op operation) string {
+	undo := ""
+	if m.keys.Undo.Enabled() {
+		undo = ", press " + m.keys.Undo.Help().Key + " to undo"
+	}
+	switch op.kind {
+	case opMove:
+		column := string(op.after.Status)
+		for _, col := range m.columns {
+			if col.Status == op.after.Status {
+				column = col.Name
+			}
+		}
+		return fmt.Sprintf("moved %q → %s", truncateText(op.after.Title, 30), column)
+	case opDelete:
+		return fmt.Sprintf("deleted %q%s", truncateText(op.before.Title, 30), undo)
+	case opArchive:
+		return fmt.Sprintf("archived %q%s", truncateText(op.before.Title, 30), undo)
+	case opEdit:
+		before, after := op.before.Snoozed(m.currentTime), op.after.Snoozed(m.currentTime)
+		switch {
+		case op.after.Pinned && !op.before.Pinned:
+			return fmt.Sprintf("pinned %q", truncateText(op.after.Title, 30))
+		case op.before.Pinned && !op.after.Pinned:
+			return fmt.Sprintf("unpinned %q", truncateText(op.after.Title, 30))
+		case after && (!before || !op.before.SnoozedUntil.Equal(*op.after.SnoozedUntil)):
+			return fmt.Sprintf("snoozed %q until %s%s", truncateText(op.after.Title, 30), op.after.SnoozedUntil.Format("Mon "+dates.DateFormat), undo)
+		case before && !after:
+			return fmt.Sprintf("woke %q", truncateText(op.after.Title, 30))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,244 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/model"
+	"github.com/happytaoer/cli_kanban/internal/quickadd"
+)
+
+// templatesLoadedMsg carries the templates listed in the template picker
+type templatesLoadedMsg struct {
+	templates []model.Template
+}
+
+// templateSavedMsg reports a template saved from a task
+type templateSavedMsg struct {
+	name string
+}
+
+// loadTemplates reads the templates of the workspace
+func (m Model) loadTemplates() tea.Cmd {
+	return func() tea.Msg {
+		templates, err := m.db.GetTemplates()
+		if err != nil {
+			return errMsg{err}
+		}
+		return templatesLoadedMsg{templates}
+	}
+}
+
+// saveTemplate stores a template, replacing one of the same name
+func (m Model) saveTemplate(t model.Template) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.db.SaveTemplate(t); err != nil {
+			return errMsg{err}
+		}
+		return templateSavedMsg{t.Name}
+	}
+}
+
+// startSaveTemplate opens the prompt saving task as a template, which first
+// asks for the template's name and then for its title
+func (m Model) startSaveTemplate(task *model.Task) (tea.Model, tea.Cmd) {
+	draft := model.TemplateFrom("", *task)
+	m.templateDraft = &draft
+	m.templateInput.Placeholder = "Template name..."
+	m.templateInput.SetValue(templateName(task.Title))
+	m.templateInput.CursorEnd()
+	m.templateInput.Focus()
+	m.viewMode = ViewModeSaveTemplate
+	m.err = nil
+	return m, nil
+}
+
+// templateName suggests a template name for a task: the first word of its
+// title, lowercased
+func templateName(title string) string {
+	words := strings.Fields(strings.ToLower(title))
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.Trim(words[0], ".,:;!?")
+}
+
+// handleSaveTemplateKeys handles keyboard input in the save as template prompt
+func (m Model) handleSaveTemplateKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "enter" {
+		value := strings.TrimSpace(m.templateInput.Value())
+		if value == "" || m.templateDraft == nil {
+			return m, nil
+		}
+		if m.templateDraft.Name == "" {
+			// On to the title, where placeholders can replace what changes
+			m.templateDraft.Name = value
+			m.templateInput.Placeholder = "Title, e.g. Release v{{version}}..."
+			m.templateInput.SetValue(m.templateDraft.Title)
+			m.templateInput.CursorEnd()
+			return m, nil
+		}
+		draft := *m.templateDraft
+		draft.Title = value
+		m.templateDraft = nil
+		m.viewMode = ViewModeBoard
+		return m, m.saveTemplate(draft)
+	}
+
+	var cmd tea.Cmd
+	m.templateInput, cmd = m.templateInput.Update(msg)
+	return m, cmd
+}
+
+// handleTemplatePickerKeys handles keyboard input in the template picker of
+// the add task view. Choosing a template puts its title in the task input.
+func (m Model) handleTemplatePickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.templateCursor > 0 {
+			m.templateCursor--
+		}
+	case "down", "j":
+		if m.templateCursor < len(m.templates)-1 {
+			m.templateCursor++
+		}
+	case "enter":
+		if m.templateCursor >= len(m.templates) {
+			return m, nil
+		}
+		t := m.templates[m.templateCursor]
+		m.fromTemplate = &t
+		m.textInput.SetValue(t.Title)
+		m.textInput.CursorEnd()
+		m.viewMode = ViewModeAddTask
+	}
+	return m, nil
+}
+
+// templateTask returns the task to create from the add task input when it
+// started from a template: the template's task under the typed title, with
+// the quick-add attributes on top
+func templateTask(t model.Template, parsed quickadd.Result, status model.TaskStatus) model.Task {
+	return parsed.Apply(t.Draft(parsed.Title, status))
+}
+
+// renderTemplateInfo describes the template the add task input started from,
+// warning when the typed title no longer fills in its placeholders
+func (m Model) renderTemplateInfo() string {
+	t := m.fromTemplate
+	parts := []string{"From template " + t.Name}
+	if len(t.Subtasks) > 0 {
+		parts = append(parts, pluralize(len(t.Subtasks), "checklist item", "checklist items"))
+	}
+	if len(t.Tags) > 0 {
+		parts = append(parts, "#"+strings.Join(t.Tags, " #"))
+	}
+	if t.Priority != model.PriorityNone {
+		parts = append(parts, priorityMarker(t.Priority)+" "+string(t.Priority))
+	}
+	info := lipgloss.NewStyle().Foreground(colorSecondary).Render(strings.Join(parts, " · "))
+
+	vars := t.Vars()
+	if len(vars) == 0 {
+		return info
+	}
+	parsed, err := quickadd.Parse(m.textInput.Value(), m.currentTime)
+	if err != nil {
+		return info
+	}
+	warning := lipgloss.NewStyle().Foreground(colorWarning)
+	if strings.Contains(parsed.Title, "{{") {
+		return info + "\n" + warning.Render("Replace the {{…}} placeholders in the title with their values")
+	}
+	if _, ok := t.MatchTitle(parsed.Title); !ok {
+		return info + "\n" + warning.Render(fmt.Sprintf("The title no longer follows %q, so its placeholders stay in the description", t.Title))
+	}
+	return info
+}
+
+// viewSaveTemplate renders the prompt saving a task as a template
+func (m Model) viewSaveTemplate() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("💾 Save as Template"))
+	b.WriteString("\n\n")
+
+	info := lipgloss.NewStyle().Foreground(colorSecondary)
+	muted := lipgloss.NewStyle().Foreground(colorMuted)
+	help := "Enter: Next | Esc: Cancel"
+	if d := m.templateDraft; d != nil && d.Name == "" {
+		b.WriteString(info.Render("Name of the template (one of the same name is replaced):"))
+	} else if d != nil {
+		b.WriteString(info.Render(fmt.Sprintf("Title of template %s:", d.Name)))
+		b.WriteString("\n")
+		b.WriteString(muted.Render("Write {{name}} where new tasks differ, e.g. Release v{{version}}; the description can use the same placeholders."))
+		help = "Enter: Save | Esc: Cancel"
+	}
+	b.WriteString("\n\n")
+	b.WriteString(inputStyle.Render(m.templateInput.View()))
+	b.WriteString("\n\n")
+
+	if d := m.templateDraft; d != nil {
+		var parts []string
+		if len(d.Subtasks) > 0 {
+			parts = append(parts, pluralize(len(d.Subtasks), "checklist item", "checklist items"))
+		}
+		if len(d.Tags) > 0 {
+			parts = append(parts, "#"+strings.Join(d.Tags, " #"))
+		}
+		if d.Priority != model.PriorityNone {
+			parts = append(parts, string(d.Priority)+" priority")
+		}
+		if strings.TrimSpace(d.Description) != "" {
+			parts = append(parts, "the description")
+		}
+		if len(parts) > 0 {
+			b.WriteString(muted.Render("Keeps " + strings.Join(parts, ", ")))
+			b.WriteString("\n\n")
+		}
+	}
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
+
+// viewTemplates renders the template picker of the add task view
+func (m Model) viewTemplates() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("📄 New Task from Template"))
+	b.WriteString("\n\n")
+
+	if m.templates == nil {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Render("Loading templates..."))
+		return b.String()
+	}
+	if len(m.templates) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("No templates yet: save a task as one with Ctrl+S on the board."))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render("Esc: Back"))
+		return b.String()
+	}
+
+	cursor := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	infoStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	for i, t := range m.templates {
+		line := t.Name
+		info := "  " + truncateText(t.Title, 50)
+		if len(t.Subtasks) > 0 {
+			info += fmt.Sprintf(" (%s)", pluralize(len(t.Subtasks), "item", "items"))
+		}
+		if i == m.templateCursor {
+			b.WriteString(cursor.Render("> "+line) + infoStyle.Render(info))
+		} else {
+			b.WriteString("  " + line + infoStyle.Render(info))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑ ↓: Choose | Enter: Use template | Esc: Back"))
+	return b.String()
+}
@@ -0,0 +1,290 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DefaultThemeName is the theme used when the config doesn't pick one
+const DefaultThemeName = "dark"
+
+// Theme maps the semantic roles of the interface to colors
+type Theme struct {
+	Primary        lipgloss.Color // titles, selection markers and input borders
+	Secondary      lipgloss.Color // headings and hints
+	Text           lipgloss.Color // field values and due dates
+	Muted          lipgloss.Color // secondary text
+	Border         lipgloss.Color // footer and dialog borders
+	Selected       lipgloss.Color // background of the selected task
+	SelectedText   lipgloss.Color // text of the selected task
+	ColumnFirst    lipgloss.Color // header and border of the first column
+	ColumnMiddle   lipgloss.Color // header and border of the columns in between
+	ColumnLast     lipgloss.Color // header and border of the done columns
+	Success        lipgloss.Color // completed checklists
+	Warning        lipgloss.Color // tasks due soon and WIP warnings
+	Danger         lipgloss.Color // errors and exceeded WIP limits
+	Overdue        lipgloss.Color // overdue due dates
+	Highlight      lipgloss.Color // background of search matches
+	HighlightText  lipgloss.Color // text of search matches
+	TagText        lipgloss.Color // text on tag chips
+	PriorityLow    lipgloss.Color
+	PriorityMedium lipgloss.Color
+	PriorityHigh   lipgloss.Color
+	PriorityUrgent lipgloss.Color
+}
+
+// builtinThemes are the themes that can be selected by name
+var builtinThemes = map[string]Theme{
+	"dark": {
+		Primary:        "#7C3AED",
+		Secondary:      "#A78BFA",
+		Text:           "#FFFFFF",
+		Muted:          "#6B7280",
+		Border:         "#374151",
+		Selected:       "#7C3AED",
+		SelectedText:   "#FFFFFF",
+		ColumnFirst:    "#6B7280",
+		ColumnMiddle:   "#3B82F6",
+		ColumnLast:     "#10B981",
+		Success:        "#10B981",
+		Warning:        "#F59E0B",
+		Danger:         "#EF4444",
+		Overdue:        "#EF4444",
+		Highlight:      "#F59E0B",
+		HighlightText:  "#000000",
+		TagText:        "#FFFFFF",
+		PriorityLow:    "#3B82F6",
+		PriorityMedium: "#10B981",
+		PriorityHigh:   "#F59E0B",
+		PriorityUrgent: "#EF4444",
+	},
+	"light": {
+		Primary:        "#6D28D9",
+		Secondary:      "#5B21B6",
+		Text:           "#111827",
+		Muted:          "#6B7280",
+		Border:         "#D1D5DB",
+		Selected:       "#6D28D9",
+		SelectedText:   "#FFFFFF",
+		ColumnFirst:    "#4B5563",
+		ColumnMiddle:   "#1D4ED8",
+		ColumnLast:     "#047857",
+		Success:        "#047857",
+		Warning:        "#B45309",
+		Danger:         "#DC2626",
+		Overdue:        "#DC2626",
+		Highlight:      "#FDE68A",
+		HighlightText:  "#000000",
+		TagText:        "#FFFFFF",
+		PriorityLow:    "#1D4ED8",
+		PriorityMedium: "#047857",
+		PriorityHigh:   "#B45309",
+		PriorityUrgent: "#DC2626",
+	},
+	"solarized": {
+		Primary:        "#268BD2",
+		Secondary:      "#6C71C4",
+		Text:           "#EEE8D5",
+		Muted:          "#657B83",
+		Border:         "#073642",
+		Selected:       "#268BD2",
+		SelectedText:   "#FDF6E3",
+		ColumnFirst:    "#839496",
+		ColumnMiddle:   "#2AA198",
+		ColumnLast:     "#859900",
+		Success:        "#859900",
+		Warning:        "#B58900",
+		Danger:         "#DC322F",
+		Overdue:        "#DC322F",
+		Highlight:      "#B58900",
+		HighlightText:  "#002B36",
+		TagText:        "#FDF6E3",
+		PriorityLow:    "#2AA198",
+		PriorityMedium: "#859900",
+		PriorityHigh:   "#CB4B16",
+		PriorityUrgent: "#DC322F",
+	},
+}
+
+// colorRe matches hex colors (#RGB or #RRGGBB); ANSI color numbers are checked separately
+var colorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ThemeNames returns the names of the built-in themes, sorted
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultTheme returns the built-in default theme
+func DefaultTheme() Theme {
+	return builtinThemes[DefaultThemeName]
+}
+
+// NewTheme returns the named built-in theme with the given colors overridden
+// by role (e.g. "selected": "#2563EB"). An empty name picks the default theme.
+// Unknown names, roles and colors are reported in the error; the returned
+// theme still applies every override that was valid.
+func NewTheme(name string, overrides map[string]string) (Theme, error) {
+	var errs []error
+
+	if name == "" {
+		name = DefaultThemeName
+	}
+	theme, ok := builtinThemes[strings.ToLower(name)]
+	if !ok {
+		errs = append(errs, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", ")))
+		theme = DefaultTheme()
+	}
+
+	roles := theme.roles()
+	keys := make([]string, 0, len(overrides))
+	for role := range overrides {
+		keys = append(keys, role)
+	}
+	sort.Strings(keys)
+	for _, role := range keys {
+		color, ok := roles[role]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown theme color %q", role))
+			continue
+		}
+		value := strings.TrimSpace(overrides[role])
+		if !validColor(value) {
+			errs = append(errs, fmt.Errorf("invalid color %q for %s: use #RRGGBB, #RGB or an ANSI number (0-255)", value, role))
+			continue
+		}
+		*color = lipgloss.Color(value)
+	}
+
+	return theme, errors.Join(errs...)
+}
+
+// roles maps the role names used in the config file to the theme's colors
+func (t *Theme) roles() map[string]*lipgloss.Color {
+	return map[string]*lipgloss.Color{
+		"primary":         &t.Primary,
+		"secondary":       &t.Secondary,
+		"text":            &t.Text,
+		"muted":           &t.Muted,
+		"border":          &t.Border,
+		"selected":        &t.Selected,
+		"selected_text":   &t.SelectedText,
+		"column_first":    &t.ColumnFirst,
+		"column_middle":   &t.ColumnMiddle,
+		"column_last":     &t.ColumnLast,
+		"success":         &t.Success,
+		"warning":         &t.Warning,
+		"danger":          &t.Danger,
+		"overdue":         &t.Overdue,
+		"highlight":       &t.Highlight,
+		"highlight_text":  &t.HighlightText,
+		"tag_text":        &t.TagText,
+		"priority_low":    &t.PriorityLow,
+		"priority_medium": &t.PriorityMedium,
+		"priority_high":   &t.PriorityHigh,
+		"priority_urgent": &t.PriorityUrgent,
+	}
+}
+
+// validColor reports whether value is a hex color or an ANSI color number
+func validColor(value string) bool {
+	if colorRe.MatchString(value) {
+		return true
+	}
+	n, err := strconv.Atoi(value)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// applyTheme sets the colors and rebuilds the shared styles
+func applyTheme(t Theme) {
+	colorPrimary = t.Primary
+	colorSecondary = t.Secondary
+	colorText = t.Text
+	colorMuted = t.Muted
+	colorBorder = t.Border
+	colorSelected = t.Selected
+	colorSelectedText = t.SelectedText
+	colorColumnFirst = t.ColumnFirst
+	colorColumnMiddle = t.ColumnMiddle
+	colorColumnLast = t.ColumnLast
+	colorSuccess = t.Success
+	colorWarning = t.Warning
+	colorDanger = t.Danger
+	colorOverdue = t.Overdue
+	colorTagText = t.TagText
+	colorPriorityLow = t.PriorityLow
+	colorPriorityMedium = t.PriorityMedium
+	colorPriorityHigh = t.PriorityHigh
+	colorPriorityUrgent = t.PriorityUrgent
+
+	titleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		MarginBottom(1)
+
+	columnStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorder).
+		Padding(1, 2).
+		Width(30)
+
+	columnTitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorSecondary).
+		MarginBottom(1)
+
+	taskStyle = lipgloss.NewStyle().
+		Padding(0, 1).
+		MarginBottom(1).
+		Width(26)
+
+	taskActiveStyle = lipgloss.NewStyle().
+		Padding(0, 1).
+		MarginBottom(1).
+		Width(26).
+		Background(colorSelected).
+		Foreground(colorSelectedText).
+		Bold(true)
+
+	helpStyle = lipgloss.NewStyle().
+		Foreground(colorMuted)
+
+	footerStyle = lipgloss.NewStyle().
+		BorderTop(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(colorBorder).
+		Foreground(colorMuted).
+		PaddingTop(1)
+
+	inputStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 2).
+		Width(60)
+
+	errorStyle = lipgloss.NewStyle().
+		Foreground(colorDanger).
+		Bold(true)
+
+	statsStyle = lipgloss.NewStyle().
+		Foreground(colorMuted).
+		MarginBottom(1)
+
+	searchHighlightStyle = lipgloss.NewStyle().
+		Background(t.Highlight).
+		Foreground(t.HighlightText)
+}
+
+func init() {
+	applyTheme(DefaultTheme())
+}
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// timerChangedMsg reports a timer that was started or stopped
+type timerChangedMsg struct {
+	timer   *db.TimeEntry // the running timer, nil when none runs
+	stopped *db.TimeEntry // the timer that was stopped, if any
+}
+
+// toggleTimer stops the timer when it runs on task (or when there is no
+// task), and otherwise starts one on task, stopping any other
+func (m Model) toggleTimer(task *model.Task) tea.Cmd {
+	running := m.timer
+	return func() tea.Msg {
+		if task == nil || (running != nil && running.TaskID == task.ID) {
+			stopped, err := m.db.StopTimer()
+			if err != nil {
+				return errMsg{err}
+			}
+			return timerChangedMsg{stopped: stopped}
+		}
+		previous, err := m.db.RunningTimer()
+		if err != nil {
+			return errMsg{err}
+		}
+		timer, err := m.db.StartTimer(task.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		if previous != nil {
+			end := timer.Start
+			previous.End = &end
+		}
+		return timerChangedMsg{timer: timer, stopped: previous}
+	}
+}
+
+// timerNotice describes a timer change for the footer
+func timerNotice(msg timerChangedMsg) string {
+	stopped := ""
+	if msg.stopped != nil && msg.stopped.End != nil {
+		stopped = fmt.Sprintf("stopped %q after %s", truncateText(msg.stopped.Title, 30), dates.HoursMinutes(msg.stopped.Duration(*msg.stopped.End)))
+	}
+	switch {
+	case msg.timer != nil && stopped != "":
+		return fmt.Sprintf("%s, timing %q", stopped, truncateText(msg.timer.Title, 30))
+	case msg.timer != nil:
+		return fmt.Sprintf("timing %q", truncateText(msg.timer.Title, 30))
+	case stopped != "":
+		return stopped
+	}
+	return "no timer is running"
+}
+
+// elapsed formats the running time of a timer as a clock, e.g. 1:02:03
+func elapsed(d time.Duration) string {
+	s := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// renderTimer renders the pomodoro countdown or the running timer for the
+// status bar, or ""
+func (m Model) renderTimer() string {
+	if m.focus != nil {
+		return m.renderFocus()
+	}
+	if m.timer == nil {
+		return ""
+	}
+	text := fmt.Sprintf("⏱ %s %s", truncateText(m.timer.Title, 24), elapsed(m.timer.Duration(m.currentTime)))
+	return lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render(text)
+}
+
+// taskLogged returns the time logged on a task, counting its running timer
+func (m Model) taskLogged(taskID int64) time.Duration {
+	logged := m.detailLogged
+	if m.activityTask != taskID {
+		logged = 0
+	}
+	if m.timer != nil && m.timer.TaskID == taskID {
+		logged += m.timer.Duration(m.currentTime)
+	}
+	return logged
+}
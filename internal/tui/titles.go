@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultTitleLines is how many lines titles are cut to when the config
+// shows them whole and they are cut from the board
+const defaultTitleLines = 2
+
+// toggleTitleLines switches the cards between whole titles and titles cut
+// to a few lines, for this window only
+func (m *Model) toggleTitleLines() {
+	switch {
+	case m.titleLines > 0:
+		m.titleLines = 0
+	case m.titleSetting > 0:
+		m.titleLines = m.titleSetting
+	default:
+		m.titleLines = defaultTitleLines
+	}
+	if m.titleLines > 0 {
+		m.showNotice(fmt.Sprintf("titles cut to %s", pluralize(m.titleLines, "line", "lines")))
+	} else {
+		m.showNotice("whole titles")
+	}
+	// Cards changed height: keep the selected one on screen
+	m.ensureTaskVisible()
+}
+
+// wrapTitle wraps a card's title between its markers. Past the title lines
+// the title is cut with "…", keeping the markers after it.
+func (m *Model) wrapTitle(head, title, tail string, width int) string {
+	wrapped := wrapText(head+title+tail, width)
+	if m.titleLines <= 0 || strings.Count(wrapped, "\n") < m.titleLines {
+		return wrapped
+	}
+	cut := func(n int, parts []string) string {
+		return wrapText(head+strings.TrimRight(strings.Join(parts[:n], ""), " ")+"…"+tail, width)
+	}
+	// The longest start of the title that fits with the ellipsis
+	parts := clusters(title)
+	lo, hi := 0, len(parts)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if strings.Count(cut(mid, parts), "\n") < m.titleLines {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return cut(lo, parts)
+}
@@ -2,12 +2,19 @@ package tui
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/agenda"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/db"
 	"github.com/happytaoer/cli_kanban/internal/model"
+	"github.com/happytaoer/cli_kanban/internal/quickadd"
 )
 
 // Update handles messages and updates the model
@@ -20,8 +27,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 
 		// Calculate viewport height (total height - header - footer)
-		headerHeight := 2 // title+stats line + spacing
-		footerHeight := 3 // footer + spacing
+		headerHeight := 2                   // title+stats line + spacing
+		footerHeight := 3 + statusBarHeight // footer + spacing, then the status bar
 		vpHeight := msg.Height - headerHeight - footerHeight
 		if vpHeight < 1 {
 			vpHeight = 1
@@ -35,49 +42,336 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.Width = msg.Width
 			m.viewport.Height = vpHeight
 		}
+		m.ensureColumnVisible()
+		m.refreshDetail()
+		m.refreshHelp()
 		return m, nil
 
 	case clockTickMsg:
 		m.currentTime = time.Time(msg)
-		return m, clockTickCmd()
+		if m.timer != nil && m.timer.TaskID == m.activityTask && m.viewMode == ViewModeDetail {
+			// Keep the logged time counting up
+			m.refreshDetail()
+		}
+		return m, tea.Batch(clockTickCmd(), m.heartbeatDue(), m.boardStateDue(), m.refreshDue(), m.advancePomodoro(), m.remindersDue(), m.snoozeDue())
+
+	case revisionCheckedMsg:
+		if msg.revision == m.revision {
+			return m, nil
+		}
+		return m, m.refreshBoard()
+
+	case sessionOpenedMsg:
+		m.sessionID = msg.id
+		m.sessionOwner = msg.owner
+		m.lastHeartbeat = m.currentTime
+		return m, nil
+
+	case sessionCheckedMsg:
+		wasReadOnly := m.readOnly()
+		m.sessionOwner = msg.owner
+		if wasReadOnly && !m.readOnly() {
+			// The other TUI closed: pick up whatever it changed
+			m.err = nil
+			return m, m.loadTasks()
+		}
+		return m, nil
 
 	case tasksLoadedMsg:
-		m.organizeTasks(msg.tasks)
+		m.strictWIP = msg.strictWIP
+		// A read-only board keeps the sorts, collapsed columns and swimlanes
+		// picked in this window
+		if m.sortModes == nil || !m.readOnly() {
+			m.sortModes = msg.sortModes
+		}
+		if m.collapsedColumns == nil || !m.readOnly() {
+			m.collapsedColumns = msg.collapsed
+			m.lanes = msg.lanes
+		}
+		m.revision = msg.revision
+		m.timer = msg.timer
+		m.columnWidths = msg.widths
+		m.organizeTasks(msg.columns, msg.tasks)
+		var cmd tea.Cmd
+		if m.openWithView {
+			m.openWithView = false
+			if msg.state != nil {
+				m.restoreBoardState(*msg.state)
+			} else if msg.view != nil {
+				cmd = m.applyView(*msg.view)
+			}
+		}
+		if m.agendaFollow != 0 {
+			m.selectTask(m.agendaFollow)
+			m.agendaFollow = 0
+		}
+		if msg.woken > 0 {
+			m.showNotice(fmt.Sprintf("☀ %s back from snooze", pluralize(msg.woken, "task", "tasks")))
+		}
+		m.pruneSelection()
 		m.err = nil
+		m.refreshDetail()
+		if msg.welcome && !m.welcomeShown && m.viewMode == ViewModeBoard {
+			m.welcomeShown = true
+			m.viewMode = ViewModeWelcome
+		}
+		if m.viewMode == ViewModeDashboard {
+			return m, tea.Batch(m.loadDashboard(), m.webhooksDue(), cmd)
+		}
+		if m.viewMode == ViewModeCalendar {
+			return m, tea.Batch(m.loadCalendar(), m.webhooksDue(), cmd)
+		}
+		if m.viewMode == ViewModeAgenda {
+			return m, tea.Batch(m.loadAgenda(), m.webhooksDue(), cmd)
+		}
+		return m, tea.Batch(m.loadDetailActivity(), m.webhooksDue(), cmd)
+
+	case webhooksMsg:
+		return m, m.webhooksDelivered(msg)
+
+	case remindersMsg:
+		if len(msg.tasks) > 0 {
+			m.showNotice(reminderText(msg.tasks, m.currentTime))
+		}
+		return m, nil
+
+	case pomodoroMsg:
+		if msg.err != nil {
+			m.focus = nil
+			m.err = msg.err
+			return m, m.loadTasks()
+		}
+		m.timer = msg.timer
+		m.showNotice(msg.notice)
+		m.refreshDetail()
+		return m, m.loadDetailActivity()
+
+	case timerChangedMsg:
+		m.timer = msg.timer
+		m.showNotice(timerNotice(msg))
+		m.refreshDetail()
+		return m, m.loadDetailActivity()
+
+	case activityLoadedMsg:
+		m.activityTask = msg.taskID
+		m.activity = msg.entries
+		m.detailLogged = msg.logged
+		m.refreshDetail()
 		return m, nil
 
-	case taskCreatedMsg:
+	case taskChangedMsg:
+		m.history.record(msg.op)
+		if msg.follow {
+			m.followTaskID = msg.op.taskID()
+		}
+		notice := m.changeNotice(msg.op)
+		if unblocked := m.flashUnblocked(msg.op); unblocked != "" {
+			if notice != "" {
+				notice += ", "
+			}
+			notice += unblocked
+		}
+		m.askCompleteEpic(msg.op, &notice)
+		if notice != "" {
+			m.showNotice(notice)
+		}
+		if next := msg.op.repeat; next != nil {
+			notice = "repeats: next occurrence created"
+			if next.Due != nil {
+				notice = "repeats: next occurrence due " + next.Due.Format(dates.DateFormat)
+			}
+			m.showNotice(notice)
+		}
 		return m, m.loadTasks()
 
-	case taskUpdatedMsg:
+	case viewsLoadedMsg:
+		m.savedViews = msg.views
+		m.defaultView = msg.defaultView
+		if m.viewCursor < 0 {
+			m.viewCursor = 0
+			for i, v := range m.savedViews {
+				if strings.EqualFold(v.Name, m.activeView) {
+					m.viewCursor = i
+				}
+			}
+		}
+		if m.viewCursor >= len(m.savedViews) {
+			m.viewCursor = max(0, len(m.savedViews)-1)
+		}
+		return m, nil
+
+	case viewsChangedMsg:
+		m.showNotice(msg.notice)
+		return m, m.loadViews()
+
+	case templatesLoadedMsg:
+		m.templates = msg.templates
+		return m, nil
+
+	case templateSavedMsg:
+		m.showNotice(fmt.Sprintf("saved template %s", msg.name))
+		return m, nil
+
+	case clipboardCopiedMsg:
+		m.showNotice(copiedNotice(msg))
+		return m, nil
+
+	case linkOpenedMsg:
+		if msg.err != nil {
+			m.showNotice(fmt.Sprintf("could not open %s: %v", truncateText(msg.url, 40), msg.err))
+		} else {
+			m.showNotice("opened " + truncateText(msg.url, 50))
+		}
+		return m, nil
+
+	case clipboardPastedMsg:
+		if len(m.columns) == 0 || m.viewMode != ViewModeBoard {
+			return m, nil
+		}
+		switch len(msg.titles) {
+		case 0:
+			m.showNotice("nothing to paste: the clipboard is empty")
+		case 1:
+			return m, m.createPastedTasks(msg.titles, m.columns[m.currentColumn].Status)
+		default:
+			// Several lines may be a paste by mistake, so ask first
+			m.pasteTitles = msg.titles
+			m.viewMode = ViewModeConfirmPaste
+		}
+		return m, nil
+
+	case tasksPastedMsg:
+		m.history.record(msg.op)
+		m.followTaskID = msg.op.parts[0].taskID()
+		m.showNotice("pasted " + pluralize(len(msg.op.parts), "task", "tasks"))
 		return m, m.loadTasks()
 
-	case taskDeletedMsg:
+	case bulkAppliedMsg:
+		m.history.record(msg.op)
+		m.selected = nil
+		notice := msg.notice
+		m.askCompleteEpic(msg.op, &notice)
+		m.showNotice(notice)
 		return m, m.loadTasks()
 
-	case descriptionUpdatedMsg:
+	case historyAppliedMsg:
+		m.historyBusy = false
+		if msg.undo {
+			m.history.redo = append(m.history.redo, msg.op)
+		} else {
+			m.history.undo = append(m.history.undo, msg.op)
+		}
+		m.followOperation(msg.op, msg.undo)
+		if msg.undo {
+			m.showNotice("undone")
+		} else {
+			m.showNotice("redone")
+		}
 		return m, m.loadTasks()
 
-	case tagsUpdatedMsg:
+	case columnsUpdatedMsg:
+		m.followColumn = msg.status
 		return m, m.loadTasks()
 
-	case dueUpdatedMsg:
+	case trashLoadedMsg:
+		m.trash = msg.tasks
+		if m.trashCursor >= len(m.trash) {
+			m.trashCursor = len(m.trash) - 1
+		}
+		if m.trashCursor < 0 {
+			m.trashCursor = 0
+		}
+		return m, nil
+
+	case trashUpdatedMsg:
+		return m, tea.Batch(m.loadTrash(), m.loadTasks())
+
+	case calendarLoadedMsg:
+		m.calendar = &msg.data
+		if count := len(m.calendarDayTasks()); m.calendarCursor >= count {
+			m.calendarCursor = max(count-1, 0)
+		}
+		return m, nil
+
+	case agendaLoadedMsg:
+		m.agendaSections = msg.sections
+		if m.agendaSections == nil {
+			m.agendaSections = []agenda.Section{}
+		}
+		if count := len(m.agendaEntries()); m.agendaCursor >= count {
+			m.agendaCursor = max(count-1, 0)
+		}
+		return m, nil
+
+	case agendaChangedMsg:
+		m.showNotice(fmt.Sprintf("✓ %s done", msg.title))
+		return m, m.loadAgenda()
+
+	case dashboardLoadedMsg:
+		m.dashboard = &msg.stats
+		return m, nil
+
+	case archiveLoadedMsg:
+		m.archive = msg.tasks
+		m.clampArchiveCursor()
+		return m, nil
+
+	case archiveUpdatedMsg:
+		return m, tea.Batch(m.loadArchive(), m.loadTasks())
+
+	case workspacesLoadedMsg:
+		m.workspaces = msg.workspaces
+		m.selectLastWorkspace()
+		return m, nil
+
+	case workspaceOpenedMsg:
+		return m.switchWorkspace(msg)
+
+	case editorFinishedMsg:
+		return m.finishEditor(msg)
+
+	case taskMovedToWorkspaceMsg:
+		m.viewMode = ViewModeBoard
+		m.movingTask = 0
+		m.showNotice(fmt.Sprintf("moved %q to %s", truncateText(msg.title, 40), msg.workspace))
 		return m, m.loadTasks()
 
+	case labelsLoadedMsg:
+		// The picker opened with # offers the task's tags only
+		if m.labelTask == 0 {
+			m.labelOptions = mergeLabels(msg.labels, m.labelSelected)
+		}
+		return m, nil
+
+	case conflictMsg:
+		m.conflict = &msg.conflict
+		m.viewMode = ViewModeConflict
+		return m, nil
+
 	case errMsg:
 		m.err = msg.err
+		m.historyBusy = false
 		return m, nil
 
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
+
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
 	}
 
 	// Handle text input updates
-	if m.viewMode == ViewModeAddTask || m.viewMode == ViewModeEditTask || m.viewMode == ViewModeEditTags {
+	if m.viewMode == ViewModeAddTask || m.viewMode == ViewModeEditTask {
 		m.textInput, cmd = m.textInput.Update(msg)
 		return m, cmd
 	}
 
+	// Handle tag picker input updates
+	if m.viewMode == ViewModeEditTags || m.viewMode == ViewModeFilterLabel {
+		m.labelInput, cmd = m.labelInput.Update(msg)
+		return m, cmd
+	}
+
 	// Handle textarea updates
 	if m.viewMode == ViewModeEditDescription {
 		m.textArea, cmd = m.textArea.Update(msg)
@@ -90,38 +384,219 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	// Handle archive search input updates
+	if m.viewMode == ViewModeArchiveSearch {
+		m.archiveInput, cmd = m.archiveInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle column name input updates
+	if m.viewMode == ViewModeAddColumn || m.viewMode == ViewModeRenameColumn {
+		m.columnInput, cmd = m.columnInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle WIP limit input updates
+	if m.viewMode == ViewModeEditWIP {
+		m.wipInput, cmd = m.wipInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle checklist item input updates
+	if m.viewMode == ViewModeAddSubtask {
+		m.subtaskInput, cmd = m.subtaskInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle workspace switcher input updates
+	if m.viewMode == ViewModeWorkspaces {
+		m.workspaceInput, cmd = m.workspaceInput.Update(msg)
+		return m, cmd
+	}
+
 	// Handle due input updates
 	if m.viewMode == ViewModeEditDue {
 		m.dueInput, cmd = m.dueInput.Update(msg)
 		return m, cmd
 	}
 
+	if m.viewMode == ViewModeEditRecurrence {
+		m.recurrenceInput, cmd = m.recurrenceInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.viewMode == ViewModeSnooze {
+		m.snoozeInput, cmd = m.snoozeInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.viewMode == ViewModeFilter {
+		m.filterInput, cmd = m.filterInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.viewMode == ViewModePalette {
+		m.paletteInput, cmd = m.paletteInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.viewMode == ViewModeEditLink {
+		m.linkInput, cmd = m.linkInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.viewMode == ViewModeDependencies {
+		m.dependencyInput, cmd = m.dependencyInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.viewMode == ViewModeEpic {
+		m.epicInput, cmd = m.epicInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.viewMode == ViewModeSaveView {
+		m.viewInput, cmd = m.viewInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.viewMode == ViewModeBoardSettings {
+		return m.updateSettingsInput(msg)
+	}
+
 	return m, nil
 }
 
 // handleKeyPress handles keyboard input
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// y waits for p to duplicate the task; any other key cancels it
+	if m.pendingYank {
+		m.pendingYank = false
+		m.notice = ""
+		if m.viewMode == ViewModeBoard && msg.String() == "p" {
+			if task := m.getCurrentTask(); task != nil {
+				return m, m.duplicateTask(task)
+			}
+			return m, nil
+		}
+	}
+
+	// Any key closes the welcome overlay
+	if m.viewMode == ViewModeWelcome {
+		return m.dismissWelcome()
+	}
+
 	// Global keys
-	switch msg.String() {
-	case "ctrl+c", "q":
+	switch {
+	case key.Matches(msg, m.keys.Quit):
 		if m.viewMode == ViewModeBoard {
 			return m, tea.Quit
 		}
-	case "esc":
+	case msg.String() == "esc":
+		if m.viewMode == ViewModeSearch {
+			// Cancelling the search input also clears the live filter
+			m.viewMode = ViewModeBoard
+			m.searchInput.SetValue("")
+			m.searchQuery = ""
+			m.ensureTaskVisible()
+			return m, nil
+		}
+		if m.viewMode == ViewModeArchiveSearch || (m.viewMode == ViewModeArchive && m.archiveQuery != "") {
+			// Clear the archive search before leaving the archive
+			m.viewMode = ViewModeArchive
+			m.archiveInput.SetValue("")
+			m.archiveQuery = ""
+			m.clampArchiveCursor()
+			return m, nil
+		}
+		if m.viewMode == ViewModeConfirmPurge {
+			m.pendingDeleteID = 0
+			m.viewMode = ViewModeTrash
+			return m, nil
+		}
+		if m.viewMode == ViewModeTemplates {
+			m.viewMode = ViewModeAddTask
+			return m, nil
+		}
+		if m.viewMode == ViewModeAddSubtask {
+			m.viewMode = ViewModeDetail
+			m.subtaskInput.SetValue("")
+			m.refreshDetail()
+			return m, nil
+		}
+		if m.viewMode == ViewModeLinks {
+			m.viewMode = m.linkReturn
+			return m, nil
+		}
+		if m.viewMode == ViewModeDependencies {
+			m.closeDependencies()
+			return m, nil
+		}
+		if m.viewMode == ViewModeEpic {
+			m.closeEpic()
+			return m, nil
+		}
+		if m.viewMode == ViewModeSaveView {
+			m.viewMode = ViewModeViews
+			m.viewInput.SetValue("")
+			return m, nil
+		}
+		if m.viewMode == ViewModeBoardSettings {
+			m.closeBoardSettings()
+			return m, nil
+		}
+		if m.viewMode == ViewModeSnooze {
+			m.closeSnoozePicker()
+			return m, nil
+		}
 		if m.viewMode != ViewModeBoard {
+			if m.viewMode == ViewModeAddTask || m.viewMode == ViewModeSaveTemplate {
+				// Errors belong to the discarded input
+				m.err = nil
+			}
+			m.fromTemplate = nil
+			m.templateDraft = nil
+			m.pasteTitles = nil
 			m.viewMode = ViewModeBoard
 			m.textInput.SetValue("")
 			return m, nil
 		}
-		// If in board mode with active search, clear search first
-		if m.searchQuery != "" {
-			m.searchQuery = ""
-			m.searchInput.SetValue("")
+		// In board mode, drop a half-typed count, clear the selection, then
+		// the tag filter set with #, then active filters, then zoom out
+		pending := m.vimPending() != ""
+		m.pendingCount = 0
+		m.pendingG = false
+		m.gotoID = ""
+		if len(m.selected) > 0 {
+			m.selected = nil
+			return m, nil
+		}
+		if m.quickLabel != "" && m.labelFilter == m.quickLabel {
+			m.labelFilter = ""
+			m.quickLabel = ""
+			m.ensureTaskVisible()
+			return m, nil
+		}
+		if m.filtered() {
+			m.clearFilters()
+			m.ensureColumnVisible()
+			m.ensureTaskVisible()
+			return m, nil
+		}
+		if m.zoomed {
+			m.toggleZoom()
+			return m, nil
+		}
+		if pending {
 			return m, nil
 		}
 		return m, tea.Quit
 	}
 
+	if m.blockReadOnly(msg) {
+		return m, nil
+	}
+
 	// Mode-specific keys
 	switch m.viewMode {
 	case ViewModeBoard:
@@ -136,12 +611,86 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleEditTagsKeys(msg)
 	case ViewModeEditDue:
 		return m.handleEditDueKeys(msg)
+	case ViewModeEditRecurrence:
+		return m.handleEditRecurrenceKeys(msg)
+	case ViewModeSnooze:
+		return m.handleSnoozeKeys(msg)
+	case ViewModeSnoozed:
+		return m.handleSnoozedKeys(msg)
 	case ViewModeConfirmDelete:
 		return m.handleConfirmDeleteKeys(msg)
 	case ViewModeHelp:
 		return m.handleHelpKeys(msg)
 	case ViewModeSearch:
 		return m.handleSearchKeys(msg)
+	case ViewModeFilterLabel:
+		return m.handleFilterLabelKeys(msg)
+	case ViewModeFilter:
+		return m.handleFilterKeys(msg)
+	case ViewModePalette:
+		return m.handlePaletteKeys(msg)
+	case ViewModeEditLink:
+		return m.handleEditLinkKeys(msg)
+	case ViewModeDependencies:
+		return m.handleDependencyKeys(msg)
+	case ViewModeEpic:
+		return m.handleEpicKeys(msg)
+	case ViewModeConfirmEpic:
+		return m.handleConfirmEpicKeys(msg)
+	case ViewModeViews:
+		return m.handleViewKeys(msg)
+	case ViewModeBoardSettings:
+		return m.handleBoardSettingsKeys(msg)
+	case ViewModeSaveView:
+		return m.handleSaveViewKeys(msg)
+	case ViewModeLinks:
+		return m.handleLinkPickerKeys(msg)
+	case ViewModeDetail:
+		return m.handleDetailKeys(msg)
+	case ViewModeAddSubtask:
+		return m.handleAddSubtaskKeys(msg)
+	case ViewModeAddColumn, ViewModeRenameColumn:
+		return m.handleColumnNameKeys(msg)
+	case ViewModeDeleteColumn:
+		return m.handleDeleteColumnKeys(msg)
+	case ViewModeEditWIP:
+		return m.handleEditWIPKeys(msg)
+	case ViewModeConfirmWIP:
+		return m.handleConfirmWIPKeys(msg)
+	case ViewModeConfirmBlocked:
+		return m.handleConfirmBlockedKeys(msg)
+	case ViewModeTrash:
+		return m.handleTrashKeys(msg)
+	case ViewModeConfirmPurge:
+		return m.handleConfirmPurgeKeys(msg)
+	case ViewModeWorkspaces:
+		return m.handleWorkspaceKeys(msg)
+	case ViewModeArchive:
+		return m.handleArchiveKeys(msg)
+	case ViewModeDashboard:
+		return m.handleDashboardKeys(msg)
+	case ViewModeCalendar:
+		return m.handleCalendarKeys(msg)
+	case ViewModeAgenda:
+		return m.handleAgendaKeys(msg)
+	case ViewModeArchiveSearch:
+		return m.handleArchiveSearchKeys(msg)
+	case ViewModeConfirmArchiveColumn:
+		return m.handleConfirmArchiveColumnKeys(msg)
+	case ViewModeBulkMove, ViewModeBulkPriority:
+		return m.handleBulkPickerKeys(msg)
+	case ViewModeBulkTag:
+		return m.handleBulkTagKeys(msg)
+	case ViewModeConfirmBulkDelete:
+		return m.handleConfirmBulkDeleteKeys(msg)
+	case ViewModeSaveTemplate:
+		return m.handleSaveTemplateKeys(msg)
+	case ViewModeTemplates:
+		return m.handleTemplatePickerKeys(msg)
+	case ViewModeConfirmPaste:
+		return m.handleConfirmPasteKeys(msg)
+	case ViewModeConflict:
+		return m.handleConflictKeys(msg)
 	}
 
 	return m, nil
@@ -149,43 +698,140 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // handleBoardKeys handles keyboard input in board view mode
 func (m Model) handleBoardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "left", "h":
+	if len(m.columns) == 0 {
+		// Columns haven't loaded yet
+		return m, nil
+	}
+	next, cmd, ok := m.handleCountKeys(msg)
+	if ok {
+		return next, cmd
+	}
+	m = next.(Model)
+	if len(m.selected) > 0 {
+		if next, cmd, ok := m.handleBulkKeys(msg); ok {
+			return next, cmd
+		}
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Left):
+		m.stepColumn(-1, true)
+		return m, nil
+
+	case key.Matches(msg, m.keys.Right):
+		m.stepColumn(1, true)
+		return m, nil
+
+	case key.Matches(msg, m.keys.FocusLeft):
+		m.stepColumn(-1, false)
+		return m, nil
+
+	case key.Matches(msg, m.keys.FocusRight):
+		m.stepColumn(1, false)
+		return m, nil
+
+	case key.Matches(msg, m.keys.Collapse):
+		return m.toggleCollapse()
+
+	case key.Matches(msg, m.keys.Lanes):
+		return m.cycleLanes()
+
+	case key.Matches(msg, m.keys.WrapTitles):
+		m.toggleTitleLines()
+		return m, nil
+
+	case key.Matches(msg, m.keys.ColumnLeft):
 		if m.currentColumn > 0 {
-			m.currentColumn--
-			m.currentTask = 0
+			col := m.columns[m.currentColumn]
+			return m, m.moveColumn(col.Status, -1)
 		}
 		return m, nil
 
-	case "right", "l":
+	case key.Matches(msg, m.keys.ColumnRight):
 		if m.currentColumn < len(m.columns)-1 {
-			m.currentColumn++
-			m.currentTask = 0
+			col := m.columns[m.currentColumn]
+			return m, m.moveColumn(col.Status, 1)
 		}
 		return m, nil
 
-	case "up", "k":
+	case key.Matches(msg, m.keys.Settings):
+		m.openBoardSettings()
+		return m, nil
+
+	case key.Matches(msg, m.keys.AddColumn):
+		m.viewMode = ViewModeAddColumn
+		m.columnInput.SetValue("")
+		m.columnInput.Focus()
+		return m, nil
+
+	case key.Matches(msg, m.keys.RenameColumn):
+		m.viewMode = ViewModeRenameColumn
+		m.columnInput.SetValue(m.columns[m.currentColumn].Name)
+		m.columnInput.CursorEnd()
+		m.columnInput.Focus()
+		return m, nil
+
+	case key.Matches(msg, m.keys.DeleteColumn):
+		if len(m.columns) == 1 {
+			m.err = fmt.Errorf("cannot delete the last column")
+			return m, nil
+		}
+		m.viewMode = ViewModeDeleteColumn
+		// Default to moving tasks into the neighbouring column
+		m.columnTarget = m.currentColumn + 1
+		if m.columnTarget >= len(m.columns) {
+			m.columnTarget = m.currentColumn - 1
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
 		if m.currentTask > 0 {
 			m.currentTask--
 			m.ensureTaskVisible()
 		}
 		return m, nil
 
-	case "down", "j":
-		col := m.columns[m.currentColumn]
-		if m.currentTask < len(col.Tasks)-1 {
+	case key.Matches(msg, m.keys.Down):
+		if m.currentTask < len(m.visibleTaskIndices(m.currentColumn))-1 {
 			m.currentTask++
 			m.ensureTaskVisible()
 		}
 		return m, nil
 
-	case "a":
+	case key.Matches(msg, m.keys.PageUp):
+		m.pageTasks(-1)
+		return m, nil
+
+	case key.Matches(msg, m.keys.PageDown):
+		m.pageTasks(1)
+		return m, nil
+
+	case key.Matches(msg, m.keys.Top):
+		m.currentTask = 0
+		m.ensureTaskVisible()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Bottom):
+		m.currentTask = len(m.visibleTaskIndices(m.currentColumn)) - 1
+		m.ensureTaskVisible()
+		return m, nil
+
+	case key.Matches(msg, m.keys.MoveTaskUp):
+		return m.reorderTask(-1)
+
+	case key.Matches(msg, m.keys.MoveTaskDown):
+		return m.reorderTask(1)
+
+	case key.Matches(msg, m.keys.Add):
 		m.viewMode = ViewModeAddTask
 		m.textInput.SetValue("")
 		m.textInput.Focus()
 		return m, nil
 
-	case "e", "enter":
+	case key.Matches(msg, m.keys.Details):
+		return m, m.openDetail()
+
+	case key.Matches(msg, m.keys.Edit):
 		task := m.getCurrentTask()
 		if task != nil {
 			m.viewMode = ViewModeEditTask
@@ -194,7 +840,7 @@ func (m Model) handleBoardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case "d", "delete":
+	case key.Matches(msg, m.keys.Delete):
 		task := m.getCurrentTask()
 		if task != nil {
 			m.pendingDeleteID = task.ID
@@ -202,18 +848,61 @@ func (m Model) handleBoardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case "m":
-		task := m.getCurrentTask()
-		if task != nil {
-			nextColumn := (m.currentColumn + 1) % len(m.columns)
-			m.currentColumn = nextColumn
-			m.followTaskID = task.ID
-			return m, m.moveTask(task, nextColumn)
+	case key.Matches(msg, m.keys.Archive):
+		if task := m.getCurrentTask(); task != nil {
+			return m, m.archiveTask(task)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.ArchiveColumn):
+		if len(m.columns[m.currentColumn].Tasks) > 0 {
+			m.viewMode = ViewModeConfirmArchiveColumn
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.ArchiveView):
+		return m.showArchive()
+
+	case key.Matches(msg, m.keys.Snooze):
+		if task := m.getCurrentTask(); task != nil {
+			m.openSnoozePicker(task, ViewModeBoard)
 		}
 		return m, nil
 
-	case "i":
+	case key.Matches(msg, m.keys.SnoozedView):
+		m.showSnoozed()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Move):
+		return m.startMove((m.currentColumn + 1) % len(m.columns))
+
+	case key.Matches(msg, m.keys.Zoom):
+		m.toggleZoom()
+		return m, nil
+
+	case key.Matches(msg, m.keys.WIPLimit):
+		col := m.columns[m.currentColumn]
+		m.viewMode = ViewModeEditWIP
+		m.wipInput.SetValue("")
+		if col.WIPLimit > 0 {
+			m.wipInput.SetValue(strconv.Itoa(col.WIPLimit))
+		}
+		m.wipInput.CursorEnd()
+		m.wipInput.Focus()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Editor):
+		if task := m.getCurrentTask(); task != nil {
+			return m, m.editInEditor(*task, task.Description)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Description):
 		task := m.getCurrentTask()
+		if task != nil && len([]rune(task.Description)) > m.textArea.CharLimit {
+			// The text area would cut the description off
+			return m, m.editInEditor(*task, task.Description)
+		}
 		if task != nil {
 			m.viewMode = ViewModeEditDescription
 			// Expand textarea to fit available width when editing description
@@ -233,43 +922,617 @@ func (m Model) handleBoardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case "t":
-		task := m.getCurrentTask()
-		if task != nil {
-			m.viewMode = ViewModeEditTags
-			m.textInput.SetValue(strings.Join(task.Tags, ", "))
-			m.textInput.Focus()
+	case key.Matches(msg, m.keys.Tags):
+		task := m.getCurrentTask()
+		if task != nil {
+			m.viewMode = ViewModeEditTags
+			m.labelSelected = append([]string{}, task.Tags...)
+			m.labelOptions = mergeLabels(m.knownLabels(), m.labelSelected)
+			m.labelCursor = 0
+			m.labelInput.SetValue("")
+			m.labelInput.Focus()
+			return m, m.loadLabels()
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.FilterLabel):
+		// Toggle filtering the board by a single tag
+		if m.labelFilter != "" {
+			m.labelFilter = ""
+			m.quickLabel = ""
+			m.ensureTaskVisible()
+			return m, nil
+		}
+		m.viewMode = ViewModeFilterLabel
+		m.labelTask = 0
+		m.labelSelected = nil
+		m.labelOptions = m.knownLabels()
+		m.labelCursor = 0
+		m.labelInput.SetValue("")
+		m.labelInput.Focus()
+		return m, m.loadLabels()
+
+	case key.Matches(msg, m.keys.QuickLabel):
+		return m.filterByTaskTag()
+
+	case key.Matches(msg, m.keys.Undo):
+		return m.undo()
+
+	case key.Matches(msg, m.keys.Redo):
+		return m.redo()
+
+	case key.Matches(msg, m.keys.Due):
+		task := m.getCurrentTask()
+		if task != nil {
+			m.viewMode = ViewModeEditDue
+			if task.Due != nil {
+				m.dueInput.SetValue(task.Due.Format("2006-01-02"))
+			} else {
+				m.dueInput.SetValue("")
+			}
+			m.dueInput.Focus()
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.EditLink):
+		m.startEditLink(m.getCurrentTask())
+		return m, nil
+
+	case key.Matches(msg, m.keys.OpenLink):
+		return m.openTaskLinks(m.getCurrentTask())
+
+	case key.Matches(msg, m.keys.Dependencies):
+		m.openDependencies()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Epic):
+		m.openEpic()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Timer):
+		// Timing by hand ends focus mode
+		m.focus = nil
+		return m, m.toggleTimer(m.getCurrentTask())
+
+	case key.Matches(msg, m.keys.Pomodoro):
+		return m.togglePomodoro(m.getCurrentTask())
+
+	case key.Matches(msg, m.keys.Duplicate):
+		if m.getCurrentTask() != nil {
+			m.pendingYank = true
+			m.showNotice("y: press p to duplicate the task")
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.SaveTemplate):
+		if task := m.getCurrentTask(); task != nil {
+			return m.startSaveTemplate(task)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Copy):
+		if task := m.getCurrentTask(); task != nil {
+			return m, copyToClipboard("title", task.Title)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.CopyMarkdown):
+		if task := m.getCurrentTask(); task != nil {
+			return m, copyToClipboard("task as markdown", taskMarkdown(task))
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Paste):
+		return m, pasteClipboard()
+
+	case key.Matches(msg, m.keys.Repeat):
+		if task := m.getCurrentTask(); task != nil {
+			m.openRecurrencePicker(task)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Priority):
+		task := m.getCurrentTask()
+		if task != nil {
+			m.followTaskID = task.ID
+			return m, m.updatePriority(task, task.Priority.Next())
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Pin):
+		if task := m.getCurrentTask(); task != nil {
+			m.followTaskID = task.ID
+			return m, m.pinTask(task, !task.Pinned)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.UrgentOnly):
+		// Toggle showing only high and urgent tasks
+		m.urgentOnly = !m.urgentOnly
+		m.currentTask = 0
+		m.ensureTaskVisible()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Filter):
+		m.openFilterPicker()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Palette):
+		m.openPalette()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Sort):
+		return m.cycleSort()
+
+	case key.Matches(msg, m.keys.Views):
+		return m, m.openViews()
+
+	case key.Matches(msg, m.keys.Help):
+		m.viewMode = ViewModeHelp
+		m.helpViewport.GotoTop()
+		m.refreshHelp()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Search):
+		m.viewMode = ViewModeSearch
+		m.searchInput.SetValue(m.searchQuery)
+		m.searchInput.Focus()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Trash):
+		m.viewMode = ViewModeTrash
+		m.trashCursor = 0
+		return m, m.loadTrash()
+
+	case key.Matches(msg, m.keys.Dashboard):
+		return m.showDashboard()
+
+	case key.Matches(msg, m.keys.Calendar):
+		return m.showCalendar()
+
+	case key.Matches(msg, m.keys.Agenda):
+		return m.showAgenda()
+
+	case key.Matches(msg, m.keys.MoveToWS):
+		return m.showMoveToWorkspace()
+
+	case key.Matches(msg, m.keys.Workspace):
+		return m.showWorkspaces()
+
+	case key.Matches(msg, m.keys.Refresh):
+		// Refresh: reload tasks from database
+		return m, m.loadTasks()
+
+	case key.Matches(msg, m.keys.Select):
+		m.toggleSelected()
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// openDetail opens the detail view of the selected task and loads its history
+func (m *Model) openDetail() tea.Cmd {
+	task := m.getCurrentTask()
+	if task == nil {
+		return nil
+	}
+	m.viewMode = ViewModeDetail
+	m.detailReturn = ViewModeBoard
+	m.subtaskCursor = 0
+	m.detailViewport.GotoTop()
+	m.refreshDetail()
+	return m.loadActivity(task.ID)
+}
+
+// startMove moves the selected task into the target column, asking first
+// when the task is blocked and the column is one for work in progress, or
+// when that goes past the column's WIP limit
+func (m Model) startMove(target int) (tea.Model, tea.Cmd) {
+	task := m.getCurrentTask()
+	if task == nil || target == m.currentColumn {
+		return m, nil
+	}
+	if m.isDoingColumn(target) && len(m.openBlockers(*task)) > 0 {
+		m.pendingMoveID = task.ID
+		m.pendingMoveTo = target
+		m.viewMode = ViewModeConfirmBlocked
+		return m, nil
+	}
+	return m.checkWIP(task, target)
+}
+
+// checkWIP moves a task into the target column, asking first when that
+// goes past the column's WIP limit
+func (m Model) checkWIP(task *model.Task, target int) (tea.Model, tea.Cmd) {
+	col := m.columns[target]
+	if col.OverWIPLimit(len(col.Tasks) + 1) {
+		if m.strictWIP {
+			m.err = fmt.Errorf("WIP limit reached: %s has %d/%d tasks", col.Name, len(col.Tasks), col.WIPLimit)
+			return m, nil
+		}
+		m.pendingMoveID = task.ID
+		m.pendingMoveTo = target
+		m.viewMode = ViewModeConfirmWIP
+		return m, nil
+	}
+	return m.moveToColumn(task, target)
+}
+
+// moveToColumn moves a task to the target column and follows it, unless
+// the view hides the column. A collapsed column opens up for a moment to
+// show the task arriving, and the focus stays put.
+func (m Model) moveToColumn(task *model.Task, target int) (tea.Model, tea.Cmd) {
+	if m.columnHidden(target) {
+		return m, m.moveTask(task, target)
+	}
+	if m.isCollapsed(target) {
+		m.expandBriefly(target)
+		return m, m.moveTask(task, target)
+	}
+	m.currentColumn = target
+	m.followTaskID = task.ID
+	m.ensureColumnVisible()
+	return m, m.moveTask(task, target)
+}
+
+// handleConfirmBlockedKeys handles the prompt shown before starting work
+// on a blocked task
+func (m Model) handleConfirmBlockedKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.viewMode = ViewModeBoard
+		task := m.getCurrentTask()
+		id := m.pendingMoveID
+		m.pendingMoveID = 0
+		if task != nil && task.ID == id && m.pendingMoveTo < len(m.columns) {
+			return m.checkWIP(task, m.pendingMoveTo)
+		}
+		return m, nil
+
+	case "n", "N", "esc":
+		m.pendingMoveID = 0
+		m.viewMode = ViewModeBoard
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handleConfirmWIPKeys handles the prompt shown before moving a task past a WIP limit
+func (m Model) handleConfirmWIPKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.viewMode = ViewModeBoard
+		task := m.getCurrentTask()
+		id := m.pendingMoveID
+		m.pendingMoveID = 0
+		if task != nil && task.ID == id && m.pendingMoveTo < len(m.columns) {
+			return m.moveToColumn(task, m.pendingMoveTo)
+		}
+		return m, nil
+
+	case "n", "N", "esc":
+		m.pendingMoveID = 0
+		m.viewMode = ViewModeBoard
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handleEditWIPKeys handles keyboard input when setting a column's WIP limit
+func (m Model) handleEditWIPKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		limit, err := parseWIPLimit(m.wipInput.Value())
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.viewMode = ViewModeBoard
+		m.wipInput.SetValue("")
+		m.err = nil
+		return m, m.setWIPLimit(m.columns[m.currentColumn].Status, limit)
+	}
+
+	var cmd tea.Cmd
+	m.wipInput, cmd = m.wipInput.Update(msg)
+	return m, cmd
+}
+
+// parseWIPLimit parses a typed WIP limit; an empty one is no limit
+func parseWIPLimit(input string) (int, error) {
+	value := strings.TrimSpace(input)
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid WIP limit %q: must be a whole number", value)
+	}
+	return limit, nil
+}
+
+// reorderTask moves the selected task up (delta < 0) or down (delta > 0) past
+// its visible neighbour. The swap is applied locally right away so repeated
+// keypresses act on the updated order before the reload arrives.
+func (m Model) reorderTask(delta int) (tea.Model, tea.Cmd) {
+	// Past the edge of its swimlane the task moves into the next lane
+	if crossed, moved, cmd := m.moveAcrossLane(delta); crossed {
+		return moved, cmd
+	}
+	if m.refuseReorder() {
+		return m, nil
+	}
+
+	visible := m.visibleTaskIndices(m.currentColumn)
+	target := m.currentTask + delta
+	if m.currentTask < 0 || m.currentTask >= len(visible) || target < 0 || target >= len(visible) {
+		return m, nil
+	}
+
+	a, b := visible[m.currentTask], visible[target]
+	if tasks := m.columns[m.currentColumn].Tasks; tasks[a].Pinned != tasks[b].Pinned {
+		m.showNotice("pinned tasks stay above the others")
+		return m, nil
+	}
+
+	// Copy the task slice so the swap doesn't affect models sharing it
+	col := &m.columns[m.currentColumn]
+	col.Tasks = append([]model.Task(nil), col.Tasks...)
+	col.Tasks[a], col.Tasks[b] = col.Tasks[b], col.Tasks[a]
+	m.currentTask = target
+	m.ensureTaskVisible()
+
+	task := col.Tasks[b]
+	m.followTaskID = task.ID
+	return m, m.swapTasks(&task, col.Tasks[a].ID)
+}
+
+// handleDetailKeys handles keyboard input in the task detail view
+func (m Model) handleDetailKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	task := m.getCurrentTask()
+	if task == nil {
+		m.viewMode = ViewModeBoard
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		switch m.detailReturn {
+		case ViewModeCalendar:
+			m.viewMode = ViewModeCalendar
+			return m, m.loadCalendar()
+		case ViewModeAgenda:
+			m.viewMode = ViewModeAgenda
+			return m, m.loadAgenda()
+		}
+		m.viewMode = ViewModeBoard
+		return m, nil
+
+	case key.Matches(msg, m.keys.Edit, m.keys.Description, m.keys.Editor, m.keys.Tags, m.keys.Due, m.keys.Repeat, m.keys.EditLink, m.keys.SaveTemplate):
+		// Jump straight into the matching editor for the selected task
+		m.viewMode = ViewModeBoard
+		return m.handleBoardKeys(msg)
+
+	case key.Matches(msg, m.keys.Undo):
+		return m.undo()
+
+	case key.Matches(msg, m.keys.Redo):
+		return m.redo()
+
+	case key.Matches(msg, m.keys.Timer):
+		m.focus = nil
+		return m, m.toggleTimer(task)
+
+	case key.Matches(msg, m.keys.Copy):
+		return m, copyToClipboard("title", task.Title)
+
+	case key.Matches(msg, m.keys.CopyMarkdown):
+		return m, copyToClipboard("task as markdown", taskMarkdown(task))
+
+	case key.Matches(msg, m.keys.OpenLink):
+		return m.openTaskLinks(task)
+
+	case key.Matches(msg, m.keys.Dependencies):
+		m.openDependencies()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Epic):
+		m.openEpic()
+		return m, nil
+
+	case key.Matches(msg, m.keys.Pomodoro):
+		return m.togglePomodoro(task)
+
+	case key.Matches(msg, m.keys.RawMarkdown):
+		m.rawDescription = !m.rawDescription
+		m.refreshDetail()
+		return m, nil
+
+	case key.Matches(msg, m.keys.AddSubtask):
+		m.viewMode = ViewModeAddSubtask
+		m.subtaskInput.SetValue("")
+		m.subtaskInput.Focus()
+		m.refreshDetail()
+		return m, nil
+	}
+
+	// Checklist keys only apply once the task has items; otherwise arrows scroll
+	if len(task.Subtasks) > 0 {
+		switch {
+		case key.Matches(msg, m.keys.Up):
+			if m.subtaskCursor > 0 {
+				m.subtaskCursor--
+			}
+			m.refreshDetail()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Down):
+			if m.subtaskCursor < len(task.Subtasks)-1 {
+				m.subtaskCursor++
+			}
+			m.refreshDetail()
+			return m, nil
+
+		case key.Matches(msg, m.keys.ToggleSubtask):
+			m.followTaskID = task.ID
+			return m, m.toggleSubtask(task, task.Subtasks[m.subtaskCursor].ID)
+
+		case key.Matches(msg, m.keys.DeleteSubtask):
+			m.followTaskID = task.ID
+			return m, m.deleteSubtask(task, task.Subtasks[m.subtaskCursor].ID)
+
+		case key.Matches(msg, m.keys.MoveTaskUp):
+			if m.subtaskCursor > 0 {
+				m.subtaskCursor--
+				m.followTaskID = task.ID
+				return m, m.moveSubtask(task, task.Subtasks[m.subtaskCursor+1].ID, -1)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.MoveTaskDown):
+			if m.subtaskCursor < len(task.Subtasks)-1 {
+				m.subtaskCursor++
+				m.followTaskID = task.ID
+				return m, m.moveSubtask(task, task.Subtasks[m.subtaskCursor-1].ID, 1)
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.detailViewport, cmd = m.detailViewport.Update(msg)
+	return m, cmd
+}
+
+// handleAddSubtaskKeys handles keyboard input when adding a checklist item
+func (m Model) handleAddSubtaskKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		title := strings.TrimSpace(m.subtaskInput.Value())
+		task := m.getCurrentTask()
+		m.viewMode = ViewModeDetail
+		m.subtaskInput.SetValue("")
+		m.refreshDetail()
+		if title != "" && task != nil {
+			// Select the new item, which is appended to the end of the checklist
+			m.subtaskCursor = len(task.Subtasks)
+			m.followTaskID = task.ID
+			return m, m.addSubtask(task, title)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.subtaskInput, cmd = m.subtaskInput.Update(msg)
+	return m, cmd
+}
+
+// refreshDetail sizes the detail viewport and fills it with the selected task
+func (m *Model) refreshDetail() {
+	if m.viewMode != ViewModeDetail && m.viewMode != ViewModeAddSubtask {
+		return
+	}
+	width := m.width
+	if width <= 0 {
+		width = 80
+	}
+	height := m.height - 4 // title + spacing + help line
+	if m.viewMode == ViewModeAddSubtask {
+		height -= 4 // checklist item input box
+	}
+	if height < 3 {
+		height = 3
+	}
+	m.detailViewport.Width = width
+	m.detailViewport.Height = height
+
+	task := m.getCurrentTask()
+	if task == nil {
+		m.viewMode = ViewModeBoard
+		return
+	}
+	if m.subtaskCursor >= len(task.Subtasks) {
+		m.subtaskCursor = len(task.Subtasks) - 1
+	}
+	if m.subtaskCursor < 0 {
+		m.subtaskCursor = 0
+	}
+	m.detailViewport.SetContent(m.renderDetail(*task, width))
+
+	// Keep the selected checklist item on screen
+	if len(task.Subtasks) > 0 {
+		line := m.checklistLine + m.subtaskCursor
+		if line < m.detailViewport.YOffset {
+			m.detailViewport.SetYOffset(line)
+		} else if line >= m.detailViewport.YOffset+m.detailViewport.Height {
+			m.detailViewport.SetYOffset(line - m.detailViewport.Height + 1)
+		}
+	}
+}
+
+// handleColumnNameKeys handles keyboard input when creating or renaming a column
+func (m Model) handleColumnNameKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		name := strings.TrimSpace(m.columnInput.Value())
+		if name == "" {
+			return m, nil
+		}
+		mode := m.viewMode
+		m.viewMode = ViewModeBoard
+		m.columnInput.SetValue("")
+		if mode == ViewModeAddColumn {
+			// New columns go right of the current one
+			return m, m.createColumn(name, m.currentColumn+1)
+		}
+		return m, m.renameColumn(m.columns[m.currentColumn].Status, name)
+	}
+
+	var cmd tea.Cmd
+	m.columnInput, cmd = m.columnInput.Update(msg)
+	return m, cmd
+}
+
+// handleDeleteColumnKeys handles keyboard input when deleting a column. An
+// empty column is deleted on confirmation; the tasks of a non-empty one are
+// moved to the column picked from the list first.
+func (m Model) handleDeleteColumnKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	col := m.columns[m.currentColumn]
+	if len(col.Tasks) == 0 {
+		switch msg.String() {
+		case "y", "Y", "enter":
+			m.viewMode = ViewModeBoard
+			return m, m.deleteColumn(col.Status, "")
+		case "n", "N":
+			m.viewMode = ViewModeBoard
 		}
 		return m, nil
+	}
 
-	case "u":
-		task := m.getCurrentTask()
-		if task != nil {
-			m.viewMode = ViewModeEditDue
-			if task.Due != nil {
-				m.dueInput.SetValue(task.Due.Format("2006-01-02"))
-			} else {
-				m.dueInput.SetValue("")
+	switch msg.String() {
+	case "up", "k":
+		for i := m.columnTarget - 1; i >= 0; i-- {
+			if i != m.currentColumn {
+				m.columnTarget = i
+				break
 			}
-			m.dueInput.Focus()
 		}
-		return m, nil
-
-	case "?":
-		m.viewMode = ViewModeHelp
-		return m, nil
-
-	case "/":
-		m.viewMode = ViewModeSearch
-		m.searchInput.SetValue(m.searchQuery)
-		m.searchInput.Focus()
-		return m, nil
-
-	case "f5":
-		// Refresh: reload tasks from database
-		return m, m.loadTasks()
+	case "down", "j":
+		for i := m.columnTarget + 1; i < len(m.columns); i++ {
+			if i != m.currentColumn {
+				m.columnTarget = i
+				break
+			}
+		}
+	case "enter":
+		// Snapshots of the moved tasks would point at the deleted column
+		m.viewMode = ViewModeBoard
+		m.history = history{}
+		return m, m.deleteColumn(col.Status, m.columns[m.columnTarget].Status)
 	}
-
 	return m, nil
 }
 
@@ -282,18 +1545,19 @@ func (m Model) handleEditDueKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if task != nil {
 			var due *time.Time
 			if dueStr != "" {
-				// Try parsing the date
-				if t, err := time.Parse("2006-01-02", dueStr); err == nil {
-					due = &t
-				} else {
+				// Accept absolute and relative dates (e.g. 2025-03-14, +3d, fri)
+				t, err := dates.Parse(dueStr, time.Now())
+				if err != nil {
 					// Invalid format, show error but stay in edit mode
-					m.err = fmt.Errorf("invalid date format, use YYYY-MM-DD")
+					m.err = err
 					return m, nil
 				}
+				due = &t
 			}
 			m.viewMode = ViewModeBoard
 			m.dueInput.SetValue("")
-			return m, m.updateDue(task.ID, due)
+			m.err = nil
+			return m, m.updateDue(task, due)
 		}
 		return m, nil
 
@@ -312,20 +1576,19 @@ func (m Model) handleEditDueKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter":
-		m.searchQuery = strings.ToLower(strings.TrimSpace(m.searchInput.Value()))
-		m.viewMode = ViewModeBoard
-		m.currentTask = 0 // reset task selection
-		return m, nil
-
-	case "esc":
-		m.searchInput.SetValue("")
-		m.searchQuery = ""
+		// The filter is already applied while typing; keep it and close the input
 		m.viewMode = ViewModeBoard
 		return m, nil
 	}
 
+	// Filter the board as the query is typed
 	var cmd tea.Cmd
 	m.searchInput, cmd = m.searchInput.Update(msg)
+	if query := strings.ToLower(strings.TrimSpace(m.searchInput.Value())); query != m.searchQuery {
+		m.searchQuery = query
+		m.currentTask = 0
+		m.ensureTaskVisible()
+	}
 	return m, cmd
 }
 
@@ -333,23 +1596,42 @@ func (m Model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleAddTaskKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter":
-		title := m.textInput.Value()
-		if title != "" {
-			status := m.columns[m.currentColumn].Status
-			m.viewMode = ViewModeBoard
-			m.textInput.SetValue("")
-			return m, m.createTask(title, status)
+		if strings.TrimSpace(m.textInput.Value()) == "" {
+			return m, nil
 		}
-		return m, nil
+		parsed, err := quickadd.Parse(m.textInput.Value(), m.currentTime)
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		status := m.columns[m.currentColumn].Status
+		draft := parsed.Task(status)
+		if m.fromTemplate != nil {
+			draft = templateTask(*m.fromTemplate, parsed, status)
+		}
+		m.viewMode = ViewModeBoard
+		m.textInput.SetValue("")
+		m.fromTemplate = nil
+		m.err = nil
+		return m, m.createTask(draft)
+
+	case "ctrl+t":
+		m.viewMode = ViewModeTemplates
+		m.templates = nil
+		m.templateCursor = 0
+		return m, m.loadTemplates()
 
 	case "esc":
 		m.viewMode = ViewModeBoard
 		m.textInput.SetValue("")
+		m.fromTemplate = nil
+		m.err = nil
 		return m, nil
 	}
 
 	var cmd tea.Cmd
 	m.textInput, cmd = m.textInput.Update(msg)
+	m.err = nil
 	return m, cmd
 }
 
@@ -362,7 +1644,7 @@ func (m Model) handleEditTaskKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if title != "" && task != nil {
 			m.viewMode = ViewModeBoard
 			m.textInput.SetValue("")
-			return m, m.updateTask(task.ID, title, task.Status)
+			return m, m.updateTask(task, title)
 		}
 		return m, nil
 
@@ -386,7 +1668,18 @@ func (m Model) handleEditDescriptionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if task != nil {
 			m.viewMode = ViewModeBoard
 			m.textArea.SetValue("")
-			return m, m.updateDescription(task.ID, description)
+			return m, m.updateDescription(task, description)
+		}
+		return m, nil
+
+	case "ctrl+e":
+		// Carry on in the external editor with what was typed so far
+		task := m.getCurrentTask()
+		if task != nil {
+			text := m.textArea.Value()
+			m.viewMode = ViewModeBoard
+			m.textArea.SetValue("")
+			return m, m.editInEditor(*task, text)
 		}
 		return m, nil
 
@@ -401,31 +1694,169 @@ func (m Model) handleEditDescriptionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-// handleEditTagsKeys handles keyboard input in edit tags mode
+// handleEditTagsKeys handles keyboard input in the tag picker
 func (m Model) handleEditTagsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.pickerItems()
+	query := strings.TrimSpace(m.labelInput.Value())
+
+	switch msg.String() {
+	case "up":
+		if m.labelCursor > 0 {
+			m.labelCursor--
+		}
+		return m, nil
+
+	case "down":
+		if m.labelCursor < len(items)-1 {
+			m.labelCursor++
+		}
+		return m, nil
+
+	case " ":
+		if query == "" {
+			if m.labelCursor < len(items) {
+				m.toggleLabel(items[m.labelCursor])
+			}
+			return m, nil
+		}
+
+	case "enter":
+		if query != "" {
+			// Toggle an exactly matching tag or create new ones inline
+			for _, tag := range parseTagsInput(query) {
+				if !containsLabel(m.labelOptions, tag) {
+					m.labelOptions = mergeLabels(m.labelOptions, []string{tag})
+				}
+				m.toggleLabel(tag)
+			}
+			m.labelInput.SetValue("")
+			m.labelCursor = 0
+			return m, nil
+		}
+		return m.saveLabels()
+
+	case "ctrl+s":
+		return m.saveLabels()
+
+	case "esc":
+		m.viewMode = ViewModeBoard
+		m.labelInput.SetValue("")
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.labelInput, cmd = m.labelInput.Update(msg)
+	if m.labelCursor >= len(m.pickerItems()) {
+		m.labelCursor = 0
+	}
+	return m, cmd
+}
+
+// handleFilterLabelKeys handles keyboard input when choosing a tag to filter by
+func (m Model) handleFilterLabelKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.pickerItems()
+
 	switch msg.String() {
+	case "up":
+		if m.labelCursor > 0 {
+			m.labelCursor--
+		}
+		return m, nil
+
+	case "down":
+		if m.labelCursor < len(items)-1 {
+			m.labelCursor++
+		}
+		return m, nil
+
 	case "enter":
-		tagsStr := m.textInput.Value()
-		task := m.getCurrentTask()
-		if task != nil {
-			tags := parseTagsInput(tagsStr)
-			m.viewMode = ViewModeBoard
-			m.textInput.SetValue("")
-			return m, m.updateTags(task.ID, tags)
+		switch {
+		case m.labelCursor >= len(items):
+		case m.labelTask != 0:
+			m.setQuickLabel(items[m.labelCursor], m.labelTask)
+		default:
+			m.labelFilter = items[m.labelCursor]
+			m.quickLabel = ""
+			m.currentTask = 0
+			m.ensureTaskVisible()
 		}
+		m.viewMode = ViewModeBoard
+		m.labelTask = 0
+		m.labelInput.SetValue("")
 		return m, nil
 
 	case "esc":
 		m.viewMode = ViewModeBoard
-		m.textInput.SetValue("")
+		m.labelTask = 0
+		m.labelInput.SetValue("")
 		return m, nil
 	}
 
 	var cmd tea.Cmd
-	m.textInput, cmd = m.textInput.Update(msg)
+	m.labelInput, cmd = m.labelInput.Update(msg)
+	if m.labelCursor >= len(m.pickerItems()) {
+		m.labelCursor = 0
+	}
 	return m, cmd
 }
 
+// saveLabels stores the tags checked in the picker on the selected task
+func (m Model) saveLabels() (tea.Model, tea.Cmd) {
+	task := m.getCurrentTask()
+	m.viewMode = ViewModeBoard
+	m.labelInput.SetValue("")
+	if task == nil {
+		return m, nil
+	}
+	m.followTaskID = task.ID
+	tags := append([]string{}, m.labelSelected...)
+	return m, m.editFields(task, func(t *model.Task) { t.Tags = tags })
+}
+
+// toggleLabel checks or unchecks a tag in the picker
+func (m *Model) toggleLabel(tag string) {
+	for i, t := range m.labelSelected {
+		if t == tag {
+			m.labelSelected = append(m.labelSelected[:i], m.labelSelected[i+1:]...)
+			return
+		}
+	}
+	m.labelSelected = append(m.labelSelected, tag)
+}
+
+// knownLabels returns the tags used by loaded tasks, sorted
+func (m Model) knownLabels() []string {
+	var labels []string
+	for _, col := range m.columns {
+		for _, task := range col.Tasks {
+			labels = mergeLabels(labels, task.Tags)
+		}
+	}
+	return labels
+}
+
+// mergeLabels returns the sorted union of two tag lists
+func mergeLabels(a, b []string) []string {
+	merged := make([]string, 0, len(a)+len(b))
+	for _, l := range append(append([]string{}, a...), b...) {
+		if !containsLabel(merged, l) {
+			merged = append(merged, l)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// containsLabel reports whether labels contains tag
+func containsLabel(labels []string, tag string) bool {
+	for _, l := range labels {
+		if l == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // parseTagsInput parses comma-separated tags input
 func parseTagsInput(input string) []string {
 	parts := strings.Split(input, ",")
@@ -442,11 +1873,22 @@ func parseTagsInput(input string) []string {
 // handleConfirmDeleteKeys handles keyboard input in delete confirmation mode
 func (m Model) handleConfirmDeleteKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
-	case "y", "Y":
+	case "y", "Y", "o", "O":
+		task := m.getCurrentTask()
 		id := m.pendingDeleteID
 		m.pendingDeleteID = 0
 		m.viewMode = ViewModeBoard
-		return m, m.deleteTask(id)
+		if task == nil || task.ID != id {
+			return m, nil
+		}
+		// An epic goes with its children, or o leaves them on the board
+		if len(m.children(task.ID)) > 0 {
+			return m, m.deleteEpic(task, msg.String() == "y" || msg.String() == "Y")
+		}
+		if msg.String() == "y" || msg.String() == "Y" {
+			return m, m.deleteTask(task)
+		}
+		return m, nil
 
 	case "n", "N", "esc":
 		m.pendingDeleteID = 0
@@ -457,75 +1899,297 @@ func (m Model) handleConfirmDeleteKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// handleHelpKeys handles keyboard input in help mode
-func (m Model) handleHelpKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	m.viewMode = ViewModeBoard
+// handleTrashKeys handles keyboard input in the trash view
+func (m Model) handleTrashKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Trash):
+		m.viewMode = ViewModeBoard
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if m.trashCursor > 0 {
+			m.trashCursor--
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		if m.trashCursor < len(m.trash)-1 {
+			m.trashCursor++
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.RestoreTask):
+		if m.trashCursor < len(m.trash) {
+			return m, m.restoreFromTrash(m.trash[m.trashCursor].ID)
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.PurgeTask):
+		if m.trashCursor < len(m.trash) {
+			m.pendingDeleteID = m.trash[m.trashCursor].ID
+			m.viewMode = ViewModeConfirmPurge
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handleConfirmPurgeKeys handles the second confirmation before a task is
+// deleted permanently from the trash
+func (m Model) handleConfirmPurgeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		id := m.pendingDeleteID
+		m.pendingDeleteID = 0
+		m.viewMode = ViewModeTrash
+		return m, m.purgeTask(id)
+
+	case "n", "N":
+		m.pendingDeleteID = 0
+		m.viewMode = ViewModeTrash
+		return m, nil
+	}
+
 	return m, nil
 }
 
-// createTask creates a new task
-func (m Model) createTask(title string, status model.TaskStatus) tea.Cmd {
+// handleHelpKeys handles keyboard input in the help overlay: ? closes it
+// (as does Esc), other keys scroll
+func (m Model) handleHelpKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, m.keys.Help) {
+		m.viewMode = ViewModeBoard
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.helpViewport, cmd = m.helpViewport.Update(msg)
+	return m, cmd
+}
+
+// refreshHelp sizes the help viewport and fills it with the key bindings
+func (m *Model) refreshHelp() {
+	if m.viewMode != ViewModeHelp {
+		return
+	}
+	width := m.width
+	if width <= 0 {
+		width = 80
+	}
+	height := m.height - 4 // title + spacing + help line
+	if height < 3 {
+		height = 3
+	}
+	m.helpViewport.Width = width
+	m.helpViewport.Height = height
+	m.helpViewport.SetContent(m.renderHelp())
+}
+
+// createTask creates a new task from a quick-add draft
+func (m Model) createTask(draft model.Task) tea.Cmd {
 	return func() tea.Msg {
-		task, err := m.db.CreateTask(title, status)
+		task, err := m.db.CreateTaskFrom(draft)
 		if err != nil {
 			return errMsg{err}
 		}
-		return taskCreatedMsg{task}
+		created, err := m.db.GetTask(task.ID)
+		if err != nil {
+			return errMsg{err}
+		}
+		return taskChangedMsg{op: operation{kind: opCreate, after: created}}
 	}
 }
 
-// updateTask updates a task
-func (m Model) updateTask(id int64, title string, status model.TaskStatus) tea.Cmd {
+// duplicateTask copies a task right below itself and selects the copy
+func (m Model) duplicateTask(task *model.Task) tea.Cmd {
 	return func() tea.Msg {
-		err := m.db.UpdateTask(id, title, status)
+		created, err := m.db.DuplicateTask(task.ID, db.DuplicateSuffix)
 		if err != nil {
 			return errMsg{err}
 		}
-		return taskUpdatedMsg{}
+		return taskChangedMsg{op: operation{kind: opCreate, after: created}, follow: true}
 	}
 }
 
+// updateTask updates a task's title
+func (m Model) updateTask(task *model.Task, title string) tea.Cmd {
+	return m.editFields(task, func(t *model.Task) { t.Title = title })
+}
+
 // deleteTask deletes a task
-func (m Model) deleteTask(id int64) tea.Cmd {
+func (m Model) deleteTask(task *model.Task) tea.Cmd {
+	return m.recordChange(opDelete, task, func() error {
+		return m.db.DeleteTask(task.ID)
+	})
+}
+
+// updateDescription updates a task's description
+func (m Model) updateDescription(task *model.Task, description string) tea.Cmd {
+	return m.editFields(task, func(t *model.Task) { t.Description = description })
+}
+
+// updateTags updates a task's tags
+func (m Model) updateTags(task *model.Task, tags []string) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.UpdateTaskTags(task.ID, tags)
+	})
+}
+
+// updateDue updates a task's due date
+func (m Model) updateDue(task *model.Task, due *time.Time) tea.Cmd {
+	return m.editFields(task, func(t *model.Task) { t.Due = due })
+}
+
+// updatePriority updates a task's priority
+func (m Model) updatePriority(task *model.Task, priority model.TaskPriority) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.UpdateTaskPriority(task.ID, priority)
+	})
+}
+
+// pinTask pins a task above the others of its column, or unpins it
+func (m Model) pinTask(task *model.Task, pinned bool) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.SetTaskPinned(task.ID, pinned)
+	})
+}
+
+// addSubtask appends a checklist item to a task
+func (m Model) addSubtask(task *model.Task, title string) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.AddSubtask(task.ID, title)
+	})
+}
+
+// toggleSubtask flips a checklist item between open and done
+func (m Model) toggleSubtask(task *model.Task, id int64) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.ToggleSubtask(id)
+	})
+}
+
+// deleteSubtask removes a checklist item
+func (m Model) deleteSubtask(task *model.Task, id int64) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.DeleteSubtask(id)
+	})
+}
+
+// moveSubtask moves a checklist item up or down by one place
+func (m Model) moveSubtask(task *model.Task, id int64, delta int) tea.Cmd {
+	return m.recordChange(opEdit, task, func() error {
+		return m.db.MoveSubtask(id, delta)
+	})
+}
+
+// createColumn adds a column at the given position and selects it
+func (m Model) createColumn(name string, index int) tea.Cmd {
 	return func() tea.Msg {
-		err := m.db.DeleteTask(id)
+		col, err := m.db.CreateColumn(name, index)
 		if err != nil {
 			return errMsg{err}
 		}
-		return taskDeletedMsg{}
+		return columnsUpdatedMsg{col.Status}
 	}
 }
 
-// updateDescription updates a task's description
-func (m Model) updateDescription(id int64, description string) tea.Cmd {
+// renameColumn changes the name of a column
+func (m Model) renameColumn(status model.TaskStatus, name string) tea.Cmd {
 	return func() tea.Msg {
-		err := m.db.UpdateTaskDescription(id, description)
+		err := m.db.RenameColumn(status, name)
 		if err != nil {
 			return errMsg{err}
 		}
-		return descriptionUpdatedMsg{}
+		return columnsUpdatedMsg{status}
 	}
 }
 
-// updateTags updates a task's tags
-func (m Model) updateTags(id int64, tags []string) tea.Cmd {
+// deleteColumn removes a column, moving its tasks to the moveTo column
+func (m Model) deleteColumn(status, moveTo model.TaskStatus) tea.Cmd {
 	return func() tea.Msg {
-		err := m.db.UpdateTaskTags(id, tags)
+		err := m.db.DeleteColumn(status, moveTo)
 		if err != nil {
 			return errMsg{err}
 		}
-		return tagsUpdatedMsg{}
+		return columnsUpdatedMsg{moveTo}
 	}
 }
 
-// updateDue updates a task's due date
-func (m Model) updateDue(id int64, due *time.Time) tea.Cmd {
+// setWIPLimit sets the WIP limit of a column
+func (m Model) setWIPLimit(status model.TaskStatus, limit int) tea.Cmd {
+	return func() tea.Msg {
+		err := m.db.SetWIPLimit(status, limit)
+		if err != nil {
+			return errMsg{err}
+		}
+		return columnsUpdatedMsg{status}
+	}
+}
+
+// setColumnDone marks a column done or not
+func (m Model) setColumnDone(status model.TaskStatus, done bool) tea.Cmd {
+	return func() tea.Msg {
+		err := m.db.SetColumnDone(status, done)
+		if err != nil {
+			return errMsg{err}
+		}
+		return columnsUpdatedMsg{status}
+	}
+}
+
+// moveColumn moves a column one place left or right, keeping it selected
+func (m Model) moveColumn(status model.TaskStatus, delta int) tea.Cmd {
+	return func() tea.Msg {
+		err := m.db.MoveColumn(status, delta)
+		if err != nil {
+			return errMsg{err}
+		}
+		return columnsUpdatedMsg{status}
+	}
+}
+
+// swapTasks exchanges the positions of two tasks within a column
+func (m Model) swapTasks(task *model.Task, otherID int64) tea.Cmd {
+	before := snapshot(task)
+	return func() tea.Msg {
+		err := m.db.SwapTaskPositions(task.ID, otherID)
+		if err != nil {
+			return errMsg{err}
+		}
+		return taskChangedMsg{op: operation{kind: opReorder, before: before, after: before, otherID: otherID}}
+	}
+}
+
+// loadTrash loads the deleted tasks shown in the trash view
+func (m Model) loadTrash() tea.Cmd {
+	return func() tea.Msg {
+		tasks, err := m.db.GetTrash()
+		if err != nil {
+			return errMsg{err}
+		}
+		return trashLoadedMsg{tasks}
+	}
+}
+
+// restoreFromTrash brings a deleted task back to its column
+func (m Model) restoreFromTrash(id int64) tea.Cmd {
+	return func() tea.Msg {
+		err := m.db.RestoreFromTrash(id)
+		if err != nil {
+			return errMsg{err}
+		}
+		return trashUpdatedMsg{}
+	}
+}
+
+// purgeTask permanently deletes a task from the trash
+func (m Model) purgeTask(id int64) tea.Cmd {
 	return func() tea.Msg {
-		err := m.db.UpdateTaskDue(id, due)
+		err := m.db.PurgeTask(id)
 		if err != nil {
 			return errMsg{err}
 		}
-		return dueUpdatedMsg{}
+		return trashUpdatedMsg{}
 	}
 }
 
@@ -533,11 +2197,16 @@ func (m Model) updateDue(id int64, due *time.Time) tea.Cmd {
 func (m Model) moveTask(task *model.Task, targetColumn int) tea.Cmd {
 	newStatus := m.columns[targetColumn].Status
 
+	before := snapshot(task)
 	return func() tea.Msg {
-		err := m.db.UpdateTaskStatus(task.ID, newStatus)
+		repeat, err := m.db.UpdateTaskStatus(task.ID, newStatus)
+		if err != nil {
+			return errMsg{err}
+		}
+		after, err := m.db.GetTask(task.ID)
 		if err != nil {
 			return errMsg{err}
 		}
-		return taskUpdatedMsg{}
+		return taskChangedMsg{op: operation{kind: opMove, before: before, after: after, repeat: repeat}}
 	}
 }
@@ -6,72 +6,47 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/dates"
+	"github.com/happytaoer/cli_kanban/internal/db"
 	"github.com/happytaoer/cli_kanban/internal/model"
+	"github.com/happytaoer/cli_kanban/internal/quickadd"
 )
 
+// Colors and styles shared by the views, set from the theme by applyTheme
 var (
 	// Colors
-	colorPrimary    = lipgloss.Color("#7C3AED")
-	colorSecondary  = lipgloss.Color("#A78BFA")
-	colorInProgress = lipgloss.Color("#3B82F6")
-	colorSuccess    = lipgloss.Color("#10B981")
-	colorDanger     = lipgloss.Color("#EF4444")
-	colorMuted      = lipgloss.Color("#6B7280")
-	colorBorder     = lipgloss.Color("#374151")
+	colorPrimary        lipgloss.Color
+	colorSecondary      lipgloss.Color
+	colorText           lipgloss.Color
+	colorMuted          lipgloss.Color
+	colorBorder         lipgloss.Color
+	colorSelected       lipgloss.Color
+	colorSelectedText   lipgloss.Color
+	colorColumnFirst    lipgloss.Color
+	colorColumnMiddle   lipgloss.Color
+	colorColumnLast     lipgloss.Color
+	colorSuccess        lipgloss.Color
+	colorWarning        lipgloss.Color
+	colorDanger         lipgloss.Color
+	colorOverdue        lipgloss.Color
+	colorTagText        lipgloss.Color
+	colorPriorityLow    lipgloss.Color
+	colorPriorityMedium lipgloss.Color
+	colorPriorityHigh   lipgloss.Color
+	colorPriorityUrgent lipgloss.Color
 
 	// Styles
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorPrimary).
-			MarginBottom(1)
-
-	columnStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorBorder).
-			Padding(1, 2).
-			Width(30)
-
-	columnTitleStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(colorSecondary).
-				MarginBottom(1)
-
-	taskStyle = lipgloss.NewStyle().
-			Padding(0, 1).
-			MarginBottom(1).
-			Width(26)
-
-	taskActiveStyle = lipgloss.NewStyle().
-			Padding(0, 1).
-			MarginBottom(1).
-			Width(26).
-			Background(colorPrimary).
-			Foreground(lipgloss.Color("#FFFFFF")).
-			Bold(true)
-
-	helpStyle = lipgloss.NewStyle().
-			Foreground(colorMuted)
-
-	footerStyle = lipgloss.NewStyle().
-			BorderTop(true).
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(colorBorder).
-			Foreground(colorMuted).
-			PaddingTop(1)
-
-	inputStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorPrimary).
-			Padding(1, 2).
-			Width(60)
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(colorDanger).
-			Bold(true)
-
-	statsStyle = lipgloss.NewStyle().
-			Foreground(colorMuted).
-			MarginBottom(1)
+	titleStyle           lipgloss.Style
+	columnStyle          lipgloss.Style
+	columnTitleStyle     lipgloss.Style
+	taskStyle            lipgloss.Style
+	taskActiveStyle      lipgloss.Style
+	helpStyle            lipgloss.Style
+	footerStyle          lipgloss.Style
+	inputStyle           lipgloss.Style
+	errorStyle           lipgloss.Style
+	statsStyle           lipgloss.Style
+	searchHighlightStyle lipgloss.Style // marks the parts of a title matching the search query
 )
 
 // View renders the TUI
@@ -85,12 +60,64 @@ func (m Model) View() string {
 		return m.viewEditDescription()
 	case ViewModeEditTags:
 		return m.viewEditTags()
+	case ViewModeFilterLabel:
+		return m.viewFilterLabel()
+	case ViewModeFilter:
+		return m.viewFilter()
+	case ViewModePalette:
+		return m.viewPalette()
+	case ViewModeEditLink:
+		return m.viewEditLink()
+	case ViewModeLinks:
+		return m.viewLinks()
+	case ViewModeDependencies:
+		return m.viewDependencies()
+	case ViewModeEpic:
+		return m.viewEpic()
+	case ViewModeViews, ViewModeSaveView:
+		return m.viewViews()
+	case ViewModeBoardSettings:
+		return m.viewBoardSettings()
+	case ViewModeDetail, ViewModeAddSubtask:
+		return m.viewDetail()
 	case ViewModeEditDue:
 		return m.viewEditDue()
-	case ViewModeConfirmDelete:
-		return m.viewConfirmDelete()
+	case ViewModeEditRecurrence:
+		return m.viewEditRecurrence()
+	case ViewModeTrash, ViewModeConfirmPurge:
+		return m.viewTrash()
+	case ViewModeArchive, ViewModeArchiveSearch:
+		return m.viewArchive()
+	case ViewModeSnooze:
+		return m.viewSnooze()
+	case ViewModeSnoozed:
+		return m.viewSnoozed()
+	case ViewModeDashboard:
+		return m.viewDashboard()
+	case ViewModeCalendar:
+		return m.viewCalendar()
+	case ViewModeAgenda:
+		return m.viewAgenda()
+	case ViewModeAddColumn, ViewModeRenameColumn:
+		return m.viewColumnName()
+	case ViewModeDeleteColumn:
+		return m.viewDeleteColumn()
+	case ViewModeEditWIP:
+		return m.viewEditWIP()
 	case ViewModeHelp:
 		return m.viewHelp()
+	case ViewModeWorkspaces:
+		return m.viewWorkspaces()
+	case ViewModeBulkMove, ViewModeBulkPriority, ViewModeBulkTag:
+		return m.viewBulk()
+	case ViewModeSaveTemplate:
+		return m.viewSaveTemplate()
+	case ViewModeTemplates:
+		return m.viewTemplates()
+	case ViewModeWelcome:
+		return m.viewWelcome()
+	case ViewModeConflict:
+		return m.viewConflict()
 	default:
 		return m.viewBoard()
 	}
@@ -100,11 +127,30 @@ func (m Model) View() string {
 func (m Model) viewBoard() string {
 	// Header: Title + Statistics on same line
 	title := titleStyle.Render("📋 Kanban Board")
-	stats := m.renderStats()
+	if m.workspace != "" {
+		title = lipgloss.JoinHorizontal(lipgloss.Top, title, statsStyle.Render(" "+m.workspace))
+	}
+	if m.locked {
+		title = lipgloss.JoinHorizontal(lipgloss.Top, title, lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render(" 🔒 read-only"))
+	} else if m.readOnly() {
+		// Another TUI holds the workspace
+		title = lipgloss.JoinHorizontal(lipgloss.Top, title, lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render(" [read-only]"))
+	}
+	if timer := m.renderTimer(); timer != "" {
+		title = lipgloss.JoinHorizontal(lipgloss.Top, title, "  ", timer)
+	}
 	headerWidth := m.width
 	if headerWidth <= 0 {
 		headerWidth = 80
 	}
+	// Drop the clock, then the counts, when the header doesn't fit
+	stats := m.renderStats(true)
+	if lipgloss.Width(title)+1+lipgloss.Width(stats) > headerWidth {
+		stats = m.renderStats(false)
+	}
+	if lipgloss.Width(title)+1+lipgloss.Width(stats) > headerWidth {
+		stats = ""
+	}
 	// Place title on left, stats on right
 	spacerWidth := headerWidth - lipgloss.Width(title) - lipgloss.Width(stats)
 	if spacerWidth < 0 {
@@ -116,12 +162,42 @@ func (m Model) viewBoard() string {
 		stats,
 	)
 
-	// Columns content for viewport
-	columns := make([]string, len(m.columns))
-	for i, col := range m.columns {
-		columns[i] = m.renderColumn(i, col)
+	// Columns content for viewport, scrolled horizontally when they don't all fit
+	shown := m.shownColumns()
+	start := m.columnOffset
+	end := m.pageEnd(start)
+	indicatorStyle := lipgloss.NewStyle().Foreground(colorMuted).Width(columnIndicatorWidth).Align(lipgloss.Center).PaddingTop(2)
+	single := m.layout().single
+	var lanes laneLayout
+	if m.lanes != laneNone {
+		lanes = m.layoutLanes()
+	}
+	var columns []string
+	if start > 0 && !single {
+		columns = append(columns, indicatorStyle.Render("◀"))
+	}
+	for _, i := range shown[start:end] {
+		if m.isCollapsed(i) && !single {
+			columns = append(columns, m.renderCollapsedColumn(i, m.columns[i]))
+			continue
+		}
+		if m.lanes != laneNone {
+			columns = append(columns, m.renderLaneColumn(i, m.columns[i], lanes))
+			continue
+		}
+		columns = append(columns, m.renderColumn(i, m.columns[i]))
+	}
+	if end < len(shown) && !single {
+		columns = append(columns, indicatorStyle.Render("▶"))
 	}
 	columnsView := lipgloss.JoinHorizontal(lipgloss.Top, columns...)
+	if m.boardEmpty() {
+		columnsView += "\n" + lipgloss.PlaceHorizontal(max(lipgloss.Width(columnsView), 1), lipgloss.Center, m.renderEmptyBoard())
+	}
+	if single && len(shown) > 1 {
+		// Only the focused column fits: list the others above it
+		columnsView = m.renderBreadcrumb(headerWidth) + "\n" + columnsView
+	}
 
 	// Error message appended to columns if present
 	if m.err != nil {
@@ -138,56 +214,93 @@ func (m Model) viewBoard() string {
 		helpWidth = 80
 	}
 
-	if m.viewMode == ViewModeSearch {
+	if m.viewMode == ViewModeConfirmDelete {
+		// Ask before moving the selected task to the trash
+		prompt := "Delete this task? y/n"
+		if task := m.getCurrentTask(); task != nil {
+			prompt = fmt.Sprintf("Delete '%s'? y/n", task.Title)
+			if n := len(m.children(task.ID)); n > 0 {
+				prompt = fmt.Sprintf("Delete epic '%s'? y: with its %s | o: keep them | n: cancel", task.Title, pluralize(n, "child", "children"))
+			}
+		}
+		footerContent = errorStyle.Render(prompt)
+	} else if m.viewMode == ViewModeConfirmBulkDelete {
+		footerContent = errorStyle.Render(fmt.Sprintf("Delete %s? y/n", pluralize(len(m.selectedTasks()), "selected task", "selected tasks")))
+	} else if m.viewMode == ViewModeConfirmPaste {
+		// Ask before turning several clipboard lines into tasks
+		prompt := fmt.Sprintf("Create %s in %s from the clipboard? y/n", pluralize(len(m.pasteTitles), "task", "tasks"), m.columns[m.currentColumn].Name)
+		footerContent = lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render(prompt)
+	} else if m.viewMode == ViewModeConfirmArchiveColumn {
+		// Ask before archiving a whole column
+		col := m.columns[m.currentColumn]
+		prompt := fmt.Sprintf("Archive %s in %s? y/n", pluralize(len(col.Tasks), "task", "tasks"), col.Name)
+		footerContent = lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render(prompt)
+	} else if m.viewMode == ViewModeConfirmBlocked {
+		// Ask before starting work on a task still waiting on others
+		blockers := []model.Task{}
+		title := ""
+		if task := m.getCurrentTask(); task != nil {
+			blockers = m.openBlockers(*task)
+			title = task.Title
+		}
+		prompt := fmt.Sprintf("%s %q is %s, move anyway? y/n", blockedMarker, truncateText(title, 30), blockedNotice(blockers))
+		footerContent = lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render(prompt)
+	} else if m.viewMode == ViewModeConfirmEpic {
+		// Ask before completing an epic whose children are all done
+		title := ""
+		if epic, _ := m.boardTask(m.pendingEpicID); epic != nil {
+			title = epic.Title
+		}
+		prompt := fmt.Sprintf("%s All children of %q are done, complete it too? y/n", epicMarker, truncateText(title, 30))
+		footerContent = lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render(prompt)
+	} else if m.viewMode == ViewModeConfirmWIP {
+		// Ask before moving a task past a column's WIP limit
+		target := m.columns[min(m.pendingMoveTo, len(m.columns)-1)]
+		prompt := fmt.Sprintf("WIP limit exceeded in %s (%d/%d), move anyway? y/n", target.Name, len(target.Tasks), target.WIPLimit)
+		footerContent = lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render(prompt)
+	} else if m.viewMode == ViewModeSearch {
 		// Show search input in footer
 		searchLabel := lipgloss.NewStyle().Bold(true).Render("Search: ")
 		footerContent = searchLabel + m.searchInput.View()
-	} else if m.searchQuery != "" {
-		// Show active search filter
-		searchInfo := lipgloss.NewStyle().Render(fmt.Sprintf("Filter: \"%s\"", m.searchQuery))
-		helpText := "/ : Search | Esc: Clear filter | F5: Refresh | ← → : Navigate | a: Add | e: Edit | ?: Help | q: Quit"
-		footerContent = searchInfo + "  |  " + helpText
+		if m.searchQuery != "" {
+			footerContent += helpStyle.Render(fmt.Sprintf("  (%s)", pluralize(m.matchCount(), "match", "matches")))
+		}
+	} else if len(m.selected) > 0 {
+		footerContent = m.renderSelection()
+	} else if m.filtered() {
+		// The filters themselves are listed in the status bar
+		footerContent = "Esc clear filter | " + m.keys.footerHints()
 	} else {
-		// Normal help text
-		footerContent = "← → : Navigate | a: Add | e: Edit | i: Desc | t: Tags | u: Due | d: Del | m: Move | / : Search | F5: Refresh | ?: Help | q: Quit"
+		footerContent = m.keys.footerHints()
+	}
+	if pending := m.vimPending(); pending != "" {
+		footerContent = lipgloss.NewStyle().Bold(true).Render(pending) + "  |  " + footerContent
 	}
 
 	helpContent := lipgloss.PlaceHorizontal(helpWidth, lipgloss.Left, footerContent)
 	footer := footerStyle.Width(helpWidth).Render(helpContent)
 
-	// Combine: header + viewport + footer
-	return fmt.Sprintf("%s\n%s\n%s", header, m.viewport.View(), footer)
+	// Combine: header + viewport + footer + status bar
+	return fmt.Sprintf("%s\n%s\n%s\n%s", header, m.viewport.View(), footer, m.renderStatusBar(helpWidth))
 }
 
-// renderStats renders the statistics bar
-func (m Model) renderStats() string {
+// renderStats renders the statistics bar, optionally followed by the clock
+func (m Model) renderStats(clock bool) string {
 	var parts []string
-	for _, col := range m.columns {
-		labelStyle := lipgloss.NewStyle().Foreground(colorMuted)
-		switch col.Status {
-		case model.StatusInProgress:
-			labelStyle = labelStyle.Copy().Foreground(colorInProgress)
-		case model.StatusDone:
-			labelStyle = labelStyle.Copy().Foreground(colorSuccess)
-		default:
-			labelStyle = labelStyle.Copy().Foreground(colorMuted)
-		}
+	for i, col := range m.columns {
+		labelStyle := lipgloss.NewStyle().Foreground(m.columnColor(i))
 
 		label := labelStyle.Render(col.Name)
 		count := 0
-		if m.searchQuery == "" {
-			count = len(col.Tasks)
-		} else {
-			for _, task := range col.Tasks {
-				if m.matchesSearch(task) {
-					count++
-				}
+		for _, task := range col.Tasks {
+			if m.taskVisible(task) {
+				count++
 			}
 		}
 		parts = append(parts, fmt.Sprintf("%s: %d", label, count))
 	}
 	statsText := strings.Join(parts, " | ")
-	if !m.currentTime.IsZero() {
+	if clock && !m.currentTime.IsZero() {
 		statsText = fmt.Sprintf("%s | 🕒 %s", statsText, m.currentTime.Format("2006-01-02 15:04:05"))
 	}
 	return statsStyle.Render(statsText)
@@ -205,38 +318,22 @@ func (m Model) renderColumn(index int, col model.Column) string {
 	if offset >= totalTasks {
 		offset = 0
 	}
-	titleStyle := columnTitleStyle
-	switch col.Status {
-	case model.StatusInProgress:
-		titleStyle = titleStyle.Copy().Foreground(colorInProgress)
-	case model.StatusDone:
-		titleStyle = titleStyle.Copy().Foreground(colorSuccess)
-	default:
-		titleStyle = titleStyle.Copy().Foreground(colorMuted)
-	}
-	title := titleStyle.Render(col.Name)
-	b.WriteString(title)
+	b.WriteString(m.renderColumnTitle(index, col))
 	b.WriteString("\n")
 
 	// Scroll up indicator
+	indicatorStyle := lipgloss.NewStyle().Foreground(colorMuted)
 	if offset > 0 {
-		scrollUp := lipgloss.NewStyle().Foreground(colorMuted).Render("  ▲ more above")
-		b.WriteString(scrollUp)
+		b.WriteString(indicatorStyle.Render(fmt.Sprintf("  ▲ %d more", offset)))
 		b.WriteString("\n")
 	}
 
-	// Tasks (only visible range)
+	// Tasks (only the ones that fit, so rendering doesn't grow with the column)
+	endIndex := offset
 	if totalTasks == 0 {
-		emptyMsg := lipgloss.NewStyle().
-			Foreground(colorMuted).
-			Italic(true).
-			Render("No tasks")
-		b.WriteString(emptyMsg)
+		b.WriteString(m.renderEmptyColumn(index, col))
 	} else {
-		endIndex := offset + maxVisibleTasks
-		if endIndex > totalTasks {
-			endIndex = totalTasks
-		}
+		endIndex = m.visibleEnd(index, visibleIndices, offset)
 		for i := offset; i < endIndex; i++ {
 			actualIdx := visibleIndices[i]
 			if actualIdx < 0 || actualIdx >= len(col.Tasks) {
@@ -244,101 +341,303 @@ func (m Model) renderColumn(index int, col model.Column) string {
 			}
 			task := col.Tasks[actualIdx]
 			isActive := index == m.currentColumn && i == m.currentTask
-			taskView := m.renderTask(task, isActive)
+			taskView := m.renderTask(index, task, isActive)
 			b.WriteString(taskView)
 			b.WriteString("\n")
 		}
 	}
 
 	// Scroll down indicator
-	if offset+maxVisibleTasks < totalTasks {
-		scrollDown := lipgloss.NewStyle().Foreground(colorMuted).Render("  ▼ more below")
-		b.WriteString(scrollDown)
+	if endIndex < totalTasks {
+		b.WriteString(indicatorStyle.Render(fmt.Sprintf("  ▼ %d more", totalTasks-endIndex)))
 	}
 
 	// Apply column style with status-specific colors
 	content := b.String()
-	style := columnStyle.Copy()
-	switch col.Status {
-	case model.StatusInProgress:
-		style = style.BorderForeground(colorInProgress)
-	case model.StatusDone:
-		style = style.BorderForeground(colorSuccess)
-	default:
-		style = style.BorderForeground(colorMuted)
-	}
+	style := columnStyle.Copy().Width(m.renderedColumnWidth(index) - 2).BorderForeground(m.columnColor(index))
 	if index == m.currentColumn {
 		style = style.Copy().Bold(true)
 	}
 	return style.Render(content)
 }
 
-// wrapText wraps text at maxWidth using character-based breaking (like HTML break-all)
-func wrapText(text string, maxWidth int) string {
-	if maxWidth <= 0 {
-		return text
+// renderEmptyColumn renders the dim hint of a column without tasks to show:
+// how to add one, or that the filters hide its tasks
+func (m Model) renderEmptyColumn(index int, col model.Column) string {
+	text := "no tasks"
+	if len(col.Tasks) > 0 {
+		text = "no matching tasks"
+	} else if add := m.keys.Add; add.Enabled() && !m.readOnly() {
+		text = fmt.Sprintf("no tasks — press %s to add one here", add.Help().Key)
+	}
+	return lipgloss.NewStyle().
+		Foreground(colorMuted).
+		Italic(true).
+		Width(m.taskWidth(index)).
+		Align(lipgloss.Center).
+		Render(text)
+}
+
+// boardEmpty reports whether no column holds a task, hidden ones included
+func (m Model) boardEmpty() bool {
+	for _, col := range m.columns {
+		if len(col.Tasks) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// renderEmptyBoard renders the hint shown under the columns of an empty
+// board, with the keys for adding the first task and more columns
+func (m Model) renderEmptyBoard() string {
+	heading := "This board is empty"
+	if len(m.columns) == 0 {
+		heading = "This board has no columns"
 	}
-	var result strings.Builder
-	lineWidth := 0
-	for _, r := range text {
-		charWidth := runeWidth(r)
-		if lineWidth+charWidth > maxWidth {
-			result.WriteRune('\n')
-			lineWidth = 0
+	lines := []string{lipgloss.NewStyle().Bold(true).Render(heading)}
+	if !m.readOnly() {
+		lines = append(lines, "")
+		if len(m.columns) > 0 {
+			if h := hint(m.keys.Add, "add a task to the focused column"); h != "" {
+				lines = append(lines, h)
+			}
+		}
+		if h := hint(m.keys.AddColumn, "add a column"); h != "" {
+			lines = append(lines, h)
+		}
+		if h := hint(m.keys.Help, "see every key"); h != "" {
+			lines = append(lines, h)
 		}
-		result.WriteRune(r)
-		lineWidth += charWidth
 	}
-	return result.String()
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorder).
+		Foreground(colorMuted).
+		Padding(1, 4).
+		Render(strings.Join(lines, "\n"))
 }
 
-// runeWidth returns the display width of a rune (CJK chars are 2, others are 1)
-func runeWidth(r rune) int {
-	// CJK characters typically take 2 columns
-	if r >= 0x4E00 && r <= 0x9FFF || // CJK Unified Ideographs
-		r >= 0x3400 && r <= 0x4DBF || // CJK Unified Ideographs Extension A
-		r >= 0xFF00 && r <= 0xFFEF { // Fullwidth Forms
-		return 2
+// renderColumnTitle renders a column's header with its WIP count and how
+// many of its tasks are snoozed
+func (m *Model) renderColumnTitle(index int, col model.Column) string {
+	titleStyle := columnTitleStyle.Copy().Foreground(m.columnColor(index))
+	name := col.Name
+	if col.WIPLimit > 0 {
+		name += fmt.Sprintf(" (%d/%d)", len(col.Tasks), col.WIPLimit)
+		if col.OverWIPLimit(len(col.Tasks)) {
+			titleStyle = titleStyle.Copy().Foreground(colorDanger)
+		}
+	}
+	if mode := m.columnSort(index); mode != sortManual {
+		name += " " + mode.indicator()
+	}
+	if n := m.snoozedCount(col.Status); n > 0 {
+		name += fmt.Sprintf(" · %d snoozed", n)
 	}
-	return 1
+	return titleStyle.Render(truncateText(name, m.taskWidth(index)))
 }
 
-// renderTask renders a single task
-func (m Model) renderTask(task model.Task, isActive bool) string {
+// renderBreadcrumb renders the column names with their task counts on one
+// line, highlighting the focused column, for the single-column layout; when
+// zoomed the names are numbered for the column keys
+func (m Model) renderBreadcrumb(width int) string {
+	const separator = " · "
+	shown := m.shownColumns()
+	names := make([]string, len(shown))
+	total := 0
+	for n, i := range shown {
+		col := m.columns[i]
+		count := 0
+		for _, task := range col.Tasks {
+			if m.taskVisible(task) {
+				count++
+			}
+		}
+		names[n] = fmt.Sprintf("%s %d", col.Name, count)
+		if m.zoomed && i < len(m.keys.Column.Keys()) {
+			names[n] = m.keys.Column.Keys()[i] + ":" + names[n]
+		}
+		total += lipgloss.Width(names[n])
+	}
+
+	// Shorten the names evenly when they don't fit on one line
+	total += (len(names) - 1) * lipgloss.Width(separator)
+	if total > width {
+		maxName := (width-(len(names)-1)*lipgloss.Width(separator))/len(names) - 1
+		for i := range names {
+			names[i] = truncateText(names[i], maxName)
+		}
+	}
+
+	parts := make([]string, len(names))
+	for n, name := range names {
+		i := shown[n]
+		style := lipgloss.NewStyle().Foreground(colorMuted)
+		if i == m.currentColumn {
+			style = lipgloss.NewStyle().Foreground(m.columnColor(i)).Bold(true).Underline(true)
+		}
+		parts[n] = style.Render(name)
+	}
+	return strings.Join(parts, helpStyle.Render(separator))
+}
+
+// columnIndicatorWidth is the width of the arrows shown when columns are scrolled off screen
+const columnIndicatorWidth = 2
+
+// renderedColumnWidth returns the on-screen width of a column, including its border
+func (m *Model) renderedColumnWidth(index int) int {
+	return m.layout().widthOf(index)
+}
+
+// taskWidth returns the width of a task card inside the column at index
+func (m *Model) taskWidth(index int) int {
+	// Column border and horizontal padding
+	width := m.renderedColumnWidth(index) - 2 - columnStyle.GetHorizontalPadding()
+	if width < 4 {
+		width = 4
+	}
+	return width
+}
+
+// columnColor returns the accent color of a column: muted for the first,
+// green for the done ones and blue for those in between
+func (m *Model) columnColor(index int) lipgloss.Color {
+	switch {
+	case index > 0 && index < len(m.columns) && m.columns[index].Done:
+		return colorColumnLast
+	case index == 0:
+		return colorColumnFirst
+	default:
+		return colorColumnMiddle
+	}
+}
+
+// renderTask renders a single task of the column at index
+func (m *Model) renderTask(index int, task model.Task, isActive bool) string {
 	var b strings.Builder
 
 	// Get max width for text wrapping (account for padding)
-	maxWidth := taskStyle.GetWidth() - 2 // subtract padding
+	cardWidth := m.taskWidth(index)
+	maxWidth := cardWidth - taskStyle.GetHorizontalPadding()
 	if maxWidth <= 0 {
-		maxWidth = 22
+		maxWidth = 1
 	}
 
-	// Wrap title text using character-based breaking
-	wrappedTitle := wrapText(task.Title, maxWidth)
+	// Wrap title text using character-based breaking, prefixed by the pin and
+	// priority markers
+	head, tail := "", ""
+	if marker := priorityMarker(task.Priority); marker != "" {
+		head = marker + " "
+	}
+	pin := ""
+	if task.Pinned {
+		pin = pinnedMarker + " "
+		head = pin + head
+	}
+	if strings.TrimSpace(task.Description) != "" {
+		tail += " ≡"
+	}
+	if task.Recurrence != "" {
+		tail += " ↻"
+	}
+	if m.timer != nil && m.timer.TaskID == task.ID {
+		tail += " ⏱"
+	}
+	if !m.isDoneStatus(task.Status) && len(m.openBlockers(task)) > 0 {
+		tail += " " + blockedMarker
+	}
+	// Tasks selected for a bulk action are checked
+	check := ""
+	if m.selected[task.ID] {
+		check = "✓ "
+		head = check + head
+	}
+	wrappedTitle := strings.TrimPrefix(highlightMatches(m.wrapTitle(head, task.Title, tail, maxWidth), m.titleHighlight()), check+pin)
+	if marker := priorityMarker(task.Priority); marker != "" {
+		style := lipgloss.NewStyle().Foreground(priorityColor(task.Priority)).Bold(true)
+		wrappedTitle = style.Render(marker) + strings.TrimPrefix(wrappedTitle, marker)
+	}
+	if pin != "" {
+		wrappedTitle = lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render(pinnedMarker) + " " + wrappedTitle
+	}
+	if check != "" {
+		wrappedTitle = lipgloss.NewStyle().Foreground(colorSuccess).Bold(true).Render("✓") + " " + wrappedTitle
+	}
+	// The short ID, dimmed, after the title or on its own line when it
+	// doesn't fit; g 42 Enter goes to it
+	id := lipgloss.NewStyle().Foreground(colorMuted).Faint(true).Render(fmt.Sprintf("#%d", task.ID))
+	lines := strings.Split(wrappedTitle, "\n")
+	if lipgloss.Width(lines[len(lines)-1])+1+lipgloss.Width(id) <= maxWidth {
+		wrappedTitle += " " + id
+	} else {
+		wrappedTitle += "\n" + id
+	}
 	b.WriteString(wrappedTitle)
 
-	// Render due date if present (below title)
+	// Render due date if present (below title), colored by urgency
 	if task.Due != nil {
 		dueStr := task.Due.Format("2006-01-02")
-		dueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+		dueStyle := lipgloss.NewStyle().Foreground(colorText)
+		if !m.isDoneStatus(task.Status) {
+			switch dueUrgency(*task.Due, m.currentTime) {
+			case dueOverdue:
+				dueStyle = dueStyle.Copy().Foreground(colorOverdue).Bold(true)
+			case dueSoon:
+				dueStyle = dueStyle.Copy().Foreground(colorWarning).Bold(true)
+			}
+		}
 		b.WriteString("\n")
 		b.WriteString(dueStyle.Render("📅 " + dueStr))
 	}
 
+	// Mark tasks nobody touched for a while with their age, dimmed, on the
+	// due date's line when there is one
+	if m.isStale(task) {
+		if task.Due != nil {
+			b.WriteString(" ")
+		} else {
+			b.WriteString("\n")
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Faint(true).Render("⋯ " + m.staleAge(task)))
+	}
+
+	// Tasks back from snooze are marked on their first day back
+	if task.WokeToday(m.currentTime) {
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(colorWarning).Bold(true).Render("☀ back from snooze"))
+	}
+
+	// Render checklist progress if the task has subtasks
+	if done, total := task.SubtaskProgress(); total > 0 {
+		progressStyle := lipgloss.NewStyle().Foreground(colorMuted)
+		if done == total {
+			progressStyle = progressStyle.Copy().Foreground(colorSuccess)
+		}
+		b.WriteString("\n")
+		b.WriteString(progressStyle.Render(fmt.Sprintf("☑ %d/%d", done, total)))
+	}
+
+	// Render the progress of an epic's children
+	if p := m.epics[task.ID]; p.total > 0 {
+		progressStyle := lipgloss.NewStyle().Foreground(colorMuted)
+		if p.done == p.total {
+			progressStyle = progressStyle.Copy().Foreground(colorSuccess)
+		}
+		b.WriteString("\n")
+		b.WriteString(progressStyle.Render(epicMarker + " " + epicProgressText(p)))
+	}
+
 	// Render tags if present
 	if len(task.Tags) > 0 {
 		b.WriteString("\n")
 		lineWidth := 0
-		maxWidth := taskStyle.GetWidth()
-		if maxWidth == 0 {
-			maxWidth = 24
-		}
 		for _, tag := range task.Tags {
 			tagStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#FFFFFF")).
+				Foreground(colorTagText).
 				Background(getTagColor(tag)).
 				Padding(0, 1)
-			rendered := tagStyle.Render(tag)
+			rendered := tagStyle.Render(truncateText(tag, maxWidth-tagStyle.GetHorizontalPadding()))
 			tagWidth := lipgloss.Width(rendered)
 			space := 0
 			if lineWidth > 0 {
@@ -360,9 +659,122 @@ func (m Model) renderTask(task model.Task, isActive bool) string {
 
 	text := b.String()
 	if isActive {
-		return taskActiveStyle.Render(text)
+		return taskActiveStyle.Copy().Width(cardWidth).Render(text)
+	}
+	// Tasks a change just unblocked flash for a moment
+	if m.isFlashed(task.ID) {
+		return taskStyle.Copy().Width(cardWidth).Background(colorSuccess).Foreground(colorSelectedText).Render(text)
+	}
+	return taskStyle.Copy().Width(cardWidth).Render(text)
+}
+
+// titleHighlight returns the text to highlight in task titles for the active
+// search, or "" when the query doesn't search titles
+func (m *Model) titleHighlight() string {
+	query := m.searchQuery
+	if strings.HasPrefix(query, "title:") {
+		return strings.TrimPrefix(query, "title:")
+	}
+	for _, prefix := range []string{"desc:", "tag:", "due:"} {
+		if strings.HasPrefix(query, prefix) {
+			return ""
+		}
+	}
+	return query
+}
+
+// highlightMatches highlights each case-insensitive occurrence of query in text
+func highlightMatches(text, query string) string {
+	lower := strings.ToLower(text)
+	if query == "" || len(lower) != len(text) {
+		// Bail out when lowercasing changes byte offsets
+		return text
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, query)
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		b.WriteString(text[:i])
+		match := text[i : i+len(query)]
+		if clusterEnd(text, i) && clusterEnd(text, i+len(query)) {
+			match = searchHighlightStyle.Render(match)
+		}
+		// A match cutting a cluster, e.g. e of an e with a combining accent,
+		// stays plain: styling half of it detaches the accent
+		b.WriteString(match)
+		text, lower = text[i+len(query):], lower[i+len(query):]
+	}
+}
+
+// pluralize formats a count with the singular or plural noun
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// pinnedMarker is shown before the title of a pinned task
+const pinnedMarker = "★"
+
+// priorityMarker returns the symbol shown before the title of a task with the given priority
+func priorityMarker(p model.TaskPriority) string {
+	switch p {
+	case model.PriorityLow:
+		return "↓"
+	case model.PriorityMedium:
+		return "●"
+	case model.PriorityHigh:
+		return "▲"
+	case model.PriorityUrgent:
+		return "‼"
+	default:
+		return ""
+	}
+}
+
+// priorityColor returns the color of the priority marker
+func priorityColor(p model.TaskPriority) lipgloss.Color {
+	switch p {
+	case model.PriorityLow:
+		return colorPriorityLow
+	case model.PriorityMedium:
+		return colorPriorityMedium
+	case model.PriorityHigh:
+		return colorPriorityHigh
+	default:
+		return colorPriorityUrgent
+	}
+}
+
+// urgency describes how close a due date is
+type urgency int
+
+const (
+	dueLater   urgency = iota
+	dueSoon            // due within the next 24 hours (today or tomorrow)
+	dueOverdue         // due date has passed
+)
+
+// dueUrgency classifies a due date relative to now
+func dueUrgency(due, now time.Time) urgency {
+	if now.IsZero() {
+		now = time.Now()
+	}
+	today := dates.StartOfDay(now)
+	day := dates.Day(due, now.Location())
+	switch {
+	case day.Before(today):
+		return dueOverdue
+	case day.Sub(now) < 24*time.Hour:
+		return dueSoon
+	default:
+		return dueLater
 	}
-	return taskStyle.Render(text)
 }
 
 // getTagColor returns a color based on tag name hash
@@ -383,13 +795,17 @@ func getTagColor(tag string) lipgloss.Color {
 }
 
 // matchesSearch checks if a task matches the current search query
-func (m Model) matchesSearch(task model.Task) bool {
-	if m.searchQuery == "" {
+func (m *Model) matchesSearch(task model.Task) bool {
+	return matchesQuery(task, m.searchQuery)
+}
+
+// matchesQuery checks if a task matches a lowercased search query, which
+// supports the title:, desc:, tag: and due: prefixes
+func matchesQuery(task model.Task, query string) bool {
+	if query == "" {
 		return true
 	}
 
-	query := m.searchQuery
-
 	// Check for title: prefix (title-only search)
 	if strings.HasPrefix(query, "title:") {
 		titleQuery := strings.TrimPrefix(query, "title:")
@@ -538,6 +954,165 @@ func (m Model) matchesSearch(task model.Task) bool {
 	return false
 }
 
+// sourceText describes where an imported task comes from, e.g. owner/name#12
+// for a GitHub issue
+func sourceText(task model.Task) string {
+	if repo, ok := strings.CutPrefix(task.Source, "github:"); ok {
+		return repo + "#" + task.SourceID
+	}
+	return task.Source + " " + task.SourceID
+}
+
+// viewDetail renders the task detail view
+func (m Model) viewDetail() string {
+	var b strings.Builder
+
+	title := titleStyle.Render("🔎 Task Details")
+	b.WriteString(title)
+	b.WriteString("\n")
+
+	b.WriteString(m.detailViewport.View())
+	b.WriteString("\n")
+
+	if m.viewMode == ViewModeAddSubtask {
+		b.WriteString(inputStyle.Copy().Padding(0, 1).Render(m.subtaskInput.View()))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("Enter: Add item | Esc: Cancel"))
+		return b.String()
+	}
+
+	scroll := ""
+	if m.detailViewport.TotalLineCount() > m.detailViewport.Height {
+		scroll = fmt.Sprintf(" | %3.f%%", m.detailViewport.ScrollPercent()*100)
+	}
+	keys := "↑ ↓ PgUp PgDn: Scroll | a: Add item"
+	if task := m.getCurrentTask(); task != nil && len(task.Subtasks) > 0 {
+		keys = "↑ ↓: Select | Space: Toggle | J/K: Reorder | a: Add item | d: Delete item | PgUp PgDn: Scroll"
+	}
+	help := helpStyle.Render(keys + " | e: Title | i: Desc | t: Tags | @: Due | %: Repeat | U: Link | o: Open link | b: Dependencies | Ctrl+T: Timer | P: Focus | Ctrl+S: Template | c/Y: Copy | r: Raw | u: Undo | Enter/Esc: Back" + scroll)
+	if notice := m.renderNotice(); notice != "" {
+		help = notice + "  " + help
+	}
+	b.WriteString(help)
+
+	return b.String()
+}
+
+// renderDetail renders the full content of a task for the detail view and
+// records where its checklist starts so the selection can be kept on screen
+func (m *Model) renderDetail(task model.Task, width int) string {
+	var b strings.Builder
+
+	labelStyle := lipgloss.NewStyle().Foreground(colorMuted).Width(10)
+	valueStyle := lipgloss.NewStyle().Foreground(colorText)
+	field := func(label, value string) {
+		b.WriteString(labelStyle.Render(label))
+		b.WriteString(valueStyle.Render(value))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(colorSecondary).Render(wrapText(task.Title, width)))
+	b.WriteString("\n\n")
+
+	columnName := string(task.Status)
+	if col, ok := model.FindColumn(m.columns, string(task.Status)); ok {
+		columnName = col.Name
+	}
+	field("Column", columnName)
+
+	priority := "none"
+	if task.Priority != model.PriorityNone {
+		priority = priorityMarker(task.Priority) + " " + string(task.Priority)
+	}
+	field("Priority", priority)
+	if task.Pinned {
+		field("Pinned", pinnedMarker+" above the other tasks of its column")
+	}
+
+	if len(task.Tags) > 0 {
+		field("Tags", strings.Join(task.Tags, ", "))
+	}
+	if task.Due != nil {
+		field("Due", task.Due.Format("2006-01-02"))
+	}
+	if rule, err := dates.ParseRecurrence(task.Recurrence); err == nil && !rule.IsZero() {
+		field("Repeats", rule.Describe())
+	}
+	if logged := m.taskLogged(task.ID); logged > 0 || m.timer != nil && m.timer.TaskID == task.ID {
+		value := dates.HoursMinutes(logged)
+		if m.timer != nil && m.timer.TaskID == task.ID {
+			value += " (timer running for " + elapsed(m.timer.Duration(m.currentTime)) + ")"
+		}
+		field("Logged", value)
+	}
+	field("ID", fmt.Sprintf("#%d", task.ID))
+	if task.Source != "" {
+		field("Source", sourceText(task))
+	}
+	if task.URL != "" {
+		field("Link", task.URL)
+	}
+	timestamp := func(t time.Time) string {
+		return fmt.Sprintf("%s (%s)", t.Local().Format("2006-01-02 15:04:05"), dates.Relative(t, m.currentTime))
+	}
+	field("Created", timestamp(task.CreatedAt))
+	field("Updated", timestamp(task.UpdatedAt))
+	if task.CompletedAt != nil {
+		field("Completed", timestamp(*task.CompletedAt))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.renderDependencies(task, width))
+	b.WriteString(m.renderEpic(task, width))
+	heading := "Checklist"
+	if done, total := task.SubtaskProgress(); total > 0 {
+		heading += fmt.Sprintf(" %d/%d", done, total)
+	}
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(colorSecondary).Render(heading))
+	b.WriteString("\n")
+	m.checklistLine = strings.Count(b.String(), "\n")
+	if len(task.Subtasks) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("No checklist items (press a to add one)"))
+		b.WriteString("\n")
+	}
+	for i, st := range task.Subtasks {
+		box, style := "[ ] ", lipgloss.NewStyle()
+		if st.Done {
+			box, style = "[x] ", lipgloss.NewStyle().Foreground(colorMuted).Strikethrough(true)
+		}
+		line := box + truncateText(st.Title, width-6)
+		if i == m.subtaskCursor {
+			b.WriteString(lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render("> "))
+			style = style.Copy().Bold(true)
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(style.Render(line))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(colorSecondary).Render("Description"))
+	b.WriteString("\n")
+	if strings.TrimSpace(task.Description) == "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("No description (press i to add one)"))
+		b.WriteString("\n")
+	} else if m.rawDescription {
+		for _, line := range strings.Split(task.Description, "\n") {
+			b.WriteString(wrapText(line, width))
+			b.WriteString("\n")
+		}
+	} else {
+		b.WriteString(renderMarkdown(task.Description, width))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.renderHistory(task.ID, width))
+
+	return b.String()
+}
+
 // viewAddTask renders the add task view
 func (m Model) viewAddTask() string {
 	var b strings.Builder
@@ -550,17 +1125,63 @@ func (m Model) viewAddTask() string {
 	info := fmt.Sprintf("Adding to column: %s", col.Name)
 	b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(info))
 	b.WriteString("\n\n")
+	if m.fromTemplate != nil {
+		b.WriteString(m.renderTemplateInfo())
+		b.WriteString("\n\n")
+	}
 
 	input := inputStyle.Render(m.textInput.View())
 	b.WriteString(input)
 	b.WriteString("\n\n")
 
-	help := helpStyle.Render("Enter: Save | Esc: Cancel")
+	if preview := m.renderQuickAddPreview(); preview != "" {
+		b.WriteString(preview)
+		b.WriteString("\n\n")
+	}
+
+	help := helpStyle.Render("Enter: Save | Esc: Cancel | Ctrl+T: From template | !high #tag @fri set priority, tags and due date")
 	b.WriteString(help)
 
 	return b.String()
 }
 
+// renderQuickAddPreview shows what the quick-add syntax in the task input
+// parses to, or why it doesn't parse. It is empty for a plain title.
+func (m Model) renderQuickAddPreview() string {
+	value := m.textInput.Value()
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+	if m.err != nil {
+		return errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
+	}
+	parsed, err := quickadd.Parse(value, m.currentTime)
+	if err != nil {
+		return lipgloss.NewStyle().Foreground(colorWarning).Render(err.Error())
+	}
+	if !parsed.Parsed() {
+		return ""
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(colorMuted).Width(10)
+	valueStyle := lipgloss.NewStyle().Foreground(colorText)
+	var lines []string
+	field := func(label, value string) {
+		lines = append(lines, labelStyle.Render(label)+valueStyle.Render(value))
+	}
+	field("Title", parsed.Title)
+	if parsed.Priority != model.PriorityNone {
+		field("Priority", priorityMarker(parsed.Priority)+" "+string(parsed.Priority))
+	}
+	if len(parsed.Tags) > 0 {
+		field("Tags", strings.Join(parsed.Tags, ", "))
+	}
+	if parsed.Due != nil {
+		field("Due", parsed.Due.Format("Mon 2006-01-02"))
+	}
+	return strings.Join(lines, "\n")
+}
+
 // viewEditTask renders the edit task view
 func (m Model) viewEditTask() string {
 	var b strings.Builder
@@ -598,13 +1219,13 @@ func (m Model) viewEditDescription() string {
 	b.WriteString(textAreaView)
 	b.WriteString("\n\n")
 
-	help := helpStyle.Render("Ctrl+S: Save | Esc: Cancel")
+	help := helpStyle.Render("Ctrl+S: Save | Ctrl+E: Open in $EDITOR | Esc: Cancel")
 	b.WriteString(help)
 
 	return b.String()
 }
 
-// viewEditTags renders the edit tags view
+// viewEditTags renders the tag picker
 func (m Model) viewEditTags() string {
 	var b strings.Builder
 
@@ -619,20 +1240,88 @@ func (m Model) viewEditTags() string {
 		b.WriteString("\n\n")
 	}
 
-	hint := lipgloss.NewStyle().Foreground(colorMuted).Render("Separate tags with commas (e.g., bug, urgent, feature)")
-	b.WriteString(hint)
+	input := inputStyle.Render(m.labelInput.View())
+	b.WriteString(input)
 	b.WriteString("\n\n")
 
-	input := inputStyle.Render(m.textInput.View())
+	b.WriteString(m.renderLabelList(true))
+	b.WriteString("\n")
+
+	help := helpStyle.Render("↑ ↓: Select | Space: Toggle | Enter: Create/toggle typed tag, or save | Ctrl+S: Save | Esc: Cancel")
+	b.WriteString(help)
+
+	return b.String()
+}
+
+// viewFilterLabel renders the tag chooser used to filter the board
+func (m Model) viewFilterLabel() string {
+	var b strings.Builder
+
+	title := titleStyle.Render("🏷️  Filter by Tag")
+	b.WriteString(title)
+	b.WriteString("\n\n")
+
+	if task := m.labelPickerTask(); task != nil {
+		info := fmt.Sprintf("Tags of: %s", task.Title)
+		b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(info))
+		b.WriteString("\n\n")
+	}
+
+	input := inputStyle.Render(m.labelInput.View())
 	b.WriteString(input)
 	b.WriteString("\n\n")
 
-	help := helpStyle.Render("Enter: Save | Esc: Cancel")
+	b.WriteString(m.renderLabelList(false))
+	b.WriteString("\n")
+
+	help := helpStyle.Render("↑ ↓: Select | Enter: Filter | Esc: Cancel")
 	b.WriteString(help)
 
 	return b.String()
 }
 
+// renderLabelList renders the picker entries, with checkboxes when editing a task's tags
+func (m Model) renderLabelList(checkboxes bool) string {
+	var b strings.Builder
+
+	items := m.pickerItems()
+	query := strings.ToLower(strings.TrimSpace(m.labelInput.Value()))
+	if len(items) == 0 && query == "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("No tags yet"))
+		b.WriteString("\n")
+	}
+
+	for i, label := range items {
+		cursor := "  "
+		if i == m.labelCursor {
+			cursor = "> "
+		}
+		line := cursor
+		if checkboxes {
+			box := "[ ] "
+			if containsLabel(m.labelSelected, label) {
+				box = "[x] "
+			}
+			line += box
+		}
+		chip := lipgloss.NewStyle().
+			Foreground(colorTagText).
+			Background(getTagColor(label)).
+			Padding(0, 1).
+			Render(label)
+		b.WriteString(line + chip)
+		b.WriteString("\n")
+	}
+
+	if checkboxes && query != "" && !containsLabel(m.labelOptions, query) {
+		create := fmt.Sprintf("  [+] Create %q (Enter)", query)
+		b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(create))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
 // viewEditDue renders the edit due date view
 func (m Model) viewEditDue() string {
 	var b strings.Builder
@@ -654,7 +1343,7 @@ func (m Model) viewEditDue() string {
 		}
 	}
 
-	hint := lipgloss.NewStyle().Foreground(colorMuted).Render("Format: YYYY-MM-DD (leave empty to clear)")
+	hint := lipgloss.NewStyle().Foreground(colorMuted).Render("Format: YYYY-MM-DD, today, tomorrow, +3d, +2w, fri (leave empty to clear)")
 	b.WriteString(hint)
 	b.WriteString("\n\n")
 
@@ -668,84 +1357,325 @@ func (m Model) viewEditDue() string {
 	return b.String()
 }
 
-// viewConfirmDelete renders the delete confirmation view
-func (m Model) viewConfirmDelete() string {
+// viewTrash renders the list of deleted tasks
+func (m Model) viewTrash() string {
 	var b strings.Builder
 
-	title := titleStyle.Render("⚠️  Confirm Delete")
-	b.WriteString(title)
+	b.WriteString(titleStyle.Render("🗑  Trash"))
 	b.WriteString("\n\n")
 
-	task := m.getCurrentTask()
-	if task != nil {
-		warning := lipgloss.NewStyle().
-			Foreground(colorDanger).
-			Bold(true).
-			Render(fmt.Sprintf("Are you sure you want to delete this task?\n\n\"%s\"", task.Title))
-		b.WriteString(warning)
+	if len(m.trash) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("The trash is empty"))
 		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(closeHint(m.keys.Trash) + ": Back"))
+		return b.String()
 	}
 
-	help := helpStyle.Render("y: Yes, delete | n/Esc: Cancel")
-	b.WriteString(help)
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	infoStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	for i, task := range m.trash {
+		column := string(task.Status)
+		if col, ok := model.FindColumn(m.columns, column); ok {
+			column = col.Name
+		}
+		deleted := ""
+		if task.DeletedAt != nil {
+			deleted = ", deleted " + dates.Relative(*task.DeletedAt, m.currentTime)
+		}
+		line := truncateText(task.Title, 50)
+		info := infoStyle.Render(fmt.Sprintf("  (%s%s)", column, deleted))
+		if i == m.trashCursor {
+			b.WriteString(selectedStyle.Render("> "+line) + info)
+		} else {
+			b.WriteString("  " + line + info)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if m.viewMode == ViewModeConfirmPurge && m.trashCursor < len(m.trash) {
+		prompt := fmt.Sprintf("Permanently delete '%s'? This cannot be undone. y/n", m.trash[m.trashCursor].Title)
+		b.WriteString(errorStyle.Render(prompt))
+		return b.String()
+	}
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+	if notice := m.renderNotice(); notice != "" {
+		b.WriteString(notice + "  ")
+	}
+	b.WriteString(helpStyle.Render(joinHints("↑ ↓: Select", hint(m.keys.RestoreTask, "Restore"), hint(m.keys.PurgeTask, "Delete permanently"), closeHint(m.keys.Trash)+": Back",
+		fmt.Sprintf("Tasks are purged after %d days", int(db.TrashRetention.Hours()/24)))))
+
+	return b.String()
+}
+
+// viewWorkspaces renders the workspace switcher
+func (m Model) viewWorkspaces() string {
+	var b strings.Builder
+
+	heading, action := "🗂  Workspaces", "Open"
+	if m.movingTask != 0 {
+		heading, action = "🗂  Move Task to Workspace", "Move"
+	}
+	b.WriteString(titleStyle.Render(heading))
+	b.WriteString("\n\n")
+	if task := m.getCurrentTask(); m.movingTask != 0 && task != nil {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render("Moving: " + truncateText(task.Title, 60)))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(inputStyle.Render(m.workspaceInput.View()))
+	b.WriteString("\n\n")
+
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	infoStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	items := m.workspaceItems()
+	for i, name := range items {
+		info := ""
+		if name == m.workspace {
+			info = infoStyle.Render("  (open)")
+		}
+		if i == m.workspaceCursor {
+			b.WriteString(selectedStyle.Render("> "+name) + info)
+		} else {
+			b.WriteString("  " + name + info)
+		}
+		b.WriteString("\n")
+	}
+
+	name := strings.TrimSpace(m.workspaceInput.Value())
+	create := "[+] Create new workspace… (type a name)"
+	if name != "" {
+		create = fmt.Sprintf("[+] Create new workspace %q", name)
+	}
+	switch {
+	case !m.offersCreate():
+	case m.workspaceCursor == len(items):
+		b.WriteString(selectedStyle.Render("> " + create))
+		b.WriteString("\n")
+	default:
+		b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render("  " + create))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+	if m.movingTask != 0 && len(items) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("No other workspaces (create one with w)"))
+		b.WriteString("\n\n")
+	}
+	b.WriteString(helpStyle.Render("↑ ↓: Select | Type: Find | Enter: " + action + " | Esc: Cancel"))
+
+	return b.String()
+}
+
+// viewColumnName renders the create and rename column views
+func (m Model) viewColumnName() string {
+	var b strings.Builder
+
+	heading := "➕ Add Column"
+	info := "New column is added right of: "
+	if m.viewMode == ViewModeRenameColumn {
+		heading = "✏️  Rename Column"
+		info = "Renaming column: "
+	}
+	b.WriteString(titleStyle.Render(heading))
+	b.WriteString("\n\n")
+
+	if len(m.columns) > 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(info + m.columns[m.currentColumn].Name))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(inputStyle.Render(m.columnInput.View()))
+	b.WriteString("\n\n")
+
+	b.WriteString(helpStyle.Render("Enter: Save | Esc: Cancel"))
+
+	return b.String()
+}
+
+// viewEditWIP renders the WIP limit editor for the current column
+func (m Model) viewEditWIP() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("🚧 WIP Limit"))
+	b.WriteString("\n\n")
+
+	col := m.columns[m.currentColumn]
+	info := fmt.Sprintf("Column: %s (%d tasks)", col.Name, len(col.Tasks))
+	if m.strictWIP {
+		info += " | strict: moves into a full column are blocked"
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(info))
+	b.WriteString("\n\n")
+
+	b.WriteString(inputStyle.Render(m.wipInput.View()))
+	b.WriteString("\n\n")
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(helpStyle.Render("Enter: Save | Esc: Cancel"))
 
 	return b.String()
 }
 
-// viewHelp renders the help view
+// viewDeleteColumn renders the delete column confirmation, with a target
+// picker when the column still has tasks
+func (m Model) viewDeleteColumn() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("⚠️  Delete Column"))
+	b.WriteString("\n\n")
+
+	col := m.columns[m.currentColumn]
+	warning := lipgloss.NewStyle().Foreground(colorDanger).Bold(true)
+	if len(col.Tasks) == 0 {
+		b.WriteString(warning.Render(fmt.Sprintf("Delete the empty column \"%s\"?", col.Name)))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render("y: Yes, delete | n/Esc: Cancel"))
+		return b.String()
+	}
+
+	b.WriteString(warning.Render(fmt.Sprintf("Column \"%s\" has %d tasks. Move them to:", col.Name, len(col.Tasks))))
+	b.WriteString("\n\n")
+	for i, target := range m.columns {
+		if i == m.currentColumn {
+			continue
+		}
+		if i == m.columnTarget {
+			b.WriteString(lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).Render("> " + target.Name))
+		} else {
+			b.WriteString("  " + target.Name)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑ ↓: Choose column | Enter: Move tasks and delete | Esc: Cancel"))
+
+	return b.String()
+}
+
+// viewHelp renders the help overlay
 func (m Model) viewHelp() string {
 	var b strings.Builder
 
 	title := titleStyle.Render("❓ Help")
 	b.WriteString(title)
 	b.WriteString("\n\n")
+	b.WriteString(m.helpViewport.View())
+	b.WriteString("\n")
+
+	scroll := ""
+	if m.helpViewport.TotalLineCount() > m.helpViewport.Height {
+		scroll = fmt.Sprintf(" | %3.f%%", m.helpViewport.ScrollPercent()*100)
+	}
+	b.WriteString(helpStyle.Render("↑ ↓ PgUp PgDn: Scroll | " + closeHint(m.keys.Help) + ": Close" + scroll))
+
+	return b.String()
+}
 
-	helpText := `Navigation:
-  ← → or h l    Move between columns
-  ↑ ↓ or j k    Move between tasks
-
-Actions:
-  a             Add new task to current column
-  e or Enter    Edit selected task title
-  i             Edit selected task description
-  t             Edit selected task tags
-  u             Edit selected task due date
-  d or Delete   Delete selected task
-  m             Move task to next column
-
-Search:
-  /             Open search input
-  Enter         Apply search filter
-  Esc           Clear search filter (when active)
-  
-  Search syntax:
-    keyword      Search in title, description and tags
-    title:text   Search only in title
-    desc:text    Search only in description
-    tag:name     Search only in tags (exact match)
-    due:YYYY-MM-DD   Exact due date match
-    due:<YYYY-MM-DD  Due before date
-    due:>YYYY-MM-DD  Due after date
-    due:<=YYYY-MM-DD Due on or before date
-    due:>=YYYY-MM-DD Due on or after date
-    due:today    Due today
-    due:yesterday Due yesterday
-    due:tomorrow Due tomorrow
-    due:overdue  Past due date
-    due:none     No due date set
-
-Other:
-  F5            Refresh board
-  ?             Show this help
-  q or Ctrl+C   Quit application
-  Esc           Cancel current action or quit
+// searchSyntaxHelp documents the query prefixes understood by the search input
+const searchSyntaxHelp = `Search syntax:
+  keyword            Search in title, description and tags
+  title:text         Search only in title
+  desc:text          Search only in description
+  tag:name           Search only in tags (exact match)
+  due:YYYY-MM-DD     Exact due date match
+  due:<YYYY-MM-DD    Due before date (also >, <=, >=)
+  due:today          Due today (also yesterday, tomorrow)
+  due:overdue        Past due date
+  due:none           No due date set
 `
 
-	b.WriteString(helpText)
+// renderHelp lists every key binding of the keymap, grouped by section
+func (m Model) renderHelp() string {
+	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(colorPrimary)
+	keyStyle := lipgloss.NewStyle().Bold(true)
+
+	var b strings.Builder
+	for _, section := range m.keys.sections() {
+		b.WriteString(sectionStyle.Render(section.title + ":"))
+		b.WriteString("\n")
+		for _, binding := range section.bindings {
+			if !binding.Enabled() {
+				continue
+			}
+			help := binding.Help()
+			b.WriteString("  " + keyStyle.Render(padRight(help.Key, 14)) + " " + help.Desc + "\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(searchSyntaxHelp)
+	return b.String()
+}
+
+// viewArchive renders the list of archived tasks, filtered by the archive search
+func (m Model) viewArchive() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("📦 Archive"))
+	b.WriteString("\n\n")
+
+	if len(m.archive) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("The archive is empty"))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(closeHint(m.keys.ArchiveView) + ": Back"))
+		return b.String()
+	}
+
+	items := m.archiveItems()
+	if len(items) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorMuted).Italic(true).Render("No archived task matches"))
+		b.WriteString("\n")
+	}
+
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	infoStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	for i, task := range items {
+		column := string(task.Status)
+		if col, ok := model.FindColumn(m.columns, column); ok {
+			column = col.Name
+		}
+		archived := ""
+		if task.ArchivedAt != nil {
+			archived = ", archived " + dates.Relative(*task.ArchivedAt, m.currentTime)
+		}
+		line := truncateText(task.Title, 50)
+		info := infoStyle.Render(fmt.Sprintf("  (%s%s)", column, archived))
+		if i == m.archiveCursor {
+			b.WriteString(selectedStyle.Render("> "+line) + info)
+		} else {
+			b.WriteString("  " + line + info)
+		}
+		b.WriteString("\n")
+	}
 	b.WriteString("\n")
 
-	help := helpStyle.Render("Press any key to return to board...")
-	b.WriteString(help)
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+	if m.viewMode == ViewModeArchiveSearch {
+		b.WriteString(lipgloss.NewStyle().Bold(true).Render("Search: ") + m.archiveInput.View())
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  (%s)", pluralize(len(items), "match", "matches"))))
+		return b.String()
+	}
+	if m.archiveQuery != "" {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("filter: %s (%s)  |  ", m.archiveQuery, pluralize(len(items), "match", "matches"))))
+	}
+	if notice := m.renderNotice(); notice != "" {
+		b.WriteString(notice + "  ")
+	}
+	b.WriteString(helpStyle.Render(joinHints("↑ ↓: Select", hint(m.keys.Search, "Search"), hint(m.keys.Unarchive, "Unarchive"), closeHint(m.keys.ArchiveView)+": Back")))
 
 	return b.String()
 }
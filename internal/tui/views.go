@@ -0,0 +1,419 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// viewsLoadedMsg carries the saved views listed in the view picker
+type viewsLoadedMsg struct {
+	views       []model.SavedView
+	defaultView string // name of the view the board opens with, "" for none
+}
+
+// viewsChangedMsg reports a view saved, deleted or made the default
+type viewsChangedMsg struct {
+	notice string
+}
+
+// loadViews reads the saved views of the workspace
+func (m Model) loadViews() tea.Cmd {
+	return func() tea.Msg {
+		views, err := m.db.GetViews()
+		if err != nil {
+			return errMsg{err}
+		}
+		def, err := m.db.DefaultView()
+		if err != nil {
+			return errMsg{err}
+		}
+		name := ""
+		if def != nil {
+			name = def.Name
+		}
+		return viewsLoadedMsg{views, name}
+	}
+}
+
+// changeViews runs a change to the saved views and reports it with notice
+func (m Model) changeViews(notice string, change func() error) tea.Cmd {
+	return func() tea.Msg {
+		if err := change(); err != nil {
+			return errMsg{err}
+		}
+		return viewsChangedMsg{notice}
+	}
+}
+
+// filtered reports whether filters or a view narrow the board
+func (m Model) filtered() bool {
+	return m.searchQuery != "" || m.labelFilter != "" || m.urgentOnly || m.staleOnly || m.pinnedOnly || len(m.hiddenColumns) > 0
+}
+
+// clearFilters shows every task and column again
+func (m *Model) clearFilters() {
+	m.searchQuery = ""
+	m.searchInput.SetValue("")
+	m.labelFilter = ""
+	m.quickLabel = ""
+	m.urgentOnly = false
+	m.staleOnly = false
+	m.pinnedOnly = false
+	m.hiddenColumns = nil
+	m.activeView = ""
+}
+
+// currentView returns the filters, sorts and hidden columns of the board
+// as a view named name
+func (m Model) currentView(name string) model.SavedView {
+	v := model.SavedView{
+		Name:       name,
+		Search:     m.searchQuery,
+		Label:      m.labelFilter,
+		UrgentOnly: m.urgentOnly,
+		StaleOnly:  m.staleOnly,
+		PinnedOnly: m.pinnedOnly,
+	}
+	for _, col := range m.columns {
+		if mode := m.sortModes[col.Status]; mode != sortManual {
+			if v.Sorts == nil {
+				v.Sorts = make(map[model.TaskStatus]string)
+			}
+			v.Sorts[col.Status] = string(mode)
+		}
+		if m.hiddenColumns[col.Status] {
+			v.Hidden = append(v.Hidden, col.Status)
+		}
+	}
+	return v
+}
+
+// setView puts the board in a saved view: its filters, its column sorts,
+// and its hidden columns, unless it hides them all
+func (m *Model) setView(v model.SavedView) {
+	m.searchQuery = v.Search
+	m.searchInput.SetValue(v.Search)
+	m.labelFilter = v.Label
+	m.quickLabel = ""
+	m.urgentOnly = v.UrgentOnly
+	m.staleOnly = v.StaleOnly
+	m.pinnedOnly = v.PinnedOnly
+
+	m.sortModes = make(columnSorts, len(v.Sorts))
+	for status, name := range v.Sorts {
+		if mode := parseSortMode(name); mode != sortManual {
+			m.sortModes[status] = mode
+		}
+	}
+
+	m.hiddenColumns = nil
+	for _, col := range m.columns {
+		if v.Hides(col.Status) {
+			if m.hiddenColumns == nil {
+				m.hiddenColumns = make(map[model.TaskStatus]bool)
+			}
+			m.hiddenColumns[col.Status] = true
+		}
+	}
+	if len(m.hiddenColumns) == len(m.columns) {
+		m.hiddenColumns = nil
+	}
+	m.activeView = v.Name
+}
+
+// applyView switches the board to a saved view, keeping the selected task
+// selected when the view shows it, and saves the view's column sorts
+func (m *Model) applyView(v model.SavedView) tea.Cmd {
+	if task := m.getCurrentTask(); task != nil {
+		m.followTaskID = task.ID
+	}
+	m.setView(v)
+	m.organizeTasks(m.columns, m.allTasks())
+	m.showNotice("view " + v.Name)
+	if m.locked || m.readOnly() {
+		return nil
+	}
+	sorts := make(map[model.TaskStatus]string, len(m.sortModes))
+	for status, mode := range m.sortModes {
+		sorts[status] = string(mode)
+	}
+	return func() tea.Msg {
+		if err := m.db.SetSortModes(sorts); err != nil {
+			return errMsg{err}
+		}
+		return nil
+	}
+}
+
+// openViews opens the view picker on the view in use
+func (m *Model) openViews() tea.Cmd {
+	m.viewMode = ViewModeViews
+	m.viewCursor = -1 // put on the view in use once the views are loaded
+	m.err = nil
+	return m.loadViews()
+}
+
+// startSaveView opens the prompt naming the view the current filters are
+// saved as, suggesting the view in use
+func (m *Model) startSaveView() {
+	m.viewMode = ViewModeSaveView
+	m.viewInput.SetValue(m.activeView)
+	m.viewInput.CursorEnd()
+	m.viewInput.Focus()
+	m.err = nil
+}
+
+// handleViewKeys handles keyboard input in the view picker: Enter switches
+// to the picked view, s saves the board as a view, d deletes the picked one
+// and * makes it the view the board opens with
+func (m Model) handleViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "esc" || key.Matches(msg, m.keys.Views):
+		m.viewMode = ViewModeBoard
+		m.err = nil
+		return m, nil
+
+	case key.Matches(msg, m.keys.Up):
+		if m.viewCursor > 0 {
+			m.viewCursor--
+		}
+		return m, nil
+
+	case key.Matches(msg, m.keys.Down):
+		if m.viewCursor < len(m.savedViews)-1 {
+			m.viewCursor++
+		}
+		return m, nil
+
+	case msg.String() == "enter":
+		if m.viewCursor < 0 || m.viewCursor >= len(m.savedViews) {
+			return m, nil
+		}
+		m.viewMode = ViewModeBoard
+		return m, m.applyView(m.savedViews[m.viewCursor])
+
+	case msg.String() == "s":
+		if m.refuseReadOnly() {
+			return m, nil
+		}
+		m.startSaveView()
+		return m, nil
+
+	case msg.String() == "d" || msg.String() == "delete":
+		if m.viewCursor < 0 || m.viewCursor >= len(m.savedViews) || m.refuseReadOnly() {
+			return m, nil
+		}
+		name := m.savedViews[m.viewCursor].Name
+		if m.activeView == name {
+			m.activeView = ""
+		}
+		m.viewCursor = max(0, min(m.viewCursor, len(m.savedViews)-2))
+		return m, m.changeViews("deleted view "+name, func() error { return m.db.DeleteView(name) })
+
+	case msg.String() == "*":
+		if m.viewCursor < 0 || m.viewCursor >= len(m.savedViews) || m.refuseReadOnly() {
+			return m, nil
+		}
+		name := m.savedViews[m.viewCursor].Name
+		if strings.EqualFold(name, m.defaultView) {
+			return m, m.changeViews("the board opens without a view", func() error { return m.db.SetDefaultView("") })
+		}
+		return m, m.changeViews("the board opens with view "+name, func() error { return m.db.SetDefaultView(name) })
+	}
+	return m, nil
+}
+
+// handleSaveViewKeys handles keyboard input in the prompt naming a view
+func (m Model) handleSaveViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		name := strings.TrimSpace(m.viewInput.Value())
+		if name == "" {
+			return m, nil
+		}
+		v := m.currentView(name)
+		m.viewMode = ViewModeViews
+		m.viewInput.SetValue("")
+		m.activeView = name
+		return m, m.changeViews("saved view "+name, func() error { return m.db.SaveView(v) })
+
+	case "esc":
+		m.viewMode = ViewModeViews
+		m.viewInput.SetValue("")
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.viewInput, cmd = m.viewInput.Update(msg)
+	return m, cmd
+}
+
+// describeView sums up what a view does, e.g. "tag: bug, priority: high+,
+// Todo ↓due, hides Done"
+func describeView(v model.SavedView, columns []model.Column) string {
+	var parts []string
+	if v.Search != "" {
+		parts = append(parts, "filter: "+v.Search)
+	}
+	if v.Label != "" {
+		parts = append(parts, "tag: "+v.Label)
+	}
+	if v.UrgentOnly {
+		parts = append(parts, "priority: high+")
+	}
+	if v.StaleOnly {
+		parts = append(parts, "stale")
+	}
+	var hidden []string
+	for _, col := range columns {
+		if mode := parseSortMode(v.Sorts[col.Status]); mode != sortManual {
+			parts = append(parts, col.Name+" "+mode.indicator())
+		}
+		if v.Hides(col.Status) {
+			hidden = append(hidden, col.Name)
+		}
+	}
+	if len(hidden) > 0 {
+		parts = append(parts, "hides "+strings.Join(hidden, ", "))
+	}
+	if len(parts) == 0 {
+		return "the whole board"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// viewViews renders the view picker, and under it the prompt naming a view
+// while one is saved
+func (m Model) viewViews() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("◫ Saved Views"))
+	b.WriteString("\n\n")
+
+	if len(m.savedViews) == 0 {
+		b.WriteString(helpStyle.Render("No saved views yet: filter and sort the board, then press s here to save it as one"))
+		b.WriteString("\n")
+	}
+	width := max(m.width-4, 30)
+	selectedStyle := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	normalStyle := lipgloss.NewStyle().Foreground(colorText)
+	mutedStyle := lipgloss.NewStyle().Foreground(colorMuted)
+	for i, v := range m.savedViews {
+		marks := "  "
+		if strings.EqualFold(v.Name, m.defaultView) {
+			marks = "★ "
+		}
+		name := v.Name
+		if v.Name == m.activeView {
+			name += " (in use)"
+		}
+		line := marks + name
+		detail := "  " + describeView(v, m.columns)
+		detail = truncateText(detail, max(width-lipgloss.Width(line)-2, 10))
+		if i == m.viewCursor && m.viewMode == ViewModeViews {
+			b.WriteString(selectedStyle.Render("▸ " + line))
+		} else {
+			b.WriteString(normalStyle.Render("  " + line))
+		}
+		b.WriteString(mutedStyle.Render(detail))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if m.viewMode == ViewModeSaveView {
+		b.WriteString(mutedStyle.Render("Save " + describeView(m.currentView(""), m.columns) + " as"))
+		b.WriteString("\n")
+		b.WriteString(inputStyle.Render(m.viewInput.View()))
+		b.WriteString("\n\n")
+	}
+
+	if m.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString("\n\n")
+	}
+
+	if m.viewMode == ViewModeSaveView {
+		b.WriteString(helpStyle.Render("Enter: Save (a view of the same name is replaced) | Esc: Cancel"))
+	} else {
+		b.WriteString(helpStyle.Render("↑/↓: Pick | Enter: Switch to the view | s: Save the board as a view | d: Delete | *: Open the board with it | Esc: Back"))
+	}
+	return b.String()
+}
+
+// shownColumns returns the indices of the columns on the board, leaving
+// out the ones the view hides
+func (m *Model) shownColumns() []int {
+	shown := make([]int, 0, len(m.columns))
+	for i, col := range m.columns {
+		if !m.hiddenColumns[col.Status] {
+			shown = append(shown, i)
+		}
+	}
+	return shown
+}
+
+// shownPosition returns where column index is among the shown columns, or
+// -1 when it is hidden
+func (m Model) shownPosition(index int) int {
+	for pos, i := range m.shownColumns() {
+		if i == index {
+			return pos
+		}
+	}
+	return -1
+}
+
+// columnHidden reports whether the view hides the column at index
+func (m *Model) columnHidden(index int) bool {
+	return index >= 0 && index < len(m.columns) && m.hiddenColumns[m.columns[index].Status]
+}
+
+// stepColumn focuses the next shown column to the left (delta < 0) or
+// right, skipping collapsed ones unless told not to, reporting whether
+// there was one
+func (m *Model) stepColumn(delta int, skipCollapsed bool) bool {
+	for i := m.currentColumn + delta; i >= 0 && i < len(m.columns); i += delta {
+		if !m.columnHidden(i) && !(skipCollapsed && m.isCollapsed(i)) {
+			m.currentColumn = i
+			m.currentTask = 0
+			m.ensureColumnVisible()
+			m.ensureTaskVisible()
+			return true
+		}
+	}
+	return false
+}
+
+// ViewBoard returns a board as a saved view shows it: without the columns
+// it hides or the tasks it filters out, and with its columns sorted.
+// staleAfter is the stale.after setting of the config.
+func ViewBoard(columns []model.Column, v model.SavedView, staleAfter string) []model.Column {
+	m := Model{staleAfter: staleAfter, currentTime: time.Now()}
+	var tasks []model.Task
+	for _, col := range columns {
+		tasks = append(tasks, col.Tasks...)
+	}
+	m.organizeTasks(columns, tasks)
+	m.setView(v)
+
+	var board []model.Column
+	for _, i := range m.shownColumns() {
+		col := m.columns[i]
+		shown := []model.Task{}
+		for _, task := range col.Tasks {
+			if m.taskVisible(task) {
+				shown = append(shown, task)
+			}
+		}
+		sortTasks(shown, m.sortModes[col.Status])
+		col.Tasks = shown
+		board = append(board, col)
+	}
+	return board
+}
@@ -0,0 +1,221 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxVimCount caps a count typed before a motion
+const maxVimCount = 999
+
+// maxGoToDigits caps the task ID typed after g
+const maxGoToDigits = 9
+
+// handleCountKeys handles the digits of the board, g and the vim layer. A
+// digit jumps to that column at once and is kept as a count: m next moves
+// the task there instead, and with vim on a motion next takes the jump back
+// and repeats itself that often, e.g. 3j, and more digits make a longer
+// count. g, a task ID and Enter go to that task; with vim on gg goes to the
+// first task, and a count before gg or G to the Nth. It reports whether the
+// key was consumed; keys it leaves go to the board as usual, which also
+// drops a pending count.
+func (m Model) handleCountKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	count := m.pendingCount
+	m.pendingCount = 0
+
+	if m.pendingG {
+		return m.handleGoToKeys(msg, count)
+	}
+
+	if digit, ok := m.countDigit(msg, count); ok {
+		if count == 0 {
+			m.countColumn, m.countTask = m.currentColumn, m.currentTask
+		}
+		m.undoColumnJump()
+		if count == 0 || !m.vim {
+			m.jumpToColumn(digit - 1)
+			m.pendingCount = digit
+		} else {
+			m.pendingCount = min(count*10+digit, maxVimCount)
+		}
+		return m, nil, true
+	}
+	if count == 0 {
+		if key.Matches(msg, m.keys.GoTo) {
+			m.pendingG = true
+			return m, nil, true
+		}
+		return m, nil, false
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Move):
+		m.undoColumnJump()
+		if count > len(m.columns) {
+			m.err = fmt.Errorf("there is no column %d", count)
+			return m, nil, true
+		}
+		next, cmd := m.startMove(count - 1)
+		return next, cmd, true
+
+	case m.vim && key.Matches(msg, m.keys.GoTo):
+		m.undoColumnJump()
+		m.pendingG = true
+		m.pendingCount = count
+		return m, nil, true
+
+	case m.vim && key.Matches(msg, m.keys.Bottom):
+		m.undoColumnJump()
+		m.selectNth(count)
+		return m, nil, true
+
+	case m.vim && key.Matches(msg, m.keys.Up, m.keys.Down, m.keys.Left, m.keys.Right, m.keys.PageUp, m.keys.PageDown):
+		m.undoColumnJump()
+		var next tea.Model = m
+		for i := 0; i < count; i++ {
+			next, _ = next.(Model).handleBoardKeys(msg)
+		}
+		return next, nil, true
+	}
+	return m, nil, false
+}
+
+// handleGoToKeys handles the keys after g: digits type a task ID, Enter
+// goes to that task, and with vim on a second g without an ID goes to the
+// first task, or the countth. Any other key drops the g and goes to the
+// board.
+func (m Model) handleGoToKeys(msg tea.KeyMsg, count int) (tea.Model, tea.Cmd, bool) {
+	id := m.gotoID
+	m.pendingG = false
+	m.gotoID = ""
+
+	switch s := msg.String(); {
+	case len(s) == 1 && s[0] >= '0' && s[0] <= '9' && count == 0:
+		if s != "0" || id != "" {
+			id += s
+		}
+		m.pendingG = true
+		m.gotoID = id[:min(len(id), maxGoToDigits)]
+		return m, nil, true
+
+	case s == "backspace" && id != "":
+		m.pendingG = true
+		m.gotoID = id[:len(id)-1]
+		return m, nil, true
+
+	case s == "enter" && id != "":
+		n, _ := strconv.ParseInt(id, 10, 64)
+		m.goToTask(n)
+		return m, nil, true
+
+	case m.vim && id == "" && key.Matches(msg, m.keys.GoTo):
+		m.selectNth(max(count, 1))
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// goToTask selects the task with the given ID, focusing and scrolling to
+// its column and clearing filters that hide it
+func (m *Model) goToTask(id int64) {
+	if !m.selectTask(id) {
+		m.err = fmt.Errorf("no task #%d on the board", id)
+		return
+	}
+	m.err = nil
+}
+
+// countDigit returns the digit a key adds to the count: the position of
+// the key among the column keys, or 0 after another digit with vim on
+func (m Model) countDigit(msg tea.KeyMsg, count int) (int, bool) {
+	if key.Matches(msg, m.keys.Column) {
+		for i, k := range m.keys.Column.Keys() {
+			if k == msg.String() {
+				return i + 1, true
+			}
+		}
+	}
+	if m.vim && count > 0 && msg.String() == "0" {
+		return 0, true
+	}
+	return 0, false
+}
+
+// jumpToColumn focuses the column at index i, when there is one
+func (m *Model) jumpToColumn(i int) {
+	if i < 0 || i >= len(m.columns) || i == m.currentColumn {
+		return
+	}
+	if m.columnHidden(i) {
+		m.showNotice(m.columns[i].Name + " is hidden by the view")
+		return
+	}
+	m.currentColumn = i
+	m.currentTask = 0
+	m.ensureColumnVisible()
+	m.ensureTaskVisible()
+}
+
+// undoColumnJump goes back to the task selected before the digit that
+// started the count jumped to its column
+func (m *Model) undoColumnJump() {
+	if m.countColumn >= len(m.columns) {
+		return
+	}
+	m.currentColumn = m.countColumn
+	m.currentTask = m.countTask
+	m.ensureColumnVisible()
+	m.ensureTaskVisible()
+}
+
+// selectNth selects the nth visible task of the current column, or its
+// last one when it has fewer
+func (m *Model) selectNth(n int) {
+	visible := len(m.visibleTaskIndices(m.currentColumn))
+	if visible == 0 {
+		return
+	}
+	m.currentTask = min(n, visible) - 1
+	m.ensureTaskVisible()
+}
+
+// vimPending shows the count or g typed so far, e.g. "3g" or "g42", or ""
+func (m Model) vimPending() string {
+	s := ""
+	if m.pendingCount > 0 {
+		s = strconv.Itoa(m.pendingCount)
+	}
+	if m.pendingG {
+		s += "g" + m.gotoID
+	}
+	return s
+}
+
+// withoutVim drops the vim layer: h/j/k/l and G from the navigation
+// bindings, and gg; digits then only jump to columns
+func (k *keyMap) withoutVim() {
+	k.Left = key.NewBinding(key.WithKeys("left"), key.WithHelp("←", k.Left.Help().Desc))
+	k.Right = key.NewBinding(key.WithKeys("right"), key.WithHelp("→", k.Right.Help().Desc))
+	k.Up = key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", k.Up.Help().Desc))
+	k.Down = key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", k.Down.Help().Desc))
+	k.Bottom = key.NewBinding(key.WithKeys("end"), key.WithHelp("End", k.Bottom.Help().Desc))
+	k.GoTop.Unbind()
+}
+
+// mirrorGoTop binds gg to pressing GoTo's key twice, so it follows the
+// config, and drops it when GoTo is disabled
+func (k *keyMap) mirrorGoTop() {
+	if !k.GoTo.Enabled() {
+		k.GoTop.Unbind()
+		return
+	}
+	labels := make([]string, len(k.GoTo.Keys()))
+	for i, p := range k.GoTo.Keys() {
+		labels[i] = keyLabel(p) + " " + keyLabel(p)
+	}
+	k.GoTop = key.NewBinding(key.WithKeys(k.GoTo.Keys()...), key.WithHelp(strings.Join(labels, " / "), k.GoTop.Help().Desc))
+}
@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/webhook"
+)
+
+// webhooksMsg reports a webhook delivery round
+type webhooksMsg struct {
+	result webhook.Result
+	err    error
+}
+
+// webhooksDue returns a delivery of the changes not posted to the
+// workspace's webhooks yet, or nil when none are configured or a delivery is
+// still running. Deliveries run in the background and retry on their own, so
+// the board never waits for them. A read-only board leaves them to the TUI
+// holding the workspace.
+func (m *Model) webhooksDue() tea.Cmd {
+	urls := webhook.URLs(m.webhooks, m.workspace)
+	if len(urls) == 0 || m.delivering || m.readOnly() {
+		return nil
+	}
+	m.delivering = true
+	database, ws := m.db, m.workspace
+	return func() tea.Msg {
+		result, err := webhook.Deliver(context.Background(), database, ws, urls)
+		return webhooksMsg{result, err}
+	}
+}
+
+// webhooksDelivered shows a failing webhook once, not on every delivery,
+// until it succeeds again, then looks for changes made meanwhile
+func (m *Model) webhooksDelivered(msg webhooksMsg) tea.Cmd {
+	m.delivering = false
+	if msg.err != nil {
+		if !m.webhookFailing[""] {
+			m.showNotice(fmt.Sprintf("Webhooks: %s", msg.err))
+			m.webhookFailing[""] = true
+		}
+		return nil
+	}
+	delete(m.webhookFailing, "")
+	for _, u := range webhook.URLs(m.webhooks, m.workspace) {
+		err, failed := msg.result.Failed[u]
+		switch {
+		case failed && !m.webhookFailing[u]:
+			m.showNotice(fmt.Sprintf("Webhook failed: %s", err))
+			m.webhookFailing[u] = true
+		case !failed && msg.result.Events > 0:
+			delete(m.webhookFailing, u)
+		}
+	}
+	if msg.result.Events == 0 {
+		return nil
+	}
+	return m.webhooksDue()
+}
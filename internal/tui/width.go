@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// Text is measured, wrapped and cut by grapheme cluster, the unit a terminal
+// draws as one character: a letter with its combining accents, a flag, or an
+// emoji joined from several (👩‍💻). Cutting inside one leaves a stray accent
+// or half an emoji behind.
+//
+// A cluster is as wide as lipgloss measures it, the sum of its runes, so
+// padded cards and highlighted rows line up with the borders lipgloss draws.
+
+// runeWidth returns the display width of a rune: 2 for wide characters such
+// as CJK and most emoji, 0 for combining marks, 1 otherwise. It matches the
+// measuring lipgloss does, so wrapped text lines up with the borders.
+func runeWidth(r rune) int {
+	return runewidth.RuneWidth(r)
+}
+
+// textWidth returns the display width of text without escape sequences
+func textWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// clusters splits text into its grapheme clusters
+func clusters(text string) []string {
+	var parts []string
+	state := -1
+	for text != "" {
+		var cluster string
+		cluster, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+		parts = append(parts, cluster)
+	}
+	return parts
+}
+
+// wrapText wraps text at maxWidth using character-based breaking (like HTML
+// break-all), never inside a cluster
+func wrapText(text string, maxWidth int) string {
+	if maxWidth <= 0 {
+		return text
+	}
+	var result strings.Builder
+	lineWidth := 0
+	for _, c := range clusters(text) {
+		charWidth := textWidth(c)
+		if lineWidth+charWidth > maxWidth && lineWidth > 0 {
+			result.WriteRune('\n')
+			lineWidth = 0
+		}
+		result.WriteString(c)
+		lineWidth += charWidth
+	}
+	return result.String()
+}
+
+// truncateText cuts text to maxWidth display columns, ending with an ellipsis when shortened
+func truncateText(text string, maxWidth int) string {
+	if maxWidth <= 0 || textWidth(text) <= maxWidth {
+		return text
+	}
+	var result strings.Builder
+	width := 0
+	for _, c := range clusters(text) {
+		if width+textWidth(c) > maxWidth-1 {
+			break
+		}
+		result.WriteString(c)
+		width += textWidth(c)
+	}
+	result.WriteString("…")
+	return result.String()
+}
+
+// truncateClusters returns the longest prefix of s that fits in width
+// columns, and at least its first cluster so wrapping always makes progress
+func truncateClusters(s string, width int) string {
+	used, end := 0, 0
+	for i, c := range clusters(s) {
+		if used+textWidth(c) > width && i > 0 {
+			break
+		}
+		used += textWidth(c)
+		end += len(c)
+	}
+	return s[:end]
+}
+
+// padRight pads text with spaces to width display columns; fmt's %-10s
+// counts runes, which misaligns wide characters
+func padRight(text string, width int) string {
+	if gap := width - textWidth(text); gap > 0 {
+		return text + strings.Repeat(" ", gap)
+	}
+	return text
+}
+
+// clipWidth cuts styled text to width display columns without splitting a
+// cluster or an escape sequence; the escape sequences after the cut are
+// kept, so styles are still reset
+func clipWidth(text string, width int) string {
+	var b strings.Builder
+	used := 0
+	full := false
+	for text != "" {
+		if text[0] == '\x1b' {
+			n := escapeLength(text)
+			b.WriteString(text[:n])
+			text = text[n:]
+			continue
+		}
+		end := strings.IndexByte(text, '\x1b')
+		if end < 0 {
+			end = len(text)
+		}
+		for _, c := range clusters(text[:end]) {
+			if full || used+textWidth(c) > width {
+				full = true
+				continue
+			}
+			b.WriteString(c)
+			used += textWidth(c)
+		}
+		text = text[end:]
+	}
+	return b.String()
+}
+
+// escapeLength returns the length of the escape sequence text starts with:
+// a CSI sequence such as a color up to its final byte, or ESC and one byte
+func escapeLength(text string) int {
+	if len(text) < 2 {
+		return len(text)
+	}
+	if text[1] != '[' {
+		return 2
+	}
+	for i := 2; i < len(text); i++ {
+		if text[i] >= 0x40 && text[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+// clusterEnd reports whether byte offset i of text falls between two
+// clusters, so a highlight can stop there
+func clusterEnd(text string, i int) bool {
+	offset := 0
+	for _, c := range clusters(text) {
+		if offset >= i {
+			return offset == i
+		}
+		offset += len(c)
+	}
+	return offset == i
+}
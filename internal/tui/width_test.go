@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	cafe     = "cafe\u0301"                 // an e and a combining acute accent
+	coder    = "\U0001F469\u200d\U0001F4BB" // woman, zero width joiner, laptop
+	family   = "\U0001F468\u200d\U0001F469\u200d\U0001F467"
+	flag     = "\U0001F1EF\U0001F1F5"
+	japanese = "日本語です"
+)
+
+func TestTextWidth(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+	}{
+		{"", 0},
+		{"todo", 4},
+		{japanese, 10},
+		{"a日b", 4},
+		{cafe, 4},
+		{"e\u0301\u0302", 1},
+		{coder, 4},
+		{family, 6},
+		{flag, 2},
+	}
+	for _, tt := range tests {
+		if got := textWidth(tt.text); got != tt.width {
+			t.Errorf("textWidth(%q) = %d, want %d", tt.text, got, tt.width)
+		}
+		// Cards are padded by textWidth inside borders lipgloss draws
+		if got := lipgloss.Width(tt.text); got != tt.width {
+			t.Errorf("lipgloss.Width(%q) = %d, want %d", tt.text, got, tt.width)
+		}
+	}
+}
+
+func TestClusters(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"ab", []string{"a", "b"}},
+		{japanese, []string{"日", "本", "語", "で", "す"}},
+		{cafe, []string{"c", "a", "f", "e\u0301"}},
+		{"a" + coder + "b", []string{"a", coder, "b"}},
+		{family + flag, []string{family, flag}},
+	}
+	for _, tt := range tests {
+		if got := clusters(tt.text); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("clusters(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"too long", 5, "too …"},
+		{"anything", 0, "anything"},
+		// A wide character that would end on the odd last cell is left out
+		{japanese, 6, "日本…"},
+		{japanese, 7, "日本語…"},
+		{japanese, 10, japanese},
+		{"a" + japanese, 6, "a日本…"},
+		// An accent stays with its letter
+		{cafe + "s", 4, "caf…"},
+		{cafe + "s", 5, cafe + "s"},
+		{cafe + " au lait", 5, cafe + "…"},
+		// Joined emoji are cut whole
+		{coder + coder, 5, coder + "…"},
+		{coder + coder, 4, "…"},
+		{"a" + family, 6, "a…"},
+	}
+	for _, tt := range tests {
+		got := truncateText(tt.text, tt.width)
+		if got != tt.want {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+		if tt.width > 0 && textWidth(got) > tt.width {
+			t.Errorf("truncateText(%q, %d) = %q is %d wide", tt.text, tt.width, got, textWidth(got))
+		}
+	}
+}
+
+func TestTruncateClusters(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"abc", 2, "ab"},
+		{japanese, 3, "日"},
+		{japanese, 4, "日本"},
+		{cafe, 3, "caf"},
+		{cafe, 4, cafe},
+		// The first cluster is kept even when it doesn't fit
+		{japanese, 1, "日"},
+		{coder + "b", 2, coder},
+	}
+	for _, tt := range tests {
+		if got := truncateClusters(tt.text, tt.width); got != tt.want {
+			t.Errorf("truncateClusters(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"abcdef", 3, "abc\ndef"},
+		{japanese, 5, "日本\n語で\nす"},
+		{japanese, 4, "日本\n語で\nす"},
+		{"ab" + cafe, 3, "abc\nafe\u0301"},
+		{coder + coder + "a", 5, coder + "\n" + coder + "a"},
+		// A cluster wider than a line gets a line of its own
+		{"a日b", 1, "a\n日\nb"},
+	}
+	for _, tt := range tests {
+		if got := wrapText(tt.text, tt.width); got != tt.want {
+			t.Errorf("wrapText(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestPadRight(t *testing.T) {
+	for _, text := range []string{"todo", japanese[:6], cafe, coder} {
+		if got := padRight(text, 8); textWidth(got) != 8 || !strings.HasPrefix(got, text) {
+			t.Errorf("padRight(%q, 8) = %q, %d wide", text, got, textWidth(got))
+		}
+	}
+	if got := padRight(japanese, 4); got != japanese {
+		t.Errorf("padRight cut %q to %q", japanese, got)
+	}
+}
+
+func TestClipWidth(t *testing.T) {
+	bold, reset := "\x1b[1m", "\x1b[0m"
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{bold + "abc" + reset, 2, bold + "ab" + reset},
+		{bold + japanese + reset, 3, bold + "日" + reset},
+		{bold + "日" + reset + "本語", 4, bold + "日" + reset + "本"},
+		{cafe + bold + "s" + reset, 4, cafe + bold + reset},
+		{"a" + coder + bold + "b" + reset, 4, "a" + bold + reset},
+	}
+	for _, tt := range tests {
+		if got := clipWidth(tt.text, tt.width); got != tt.want {
+			t.Errorf("clipWidth(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestClusterEnd(t *testing.T) {
+	text := "e\u0301" + coder // clusters of 3 and 11 bytes
+	for i, want := range map[int]bool{0: true, 1: false, 3: true, 5: false, 13: false, 14: true} {
+		if got := clusterEnd(text, i); got != want {
+			t.Errorf("clusterEnd(%q, %d) = %v, want %v", text, i, got, want)
+		}
+	}
+}
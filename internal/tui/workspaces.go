@@ -0,0 +1,308 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+	"github.com/happytaoer/cli_kanban/internal/workspace"
+)
+
+// workspacesLoadedMsg carries the workspaces listed in the switcher
+type workspacesLoadedMsg struct {
+	workspaces []workspace.Workspace
+}
+
+// workspaceOpenedMsg carries the database of the workspace to switch to
+type workspaceOpenedMsg struct {
+	name     string
+	database *db.DB
+}
+
+// loadWorkspaces scans the data directory for workspace databases
+func (m Model) loadWorkspaces() tea.Cmd {
+	return func() tea.Msg {
+		workspaces, err := workspace.List()
+		if err != nil {
+			return errMsg{err}
+		}
+		return workspacesLoadedMsg{workspaces}
+	}
+}
+
+// openWorkspace opens (or creates) the database of a workspace. A board
+// opened with --read-only opens the others read-only too.
+func (m Model) openWorkspace(name string) tea.Cmd {
+	locked := m.locked
+	sample := m.sample
+	keys := m.keys
+	return func() tea.Msg {
+		path, err := workspace.Path(name)
+		if err != nil {
+			return errMsg{err}
+		}
+		open := db.New
+		if locked {
+			open = db.NewReadOnly
+		}
+		_, statErr := os.Stat(path)
+		database, err := open(path)
+		if err != nil {
+			return errMsg{fmt.Errorf("failed to open workspace %q: %w", name, err)}
+		}
+		// A workspace created here starts as a sample board
+		if sample && !locked && errors.Is(statErr, fs.ErrNotExist) {
+			if err := seedSample(database, keys); err != nil {
+				database.Close()
+				return errMsg{err}
+			}
+		}
+		return workspaceOpenedMsg{name, database}
+	}
+}
+
+// taskMovedToWorkspaceMsg reports a task moved into another workspace
+type taskMovedToWorkspaceMsg struct {
+	title     string
+	workspace string
+}
+
+// moveTaskToWorkspace moves a task into another workspace, keeping its column
+// when that workspace has one with the same key and using its first column otherwise
+func (m Model) moveTaskToWorkspace(task model.Task, name string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := workspace.Path(name)
+		if err != nil {
+			return errMsg{err}
+		}
+		dst, err := db.New(path)
+		if err != nil {
+			return errMsg{fmt.Errorf("failed to open workspace %q: %w", name, err)}
+		}
+		defer dst.Close()
+
+		columns, err := dst.GetColumns()
+		if err != nil {
+			return errMsg{err}
+		}
+		var status model.TaskStatus
+		if _, ok := model.FindColumn(columns, string(task.Status)); ok {
+			status = task.Status
+		}
+		if _, err := m.db.MoveTaskTo(dst, task.ID, status); err != nil {
+			return errMsg{err}
+		}
+		return taskMovedToWorkspaceMsg{task.Title, name}
+	}
+}
+
+// showWorkspaces opens the workspace switcher
+func (m Model) showWorkspaces() (tea.Model, tea.Cmd) {
+	m.movingTask = 0
+	return m.openSwitcher()
+}
+
+// showMoveToWorkspace opens the workspace switcher to pick the workspace the
+// selected task moves to
+func (m Model) showMoveToWorkspace() (tea.Model, tea.Cmd) {
+	task := m.getCurrentTask()
+	if task == nil {
+		return m, nil
+	}
+	m.movingTask = task.ID
+	return m.openSwitcher()
+}
+
+// openSwitcher shows the workspace list with an empty filter
+func (m Model) openSwitcher() (tea.Model, tea.Cmd) {
+	m.viewMode = ViewModeWorkspaces
+	m.workspaceInput.Placeholder = "Type to find or create a workspace..."
+	if m.movingTask != 0 {
+		m.workspaceInput.Placeholder = "Type to find a workspace..."
+	}
+	m.workspaceInput.SetValue("")
+	m.workspaceInput.Focus()
+	m.workspaceCursor = 0
+	m.err = nil
+	return m, m.loadWorkspaces()
+}
+
+// workspaceItems returns the names of the workspaces matching the typed text.
+// The text matches fuzzily: its characters must appear in the name in order.
+func (m Model) workspaceItems() []string {
+	query := strings.ToLower(strings.TrimSpace(m.workspaceInput.Value()))
+	items := make([]string, 0, len(m.workspaces))
+	for _, ws := range m.workspaces {
+		if m.movingTask != 0 && ws.Name == m.workspace {
+			continue
+		}
+		if fuzzyMatch(ws.Name, query) {
+			items = append(items, ws.Name)
+		}
+	}
+	return items
+}
+
+// fuzzyMatch reports whether the characters of query appear in s in order
+func fuzzyMatch(s, query string) bool {
+	q := []rune(query)
+	matched := 0
+	for _, r := range s {
+		if matched < len(q) && r == q[matched] {
+			matched++
+		}
+	}
+	return matched == len(q)
+}
+
+// offersCreate reports whether the switcher lists the entry creating a new
+// workspace, which is hidden once the typed name is an existing workspace.
+// Tasks are only moved into existing workspaces, and a read-only board only
+// opens existing ones.
+func (m Model) offersCreate() bool {
+	if m.movingTask != 0 || m.locked {
+		return false
+	}
+	name := strings.TrimSpace(m.workspaceInput.Value())
+	for _, ws := range m.workspaces {
+		if ws.Name == name {
+			return false
+		}
+	}
+	return true
+}
+
+// selectLastWorkspace puts the switcher cursor on the workspace that was open
+// before the current one, so that w Enter jumps back and forth
+func (m *Model) selectLastWorkspace() {
+	name := m.lastWorkspace
+	if name == "" {
+		name = m.workspace
+	}
+	for i, item := range m.workspaceItems() {
+		if item == name {
+			m.workspaceCursor = i
+			return
+		}
+	}
+	m.workspaceCursor = 0
+}
+
+// handleWorkspaceKeys handles keyboard input in the workspace switcher. The
+// entry after the listed workspaces creates a new one named after the typed text.
+func (m Model) handleWorkspaceKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.workspaceItems()
+
+	switch msg.String() {
+	case "up":
+		if m.workspaceCursor > 0 {
+			m.workspaceCursor--
+		}
+		return m, nil
+
+	case "down":
+		last := len(items) - 1
+		if m.offersCreate() {
+			last++
+		}
+		if m.workspaceCursor < last {
+			m.workspaceCursor++
+		}
+		return m, nil
+
+	case "enter":
+		if m.movingTask != 0 {
+			task := m.getCurrentTask()
+			if task == nil || task.ID != m.movingTask || m.workspaceCursor >= len(items) {
+				return m, nil
+			}
+			return m, m.moveTaskToWorkspace(*task, items[m.workspaceCursor])
+		}
+		name := strings.TrimSpace(m.workspaceInput.Value())
+		if m.workspaceCursor < len(items) {
+			name = items[m.workspaceCursor]
+		} else if !m.offersCreate() {
+			return m, nil
+		} else if err := workspace.Validate(name); err != nil {
+			m.err = err
+			return m, nil
+		}
+		if name == m.workspace {
+			m.viewMode = ViewModeBoard
+			return m, nil
+		}
+		return m, m.openWorkspace(name)
+	}
+
+	var cmd tea.Cmd
+	m.workspaceInput, cmd = m.workspaceInput.Update(msg)
+	m.workspaceCursor = 0
+	m.err = nil
+	return m, cmd
+}
+
+// switchWorkspace closes the current database and shows the board of the
+// newly opened workspace, dropping everything tied to the old one
+func (m Model) switchWorkspace(msg workspaceOpenedMsg) (tea.Model, tea.Cmd) {
+	err := m.endFocus()
+	if stateErr := m.saveBoardState(); err == nil {
+		err = stateErr
+	}
+	if sessionErr := m.closeSession(); err == nil {
+		err = sessionErr
+	}
+	if m.db != nil {
+		if closeErr := m.db.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	m.db = msg.database
+	m.lastWorkspace = m.workspace
+	m.workspace = msg.name
+	m.viewMode = ViewModeBoard
+	m.columns = nil
+	m.currentColumn = 0
+	m.currentTask = 0
+	m.scrollOffsets = nil
+	m.columnOffset = 0
+	m.history = history{}
+	m.historyBusy = false
+	m.trash = nil
+	m.trashCursor = 0
+	m.archive = nil
+	m.archiveCursor = 0
+	m.archiveQuery = ""
+	m.archiveInput.SetValue("")
+	m.labelOptions = nil
+	m.clearFilters()
+	m.sortModes = nil
+	m.zoomed = false
+	m.openWithView = true
+	m.savedState = nil
+	m.err = err
+	return m, tea.Batch(m.loadTasks(), m.openSession())
+}
+
+// Close ends the session on the workspace that is open and closes its database
+func (m Model) Close() error {
+	if m.db == nil {
+		return nil
+	}
+	err := m.endFocus()
+	if stateErr := m.saveBoardState(); err == nil {
+		err = stateErr
+	}
+	if sessionErr := m.closeSession(); err == nil {
+		err = sessionErr
+	}
+	if closeErr := m.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,260 @@
+// Package webhook posts task changes to the URLs configured for a workspace.
+// Changes are read from the activity log of the workspace, so those made by
+// the TUI, a command or the HTTP API are all delivered. Each URL has its own
+// place in the log, moved past a change once the URL accepted it, so a URL
+// that is down gets the changes it missed when it is back.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// AllWorkspaces is the workspace key of URLs that receive the changes of
+// every workspace
+const AllWorkspaces = "*"
+
+// Event names
+const (
+	EventCreated   = "created"
+	EventMoved     = "moved"
+	EventCompleted = "completed" // moved into a done column from another one
+	EventDeleted   = "deleted"   // moved to the trash
+)
+
+// batchSize is the number of activity entries read at a time for a URL
+const batchSize = 100
+
+// backoff is the wait before each retry of a failed post
+var backoff = []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+
+// Column is a column as it is named on the board
+type Column struct {
+	Status model.TaskStatus `json:"status"`
+	Name   string           `json:"name"`
+}
+
+// Event is the JSON body posted for a change
+type Event struct {
+	Event     string      `json:"event"`
+	Workspace string      `json:"workspace"`
+	Timestamp time.Time   `json:"timestamp"`
+	OldColumn *Column     `json:"old_column,omitempty"`
+	NewColumn *Column     `json:"new_column,omitempty"`
+	Task      *model.Task `json:"task"`
+}
+
+// URLs returns the URLs configured for workspace in hooks, which maps
+// workspace names, or AllWorkspaces, to URLs
+func URLs(hooks map[string][]string, workspace string) []string {
+	var urls []string
+	seen := map[string]bool{}
+	for _, key := range []string{AllWorkspaces, workspace} {
+		for _, u := range hooks[key] {
+			if u = strings.TrimSpace(u); u != "" && !seen[u] {
+				urls = append(urls, u)
+				seen[u] = true
+			}
+		}
+	}
+	return urls
+}
+
+// Result reports a delivery round
+type Result struct {
+	Events int              // changes posted, counted once per URL
+	Failed map[string]error // URLs that failed, with why
+}
+
+// Deliver posts the changes not delivered yet to every URL, retrying a
+// failed post after a short backoff. A URL that still fails is left for the
+// next round, which starts again from the change it refused, so a server
+// that is down doesn't hold up the others. Rounds run until every URL is
+// drained or failed.
+func Deliver(ctx context.Context, database *db.DB, workspace string, urls []string) (Result, error) {
+	return deliver(ctx, database, workspace, urls, backoff)
+}
+
+// DeliverOnce is Deliver with a single attempt per post and no backoff, for
+// a process about to exit: what fails is left for the next round
+func DeliverOnce(ctx context.Context, database *db.DB, workspace string, urls []string) (Result, error) {
+	return deliver(ctx, database, workspace, urls, nil)
+}
+
+func deliver(ctx context.Context, database *db.DB, workspace string, urls []string, retries []time.Duration) (Result, error) {
+	result := Result{Failed: map[string]error{}}
+	if len(urls) == 0 {
+		return result, nil
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs []error
+	for _, u := range urls {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			posted, err := deliverTo(ctx, client, database, workspace, u, retries)
+			mu.Lock()
+			defer mu.Unlock()
+			result.Events += posted
+			var failure *postError
+			switch {
+			case errors.As(err, &failure):
+				result.Failed[u] = failure.err
+			case err != nil:
+				errs = append(errs, err)
+			}
+		}(u)
+	}
+	wg.Wait()
+	return result, errors.Join(errs...)
+}
+
+// postError is a post a URL failed
+type postError struct{ err error }
+
+func (e *postError) Error() string { return e.err.Error() }
+
+// deliverTo posts the changes not delivered to url yet, moving its cursor
+// past each one url accepts, and returns how many it posted. It stops at the
+// first post that fails, returned as a *postError, and when another process
+// moved the cursor: that one delivers the rest.
+func deliverTo(ctx context.Context, client *http.Client, database *db.DB, workspace, url string, retries []time.Duration) (int, error) {
+	posted := 0
+	for {
+		batch, err := database.PendingActivity(url, batchSize, time.Now())
+		if err != nil {
+			return posted, err
+		}
+		if batch.Through == batch.Cursor {
+			return posted, nil
+		}
+		events, err := buildEvents(database, workspace, batch.Entries)
+		if err != nil {
+			return posted, err
+		}
+		cursor := batch.Cursor
+		for _, event := range events {
+			if err := post(ctx, client, url, event.body, retries); err != nil {
+				return posted, &postError{err}
+			}
+			posted++
+			if ok, err := database.AdvanceActivity(url, cursor, event.id); err != nil || !ok {
+				return posted, err
+			}
+			cursor = event.id
+		}
+		if ok, err := database.AdvanceActivity(url, cursor, batch.Through); err != nil || !ok {
+			return posted, err
+		}
+		if len(batch.Entries) < batchSize {
+			return posted, nil
+		}
+	}
+}
+
+// event is the JSON body posted for an activity entry
+type event struct {
+	id   int64 // the activity entry
+	body []byte
+}
+
+// buildEvents turns activity entries into the JSON bodies to post
+func buildEvents(database *db.DB, workspace string, entries []db.Activity) ([]event, error) {
+	columns, err := database.GetColumns()
+	if err != nil {
+		return nil, err
+	}
+	column := func(status model.TaskStatus) *Column {
+		if status == "" {
+			return nil
+		}
+		if col, ok := model.FindColumn(columns, string(status)); ok {
+			return &Column{Status: col.Status, Name: col.Name}
+		}
+		return &Column{Status: status, Name: string(status)}
+	}
+
+	events := make([]event, 0, len(entries))
+	for _, a := range entries {
+		e := Event{Workspace: workspace, Timestamp: a.At, OldColumn: column(a.OldStatus), NewColumn: column(a.NewStatus)}
+		switch a.Action {
+		case db.ActionCreate:
+			e.Event = EventCreated
+		case db.ActionMove:
+			e.Event = EventMoved
+			if model.IsDone(columns, a.NewStatus) && !model.IsDone(columns, a.OldStatus) {
+				e.Event = EventCompleted
+			}
+		case db.ActionDelete:
+			e.Event = EventDeleted
+		default:
+			continue
+		}
+		// The task as it is now; one deleted permanently since is left
+		// with what the entry says about it
+		task, err := database.GetTask(a.TaskID)
+		if err != nil {
+			status := a.NewStatus
+			if status == "" {
+				status = a.OldStatus
+			}
+			task = &model.Task{ID: a.TaskID, Title: a.Title(), Status: status}
+		}
+		e.Task = task
+		body, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event{a.ID, body})
+	}
+	return events, nil
+}
+
+// post sends one event to url, retrying after each of the waits while it
+// fails
+func post(ctx context.Context, client *http.Client, url string, body []byte, retries []time.Duration) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = postOnce(ctx, client, url, body); err == nil || attempt == len(retries) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retries[attempt]):
+		}
+	}
+}
+
+// postOnce sends one event to url; any status but 2xx is an error
+func postOnce(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cli_kanban-webhook")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	return nil
+}
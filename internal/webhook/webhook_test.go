@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/model"
+)
+
+// receiver is a webhook URL recording what is posted to it
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+	events   []Event
+	failures int // posts still to refuse with a 500
+}
+
+// newReceiver returns a receiver that refuses its first failures posts
+func newReceiver(t *testing.T, failures int) *receiver {
+	r := &receiver{failures: failures}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.requests = append(r.requests, req)
+		if r.failures > 0 {
+			r.failures--
+			http.Error(w, "down", http.StatusInternalServerError)
+			return
+		}
+		var e Event
+		body, _ := io.ReadAll(req.Body)
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Errorf("posted %q: %v", body, err)
+		}
+		r.events = append(r.events, e)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// names returns the events received, by name
+func (r *receiver) names() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, len(r.events))
+	for i, e := range r.events {
+		names[i] = e.Event
+	}
+	return strings.Join(names, ",")
+}
+
+// newBoard returns a board where a task was created, started, completed and
+// deleted, and another one created
+func newBoard(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.NewMemory()
+	if err != nil {
+		t.Fatalf("NewMemory: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	task, err := database.CreateTask("Fix login", model.StatusTodo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range []model.TaskStatus{model.StatusInProgress, model.StatusDone} {
+		if _, err := database.UpdateTaskStatus(task.ID, status); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := database.DeleteTask(task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.CreateTask("Write docs", model.StatusTodo); err != nil {
+		t.Fatal(err)
+	}
+	return database
+}
+
+func TestDeliverPayload(t *testing.T) {
+	database := newBoard(t)
+	r := newReceiver(t, 0)
+
+	result, err := DeliverOnce(context.Background(), database, "work", []string{r.URL})
+	if err != nil {
+		t.Fatalf("DeliverOnce: %v", err)
+	}
+	if result.Events != 5 || len(result.Failed) != 0 {
+		t.Fatalf("DeliverOnce = %+v, want 5 events delivered", result)
+	}
+	if got, want := r.names(), "created,moved,completed,deleted,created"; got != want {
+		t.Fatalf("received %s, want %s", got, want)
+	}
+
+	for _, req := range r.requests {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" || req.Header.Get("User-Agent") != "cli_kanban-webhook" {
+			t.Errorf("posted with %s, Content-Type %q and User-Agent %q", req.Method, req.Header.Get("Content-Type"), req.Header.Get("User-Agent"))
+		}
+	}
+
+	created, moved, completed := r.events[0], r.events[1], r.events[2]
+	if created.Workspace != "work" || created.Task == nil || created.Task.Title != "Fix login" || created.Timestamp.IsZero() {
+		t.Errorf("created event = %+v", created)
+	}
+	if created.OldColumn != nil || created.NewColumn == nil || *created.NewColumn != (Column{model.StatusTodo, "Todo"}) {
+		t.Errorf("created event moved from %v to %v, want to Todo", created.OldColumn, created.NewColumn)
+	}
+	if moved.OldColumn == nil || *moved.OldColumn != (Column{model.StatusTodo, "Todo"}) ||
+		moved.NewColumn == nil || *moved.NewColumn != (Column{model.StatusInProgress, "In Progress"}) {
+		t.Errorf("moved event moved from %v to %v, want from Todo to In Progress", moved.OldColumn, moved.NewColumn)
+	}
+	if completed.NewColumn == nil || completed.NewColumn.Status != model.StatusDone {
+		t.Errorf("completed event moved to %v, want Done", completed.NewColumn)
+	}
+	// Events carry the task as it is now, in the trash
+	if completed.Task == nil || completed.Task.DeletedAt == nil {
+		t.Errorf("completed event has the task as %+v, want it deleted", completed.Task)
+	}
+
+	// Delivered changes aren't posted again
+	if result, err := DeliverOnce(context.Background(), database, "work", []string{r.URL}); err != nil || result.Events != 0 {
+		t.Errorf("second DeliverOnce = %+v, %v, want nothing posted", result, err)
+	}
+}
+
+func TestDeliverFailure(t *testing.T) {
+	database := newBoard(t)
+	down := newReceiver(t, 2)
+	up := newReceiver(t, 0)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	result, err := DeliverOnce(context.Background(), database, "work", []string{down.URL, up.URL, closed.URL})
+	if err != nil {
+		t.Fatalf("DeliverOnce: %v", err)
+	}
+	if result.Events != 5 || len(result.Failed) != 2 {
+		t.Fatalf("DeliverOnce = %+v, want 5 events and 2 URLs failed", result)
+	}
+	if err := result.Failed[down.URL]; err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("failure of a URL answering 500 = %v", err)
+	}
+	if result.Failed[closed.URL] == nil {
+		t.Error("an unreachable URL didn't fail")
+	}
+	// A URL that is down doesn't hold up the others
+	if got := up.names(); got != "created,moved,completed,deleted,created" {
+		t.Errorf("the working URL received %s", got)
+	}
+
+	// A retry after the backoff gets through, and the URL receives what it
+	// missed from the change it refused
+	result, err = deliver(context.Background(), database, "work", []string{down.URL}, []time.Duration{time.Millisecond})
+	if err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if result.Events != 5 || len(result.Failed) != 0 {
+		t.Errorf("deliver with a retry = %+v, want 5 events delivered", result)
+	}
+	if got := down.names(); got != "created,moved,completed,deleted,created" {
+		t.Errorf("the URL back up received %s", got)
+	}
+	if len(down.requests) != 7 {
+		t.Errorf("the URL back up got %d posts, want 2 refused and 5 accepted", len(down.requests))
+	}
+}
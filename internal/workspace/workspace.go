@@ -0,0 +1,245 @@
+// Package workspace locates the per-workspace database files and the config
+// directory.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/happytaoer/cli_kanban/internal/crypt"
+)
+
+const (
+	// Default is the workspace used when none is given
+	Default = "default"
+	// FilePrefix starts the file name of every workspace database
+	FilePrefix = "cli_kanban__"
+	// HomeEnv is the environment variable relocating the data directory
+	HomeEnv = "CLI_KANBAN_HOME"
+)
+
+// dataDirOverride is the data directory set with SetDataDir
+var dataDirOverride string
+
+// NameRe matches valid workspace names
+var NameRe = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Workspace is a workspace database found in the data directory
+type Workspace struct {
+	Name      string
+	Path      string
+	Encrypted bool
+}
+
+// Validate returns an error if name is not a valid workspace name
+func Validate(name string) error {
+	if !NameRe.MatchString(name) {
+		return fmt.Errorf("invalid workspace name %q: must match %s", name, NameRe.String())
+	}
+	return nil
+}
+
+// SetDataDir makes DataDir return dir, taking precedence over HomeEnv. An
+// empty dir restores the default lookup.
+func SetDataDir(dir string) {
+	dataDirOverride = dir
+}
+
+// DataDir returns the directory holding the workspace databases: the one set
+// with SetDataDir, else $CLI_KANBAN_HOME, else $XDG_DATA_HOME/cli_kanban
+// (~/.cli_kanban with UseLegacyDir)
+func DataDir() (string, error) {
+	if dir, ok, err := customDir(); ok || err != nil {
+		return dir, err
+	}
+	return defaultDataDir()
+}
+
+// customDir returns the data directory set with SetDataDir or HomeEnv, if any
+func customDir() (string, bool, error) {
+	dir := dataDirOverride
+	if dir == "" {
+		dir = os.Getenv(HomeEnv)
+	}
+	if dir == "" {
+		return "", false, nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid data directory %q: %w", dir, err)
+	}
+	return abs, true, nil
+}
+
+// homeDir returns the user's home directory
+func homeDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user home directory: %w", err)
+	}
+
+	if homeDir == "" {
+		return "", errors.New("failed to determine user home directory")
+	}
+
+	return homeDir, nil
+}
+
+// File returns the database file path of a workspace inside dataDir
+func File(dataDir, ws string) string {
+	return filepath.Join(dataDir, FilePrefix+ws+".db")
+}
+
+// Path validates the workspace name, prepares the data directory and
+// returns the database path for the workspace, migrating the legacy default db if needed.
+func Path(ws string) (string, error) {
+	if ws == "" {
+		ws = Default
+	}
+	if err := Validate(ws); err != nil {
+		return "", err
+	}
+
+	dataDir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create data directory %q: %w", dataDir, err)
+	}
+
+	// One-time migration: copy old single-db default (~/.cli_kanban.db) into the new default workspace db.
+	// A relocated data directory starts out empty instead.
+	if defaultDir, err := defaultDataDir(); err == nil && ws == Default && dataDir == defaultDir {
+		oldPath, err := legacyDefaultDBPath()
+		if err != nil {
+			return "", err
+		}
+		newPath := File(dataDir, Default)
+		if err := migrateLegacyDefaultDB(oldPath, newPath); err != nil {
+			return "", err
+		}
+	}
+
+	return File(dataDir, ws), nil
+}
+
+// List returns the workspaces in the data directory, sorted by name. A
+// missing data directory means there are no workspaces yet.
+func List() ([]Workspace, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read data directory %q: %w", dataDir, err)
+	}
+
+	workspaces := make([]Workspace, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if !strings.HasPrefix(name, FilePrefix) || !strings.HasSuffix(name, ".db") {
+			continue
+		}
+		ws := strings.TrimSuffix(strings.TrimPrefix(name, FilePrefix), ".db")
+		if ws == "" {
+			continue
+		}
+		path := filepath.Join(dataDir, name)
+		encrypted, _ := crypt.IsEncrypted(path)
+		workspaces = append(workspaces, Workspace{Name: ws, Path: path, Encrypted: encrypted})
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool { return workspaces[i].Name < workspaces[j].Name })
+	return workspaces, nil
+}
+
+func legacyDefaultDBPath() (string, error) {
+	homeDir, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".cli_kanban.db"), nil
+}
+
+func migrateLegacyDefaultDB(oldPath, newPath string) error {
+	if fileExists(newPath) {
+		return nil
+	}
+	if !fileExists(oldPath) {
+		return nil
+	}
+	return CopyFile(oldPath, newPath, 0o600)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// CopyFile copies a database file to dst, which must not exist yet, and
+// flushes the copy to disk
+func CopyFile(src, dst string, mode os.FileMode) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open db %q: %w", src, err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create db %q: %w", dst, err)
+	}
+	defer func() {
+		_ = dstFile.Close()
+	}()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("failed to copy db %q to %q: %w", src, dst, err)
+	}
+	if err := dstFile.Sync(); err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("failed to sync db %q: %w", dst, err)
+	}
+	if err := dstFile.Close(); err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("failed to close db %q: %w", dst, err)
+	}
+	if err := SyncDir(filepath.Dir(dst)); err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// SyncDir flushes the directory at path to disk, so a file just created or
+// renamed in it survives a crash
+func SyncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to sync directory %q: %w", path, err)
+	}
+	err = dir.Sync()
+	if closeErr := dir.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync directory %q: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package workspace
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	appDirName    = "cli_kanban"
+	legacyDirName = ".cli_kanban"
+	// ConfigFile is the name of the config file in the config directory
+	ConfigFile = "config.yaml"
+)
+
+// legacyFolders are the folders of ~/.cli_kanban migrated with the databases:
+// their backups, git clones and trashed workspaces
+var legacyFolders = []string{"backups", "git", "trash"}
+
+// legacyLayout keeps the data and config in ~/.cli_kanban, see UseLegacyDir
+var legacyLayout bool
+
+// UseLegacyDir keeps the databases and the config in ~/.cli_kanban, as before
+// the XDG base directories were used, when on is true
+func UseLegacyDir(on bool) {
+	legacyLayout = on
+}
+
+// ConfigDir returns the directory holding config.yaml: the relocated data
+// directory when one is set, else $XDG_CONFIG_HOME/cli_kanban (~/.cli_kanban
+// with UseLegacyDir)
+func ConfigDir() (string, error) {
+	if dir, ok, err := customDir(); ok || err != nil {
+		return dir, err
+	}
+	if legacyLayout {
+		return legacyDir()
+	}
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// defaultDataDir returns the data directory used when none is set
+func defaultDataDir() (string, error) {
+	if legacyLayout {
+		return legacyDir()
+	}
+	return xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// legacyDir returns the directory that held everything before XDG support
+func legacyDir() (string, error) {
+	homeDir, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, legacyDirName), nil
+}
+
+// xdgDir returns the cli_kanban directory under the base directory named by
+// env, or under fallback in the home directory when env is unset. Relative
+// paths are ignored, as the XDG spec requires.
+func xdgDir(env, fallback string) (string, error) {
+	if base := os.Getenv(env); filepath.IsAbs(base) {
+		return filepath.Join(base, appDirName), nil
+	}
+	homeDir, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, fallback, appDirName), nil
+}
+
+// MigrateLegacyDir copies the workspace databases, with the changes of their
+// write-ahead logs, the legacyFolders and the config of ~/.cli_kanban to the
+// XDG directories the first time they are used, that is when the XDG data
+// directory doesn't exist yet. Each copy is verified; the originals are left
+// untouched. It returns a note describing the migration,
+// or "" when there was nothing to do. Relocated and legacy layouts are never
+// migrated.
+func MigrateLegacyDir() (string, error) {
+	if _, ok, err := customDir(); ok || err != nil || legacyLayout {
+		return "", err
+	}
+	oldDir, err := legacyDir()
+	if err != nil {
+		return "", err
+	}
+	newDir, err := defaultDataDir()
+	if err != nil {
+		return "", err
+	}
+	if fileExists(newDir) {
+		return "", nil
+	}
+
+	entries, err := os.ReadDir(oldDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read legacy data directory %q: %w", oldDir, err)
+	}
+	var names, folders []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, FilePrefix) && strings.HasSuffix(name, ".db") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	for _, folder := range legacyFolders {
+		if info, err := os.Stat(filepath.Join(oldDir, folder)); err == nil && info.IsDir() {
+			folders = append(folders, folder)
+		}
+	}
+
+	if err := os.MkdirAll(newDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create data directory %q: %w", newDir, err)
+	}
+	var files []string
+	for _, name := range names {
+		// Changes not checkpointed yet are only in the write-ahead log,
+		// which SQLite replays on opening the copy; the -shm index is
+		// rebuilt from it
+		files = append(files, name)
+		if fileExists(filepath.Join(oldDir, name+"-wal")) {
+			files = append(files, name+"-wal")
+		}
+	}
+	for _, name := range files {
+		err = copyVerified(filepath.Join(oldDir, name), filepath.Join(newDir, name), 0o600)
+		if err != nil {
+			break
+		}
+	}
+	for i := 0; err == nil && i < len(folders); i++ {
+		err = copyTree(filepath.Join(oldDir, folders[i]), filepath.Join(newDir, folders[i]))
+	}
+	if err != nil {
+		// Start over next time rather than leave a partial copy behind
+		_ = os.RemoveAll(newDir)
+		return "", fmt.Errorf("failed to migrate %s to %s (run with --legacy-dir to keep using it): %w", oldDir, newDir, err)
+	}
+
+	note := fmt.Sprintf("Copied %d workspace database(s) from %s to %s.", len(names), oldDir, newDir)
+	if len(folders) > 0 {
+		note += fmt.Sprintf(" Also copied: %s.", strings.Join(folders, ", "))
+	}
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	oldConfig, newConfig := filepath.Join(oldDir, ConfigFile), filepath.Join(configDir, ConfigFile)
+	if fileExists(oldConfig) && !fileExists(newConfig) {
+		if err := os.MkdirAll(configDir, 0o700); err != nil {
+			return "", fmt.Errorf("failed to create config directory %q: %w", configDir, err)
+		}
+		if err := copyVerified(oldConfig, newConfig, 0o600); err != nil {
+			return "", fmt.Errorf("failed to migrate %s (run with --legacy-dir to keep using it): %w", oldConfig, err)
+		}
+		note += fmt.Sprintf(" Copied %s to %s.", ConfigFile, configDir)
+	}
+	note += fmt.Sprintf(" The originals in %s were left untouched and can be removed; run with --legacy-dir to keep using them instead.", oldDir)
+	return note, nil
+}
+
+// copyTree copies the folder src to dst, which must not exist yet, verifying
+// each file and keeping its permissions and the symlinks
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			if err := os.Mkdir(target, info.Mode().Perm()|0o700); err != nil {
+				return fmt.Errorf("failed to create directory %q: %w", target, err)
+			}
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return fmt.Errorf("failed to create symlink %q: %w", target, err)
+			}
+		case d.Type().IsRegular():
+			return copyVerified(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// copyVerified copies src to dst with the permissions mode and checks that
+// both have the same contents
+func copyVerified(src, dst string, mode os.FileMode) error {
+	if err := CopyFile(src, dst, mode); err != nil {
+		return err
+	}
+	srcSum, err := fileSum(src)
+	if err != nil {
+		return err
+	}
+	dstSum, err := fileSum(dst)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(srcSum, dstSum) {
+		_ = os.Remove(dst)
+		return fmt.Errorf("copy of %q differs from the original", src)
+	}
+	return nil
+}
+
+// fileSum returns the SHA-256 checksum of a file
+func fileSum(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+	return h.Sum(nil), nil
+}
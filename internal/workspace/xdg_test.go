@@ -0,0 +1,227 @@
+package workspace
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// dirs are the directories of a test user
+type dirs struct {
+	home, data, config, legacy string
+}
+
+// newHome points HOME and the XDG base directories at a temporary home, and
+// returns its directories
+func newHome(t *testing.T) dirs {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv(HomeEnv, "")
+	SetDataDir("")
+	UseLegacyDir(false)
+	t.Cleanup(func() { UseLegacyDir(false) })
+	return dirs{
+		home:   home,
+		data:   filepath.Join(home, "data", appDirName),
+		config: filepath.Join(home, "config", appDirName),
+		legacy: filepath.Join(home, legacyDirName),
+	}
+}
+
+// writeFile writes data to path, creating its directory
+func writeFile(t *testing.T, path, data string, mode os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFile returns the contents of path, "" when it is missing
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// newLegacyDir fills ~/.cli_kanban as an older version left it: the database
+// of the default workspace, still open with a change only in its write-ahead
+// log, that of the work workspace, their folders and the config. It returns
+// the open database.
+func newLegacyDir(t *testing.T, d dirs) *sql.DB {
+	t.Helper()
+	if err := os.MkdirAll(d.legacy, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sql.Open("sqlite3", File(d.legacy, Default)+"?_journal_mode=WAL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		"PRAGMA wal_autocheckpoint = 0",
+		"CREATE TABLE tasks (title TEXT)",
+		"INSERT INTO tasks VALUES ('Fix login')",
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	if !fileExists(File(d.legacy, Default) + "-wal") {
+		t.Fatal("the change isn't in the write-ahead log")
+	}
+
+	writeFile(t, File(d.legacy, "work"), "work database", 0o600)
+	writeFile(t, filepath.Join(d.legacy, ConfigFile), "theme: dracula\n", 0o600)
+	writeFile(t, filepath.Join(d.legacy, "backups", "work", "2026-01-02.db"), "backup", 0o600)
+	writeFile(t, filepath.Join(d.legacy, "git", "work", ".git", "objects", "ab", "cdef"), "object", 0o444)
+	writeFile(t, filepath.Join(d.legacy, "trash", "old", FilePrefix+"old.db"), "trashed", 0o600)
+	writeFile(t, filepath.Join(d.legacy, "notes.txt"), "not ours", 0o600)
+	return conn
+}
+
+func TestFreshInstall(t *testing.T) {
+	d := newHome(t)
+	if note, err := MigrateLegacyDir(); note != "" || err != nil {
+		t.Errorf("MigrateLegacyDir = %q, %v, want nothing to do", note, err)
+	}
+	if fileExists(d.data) {
+		t.Error("the migration created the data directory")
+	}
+
+	if got, err := DataDir(); err != nil || got != d.data {
+		t.Errorf("DataDir = %q, %v, want %q", got, err, d.data)
+	}
+	if got, err := ConfigDir(); err != nil || got != d.config {
+		t.Errorf("ConfigDir = %q, %v, want %q", got, err, d.config)
+	}
+	if got, err := Path("work"); err != nil || got != File(d.data, "work") || !fileExists(d.data) {
+		t.Errorf("Path = %q, %v, want %q in a new data directory", got, err, File(d.data, "work"))
+	}
+
+	// Without the XDG variables, or with relative ones, the defaults are
+	// under the home directory
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "relative")
+	if got, err := DataDir(); err != nil || got != filepath.Join(d.home, ".local", "share", appDirName) {
+		t.Errorf("DataDir without XDG_DATA_HOME = %q, %v", got, err)
+	}
+	if got, err := ConfigDir(); err != nil || got != filepath.Join(d.home, ".config", appDirName) {
+		t.Errorf("ConfigDir with a relative XDG_CONFIG_HOME = %q, %v", got, err)
+	}
+}
+
+func TestMigrateLegacyDir(t *testing.T) {
+	d := newHome(t)
+	newLegacyDir(t, d)
+
+	note, err := MigrateLegacyDir()
+	if err != nil {
+		t.Fatalf("MigrateLegacyDir: %v", err)
+	}
+	for _, want := range []string{"Copied 2 workspace database(s)", "Also copied: backups, git, trash.", "Copied " + ConfigFile} {
+		if !strings.Contains(note, want) {
+			t.Errorf("note %q doesn't say %q", note, want)
+		}
+	}
+
+	for _, name := range []string{
+		FilePrefix + "work.db",
+		filepath.Join("backups", "work", "2026-01-02.db"),
+		filepath.Join("git", "work", ".git", "objects", "ab", "cdef"),
+		filepath.Join("trash", "old", FilePrefix+"old.db"),
+	} {
+		if got, want := readFile(t, filepath.Join(d.data, name)), readFile(t, filepath.Join(d.legacy, name)); got != want {
+			t.Errorf("%s was copied as %q, want %q", name, got, want)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(d.data, "git", "work", ".git", "objects", "ab", "cdef")); err != nil || info.Mode().Perm() != 0o444 {
+		t.Errorf("git object copied as %v, %v, want it read-only", info, err)
+	}
+	if fileExists(filepath.Join(d.data, "notes.txt")) {
+		t.Error("a file of another program was copied")
+	}
+	if got := readFile(t, filepath.Join(d.config, ConfigFile)); got != "theme: dracula\n" {
+		t.Errorf("config copied as %q", got)
+	}
+
+	// The copy holds the change that was only in the write-ahead log
+	copied, err := sql.Open("sqlite3", File(d.data, Default))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copied.Close()
+	var title string
+	if err := copied.QueryRow("SELECT title FROM tasks").Scan(&title); err != nil || title != "Fix login" {
+		t.Errorf("copied database holds %q, %v, want the task", title, err)
+	}
+
+	workspaces, err := List()
+	if err != nil || len(workspaces) != 2 || workspaces[0].Name != Default || workspaces[1].Name != "work" {
+		t.Errorf("List = %+v, %v, want default and work", workspaces, err)
+	}
+	if !fileExists(File(d.legacy, Default)) || readFile(t, File(d.legacy, "work")) != "work database" {
+		t.Error("the originals were changed")
+	}
+}
+
+func TestMigrateLegacyDirAlreadyMigrated(t *testing.T) {
+	d := newHome(t)
+	newLegacyDir(t, d)
+	if _, err := MigrateLegacyDir(); err != nil {
+		t.Fatalf("MigrateLegacyDir: %v", err)
+	}
+
+	// Later changes to the legacy directory are left there
+	writeFile(t, File(d.legacy, "work"), "changed since", 0o600)
+	writeFile(t, File(d.legacy, "new"), "new database", 0o600)
+	writeFile(t, filepath.Join(d.legacy, ConfigFile), "theme: nord\n", 0o600)
+
+	if note, err := MigrateLegacyDir(); note != "" || err != nil {
+		t.Errorf("second MigrateLegacyDir = %q, %v, want nothing to do", note, err)
+	}
+	if got := readFile(t, File(d.data, "work")); got != "work database" {
+		t.Errorf("work database is now %q, want the first copy", got)
+	}
+	if fileExists(File(d.data, "new")) {
+		t.Error("a database added to the legacy directory was copied")
+	}
+	if got := readFile(t, filepath.Join(d.config, ConfigFile)); got != "theme: dracula\n" {
+		t.Errorf("config is now %q, want the first copy", got)
+	}
+}
+
+func TestLegacyDir(t *testing.T) {
+	d := newHome(t)
+	newLegacyDir(t, d)
+	UseLegacyDir(true)
+
+	if note, err := MigrateLegacyDir(); note != "" || err != nil {
+		t.Errorf("MigrateLegacyDir with --legacy-dir = %q, %v, want nothing to do", note, err)
+	}
+	if fileExists(d.data) || fileExists(d.config) {
+		t.Error("the XDG directories were created with --legacy-dir")
+	}
+	if got, err := DataDir(); err != nil || got != d.legacy {
+		t.Errorf("DataDir = %q, %v, want %q", got, err, d.legacy)
+	}
+	if got, err := ConfigDir(); err != nil || got != d.legacy {
+		t.Errorf("ConfigDir = %q, %v, want %q", got, err, d.legacy)
+	}
+	if got, err := Path("work"); err != nil || got != File(d.legacy, "work") {
+		t.Errorf("Path = %q, %v, want %q", got, err, File(d.legacy, "work"))
+	}
+}
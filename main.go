@@ -1,48 +1,805 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"regexp"
+	"runtime/debug"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/happytaoer/cli_kanban/internal/agenda"
+	"github.com/happytaoer/cli_kanban/internal/archive"
+	"github.com/happytaoer/cli_kanban/internal/backup"
+	"github.com/happytaoer/cli_kanban/internal/config"
+	"github.com/happytaoer/cli_kanban/internal/crypt"
+	"github.com/happytaoer/cli_kanban/internal/dates"
 	"github.com/happytaoer/cli_kanban/internal/db"
+	"github.com/happytaoer/cli_kanban/internal/export"
+	"github.com/happytaoer/cli_kanban/internal/github"
+	"github.com/happytaoer/cli_kanban/internal/gitsync"
+	"github.com/happytaoer/cli_kanban/internal/keychain"
+	"github.com/happytaoer/cli_kanban/internal/mdsync"
+	"github.com/happytaoer/cli_kanban/internal/model"
+	"github.com/happytaoer/cli_kanban/internal/quickadd"
+	"github.com/happytaoer/cli_kanban/internal/server"
 	"github.com/happytaoer/cli_kanban/internal/tui"
+	"github.com/happytaoer/cli_kanban/internal/webhook"
+	"github.com/happytaoer/cli_kanban/internal/workspace"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
-	workspace       string
+	workspaceName   string
+	customDataDir   string
+	legacyDir       bool
 	listWorkspaces  bool
+	listWSJSON      bool
 	deleteWorkspace string
+	forceDelete     bool
+	noMouse         bool
+	readOnly        bool
+	noSample        bool
+	demo            bool
+
+	addColumn          string
+	addCreateWorkspace bool
+	addRepeat          string
+	addTemplate        string
+	addVars            []string
+	addStdin           bool
+	addQuickAdd        bool
+	addDryRun          bool
+	addParent          int64
+
+	templatesDelete string
+
+	listColumn string
+	listJSON   bool
+	listView   string
+
+	moveColumn      string
+	moveToWorkspace string
+
+	exportFormat       string
+	exportOutput       string
+	exportICSComponent string
+
+	importMerge     bool
+	importOverwrite bool
+	importFormat    string
+
+	trelloIncludeArchived bool
+
+	githubRepo   string
+	githubState  string
+	githubLabels []string
+
+	syncDir    string
+	syncDryRun bool
+
+	syncGitRemote string
+	syncGitStatus bool
+
+	serveAddr string
+	serveAll  bool
+
+	cloneColumnsOnly bool
+
+	mergePrefix       string
+	mergeDeleteSource bool
+
+	encryptKeychain bool
+
+	archiveOutput string
+	unarchiveAs   string
+
+	wipStrict bool
+
+	pruneOlderThan string
+
+	restoreFrom string
+
+	doctorAll bool
+	doctorFix bool
+
+	searchAll     bool
+	searchColumn  string
+	searchLabel   string
+	searchOverdue bool
+	searchJSON    bool
+
+	statsSince   string
+	statsJSON    bool
+	statsOneLine bool
+
+	logLimit int
+	logTask  int64
+	logJSON  bool
+
+	timesheetSince string
+	timesheetJSON  bool
+
+	remindQuiet bool
 )
 
+// errWorkspaceNotFound is returned when a command targets a workspace whose database does not exist
+var errWorkspaceNotFound = errors.New("workspace not found")
+
+// errTasksDue makes remind exit with status 1 without printing an error
+var errTasksDue = errors.New("tasks are due")
+
+// version is the version of the binary, set when building a release with
+// -ldflags "-X main.version=v1.2.3"
+var version string
+
 const (
-	defaultWorkspace = "default"
-	dataDirName      = ".cli_kanban"
-	dbFilePrefix     = "cli_kanban__"
+	trashDirName    = "trash"
+	trashTimeFormat = "20060102T150405.000"
 )
 
-var workspaceNameRe = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+// workspaceFolders are the folders of the data directory that keep a
+// subfolder per workspace, named after it: its backups and the clone it
+// syncs through with sync git. They follow the database when it is renamed
+// or moved to the trash.
+var workspaceFolders = []string{"backups", "git"}
 
 func main() {
+	db.SetPassphraseFunc(workspacePassphrase)
+
 	rootCmd := &cobra.Command{
 		Use:   "cli_kanban",
 		Short: "A terminal-based Kanban board",
 		Long:  `cli_kanban is a beautiful TUI application for managing tasks in a Kanban board format.`,
 		RunE:  runTUI,
+
+		SilenceUsage:  true,
+		SilenceErrors: true,
+
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			workspace.SetDataDir(customDataDir)
+			workspace.UseLegacyDir(legacyDir)
+			note, err := workspace.MigrateLegacyDir()
+			if err != nil {
+				return err
+			}
+			if note != "" {
+				fmt.Fprintln(os.Stderr, note)
+			}
+			return nil
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			// The TUI and serve post changes as they are made, and the
+			// commands that don't change tasks leave the database closed
+			if _, ok := cmd.Annotations[changesTasks]; ok {
+				deliverWebhooks(workspaceName)
+			}
+		},
 	}
 
-	rootCmd.PersistentFlags().StringVarP(&workspace, "workspace", "w", defaultWorkspace, "Workspace name (lowercase, digits, _, -)")
+	rootCmd.PersistentFlags().StringVarP(&workspaceName, "workspace", "w", workspace.Default, "Workspace name (lowercase, digits, _, -)")
+	rootCmd.PersistentFlags().StringVar(&customDataDir, "data-dir", "", "Directory holding the workspace databases and config (default $"+workspace.HomeEnv+" or $XDG_DATA_HOME/cli_kanban)")
+	rootCmd.PersistentFlags().BoolVar(&legacyDir, "legacy-dir", false, "Keep the databases and config in ~/.cli_kanban instead of the XDG directories")
 	rootCmd.PersistentFlags().BoolVarP(&listWorkspaces, "list", "l", false, "List available workspaces and exit")
 	rootCmd.PersistentFlags().StringVarP(&deleteWorkspace, "delete", "d", "", "Delete a workspace database and exit")
+	rootCmd.Flags().BoolVar(&forceDelete, "force", false, "Delete without asking for confirmation")
+	rootCmd.Flags().BoolVar(&noMouse, "no-mouse", false, "Disable mouse support (keeps terminal text selection working)")
+	rootCmd.Flags().BoolVar(&readOnly, "read-only", false, "Open the board without changing it: the database is opened read-only and editing keys are disabled")
+	rootCmd.Flags().BoolVar(&noSample, "no-sample", false, "Start a new workspace empty instead of with example tasks and a welcome overlay")
+	rootCmd.Flags().BoolVar(&listWSJSON, "json", false, "With --list, output the workspaces as JSON")
+	rootCmd.Flags().BoolVar(&demo, "demo", false, "Try the app on a sample board held in memory: everything works, nothing is saved")
+	_ = rootCmd.RegisterFlagCompletionFunc("workspace", completeWorkspaces)
+	_ = rootCmd.RegisterFlagCompletionFunc("delete", completeWorkspaces)
+
+	addCmd := &cobra.Command{
+		Use:   "add <title>",
+		Short: "Add a task without opening the TUI",
+		Long: `Add a task without opening the TUI. The title may set attributes inline:
+!high sets the priority, #backend adds a tag and @fri (or @tomorrow, @+2w,
+@2025-03-14) sets the due date, e.g. "Fix login bug !high #backend @fri".
+With --repeat (e.g. --repeat weekly) the task comes back in the first column,
+due on its next date, each time it is moved to the done column.
+
+With --template the task starts from a template saved in the TUI, taking its
+description, tags, priority and checklist. Its {{var}} placeholders are filled
+with --var, e.g. add --template release --var version=1.4; a title given as
+well replaces the template's.
+
+With --stdin every non-empty line read from standard input becomes a task, as
+given or, with --quick-add, parsed for the inline attributes above, e.g.
+git log --oneline | cli_kanban add --stdin --column backlog. The tasks are
+created in one transaction, so either all of them are or none.
+
+--dry-run prints the tasks that would be created without creating them.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if addStdin {
+				return cobra.NoArgs(cmd, args)
+			}
+			if addTemplate != "" {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		Annotations: map[string]string{changesTasks: ""},
+		RunE:        runAdd,
+	}
+	addCmd.Flags().StringVarP(&addColumn, "column", "c", "", "Column to add the task to (defaults to the first column)")
+	addCmd.Flags().BoolVar(&addCreateWorkspace, "create-workspace", false, "Create the workspace if it does not exist")
+	addCmd.Flags().StringVar(&addRepeat, "repeat", "", "Repeat the task when it is done: "+dates.RecurrenceHelp)
+	addCmd.Flags().StringVar(&addTemplate, "template", "", "Start the task from this template")
+	addCmd.Flags().StringArrayVar(&addVars, "var", nil, "Value of a template placeholder as name=value (repeatable)")
+	addCmd.Flags().BoolVar(&addStdin, "stdin", false, "Create one task per non-empty line of standard input")
+	addCmd.Flags().BoolVar(&addQuickAdd, "quick-add", false, "With --stdin, parse !priority, #tag and @due in every line")
+	addCmd.Flags().BoolVar(&addDryRun, "dry-run", false, "Print the tasks that would be created without creating them")
+	addCmd.Flags().Int64Var(&addParent, "parent", 0, "Add the task as a child of this epic (a task ID)")
+	_ = addCmd.RegisterFlagCompletionFunc("column", completeColumns)
+	_ = addCmd.RegisterFlagCompletionFunc("repeat", cobra.FixedCompletions([]string{"daily", "weekly", "weekdays", "monthly"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.AddCommand(addCmd)
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List tasks without opening the TUI",
+		Args:  cobra.NoArgs,
+		RunE:  runList,
+	}
+	listCmd.Flags().StringVarP(&listColumn, "column", "c", "", "Only list tasks in this column")
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Output tasks as JSON")
+	listCmd.Flags().StringVar(&listView, "view", "", "Only list what this saved view shows, in its order")
+	_ = listCmd.RegisterFlagCompletionFunc("column", completeColumns)
+	_ = listCmd.RegisterFlagCompletionFunc("view", completeViews)
+	rootCmd.AddCommand(listCmd)
+
+	moveCmd := &cobra.Command{
+		Use:     "move <task-id> [column]",
+		Aliases: []string{"mv"},
+		Short:   "Move a task to another column or workspace without opening the TUI",
+		Long: `Move a task to another column, given as an argument or with --column. With
+--to-workspace the task moves into another workspace, e.g.
+mv 42 --to-workspace work --column todo, keeping its description, tags,
+checklist and timestamps; without --column it lands in the first column.
+The task gets a new ID there and is only removed from this workspace once
+the copy is saved.`,
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: completeMoveArgs,
+		Annotations:       map[string]string{changesTasks: ""},
+		RunE:              runMove,
+	}
+	moveCmd.Flags().StringVarP(&moveColumn, "column", "c", "", "Column to move the task to")
+	moveCmd.Flags().StringVar(&moveToWorkspace, "to-workspace", "", "Move the task into this workspace")
+	_ = moveCmd.RegisterFlagCompletionFunc("column", completeColumns)
+	_ = moveCmd.RegisterFlagCompletionFunc("to-workspace", completeWorkspaces)
+	rootCmd.AddCommand(moveCmd)
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a workspace board",
+		Args:  cobra.NoArgs,
+		RunE:  runExport,
+	}
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "json", "Export format (json, markdown, csv, org, ics, taskwarrior)")
+	exportCmd.Flags().StringVar(&exportICSComponent, "ics-component", "vtodo", "With --format ics, write tasks as to-dos (vtodo) or all-day events (vevent)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to file instead of stdout")
+	rootCmd.AddCommand(exportCmd)
+
+	importCmd := &cobra.Command{
+		Use:         "import <file>",
+		Short:       "Import a board exported with the export command",
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{changesTasks: ""},
+		RunE:        runImport,
+	}
+	importCmd.PersistentFlags().BoolVar(&importMerge, "merge", false, "Add imported tasks to a non-empty workspace")
+	importCmd.PersistentFlags().BoolVar(&importOverwrite, "overwrite", false, "Replace all tasks in a non-empty workspace")
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "", "Import format (json, csv); detected from the file extension by default")
+	rootCmd.AddCommand(importCmd)
+
+	importTrelloCmd := &cobra.Command{
+		Use:   "trello <board.json>",
+		Short: "Import a board exported from Trello as JSON",
+		Long: `Import a board exported from Trello as JSON (board menu, Print, export and
+share, Export as JSON). Lists become columns and cards tasks, in board order,
+with their descriptions, labels as tags, due dates and checklists. The last list
+is the done column. Archived lists and cards are skipped unless
+--include-archived is given. Attachments, comments and members have no
+counterpart on the board; the summary printed at the end counts them, along
+with everything else that was or wasn't imported.`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{changesTasks: ""},
+		RunE:        runImportTrello,
+	}
+	importTrelloCmd.Flags().BoolVar(&trelloIncludeArchived, "include-archived", false, "Also import archived lists and cards")
+	importCmd.AddCommand(importTrelloCmd)
+
+	importGitHubCmd := &cobra.Command{
+		Use:   "github",
+		Short: "Import the issues of a GitHub repository",
+		Long: `Import the issues of a GitHub repository as tasks: open issues go to the
+first column, closed ones to the done column, and labels become tags. The
+token in GITHUB_TOKEN is used when set, and needed for private repositories;
+GITHUB_API_URL points to a GitHub Enterprise server.
+
+Importing again updates the tasks of issues already imported, matched by issue
+number, instead of adding them twice: the title, description and link are
+replaced, new labels are added, and a task moves only to follow an issue that
+was closed or reopened. Issues are saved a page of 100 at a time, so when a
+request fails, the pages before it stay imported.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{changesTasks: ""},
+		RunE:        runImportGitHub,
+	}
+	importGitHubCmd.Flags().StringVar(&githubRepo, "repo", "", "Repository as owner/name")
+	importGitHubCmd.Flags().StringVar(&githubState, "state", "all", "Issues to import: open, closed or all")
+	importGitHubCmd.Flags().StringSliceVar(&githubLabels, "label", nil, "Only import issues with this label (repeatable; issues need all of them)")
+	_ = importGitHubCmd.MarkFlagRequired("repo")
+	importCmd.AddCommand(importGitHubCmd)
+
+	importTaskwarriorCmd := &cobra.Command{
+		Use:   "taskwarrior <export.json>",
+		Short: "Import tasks exported from taskwarrior",
+		Long: `Import the output of task export, read from a file or from stdin with -:
+pending and waiting tasks go to the first column, completed ones to the done
+column. Tags and the project become tags, priorities H, M and L become high,
+medium and low, the due date is kept as a day, and annotations are added to
+the description, one line each. Deleted tasks and recurrence templates are
+skipped; the pending instances of recurring tasks are imported.
+
+Each task keeps its taskwarrior UUID, so importing again updates the tasks
+already imported instead of adding them twice: the title and description are
+replaced, new tags are added, and a task moves only when it was completed or
+reopened in taskwarrior.`,
+		Example:     "  task export | cli_kanban import taskwarrior - -w work",
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{changesTasks: ""},
+		RunE:        runImportTaskwarrior,
+	}
+	importCmd.AddCommand(importTaskwarriorCmd)
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Keep a workspace in sync with files outside cli_kanban",
+	}
+	rootCmd.AddCommand(syncCmd)
+
+	syncMarkdownCmd := &cobra.Command{
+		Use:   "markdown",
+		Short: "Mirror a workspace as a folder of markdown files, both ways",
+		Long: `Mirror a workspace as a folder of markdown files, such as a folder of an
+Obsidian vault: one file per task, with its id, title, column, labels, due date
+and priority as YAML frontmatter and its description as the body.
+
+Each run syncs both ways. New tasks get a file, and a file without an id
+becomes a new task. Where a file and its task differ, the newer one wins: a
+file modified after the task's last update updates the task, otherwise the
+file is rewritten. Deleting a file archives its task, and the file of a task
+archived or deleted on the board is removed. Other frontmatter keys are kept
+when a file is rewritten. --dry-run prints what would change, with diffs,
+without changing anything.`,
+		Example:     "  cli_kanban sync markdown --dir ~/vault/kanban --workspace work",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{changesTasks: ""},
+		RunE:        runSyncMarkdown,
+	}
+	syncMarkdownCmd.Flags().StringVar(&syncDir, "dir", "", "Folder holding the task files")
+	syncMarkdownCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "Print what would change without changing anything")
+	_ = syncMarkdownCmd.MarkFlagRequired("dir")
+	syncCmd.AddCommand(syncMarkdownCmd)
+
+	syncGitCmd := &cobra.Command{
+		Use:   "git",
+		Short: "Sync a workspace between machines through a git repository",
+		Long: `Sync a workspace between machines through a git repository. Each task is
+a JSON file in a folder named after the workspace, with sorted keys and tags,
+so the same board always gives the same files and a change a small diff.
+Several workspaces can share one repository.
+
+The first sync clones --remote into git/<workspace> in the data directory;
+later ones reuse the clone. Each run commits the changes made on the board,
+fetches the remote, merges its commits task by task, pushes the result and
+applies the changes from the remote to the board:
+
+  - a task changed on one side only takes that change
+  - a task changed on both sides takes each field from the side that changed
+    it; a field changed on both takes the side updated last (updated_at),
+    but a title changed on both keeps both titles, newest first
+  - a task deleted on one side and changed on the other is kept
+  - a task deleted on the remote is archived on the board, and one archived
+    or deleted on the board is removed from the repository
+
+Titles, descriptions, columns, priorities, due dates and tags are synced;
+checklists, links, recurrences, dependencies, epics and time entries stay on
+each machine. A task in a column the other board hasn't got is skipped until
+the column is added there. --status fetches and lists the changes waiting on
+either side without applying anything.`,
+		Example:     "  cli_kanban sync git --remote git@example.com:me/boards.git --workspace work\n  cli_kanban sync git --workspace work --status",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{changesTasks: ""},
+		RunE:        runSyncGit,
+	}
+	syncGitCmd.Flags().StringVar(&syncGitRemote, "remote", "", "Repository to sync with; only needed the first time")
+	syncGitCmd.Flags().BoolVar(&syncGitStatus, "status", false, "List the changes waiting on the board and on the remote without applying them")
+	syncCmd.AddCommand(syncGitCmd)
+
+	renameCmd := &cobra.Command{
+		Use:               "rename <old-name> <new-name>",
+		Short:             "Rename a workspace",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeFirstArg(completeWorkspaces),
+		RunE:              runRename,
+	}
+	rootCmd.AddCommand(renameCmd)
+
+	cloneCmd := &cobra.Command{
+		Use:               "clone <source> <target>",
+		Short:             "Copy a workspace into a new workspace",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeFirstArg(completeWorkspaces),
+		RunE:              runClone,
+	}
+	cloneCmd.Flags().BoolVar(&cloneColumnsOnly, "columns-only", false, "Copy the board structure without any tasks")
+	rootCmd.AddCommand(cloneCmd)
+
+	mergeCmd := &cobra.Command{
+		Use:   "merge <source> <destination>",
+		Short: "Copy all columns and tasks of a workspace into another",
+		Long: `Copy the columns and tasks of the source workspace into the destination.
+Columns are matched by name and created at the right end of the board when
+missing; tasks are appended to their column in their original order, with
+their description, tags, checklist and timestamps. Tags are matched by name,
+so a tag both workspaces use stays a single tag. The destination is written
+in one transaction: if anything fails, nothing is merged.`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeWorkspaces,
+		RunE:              runMerge,
+	}
+	mergeCmd.Flags().StringVar(&mergePrefix, "prefix", "", "Prefix the titles of merged tasks, e.g. \"[home] \"")
+	mergeCmd.Flags().BoolVar(&mergeDeleteSource, "delete-source", false, "Move the source workspace to the trash after merging")
+	rootCmd.AddCommand(mergeCmd)
+
+	restoreWorkspaceCmd := &cobra.Command{
+		Use:   "restore-workspace <name>",
+		Short: "Restore the most recently deleted copy of a workspace",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runRestoreWorkspace,
+	}
+	rootCmd.AddCommand(restoreWorkspaceCmd)
+
+	archiveCmd := &cobra.Command{
+		Use:   "archive",
+		Short: "Pack a whole workspace into one file, to back it up or move it",
+		Long: `Pack a workspace into a gzipped tarball: a consistent copy of its database,
+with its columns, tags, settings, templates and activity log, and a manifest
+holding the schema and app versions and the checksum of every file. An
+encrypted workspace stays encrypted in the archive. unarchive turns the file
+back into a workspace, on this machine or another one.`,
+		Args: cobra.NoArgs,
+		RunE: runArchive,
+	}
+	archiveCmd.Flags().StringVarP(&archiveOutput, "output", "o", "", "Write the archive to this file (default <workspace>"+archive.Extension+")")
+	rootCmd.AddCommand(archiveCmd)
+
+	unarchiveCmd := &cobra.Command{
+		Use:   "unarchive <file>",
+		Short: "Create a workspace from a file written by archive",
+		Long: `Create a workspace from a file written by archive, named as in the archive
+or as --as says. The checksums of the manifest are verified first, and an
+existing workspace is never overwritten. The database is brought to the schema
+version of this binary.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runUnarchive,
+	}
+	unarchiveCmd.Flags().StringVar(&unarchiveAs, "as", "", "Name of the new workspace (default the archived workspace's name)")
+	rootCmd.AddCommand(unarchiveCmd)
+
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt a workspace database with a passphrase",
+		Long: `Encrypt the database of a workspace with a passphrase, asked for on the
+terminal and never taken on the command line. Opening the workspace then asks
+for it, decrypts the database to a private temporary copy and encrypts the copy
+back when cli_kanban exits. Meanwhile other cli_kanban processes can read the
+last saved version but not change it.
+
+With --keychain the passphrase is also saved in the keychain of the system
+(macOS Keychain, or the Secret Service through secret-tool) and isn't asked for
+again. Run it on an encrypted workspace to save its passphrase, or with
+--keychain=false to forget it. To change the passphrase, decrypt the workspace
+and encrypt it again.`,
+		Args: cobra.NoArgs,
+		RunE: runEncrypt,
+	}
+	encryptCmd.Flags().BoolVar(&encryptKeychain, "keychain", false, "Save the passphrase in the OS keychain (use --keychain=false to forget it)")
+	rootCmd.AddCommand(encryptCmd)
+
+	decryptCmd := &cobra.Command{
+		Use:   "decrypt",
+		Short: "Turn an encrypted workspace back into a plain database",
+		Long: `Decrypt the database of an encrypted workspace for good, asking for its
+passphrase, and remove the passphrase from the keychain.`,
+		Args: cobra.NoArgs,
+		RunE: runDecrypt,
+	}
+	rootCmd.AddCommand(decryptCmd)
+
+	wipCmd := &cobra.Command{
+		Use:   "wip [column] [limit]",
+		Short: "Show or set column WIP limits",
+		Long: `Show the WIP limits of a workspace, or set the limit of one column (0 removes it).
+With --strict-wip, moving a task into a full column is blocked instead of only warned about.`,
+		Args:              cobra.RangeArgs(0, 2),
+		ValidArgsFunction: completeFirstArg(completeColumns),
+		RunE:              runWIP,
+	}
+	wipCmd.Flags().BoolVar(&wipStrict, "strict-wip", false, "Block moves into columns at their WIP limit (use --strict-wip=false to only warn)")
+	rootCmd.AddCommand(wipCmd)
+
+	columnsCmd := &cobra.Command{
+		Use:   "columns",
+		Short: "List the columns of a workspace, or mark which ones are done",
+		Long: `List the columns of a workspace in board order with their task count,
+marking the done ones. Moving a task into a done column completes it: it gets
+its completion time, its recurrence comes back, and archive, stats, reminders
+and exports count it as done. A board has at least one done column.`,
+		Args: cobra.NoArgs,
+		RunE: runColumns,
+	}
+	rootCmd.AddCommand(columnsCmd)
+
+	columnsSetDoneCmd := &cobra.Command{
+		Use:               "set-done <column>",
+		Short:             "Mark a column done, completing the tasks in it",
+		Example:           "  cli_kanban columns set-done deployed --workspace work",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeColumns),
+		RunE:              runColumnsDone(true),
+	}
+	columnsCmd.AddCommand(columnsSetDoneCmd)
+
+	columnsUnsetDoneCmd := &cobra.Command{
+		Use:               "unset-done <column>",
+		Short:             "Stop treating a column as done, reopening the tasks in it",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeColumns),
+		RunE:              runColumnsDone(false),
+	}
+	columnsCmd.AddCommand(columnsUnsetDoneCmd)
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show or change the settings stored in a workspace",
+		Long: `Show or change the settings a workspace keeps in its database, such as
+strict_wip or the sort mode of a column (sort:<status>), which the board also
+changes as you use it. config.yaml holds the settings shared by every
+workspace. Keys from a newer cli_kanban are listed and kept as they are.`,
+	}
+	rootCmd.AddCommand(configCmd)
+
+	configListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the settings of a workspace, defaults included",
+		Args:  cobra.NoArgs,
+		RunE:  runConfigList,
+	}
+	configCmd.AddCommand(configListCmd)
+
+	configGetCmd := &cobra.Command{
+		Use:               "get <key>",
+		Short:             "Print a setting of a workspace, or its default",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeFirstArg(completeSettings),
+		RunE:              runConfigGet,
+	}
+	configCmd.AddCommand(configGetCmd)
+
+	configSetCmd := &cobra.Command{
+		Use:               "set <key> <value>",
+		Short:             "Change a setting of a workspace (an empty value resets it)",
+		Example:           "  cli_kanban config set strict_wip true --workspace work\n  cli_kanban config set sort:todo priority",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeFirstArg(completeSettings),
+		RunE:              runConfigSet,
+	}
+	configCmd.AddCommand(configSetCmd)
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Archive done tasks completed a while ago",
+		Long: `Archive the tasks of the done column that were completed before the given age,
+e.g. --older-than 90d. Ages are in hours (h), days (d), weeks (w) or months (m).
+Archived tasks leave the board and can be brought back from the archive view (A).`,
+		Args: cobra.NoArgs,
+		RunE: runPrune,
+	}
+	pruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "90d", "Archive tasks completed longer ago than this (e.g. 48h, 90d, 12w, 6m)")
+	rootCmd.AddCommand(pruneCmd)
+
+	templatesCmd := &cobra.Command{
+		Use:   "templates",
+		Short: "List or delete the task templates of a workspace",
+		Long: `List the task templates of a workspace with their title and placeholders.
+Templates are saved from a task in the TUI (Ctrl+S) and used with add --template
+or from the task input (Ctrl+T).`,
+		Args: cobra.NoArgs,
+		RunE: runTemplates,
+	}
+	templatesCmd.Flags().StringVar(&templatesDelete, "delete", "", "Delete the template with this name")
+	rootCmd.AddCommand(templatesCmd)
+
+	backupsCmd := &cobra.Command{
+		Use:   "backups",
+		Short: "List the backups of a workspace",
+		Long: `List the backups of a workspace, newest first. A backup is made when the TUI
+starts and the last one is older than backups.interval in config.yaml (1d by
+default); the newest backups.keep (10 by default) are kept.`,
+		Args: cobra.NoArgs,
+		RunE: runBackups,
+	}
+	rootCmd.AddCommand(backupsCmd)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a workspace from a backup",
+		Long: `Replace a workspace database with one of its backups, e.g.
+restore --workspace work --from 20250314T093000. The timestamp may be shortened
+as long as it matches a single backup, or be "latest". The current database is
+backed up first, so a restore can itself be undone.`,
+		Args: cobra.NoArgs,
+		RunE: runRestore,
+	}
+	restoreCmd.Flags().StringVar(&restoreFrom, "from", "", "Timestamp of the backup to restore (see the backups command), or latest")
+	_ = restoreCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(restoreCmd)
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check workspace databases for problems",
+		Long: `Check a workspace database (or all of them with --all): SQLite's integrity and
+foreign key checks, tasks in columns that don't exist, labels no task uses and
+tasks sharing a position. The database size and schema version are reported
+too. The database is only read unless --fix is given, which repairs the fixable
+problems: an old schema is migrated, orphaned tasks move to the first column,
+unused labels are deleted and positions are renumbered.
+
+Exits with status 1 when problems remain, so it can be run from cron.`,
+		Args: cobra.NoArgs,
+		RunE: runDoctor,
+	}
+	doctorCmd.Flags().BoolVar(&doctorAll, "all", false, "Check every workspace in the data directory")
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Repair the problems that can be fixed")
+	rootCmd.AddCommand(doctorCmd)
+
+	searchCmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search the tasks of a workspace or of all workspaces",
+		Long: `Search the titles and descriptions of the tasks on the board for every word
+of the query, e.g. search "oauth login" --all-workspaces. Each match is printed
+with its workspace, column and a snippet around the matched text.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSearch,
+	}
+	searchCmd.Flags().BoolVarP(&searchAll, "all-workspaces", "a", false, "Search every workspace in the data directory")
+	searchCmd.Flags().StringVarP(&searchColumn, "column", "c", "", "Only search tasks in this column")
+	searchCmd.Flags().StringVar(&searchLabel, "label", "", "Only search tasks with this tag")
+	searchCmd.Flags().BoolVar(&searchOverdue, "overdue", false, "Only search tasks past their due date")
+	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "Output matches as JSON")
+	rootCmd.AddCommand(searchCmd)
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show task counts, throughput and cycle time",
+		Long: `Show the number of tasks, stale tasks and the oldest task of each column, how
+many tasks were completed each week since --since (30d by default) and the
+average time tasks took from creation to completion. Tasks are stale when they
+went untouched for longer than stale.after in the config (14d by default).
+--oneline prints a compact summary for a status bar such as tmux's.`,
+		Args: cobra.NoArgs,
+		RunE: runStats,
+	}
+	statsCmd.Flags().StringVar(&statsSince, "since", "30d", "Count completions in this period (e.g. 48h, 30d, 12w, 6m)")
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Output the statistics as JSON")
+	statsCmd.Flags().BoolVar(&statsOneLine, "oneline", false, "Print a one-line summary")
+	rootCmd.AddCommand(statsCmd)
+
+	logCmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show the activity log of a workspace",
+		Long: `Show the latest changes to the tasks of a workspace, newest first: every
+create, edit, move, delete, restore and archive. Entries older than
+activity.retention in the config file (180d by default) are pruned.`,
+		Args: cobra.NoArgs,
+		RunE: runLog,
+	}
+	logCmd.Flags().IntVarP(&logLimit, "limit", "n", 50, "Number of entries to show")
+	logCmd.Flags().Int64Var(&logTask, "task", 0, "Only show the entries of this task ID")
+	logCmd.Flags().BoolVar(&logJSON, "json", false, "Output the entries as JSON")
+	_ = logCmd.RegisterFlagCompletionFunc("task", completeTaskIDs)
+	rootCmd.AddCommand(logCmd)
+
+	timesheetCmd := &cobra.Command{
+		Use:   "timesheet",
+		Short: "Show the time logged on tasks",
+		Long: `Show the time logged with the TUI's timer since a day, totalled per task
+and per day. A running timer counts up to now.`,
+		Args: cobra.NoArgs,
+		RunE: runTimesheet,
+	}
+	timesheetCmd.Flags().StringVar(&timesheetSince, "since", "monday", "Start of the period: a weekday (its latest occurrence), today, yesterday, YYYY-MM-DD or an age such as 7d")
+	timesheetCmd.Flags().BoolVar(&timesheetJSON, "json", false, "Output the totals as JSON, in minutes")
+	rootCmd.AddCommand(timesheetCmd)
+
+	remindCmd := &cobra.Command{
+		Use:   "remind",
+		Short: "List the tasks due today or overdue",
+		Long: `List the tasks of a workspace that are due today or overdue, leaving out
+the done column. Exits with status 1 when there are any, so it can be used in
+a shell prompt or a cron job, e.g. cli_kanban remind -q || echo "tasks due".`,
+		Args: cobra.NoArgs,
+		RunE: runRemind,
+	}
+	remindCmd.Flags().BoolVarP(&remindQuiet, "quiet", "q", false, "Print nothing, only set the exit status")
+	rootCmd.AddCommand(remindCmd)
+
+	todayCmd := &cobra.Command{
+		Use:   "today",
+		Short: "List what there is to do today across all workspaces",
+		Long: `List, for every workspace, the tasks that are overdue or due today, those
+back from snooze today and those in progress, in a column between the first
+and the done column. Snoozed tasks are left out until they wake. Short
+enough for a shell's login message, e.g. cli_kanban today in ~/.profile.`,
+		Args: cobra.NoArgs,
+		RunE: runToday,
+	}
+	rootCmd.AddCommand(todayCmd)
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a workspace over a JSON REST API",
+		Long: `Serve a workspace, or with --all every workspace, over a small JSON REST API:
+
+  GET    /api/workspaces
+  GET    /api/columns
+  GET    /api/tasks[?column=todo]
+  POST   /api/tasks                {"title": "...", "column": "todo", ...}
+  GET    /api/tasks/{id}
+  PATCH  /api/tasks/{id}           {"priority": "high", "due": "fri", ...}
+  POST   /api/tasks/{id}/move      {"column": "done"}
+  DELETE /api/tasks/{id}
+
+Paths under /api/workspaces/{name}/ reach another workspace with --all. Tasks
+are created with title, description, column, priority, tags and due, and
+"quick_add": true parses !priority, #tag and @due out of the title. When
+server.token is set in config.yaml, every request needs the header
+"Authorization: Bearer <token>". Changes go through the same database as the
+TUI, so an open board shows them right away. Ctrl+C stops the server once
+the requests in progress are answered.`,
+		Args: cobra.NoArgs,
+		RunE: runServe,
+	}
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:7070", "Address to listen on")
+	serveCmd.Flags().BoolVar(&serveAll, "all", false, "Serve every workspace")
+	rootCmd.AddCommand(serveCmd)
 
 	if err := rootCmd.Execute(); err != nil {
+		if errors.Is(err, errTasksDue) {
+			os.Exit(1)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -52,6 +809,22 @@ func runTUI(cmd *cobra.Command, args []string) error {
 	if listWorkspaces && deleteWorkspace != "" {
 		return errors.New("cannot use --list and --delete together")
 	}
+	if listWSJSON && !listWorkspaces {
+		return errors.New("--json only applies to --list")
+	}
+	if demo {
+		switch {
+		case cmd.Flags().Changed("workspace"):
+			return errors.New("cannot use --demo and --workspace together")
+		case deleteWorkspace != "":
+			return errors.New("cannot use --demo and --delete together")
+		case listWorkspaces:
+			return errors.New("cannot use --demo and --list together")
+		case readOnly:
+			return errors.New("cannot use --demo and --read-only together")
+		}
+		return runDemo()
+	}
 
 	if listWorkspaces {
 		return listWorkspaceDatabases()
@@ -61,187 +834,2933 @@ func runTUI(cmd *cobra.Command, args []string) error {
 		return deleteWorkspaceDatabase(deleteWorkspace)
 	}
 
-	ws := workspace
-	if ws == "" {
-		ws = defaultWorkspace
-	}
-	if !workspaceNameRe.MatchString(ws) {
-		return fmt.Errorf("invalid workspace name %q: must match %s", ws, workspaceNameRe.String())
-	}
-
-	dataDir, err := cliKanbanDataDir()
+	dbPath, err := workspace.Path(workspaceName)
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(dataDir, 0o700); err != nil {
-		return fmt.Errorf("failed to create data directory %q: %w", dataDir, err)
+
+	cfg, cfgPath := loadConfig()
+	opts := loadOptions(cfg, cfgPath)
+	if err := tui.CheckKeys(opts.Keys, opts.Vim); err != nil {
+		return fmt.Errorf("config %s: %w", cfgPath, err)
+	}
+	if noSample {
+		opts.Sample = false
 	}
 
-	// One-time migration: copy old single-db default (~/.cli_kanban.db) into the new default workspace db.
-	if ws == defaultWorkspace {
-		oldPath, err := legacyDefaultDBPath()
-		if err != nil {
-			return err
+	// Initialize database
+	var database *db.DB
+	if readOnly {
+		// Nothing is written, not even the backup and activity upkeep
+		if !fileExists(dbPath) {
+			return fmt.Errorf("%w: %s in %s", errWorkspaceNotFound, workspaceName, filepath.Dir(dbPath))
 		}
-		newPath := filepath.Join(dataDir, dbFilePrefix+defaultWorkspace+".db")
-		if err := migrateLegacyDefaultDB(oldPath, newPath); err != nil {
-			return err
+		opts.ReadOnly = true
+		if database, err = db.NewReadOnly(dbPath); err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
 		}
+	} else {
+		autoBackup(cfg, workspaceName, dbPath)
+		fresh := !fileExists(dbPath)
+		if database, err = db.New(dbPath); err != nil {
+			return fmt.Errorf("failed to initialize database: %w", err)
+		}
+		if fresh && opts.Sample {
+			if err := tui.SeedSample(database, opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: sample board: %s\n", oneLine(err))
+			}
+		}
+		pruneActivity(cfg, workspaceName, database)
 	}
 
-	dbPath := filepath.Join(dataDir, dbFilePrefix+ws+".db")
+	return runBoard(database, workspaceName, cfg, cfgPath, opts)
+}
 
-	// Initialize database
-	database, err := db.New(dbPath)
+// runDemo runs the TUI on the demo board: a database in memory, and an
+// empty data directory that is removed on exit, so switching workspaces
+// doesn't reach the real ones either. The config is still the user's.
+func runDemo() error {
+	cfg, cfgPath := loadConfig()
+	opts := loadOptions(cfg, cfgPath)
+	if err := tui.CheckKeys(opts.Keys, opts.Vim); err != nil {
+		return fmt.Errorf("config %s: %w", cfgPath, err)
+	}
+	// Changes to the demo board aren't news to anyone
+	opts.Webhooks = nil
+
+	dir, err := os.MkdirTemp("", "cli_kanban-demo-")
+	if err != nil {
+		return fmt.Errorf("failed to create the demo data directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	workspace.SetDataDir(dir)
+
+	database, err := db.NewMemory()
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
-	defer database.Close()
+	if err := tui.SeedDemo(database, time.Now()); err != nil {
+		database.Close()
+		return fmt.Errorf("failed to fill the demo board: %w", err)
+	}
+	if err := runBoard(database, tui.DemoWorkspace, cfg, cfgPath, opts); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Demo board closed: every change made to it was discarded.")
+	return nil
+}
 
-	// Create TUI model
-	model := tui.NewModel(database)
+// runBoard runs the TUI on database, the workspace called name, until it quits,
+// then closes the database it ended on
+func runBoard(database *db.DB, name string, cfg config.Config, cfgPath string, opts tui.Options) error {
+	model := tui.NewModel(database, name, loadTheme(cfg, cfgPath), opts)
 
-	// Start TUI
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	options := []tea.ProgramOption{tea.WithAltScreen()}
+	if !noMouse {
+		options = append(options, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(model, options...)
+	// The TUI holds the terminal: passphrases can't be asked for meanwhile
+	setAskPassphrase(false)
+	finalModel, err := p.Run()
+	setAskPassphrase(true)
+	// The TUI may have switched workspaces, so close the database it ended on
+	var closeErr error
+	if m, ok := finalModel.(tui.Model); ok {
+		closeErr = m.Close()
+	} else {
+		closeErr = database.Close()
+	}
+	if closeErr != nil && err == nil {
+		return closeErr
+	}
+	if err != nil {
 		return fmt.Errorf("failed to run TUI: %w", err)
 	}
 
 	return nil
 }
 
-func deleteWorkspaceDatabase(ws string) error {
-	if !workspaceNameRe.MatchString(ws) {
-		return fmt.Errorf("invalid workspace name %q: must match %s", ws, workspaceNameRe.String())
+func runAdd(cmd *cobra.Command, args []string) error {
+	if addStdin && addTemplate != "" {
+		return errors.New("--stdin cannot be combined with --template")
 	}
-
-	dataDir, err := cliKanbanDataDir()
+	if addQuickAdd && !addStdin {
+		return errors.New("--quick-add needs --stdin: a title given as an argument is always parsed")
+	}
+	var parsed *quickadd.Result
+	if len(args) > 0 {
+		result, err := quickadd.Parse(args[0], time.Now())
+		if err != nil {
+			return err
+		}
+		parsed = &result
+	}
+	if _, err := dates.ParseRecurrence(addRepeat); err != nil {
+		return err
+	}
+	vars, err := parseTemplateVars(addVars)
 	if err != nil {
 		return err
 	}
-	dbPath := filepath.Join(dataDir, dbFilePrefix+ws+".db")
-
-	if err := os.Remove(dbPath); err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return fmt.Errorf("workspace %q not found", ws)
+	if len(vars) > 0 && addTemplate == "" {
+		return errors.New("--var needs --template")
+	}
+	var lines []string
+	if addStdin {
+		if lines, err = readLines(cmd.InOrStdin()); err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return errors.New("no tasks to add: standard input has no non-empty line")
 		}
-
-		return fmt.Errorf("failed to delete workspace %q: %w", ws, err)
 	}
 
-	fmt.Printf("Deleted workspace %s\t%s\n", ws, dbPath)
-	return nil
-}
-
-func listWorkspaceDatabases() error {
-	dataDir, err := cliKanbanDataDir()
+	// A dry run never creates the workspace
+	database, err := openWorkspaceDB(workspaceName, addCreateWorkspace && !addDryRun)
+	if errors.Is(err, errWorkspaceNotFound) && !addDryRun {
+		return fmt.Errorf("%w (use --create-workspace to create it)", err)
+	}
 	if err != nil {
 		return err
 	}
+	defer database.Close()
 
-	entries, err := os.ReadDir(dataDir)
+	columns, err := database.GetColumns()
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			fmt.Println("No workspaces found.")
-			return nil
+		return err
+	}
+	col := columns[0]
+	if addColumn != "" {
+		found, ok := model.FindColumn(columns, addColumn)
+		if !ok {
+			return fmt.Errorf("unknown column %q: must be one of %s", addColumn, columnNames(columns))
+		}
+		col = found
+	}
+	if addParent != 0 {
+		epic, err := database.GetTask(addParent)
+		if err != nil {
+			return fmt.Errorf("--parent: %w", err)
+		}
+		if epic.DeletedAt != nil || epic.ArchivedAt != nil {
+			return fmt.Errorf("epic #%d is archived or in the trash", addParent)
 		}
-		return fmt.Errorf("failed to read data directory %q: %w", dataDir, err)
 	}
 
-	workspaces := make([]string, 0, len(entries))
-	pathsByWorkspace := make(map[string]string)
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
+	if addStdin {
+		return addLines(database, lines, col)
+	}
+
+	var draft model.Task
+	if addTemplate != "" {
+		tmpl, err := database.GetTemplate(addTemplate)
+		if err != nil {
+			return err
 		}
-		name := e.Name()
-		if !strings.HasPrefix(name, dbFilePrefix) || !strings.HasSuffix(name, ".db") {
-			continue
+		if draft, err = tmpl.Task(vars, col.Status); err != nil {
+			return fmt.Errorf("%w (set placeholders with --var name=value)", err)
 		}
-		ws := strings.TrimSuffix(strings.TrimPrefix(name, dbFilePrefix), ".db")
-		if ws == "" {
-			continue
+		if parsed != nil {
+			draft = parsed.Apply(draft)
 		}
-		workspaces = append(workspaces, ws)
-		pathsByWorkspace[ws] = filepath.Join(dataDir, name)
+	} else {
+		draft = parsed.Task(col.Status)
 	}
-
-	sort.Strings(workspaces)
-	if len(workspaces) == 0 {
-		fmt.Println("No workspaces found.")
+	draft.Recurrence = addRepeat
+	draft.Parent = addParent
+	if addDryRun {
+		printDraft(draft)
 		return nil
 	}
-	for _, ws := range workspaces {
-		fmt.Printf("%s\t%s\n", ws, pathsByWorkspace[ws])
+	task, err := database.CreateTaskFrom(draft)
+	if err != nil {
+		return err
 	}
+
+	fmt.Println(task.ID)
 	return nil
 }
 
-func cliKanbanDataDir() (string, error) {
-	homeDir, err := os.UserHomeDir()
+// readLines reads the non-empty lines of r, trimmed
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read standard input: %w", err)
+	}
+	return lines, nil
+}
+
+// addLines creates one task per line on top of col, in the order of the
+// lines, and prints how many were created
+func addLines(database *db.DB, lines []string, col model.Column) error {
+	now := time.Now()
+	drafts := make([]model.Task, len(lines))
+	for i, line := range lines {
+		drafts[i] = model.Task{Title: line, Status: col.Status}
+		if addQuickAdd {
+			parsed, err := quickadd.Parse(line, now)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", i+1, err)
+			}
+			drafts[i] = parsed.Task(col.Status)
+		}
+		drafts[i].Recurrence = addRepeat
+		drafts[i].Parent = addParent
+	}
+
+	if addDryRun {
+		for _, draft := range drafts {
+			printDraft(draft)
+		}
+		fmt.Printf("Would create %d tasks in %s\n", len(drafts), col.Name)
+		return nil
+	}
+	created, err := database.CreateTasks(drafts)
 	if err != nil {
-		return "", fmt.Errorf("failed to determine user home directory: %w", err)
+		return err
 	}
+	fmt.Printf("Created %d tasks in %s\n", len(created), col.Name)
+	return nil
+}
 
-	if homeDir == "" {
-		return "", errors.New("failed to determine user home directory")
+// printDraft prints a task add --dry-run would create: its column, title,
+// priority, tags and due date, tab separated like list
+func printDraft(draft model.Task) {
+	due := ""
+	if draft.Due != nil {
+		due = draft.Due.Format(dates.DateFormat)
 	}
+	fmt.Printf("%s\t%s\t%s\t%s\t%s\n", draft.Status, draft.Title, draft.Priority, strings.Join(draft.Tags, ","), due)
+}
 
-	return filepath.Join(homeDir, dataDirName), nil
+// parseTemplateVars parses --var values of the form name=value
+func parseTemplateVars(values []string) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --var %q: use name=value", v)
+		}
+		vars[name] = value
+	}
+	return vars, nil
 }
 
-func legacyDefaultDBPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+func runList(cmd *cobra.Command, args []string) error {
+	database, err := openWorkspaceDB(workspaceName, false)
 	if err != nil {
-		return "", fmt.Errorf("failed to determine user home directory: %w", err)
+		return err
 	}
-	if homeDir == "" {
-		return "", errors.New("failed to determine user home directory")
+	defer database.Close()
+
+	columns, err := database.GetBoard()
+	if err != nil {
+		return err
 	}
-	return filepath.Join(homeDir, ".cli_kanban.db"), nil
-}
 
-func migrateLegacyDefaultDB(oldPath, newPath string) error {
-	if fileExists(newPath) {
-		return nil
+	if listView != "" {
+		view, err := database.GetView(listView)
+		if err != nil {
+			return err
+		}
+		cfg, _ := loadConfig()
+		all := columns
+		columns = tui.ViewBoard(columns, *view, cfg.Stale.After)
+		if listColumn != "" {
+			if _, ok := model.FindColumn(columns, listColumn); !ok {
+				if _, known := model.FindColumn(all, listColumn); known {
+					return fmt.Errorf("view %s hides column %q", view.Name, listColumn)
+				}
+			}
+		}
 	}
-	if !fileExists(oldPath) {
-		return nil
+
+	if listColumn != "" {
+		col, ok := model.FindColumn(columns, listColumn)
+		if !ok {
+			return fmt.Errorf("unknown column %q: must be one of %s", listColumn, columnNames(columns))
+		}
+		columns = []model.Column{col}
 	}
-	return copyFile(oldPath, newPath, 0o600)
-}
 
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
+	if listJSON {
+		tasks := []model.Task{}
+		for _, col := range columns {
+			tasks = append(tasks, col.Tasks...)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tasks)
+	}
+
+	for _, col := range columns {
+		for _, task := range col.Tasks {
+			// "-" keeps four fields, without a trailing tab, for no priority
+			priority := string(task.Priority)
+			if priority == "" {
+				priority = "-"
+			}
+			fmt.Printf("%d\t%s\t%s\t%s\n", task.ID, col.Status, task.Title, priority)
+		}
+	}
+	return nil
 }
 
-func copyFile(src, dst string, mode os.FileMode) error {
-	srcFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open legacy db %q: %w", src, err)
+func runMove(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || id <= 0 {
+		return fmt.Errorf("invalid task id %q: must be a positive integer", args[0])
+	}
+	column := moveColumn
+	if len(args) == 2 {
+		if column != "" {
+			return errors.New("give the column either as an argument or with --column, not both")
+		}
+		column = args[1]
 	}
-	defer srcFile.Close()
 
-	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	database, err := openWorkspaceDB(workspaceName, false)
 	if err != nil {
-		return fmt.Errorf("failed to create db %q: %w", dst, err)
+		return err
 	}
-	defer func() {
-		_ = dstFile.Close()
-	}()
+	defer database.Close()
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		_ = os.Remove(dst)
-		return fmt.Errorf("failed to copy legacy db to %q: %w", dst, err)
+	if moveToWorkspace != "" {
+		return moveToOtherWorkspace(database, id, column)
+	}
+	if column == "" {
+		return errors.New("missing column: use move <task-id> <column>")
 	}
-	if err := dstFile.Sync(); err != nil {
-		_ = os.Remove(dst)
-		return fmt.Errorf("failed to sync db %q: %w", dst, err)
+
+	columns, err := database.GetBoard()
+	if err != nil {
+		return err
 	}
-	if err := dstFile.Close(); err != nil {
-		_ = os.Remove(dst)
-		return fmt.Errorf("failed to close db %q: %w", dst, err)
+	to, ok := model.FindColumn(columns, column)
+	if !ok {
+		return fmt.Errorf("unknown column %q: must be one of %s", column, columnNames(columns))
 	}
-	return nil
+
+	task, err := database.GetTask(id)
+	if err != nil {
+		return err
+	}
+	fromName := string(task.Status)
+	if from, ok := model.FindColumn(columns, string(task.Status)); ok {
+		fromName = from.Name
+	}
+
+	next, err := database.UpdateTaskStatus(task.ID, to.Status)
+	if err != nil {
+		return err
+	}
+	if task.Status != to.Status && to.OverWIPLimit(len(to.Tasks)+1) {
+		fmt.Fprintf(os.Stderr, "Warning: WIP limit exceeded in %s (%d/%d)\n", to.Name, len(to.Tasks)+1, to.WIPLimit)
+	}
+
+	fmt.Printf("%s: %s → %s\n", task.Title, fromName, to.Name)
+	if next != nil {
+		fmt.Printf("Repeats %s: created #%d", next.Recurrence, next.ID)
+		if next.Due != nil {
+			fmt.Printf(", due %s", next.Due.Format(dates.DateFormat))
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// moveToOtherWorkspace moves a task into the --to-workspace workspace
+func moveToOtherWorkspace(database *db.DB, id int64, column string) error {
+	from := workspaceName
+	if from == "" {
+		from = workspace.Default
+	}
+	if moveToWorkspace == from {
+		return fmt.Errorf("task %d is already in workspace %s", id, from)
+	}
+	dst, err := openWorkspaceDB(moveToWorkspace, false)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	columns, err := dst.GetBoard()
+	if err != nil {
+		return err
+	}
+	var status model.TaskStatus
+	if column != "" {
+		to, ok := model.FindColumn(columns, column)
+		if !ok {
+			return fmt.Errorf("unknown column %q in workspace %s: must be one of %s", column, moveToWorkspace, columnNames(columns))
+		}
+		status = to.Status
+	}
+
+	task, err := database.MoveTaskTo(dst, id, status)
+	if err != nil {
+		return err
+	}
+	if to, ok := model.FindColumn(columns, string(task.Status)); ok {
+		if to.OverWIPLimit(len(to.Tasks) + 1) {
+			fmt.Fprintf(os.Stderr, "Warning: WIP limit exceeded in %s (%d/%d)\n", to.Name, len(to.Tasks)+1, to.WIPLimit)
+		}
+		column = to.Name
+	}
+
+	fmt.Printf("%s: %s → %s/%s (now #%d)\n", task.Title, from, moveToWorkspace, column, task.ID)
+	return nil
+}
+
+func runWIP(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		return errors.New("missing limit: use wip <column> <limit>")
+	}
+
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if cmd.Flags().Changed("strict-wip") {
+		if err := database.SetStrictWIP(wipStrict); err != nil {
+			return err
+		}
+	}
+
+	columns, err := database.GetBoard()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 2 {
+		col, ok := model.FindColumn(columns, args[0])
+		if !ok {
+			return fmt.Errorf("unknown column %q: must be one of %s", args[0], columnNames(columns))
+		}
+		limit, err := strconv.Atoi(args[1])
+		if err != nil || limit < 0 {
+			return fmt.Errorf("invalid limit %q: must be a non-negative integer", args[1])
+		}
+		return database.SetWIPLimit(col.Status, limit)
+	}
+	if cmd.Flags().Changed("strict-wip") {
+		return nil
+	}
+
+	strict, err := database.StrictWIP()
+	if err != nil {
+		return err
+	}
+	for _, col := range columns {
+		limit := "-"
+		if col.WIPLimit > 0 {
+			limit = strconv.Itoa(col.WIPLimit)
+		}
+		fmt.Printf("%s\t%d/%s\n", col.Status, len(col.Tasks), limit)
+	}
+	fmt.Printf("strict-wip\t%t\n", strict)
+	return nil
+}
+
+func runColumns(cmd *cobra.Command, args []string) error {
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	columns, err := database.GetBoard()
+	if err != nil {
+		return err
+	}
+	for _, col := range columns {
+		line := fmt.Sprintf("%s\t%s\t%d", col.Status, col.Name, len(col.Tasks))
+		if col.Done {
+			line += "\tdone"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// runColumnsDone returns the command marking a column done, or not
+func runColumnsDone(done bool) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		database, err := openWorkspaceDB(workspaceName, false)
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		columns, err := database.GetColumns()
+		if err != nil {
+			return err
+		}
+		col, ok := model.FindColumn(columns, args[0])
+		if !ok {
+			return fmt.Errorf("unknown column %q: must be one of %s", args[0], columnNames(columns))
+		}
+		return database.SetColumnDone(col.Status, done)
+	}
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	stored, err := database.Settings()
+	if err != nil {
+		return err
+	}
+	values := make(map[string]string, len(stored))
+	for _, s := range stored {
+		values[s.Key] = s.Value
+	}
+	for _, info := range db.KnownSettings() {
+		if info.Column {
+			continue
+		}
+		value, ok := values[info.Key]
+		if !ok {
+			value = info.Default
+		}
+		fmt.Printf("%s\t%s\n", info.Key, value)
+	}
+	// Column settings and the keys of newer versions, as stored
+	for _, s := range stored {
+		info, _, known := db.LookupSetting(s.Key)
+		switch {
+		case s.Key == db.SettingBoardID:
+			// Kept by cli_kanban itself
+		case !known:
+			fmt.Printf("%s\t%s\t(unknown to this version, kept)\n", s.Key, s.Value)
+		case info.Column:
+			fmt.Printf("%s\t%s\n", s.Key, s.Value)
+		}
+	}
+	return nil
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	value, ok, err := database.GetSetting(args[0])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		info, _, known := db.LookupSetting(args[0])
+		if !known {
+			return fmt.Errorf("unknown setting %q", args[0])
+		}
+		value = info.Default
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if err := database.ConfigureSetting(args[0], args[1]); err != nil {
+		if _, _, known := db.LookupSetting(args[0]); !known {
+			return fmt.Errorf("%w: must be one of %s", err, settingKeys())
+		}
+		return err
+	}
+	return nil
+}
+
+// settingKeys lists the keys config set takes, for error messages
+func settingKeys() string {
+	var keys []string
+	for _, info := range db.KnownSettings() {
+		if info.Column {
+			keys = append(keys, info.Key+"<status>")
+		} else {
+			keys = append(keys, info.Key)
+		}
+	}
+	return strings.Join(keys, ", ")
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	before, err := dates.Ago(pruneOlderThan, time.Now())
+	if err != nil {
+		return err
+	}
+
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	count, err := database.ArchiveDone(before)
+	if err != nil {
+		return err
+	}
+	// The cutoff is an instant, e.g. 48h ago, so its time is printed too
+	fmt.Printf("Archived %d tasks completed before %s\n", count, before.Format(dates.DateFormat+" 15:04"))
+	return nil
+}
+
+func runTemplates(cmd *cobra.Command, args []string) error {
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if templatesDelete != "" {
+		if err := database.DeleteTemplate(templatesDelete); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted template %s\n", templatesDelete)
+		return nil
+	}
+
+	templates, err := database.GetTemplates()
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		fmt.Println("No templates. Save one from a task in the TUI with Ctrl+S.")
+		return nil
+	}
+	for _, t := range templates {
+		line := fmt.Sprintf("%s\t%s", t.Name, t.Title)
+		if vars := t.Vars(); len(vars) > 0 {
+			line += "\tvars: " + strings.Join(vars, ", ")
+		}
+		if len(t.Subtasks) > 0 {
+			line += fmt.Sprintf("\t%d checklist item(s)", len(t.Subtasks))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func runBackups(cmd *cobra.Command, args []string) error {
+	if err := workspace.Validate(workspaceName); err != nil {
+		return err
+	}
+	backups, err := backup.List(workspaceName)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		dir, err := backup.Dir(workspaceName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("No backups of workspace %s in %s.\n", workspaceName, dir)
+		return nil
+	}
+	for _, b := range backups {
+		fmt.Printf("%s\t%s\t%s\n", b.Name, b.Time.Format("2006-01-02 15:04:05"), b.Path)
+	}
+	return nil
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	dbPath, err := workspace.Path(workspaceName)
+	if err != nil {
+		return err
+	}
+	b, err := backup.Find(workspaceName, restoreFrom)
+	if err != nil {
+		return err
+	}
+
+	current, err := backup.Restore(workspaceName, dbPath, b)
+	if current != nil {
+		fmt.Printf("Backed up current database\t%s\n", current.Path)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored workspace %s from %s\t%s\n", workspaceName, b.Name, dbPath)
+	return nil
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	var workspaces []workspace.Workspace
+	if doctorAll {
+		all, err := workspace.List()
+		if err != nil {
+			return err
+		}
+		workspaces = all
+	} else {
+		dbPath, err := workspace.Path(workspaceName)
+		if err != nil {
+			return err
+		}
+		if !fileExists(dbPath) {
+			return fmt.Errorf("%w: %s in %s", errWorkspaceNotFound, workspaceName, filepath.Dir(dbPath))
+		}
+		workspaces = []workspace.Workspace{{Name: workspaceName, Path: dbPath}}
+	}
+
+	failed := 0
+	for i, ws := range workspaces {
+		if i > 0 {
+			fmt.Println()
+		}
+		ok, err := doctorWorkspace(ws)
+		if err != nil {
+			fmt.Printf("  error\t%v\n", err)
+		}
+		if !ok || err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("found problems in %d of %d workspace(s)", failed, len(workspaces))
+	}
+	return nil
+}
+
+// doctorWorkspace prints the check report of a workspace, repairing it first
+// with --fix, and reports whether no problems remain
+func doctorWorkspace(ws workspace.Workspace) (bool, error) {
+	fmt.Printf("%s\t%s\n", ws.Name, ws.Path)
+	if info, err := os.Stat(ws.Path); err == nil {
+		fmt.Printf("  size\t%s\n", formatBytes(info.Size()))
+	}
+
+	// The database is checked as it is: opening it for writing would
+	// migrate it and empty its trash first
+	database, err := db.Inspect(ws.Path)
+	if err != nil {
+		return false, err
+	}
+	report, err := database.Check()
+	database.Close()
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("  schema\t%d\n", report.SchemaVersion)
+
+	if doctorFix && hasFixable(report.Problems) {
+		if report, err = repairWorkspace(ws.Path, report.SchemaVersion); err != nil {
+			return false, err
+		}
+	}
+
+	if len(report.Problems) == 0 {
+		fmt.Println("  ok")
+		return true, nil
+	}
+	for _, p := range report.Problems {
+		hint := ""
+		if p.Fixable {
+			hint = " (fixable with --fix)"
+		}
+		fmt.Printf("  %s\t%s%s\n", p.Check, p.Detail, hint)
+	}
+	return false, nil
+}
+
+// repairWorkspace opens a workspace database for writing, which migrates it
+// from schema to the current one, repairs what can be and checks it again
+func repairWorkspace(path string, schema int) (db.Report, error) {
+	database, err := db.New(path)
+	if err != nil {
+		return db.Report{}, err
+	}
+	defer database.Close()
+	if schema != db.SchemaVersion() {
+		fmt.Printf("  migrated\tto schema %d\n", db.SchemaVersion())
+	}
+	changed, err := database.Repair()
+	if err != nil {
+		return db.Report{}, err
+	}
+	fmt.Printf("  fixed\t%d row(s)\n", changed)
+	return database.Check()
+}
+
+// hasFixable reports whether any of the problems can be repaired
+func hasFixable(problems []db.Problem) bool {
+	for _, p := range problems {
+		if p.Fixable {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBytes formats a file size with a binary unit, e.g. 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// searchMatch is a task found by the search command
+type searchMatch struct {
+	Workspace string `json:"workspace"`
+	Column    string `json:"column"`
+	Snippet   string `json:"snippet"`
+	model.Task
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	names := []string{workspaceName}
+	if searchAll {
+		all, err := workspace.List()
+		if err != nil {
+			return err
+		}
+		names = names[:0]
+		for _, ws := range all {
+			names = append(names, ws.Name)
+		}
+	}
+
+	matches := []searchMatch{}
+	for _, name := range names {
+		found, err := searchWorkspace(name, args[0])
+		if err != nil {
+			return fmt.Errorf("workspace %s: %w", name, err)
+		}
+		matches = append(matches, found...)
+	}
+
+	if searchJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(matches)
+	}
+	words := strings.Fields(args[0])
+	bold := lipgloss.NewStyle().Bold(true)
+	for _, m := range matches {
+		fmt.Printf("%s\t%s\t%d\t%s\t%s\n", m.Workspace, m.Column, m.ID, highlight(m.Title, words, bold), highlight(m.Snippet, words, bold))
+	}
+	if len(matches) == 0 {
+		fmt.Fprintln(os.Stderr, "No matching tasks.")
+	}
+	return nil
+}
+
+// searchWorkspace runs the search command's query on one workspace. With
+// --all-workspaces, workspaces without the --column filter's column are skipped.
+func searchWorkspace(name, query string) ([]searchMatch, error) {
+	database, err := openWorkspaceDB(name, false)
+	if err != nil {
+		return nil, err
+	}
+	defer database.Close()
+
+	columns, err := database.GetColumns()
+	if err != nil {
+		return nil, err
+	}
+	opts := db.SearchOptions{Label: searchLabel, Overdue: searchOverdue}
+	if searchColumn != "" {
+		col, ok := model.FindColumn(columns, searchColumn)
+		if !ok {
+			if searchAll {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown column %q: must be one of %s", searchColumn, columnNames(columns))
+		}
+		opts.Column = col.Status
+	}
+
+	tasks, err := database.Search(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]searchMatch, 0, len(tasks))
+	for _, task := range tasks {
+		column := string(task.Status)
+		if col, ok := model.FindColumn(columns, column); ok {
+			column = col.Name
+		}
+		matches = append(matches, searchMatch{
+			Workspace: name,
+			Column:    column,
+			Snippet:   snippet(task.Description, strings.Fields(query)),
+			Task:      task,
+		})
+	}
+	return matches, nil
+}
+
+// snippetRadius is how many characters of context a snippet keeps on each
+// side of the first match
+const snippetRadius = 30
+
+// snippet returns the line of text around the first of the words it
+// contains, shortened to snippetRadius characters on each side, or "" when
+// it contains none of them
+func snippet(text string, words []string) string {
+	start := -1
+	for i := range text {
+		if matchLen(text[i:], words) > 0 {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+
+	// Stay on the matched line, then trim it to the radius
+	from := strings.LastIndexByte(text[:start], '\n') + 1
+	to := len(text)
+	if i := strings.IndexByte(text[start:], '\n'); i >= 0 {
+		to = start + i
+	}
+	line := []rune(text[from:to])
+	at := utf8.RuneCountInString(text[from:start])
+	prefix, suffix := "", ""
+	if at > snippetRadius {
+		line, at, prefix = line[at-snippetRadius:], snippetRadius, "…"
+	}
+	if len(line) > at+2*snippetRadius {
+		line, suffix = line[:at+2*snippetRadius], "…"
+	}
+	return prefix + strings.TrimSpace(string(line)) + suffix
+}
+
+// highlight renders every case-insensitive occurrence of the words in text
+// with style. The style is plain when stdout is not a terminal.
+func highlight(text string, words []string, style lipgloss.Style) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		if n := matchLen(text[i:], words); n > 0 {
+			b.WriteString(style.Render(text[i : i+n]))
+			i += n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		b.WriteString(text[i : i+size])
+		i += size
+	}
+	return b.String()
+}
+
+// matchLen returns the length in bytes of the longest of the words that s
+// starts with, ignoring case, or 0 when it starts with none
+func matchLen(s string, words []string) int {
+	longest := 0
+	for _, w := range words {
+		i := 0
+		for _, wr := range w {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if size == 0 || unicode.ToLower(r) != unicode.ToLower(wr) {
+				i = 0
+				break
+			}
+			i += size
+		}
+		if i > longest {
+			longest = i
+		}
+	}
+	return longest
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	since, err := dates.Ago(statsSince, now)
+	if err != nil {
+		return err
+	}
+
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	cfg, path := loadConfig()
+	stats, err := database.Stats(since, staleBefore(cfg, path, now), now)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case statsJSON:
+		return printStatsJSON(stats)
+
+	case statsOneLine:
+		parts := make([]string, 0, len(stats.Columns))
+		for _, col := range stats.Columns {
+			parts = append(parts, fmt.Sprintf("%s %d", col.Name, col.Count))
+		}
+		line := strings.Join(parts, " · ") + fmt.Sprintf(" | %d done/%s", stats.Completed, statsSince)
+		if stats.Completed > 0 {
+			line += " | cycle " + dates.Duration(stats.CycleTime)
+		}
+		stale := 0
+		for _, col := range stats.Columns {
+			stale += col.Stale
+		}
+		if stale > 0 {
+			line += fmt.Sprintf(" | %d stale", stale)
+		}
+		fmt.Println(line)
+		return nil
+	}
+
+	fmt.Println("Columns")
+	for _, col := range stats.Columns {
+		oldest := ""
+		if col.Oldest != nil {
+			oldest = fmt.Sprintf("\toldest: #%d %s (%s)", col.Oldest.ID, col.Oldest.Title, dates.Relative(col.Oldest.CreatedAt, now))
+		}
+		stale := ""
+		if col.Stale > 0 {
+			stale = fmt.Sprintf("\tstale: %d", col.Stale)
+		}
+		fmt.Printf("  %s\t%d%s%s\n", col.Name, col.Count, stale, oldest)
+	}
+	fmt.Printf("\nCompleted since %s: %d\n", since.Format(dates.DateFormat), stats.Completed)
+	for _, week := range stats.Weeks {
+		fmt.Printf("  week of %s\t%d\n", week.Start.Format(dates.DateFormat), week.Completed)
+	}
+	if stats.Completed > 0 {
+		fmt.Printf("\nAverage cycle time: %s\n", dates.Duration(stats.CycleTime))
+	}
+	return nil
+}
+
+// printStatsJSON writes the stats command's output as JSON
+func printStatsJSON(stats db.Stats) error {
+	type column struct {
+		Name   string      `json:"name"`
+		Status string      `json:"status"`
+		Count  int         `json:"count"`
+		Stale  int         `json:"stale"`
+		Oldest *model.Task `json:"oldest,omitempty"`
+	}
+	type week struct {
+		Start     string `json:"start"`
+		Completed int    `json:"completed"`
+	}
+	out := struct {
+		Since          time.Time `json:"since"`
+		Columns        []column  `json:"columns"`
+		Weeks          []week    `json:"weeks"`
+		Completed      int       `json:"completed"`
+		CycleTimeHours float64   `json:"cycle_time_hours"`
+	}{
+		Since:          stats.Since,
+		Columns:        []column{},
+		Weeks:          []week{},
+		Completed:      stats.Completed,
+		CycleTimeHours: stats.CycleTime.Hours(),
+	}
+	for _, col := range stats.Columns {
+		out.Columns = append(out.Columns, column{col.Name, string(col.Status), col.Count, col.Stale, col.Oldest})
+	}
+	for _, w := range stats.Weeks {
+		out.Weeks = append(out.Weeks, week{w.Start.Format(dates.DateFormat), w.Completed})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func runLog(cmd *cobra.Command, args []string) error {
+	if logLimit <= 0 {
+		return fmt.Errorf("--limit must be positive, got %d", logLimit)
+	}
+
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	cfg, _ := loadConfig()
+	pruneActivity(cfg, workspaceName, database)
+
+	var entries []db.Activity
+	if logTask != 0 {
+		entries, err = database.GetTaskActivity(logTask)
+		if len(entries) > logLimit {
+			entries = entries[:logLimit]
+		}
+	} else {
+		entries, err = database.GetActivity(logLimit)
+	}
+	if err != nil {
+		return err
+	}
+
+	if logJSON {
+		if entries == nil {
+			entries = []db.Activity{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No activity")
+		return nil
+	}
+	columns, err := database.GetColumns()
+	if err != nil {
+		return err
+	}
+	for _, a := range entries {
+		fmt.Printf("%s  #%d %s: %s\n", a.At.Local().Format(dates.DateFormat+" 15:04"), a.TaskID, a.Title(), a.Describe(columns))
+	}
+	return nil
+}
+
+// runServe serves the API until interrupted, then shuts down cleanly
+func runServe(cmd *cobra.Command, args []string) error {
+	ws := workspaceName
+	if ws == "" {
+		ws = workspace.Default
+	}
+	// Open the workspace now, asking for its passphrase when it is
+	// encrypted, rather than on the first request. With --all it needn't
+	// exist.
+	path, err := workspace.Path(ws)
+	if err != nil {
+		return err
+	}
+	var database *db.DB
+	if !serveAll || fileExists(path) {
+		if database, err = openWorkspaceDB(ws, false); err != nil {
+			return err
+		}
+	}
+	cfg, _ := loadConfig()
+
+	api := server.New(server.Options{
+		Workspace: ws,
+		Database:  database,
+		All:       serveAll,
+		Token:     cfg.Server.Token,
+		Webhooks:  cfg.Webhooks,
+		Warnings:  os.Stderr,
+	})
+	defer func() {
+		// Encrypted workspaces are saved as they close
+		if err := api.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", oneLine(err))
+		}
+	}()
+	srv := &http.Server{Addr: serveAddr, Handler: api, ReadHeaderTimeout: 10 * time.Second}
+	listener, err := net.Listen("tcp", serveAddr)
+	if err != nil {
+		return err
+	}
+
+	served := "workspace " + ws
+	if serveAll {
+		served = "all workspaces"
+	}
+	fmt.Printf("Serving %s on http://%s/api (Ctrl+C to stop)\n", served, listener.Addr())
+	if cfg.Server.Token == "" {
+		if host, _, _ := net.SplitHostPort(serveAddr); host != "127.0.0.1" && host != "localhost" && host != "::1" {
+			fmt.Fprintln(os.Stderr, "Warning: no server.token is set in config.yaml, so anyone who can reach this address can change the board")
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(listener) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	fmt.Println("Shutting down")
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdown)
+}
+
+// changesTasks annotates the commands that create, move or delete tasks of
+// the workspace given with --workspace, whose webhooks they post to at exit
+const changesTasks = "changes-tasks"
+
+// webhookTimeout bounds the time a command waits for its webhooks at exit
+const webhookTimeout = 3 * time.Second
+
+// deliverWebhooks posts the changes made to a workspace to its webhooks
+// before the command exits, once and without retrying, warning about the
+// URLs that failed. What they missed waits for the next command or the TUI.
+// The config was warned about already, if anything was wrong with it.
+func deliverWebhooks(ws string) {
+	path, err := config.Path()
+	if err != nil {
+		return
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return
+	}
+	urls := webhook.URLs(cfg.Webhooks, ws)
+	if len(urls) == 0 {
+		return
+	}
+	database, err := openWorkspaceDB(ws, false)
+	if err != nil {
+		return
+	}
+	defer database.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	result, err := webhook.DeliverOnce(ctx, database, ws, urls)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: webhooks: %s\n", oneLine(err))
+	}
+	for _, u := range urls {
+		if err, failed := result.Failed[u]; failed {
+			fmt.Fprintf(os.Stderr, "Warning: webhook failed: %s\n", oneLine(err))
+		}
+	}
+}
+
+func runRemind(cmd *cobra.Command, args []string) error {
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	now := time.Now()
+	tasks, err := database.DueTasks(now)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		if !remindQuiet {
+			fmt.Println("Nothing due today.")
+		}
+		return nil
+	}
+	if remindQuiet {
+		return errTasksDue
+	}
+
+	today := dates.StartOfDay(now)
+	heading := ""
+	for _, task := range tasks {
+		day := dates.Day(*task.Due, now.Location())
+		section := "Due today"
+		if day.Before(today) {
+			section = "Overdue"
+		}
+		if section != heading {
+			if heading != "" {
+				fmt.Println()
+			}
+			fmt.Println(section)
+			heading = section
+		}
+		fmt.Printf("  %s\t#%d %s\n", day.Format("Mon "+dates.DateFormat), task.ID, task.Title)
+	}
+	return errTasksDue
+}
+
+func runToday(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	sections, err := agenda.Load(now)
+	if err != nil {
+		return err
+	}
+	if len(sections) == 0 {
+		fmt.Println("Nothing to do today.")
+		return nil
+	}
+
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(section.Workspace)
+		if section.Err != nil {
+			fmt.Printf("  error: %s\n", oneLine(section.Err))
+			continue
+		}
+		for _, item := range section.Items {
+			when := item.Column
+			if item.Kind == agenda.Overdue || item.Kind == agenda.Today {
+				when = item.Task.Due.Format("Mon " + dates.DateFormat)
+			}
+			fmt.Printf("  %-7s  %-14s  #%d %s\n", item.Kind, when, item.Task.ID, item.Task.Title)
+		}
+	}
+	return nil
+}
+
+func runTimesheet(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+	since, err := dates.Since(timesheetSince, now)
+	if err != nil {
+		return err
+	}
+
+	database, err := openWorkspaceDB(workspaceName, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	sheet, err := database.GetTimesheet(since, now)
+	if err != nil {
+		return err
+	}
+
+	if timesheetJSON {
+		type task struct {
+			ID      int64  `json:"id"`
+			Title   string `json:"title"`
+			Minutes int    `json:"minutes"`
+		}
+		type day struct {
+			Day     string `json:"day"`
+			Minutes int    `json:"minutes"`
+		}
+		minutes := func(d time.Duration) int { return int(d.Round(time.Minute) / time.Minute) }
+		out := struct {
+			Since   string `json:"since"`
+			Tasks   []task `json:"tasks"`
+			Days    []day  `json:"days"`
+			Minutes int    `json:"minutes"`
+		}{Since: since.Format(dates.DateFormat), Tasks: []task{}, Days: []day{}, Minutes: minutes(sheet.Total)}
+		for _, t := range sheet.Tasks {
+			out.Tasks = append(out.Tasks, task{t.ID, t.Title, minutes(t.Logged)})
+		}
+		for _, d := range sheet.Days {
+			out.Days = append(out.Days, day{d.Day.Format(dates.DateFormat), minutes(d.Logged)})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(sheet.Tasks) == 0 {
+		fmt.Printf("No time logged since %s\n", since.Format("Mon "+dates.DateFormat))
+		return nil
+	}
+	fmt.Printf("Time logged since %s\n\n", since.Format("Mon "+dates.DateFormat))
+	fmt.Println("Tasks")
+	for _, t := range sheet.Tasks {
+		fmt.Printf("  %s\t#%d %s\n", dates.HoursMinutes(t.Logged), t.ID, t.Title)
+	}
+	fmt.Println("\nDays")
+	for _, d := range sheet.Days {
+		fmt.Printf("  %s\t%s\n", d.Day.Format("Mon "+dates.DateFormat), dates.HoursMinutes(d.Logged))
+	}
+	fmt.Printf("\nTotal: %s\n", dates.HoursMinutes(sheet.Total))
+	return nil
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	var write func(io.Writer, export.Document) error
+	switch exportFormat {
+	case "json":
+		write = export.WriteJSON
+	case "markdown", "md":
+		write = export.WriteMarkdown
+	case "csv":
+		write = export.WriteCSV
+	case "org":
+		write = export.WriteOrg
+	case "ics":
+		component := export.ICSComponent(strings.ToUpper(exportICSComponent))
+		if component != export.ICSTodo && component != export.ICSEvent {
+			return fmt.Errorf("invalid --ics-component %q: use vtodo or vevent", exportICSComponent)
+		}
+		write = func(w io.Writer, doc export.Document) error {
+			return export.WriteICS(w, doc, component)
+		}
+	case "taskwarrior":
+		write = export.WriteTaskwarrior
+	default:
+		return fmt.Errorf("unsupported export format %q: must be json, markdown, csv, org, ics or taskwarrior", exportFormat)
+	}
+
+	ws := workspaceName
+	if ws == "" {
+		ws = workspace.Default
+	}
+	database, err := openWorkspaceDB(ws, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	columns, err := database.GetBoard()
+	if err != nil {
+		return err
+	}
+	doc := export.NewDocument(ws, columns, time.Now())
+
+	if exportOutput == "" {
+		return write(os.Stdout, doc)
+	}
+
+	f, err := os.Create(exportOutput)
+	if err != nil {
+		return fmt.Errorf("failed to create %q: %w", exportOutput, err)
+	}
+	if err := write(f, doc); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %q: %w", exportOutput, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %q: %w", exportOutput, err)
+	}
+	return nil
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	format := importFormat
+	if format == "" {
+		format = "json"
+		if strings.EqualFold(filepath.Ext(args[0]), ".csv") {
+			format = "csv"
+		}
+	}
+	var read func(io.Reader) (*export.Document, error)
+	switch format {
+	case "json":
+		read = export.ReadJSON
+	case "csv":
+		read = export.ReadCSV
+	default:
+		return fmt.Errorf("unsupported import format %q: must be json or csv", format)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", args[0], err)
+	}
+	doc, err := read(f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+
+	imported, err := importDocument(doc)
+	if err != nil {
+		return err
+	}
+
+	// The columns the tasks went into, leaving out the empty ones
+	filled := 0
+	for _, col := range doc.Columns {
+		if len(col.Tasks) > 0 {
+			filled++
+		}
+	}
+	fmt.Printf("Imported %s into %s\n", countOf(imported, "task"), countOf(filled, "column"))
+	return nil
+}
+
+// importDocument imports the columns and tasks of doc into the workspace,
+// which is created if needed, following --merge and --overwrite. It returns
+// the number of tasks imported.
+func importDocument(doc *export.Document) (int, error) {
+	if importMerge && importOverwrite {
+		return 0, errors.New("cannot use --merge and --overwrite together")
+	}
+	columns := make([]model.Column, len(doc.Columns))
+	for i, col := range doc.Columns {
+		columns[i] = model.Column{Name: col.Name, Status: col.Status, Done: col.Done, Tasks: col.Tasks}
+	}
+
+	dbPath, err := workspace.Path(workspaceName)
+	if err != nil {
+		return 0, err
+	}
+	created := !fileExists(dbPath)
+
+	database, err := openWorkspaceDB(workspaceName, true)
+	if err != nil {
+		return 0, err
+	}
+	defer database.Close()
+
+	count, err := database.CountTasks()
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 && !importMerge && !importOverwrite {
+		return 0, fmt.Errorf("workspace already contains %d tasks (use --merge or --overwrite)", count)
+	}
+
+	if importMerge {
+		// Merged columns the board doesn't have yet are added at its right end
+		boardColumns, err := database.GetColumns()
+		if err != nil {
+			return 0, err
+		}
+		for _, col := range columns {
+			if col.Status == "" && strings.TrimSpace(col.Name) == "" {
+				continue // goes to the first column
+			}
+			if _, ok := model.FindColumn(boardColumns, string(col.Status)); ok {
+				continue
+			}
+			if _, ok := model.FindColumn(boardColumns, col.Name); !ok {
+				fmt.Fprintf(os.Stderr, "Creating column %q\n", col.Name)
+			}
+		}
+	}
+
+	imported, err := database.ImportTasks(columns, !importMerge)
+	if err != nil {
+		if created {
+			_ = database.Close()
+			_ = os.Remove(dbPath)
+		}
+		return 0, err
+	}
+	return imported, nil
+}
+
+// runImportTrello imports a Trello board export and prints what it mapped
+func runImportTrello(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", args[0], err)
+	}
+	doc, summary, err := export.ReadTrello(f, trelloIncludeArchived)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	if _, err := importDocument(doc); err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported Trello board %q:\n", doc.Workspace)
+	fmt.Printf("  %s as columns\n", countOf(summary.Lists, "list"))
+	fmt.Printf("  %s as tasks\n", countOf(summary.Cards, "card"))
+	fmt.Printf("  %s as tags\n", countOf(summary.Labels, "label"))
+	fmt.Printf("  %s\n", countOf(summary.ChecklistItems, "checklist item"))
+	fmt.Printf("  %s\n", countOf(summary.DueDates, "due date"))
+	var skipped []string
+	if summary.ArchivedLists > 0 {
+		skipped = append(skipped, countOf(summary.ArchivedLists, "archived list"))
+	}
+	if summary.ArchivedCards > 0 {
+		skipped = append(skipped, countOf(summary.ArchivedCards, "archived card"))
+	}
+	if summary.Attachments > 0 {
+		skipped = append(skipped, countOf(summary.Attachments, "attachment"))
+	}
+	if summary.Comments > 0 {
+		skipped = append(skipped, countOf(summary.Comments, "comment"))
+	}
+	if summary.Members > 0 {
+		skipped = append(skipped, countOf(summary.Members, "card member"))
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("Not imported: %s\n", strings.Join(skipped, ", "))
+		if summary.ArchivedLists+summary.ArchivedCards > 0 && !trelloIncludeArchived {
+			fmt.Println("(use --include-archived to import archived lists and cards)")
+		}
+	}
+	return nil
+}
+
+// countOf returns n followed by noun, with an s unless n is 1
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// runSyncMarkdown syncs a workspace with a folder of markdown files
+func runSyncMarkdown(cmd *cobra.Command, args []string) error {
+	ws := workspaceName
+	if ws == "" {
+		ws = workspace.Default
+	}
+	dir := syncDir
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(home, rest)
+	}
+
+	database, err := openWorkspaceDB(ws, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+	result, err := mdsync.Sync(database, mdsync.Options{Dir: dir, Workspace: ws, DryRun: syncDryRun, Out: os.Stdout})
+	if err != nil {
+		return err
+	}
+
+	changes := []string{
+		countOf(result.Written, "file") + " written",
+		countOf(result.Updated, "task") + " updated",
+		fmt.Sprintf("%d created", result.Created),
+		fmt.Sprintf("%d archived", result.Archived),
+		countOf(result.Removed, "file") + " removed",
+	}
+	if syncDryRun {
+		fmt.Printf("Dry run, nothing changed: %s\n", strings.Join(changes, ", "))
+	} else {
+		fmt.Printf("Synced %s with %s: %s\n", ws, dir, strings.Join(changes, ", "))
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%s could not be synced", countOf(result.Failed, "file"))
+	}
+	return nil
+}
+
+// runSyncGit syncs a workspace with a git repository
+func runSyncGit(cmd *cobra.Command, args []string) error {
+	ws := workspaceName
+	if ws == "" {
+		ws = workspace.Default
+	}
+	dbPath, err := existingWorkspacePath(ws)
+	if err != nil {
+		return err
+	}
+	if encrypted, err := crypt.IsEncrypted(dbPath); err != nil || encrypted {
+		if err == nil {
+			err = fmt.Errorf("workspace %s is encrypted: syncing it would put its tasks in the repository unencrypted", ws)
+		}
+		return err
+	}
+	dataDir, err := workspace.DataDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(dataDir, "git", ws)
+
+	database, err := openWorkspaceDB(ws, false)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+	result, err := gitsync.Sync(database, gitsync.Options{Dir: dir, Remote: syncGitRemote, Workspace: ws, Status: syncGitStatus, Out: os.Stdout})
+	if err != nil {
+		return err
+	}
+
+	if syncGitStatus {
+		fmt.Printf("%s to push, %s to pull\n", countOf(result.Local, "change"), countOf(result.Remote, "change"))
+	} else {
+		changes := []string{
+			countOf(result.Local, "local change"),
+			countOf(result.Remote, "remote change"),
+			fmt.Sprintf("%d merged", result.Merged),
+			fmt.Sprintf("%d created", result.Created),
+			fmt.Sprintf("%d updated", result.Updated),
+			fmt.Sprintf("%d archived", result.Archived),
+		}
+		pushed := "nothing to push"
+		if result.Pushed {
+			pushed = "pushed"
+		}
+		fmt.Printf("Synced %s with git: %s; %s\n", ws, strings.Join(changes, ", "), pushed)
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%s could not be synced", countOf(result.Failed, "task"))
+	}
+	return nil
+}
+
+// runImportTaskwarrior imports the output of task export, matching the tasks
+// imported before by UUID
+func runImportTaskwarrior(cmd *cobra.Command, args []string) error {
+	in := io.Reader(os.Stdin)
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open %q: %w", args[0], err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	database, err := openWorkspaceDB(workspaceName, true)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+	columns, err := database.GetColumns()
+	if err != nil {
+		return err
+	}
+	drafts, summary, err := export.ReadTaskwarrior(in, columns[0].Status, columns[model.DoneColumn(columns)].Status)
+	if err != nil {
+		return err
+	}
+	result, err := database.SyncTasks(drafts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %s from taskwarrior (%d new, %d updated)\n", countOf(summary.Tasks, "task"), result.Created, result.Updated)
+	var skipped []string
+	if summary.Deleted > 0 {
+		skipped = append(skipped, countOf(summary.Deleted, "deleted task"))
+	}
+	if summary.Recurring > 0 {
+		skipped = append(skipped, countOf(summary.Recurring, "recurrence template"))
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped %s\n", strings.Join(skipped, ", "))
+	}
+	return nil
+}
+
+// runImportGitHub imports the issues of a GitHub repository, page by page
+func runImportGitHub(cmd *cobra.Command, args []string) error {
+	if err := github.ValidateRepo(githubRepo); err != nil {
+		return err
+	}
+	state := strings.ToLower(githubState)
+	if state != "open" && state != "closed" && state != "all" {
+		return fmt.Errorf("invalid --state %q: use open, closed or all", githubState)
+	}
+
+	database, err := openWorkspaceDB(workspaceName, true)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+	columns, err := database.GetColumns()
+	if err != nil {
+		return err
+	}
+	open, done := columns[0].Status, columns[model.DoneColumn(columns)].Status
+
+	client := github.NewClient(os.Getenv("GITHUB_API_URL"), os.Getenv("GITHUB_TOKEN"))
+	query := github.IssueQuery{State: state, Labels: githubLabels}
+	source := "github:" + strings.ToLower(githubRepo)
+	var total db.SyncResult
+	summary := func() string {
+		return fmt.Sprintf("Imported %d issues from %s (%d new, %d updated)", total.Created+total.Updated, githubRepo, total.Created, total.Updated)
+	}
+	for page := 1; page != 0; {
+		issues, next, err := client.Issues(githubRepo, query, page)
+		if err != nil {
+			fmt.Println(summary() + fmt.Sprintf(" before page %d failed", page))
+			return fmt.Errorf("page %d: %w", page, err)
+		}
+		// Issues come oldest first, and each new task goes on top of the
+		// previous ones, leaving the newest issue on top
+		drafts := make([]model.Task, len(issues))
+		for i, issue := range issues {
+			i = len(issues) - 1 - i
+			drafts[i] = model.Task{
+				Title:       issue.Title,
+				Description: issue.Body,
+				Status:      open,
+				Source:      source,
+				SourceID:    strconv.Itoa(issue.Number),
+				URL:         issue.HTMLURL,
+			}
+			if issue.Closed() {
+				drafts[i].Status = done
+			}
+			for _, label := range issue.Labels {
+				drafts[i].Tags = append(drafts[i].Tags, label.Name)
+			}
+		}
+		result, err := database.SyncTasks(drafts)
+		if err != nil {
+			fmt.Println(summary() + fmt.Sprintf(" before page %d failed", page))
+			return fmt.Errorf("page %d: %w", page, err)
+		}
+		total.Created += result.Created
+		total.Updated += result.Updated
+		page = next
+	}
+
+	fmt.Println(summary())
+	return nil
+}
+
+func runRename(cmd *cobra.Command, args []string) error {
+	oldName, newName := args[0], args[1]
+	for _, ws := range []string{oldName, newName} {
+		if err := workspace.Validate(ws); err != nil {
+			return err
+		}
+	}
+	if oldName == newName {
+		return errors.New("old and new workspace names are the same")
+	}
+
+	// workspace.Path also migrates the legacy default db, so renaming "default" works before first launch.
+	oldPath, err := workspace.Path(oldName)
+	if err != nil {
+		return err
+	}
+	newPath, err := workspace.Path(newName)
+	if err != nil {
+		return err
+	}
+
+	if !fileExists(oldPath) {
+		return fmt.Errorf("workspace %q not found in %s", oldName, filepath.Dir(oldPath))
+	}
+	if fileExists(newPath) {
+		return fmt.Errorf("workspace %q already exists", newName)
+	}
+
+	// Its backups and git clone move along, so a new workspace of the old
+	// name doesn't take them over
+	dataDir, err := workspace.DataDir()
+	if err != nil {
+		return err
+	}
+	var folders [][2]string
+	for _, folder := range workspaceFolders {
+		from, to := filepath.Join(dataDir, folder, oldName), filepath.Join(dataDir, folder, newName)
+		if !fileExists(from) {
+			continue
+		}
+		if fileExists(to) {
+			return fmt.Errorf("cannot rename workspace %q: %s already exists", oldName, to)
+		}
+		folders = append(folders, [2]string{from, to})
+	}
+
+	// Its write-ahead log would be left behind under the old name
+	if err := db.Checkpoint(oldPath); err != nil {
+		return fmt.Errorf("failed to rename workspace %q: %w", oldName, err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename workspace %q to %q: %w", oldName, newName, err)
+	}
+	fmt.Printf("Renamed workspace %s to %s\t%s\n", oldName, newName, newPath)
+
+	for _, folder := range folders {
+		if err := os.Rename(folder[0], folder[1]); err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", folder[0], folder[1], err)
+		}
+	}
+	// The keychain keeps the passphrase of an encrypted database by its path
+	if pass, ok := keychain.Get(oldPath); ok {
+		if err := keychain.Set(newPath, pass); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", oneLine(err))
+		} else if err := keychain.Delete(oldPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", oneLine(err))
+		}
+	}
+	return nil
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	source, target := args[0], args[1]
+	for _, ws := range []string{source, target} {
+		if err := workspace.Validate(ws); err != nil {
+			return err
+		}
+	}
+	if source == target {
+		return errors.New("source and destination workspace names are the same")
+	}
+
+	src, err := openWorkspaceDB(source, false)
+	if err != nil {
+		return err
+	}
+	columns, err := src.GetBoard()
+	_ = src.Close()
+	if err != nil {
+		return err
+	}
+
+	dst, err := openWorkspaceDB(target, false)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	dstColumns, err := dst.GetColumns()
+	if err != nil {
+		return err
+	}
+
+	// Match columns by name; one the destination lacks is created under a key
+	// derived from its name, even if the destination uses its key otherwise
+	var created []string
+	tasks := 0
+	for i, col := range columns {
+		if match, ok := model.FindColumn(dstColumns, col.Name); ok {
+			columns[i].Status = match.Status
+		} else {
+			columns[i].Status = ""
+			created = append(created, col.Name)
+		}
+		for j := range col.Tasks {
+			col.Tasks[j].Title = mergePrefix + col.Tasks[j].Title
+		}
+		tasks += len(col.Tasks)
+	}
+
+	merged, err := dst.ImportTasks(columns, false)
+	if err != nil {
+		return fmt.Errorf("failed to merge %s into %s: %w", source, target, err)
+	}
+
+	fmt.Printf("Merged %d task(s) from %s into %s\n", merged, source, target)
+	for _, name := range created {
+		fmt.Printf("  created column %s\n", name)
+	}
+
+	if !mergeDeleteSource {
+		fmt.Printf("The source workspace is unchanged (delete it with: cli_kanban --delete %s)\n", source)
+		return nil
+	}
+	forceDelete = true
+	return deleteWorkspaceDatabase(source)
+}
+
+func runClone(cmd *cobra.Command, args []string) error {
+	source, target := args[0], args[1]
+	for _, ws := range []string{source, target} {
+		if err := workspace.Validate(ws); err != nil {
+			return err
+		}
+	}
+	if source == target {
+		return errors.New("source and target workspace names are the same")
+	}
+
+	sourcePath, err := workspace.Path(source)
+	if err != nil {
+		return err
+	}
+	targetPath, err := workspace.Path(target)
+	if err != nil {
+		return err
+	}
+
+	if !fileExists(sourcePath) {
+		return fmt.Errorf("workspace %q not found in %s", source, filepath.Dir(sourcePath))
+	}
+	if fileExists(targetPath) {
+		return fmt.Errorf("workspace %q already exists", target)
+	}
+
+	if !cloneColumnsOnly {
+		if err := db.CopyFile(sourcePath, targetPath); err != nil {
+			return err
+		}
+	} else if err := cloneColumns(sourcePath, targetPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Cloned workspace %s to %s\t%s\n", source, target, targetPath)
+	return nil
+}
+
+// cloneColumns writes a copy of the database at src without its tasks to dst.
+// The copy is made and cleared next to dst first, so no workspace appears
+// at dst until it holds the columns only.
+func cloneColumns(src, dst string) error {
+	tmpPath := dst + ".partial"
+	_ = os.Remove(tmpPath) // left by a clone that was interrupted
+	if err := db.CopyFile(src, tmpPath); err != nil {
+		return err
+	}
+	encrypted, err := crypt.IsEncrypted(tmpPath)
+	if err == nil && encrypted {
+		err = sharePassphrase(src, tmpPath)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	database, err := db.New(tmpPath)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	err = database.DeleteAllTasks()
+	if closeErr := database.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, dst)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// loadConfig reads config.yaml. When the file can't be read, a warning is
+// printed and the defaults are used.
+func loadConfig() (config.Config, string) {
+	path, err := config.Path()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s; using the default settings\n", oneLine(err))
+		return config.Config{}, ""
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s; using the default settings\n", oneLine(err))
+		return config.Config{}, path
+	}
+	return cfg, path
+}
+
+// loadTheme builds the theme selected in the config. When it names unknown
+// themes or colors, a warning is printed and the defaults are used for
+// whatever was wrong.
+func loadTheme(cfg config.Config, path string) tui.Theme {
+	theme, err := tui.NewTheme(cfg.Theme.Name, cfg.Theme.Colors)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: config %s: %s\n", path, oneLine(err))
+	}
+	return theme
+}
+
+// loadOptions builds the TUI settings from the config, warning about
+// invalid values and using the defaults for them
+func loadOptions(cfg config.Config, path string) tui.Options {
+	opts := tui.DefaultOptions()
+	var err error
+	if opts.Pomodoro, err = tui.NewPomodoro(cfg.Pomodoro.Work, cfg.Pomodoro.Break, cfg.Pomodoro.Notify); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: config %s: %s\n", path, oneLine(err))
+	}
+	if opts.Remind, err = tui.ParseNotify(cfg.Reminders.Notify); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: config %s: reminders: %s\n", path, oneLine(err))
+	}
+	opts.Webhooks = cfg.Webhooks
+	if cfg.Vim != nil {
+		opts.Vim = *cfg.Vim
+	}
+	if cfg.SampleBoard != nil {
+		opts.Sample = *cfg.SampleBoard
+	}
+	if cfg.RestoreSession != nil {
+		opts.Restore = *cfg.RestoreSession
+	}
+	if cfg.TitleLines < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: config %s: title_lines: %d is negative, showing whole titles\n", path, cfg.TitleLines)
+	} else {
+		opts.TitleLines = cfg.TitleLines
+	}
+	if len(cfg.Keys) > 0 {
+		opts.Keys = make(map[string][]string, len(cfg.Keys))
+		for action, keys := range cfg.Keys {
+			opts.Keys[action] = keys
+		}
+	}
+	if _, err := db.StaleBefore(cfg.Stale.After, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: config %s: stale: %s\n", path, oneLine(err))
+	} else {
+		opts.StaleAfter = cfg.Stale.After
+	}
+	return opts
+}
+
+// staleBefore returns the time before which untouched tasks are stale, as
+// the config sets it, warning about an invalid setting and using the default
+func staleBefore(cfg config.Config, path string, now time.Time) time.Time {
+	before, err := db.StaleBefore(cfg.Stale.After, now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: config %s: stale: %s\n", path, oneLine(err))
+		before, _ = db.StaleBefore("", now)
+	}
+	return before
+}
+
+// backupKeep returns how many backups the config keeps per workspace
+func backupKeep(cfg config.Config) int {
+	if cfg.Backups.Keep == nil {
+		return backup.DefaultKeep
+	}
+	return *cfg.Backups.Keep
+}
+
+// autoBackup backs up a workspace database on startup when the last backup
+// is old enough. Failures are only warned about so the board still opens.
+func autoBackup(cfg config.Config, ws, dbPath string) {
+	if _, err := backup.Auto(ws, dbPath, cfg.Backups.Interval, backupKeep(cfg)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: backup of workspace %s failed: %s\n", ws, oneLine(err))
+	}
+}
+
+// pruneActivity deletes activity entries older than the configured retention.
+// Failures are only warned about, like those of autoBackup.
+func pruneActivity(cfg config.Config, ws string, database *db.DB) {
+	retention := cfg.Activity.Retention
+	if retention == "" {
+		retention = db.DefaultActivityRetention
+	}
+	if retention == "never" {
+		return
+	}
+	before, err := dates.Ago(retention, time.Now())
+	if err == nil {
+		_, err = database.PruneActivity(before)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: pruning the activity of workspace %s failed: %s\n", ws, oneLine(err))
+	}
+}
+
+// oneLine flattens a multi-line error message for a single warning line
+func oneLine(err error) string {
+	msg := strings.ReplaceAll(err.Error(), ":\n", ": ")
+	msg = strings.ReplaceAll(msg, "\n", "; ")
+	return strings.Join(strings.Fields(msg), " ")
+}
+
+// openWorkspaceDB opens an existing workspace database. When create is false and
+// the workspace does not exist yet, an error is returned instead of creating it.
+func openWorkspaceDB(ws string, create bool) (*db.DB, error) {
+	if ws == "" {
+		ws = workspace.Default
+	}
+	dbPath, err := workspace.Path(ws)
+	if err != nil {
+		return nil, err
+	}
+	if !create && !fileExists(dbPath) {
+		return nil, fmt.Errorf("%w: %s in %s", errWorkspaceNotFound, ws, filepath.Dir(dbPath))
+	}
+
+	database, err := db.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return database, nil
+}
+
+// columnNames returns a comma-separated list of column status keys for error messages
+func columnNames(columns []model.Column) string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = string(col.Status)
+	}
+	return strings.Join(names, ", ")
+}
+
+func deleteWorkspaceDatabase(ws string) error {
+	if err := workspace.Validate(ws); err != nil {
+		return err
+	}
+
+	dataDir, err := workspace.DataDir()
+	if err != nil {
+		return err
+	}
+	dbPath := workspace.File(dataDir, ws)
+
+	if !fileExists(dbPath) {
+		return fmt.Errorf("workspace %q not found in %s", ws, dataDir)
+	}
+
+	if !forceDelete {
+		database, err := db.New(dbPath)
+		if err != nil {
+			return fmt.Errorf("failed to open workspace %q: %w", ws, err)
+		}
+		count, err := database.CountTasks()
+		_ = database.Close()
+		if err != nil {
+			return err
+		}
+
+		if !confirm(fmt.Sprintf("Delete workspace '%s' containing %d tasks? [y/N] ", ws, count)) {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	trashDir := filepath.Join(dataDir, trashDirName)
+	if err := os.MkdirAll(trashDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create trash directory %q: %w", trashDir, err)
+	}
+	trashBase := filepath.Join(trashDir, workspace.FilePrefix+ws+"."+time.Now().Format(trashTimeFormat))
+	trashPath := trashBase + ".db"
+	if fileExists(trashPath) {
+		return fmt.Errorf("trashed copy %q already exists, try again", trashPath)
+	}
+
+	if err := db.Checkpoint(dbPath); err != nil {
+		return fmt.Errorf("failed to delete workspace %q: %w", ws, err)
+	}
+	if err := os.Rename(dbPath, trashPath); err != nil {
+		return fmt.Errorf("failed to delete workspace %q: %w", ws, err)
+	}
+	// Its backups and git clone go to the trash with it, named alike, so
+	// a new workspace of the same name starts without them. The keychain
+	// entry stays, for restore-workspace.
+	for _, folder := range workspaceFolders {
+		path := filepath.Join(dataDir, folder, ws)
+		if !fileExists(path) {
+			continue
+		}
+		if err := os.Rename(path, trashBase+"."+folder); err != nil {
+			return fmt.Errorf("failed to move %s to the trash: %w", path, err)
+		}
+	}
+
+	fmt.Printf("Deleted workspace %s\t%s\n", ws, dbPath)
+	hint := "cli_kanban restore-workspace " + ws
+	if customDataDir != "" {
+		hint += " --data-dir " + dataDir
+	}
+	fmt.Printf("Moved to trash\t%s (recover with: %s)\n", trashPath, hint)
+	return nil
+}
+
+func runRestoreWorkspace(cmd *cobra.Command, args []string) error {
+	ws := args[0]
+	if err := workspace.Validate(ws); err != nil {
+		return err
+	}
+
+	dataDir, err := workspace.DataDir()
+	if err != nil {
+		return err
+	}
+	dbPath := workspace.File(dataDir, ws)
+	if fileExists(dbPath) {
+		return fmt.Errorf("workspace %q already exists", ws)
+	}
+
+	trashDir := filepath.Join(dataDir, trashDirName)
+	entries, err := os.ReadDir(trashDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read trash directory %q: %w", trashDir, err)
+	}
+
+	// Trashed files are named cli_kanban__<ws>.<timestamp>.db, so the newest sorts last.
+	prefix := workspace.FilePrefix + ws + "."
+	var newest string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".db") {
+			continue
+		}
+		if name > newest {
+			newest = name
+		}
+	}
+	if newest == "" {
+		return fmt.Errorf("no trashed copy of workspace %q found in %s", ws, trashDir)
+	}
+
+	trashPath := filepath.Join(trashDir, newest)
+	if err := db.Checkpoint(trashPath); err != nil {
+		return fmt.Errorf("failed to restore workspace %q: %w", ws, err)
+	}
+	if err := os.Rename(trashPath, dbPath); err != nil {
+		return fmt.Errorf("failed to restore workspace %q: %w", ws, err)
+	}
+	trashBase := strings.TrimSuffix(trashPath, ".db")
+	for _, folder := range workspaceFolders {
+		path := filepath.Join(dataDir, folder, ws)
+		if !fileExists(trashBase+"."+folder) || fileExists(path) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+		if err := os.Rename(trashBase+"."+folder, path); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	}
+
+	fmt.Printf("Restored workspace %s\t%s\n", ws, dbPath)
+	return nil
+}
+
+// existingWorkspacePath returns the database path of a workspace that must exist
+func existingWorkspacePath(ws string) (string, error) {
+	dbPath, err := workspace.Path(ws)
+	if err != nil {
+		return "", err
+	}
+	if !fileExists(dbPath) {
+		return "", fmt.Errorf("%w: %s in %s", errWorkspaceNotFound, ws, filepath.Dir(dbPath))
+	}
+	return dbPath, nil
+}
+
+// appVersion returns the version of the binary: the one set when building
+// it, else the module version recorded by go install, else "dev"
+func appVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+func runArchive(cmd *cobra.Command, args []string) error {
+	ws := workspaceName
+	dbPath, err := existingWorkspacePath(ws)
+	if err != nil {
+		return err
+	}
+	output := archiveOutput
+	if output == "" {
+		output = ws + archive.Extension
+	}
+	encrypted, err := crypt.IsEncrypted(dbPath)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "cli_kanban-archive-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Opening the database brings it to the current schema first
+	database, err := openWorkspaceDB(ws, false)
+	if err != nil {
+		return err
+	}
+	src := filepath.Join(dir, archive.DatabaseName)
+	if encrypted {
+		// The encrypted file is only ever replaced whole, so once closed
+		// it is consistent, and it goes into the archive still sealed
+		src = dbPath
+	} else {
+		err = database.Snapshot(src)
+	}
+	if closeErr := database.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	manifest := archive.Manifest{
+		AppVersion: appVersion(),
+		Schema:     db.SchemaVersion(),
+		Workspace:  ws,
+		Encrypted:  encrypted,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+	if err := archive.Write(output, manifest, map[string]string{archive.DatabaseName: src}); err != nil {
+		return err
+	}
+	fmt.Printf("Archived workspace %s\t%s\n", ws, output)
+	return nil
+}
+
+func runUnarchive(cmd *cobra.Command, args []string) error {
+	path := args[0]
+	if unarchiveAs != "" {
+		if err := workspace.Validate(unarchiveAs); err != nil {
+			return err
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "cli_kanban-archive-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := archive.Extract(path, dir)
+	if err != nil {
+		return err
+	}
+	if _, ok := manifest.Files[archive.DatabaseName]; !ok {
+		return fmt.Errorf("archive %s holds no workspace database", path)
+	}
+	if latest := db.SchemaVersion(); manifest.Schema > latest {
+		return fmt.Errorf("archive %s has schema version %d, this binary supports %d; please upgrade cli_kanban", path, manifest.Schema, latest)
+	}
+	src := filepath.Join(dir, archive.DatabaseName)
+
+	ws := unarchiveAs
+	if ws == "" {
+		ws = manifest.Workspace
+	}
+	dbPath, err := workspace.Path(ws)
+	if err != nil {
+		return fmt.Errorf("archive %s: %w", path, err)
+	}
+	if fileExists(dbPath) {
+		return fmt.Errorf("workspace %q already exists (use --as to unarchive under another name)", ws)
+	}
+	if err := workspace.CopyFile(src, dbPath, 0o600); err != nil {
+		return err
+	}
+	// An encrypted database is checked and migrated when it is first opened
+	// with its passphrase
+	if !manifest.Encrypted {
+		database, err := db.New(dbPath)
+		if err == nil {
+			err = database.Close()
+		}
+		if err != nil {
+			_ = os.Remove(dbPath)
+			return fmt.Errorf("failed to initialize database: %w", err)
+		}
+	}
+
+	fmt.Printf("Unarchived workspace %s from %s\t%s\n", ws, path, dbPath)
+	return nil
+}
+
+func runEncrypt(cmd *cobra.Command, args []string) error {
+	ws := workspaceName
+	dbPath, err := existingWorkspacePath(ws)
+	if err != nil {
+		return err
+	}
+	encrypted, err := crypt.IsEncrypted(dbPath)
+	if err != nil {
+		return err
+	}
+
+	if encrypted {
+		if !cmd.Flags().Changed("keychain") {
+			return fmt.Errorf("workspace %s is already encrypted; to change its passphrase, decrypt it and encrypt it again", ws)
+		}
+		if !encryptKeychain {
+			if err := keychain.Delete(dbPath); err != nil {
+				return err
+			}
+			fmt.Printf("Removed the passphrase of workspace %s from the keychain\n", ws)
+			return nil
+		}
+		pass, err := readPassphrase(fmt.Sprintf("Passphrase for workspace %s: ", ws))
+		if err != nil {
+			return err
+		}
+		if _, _, err := crypt.OpenFile(dbPath, pass); err != nil {
+			return err
+		}
+		if err := keychain.Set(dbPath, pass); err != nil {
+			return err
+		}
+		fmt.Printf("Saved the passphrase of workspace %s in the keychain\n", ws)
+		return nil
+	}
+
+	pass, err := readPassphrase(fmt.Sprintf("New passphrase for workspace %s: ", ws))
+	if err != nil {
+		return err
+	}
+	if pass == "" {
+		return errors.New("the passphrase can't be empty")
+	}
+	again, err := readPassphrase("Repeat the passphrase: ")
+	if err != nil {
+		return err
+	}
+	if again != pass {
+		return errors.New("the passphrases don't match")
+	}
+	if err := db.Encrypt(dbPath, pass); err != nil {
+		return err
+	}
+	fmt.Printf("Encrypted workspace %s\t%s\n", ws, dbPath)
+	if encryptKeychain {
+		if err := keychain.Set(dbPath, pass); err != nil {
+			return err
+		}
+		fmt.Println("Saved its passphrase in the keychain")
+	}
+	// Backups are copies of the file: the earlier ones are still plain
+	if backups, err := backup.List(ws); err == nil && len(backups) > 0 {
+		dir, _ := backup.Dir(ws)
+		fmt.Fprintf(os.Stderr, "Warning: the %s made before aren't encrypted; delete them from %s if they shouldn't stay readable\n", countOf(len(backups), "backup"), dir)
+	}
+	return nil
+}
+
+func runDecrypt(cmd *cobra.Command, args []string) error {
+	ws := workspaceName
+	dbPath, err := existingWorkspacePath(ws)
+	if err != nil {
+		return err
+	}
+	if err := db.Decrypt(dbPath); err != nil {
+		return err
+	}
+	if err := keychain.Delete(dbPath); err != nil && !errors.Is(err, keychain.ErrUnavailable) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", oneLine(err))
+	}
+	fmt.Printf("Decrypted workspace %s\t%s\n", ws, dbPath)
+	return nil
+}
+
+// maxPassphraseAttempts is how many passphrases an encrypted workspace is
+// tried with before giving up
+const maxPassphraseAttempts = 3
+
+var (
+	passphraseMu sync.Mutex
+	// passphrases holds the passphrases entered or read from the keychain
+	// during this run, by database path
+	passphrases = map[string]string{}
+	// askPassphrase allows asking for passphrases on the terminal
+	askPassphrase = true
+)
+
+// setAskPassphrase allows or forbids asking for passphrases on the terminal
+func setAskPassphrase(ask bool) {
+	passphraseMu.Lock()
+	askPassphrase = ask
+	passphraseMu.Unlock()
+}
+
+// workspacePassphrase returns the passphrase of an encrypted workspace
+// database: the one that opened it before, the one saved in the keychain,
+// else one asked for on the terminal when that is allowed
+func workspacePassphrase(path string, failed int) (string, error) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	ws := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), workspace.FilePrefix), ".db")
+	if failed == 0 {
+		if pass, ok := passphrases[path]; ok {
+			return pass, nil
+		}
+		if pass, ok := keychain.Get(path); ok {
+			passphrases[path] = pass
+			return pass, nil
+		}
+	}
+	delete(passphrases, path)
+
+	switch {
+	case failed >= maxPassphraseAttempts || failed > 0 && !askPassphrase:
+		return "", fmt.Errorf("%w for workspace %s", crypt.ErrWrongPassphrase, ws)
+	case !askPassphrase:
+		return "", fmt.Errorf("workspace %s is encrypted: open it with cli_kanban -w %s to enter its passphrase", ws, ws)
+	}
+	if failed > 0 {
+		fmt.Fprintln(os.Stderr, "Wrong passphrase, try again.")
+	}
+	pass, err := readPassphrase(fmt.Sprintf("Passphrase for workspace %s: ", ws))
+	if err != nil {
+		return "", err
+	}
+	passphrases[path] = pass
+	return pass, nil
+}
+
+// sharePassphrase makes the encrypted database at to, a copy of the one at
+// from, open with the passphrase of from, asked for as the passphrase of
+// from until it opens from
+func sharePassphrase(from, to string) error {
+	for failed := 0; ; failed++ {
+		pass, err := workspacePassphrase(from, failed)
+		if err != nil {
+			return err
+		}
+		if _, _, err := crypt.OpenFile(from, pass); err != nil {
+			if errors.Is(err, crypt.ErrWrongPassphrase) {
+				continue
+			}
+			return err
+		}
+		passphraseMu.Lock()
+		passphrases[to] = pass
+		passphraseMu.Unlock()
+		return nil
+	}
+}
+
+// readPassphrase asks for a passphrase on the terminal without echoing it,
+// on the controlling terminal when the input is redirected
+func readPassphrase(prompt string) (string, error) {
+	in := os.Stdin
+	if !term.IsTerminal(int(in.Fd())) {
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return "", errors.New("a passphrase is needed, but there is no terminal to ask for it on")
+		}
+		defer tty.Close()
+		in = tty
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	pass, err := term.ReadPassword(int(in.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the passphrase: %w", err)
+	}
+	return string(pass), nil
+}
+
+// confirm prints prompt and reports whether the user answered yes
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func listWorkspaceDatabases() error {
+	dir, err := workspace.DataDir()
+	if err != nil {
+		return err
+	}
+	workspaces, err := workspace.List()
+	if err != nil {
+		return err
+	}
+	infos := make([]workspaceInfo, len(workspaces))
+	for i, ws := range workspaces {
+		infos[i] = describeWorkspace(ws)
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].Modified.After(infos[j].Modified)
+	})
+
+	if listWSJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+	if len(infos) == 0 {
+		fmt.Printf("No workspaces found in %s.\n", dir)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTASKS\tDONE\tMODIFIED\tSIZE\tPATH")
+	for _, info := range infos {
+		// The error, which can be long, ends the row so the others stay narrow
+		var tasks, done, note string
+		switch {
+		case info.Error != "":
+			tasks, note = "error", "  "+info.Error
+		case info.Encrypted:
+			tasks = "encrypted"
+		default:
+			tasks, done = strconv.Itoa(*info.Tasks), strconv.Itoa(*info.Done)
+		}
+		modified := "-"
+		if !info.Modified.IsZero() {
+			modified = info.Modified.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s%s\n", info.Name, tasks, done, modified, formatBytes(info.Size), info.Path, note)
+	}
+	return w.Flush()
+}
+
+// workspaceInfo is a workspace as --list describes it. Tasks and Done are
+// left out for encrypted workspaces, which can't be read without their
+// passphrase, and for those that fail to open, which carry the Error.
+type workspaceInfo struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Tasks     *int      `json:"tasks,omitempty"`
+	Done      *int      `json:"done,omitempty"`
+	Modified  time.Time `json:"modified"`
+	Size      int64     `json:"size"`
+	Encrypted bool      `json:"encrypted"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// describeWorkspace stats a workspace database and counts its tasks. The
+// write-ahead log counts toward the size and the modification time, as
+// recent changes may sit there without having touched the database file.
+func describeWorkspace(ws workspace.Workspace) workspaceInfo {
+	info := workspaceInfo{Name: ws.Name, Path: ws.Path, Encrypted: ws.Encrypted}
+	for _, path := range []string{ws.Path, ws.Path + "-wal"} {
+		if st, err := os.Stat(path); err == nil {
+			info.Size += st.Size()
+			if st.ModTime().After(info.Modified) {
+				info.Modified = st.ModTime()
+			}
+		}
+	}
+	if ws.Encrypted {
+		return info
+	}
+
+	database, err := db.OpenReadOnly(ws.Path)
+	if err != nil {
+		info.Error = oneLine(err)
+		return info
+	}
+	defer database.Close()
+	total, done, err := database.CountBoard()
+	if err != nil {
+		info.Error = oneLine(err)
+		return info
+	}
+	info.Tasks, info.Done = &total, &done
+	return info
+}
+
+// completionDB opens a workspace database read-only for shell completion.
+// Completion runs without PersistentPreRunE, so the data directory flags are
+// applied here, and nothing is created or migrated on the way.
+func completionDB(ws string) (*db.DB, error) {
+	workspace.SetDataDir(customDataDir)
+	workspace.UseLegacyDir(legacyDir)
+	if ws == "" {
+		ws = workspace.Default
+	}
+	if err := workspace.Validate(ws); err != nil {
+		return nil, err
+	}
+	dataDir, err := workspace.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	dbPath := workspace.File(dataDir, ws)
+	if !fileExists(dbPath) {
+		return nil, fmt.Errorf("%w: %s", errWorkspaceNotFound, ws)
+	}
+	return db.OpenReadOnly(dbPath)
+}
+
+// completeWorkspaces completes the names of the workspaces in the data directory
+func completeWorkspaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	workspace.SetDataDir(customDataDir)
+	workspace.UseLegacyDir(legacyDir)
+	workspaces, err := workspace.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, ws := range workspaces {
+		if strings.HasPrefix(ws.Name, toComplete) {
+			names = append(names, ws.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTaskIDs completes the IDs of the tasks on the --workspace board,
+// described by their titles
+func completeTaskIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	database, err := completionDB(workspaceName)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer database.Close()
+	tasks, err := database.GetAllTasks()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var ids []string
+	for _, task := range tasks {
+		id := strconv.FormatInt(task.ID, 10)
+		if strings.HasPrefix(id, toComplete) {
+			ids = append(ids, id+"\t"+task.Title)
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeColumns completes the column keys of the --workspace board, or of
+// the --to-workspace board when moving a task there
+func completeColumns(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ws := workspaceName
+	if f := cmd.Flags().Lookup("to-workspace"); f != nil && f.Value.String() != "" {
+		ws = f.Value.String()
+	}
+	database, err := completionDB(ws)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer database.Close()
+	columns, err := database.GetColumns()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, col := range columns {
+		if strings.HasPrefix(string(col.Status), toComplete) {
+			keys = append(keys, string(col.Status)+"\t"+col.Name)
+		}
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeSettings completes the keys of the workspace settings, with one
+// per column for the column settings
+func completeSettings(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	database, err := completionDB(workspaceName)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer database.Close()
+	columns, err := database.GetColumns()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, info := range db.KnownSettings() {
+		candidates := []string{info.Key}
+		if info.Column {
+			candidates = nil
+			for _, col := range columns {
+				candidates = append(candidates, info.Key+string(col.Status))
+			}
+		}
+		for _, key := range candidates {
+			if strings.HasPrefix(key, toComplete) {
+				keys = append(keys, key+"\t"+info.Help)
+			}
+		}
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeViews completes the names of the saved views
+func completeViews(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	database, err := completionDB(workspaceName)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer database.Close()
+	views, err := database.GetViews()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, v := range views {
+		if strings.HasPrefix(strings.ToLower(v.Name), strings.ToLower(toComplete)) {
+			names = append(names, v.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeMoveArgs completes the task ID and then the column of move
+func completeMoveArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeTaskIDs(cmd, args, toComplete)
+	case 1:
+		return completeColumns(cmd, args, toComplete)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeFirstArg completes only the first argument of a command with complete
+func completeFirstArg(complete func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return complete(cmd, args, toComplete)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }